@@ -0,0 +1,16 @@
+// Code generated by apigen from the Docker Engine API v1.41. DO NOT EDIT.
+
+package docker
+
+type BuildCache struct {
+	ID          string `json:"ID,omitempty" yaml:"ID,omitempty" toml:"ID,omitempty"`
+	Parent      string `json:"Parent,omitempty" yaml:"Parent,omitempty" toml:"Parent,omitempty"`
+	Type        string `json:"Type,omitempty" yaml:"Type,omitempty" toml:"Type,omitempty"`
+	Description string `json:"Description,omitempty" yaml:"Description,omitempty" toml:"Description,omitempty"`
+	InUse       bool   `json:"InUse,omitempty" yaml:"InUse,omitempty" toml:"InUse,omitempty"`
+	Shared      bool   `json:"Shared,omitempty" yaml:"Shared,omitempty" toml:"Shared,omitempty"`
+	Size        int    `json:"Size,omitempty" yaml:"Size,omitempty" toml:"Size,omitempty"`
+	CreatedAt   int    `json:"CreatedAt,omitempty" yaml:"CreatedAt,omitempty" toml:"CreatedAt,omitempty"`
+	LastUsedAt  int    `json:"LastUsedAt,omitempty" yaml:"LastUsedAt,omitempty" toml:"LastUsedAt,omitempty"`
+	UsageCount  int    `json:"UsageCount,omitempty" yaml:"UsageCount,omitempty" toml:"UsageCount,omitempty"`
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// versionTag is the key of the struct tags declaring the versions of the API
+// supporting a field, e.g. `docker:"min=1.25"` for a field added in the
+// version 1.25 of the API.
+const versionTag = "docker"
+
+// PruneForAPIVersion returns a copy of v, a struct or a pointer to a struct,
+// without the fields that the given version of the API doesn't support, as
+// declared by their docker:"min=..." tags, along with the names of the
+// pruned fields that were set, e.g. "HostConfig.AutoRemove".
+//
+// Old daemons either reject requests with fields they don't know, or
+// silently ignore them, so callers may use the names to report the settings
+// that won't apply. When version is nil, v is returned as is.
+func PruneForAPIVersion(v interface{}, version APIVersion) (interface{}, []string) {
+	if v == nil || version == nil {
+		return v, nil
+	}
+	p := fieldPruner{version: version}
+	return p.prune(reflect.ValueOf(v), "").Interface(), p.pruned
+}
+
+// MarshalForAPIVersion returns the JSON encoding of v without the fields
+// that the given version of the API doesn't support. See PruneForAPIVersion
+// for more details.
+func MarshalForAPIVersion(v interface{}, version APIVersion) ([]byte, error) {
+	pruned, _ := PruneForAPIVersion(v, version)
+	return json.Marshal(pruned)
+}
+
+type fieldPruner struct {
+	version APIVersion
+	pruned  []string
+}
+
+func (p *fieldPruner) prune(v reflect.Value, path string) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || !mayHaveVersionTags(v.Type()) {
+			return v
+		}
+		ptr := reflect.New(v.Type().Elem())
+		ptr.Elem().Set(p.prune(v.Elem(), path))
+		return ptr
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := path
+			if !field.Anonymous {
+				name = strings.TrimPrefix(path+"."+field.Name, ".")
+			}
+			f := out.Field(i)
+			zero := reflect.Zero(field.Type)
+			if min := minAPIVersion(field); min != nil && p.version.LessThan(min) {
+				if !reflect.DeepEqual(f.Interface(), zero.Interface()) {
+					p.pruned = append(p.pruned, name)
+				}
+				f.Set(zero)
+				continue
+			}
+			f.Set(p.prune(f, name))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() || !mayHaveVersionTags(v.Type().Elem()) {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(p.prune(v.Index(i), fmt.Sprintf("%s[%d]", path, i)))
+		}
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(p.prune(v.Elem(), path))
+		return out
+	}
+	return v
+}
+
+// mayHaveVersionTags tells whether values of the type may hold struct
+// fields, the only ones that are pruned.
+func mayHaveVersionTags(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Interface
+}
+
+// minAPIVersion returns the first version of the API supporting the field,
+// or nil when the field has no docker:"min=..." tag.
+func minAPIVersion(field reflect.StructField) APIVersion {
+	for _, opt := range strings.Split(field.Tag.Get(versionTag), ",") {
+		if strings.HasPrefix(opt, "min=") {
+			version, err := NewAPIVersion(strings.TrimPrefix(opt, "min="))
+			if err != nil {
+				return nil
+			}
+			return version
+		}
+	}
+	return nil
+}
+
+// pruneAPIVersion returns the version of the API the requests are pruned for
+// when the client has PruneUnsupportedFields set: the version the client
+// speaks, that is the one it was created with, or else the version of the
+// server, fetched when it isn't known yet, e.g. for clients created with
+// NewClient.
+func (c *Client) pruneAPIVersion() (APIVersion, error) {
+	if version := c.getExpectedAPIVersion(); version != nil {
+		return version, nil
+	}
+	if c.requestedAPIVersion != nil {
+		return c.requestedAPIVersion, nil
+	}
+	if version := c.getServerAPIVersion(); version != nil {
+		return version, nil
+	}
+	if err := c.checkAPIVersion(); err != nil {
+		return nil, err
+	}
+	return c.getExpectedAPIVersion(), nil
+}
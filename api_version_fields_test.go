@@ -0,0 +1,216 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPruneForAPIVersion(t *testing.T) {
+	t.Parallel()
+	hostConfig := HostConfig{
+		Memory:     1024,
+		Init:       true,
+		AutoRemove: true,
+		Mounts:     []HostMount{{Target: "/data", Type: "volume"}},
+	}
+	pruned, names := PruneForAPIVersion(&hostConfig, apiVersion124)
+	expectedNames := []string{"Mounts", "Init", "AutoRemove"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("PruneForAPIVersion: wrong pruned fields. Want %#v. Got %#v.", expectedNames, names)
+	}
+	got := pruned.(*HostConfig)
+	expected := HostConfig{Memory: 1024}
+	if !reflect.DeepEqual(*got, expected) {
+		t.Errorf("PruneForAPIVersion: wrong result. Want %#v. Got %#v.", expected, *got)
+	}
+	if !hostConfig.AutoRemove || len(hostConfig.Mounts) != 1 {
+		t.Errorf("PruneForAPIVersion: modified the original value: %#v", hostConfig)
+	}
+
+	pruned, names = PruneForAPIVersion(&hostConfig, apiVersion125)
+	if len(names) != 0 {
+		t.Errorf("PruneForAPIVersion: unexpected pruned fields: %#v", names)
+	}
+	if !reflect.DeepEqual(*pruned.(*HostConfig), hostConfig) {
+		t.Errorf("PruneForAPIVersion: wrong result. Want %#v. Got %#v.", hostConfig, *pruned.(*HostConfig))
+	}
+}
+
+func TestPruneForAPIVersionNested(t *testing.T) {
+	t.Parallel()
+	data := struct {
+		*Config
+		HostConfig *HostConfig
+		Hosts      []*HostConfig
+	}{
+		&Config{Image: "busybox", Shell: []string{"/bin/sh"}},
+		&HostConfig{Runtime: "runc"},
+		[]*HostConfig{nil, {ShmSize: 64}},
+	}
+	_, names := PruneForAPIVersion(data, apiVersion119)
+	expected := []string{"Shell", "HostConfig.Runtime", "Hosts[1].ShmSize"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("PruneForAPIVersion: wrong pruned fields. Want %#v. Got %#v.", expected, names)
+	}
+}
+
+func TestPruneForAPIVersionUnknownVersion(t *testing.T) {
+	t.Parallel()
+	hostConfig := &HostConfig{AutoRemove: true}
+	pruned, names := PruneForAPIVersion(hostConfig, nil)
+	if pruned != hostConfig || names != nil {
+		t.Errorf("PruneForAPIVersion: want the value as is without a version. Got %#v, %#v.", pruned, names)
+	}
+}
+
+func TestMarshalForAPIVersion(t *testing.T) {
+	t.Parallel()
+	data, err := MarshalForAPIVersion(Config{Image: "busybox", StopTimeout: 10, StopSignal: "SIGINT"}, apiVersion124)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["StopTimeout"]; ok {
+		t.Errorf("MarshalForAPIVersion: StopTimeout not pruned: %s", data)
+	}
+	if got["StopSignal"] != "SIGINT" || got["Image"] != "busybox" {
+		t.Errorf("MarshalForAPIVersion: wrong JSON: %s", data)
+	}
+}
+
+func TestVersionTags(t *testing.T) {
+	t.Parallel()
+	for _, v := range []interface{}{Config{}, HostConfig{}} {
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			tag, ok := field.Tag.Lookup(versionTag)
+			if !ok {
+				continue
+			}
+			if minAPIVersion(field) == nil {
+				t.Errorf("%s.%s: invalid version tag %q", typ.Name(), field.Name, tag)
+			}
+			if !strings.Contains(string(field.Tag), "omitempty") {
+				t.Errorf("%s.%s: versioned field without omitempty", typ.Name(), field.Name)
+			}
+		}
+	}
+}
+
+func TestCreateContainerPrunesUnsupportedFields(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id":"abc123"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion124
+	client.PruneUnsupportedFields = true
+	opts := CreateContainerOptions{
+		Config:     &Config{Image: "busybox", Healthcheck: &HealthConfig{Test: []string{"NONE"}}, Shell: []string{"/bin/sh"}},
+		HostConfig: &HostConfig{Memory: 1024, AutoRemove: true},
+	}
+	if _, err := client.CreateContainer(opts); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["Shell"]; ok {
+		t.Errorf("CreateContainer: Shell sent to API 1.24: %#v", got)
+	}
+	if _, ok := got["Healthcheck"]; !ok {
+		t.Errorf("CreateContainer: Healthcheck not sent to API 1.24: %#v", got)
+	}
+	hostConfig, _ := got["HostConfig"].(map[string]interface{})
+	if _, ok := hostConfig["AutoRemove"]; ok {
+		t.Errorf("CreateContainer: AutoRemove sent to API 1.24: %#v", hostConfig)
+	}
+	if hostConfig["Memory"] != float64(1024) {
+		t.Errorf("CreateContainer: Memory not sent: %#v", hostConfig)
+	}
+	if opts.HostConfig.AutoRemove != true || len(opts.Config.Shell) != 1 {
+		t.Error("CreateContainer: modified the options")
+	}
+}
+
+func TestCreateContainerKeepsUnsupportedFields(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id":"abc123"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion124
+	opts := CreateContainerOptions{Config: &Config{Image: "busybox"}, HostConfig: &HostConfig{AutoRemove: true}}
+	if _, err := client.CreateContainer(opts); err != nil {
+		t.Fatal(err)
+	}
+	var got struct{ HostConfig map[string]interface{} }
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.HostConfig["AutoRemove"] != true {
+		t.Errorf("CreateContainer: AutoRemove pruned without PruneUnsupportedFields: %#v", got.HostConfig)
+	}
+}
+
+// pruneServer serves the given version of the API, sending the bodies of the
+// containers created to bodies.
+func pruneServer(version string, bodies chan<- map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/version") {
+			w.Write([]byte(`{"ApiVersion":"` + version + `"}`))
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies <- body
+		w.Write([]byte(`{"Id":"abc123"}`))
+	}))
+}
+
+func TestCreateContainerPrunesForServerVersion(t *testing.T) {
+	t.Parallel()
+	bodies := make(chan map[string]interface{}, 1)
+	server := pruneServer("1.24", bodies)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.PruneUnsupportedFields = true
+	opts := CreateContainerOptions{Config: &Config{Image: "busybox", Shell: []string{"/bin/sh"}}}
+	if _, err := client.CreateContainer(opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-bodies; got["Shell"] != nil {
+		t.Errorf("CreateContainer: Shell sent to API 1.24: %#v", got)
+	}
+}
+
+func TestCreateContainerPrunesForRequestedVersion(t *testing.T) {
+	t.Parallel()
+	bodies := make(chan map[string]interface{}, 1)
+	server := pruneServer("1.40", bodies)
+	defer server.Close()
+	client, err := NewVersionedClient(server.URL, "1.24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.PruneUnsupportedFields = true
+	opts := CreateContainerOptions{Config: &Config{Image: "busybox", Shell: []string{"/bin/sh"}}}
+	if _, err := client.CreateContainer(opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-bodies; got["Shell"] != nil {
+		t.Errorf("CreateContainer: Shell sent to API 1.24: %#v", got)
+	}
+}
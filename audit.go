@@ -0,0 +1,143 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AuditSink receives a record of every mutating call (POST, PUT and DELETE
+// requests) sent by a Client to the daemon, to keep an audit trail of what
+// the client did.
+//
+// When Client.AuditSink is set, Audit is called synchronously once the
+// outcome of each call is known, possibly from several goroutines at once,
+// so implementations should be safe for concurrent use and return quickly.
+// Calls that start attach and exec sessions are audited when the daemon
+// accepts or refuses the session.
+type AuditSink interface {
+	Audit(record AuditRecord)
+}
+
+// AuditSinkFunc is a function that implements the AuditSink interface.
+type AuditSinkFunc func(record AuditRecord)
+
+// Audit calls fn(record).
+func (fn AuditSinkFunc) Audit(record AuditRecord) {
+	fn(record)
+}
+
+// AuditRecord describes a mutating call sent to the daemon.
+type AuditRecord struct {
+	Time     time.Time
+	Duration time.Duration
+
+	// Identity is the value of Client.AuditIdentity when the call was sent.
+	Identity string
+
+	// Method and Path are the HTTP method and path of the request. The query
+	// string is left out of Path, as it may hold secrets such as build
+	// arguments, and only the names of its parameters are kept, sorted, in
+	// Parameters.
+	Method     string
+	Path       string
+	Parameters []string
+
+	// ObjectType is the kind of object the call targets, e.g. "container",
+	// "image" or "network", and ObjectID the ID or name of the object, when
+	// present in the path.
+	ObjectType string
+	ObjectID   string
+
+	// Action is the operation on the object, e.g. "create", "start" or
+	// "delete".
+	Action string
+
+	// StatusCode is the status of the response of the daemon, or zero when
+	// no response was received. Err is the error returned by the call.
+	StatusCode int
+	Err        error
+}
+
+var auditObjectTypes = map[string]string{
+	"configs":    "config",
+	"containers": "container",
+	"exec":       "exec",
+	"images":     "image",
+	"networks":   "network",
+	"nodes":      "node",
+	"plugins":    "plugin",
+	"secrets":    "secret",
+	"services":   "service",
+	"tasks":      "task",
+	"volumes":    "volume",
+}
+
+// auditing returns whether a call with the given method has to be audited.
+func (c *Client) auditing(method string) bool {
+	if c.AuditSink == nil {
+		return false
+	}
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// audit sends the record of a call started at the given time to the sink.
+func (c *Client) audit(method, path string, start time.Time, status int, err error) {
+	record := newAuditRecord(method, path)
+	record.Time = start
+	record.Duration = time.Since(start)
+	record.Identity = c.AuditIdentity
+	record.StatusCode = status
+	record.Err = err
+	if e, ok := err.(*Error); ok {
+		record.StatusCode = e.Status
+	}
+	c.AuditSink.Audit(record)
+}
+
+// newAuditRecord describes the call from its path, dropping the values of the
+// query string. Paths are in the form
+// /<type>/<action> for operations on collections, /<type>/<id> for removals
+// and /<type>/<id>/<action> otherwise, where the ID of images may contain
+// slashes.
+func newAuditRecord(method, path string) AuditRecord {
+	var record AuditRecord
+	if i := strings.Index(path, "?"); i > -1 {
+		values, _ := url.ParseQuery(path[i+1:])
+		for name := range values {
+			record.Parameters = append(record.Parameters, name)
+		}
+		sort.Strings(record.Parameters)
+		path = path[:i]
+	}
+	record.Method = method
+	record.Path = path
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	record.ObjectType = parts[0]
+	if objectType, ok := auditObjectTypes[parts[0]]; ok {
+		record.ObjectType = objectType
+	}
+	switch {
+	case len(parts) == 1:
+		record.Action = parts[0]
+	case method == http.MethodDelete:
+		record.ObjectID = strings.Join(parts[1:], "/")
+		record.Action = "delete"
+	case len(parts) == 2:
+		record.Action = parts[1]
+	default:
+		record.ObjectID = strings.Join(parts[1:len(parts)-1], "/")
+		record.Action = parts[len(parts)-1]
+	}
+	return record
+}
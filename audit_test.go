@@ -0,0 +1,155 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type auditRecorder struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (r *auditRecorder) Audit(record AuditRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+}
+
+func (r *auditRecorder) get() []AuditRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]AuditRecord(nil), r.records...)
+}
+
+func TestNewAuditRecord(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		method   string
+		path     string
+		expected AuditRecord
+	}{
+		{"POST", "/containers/create?name=web", AuditRecord{Path: "/containers/create", Parameters: []string{"name"}, ObjectType: "container", Action: "create"}},
+		{"POST", "/containers/abc/start", AuditRecord{Path: "/containers/abc/start", ObjectType: "container", ObjectID: "abc", Action: "start"}},
+		{"DELETE", "/containers/abc?force=1", AuditRecord{Path: "/containers/abc", Parameters: []string{"force"}, ObjectType: "container", ObjectID: "abc", Action: "delete"}},
+		{"DELETE", "/images/library/ubuntu:18.04", AuditRecord{Path: "/images/library/ubuntu:18.04", ObjectType: "image", ObjectID: "library/ubuntu:18.04", Action: "delete"}},
+		{"POST", "/images/library/ubuntu/push?tag=18.04", AuditRecord{Path: "/images/library/ubuntu/push", Parameters: []string{"tag"}, ObjectType: "image", ObjectID: "library/ubuntu", Action: "push"}},
+		{"PUT", "/containers/abc/archive?path=/tmp", AuditRecord{Path: "/containers/abc/archive", Parameters: []string{"path"}, ObjectType: "container", ObjectID: "abc", Action: "archive"}},
+		{"POST", "/build?t=app&buildargs=%7B%22TOKEN%22%3A%22s3cr3t%22%7D", AuditRecord{Path: "/build", Parameters: []string{"buildargs", "t"}, ObjectType: "build", Action: "build"}},
+		{"POST", "/swarm/init", AuditRecord{Path: "/swarm/init", ObjectType: "swarm", Action: "init"}},
+		{"POST", "/exec/e1/start", AuditRecord{Path: "/exec/e1/start", ObjectType: "exec", ObjectID: "e1", Action: "start"}},
+	}
+	for _, test := range tests {
+		test.expected.Method = test.method
+		if got := newAuditRecord(test.method, test.path); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("newAuditRecord(%q, %q): Want %#v. Got %#v.", test.method, test.path, test.expected, got)
+		}
+	}
+}
+
+func TestAuditSink(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/containers/json":
+			w.Write([]byte("[]"))
+		case r.URL.Path == "/containers/abc/start":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/containers/abc" && r.Method == http.MethodDelete:
+			http.Error(w, "container is running", http.StatusConflict)
+		case r.URL.Path == "/images/create":
+			w.Write([]byte(`{"status":"Pulling from library/ubuntu"}`))
+		case r.URL.Path == "/exec/e1/start":
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n"))
+			conn.Close()
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sink auditRecorder
+	client.AuditSink = &sink
+	client.AuditIdentity = "deploy-bot"
+	if _, err := client.ListContainers(ListContainersOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartContainer("abc", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RemoveContainer(RemoveContainerOptions{ID: "abc"}); err == nil {
+		t.Fatal("RemoveContainer: expected error, got <nil>")
+	}
+	if err := client.PullImage(PullImageOptions{Repository: "ubuntu", Tag: "latest"}, AuthConfiguration{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartExec("e1", StartExecOptions{OutputStream: ioutil.Discard, RawTerminal: true}); err != nil {
+		t.Fatal(err)
+	}
+	records := sink.get()
+	type summary struct {
+		Method, ObjectType, ObjectID, Action string
+		StatusCode                           int
+		Failed                               bool
+	}
+	var got []summary
+	for _, record := range records {
+		if record.Identity != "deploy-bot" {
+			t.Errorf("AuditSink: wrong identity. Want %q. Got %q.", "deploy-bot", record.Identity)
+		}
+		if record.Time.IsZero() || !strings.HasPrefix(record.Path, "/") {
+			t.Errorf("AuditSink: incomplete record: %#v", record)
+		}
+		got = append(got, summary{record.Method, record.ObjectType, record.ObjectID, record.Action, record.StatusCode, record.Err != nil})
+	}
+	expected := []summary{
+		{"POST", "container", "abc", "start", http.StatusNoContent, false},
+		{"DELETE", "container", "abc", "delete", http.StatusConflict, true},
+		{"POST", "image", "", "create", http.StatusOK, false},
+		{"POST", "exec", "e1", "start", http.StatusOK, false},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("AuditSink: wrong records.\nWant %#v.\nGot  %#v.", expected, got)
+	}
+}
+
+func TestAuditSinkConnectionError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	var records []AuditRecord
+	client.AuditSink = AuditSinkFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})
+	if err := client.StopContainer("abc", 10); err == nil {
+		t.Fatal("StopContainer: expected error, got <nil>")
+	}
+	if len(records) != 1 {
+		t.Fatalf("AuditSink: wrong number of records. Want 1. Got %d.", len(records))
+	}
+	if records[0].StatusCode != 0 || records[0].Err == nil || records[0].Action != "stop" {
+		t.Errorf("AuditSink: wrong record: %#v", records[0])
+	}
+}
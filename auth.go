@@ -11,9 +11,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 	"strings"
 )
 
@@ -59,6 +59,116 @@ func (c AuthConfigurations) headerKey() string {
 	return "X-Registry-Config"
 }
 
+// dockerHubIndexServer is the key used by the Docker CLI and daemon for the
+// credentials of Docker Hub.
+const dockerHubIndexServer = "https://index.docker.io/v1/"
+
+// normalizeRegistryHost converts a registry address, as used in the keys of
+// AuthConfigurations, to a host name. All the aliases of Docker Hub are
+// converted to "docker.io".
+func normalizeRegistryHost(address string) string {
+	host := address
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	host = strings.ToLower(host)
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "docker.io"
+	}
+	return host
+}
+
+// dockerHubAliases are the keys of Docker Hub, in order of precedence, used
+// when c has configurations under more than one of them.
+var dockerHubAliases = []string{dockerHubIndexServer, "index.docker.io", "docker.io"}
+
+// registryKey returns the key of the configuration for the given registry
+// host. The aliases of Docker Hub are looked up in the order of
+// dockerHubAliases, and other keys matching the same host in lexical order,
+// so that the result doesn't depend on the iteration order of the map.
+func (c AuthConfigurations) registryKey(registryHost string) (string, bool) {
+	host := normalizeRegistryHost(registryHost)
+	if host == "docker.io" {
+		for _, alias := range dockerHubAliases {
+			if _, ok := c.Configs[alias]; ok {
+				return alias, true
+			}
+		}
+	} else if _, ok := c.Configs[registryHost]; ok {
+		return registryHost, true
+	}
+	var keys []string
+	for key := range c.Configs {
+		if normalizeRegistryHost(key) == host {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return keys[0], true
+}
+
+// For returns the configuration for the given registry host (for example
+// "quay.io" or "localhost:5000"). Keys are matched regardless of their scheme
+// and path, and "docker.io", "index.docker.io" and
+// "https://index.docker.io/v1/" all refer to Docker Hub. When more than one
+// of them is present, "https://index.docker.io/v1/" takes precedence over
+// "index.docker.io", which takes precedence over "docker.io".
+func (c AuthConfigurations) For(registryHost string) (AuthConfiguration, bool) {
+	key, ok := c.registryKey(registryHost)
+	if !ok {
+		return AuthConfiguration{}, false
+	}
+	return c.Configs[key], true
+}
+
+// Merge adds the configurations in other to c. Configurations in other
+// replace the ones in c for the same registry, even when they use different
+// keys for it (for example, "docker.io" and "https://index.docker.io/v1/").
+func (c *AuthConfigurations) Merge(other AuthConfigurations) {
+	if c.Configs == nil {
+		c.Configs = make(map[string]AuthConfiguration, len(other.Configs))
+	}
+	for key, conf := range other.Configs {
+		host := normalizeRegistryHost(key)
+		for existing := range c.Configs {
+			if normalizeRegistryHost(existing) == host {
+				delete(c.Configs, existing)
+			}
+		}
+		c.Configs[key] = conf
+	}
+}
+
+// RegistryConfigHeader returns the value of the X-Registry-Config header
+// with all the configurations in c, in the format expected by Docker API
+// 1.19 and above. It can be used to authenticate builds pulling images from
+// multiple private registries. The configuration of Docker Hub is sent under
+// the key used by the daemon, no matter which alias was used in c, with the
+// same precedence among aliases as For.
+func (c AuthConfigurations) RegistryConfigHeader() (string, error) {
+	configs := make(AuthConfigurations119, len(c.Configs))
+	for key, conf := range c.Configs {
+		if normalizeRegistryHost(key) != "docker.io" {
+			configs[key] = conf
+		}
+	}
+	if conf, ok := c.For("docker.io"); ok {
+		configs[dockerHubIndexServer] = conf
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
 // AuthConfigurations119 is used to serialize a set of AuthConfigurations
 // for Docker API >= 1.19.
 type AuthConfigurations119 map[string]AuthConfiguration
@@ -224,14 +334,7 @@ func (c *Client) AuthCheck(conf *AuthConfiguration) (AuthStatus, error) {
 		return authStatus, err
 	}
 	defer resp.Body.Close()
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return authStatus, err
-	}
-	if len(data) == 0 {
-		return authStatus, nil
-	}
-	if err := json.Unmarshal(data, &authStatus); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&authStatus); err != nil && err != io.EOF {
 		return authStatus, err
 	}
 	return authStatus, nil
@@ -6,11 +6,13 @@ package docker
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -237,3 +239,141 @@ func TestAuthCheck(t *testing.T) {
 		t.Fatal("expected failure from unauthorized auth")
 	}
 }
+
+func TestAuthConfigurationsFor(t *testing.T) {
+	t.Parallel()
+	auths := AuthConfigurations{Configs: map[string]AuthConfiguration{
+		"https://index.docker.io/v1/": {Username: "hub"},
+		"quay.io":                     {Username: "quay"},
+		"http://localhost:5000/v2/":   {Username: "local"},
+	}}
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"docker.io", "hub"},
+		{"index.docker.io", "hub"},
+		{"https://index.docker.io/v1/", "hub"},
+		{"quay.io", "quay"},
+		{"https://quay.io", "quay"},
+		{"localhost:5000", "local"},
+		{"gcr.io", ""},
+	}
+	for _, tt := range tests {
+		conf, ok := auths.For(tt.host)
+		if ok != (tt.expected != "") || conf.Username != tt.expected {
+			t.Errorf("For(%q): want %q. Got %q (found: %v).", tt.host, tt.expected, conf.Username, ok)
+		}
+	}
+}
+
+func TestAuthConfigurationsForDockerHubAliases(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		configs  map[string]AuthConfiguration
+		expected string
+	}{
+		{
+			map[string]AuthConfiguration{
+				"docker.io":                   {Username: "docker.io"},
+				"index.docker.io":             {Username: "index"},
+				"https://index.docker.io/v1/": {Username: "v1"},
+			},
+			"v1",
+		},
+		{
+			map[string]AuthConfiguration{
+				"docker.io":       {Username: "docker.io"},
+				"index.docker.io": {Username: "index"},
+			},
+			"index",
+		},
+		{
+			map[string]AuthConfiguration{
+				"docker.io":                  {Username: "docker.io"},
+				"https://index.docker.io/v2": {Username: "v2"},
+			},
+			"docker.io",
+		},
+		{
+			map[string]AuthConfiguration{
+				"https://registry-1.docker.io": {Username: "registry"},
+				"https://index.docker.io/v2":   {Username: "v2"},
+			},
+			"v2",
+		},
+	}
+	for _, tt := range tests {
+		auths := AuthConfigurations{Configs: tt.configs}
+		for _, host := range []string{"docker.io", "index.docker.io", dockerHubIndexServer} {
+			for i := 0; i < 10; i++ {
+				conf, _ := auths.For(host)
+				if conf.Username != tt.expected {
+					t.Fatalf("For(%q) with %v: want %q. Got %q.", host, tt.configs, tt.expected, conf.Username)
+				}
+			}
+		}
+		header, err := auths.RegistryConfigHeader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := base64.URLEncoding.DecodeString(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var configs map[string]AuthConfiguration
+		if err = json.Unmarshal(data, &configs); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]AuthConfiguration{dockerHubIndexServer: {Username: tt.expected}}
+		if !reflect.DeepEqual(configs, expected) {
+			t.Errorf("RegistryConfigHeader with %v: wrong configurations.\nWant %#v.\nGot %#v.", tt.configs, expected, configs)
+		}
+	}
+}
+
+func TestAuthConfigurationsMerge(t *testing.T) {
+	t.Parallel()
+	var auths AuthConfigurations
+	auths.Merge(AuthConfigurations{Configs: map[string]AuthConfiguration{
+		"https://index.docker.io/v1/": {Username: "old"},
+		"quay.io":                     {Username: "quay"},
+	}})
+	auths.Merge(AuthConfigurations{Configs: map[string]AuthConfiguration{
+		"docker.io": {Username: "new"},
+	}})
+	expected := map[string]AuthConfiguration{
+		"docker.io": {Username: "new"},
+		"quay.io":   {Username: "quay"},
+	}
+	if !reflect.DeepEqual(auths.Configs, expected) {
+		t.Errorf("Merge: wrong configurations.\nWant %#v.\nGot %#v.", expected, auths.Configs)
+	}
+}
+
+func TestAuthConfigurationsRegistryConfigHeader(t *testing.T) {
+	t.Parallel()
+	auths := AuthConfigurations{Configs: map[string]AuthConfiguration{
+		"docker.io": {Username: "hub", Password: "secret"},
+		"quay.io":   {Username: "quay", Password: "secret"},
+	}}
+	header, err := auths.RegistryConfigHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := base64.URLEncoding.DecodeString(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var configs map[string]AuthConfiguration
+	if err = json.Unmarshal(data, &configs); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]AuthConfiguration{
+		"https://index.docker.io/v1/": {Username: "hub", Password: "secret"},
+		"quay.io":                     {Username: "quay", Password: "secret"},
+	}
+	if !reflect.DeepEqual(configs, expected) {
+		t.Errorf("RegistryConfigHeader: wrong configurations.\nWant %#v.\nGot %#v.", expected, configs)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+// ContainerBackend is the set of high-level container operations used by
+// agents built on top of this package.
+//
+// Client implements ContainerBackend using the Docker remote API. Programs
+// that depend on ContainerBackend instead of *Client can have those
+// operations served by a different runtime (for instance, containerd's API),
+// allowing a gradual migration away from the Docker daemon.
+type ContainerBackend interface {
+	// CreateContainer creates a new container, returning the container
+	// instance.
+	CreateContainer(opts CreateContainerOptions) (*Container, error)
+
+	// StartContainer starts the container with the given ID.
+	StartContainer(id string, hostConfig *HostConfig) error
+
+	// StopContainer stops the container with the given ID, killing it after
+	// the given timeout (in seconds).
+	StopContainer(id string, timeout uint) error
+
+	// Logs writes the logs of the container to the streams in the given
+	// options.
+	Logs(opts LogsOptions) error
+}
+
+var _ ContainerBackend = (*Client)(nil)
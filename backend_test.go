@@ -0,0 +1,44 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClientAsContainerBackend(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id": "4fa6e0f0c678"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	var backend ContainerBackend = &client
+	container, err := backend.CreateContainer(CreateContainerOptions{Config: &Config{Image: "base"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.StartContainer(container.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.StopContainer(container.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"/containers/create",
+		"/containers/4fa6e0f0c678/start",
+		"/containers/4fa6e0f0c678/stop",
+	}
+	var paths []string
+	for _, req := range fakeRT.requests {
+		paths = append(paths, req.URL.Path)
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("ContainerBackend: wrong requests. Want %v. Got %v.", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("ContainerBackend: wrong request path. Want %q. Got %q.", expected[i], paths[i])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// Capabilities is a report of the features of the daemon, for tools that adapt
+// their behavior to the host. It's assembled by Client.Capabilities from the
+// ping, info and version endpoints.
+type Capabilities struct {
+	// APIVersion is the most recent API version supported by the daemon,
+	// and MinAPIVersion the oldest one.
+	APIVersion    string
+	MinAPIVersion string
+
+	ServerVersion string
+	OSType        string
+	Architecture  string
+
+	// Experimental reports whether the experimental features of the daemon
+	// are enabled.
+	Experimental bool
+
+	// BuildKit reports whether the daemon can build images with BuildKit,
+	// and BuildKitDefault whether it's the default builder.
+	BuildKit        bool
+	BuildKitDefault bool
+
+	// SwarmState is the state of the node in the swarm, and SwarmManager
+	// whether it's a manager.
+	SwarmState   swarm.LocalNodeState
+	SwarmManager bool
+
+	// CgroupVersion is "1" or "2", or empty when the daemon doesn't report
+	// it (API versions older than 1.40).
+	CgroupVersion string
+	CgroupDriver  string
+
+	StorageDriver string
+
+	// Runtimes lists the names of the OCI runtimes configured in the
+	// daemon, sorted.
+	Runtimes       []string
+	DefaultRuntime string
+
+	// CDI reports whether the daemon supports Container Device Interface
+	// devices, i.e. it has CDI spec directories configured.
+	CDI bool
+}
+
+// Capabilities returns a report of the features of the daemon.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	var caps Capabilities
+	resp, err := c.do("GET", "/_ping", doOptions{context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	caps.APIVersion = resp.Header.Get("Api-Version")
+	caps.Experimental, _ = strconv.ParseBool(resp.Header.Get("Docker-Experimental"))
+	caps.BuildKitDefault = resp.Header.Get("Builder-Version") == "2"
+	caps.OSType = resp.Header.Get("OSType")
+
+	resp, err = c.do("GET", "/version", doOptions{context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var version struct {
+		Version       string
+		APIVersion    string `json:"ApiVersion"`
+		MinAPIVersion string `json:"MinAPIVersion"`
+		Os            string
+		Arch          string
+		Experimental  bool
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return nil, err
+	}
+	caps.ServerVersion = version.Version
+	caps.MinAPIVersion = version.MinAPIVersion
+	caps.Architecture = version.Arch
+	caps.Experimental = caps.Experimental || version.Experimental
+	if caps.APIVersion == "" {
+		caps.APIVersion = version.APIVersion
+	}
+	if caps.OSType == "" {
+		caps.OSType = version.Os
+	}
+
+	resp, err = c.do("GET", "/info", doOptions{context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var info DockerInfo
+	if err = json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	caps.Experimental = caps.Experimental || info.ExperimentalBuild
+	caps.SwarmState = info.Swarm.LocalNodeState
+	caps.SwarmManager = info.Swarm.ControlAvailable
+	caps.CgroupVersion = info.CgroupVersion
+	caps.CgroupDriver = info.CgroupDriver
+	caps.StorageDriver = info.Driver
+	caps.DefaultRuntime = info.DefaultRuntime
+	for name := range info.Runtimes {
+		caps.Runtimes = append(caps.Runtimes, name)
+	}
+	sort.Strings(caps.Runtimes)
+	caps.CDI = len(info.CDISpecDirs) > 0
+
+	// BuildKit is supported on Linux daemons since API 1.31, behind the
+	// experimental flag until API 1.39.
+	if apiVersion, err := NewAPIVersion(caps.APIVersion); err == nil && caps.OSType == "linux" {
+		caps.BuildKit = apiVersion.GreaterThanOrEqualTo(apiVersion139) ||
+			(caps.Experimental && apiVersion.GreaterThanOrEqualTo(apiVersion131))
+	}
+	caps.BuildKit = caps.BuildKit || caps.BuildKitDefault
+	return &caps, nil
+}
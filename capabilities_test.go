@@ -0,0 +1,131 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func capabilitiesServer(pingHeaders map[string]string, version, info string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_ping":
+			for k, v := range pingHeaders {
+				w.Header().Set(k, v)
+			}
+			w.Write([]byte("OK"))
+		case "/version":
+			w.Write([]byte(version))
+		case "/info":
+			w.Write([]byte(info))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+	srv := capabilitiesServer(
+		map[string]string{"Api-Version": "1.41", "Docker-Experimental": "false", "Builder-Version": "2", "OSType": "linux"},
+		`{"Version":"20.10.7","ApiVersion":"1.41","MinAPIVersion":"1.12","Os":"linux","Arch":"amd64"}`,
+		`{"Driver":"overlay2","CgroupDriver":"systemd","CgroupVersion":"2","DefaultRuntime":"runc",
+		"Runtimes":{"runc":{"path":"runc"},"io.containerd.runc.v2":{"path":"runc"}},
+		"Swarm":{"LocalNodeState":"active","ControlAvailable":true},"CDISpecDirs":["/etc/cdi"]}`,
+	)
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Capabilities{
+		APIVersion:      "1.41",
+		MinAPIVersion:   "1.12",
+		ServerVersion:   "20.10.7",
+		OSType:          "linux",
+		Architecture:    "amd64",
+		BuildKit:        true,
+		BuildKitDefault: true,
+		SwarmState:      swarm.LocalNodeStateActive,
+		SwarmManager:    true,
+		CgroupVersion:   "2",
+		CgroupDriver:    "systemd",
+		StorageDriver:   "overlay2",
+		Runtimes:        []string{"io.containerd.runc.v2", "runc"},
+		DefaultRuntime:  "runc",
+		CDI:             true,
+	}
+	if !reflect.DeepEqual(*caps, expected) {
+		t.Errorf("Capabilities: wrong report.\nWant %#v.\nGot  %#v.", expected, *caps)
+	}
+}
+
+func TestCapabilitiesOldDaemon(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		experimental string
+		buildKit     bool
+	}{
+		{name: "experimental", experimental: "true", buildKit: true},
+		{name: "not experimental", experimental: "false", buildKit: false},
+	}
+	for _, tt := range tests {
+		srv := capabilitiesServer(
+			map[string]string{"Api-Version": "1.35", "Docker-Experimental": tt.experimental},
+			`{"Version":"17.12.0-ce","ApiVersion":"1.35","MinAPIVersion":"1.12","Os":"linux","Arch":"amd64"}`,
+			`{"Driver":"aufs","CgroupDriver":"cgroupfs","Swarm":{"LocalNodeState":"inactive"}}`,
+		)
+		client, err := NewClient(srv.URL)
+		if err != nil {
+			srv.Close()
+			t.Fatal(err)
+		}
+		caps, err := client.Capabilities(context.Background())
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if caps.OSType != "linux" {
+			t.Errorf("%s: wrong OSType. Want %q. Got %q.", tt.name, "linux", caps.OSType)
+		}
+		if caps.BuildKit != tt.buildKit || caps.BuildKitDefault {
+			t.Errorf("%s: wrong BuildKit support. Want %v. Got %v (default: %v).", tt.name, tt.buildKit, caps.BuildKit, caps.BuildKitDefault)
+		}
+		if caps.CgroupVersion != "" || caps.CDI || caps.SwarmState != swarm.LocalNodeStateInactive {
+			t.Errorf("%s: wrong report: %#v", tt.name, caps)
+		}
+	}
+}
+
+func TestCapabilitiesError(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Capabilities(context.Background())
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusInternalServerError {
+		t.Errorf("Capabilities: wrong error. Want *Error with status 500. Got %#v.", err)
+	}
+}
@@ -4,7 +4,12 @@
 
 package docker
 
-import "fmt"
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
 
 // ChangeType is a type for constants indicating the type of change
 // in a container
@@ -21,6 +26,18 @@ const (
 	ChangeDelete
 )
 
+func (kind ChangeType) String() string {
+	switch kind {
+	case ChangeModify:
+		return "Modified"
+	case ChangeAdd:
+		return "Added"
+	case ChangeDelete:
+		return "Deleted"
+	}
+	return fmt.Sprintf("ChangeType(%d)", int(kind))
+}
+
 // Change represents a change in a container.
 //
 // See https://goo.gl/Wo0JJp for more details.
@@ -30,14 +47,148 @@ type Change struct {
 }
 
 func (change *Change) String() string {
-	var kind string
-	switch change.Kind {
+	return fmt.Sprintf("%s %s", change.Kind.letter(), change.Path)
+}
+
+// letter returns the letter of the kind used by docker diff.
+func (kind ChangeType) letter() string {
+	switch kind {
 	case ChangeModify:
-		kind = "C"
+		return "C"
 	case ChangeAdd:
-		kind = "A"
+		return "A"
 	case ChangeDelete:
-		kind = "D"
+		return "D"
+	}
+	return ""
+}
+
+// FilterChanges returns the changes to the given path or to the files under
+// it, e.g. the changes in /etc, including /etc itself.
+func FilterChanges(changes []Change, prefix string) []Change {
+	prefix = path.Clean("/" + prefix)
+	var filtered []Change
+	for _, change := range changes {
+		if isPathUnder(path.Clean(change.Path), prefix) {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+func isPathUnder(p, dir string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// ChangesToIgnorePatterns summarizes the changes as patterns in the format
+// of .dockerignore files, one per path, sorted. Added and deleted
+// directories are summarized by their path, without the files under them,
+// and modified directories are left out when the changes of their files
+// are listed, as they're only modified because of them.
+func ChangesToIgnorePatterns(changes []Change) []string {
+	kinds := make(map[string]ChangeType, len(changes))
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		p := path.Clean("/" + change.Path)
+		if _, ok := kinds[p]; !ok {
+			paths = append(paths, p)
+		}
+		kinds[p] = change.Kind
+	}
+	// sorting by components keeps the files of a directory right after it,
+	// e.g. /etc/x before /etc-old.
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Replace(paths[i], "/", "\x00", -1) < strings.Replace(paths[j], "/", "\x00", -1)
+	})
+	var patterns []string
+	var summarized string
+	for i, p := range paths {
+		if p == "/" || (summarized != "" && isPathUnder(p, summarized)) {
+			continue
+		}
+		hasChildren := i+1 < len(paths) && isPathUnder(paths[i+1], p)
+		if hasChildren {
+			if kinds[p] == ChangeModify {
+				continue
+			}
+			summarized = p
+		}
+		patterns = append(patterns, strings.TrimPrefix(p, "/"))
 	}
-	return fmt.Sprintf("%s %s", kind, change.Path)
+	sort.Strings(patterns)
+	return patterns
+}
+
+// ChangeNode is a node of the tree of changes built by BuildChangeTree, for
+// a file or directory.
+type ChangeNode struct {
+	// Name is the base name of the file, and Path its full path.
+	Name string
+	Path string
+
+	// Kind is the kind of the change, valid when Changed is set. Nodes
+	// not changed are the parent directories of changed files that
+	// weren't reported as changed.
+	Kind    ChangeType
+	Changed bool
+
+	// Children are the files of the directory, sorted by name.
+	Children []*ChangeNode
+}
+
+// BuildChangeTree groups the changes hierarchically, by directory,
+// returning the root of the tree, for the / directory.
+func BuildChangeTree(changes []Change) *ChangeNode {
+	root := &ChangeNode{Name: "/", Path: "/"}
+	nodes := map[string]*ChangeNode{"/": root}
+	var node func(p string) *ChangeNode
+	node = func(p string) *ChangeNode {
+		if n, ok := nodes[p]; ok {
+			return n
+		}
+		n := &ChangeNode{Name: path.Base(p), Path: p}
+		nodes[p] = n
+		parent := node(path.Dir(p))
+		parent.Children = append(parent.Children, n)
+		return n
+	}
+	for _, change := range changes {
+		n := node(path.Clean("/" + change.Path))
+		n.Kind = change.Kind
+		n.Changed = true
+	}
+	for _, n := range nodes {
+		sort.Slice(n.Children, func(i, j int) bool {
+			return n.Children[i].Name < n.Children[j].Name
+		})
+	}
+	return root
+}
+
+// Walk calls fn for the node and all the nodes under it, depth first, with
+// the depth of each node relative to n.
+func (n *ChangeNode) Walk(fn func(node *ChangeNode, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *ChangeNode) walk(fn func(*ChangeNode, int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// String returns the tree under the node, one file per line, indented by
+// depth, with the kind of the change of the files changed, in the format of
+// Change.String.
+func (n *ChangeNode) String() string {
+	var b strings.Builder
+	n.Walk(func(node *ChangeNode, depth int) {
+		kind := " "
+		if node.Changed {
+			kind = node.Kind.letter()
+		}
+		fmt.Fprintf(&b, "%1s %s%s\n", kind, strings.Repeat("  ", depth), node.Name)
+	})
+	return b.String()
 }
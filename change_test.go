@@ -4,7 +4,11 @@
 
 package docker
 
-import "testing"
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
 
 func TestChangeString(t *testing.T) {
 	t.Parallel()
@@ -27,3 +31,108 @@ func TestChangeString(t *testing.T) {
 		})
 	}
 }
+
+func TestChangeTypeString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		kind     ChangeType
+		expected string
+	}{
+		{ChangeModify, "Modified"},
+		{ChangeAdd, "Added"},
+		{ChangeDelete, "Deleted"},
+		{33, "ChangeType(33)"},
+	}
+	for _, tt := range tests {
+		if got := tt.kind.String(); got != tt.expected {
+			t.Errorf("ChangeType.String(): want %q. Got %q.", tt.expected, got)
+		}
+	}
+}
+
+var testChanges = []Change{
+	{"/etc", ChangeModify},
+	{"/etc/passwd", ChangeModify},
+	{"/etcd", ChangeAdd},
+	{"/var", ChangeModify},
+	{"/var/cache/app", ChangeAdd},
+	{"/var/cache/app/index", ChangeAdd},
+	{"/var/cache/app/data/1", ChangeAdd},
+	{"/tmp/build", ChangeDelete},
+}
+
+func TestFilterChanges(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		prefix   string
+		expected []Change
+	}{
+		{"/etc", []Change{{"/etc", ChangeModify}, {"/etc/passwd", ChangeModify}}},
+		{"etc/", []Change{{"/etc", ChangeModify}, {"/etc/passwd", ChangeModify}}},
+		{"/var/cache/app/data", []Change{{"/var/cache/app/data/1", ChangeAdd}}},
+		{"/usr", nil},
+		{"/", testChanges},
+	}
+	for _, tt := range tests {
+		if got := FilterChanges(testChanges, tt.prefix); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("FilterChanges(%q): want %#v. Got %#v.", tt.prefix, tt.expected, got)
+		}
+	}
+}
+
+func TestChangesToIgnorePatterns(t *testing.T) {
+	t.Parallel()
+	expected := []string{"etc/passwd", "etcd", "tmp/build", "var/cache/app"}
+	if got := ChangesToIgnorePatterns(testChanges); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ChangesToIgnorePatterns: want %#v. Got %#v.", expected, got)
+	}
+	if got := ChangesToIgnorePatterns(nil); got != nil {
+		t.Errorf("ChangesToIgnorePatterns(nil): want <nil>. Got %#v.", got)
+	}
+}
+
+func TestChangesToIgnorePatternsSiblingPrefix(t *testing.T) {
+	t.Parallel()
+	changes := []Change{
+		{Path: "/etc", Kind: ChangeModify},
+		{Path: "/etc-old", Kind: ChangeAdd},
+		{Path: "/etc/x", Kind: ChangeAdd},
+		{Path: "/opt", Kind: ChangeAdd},
+		{Path: "/opt-old", Kind: ChangeAdd},
+		{Path: "/opt-old/y", Kind: ChangeAdd},
+		{Path: "/opt/z", Kind: ChangeAdd},
+	}
+	expected := []string{"etc-old", "etc/x", "opt", "opt-old"}
+	if got := ChangesToIgnorePatterns(changes); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ChangesToIgnorePatterns: want %#v. Got %#v.", expected, got)
+	}
+}
+
+func TestBuildChangeTree(t *testing.T) {
+	t.Parallel()
+	root := BuildChangeTree(testChanges)
+	expected := `  /
+C   etc
+C     passwd
+A   etcd
+    tmp
+D     build
+C   var
+      cache
+A       app
+          data
+A           1
+A         index
+`
+	if got := root.String(); got != expected {
+		t.Errorf("BuildChangeTree: wrong tree.\nWant:\n%s\nGot:\n%s", expected, got)
+	}
+	var paths []string
+	root.Children[3].Walk(func(node *ChangeNode, depth int) {
+		paths = append(paths, fmt.Sprintf("%d %s", depth, node.Path))
+	})
+	expectedPaths := []string{"0 /var", "1 /var/cache", "2 /var/cache/app", "3 /var/cache/app/data", "4 /var/cache/app/data/1", "3 /var/cache/app/index"}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("ChangeNode.Walk: want %#v. Got %#v.", expectedPaths, paths)
+	}
+}
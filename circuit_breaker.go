@@ -0,0 +1,142 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerCooldown         = 10 * time.Second
+)
+
+// ErrDaemonUnavailable is the error returned by the calls short-circuited by
+// the CircuitBreaker of the client while the daemon is considered down.
+var ErrDaemonUnavailable = errors.New("docker daemon unavailable")
+
+// CircuitBreaker short-circuits the calls to the daemon after consecutive
+// transport failures, i.e. calls that couldn't get a response from the
+// daemon, so callers fail fast during daemon outages instead of waiting for
+// the connections to time out. Calls answered by the daemon, even with an
+// error, count as successes.
+//
+// Once open, the circuit stays open for Cooldown, calls failing with
+// ErrDaemonUnavailable. The first call after the cooldown pings the daemon,
+// closing the circuit when the daemon answers, or opening it for another
+// cooldown otherwise, while the other calls keep failing.
+//
+// A CircuitBreaker is set in Client.CircuitBreaker, and may be shared by the
+// clients of the same daemon. It's safe for concurrent use.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive transport failures
+	// that opens the circuit. Defaults to 5.
+	FailureThreshold int
+
+	// Cooldown is how long the circuit stays open before the daemon is
+	// probed. Defaults to 10 seconds.
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// IsOpen tells whether the circuit is open, calls failing with
+// ErrDaemonUnavailable.
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold()
+}
+
+func (b *CircuitBreaker) threshold() int {
+	if b.FailureThreshold <= 0 {
+		return defaultBreakerFailureThreshold
+	}
+	return b.FailureThreshold
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return defaultBreakerCooldown
+	}
+	return b.Cooldown
+}
+
+// allow returns ErrDaemonUnavailable when the call must be short-circuited,
+// calling probe when the cooldown is over.
+func (b *CircuitBreaker) allow(probe func() error) error {
+	b.mu.Lock()
+	if b.failures < b.threshold() {
+		b.mu.Unlock()
+		return nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown() {
+		b.mu.Unlock()
+		return ErrDaemonUnavailable
+	}
+	b.probing = true
+	b.mu.Unlock()
+	err := probe()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err != nil {
+		b.openedAt = time.Now()
+		return ErrDaemonUnavailable
+	}
+	b.failures = 0
+	return nil
+}
+
+// record records the result of a call, failed when the daemon couldn't be
+// reached.
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold() {
+		b.openedAt = time.Now()
+	}
+}
+
+// breakerAllow checks the circuit breaker of the client, if any, before a
+// call.
+func (c *Client) breakerAllow(ctx context.Context) error {
+	if c.CircuitBreaker == nil {
+		return nil
+	}
+	return c.CircuitBreaker.allow(func() error {
+		resp, err := c.do("GET", "/_ping", doOptions{context: ctx, probe: true})
+		if err != nil {
+			if _, ok := err.(*Error); ok {
+				return nil
+			}
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+}
+
+// breakerRecord records the transport error of a call, nil when the daemon
+// answered, in the circuit breaker of the client, if any. ctx is the context
+// of the caller: calls it canceled aren't recorded, while calls exceeding the
+// request timeouts of the client are failures.
+func (c *Client) breakerRecord(ctx context.Context, err error) {
+	if c.CircuitBreaker == nil || ctx.Err() != nil {
+		return
+	}
+	c.CircuitBreaker.record(err != nil)
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyDaemon is a transport failing all the requests while down.
+type flakyDaemon struct {
+	mu       sync.Mutex
+	down     bool
+	requests []string
+}
+
+func (d *flakyDaemon) RoundTrip(r *http.Request) (*http.Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, r.URL.Path)
+	if d.down {
+		return nil, errors.New("dial unix /var/run/docker.sock: connect: no such file or directory")
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("{}")),
+		Request:    r,
+	}, nil
+}
+
+func (d *flakyDaemon) setDown(down bool) {
+	d.mu.Lock()
+	d.down = down
+	d.mu.Unlock()
+}
+
+func (d *flakyDaemon) requestCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.requests)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	daemon := &flakyDaemon{down: true}
+	client := newTestClient(daemon)
+	client.CircuitBreaker = &CircuitBreaker{FailureThreshold: 3, Cooldown: 50 * time.Millisecond}
+	for i := 0; i < 3; i++ {
+		if _, err := client.InspectContainer("c1"); err == nil || err == ErrDaemonUnavailable {
+			t.Fatalf("InspectContainer #%d: want a transport error. Got %v.", i, err)
+		}
+	}
+	if !client.CircuitBreaker.IsOpen() {
+		t.Fatal("CircuitBreaker: want the circuit open after 3 failures")
+	}
+	requests := daemon.requestCount()
+	if _, err := client.InspectContainer("c1"); err != ErrDaemonUnavailable {
+		t.Errorf("InspectContainer: wrong error. Want %v. Got %v.", ErrDaemonUnavailable, err)
+	}
+	if err := client.ExportContainer(ExportContainerOptions{ID: "c1", OutputStream: ioutil.Discard}); err != ErrDaemonUnavailable {
+		t.Errorf("ExportContainer: wrong error. Want %v. Got %v.", ErrDaemonUnavailable, err)
+	}
+	if n := daemon.requestCount(); n != requests {
+		t.Errorf("CircuitBreaker: unexpected requests while open: %d", n-requests)
+	}
+
+	// the probe fails while the daemon is down, and the circuit stays open.
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.InspectContainer("c1"); err != ErrDaemonUnavailable {
+		t.Errorf("InspectContainer: wrong error. Want %v. Got %v.", ErrDaemonUnavailable, err)
+	}
+	if n := daemon.requestCount(); n != requests+1 || daemon.requests[n-1] != "/_ping" {
+		t.Errorf("CircuitBreaker: want one probe. Got %#v.", daemon.requests[requests:])
+	}
+	if _, err := client.InspectContainer("c1"); err != ErrDaemonUnavailable {
+		t.Errorf("InspectContainer: wrong error. Want %v. Got %v.", ErrDaemonUnavailable, err)
+	}
+
+	daemon.setDown(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.InspectContainer("c1"); err != nil {
+		t.Errorf("InspectContainer: unexpected error after the daemon is back: %v", err)
+	}
+	if client.CircuitBreaker.IsOpen() {
+		t.Error("CircuitBreaker: want the circuit closed after a successful probe")
+	}
+}
+
+func TestCircuitBreakerResetOnResponse(t *testing.T) {
+	t.Parallel()
+	daemon := &flakyDaemon{}
+	client := newTestClient(daemon)
+	client.CircuitBreaker = &CircuitBreaker{FailureThreshold: 2}
+	for i := 0; i < 5; i++ {
+		daemon.setDown(true)
+		client.InspectContainer("c1")
+		daemon.setDown(false)
+		if _, err := client.InspectContainer("c1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.CircuitBreaker.IsOpen() {
+		t.Error("CircuitBreaker: want the circuit closed, failures aren't consecutive")
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledCalls(t *testing.T) {
+	t.Parallel()
+	daemon := &flakyDaemon{down: true}
+	client := newTestClient(daemon)
+	client.CircuitBreaker = &CircuitBreaker{FailureThreshold: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.InspectContainerWithContext("c1", ctx)
+	if client.CircuitBreaker.IsOpen() {
+		t.Error("CircuitBreaker: canceled calls must not open the circuit")
+	}
+}
+
+func TestCircuitBreakerCountsRequestTimeouts(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Timeouts = RequestTimeouts{Short: 50 * time.Millisecond}
+	client.CircuitBreaker = &CircuitBreaker{FailureThreshold: 2, Cooldown: time.Minute}
+	for i := 0; i < 2; i++ {
+		if _, err := client.InspectContainer("c1"); err != context.DeadlineExceeded {
+			t.Fatalf("InspectContainer #%d: wrong error. Want %v. Got %v.", i, context.DeadlineExceeded, err)
+		}
+	}
+	if !client.CircuitBreaker.IsOpen() {
+		t.Fatal("CircuitBreaker: want the circuit open after the calls timed out")
+	}
+	if _, err := client.InspectContainer("c1"); err != ErrDaemonUnavailable {
+		t.Errorf("InspectContainer: wrong error. Want %v. Got %v.", ErrDaemonUnavailable, err)
+	}
+}
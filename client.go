@@ -28,6 +28,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -57,7 +58,13 @@ var (
 	apiVersion119, _ = NewAPIVersion("1.19")
 	apiVersion124, _ = NewAPIVersion("1.24")
 	apiVersion125, _ = NewAPIVersion("1.25")
+	apiVersion131, _ = NewAPIVersion("1.31")
 	apiVersion135, _ = NewAPIVersion("1.35")
+	apiVersion137, _ = NewAPIVersion("1.37")
+	apiVersion139, _ = NewAPIVersion("1.39")
+	apiVersion141, _ = NewAPIVersion("1.41")
+	apiVersion142, _ = NewAPIVersion("1.42")
+	apiVersion143, _ = NewAPIVersion("1.43")
 )
 
 // APIVersion is an internal representation of a version of the Remote API.
@@ -138,18 +145,99 @@ func (version APIVersion) compare(other APIVersion) int {
 
 // Client is the basic type of this package. It provides methods for
 // interaction with the API.
+//
+// A Client is safe for concurrent use by multiple goroutines once configured:
+// its fields and the methods that change its settings (SetBasePath,
+// SetAPIVersion, WithTransport) must not be used concurrently with other
+// methods. Use Clone to get clients with different settings that share the
+// same connections to the daemon.
 type Client struct {
 	SkipServerVersionCheck bool
 	HTTPClient             *http.Client
 	TLSConfig              *tls.Config
 	Dialer                 Dialer
 
+	// VolumeHelperImage is the image used for the helper containers
+	// created by VolumeBackup and VolumeRestore. When empty,
+	// DefaultVolumeHelperImage is used.
+	VolumeHelperImage string
+
+	// PathMapper, when set, translates the source of bind mounts from paths
+	// in the client to paths in the daemon when creating containers.
+	PathMapper PathMapper
+
+	// AuditSink, when set, receives a record of every mutating call sent to
+	// the daemon, identified by AuditIdentity (e.g. the user or service on
+	// whose behalf the client runs).
+	AuditSink     AuditSink
+	AuditIdentity string
+
+	// Headers are sent along with every request to the daemon, e.g. an
+	// Authorization header for daemons behind an authenticating proxy. The
+	// headers set by the methods of the client take precedence.
+	Headers map[string]string
+
+	// Timeouts are the timeouts of the calls to the daemon, by class of
+	// endpoint, see RequestTimeouts.
+	Timeouts RequestTimeouts
+
+	// CircuitBreaker, when set, short-circuits the calls to the daemon
+	// after consecutive transport failures, see CircuitBreaker.
+	CircuitBreaker *CircuitBreaker
+
+	// PruneUnsupportedFields makes CreateContainer and StartContainer drop
+	// the fields of the configuration that the version of the API the
+	// client speaks doesn't support, instead of sending them, see
+	// PruneForAPIVersion. That's the version the client was created with,
+	// or else the version of the server, fetched when it isn't known yet.
+	PruneUnsupportedFields bool
+
+	// LocalPrivileged enables the helpers running commands of the host in
+	// the namespaces of containers, e.g. Nsenter, for clients running on
+	// the host of the daemon with its privileges.
+	LocalPrivileged bool
+
+	// KeepAlive, when positive, enables TCP keep-alive probes with the given
+	// interval on the long-lived connections opened by the client outside of
+	// HTTPClient: attach and exec sessions and the event listener. It keeps
+	// idle sessions from being dropped by NATs and load balancers. Other
+	// requests use the keep-alive settings of HTTPClient's transport.
+	KeepAlive time.Duration
+
+	// MaxResponseSize, when positive, limits the size of the JSON responses
+	// of the daemon, e.g. of inspect and list calls, guarding against
+	// pathological ones. Larger responses fail with a
+	// *ResponseTooLargeError. Streams, such as events, aren't limited.
+	MaxResponseSize int64
+
+	// DecompressResponses makes the client decompress the responses
+	// compressed with gzip without being asked to, e.g. by proxies between
+	// the client and the daemon.
+	DecompressResponses bool
+
+	// ValidateImageReferences makes TagImage and the functions removing
+	// images validate the references they're given with the reference
+	// package before calling the daemon, failing with an
+	// *InvalidImageReference when they're malformed.
+	ValidateImageReferences bool
+
 	endpoint            string
 	endpointURL         *url.URL
+	basePath            string
 	eventMonitor        *eventMonitoringState
+	pulls               *pullGroup
 	requestedAPIVersion APIVersion
-	serverAPIVersion    APIVersion
-	expectedAPIVersion  APIVersion
+
+	// procRoot is the mount point of the proc filesystem of the host,
+	// /proc when empty.
+	procRoot string
+
+	// versionMu guards serverAPIVersion and expectedAPIVersion, which are
+	// set by the first call when the version of the server isn't known yet.
+	// It's nil in clients that weren't created by the constructors.
+	versionMu          *sync.RWMutex
+	serverAPIVersion   APIVersion
+	expectedAPIVersion APIVersion
 }
 
 // Dialer is an interface that allows network connections to be dialed
@@ -215,7 +303,9 @@ func NewVersionedClient(endpoint string, apiVersionString string) (*Client, erro
 		endpoint:            endpoint,
 		endpointURL:         u,
 		eventMonitor:        new(eventMonitoringState),
+		pulls:               new(pullGroup),
 		requestedAPIVersion: requestedAPIVersion,
+		versionMu:           new(sync.RWMutex),
 	}
 	c.initializeNativeClient(defaultTransport)
 	return c, nil
@@ -264,6 +354,9 @@ func NewVersionedTLSClient(endpoint string, cert, key, ca, apiVersionString stri
 // NewClientFromEnv returns a Client instance ready for communication created from
 // Docker's default logic for the environment variables DOCKER_HOST, DOCKER_TLS_VERIFY, and DOCKER_CERT_PATH.
 //
+// When DOCKER_HOST is not set, the endpoint is discovered using
+// DiscoverDockerHost. Use Endpoint to find out which endpoint was chosen.
+//
 // See https://github.com/docker/docker/blob/1f963af697e8df3a78217f6fdbf67b8123a7db94/docker/docker.go#L68.
 // See https://github.com/docker/compose/blob/81707ef1ad94403789166d2fe042c8a718a4c748/compose/cli/docker_client.py#L7.
 func NewClientFromEnv() (*Client, error) {
@@ -344,7 +437,9 @@ func NewVersionedTLSClientFromBytes(endpoint string, certPEMBlock, keyPEMBlock,
 		endpoint:            endpoint,
 		endpointURL:         u,
 		eventMonitor:        new(eventMonitoringState),
+		pulls:               new(pullGroup),
 		requestedAPIVersion: requestedAPIVersion,
+		versionMu:           new(sync.RWMutex),
 	}
 	c.initializeNativeClient(defaultTransport)
 	return c, nil
@@ -358,15 +453,106 @@ func (c *Client) SetTimeout(t time.Duration) {
 	}
 }
 
+// SetBasePath sets a prefix for the path of all requests sent to the daemon,
+// for daemons behind reverse proxies that mount the API under a subpath, e.g.
+// "/dockerapi". The prefix goes before the API version prefix of versioned
+// clients. It also works as a version override on clients that are not
+// versioned, e.g. "/v1.41". It should not be called concurrently with any
+// other Client methods.
+func (c *Client) SetBasePath(prefix string) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	c.basePath = prefix
+}
+
+// SetAPIVersion changes the version of the API used by the client, as the
+// apiVersionString of NewVersionedClient: an empty string uses the version of
+// the server. It should not be called concurrently with any other Client
+// methods.
+func (c *Client) SetAPIVersion(apiVersionString string) error {
+	var requestedAPIVersion APIVersion
+	if apiVersionString != "" {
+		var err error
+		requestedAPIVersion, err = NewAPIVersion(apiVersionString)
+		if err != nil {
+			return err
+		}
+	}
+	if c.versionMu != nil {
+		c.versionMu.Lock()
+		defer c.versionMu.Unlock()
+	}
+	c.requestedAPIVersion = requestedAPIVersion
+	if requestedAPIVersion != nil {
+		c.expectedAPIVersion = requestedAPIVersion
+	} else {
+		c.expectedAPIVersion = c.serverAPIVersion
+	}
+	return nil
+}
+
+// Clone returns a copy of the client that shares the transport of its HTTP
+// client and its dialer, and so its connections to the daemon, but whose
+// settings can be changed independently, like Headers, the audit identity,
+// the timeout (see SetTimeout) or the API version (see SetAPIVersion). The
+// circuit breaker is shared too. The event listeners of c are not copied.
+func (c *Client) Clone() *Client {
+	clone := &Client{
+		SkipServerVersionCheck:  c.SkipServerVersionCheck,
+		TLSConfig:               c.TLSConfig,
+		Dialer:                  c.Dialer,
+		VolumeHelperImage:       c.VolumeHelperImage,
+		PathMapper:              c.PathMapper,
+		AuditSink:               c.AuditSink,
+		AuditIdentity:           c.AuditIdentity,
+		Timeouts:                c.Timeouts,
+		CircuitBreaker:          c.CircuitBreaker,
+		PruneUnsupportedFields:  c.PruneUnsupportedFields,
+		LocalPrivileged:         c.LocalPrivileged,
+		KeepAlive:               c.KeepAlive,
+		MaxResponseSize:         c.MaxResponseSize,
+		DecompressResponses:     c.DecompressResponses,
+		ValidateImageReferences: c.ValidateImageReferences,
+		endpoint:                c.endpoint,
+		endpointURL:             c.endpointURL,
+		basePath:                c.basePath,
+		eventMonitor:            new(eventMonitoringState),
+		pulls:                   new(pullGroup),
+		requestedAPIVersion:     c.requestedAPIVersion,
+		procRoot:                c.procRoot,
+		versionMu:               new(sync.RWMutex),
+		serverAPIVersion:        c.getServerAPIVersion(),
+		expectedAPIVersion:      c.getExpectedAPIVersion(),
+	}
+	if c.HTTPClient != nil {
+		httpClient := *c.HTTPClient
+		clone.HTTPClient = &httpClient
+	}
+	if c.Headers != nil {
+		clone.Headers = make(map[string]string, len(c.Headers))
+		for key, value := range c.Headers {
+			clone.Headers[key] = value
+		}
+	}
+	return clone
+}
+
 func (c *Client) checkAPIVersion() error {
 	serverAPIVersionString, err := c.getServerAPIVersionString()
 	if err != nil {
 		return err
 	}
-	c.serverAPIVersion, err = NewAPIVersion(serverAPIVersionString)
+	serverAPIVersion, err := NewAPIVersion(serverAPIVersionString)
 	if err != nil {
 		return err
 	}
+	if c.versionMu != nil {
+		c.versionMu.Lock()
+		defer c.versionMu.Unlock()
+	}
+	c.serverAPIVersion = serverAPIVersion
 	if c.requestedAPIVersion == nil {
 		c.expectedAPIVersion = c.serverAPIVersion
 	} else {
@@ -375,6 +561,26 @@ func (c *Client) checkAPIVersion() error {
 	return nil
 }
 
+// getServerAPIVersion returns the API version of the server, or nil when it
+// isn't known yet.
+func (c *Client) getServerAPIVersion() APIVersion {
+	if c.versionMu != nil {
+		c.versionMu.RLock()
+		defer c.versionMu.RUnlock()
+	}
+	return c.serverAPIVersion
+}
+
+// getExpectedAPIVersion returns the API version the client speaks, or nil
+// when the version of the server hasn't been checked yet.
+func (c *Client) getExpectedAPIVersion() APIVersion {
+	if c.versionMu != nil {
+		c.versionMu.RLock()
+		defer c.versionMu.RUnlock()
+	}
+	return c.expectedAPIVersion
+}
+
 // Endpoint returns the current endpoint. It's useful for getting the endpoint
 // when using functions that get this data from the environment (like
 // NewClientFromEnv.
@@ -430,10 +636,28 @@ type doOptions struct {
 	forceJSON bool
 	headers   map[string]string
 	context   context.Context
+	// body is sent as is, instead of the JSON encoding of data.
+	body io.Reader
+	// probe is set for the pings of the circuit breaker, which bypass it
+	// and the version check.
+	probe bool
+	// timeoutGrace is added to the request timeout of the call, for the
+	// calls the daemon answers after waiting, e.g. to stop a container.
+	timeoutGrace time.Duration
 }
 
-func (c *Client) do(method, path string, doOptions doOptions) (*http.Response, error) {
-	var params io.Reader
+func (c *Client) do(method, path string, doOptions doOptions) (resp *http.Response, err error) {
+	if c.auditing(method) {
+		start := time.Now()
+		defer func() {
+			var status int
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			c.audit(method, path, start, status, err)
+		}()
+	}
+	params := doOptions.body
 	if doOptions.data != nil || doOptions.forceJSON {
 		buf, err := json.Marshal(doOptions.data)
 		if err != nil {
@@ -441,7 +665,7 @@ func (c *Client) do(method, path string, doOptions doOptions) (*http.Response, e
 		}
 		params = bytes.NewBuffer(buf)
 	}
-	if path != "/version" && !c.SkipServerVersionCheck && c.expectedAPIVersion == nil {
+	if path != "/version" && !doOptions.probe && !c.SkipServerVersionCheck && c.getExpectedAPIVersion() == nil {
 		err := c.checkAPIVersion()
 		if err != nil {
 			return nil, err
@@ -460,6 +684,7 @@ func (c *Client) do(method, path string, doOptions doOptions) (*http.Response, e
 	if err != nil {
 		return nil, err
 	}
+	c.setHeaders(req)
 	req.Header.Set("User-Agent", userAgent)
 	if doOptions.data != nil {
 		req.Header.Set("Content-Type", "application/json")
@@ -476,7 +701,27 @@ func (c *Client) do(method, path string, doOptions doOptions) (*http.Response, e
 		ctx = context.Background()
 	}
 
-	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	// the circuit breaker tells the calls canceled by the caller from the
+	// ones exceeding the request timeouts, which are failures of the daemon.
+	callerCtx := ctx
+	ctx, cancel := c.withRequestTimeout(ctx, method, path, doOptions.timeoutGrace)
+	defer func() {
+		if resp != nil {
+			resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+		} else {
+			cancel()
+		}
+	}()
+
+	if !doOptions.probe {
+		if err := c.breakerAllow(ctx); err != nil {
+			return nil, err
+		}
+	}
+	resp, err = c.HTTPClient.Do(req.WithContext(ctx))
+	if !doOptions.probe {
+		c.breakerRecord(callerCtx, err)
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "connection refused") {
 			return nil, ErrConnectionRefused
@@ -484,6 +729,8 @@ func (c *Client) do(method, path string, doOptions doOptions) (*http.Response, e
 
 		return nil, chooseError(ctx, err)
 	}
+	c.decompressResponse(resp)
+	c.limitResponse(method, path, resp)
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
 		return nil, newError(resp)
 	}
@@ -505,6 +752,15 @@ type streamOptions struct {
 	// arrives
 	inactivityTimeout time.Duration
 	context           context.Context
+	// auxCallback receives the aux messages of a JSON stream
+	auxCallback func(AuxMessage)
+}
+
+// setHeaders sets the headers of the client in the request.
+func (c *Client) setHeaders(req *http.Request) {
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
 }
 
 // if error in context, return that instead of generic http error
@@ -517,11 +773,16 @@ func chooseError(ctx context.Context, err error) error {
 	}
 }
 
-func (c *Client) stream(method, path string, streamOptions streamOptions) error {
+func (c *Client) stream(method, path string, streamOptions streamOptions) (err error) {
+	var status int
+	if c.auditing(method) {
+		start := time.Now()
+		defer func() { c.audit(method, path, start, status, err) }()
+	}
 	if (method == "POST" || method == "PUT") && streamOptions.in == nil {
 		streamOptions.in = bytes.NewReader(nil)
 	}
-	if path != "/version" && !c.SkipServerVersionCheck && c.expectedAPIVersion == nil {
+	if path != "/version" && !c.SkipServerVersionCheck && c.getExpectedAPIVersion() == nil {
 		err := c.checkAPIVersion()
 		if err != nil {
 			return err
@@ -531,6 +792,7 @@ func (c *Client) stream(method, path string, streamOptions streamOptions) error
 	if err != nil {
 		return err
 	}
+	c.setHeaders(req)
 	req.Header.Set("User-Agent", userAgent)
 	if method == "POST" {
 		req.Header.Set("Content-Type", "plain/text")
@@ -556,6 +818,16 @@ func (c *Client) stream(method, path string, streamOptions streamOptions) error
 	subCtx, cancelRequest := context.WithCancel(ctx)
 	defer cancelRequest()
 
+	if err := c.breakerAllow(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if resp != nil {
+			c.breakerRecord(ctx, nil)
+		} else {
+			c.breakerRecord(ctx, err)
+		}
+	}()
 	if protocol == unixProtocol || protocol == namedPipeProtocol {
 		var dial net.Conn
 		dial, err = c.Dialer.Dial(protocol, address)
@@ -602,7 +874,9 @@ func (c *Client) stream(method, path string, streamOptions streamOptions) error
 			close(streamOptions.reqSent)
 		}
 	}
+	c.decompressResponse(resp)
 	defer resp.Body.Close()
+	status = resp.StatusCode
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
 		return newError(resp)
 	}
@@ -635,13 +909,28 @@ func handleStreamResponse(resp *http.Response, streamOptions *streamOptions) err
 	// if we want to get raw json stream, just copy it back to output
 	// without decoding it
 	if streamOptions.rawJSONStream {
+		if streamOptions.auxCallback != nil {
+			tap := newJSONStreamTap(streamOptions.stdout, true, auxMessages(streamOptions.auxCallback))
+			_, err = io.Copy(tap, resp.Body)
+			if tapErr := tap.Close(); err == nil {
+				err = tapErr
+			}
+			return err
+		}
 		_, err = io.Copy(streamOptions.stdout, resp.Body)
 		return err
 	}
+	var auxCallback func(jsonmessage.JSONMessage)
+	if streamOptions.auxCallback != nil {
+		fn := auxMessages(streamOptions.auxCallback)
+		auxCallback = func(msg jsonmessage.JSONMessage) {
+			fn(&msg)
+		}
+	}
 	if st, ok := streamOptions.stdout.(stream); ok {
-		err = jsonmessage.DisplayJSONMessagesToStream(resp.Body, st, nil)
+		err = jsonmessage.DisplayJSONMessagesToStream(resp.Body, st, auxCallback)
 	} else {
-		err = jsonmessage.DisplayJSONMessagesStream(resp.Body, streamOptions.stdout, 0, false, nil)
+		err = jsonmessage.DisplayJSONMessagesStream(resp.Body, streamOptions.stdout, 0, false, auxCallback)
 	}
 	return err
 }
@@ -696,6 +985,7 @@ type hijackOptions struct {
 	stdout         io.Writer
 	stderr         io.Writer
 	data           interface{}
+	context        context.Context
 }
 
 // CloseWaiter is an interface with methods for closing the underlying resource
@@ -713,8 +1003,27 @@ type closerFunc func() error
 
 func (c closerFunc) Close() error { return c() }
 
-func (c *Client) hijack(method, path string, hijackOptions hijackOptions) (CloseWaiter, error) {
-	if path != "/version" && !c.SkipServerVersionCheck && c.expectedAPIVersion == nil {
+func (c *Client) hijack(method, path string, hijackOptions hijackOptions) (_ CloseWaiter, err error) {
+	start := time.Now()
+	audit := func(status int, err error) {
+		if c.auditing(method) {
+			c.audit(method, path, start, status, err)
+		}
+	}
+	// once the request is sent, the call is audited when the daemon responds.
+	defer func() {
+		if err != nil {
+			audit(0, err)
+		}
+	}()
+	ctx := hijackOptions.context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if path != "/version" && !c.SkipServerVersionCheck && c.getExpectedAPIVersion() == nil {
 		err := c.checkAPIVersion()
 		if err != nil {
 			return nil, err
@@ -732,6 +1041,7 @@ func (c *Client) hijack(method, path string, hijackOptions hijackOptions) (Close
 	if err != nil {
 		return nil, err
 	}
+	c.setHeaders(req)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Connection", "Upgrade")
 	req.Header.Set("Upgrade", "tcp")
@@ -757,17 +1067,66 @@ func (c *Client) hijack(method, path string, hijackOptions hijackOptions) (Close
 			return nil, err
 		}
 	}
+	c.enableKeepAlive(dial)
 
 	errs := make(chan error, 1)
 	quit := make(chan struct{})
+	finished := make(chan struct{})
+	// closing the connection is the only way to interrupt the request and
+	// the copies from and to the hijacked connection.
 	go func() {
+		select {
+		case <-ctx.Done():
+			dial.Close()
+		case <-finished:
+		}
+	}()
+	// signalSuccess sends the sentinel of hijackOptions.success and waits for
+	// the caller to hand it back. It's sent even when the request fails, so
+	// callers blocked on the channel get to call Wait for the error.
+	signalSuccess := func() error {
+		if hijackOptions.success == nil {
+			return nil
+		}
+		select {
+		case hijackOptions.success <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case <-hijackOptions.success:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	}
+	go func() {
+		defer close(finished)
 		//lint:ignore SA1019 this is needed here
 		clientconn := httputil.NewClientConn(dial, nil)
 		defer clientconn.Close()
-		clientconn.Do(req)
-		if hijackOptions.success != nil {
-			hijackOptions.success <- struct{}{}
-			<-hijackOptions.success
+		resp, err := clientconn.Do(req)
+		if resp == nil {
+			err = hijackError(ctx, err)
+			audit(0, err)
+			signalSuccess()
+			errs <- err
+			return
+		}
+		// the daemon upgrades the connection (101) or, in older versions,
+		// responds with 200 before streaming. Anything else is an error
+		// described in the body of the response.
+		if resp.StatusCode != http.StatusSwitchingProtocols && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+			err = newError(resp)
+			audit(resp.StatusCode, err)
+			signalSuccess()
+			errs <- err
+			return
+		}
+		audit(resp.StatusCode, nil)
+		if err := signalSuccess(); err != nil {
+			errs <- err
+			return
 		}
 		rwc, br := clientconn.Hijack()
 		defer rwc.Close()
@@ -814,27 +1173,30 @@ func (c *Client) hijack(method, path string, hijackOptions hijackOptions) (Close
 				_, err = io.Copy(rwc, hijackOptions.in)
 			}
 			errChanIn <- err
-			rwc.(interface {
-				CloseWrite() error
-			}).CloseWrite()
+			closeWrite(rwc)
 		}()
 
 		var errIn error
 		select {
 		case errIn = <-errChanIn:
 		case <-quit:
+		case <-ctx.Done():
 		}
 
 		var errOut error
 		select {
 		case errOut = <-errChanOut:
 		case <-quit:
+		case <-ctx.Done():
+			// the output copy fails as soon as the connection is
+			// closed, wait for it so nothing is written afterwards.
+			<-errChanOut
 		}
 
 		if errIn != nil {
-			errs <- errIn
+			errs <- hijackError(ctx, errIn)
 		} else {
-			errs <- errOut
+			errs <- hijackError(ctx, errOut)
 		}
 	}()
 
@@ -847,15 +1209,63 @@ func (c *Client) hijack(method, path string, hijackOptions hijackOptions) (Close
 	}, nil
 }
 
+// enableKeepAlive enables TCP keep-alive probes on the given connection, as
+// configured by c.KeepAlive. Connections that are not TCP, like unix sockets
+// and named pipes, are left untouched.
+func (c *Client) enableKeepAlive(conn net.Conn) {
+	if c.KeepAlive <= 0 {
+		return
+	}
+	if tlsConn, ok := conn.(*tlsClientCon); ok {
+		conn = tlsConn.rawConn
+	}
+	if kac, ok := conn.(interface {
+		SetKeepAlive(bool) error
+		SetKeepAlivePeriod(time.Duration) error
+	}); ok {
+		kac.SetKeepAlive(true)
+		kac.SetKeepAlivePeriod(c.KeepAlive)
+	}
+}
+
+// closeWrite signals EOF to the other end of the connection, by shutting down
+// its writing side. It's supported on TCP, TLS and unix socket connections, as
+// well as named pipes in message mode, which is the mode used by the daemon.
+// It's a no-op on other connections.
+func closeWrite(conn net.Conn) error {
+	if cw, ok := conn.(interface {
+		CloseWrite() error
+	}); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
+
+// hijackError returns the context error when a hijacked connection was
+// interrupted by the cancellation of the context, as the error of the
+// interrupted operation is just a consequence of closing the connection.
+func hijackError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
 func (c *Client) getURL(path string) string {
 	urlStr := strings.TrimRight(c.endpointURL.String(), "/")
 	if c.endpointURL.Scheme == unixProtocol || c.endpointURL.Scheme == namedPipeProtocol {
 		urlStr = ""
 	}
+	return urlStr + c.getPath(path)
+}
+
+// getPath returns the path of the given API endpoint, with the base path and
+// the API version prefix.
+func (c *Client) getPath(path string) string {
 	if c.requestedAPIVersion != nil {
-		return fmt.Sprintf("%s/v%s%s", urlStr, c.requestedAPIVersion, path)
+		return fmt.Sprintf("%s/v%s%s", c.basePath, c.requestedAPIVersion, path)
 	}
-	return fmt.Sprintf("%s%s", urlStr, path)
+	return c.basePath + path
 }
 
 // getFakeNativeURL returns the URL needed to make an HTTP request over a UNIX
@@ -868,10 +1278,7 @@ func (c *Client) getFakeNativeURL(path string) string {
 	u.Host = "unix.sock" // Doesn't matter what this is - it's not used.
 	u.Path = ""
 	urlStr := strings.TrimRight(u.String(), "/")
-	if c.requestedAPIVersion != nil {
-		return fmt.Sprintf("%s/v%s%s", urlStr, c.requestedAPIVersion, path)
-	}
-	return fmt.Sprintf("%s%s", urlStr, path)
+	return urlStr + c.getPath(path)
 }
 
 func queryString(opts interface{}) string {
@@ -950,6 +1357,9 @@ func addQueryStringValue(items url.Values, key string, v reflect.Value) {
 type Error struct {
 	Status  int
 	Message string
+
+	// metadata is the metadata of the response, see ErrorMetadata.
+	metadata *ResponseMetadata
 }
 
 func newError(resp *http.Response) *Error {
@@ -957,16 +1367,17 @@ func newError(resp *http.Response) *Error {
 		Message string `json:"message"`
 	}
 	defer resp.Body.Close()
+	metadata := newResponseMetadata(resp)
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return &Error{Status: resp.StatusCode, Message: fmt.Sprintf("cannot read body, err: %v", err)}
+		return &Error{Status: resp.StatusCode, Message: fmt.Sprintf("cannot read body, err: %v", err), metadata: metadata}
 	}
 	var emsg ErrMsg
 	err = json.Unmarshal(data, &emsg)
 	if err != nil {
-		return &Error{Status: resp.StatusCode, Message: string(data)}
+		return &Error{Status: resp.StatusCode, Message: string(data), metadata: metadata}
 	}
-	return &Error{Status: resp.StatusCode, Message: emsg.Message}
+	return &Error{Status: resp.StatusCode, Message: emsg.Message, metadata: metadata}
 }
 
 func (e *Error) Error() string {
@@ -1024,7 +1435,7 @@ func getDockerEnv() (*dockerEnv, error) {
 	dockerHost := os.Getenv("DOCKER_HOST")
 	var err error
 	if dockerHost == "" {
-		dockerHost = defaultHost
+		dockerHost = DiscoverDockerHost()
 	}
 	dockerTLSVerify := os.Getenv("DOCKER_TLS_VERIFY") != ""
 	var dockerCertPath string
@@ -1049,6 +1460,32 @@ func getDockerEnv() (*dockerEnv, error) {
 	}, nil
 }
 
+// DiscoverDockerHost returns the endpoint used by NewClientFromEnv when
+// DOCKER_HOST is not set. It probes the native endpoints of the platform and
+// returns the first one that exists. On Unix-like systems, the candidates are
+// /var/run/docker.sock, $XDG_RUNTIME_DIR/docker.sock (rootless daemons) and
+// ~/.docker/run/docker.sock (Docker Desktop); on Windows, it's the
+// docker_engine named pipe.
+//
+// When none of the candidates exist, DiscoverDockerHost returns the default
+// endpoint of the platform.
+func DiscoverDockerHost() string {
+	return discoverHost(nativeHostCandidates())
+}
+
+func discoverHost(candidates []string) string {
+	for _, host := range candidates {
+		u, err := url.Parse(host)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.FromSlash(u.Path)); err == nil {
+			return host
+		}
+	}
+	return defaultHost
+}
+
 // defaultTransport returns a new http.Transport with similar default values to
 // http.DefaultTransport, but with idle connections and keepalives disabled.
 func defaultTransport() *http.Transport {
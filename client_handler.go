@@ -0,0 +1,173 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// handlerEndpoint is the endpoint of the clients created by
+// NewClientFromHandler. The host is never resolved.
+const handlerEndpoint = "http://docker.in-process"
+
+var errHandlerListenerClosed = errors.New("in-process listener closed")
+
+// NewClientFromHandler returns a Client that sends its requests to the given
+// handler, e.g. the fake daemon of the testing/server package or a stub,
+// served in-process over in-memory connections instead of TCP or unix
+// sockets. Every call is supported, including the ones that hijack the
+// connection, like attach and exec sessions, whose end of the input is
+// signaled to the handler like over TCP.
+//
+// The server version check is skipped, like in NewClient. The handler is
+// served as long as the process runs, see NewClientFromHandlerWithContext
+// to stop serving it.
+func NewClientFromHandler(handler http.Handler) *Client {
+	return NewClientFromHandlerWithContext(handler, context.Background())
+}
+
+// NewClientFromHandlerWithContext returns a Client that sends its requests to
+// the given handler, like NewClientFromHandler, serving it until the context
+// is done. The connections in progress are then closed, and the calls made
+// afterwards fail.
+func NewClientFromHandlerWithContext(handler http.Handler, ctx context.Context) *Client {
+	listener := newHandlerListener()
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			server.Close()
+		}()
+	}
+	client, _ := NewClient(handlerEndpoint)
+	client.Dialer = listener
+	tr := defaultTransport()
+	tr.Proxy = nil
+	tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return listener.Dial(network, addr)
+	}
+	client.HTTPClient.Transport = tr
+	return client
+}
+
+// handlerListener is a net.Listener that accepts the connections created by
+// its Dial method, which implements the Dialer interface.
+type handlerListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newHandlerListener() *handlerListener {
+	return &handlerListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+// Dial returns the client side of a new in-memory connection, whose server
+// side is returned by Accept. The network and address are ignored.
+func (l *handlerListener) Dial(network, address string) (net.Conn, error) {
+	client, server := newHandlerConns()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.closed:
+		client.Close()
+		server.Close()
+		return nil, errHandlerListenerClosed
+	}
+}
+
+func (l *handlerListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errHandlerListenerClosed
+	}
+}
+
+func (l *handlerListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *handlerListener) Addr() net.Addr {
+	return handlerAddr{}
+}
+
+type handlerAddr struct{}
+
+func (handlerAddr) Network() string { return "pipe" }
+
+func (handlerAddr) String() string { return "docker.in-process" }
+
+// handlerConn is an end of an in-memory connection made of a pipe for each
+// direction, so that each end can close its writing side, signaling EOF to
+// the other end, like TCP connections.
+type handlerConn struct {
+	r net.Conn
+	w net.Conn
+}
+
+func newHandlerConns() (*handlerConn, *handlerConn) {
+	clientW, serverR := net.Pipe()
+	serverW, clientR := net.Pipe()
+	return &handlerConn{r: clientR, w: clientW}, &handlerConn{r: serverR, w: serverW}
+}
+
+func (c *handlerConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c *handlerConn) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
+
+// CloseWrite closes the writing side of the connection, the other end
+// reading EOF.
+func (c *handlerConn) CloseWrite() error {
+	return c.w.Close()
+}
+
+func (c *handlerConn) Close() error {
+	werr := c.w.Close()
+	if err := c.r.Close(); err != nil {
+		return err
+	}
+	return werr
+}
+
+func (c *handlerConn) LocalAddr() net.Addr {
+	return handlerAddr{}
+}
+
+func (c *handlerConn) RemoteAddr() net.Addr {
+	return handlerAddr{}
+}
+
+func (c *handlerConn) SetDeadline(t time.Time) error {
+	if err := c.r.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.w.SetWriteDeadline(t)
+}
+
+func (c *handlerConn) SetReadDeadline(t time.Time) error {
+	return c.r.SetReadDeadline(t)
+}
+
+func (c *handlerConn) SetWriteDeadline(t time.Time) error {
+	return c.w.SetWriteDeadline(t)
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClientFromHandler(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Id": "abc123", "Name": "/web"}`))
+	})
+	mux.HandleFunc("/containers/db/json", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such container", http.StatusNotFound)
+	})
+	mux.HandleFunc("/containers/web/logs", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(execFrame(1, "listening\n"))
+		w.Write(execFrame(2, "warning\n"))
+	})
+	client := NewClientFromHandler(mux)
+	if err := client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	container, err := client.InspectContainer("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.ID != "abc123" || container.Name != "/web" {
+		t.Errorf("InspectContainer: wrong container: %#v", container)
+	}
+	if _, err := client.InspectContainer("db"); err == nil {
+		t.Error("InspectContainer: unexpected <nil> error")
+	} else if _, ok := err.(*NoSuchContainer); !ok {
+		t.Errorf("InspectContainer: wrong error. Want *NoSuchContainer. Got %#v.", err)
+	}
+	var stdout, stderr bytes.Buffer
+	err = client.Logs(LogsOptions{
+		Container:    "web",
+		OutputStream: &stdout,
+		ErrorStream:  &stderr,
+		Stdout:       true,
+		Stderr:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "listening\n" || stderr.String() != "warning\n" {
+		t.Errorf("Logs: wrong output. Got stdout %q and stderr %q.", stdout.String(), stderr.String())
+	}
+}
+
+func TestNewClientFromHandlerHijack(t *testing.T) {
+	t.Parallel()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/exec/exec-id/start" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		ioutil.ReadAll(r.Body)
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n"))
+		line, _ := bufio.NewReader(rw).ReadString('\n')
+		conn.Write([]byte("$ " + line))
+	})
+	client := NewClientFromHandler(handler)
+	var stdout bytes.Buffer
+	err := client.StartExec("exec-id", StartExecOptions{
+		InputStream:  strings.NewReader("whoami\n"),
+		OutputStream: &stdout,
+		RawTerminal:  true,
+		Tty:          true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "$ whoami\n" {
+		t.Errorf("StartExec: wrong output. Want %q. Got %q.", "$ whoami\n", stdout.String())
+	}
+}
+
+func TestNewClientFromHandlerStdinEOF(t *testing.T) {
+	t.Parallel()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n"))
+		// the handler only answers once the input is over.
+		input, _ := ioutil.ReadAll(rw)
+		conn.Write([]byte(strings.ToUpper(string(input))))
+	})
+	client := NewClientFromHandler(handler)
+	var stdout bytes.Buffer
+	err := client.StartExec("exec-id", StartExecOptions{
+		InputStream:  strings.NewReader("hello\nworld\n"),
+		OutputStream: &stdout,
+		RawTerminal:  true,
+		Tty:          true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "HELLO\nWORLD\n"; stdout.String() != expected {
+		t.Errorf("StartExec: wrong output. Want %q. Got %q.", expected, stdout.String())
+	}
+}
+
+func TestNewClientFromHandlerWithContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewClientFromHandlerWithContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}), ctx)
+	if err := client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for client.Ping() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Ping: want the handler no longer served after the context is done")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandlerListenerClose(t *testing.T) {
+	t.Parallel()
+	listener := newHandlerListener()
+	listener.Close()
+	if _, err := listener.Dial("tcp", "docker.in-process"); err != errHandlerListenerClosed {
+		t.Errorf("Dial: wrong error. Want %#v. Got %#v.", errHandlerListenerClosed, err)
+	}
+	if _, err := listener.Accept(); err != errHandlerListenerClosed {
+		t.Errorf("Accept: wrong error. Want %#v. Got %#v.", errHandlerListenerClosed, err)
+	}
+}
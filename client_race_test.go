@@ -0,0 +1,182 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newRaceTestServer returns a server implementing the endpoints exercised by
+// TestClientConcurrentStreams, counting the requests by the value of their
+// X-Clone header. Pulls are not counted, as concurrent pulls of the same
+// image are deduplicated, nor are events, as the event monitor of a client
+// shares a single stream among its listeners.
+func newRaceTestServer(t *testing.T, counts map[string]int, mu *sync.Mutex, stop <-chan struct{}) *httptest.Server {
+	hijack := func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n"))
+		conn.Write(execFrame(1, "output\n"))
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" && r.URL.Path != "/images/create" && r.URL.Path != "/events" {
+			mu.Lock()
+			counts[r.Header.Get("X-Clone")]++
+			mu.Unlock()
+		}
+		switch r.URL.Path {
+		case "/version":
+			w.Write([]byte(`{"ApiVersion": "1.39"}`))
+		case "/containers/json":
+			w.Write([]byte(`[{"Id": "c1"}]`))
+		case "/containers/c1/logs", "/containers/c1/export":
+			w.Write(execFrame(1, "line\n"))
+		case "/containers/c1/stats":
+			w.Write([]byte(`{"read": "2019-08-01T00:00:00Z", "memory_stats": {"usage": 1024}}`))
+		case "/images/create":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status": "Pulling from library/busybox"}` + "\n" + `{"status": "Download complete"}`))
+		case "/events":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"Type": "container", "Action": "start", "Actor": {"ID": "c1"}, "time": 1564617600}` + "\n"))
+			w.(http.Flusher).Flush()
+			// the event monitor doesn't close its connection once its
+			// listeners are removed.
+			select {
+			case <-r.Context().Done():
+			case <-stop:
+			}
+		case "/containers/c1/exec":
+			w.Write([]byte(`{"Id": "e1"}`))
+		case "/containers/c1/attach", "/exec/e1/start":
+			hijack(w, r)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+}
+
+// TestClientConcurrentStreams exercises the streaming endpoints of clients
+// sharing the same connections concurrently, to be run with the race
+// detector.
+func TestClientConcurrentStreams(t *testing.T) {
+	t.Parallel()
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	stop := make(chan struct{})
+	server := newRaceTestServer(t, counts, &mu, stop)
+	defer server.Close()
+	defer close(stop)
+	// the version of the server is checked by the first calls, concurrently.
+	client, err := NewVersionedClient(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := []func(c *Client) error{
+		func(c *Client) error {
+			_, err := c.ListContainers(ListContainersOptions{})
+			return err
+		},
+		func(c *Client) error {
+			var stdout bytes.Buffer
+			return c.Logs(LogsOptions{Container: "c1", Stdout: true, OutputStream: &stdout})
+		},
+		func(c *Client) error {
+			return c.ExportContainer(ExportContainerOptions{ID: "c1", OutputStream: ioutil.Discard})
+		},
+		func(c *Client) error {
+			stats := make(chan *Stats)
+			errC := make(chan error, 1)
+			go func() {
+				errC <- c.Stats(StatsOptions{ID: "c1", Stats: stats, Stream: false})
+			}()
+			for range stats {
+			}
+			return <-errC
+		},
+		func(c *Client) error {
+			var output bytes.Buffer
+			return c.PullImage(PullImageOptions{Repository: "busybox", Tag: "latest", OutputStream: &output}, AuthConfiguration{})
+		},
+		func(c *Client) error {
+			var stdout bytes.Buffer
+			return c.AttachToContainer(AttachToContainerOptions{Container: "c1", OutputStream: &stdout, Stdout: true, Stream: true})
+		},
+		func(c *Client) error {
+			exec, err := c.CreateExec(CreateExecOptions{Container: "c1", Cmd: []string{"true"}, AttachStdout: true})
+			if err != nil {
+				return err
+			}
+			var stdout bytes.Buffer
+			return c.StartExec(exec.ID, StartExecOptions{OutputStream: &stdout, Context: context.Background()})
+		},
+		func(c *Client) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events := make(chan *APIEvents)
+			errC := make(chan error, 1)
+			go func() {
+				errC <- c.NewStreamSupervisor().Events(ctx, EventsOptions{}, events)
+			}()
+			<-events
+			cancel()
+			if err := <-errC; err != context.Canceled {
+				return err
+			}
+			return nil
+		},
+		func(c *Client) error {
+			listener := make(chan *APIEvents, 10)
+			if err := c.AddEventListener(listener); err != nil {
+				return err
+			}
+			<-listener
+			return c.RemoveEventListener(listener)
+		},
+	}
+	const clones, iterations = 3, 5
+	var wg sync.WaitGroup
+	for i := 0; i < clones; i++ {
+		clone := client.Clone()
+		clone.Headers = map[string]string{"X-Clone": strings.Repeat("c", i+1)}
+		for _, call := range calls {
+			for j := 0; j < iterations; j++ {
+				wg.Add(1)
+				go func(call func(*Client) error) {
+					defer wg.Done()
+					if err := call(clone); err != nil {
+						t.Error(err)
+					}
+				}(call)
+			}
+		}
+	}
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	// creating and starting an exec are two requests, and pulls and the
+	// two event calls aren't counted.
+	expected := (len(calls) - 2) * iterations
+	for i := 0; i < clones; i++ {
+		if got := counts[strings.Repeat("c", i+1)]; got != expected {
+			t.Errorf("clone %d: wrong number of requests. Want %d. Got %d.", i, expected, got)
+		}
+	}
+	if len(counts) != clones {
+		t.Errorf("unexpected requests without the headers of the clones: %v", counts)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseTooLargeError is the error returned when the body of a JSON
+// response of the daemon exceeds Client.MaxResponseSize.
+type ResponseTooLargeError struct {
+	Method string
+	Path   string
+	Limit  int64
+}
+
+func (err *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response of %s %s exceeds the limit of %d bytes", err.Method, err.Path, err.Limit)
+}
+
+// limitedBody is the body of a response failing with a
+// *ResponseTooLargeError once more than the limit is read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       *ResponseTooLargeError
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	// read one byte past the limit, to tell bodies of exactly the limit
+	// from larger ones.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), b.err
+	}
+	return n, err
+}
+
+// gzipBody is the body of a response compressed with gzip, decompressed.
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil {
+		zr, err := gzip.NewReader(b.body)
+		if err != nil {
+			return 0, err
+		}
+		b.zr = zr
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
+
+// decompressResponse makes the body of the response decompressed when the
+// client has DecompressResponses set and the body is compressed with gzip,
+// e.g. by a proxy. The HTTP client only decompresses the responses it asked
+// to be compressed.
+func (c *Client) decompressResponse(resp *http.Response) {
+	if !c.DecompressResponses || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+	resp.Body = &gzipBody{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// limitResponse limits the size of the body of JSON responses to
+// c.MaxResponseSize. Streaming calls, such as /events, are left unlimited, as
+// their bodies grow for as long as they're read.
+func (c *Client) limitResponse(method, path string, resp *http.Response) {
+	if c.MaxResponseSize <= 0 || classifyRequest(method, path) == requestStreaming {
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return
+	}
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		remaining:  c.MaxResponseSize,
+		err:        &ResponseTooLargeError{Method: method, Path: path, Limit: c.MaxResponseSize},
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// newGzipClient returns a client of a server compressing its responses
+// regardless of the Accept-Encoding header, like some proxies do.
+func newGzipClient(contentType, body string) *Client {
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(body))
+		zw.Close()
+	}))
+	// keep the transport from asking for compressed responses and
+	// decompressing them.
+	client.HTTPClient.Transport.(*http.Transport).DisableCompression = true
+	return client
+}
+
+func TestDecompressResponses(t *testing.T) {
+	t.Parallel()
+	client := newGzipClient("application/json", `[{"Id":"abc","Name":"bridge"}]`)
+	if _, err := client.ListNetworks(); err == nil {
+		t.Fatal("ListNetworks: want a non-nil error decoding a compressed response")
+	}
+	client.DecompressResponses = true
+	networks, err := client.ListNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 1 || networks[0].ID != "abc" {
+		t.Errorf("ListNetworks: wrong networks: %#v", networks)
+	}
+}
+
+func TestDecompressResponsesStream(t *testing.T) {
+	t.Parallel()
+	client := newGzipClient("application/json", `{"status":"Pushed"}`+"\r\n")
+	client.DecompressResponses = true
+	var buf bytes.Buffer
+	err := client.PushImage(PushImageOptions{Name: "app", OutputStream: &buf, RawJSONStream: true}, AuthConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"Pushed"`) {
+		t.Errorf("PushImage: wrong output: %q", buf.String())
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	t.Parallel()
+	body := `[{"Id":"abc","Name":"bridge"}]`
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	client.MaxResponseSize = int64(len(body))
+	if _, err := client.ListNetworks(); err != nil {
+		t.Fatalf("ListNetworks: unexpected error for a response of exactly the limit: %v", err)
+	}
+	client.MaxResponseSize = 10
+	_, err := client.ListNetworks()
+	tooLarge, ok := err.(*ResponseTooLargeError)
+	if !ok {
+		t.Fatalf("ListNetworks: wrong error. Want *ResponseTooLargeError. Got %#v.", err)
+	}
+	expected := ResponseTooLargeError{Method: "GET", Path: "/networks", Limit: 10}
+	if *tooLarge != expected {
+		t.Errorf("ListNetworks: wrong error. Want %#v. Got %#v.", expected, *tooLarge)
+	}
+}
+
+func TestMaxResponseSizeIgnoresNonJSON(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Write(bytes.Repeat([]byte("x"), 100))
+	}))
+	client.MaxResponseSize = 10
+	resp, err := client.do("GET", "/images/app/get", doOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil || len(data) != 100 {
+		t.Errorf("do: want the whole body read. Got %d bytes and %v.", len(data), err)
+	}
+}
+
+func TestMaxResponseSizeIgnoresStreams(t *testing.T) {
+	t.Parallel()
+	event := `{"Action":"start","Type":"container","Actor":{"ID":"abc"}}` + "\n"
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(strings.Repeat(event, 10)))
+	}))
+	client.MaxResponseSize = int64(len(event))
+	resp, err := client.do("GET", "/events?since=1", doOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil || len(data) != 10*len(event) {
+		t.Errorf("do: want the whole stream read. Got %d bytes and %v.", len(data), err)
+	}
+}
+
+func TestLimitedBody(t *testing.T) {
+	t.Parallel()
+	tooLarge := &ResponseTooLargeError{Limit: 4}
+	body := &limitedBody{ReadCloser: ioutil.NopCloser(strings.NewReader("abcdef")), remaining: 4, err: tooLarge}
+	data, err := ioutil.ReadAll(body)
+	if err != tooLarge || string(data) != "abcd" {
+		t.Errorf("limitedBody: want %q and %#v. Got %q and %#v.", "abcd", tooLarge, data, err)
+	}
+}
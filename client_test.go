@@ -7,9 +7,12 @@ package docker
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -18,6 +21,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -342,6 +346,166 @@ func TestGetFakeNativeURL(t *testing.T) {
 	}
 }
 
+func TestGetURLBasePath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		endpoint   string
+		apiVersion string
+		basePath   string
+		path       string
+		expected   string
+	}{
+		{"http://localhost:4243", "", "/dockerapi", "/containers/ps", "http://localhost:4243/dockerapi/containers/ps"},
+		{"http://localhost:4243", "", "dockerapi/", "/containers/ps", "http://localhost:4243/dockerapi/containers/ps"},
+		{"http://localhost:4243", "", "/v1.41", "/containers/ps", "http://localhost:4243/v1.41/containers/ps"},
+		{"http://localhost:4243", "1.40", "/dockerapi", "/containers/ps", "http://localhost:4243/dockerapi/v1.40/containers/ps"},
+		{"http://localhost:4243", "1.40", "", "/containers/ps", "http://localhost:4243/v1.40/containers/ps"},
+		{nativeRealEndpoint, "", "/dockerapi", "/containers", "/dockerapi/containers"},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.endpoint+test.basePath+test.path, func(t *testing.T) {
+			t.Parallel()
+			client, err := NewVersionedClient(test.endpoint, test.apiVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.SetBasePath(test.basePath)
+			if got := client.getURL(test.path); got != test.expected {
+				t.Errorf("getURL(%q): Got %s. Want %s.", test.path, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetFakeNativeURLBasePath(t *testing.T) {
+	t.Parallel()
+	client, err := NewClient(nativeRealEndpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetBasePath("/dockerapi")
+	expected := "http://unix.sock/dockerapi/containers/ps"
+	if got := client.getFakeNativeURL("/containers/ps"); got != expected {
+		t.Errorf("getFakeNativeURL: Got %s. Want %s.", got, expected)
+	}
+}
+
+func TestClientBasePathRequests(t *testing.T) {
+	t.Parallel()
+	paths := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+		if strings.HasSuffix(r.URL.Path, "/attach") {
+			stdinEchoHandler(w, r)
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetBasePath("/dockerapi")
+	if err = client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = attachStdinEcho(client); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"/dockerapi/_ping", "/dockerapi/containers/a123456/attach"} {
+		if got := <-paths; got != expected {
+			t.Errorf("wrong request path. Want %q. Got %q.", expected, got)
+		}
+	}
+}
+
+func TestClientClone(t *testing.T) {
+	t.Parallel()
+	type request struct {
+		path, auth, agent string
+	}
+	requests := make(chan request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- request{r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("User-Agent")}
+		if strings.HasSuffix(r.URL.Path, "/attach") {
+			stdinEchoHandler(w, r)
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Headers = map[string]string{"Authorization": "Bearer parent", "User-Agent": "custom-agent"}
+	clone := client.Clone()
+	if clone.HTTPClient.Transport != client.HTTPClient.Transport || clone.Dialer != client.Dialer {
+		t.Error("Clone: the clone doesn't share the HTTP transport and dialer")
+	}
+	if clone.SetTimeout(time.Minute); client.HTTPClient.Timeout != 0 {
+		t.Errorf("Clone: SetTimeout on the clone changed the timeout of the original to %s", client.HTTPClient.Timeout)
+	}
+	if clone.eventMonitor == client.eventMonitor || clone.pulls == client.pulls {
+		t.Error("Clone: the clone shares the event listeners or pulls")
+	}
+	clone.Headers["Authorization"] = "Bearer clone"
+	if err = clone.SetAPIVersion("1.39"); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if err = clone.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = attachStdinEcho(clone); err != nil {
+		t.Fatal(err)
+	}
+	expected := []request{
+		{"/_ping", "Bearer parent", userAgent},
+		{"/v1.39/_ping", "Bearer clone", userAgent},
+		{"/v1.39/containers/a123456/attach", "Bearer clone", "custom-agent"},
+	}
+	for _, want := range expected {
+		if got := <-requests; got != want {
+			t.Errorf("Clone: wrong request. Want %#v. Got %#v.", want, got)
+		}
+	}
+}
+
+func TestClientSetAPIVersion(t *testing.T) {
+	t.Parallel()
+	client, err := NewClient("http://localhost:4243")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.serverAPIVersion = apiVersion143
+	if err = client.SetAPIVersion("1.39"); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.getURL("/info"); got != "http://localhost:4243/v1.39/info" {
+		t.Errorf("SetAPIVersion: wrong URL. Want %q. Got %q.", "http://localhost:4243/v1.39/info", got)
+	}
+	if !reflect.DeepEqual(client.getExpectedAPIVersion(), apiVersion139) {
+		t.Errorf("SetAPIVersion: wrong expected version. Want %s. Got %s.", apiVersion139, client.getExpectedAPIVersion())
+	}
+	if err = client.SetAPIVersion(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.getURL("/info"); got != "http://localhost:4243/info" {
+		t.Errorf("SetAPIVersion: wrong URL. Want %q. Got %q.", "http://localhost:4243/info", got)
+	}
+	if !reflect.DeepEqual(client.getExpectedAPIVersion(), apiVersion143) {
+		t.Errorf("SetAPIVersion: wrong expected version. Want %s. Got %s.", apiVersion143, client.getExpectedAPIVersion())
+	}
+	if err = client.SetAPIVersion("latest"); err == nil {
+		t.Error("SetAPIVersion: expected error, got <nil>")
+	}
+}
+
 func TestError(t *testing.T) {
 	t.Parallel()
 	fakeBody := ioutil.NopCloser(bytes.NewBufferString("bad parameter"))
@@ -867,6 +1031,465 @@ func TestClientStreamJSONDecoderFailingOutputWriter(t *testing.T) {
 	}
 }
 
+func TestClientHijackErrorBody(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		status  int
+		message string
+	}{
+		{http.StatusBadRequest, "bad parameter"},
+		{http.StatusNotFound, "No such container: a123456"},
+		{http.StatusConflict, "Container a123456 is paused, unpause the container before exec"},
+		{http.StatusInternalServerError, "oci runtime error"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(strconv.Itoa(test.status), func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.status)
+				fmt.Fprintf(w, `{"message":%q}`, test.message)
+			}))
+			defer srv.Close()
+			client, err := NewClient(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			calls := map[string]func() error{
+				"AttachToContainer": func() error {
+					return client.AttachToContainer(AttachToContainerOptions{
+						Container:    "a123456",
+						OutputStream: &stdout,
+						ErrorStream:  &stderr,
+						Stdout:       true,
+						Stderr:       true,
+						Stream:       true,
+					})
+				},
+				"StartExec": func() error {
+					return client.StartExec("e123456", StartExecOptions{
+						OutputStream: &stdout,
+						ErrorStream:  &stderr,
+					})
+				},
+			}
+			for name, call := range calls {
+				err := call()
+				e, ok := err.(*Error)
+				if !ok {
+					t.Errorf("%s: wrong error type. Want *Error. Got %#v.", name, err)
+					continue
+				}
+				if e.Status != test.status || e.Message != test.message {
+					t.Errorf("%s: wrong error. Want %d %q. Got %d %q.", name, test.status, test.message, e.Status, e.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestClientHijackErrorBodyWithSentinel(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "container not running", http.StatusConflict)
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	success := make(chan struct{})
+	cw, err := client.AttachToContainerNonBlocking(AttachToContainerOptions{
+		Container:    "a123456",
+		OutputStream: ioutil.Discard,
+		Stdout:       true,
+		Success:      success,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the sentinel is sent even for failed requests, so callers blocked on
+	// it get to the error.
+	select {
+	case v := <-success:
+		success <- v
+	case <-time.After(5 * time.Second):
+		t.Fatal("AttachToContainerNonBlocking: no sentinel for the failed request")
+	}
+	err = cw.Wait()
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusConflict || e.Message != "container not running\n" {
+		t.Errorf("AttachToContainerNonBlocking: wrong error. Got %#v.", err)
+	}
+}
+
+// endlessHijackServer upgrades the connection and then streams output until
+// the client closes the connection, reporting it on closed.
+func endlessHijackServer(closed chan<- struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n"))
+		for {
+			if _, err := conn.Write([]byte("output\n")); err != nil {
+				close(closed)
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}))
+}
+
+func TestClientHijackContextCancel(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		run  func(client *Client, ctx context.Context) (CloseWaiter, error)
+	}{
+		{
+			name: "AttachToContainer",
+			run: func(client *Client, ctx context.Context) (CloseWaiter, error) {
+				return client.AttachToContainerNonBlocking(AttachToContainerOptions{
+					Container:    "a123456",
+					InputStream:  &bytes.Buffer{},
+					OutputStream: ioutil.Discard,
+					Stdin:        true,
+					Stdout:       true,
+					Stream:       true,
+					RawTerminal:  true,
+					Context:      ctx,
+				})
+			},
+		},
+		{
+			name: "StartExec",
+			run: func(client *Client, ctx context.Context) (CloseWaiter, error) {
+				return client.StartExecNonBlocking("e123456", StartExecOptions{
+					OutputStream: ioutil.Discard,
+					RawTerminal:  true,
+					Context:      ctx,
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			closed := make(chan struct{})
+			srv := endlessHijackServer(closed)
+			defer srv.Close()
+			client, err := NewClient(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			cw, err := tt.run(client, ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errCh := make(chan error, 1)
+			go func() { errCh <- cw.Wait() }()
+			select {
+			case err = <-errCh:
+				t.Fatalf("%s: returned before cancellation: %v", tt.name, err)
+			case <-time.After(50 * time.Millisecond):
+			}
+			cancel()
+			select {
+			case err = <-errCh:
+				if err != context.Canceled {
+					t.Errorf("%s: wrong error. Want %#v. Got %#v.", tt.name, context.Canceled, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: did not return after cancellation", tt.name)
+			}
+			select {
+			case <-closed:
+			case <-time.After(5 * time.Second):
+				t.Errorf("%s: connection was not closed", tt.name)
+			}
+		})
+	}
+}
+
+func TestClientHijackContextCancelledBeforeCall(t *testing.T) {
+	t.Parallel()
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = client.AttachToContainer(AttachToContainerOptions{
+		Container:    "a123456",
+		OutputStream: ioutil.Discard,
+		Stdout:       true,
+		Context:      ctx,
+	})
+	if err != context.Canceled {
+		t.Errorf("AttachToContainer: wrong error. Want %#v. Got %#v.", context.Canceled, err)
+	}
+	if called {
+		t.Error("AttachToContainer: unexpected request with a cancelled context")
+	}
+}
+
+func TestClientHijackContextCancelWaitingSentinel(t *testing.T) {
+	t.Parallel()
+	closed := make(chan struct{})
+	srv := endlessHijackServer(closed)
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	success := make(chan struct{})
+	cw, err := client.AttachToContainerNonBlocking(AttachToContainerOptions{
+		Container:    "a123456",
+		OutputStream: ioutil.Discard,
+		Stdout:       true,
+		Success:      success,
+		Context:      ctx,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-success
+	cancel()
+	if err = cw.Wait(); err != context.Canceled {
+		t.Errorf("AttachToContainerNonBlocking: wrong error. Want %#v. Got %#v.", context.Canceled, err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("AttachToContainerNonBlocking: connection was not closed")
+	}
+}
+
+// stdinEchoHandler upgrades the connection, reads the stdin of the attach
+// session until EOF and then writes it back.
+func stdinEchoHandler(w http.ResponseWriter, r *http.Request) {
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n"))
+	data, err := ioutil.ReadAll(rw)
+	if err != nil {
+		return
+	}
+	conn.Write(data)
+}
+
+func attachStdinEcho(client *Client) (string, error) {
+	var stdout bytes.Buffer
+	cw, err := client.AttachToContainerNonBlocking(AttachToContainerOptions{
+		Container:    "a123456",
+		InputStream:  strings.NewReader("hello"),
+		OutputStream: &stdout,
+		Stdin:        true,
+		Stdout:       true,
+		Stream:       true,
+		RawTerminal:  true,
+	})
+	if err != nil {
+		return "", err
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- cw.Wait() }()
+	select {
+	case err = <-errCh:
+	case <-time.After(5 * time.Second):
+		cw.Close()
+		return "", errors.New("attach session didn't end after EOF on stdin")
+	}
+	return stdout.String(), err
+}
+
+func TestClientHijackStdinEOFNativeClient(t *testing.T) {
+	t.Parallel()
+	srv, cleanup, err := newNativeServer(http.HandlerFunc(stdinEchoHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	srv.Start()
+	defer srv.Close()
+	client, err := NewClient(nativeProtocol + "://" + srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := attachStdinEcho(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "hello" {
+		t.Errorf("AttachToContainer: wrong output. Want %q. Got %q.", "hello", output)
+	}
+}
+
+func TestClientHijackStdinEOFTLS(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewTLSServer(http.HandlerFunc(stdinEchoHandler))
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	output, err := attachStdinEcho(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "hello" {
+		t.Errorf("AttachToContainer: wrong output. Want %q. Got %q.", "hello", output)
+	}
+}
+
+// plainConn hides the CloseWrite method of the wrapped connection.
+type plainConn struct {
+	net.Conn
+}
+
+type plainConnDialer struct{}
+
+func (plainConnDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return plainConn{conn}, nil
+}
+
+func TestClientHijackStdinEOFWithoutCloseWrite(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\nhello"))
+		conn.Close()
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Dialer = plainConnDialer{}
+	output, err := attachStdinEcho(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "hello" {
+		t.Errorf("AttachToContainer: wrong output. Want %q. Got %q.", "hello", output)
+	}
+}
+
+// keepAliveConn records the keep-alive settings applied to the connection.
+type keepAliveConn struct {
+	net.Conn
+	mu        sync.Mutex
+	keepAlive bool
+	period    time.Duration
+}
+
+func (c *keepAliveConn) SetKeepAlive(keepAlive bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keepAlive = keepAlive
+	return nil
+}
+
+func (c *keepAliveConn) SetKeepAlivePeriod(period time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.period = period
+	return nil
+}
+
+func (c *keepAliveConn) CloseWrite() error {
+	return closeWrite(c.Conn)
+}
+
+func (c *keepAliveConn) settings() (bool, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keepAlive, c.period
+}
+
+type keepAliveDialer struct {
+	conns chan *keepAliveConn
+}
+
+func (d keepAliveDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	kac := &keepAliveConn{Conn: conn}
+	d.conns <- kac
+	return kac, nil
+}
+
+func TestClientHijackKeepAlive(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		keepAlive     time.Duration
+		wantKeepAlive bool
+	}{
+		{keepAlive: 10 * time.Second, wantKeepAlive: true},
+		{keepAlive: 0, wantKeepAlive: false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(stdinEchoHandler))
+		client, err := NewClient(srv.URL)
+		if err != nil {
+			srv.Close()
+			t.Fatal(err)
+		}
+		dialer := keepAliveDialer{conns: make(chan *keepAliveConn, 1)}
+		client.Dialer = dialer
+		client.KeepAlive = tt.keepAlive
+		if _, err = attachStdinEcho(client); err != nil {
+			srv.Close()
+			t.Fatal(err)
+		}
+		srv.Close()
+		keepAlive, period := (<-dialer.conns).settings()
+		if keepAlive != tt.wantKeepAlive {
+			t.Errorf("KeepAlive %s: wrong keep-alive. Want %v. Got %v.", tt.keepAlive, tt.wantKeepAlive, keepAlive)
+		}
+		if tt.wantKeepAlive && period != tt.keepAlive {
+			t.Errorf("KeepAlive %s: wrong period. Want %s. Got %s.", tt.keepAlive, tt.keepAlive, period)
+		}
+	}
+}
+
+func TestClientEnableKeepAliveTLS(t *testing.T) {
+	t.Parallel()
+	raw := &keepAliveConn{}
+	client := Client{KeepAlive: time.Minute}
+	client.enableKeepAlive(&tlsClientCon{rawConn: raw})
+	if keepAlive, period := raw.settings(); !keepAlive || period != time.Minute {
+		t.Errorf("enableKeepAlive: wrong settings on the raw connection. Want true and %s. Got %v and %s.", time.Minute, keepAlive, period)
+	}
+}
+
 type eofWriter struct{}
 
 func (w eofWriter) Write(b []byte) (int, error) { return len(b), io.EOF }
@@ -10,10 +10,28 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/pkg/homedir"
 )
 
 const defaultHost = "unix:///var/run/docker.sock"
 
+// nativeHostCandidates returns the endpoints probed by DiscoverDockerHost, in
+// order of preference: the system-wide socket, the socket of a rootless daemon
+// and the socket used by Docker Desktop.
+func nativeHostCandidates() []string {
+	hosts := []string{defaultHost}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		hosts = append(hosts, unixProtocol+"://"+filepath.Join(dir, "docker.sock"))
+	}
+	if home := homedir.Get(); home != "" {
+		hosts = append(hosts, unixProtocol+"://"+filepath.Join(home, ".docker", "run", "docker.sock"))
+	}
+	return hosts
+}
+
 // initializeNativeClient initializes the native Unix domain socket client on
 // Unix-style operating systems
 func (c *Client) initializeNativeClient(trFunc func() *http.Transport) {
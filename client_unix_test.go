@@ -53,6 +53,42 @@ func TestNewTSLAPIClientUnixEndpoint(t *testing.T) {
 	}
 }
 
+func TestNativeHostCandidates(t *testing.T) {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	defer os.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	os.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	candidates := nativeHostCandidates()
+	if len(candidates) < 2 {
+		t.Fatalf("nativeHostCandidates: expected at least 2 candidates, got %#v", candidates)
+	}
+	if candidates[0] != defaultHost {
+		t.Errorf("nativeHostCandidates: wrong first candidate. Want %q. Got %q", defaultHost, candidates[0])
+	}
+	if expected := "unix:///run/user/1000/docker.sock"; candidates[1] != expected {
+		t.Errorf("nativeHostCandidates: wrong rootless candidate. Want %q. Got %q", expected, candidates[1])
+	}
+}
+
+func TestDiscoverHost(t *testing.T) {
+	t.Parallel()
+	tmpdir, err := ioutil.TempDir("", "runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "docker.sock")
+	if err := ioutil.WriteFile(sock, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	candidates := []string{"unix://" + filepath.Join(tmpdir, "missing.sock"), "unix://" + sock}
+	if host := discoverHost(candidates); host != "unix://"+sock {
+		t.Errorf("discoverHost: wrong host. Want %q. Got %q", "unix://"+sock, host)
+	}
+	if host := discoverHost(candidates[:1]); host != defaultHost {
+		t.Errorf("discoverHost: wrong host. Want %q. Got %q", defaultHost, host)
+	}
+}
+
 func newNativeServer(handler http.Handler) (*httptest.Server, func(), error) {
 	tmpdir, err := ioutil.TempDir("", "socket")
 	if err != nil {
@@ -18,6 +18,11 @@ const (
 	namedPipeConnectTimeout = 2 * time.Second
 )
 
+// nativeHostCandidates returns the endpoints probed by DiscoverDockerHost.
+func nativeHostCandidates() []string {
+	return []string{defaultHost}
+}
+
 type pipeDialer struct {
 	dialFunc func(network, addr string) (net.Conn, error)
 }
@@ -12,6 +12,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -61,6 +63,7 @@ type APIMount struct {
 type APIContainers struct {
 	ID         string            `json:"Id" yaml:"Id" toml:"Id"`
 	Image      string            `json:"Image,omitempty" yaml:"Image,omitempty" toml:"Image,omitempty"`
+	ImageID    string            `json:"ImageID,omitempty" yaml:"ImageID,omitempty" toml:"ImageID,omitempty"`
 	Command    string            `json:"Command,omitempty" yaml:"Command,omitempty" toml:"Command,omitempty"`
 	Created    int64             `json:"Created,omitempty" yaml:"Created,omitempty" toml:"Created,omitempty"`
 	State      string            `json:"State,omitempty" yaml:"State,omitempty" toml:"State,omitempty"`
@@ -91,7 +94,7 @@ func (c *Client) ListContainers(opts ListContainersOptions) ([]APIContainers, er
 	}
 	defer resp.Body.Close()
 	var containers []APIContainers
-	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+	if err := decodeJSONList(resp.Body, &containers); err != nil {
 		return nil, err
 	}
 	return containers, nil
@@ -215,16 +218,18 @@ type PortMapping map[string]string
 
 // ContainerNetwork represents the networking settings of a container per network.
 type ContainerNetwork struct {
-	Aliases             []string `json:"Aliases,omitempty" yaml:"Aliases,omitempty" toml:"Aliases,omitempty"`
-	MacAddress          string   `json:"MacAddress,omitempty" yaml:"MacAddress,omitempty" toml:"MacAddress,omitempty"`
-	GlobalIPv6PrefixLen int      `json:"GlobalIPv6PrefixLen,omitempty" yaml:"GlobalIPv6PrefixLen,omitempty" toml:"GlobalIPv6PrefixLen,omitempty"`
-	GlobalIPv6Address   string   `json:"GlobalIPv6Address,omitempty" yaml:"GlobalIPv6Address,omitempty" toml:"GlobalIPv6Address,omitempty"`
-	IPv6Gateway         string   `json:"IPv6Gateway,omitempty" yaml:"IPv6Gateway,omitempty" toml:"IPv6Gateway,omitempty"`
-	IPPrefixLen         int      `json:"IPPrefixLen,omitempty" yaml:"IPPrefixLen,omitempty" toml:"IPPrefixLen,omitempty"`
-	IPAddress           string   `json:"IPAddress,omitempty" yaml:"IPAddress,omitempty" toml:"IPAddress,omitempty"`
-	Gateway             string   `json:"Gateway,omitempty" yaml:"Gateway,omitempty" toml:"Gateway,omitempty"`
-	EndpointID          string   `json:"EndpointID,omitempty" yaml:"EndpointID,omitempty" toml:"EndpointID,omitempty"`
-	NetworkID           string   `json:"NetworkID,omitempty" yaml:"NetworkID,omitempty" toml:"NetworkID,omitempty"`
+	IPAMConfig          *EndpointIPAMConfig `json:"IPAMConfig,omitempty" yaml:"IPAMConfig,omitempty" toml:"IPAMConfig,omitempty"`
+	Links               []string            `json:"Links,omitempty" yaml:"Links,omitempty" toml:"Links,omitempty"`
+	Aliases             []string            `json:"Aliases,omitempty" yaml:"Aliases,omitempty" toml:"Aliases,omitempty"`
+	MacAddress          string              `json:"MacAddress,omitempty" yaml:"MacAddress,omitempty" toml:"MacAddress,omitempty"`
+	GlobalIPv6PrefixLen int                 `json:"GlobalIPv6PrefixLen,omitempty" yaml:"GlobalIPv6PrefixLen,omitempty" toml:"GlobalIPv6PrefixLen,omitempty"`
+	GlobalIPv6Address   string              `json:"GlobalIPv6Address,omitempty" yaml:"GlobalIPv6Address,omitempty" toml:"GlobalIPv6Address,omitempty"`
+	IPv6Gateway         string              `json:"IPv6Gateway,omitempty" yaml:"IPv6Gateway,omitempty" toml:"IPv6Gateway,omitempty"`
+	IPPrefixLen         int                 `json:"IPPrefixLen,omitempty" yaml:"IPPrefixLen,omitempty" toml:"IPPrefixLen,omitempty"`
+	IPAddress           string              `json:"IPAddress,omitempty" yaml:"IPAddress,omitempty" toml:"IPAddress,omitempty"`
+	Gateway             string              `json:"Gateway,omitempty" yaml:"Gateway,omitempty" toml:"Gateway,omitempty"`
+	EndpointID          string              `json:"EndpointID,omitempty" yaml:"EndpointID,omitempty" toml:"EndpointID,omitempty"`
+	NetworkID           string              `json:"NetworkID,omitempty" yaml:"NetworkID,omitempty" toml:"NetworkID,omitempty"`
 }
 
 // NetworkSettings contains network-related information about a container
@@ -287,6 +292,9 @@ func parsePort(rawPort string) (int, error) {
 // Config is the list of configuration options used when creating a container.
 // Config does not contain the options that are specific to starting a container on a
 // given host.  Those are contained in HostConfig
+//
+// Fields added after the version 1.19 of the API declare the first version
+// supporting them with a docker:"min=..." tag.
 type Config struct {
 	Hostname          string              `json:"Hostname,omitempty" yaml:"Hostname,omitempty" toml:"Hostname,omitempty"`
 	Domainname        string              `json:"Domainname,omitempty" yaml:"Domainname,omitempty" toml:"Domainname,omitempty"`
@@ -300,12 +308,12 @@ type Config struct {
 	PortSpecs         []string            `json:"PortSpecs,omitempty" yaml:"PortSpecs,omitempty" toml:"PortSpecs,omitempty"`
 	ExposedPorts      map[Port]struct{}   `json:"ExposedPorts,omitempty" yaml:"ExposedPorts,omitempty" toml:"ExposedPorts,omitempty"`
 	PublishService    string              `json:"PublishService,omitempty" yaml:"PublishService,omitempty" toml:"PublishService,omitempty"`
-	StopSignal        string              `json:"StopSignal,omitempty" yaml:"StopSignal,omitempty" toml:"StopSignal,omitempty"`
-	StopTimeout       int                 `json:"StopTimeout,omitempty" yaml:"StopTimeout,omitempty" toml:"StopTimeout,omitempty"`
+	StopSignal        string              `json:"StopSignal,omitempty" yaml:"StopSignal,omitempty" toml:"StopSignal,omitempty" docker:"min=1.21"`
+	StopTimeout       int                 `json:"StopTimeout,omitempty" yaml:"StopTimeout,omitempty" toml:"StopTimeout,omitempty" docker:"min=1.25"`
 	Env               []string            `json:"Env,omitempty" yaml:"Env,omitempty" toml:"Env,omitempty"`
 	Cmd               []string            `json:"Cmd" yaml:"Cmd" toml:"Cmd"`
-	Shell             []string            `json:"Shell,omitempty" yaml:"Shell,omitempty" toml:"Shell,omitempty"`
-	Healthcheck       *HealthConfig       `json:"Healthcheck,omitempty" yaml:"Healthcheck,omitempty" toml:"Healthcheck,omitempty"`
+	Shell             []string            `json:"Shell,omitempty" yaml:"Shell,omitempty" toml:"Shell,omitempty" docker:"min=1.25"`
+	Healthcheck       *HealthConfig       `json:"Healthcheck,omitempty" yaml:"Healthcheck,omitempty" toml:"Healthcheck,omitempty" docker:"min=1.24"`
 	DNS               []string            `json:"Dns,omitempty" yaml:"Dns,omitempty" toml:"Dns,omitempty"` // For Docker API v1.9 and below only
 	Image             string              `json:"Image,omitempty" yaml:"Image,omitempty" toml:"Image,omitempty"`
 	Volumes           map[string]struct{} `json:"Volumes,omitempty" yaml:"Volumes,omitempty" toml:"Volumes,omitempty"`
@@ -409,6 +417,10 @@ type SwarmNode struct {
 
 // GraphDriver contains information about the GraphDriver used by the
 // container.
+//
+// Data depends on the driver, e.g. the overlay2 driver reports the
+// directories of the layers of the container in LowerDir, UpperDir,
+// MergedDir and WorkDir.
 type GraphDriver struct {
 	Name string            `json:"Name,omitempty" yaml:"Name,omitempty" toml:"Name,omitempty"`
 	Data map[string]string `json:"Data,omitempty" yaml:"Data,omitempty" toml:"Data,omitempty"`
@@ -474,10 +486,24 @@ type Container struct {
 	RestartCount int `json:"RestartCount,omitempty" yaml:"RestartCount,omitempty" toml:"RestartCount,omitempty"`
 
 	AppArmorProfile string `json:"AppArmorProfile,omitempty" yaml:"AppArmorProfile,omitempty" toml:"AppArmorProfile,omitempty"`
+	ProcessLabel    string `json:"ProcessLabel,omitempty" yaml:"ProcessLabel,omitempty" toml:"ProcessLabel,omitempty"`
+	MountLabel      string `json:"MountLabel,omitempty" yaml:"MountLabel,omitempty" toml:"MountLabel,omitempty"`
+
+	// Platform is the operating system of the container, e.g. "linux".
+	Platform string `json:"Platform,omitempty" yaml:"Platform,omitempty" toml:"Platform,omitempty"`
+
+	// SizeRw and SizeRootFs are only reported when inspecting with the size
+	// (see ExportContainerWithProgress).
+	SizeRw     int64 `json:"SizeRw,omitempty" yaml:"SizeRw,omitempty" toml:"SizeRw,omitempty"`
+	SizeRootFs int64 `json:"SizeRootFs,omitempty" yaml:"SizeRootFs,omitempty" toml:"SizeRootFs,omitempty"`
 }
 
 // UpdateContainerOptions specify parameters to the UpdateContainer function.
 //
+// The daemon leaves the settings that are zero in the options unchanged. The
+// settings where zero is a meaningful value are pointers, and nil leaves them
+// unchanged.
+//
 // See https://goo.gl/Y6fXUy for more details.
 type UpdateContainerOptions struct {
 	BlkioWeight        int           `json:"BlkioWeight"`
@@ -493,6 +519,7 @@ type UpdateContainerOptions struct {
 	MemoryReservation  int           `json:"MemoryReservation"`
 	KernelMemory       int           `json:"KernelMemory"`
 	RestartPolicy      RestartPolicy `json:"RestartPolicy,omitempty"`
+	PidsLimit          *int64        `json:"PidsLimit,omitempty"` // For Docker API v1.40 and above only, zero or -1 for unlimited
 	Context            context.Context
 }
 
@@ -558,7 +585,7 @@ func (c *Client) inspectContainer(id string, opts doOptions) (*Container, error)
 	resp, err := c.do("GET", path, opts)
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchContainer{ID: id}
+			return nil, &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -578,7 +605,7 @@ func (c *Client) ContainerChanges(id string) ([]Change, error) {
 	resp, err := c.do("GET", path, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchContainer{ID: id}
+			return nil, &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -599,6 +626,11 @@ type CreateContainerOptions struct {
 	HostConfig       *HostConfig       `qs:"-"`
 	NetworkingConfig *NetworkingConfig `qs:"-"`
 	Context          context.Context
+
+	// VerifyPlatform makes CreateContainer check whether the image can run
+	// on the daemon before creating the container. See
+	// VerifyImagePlatform for more details.
+	VerifyPlatform bool `qs:"-"`
 }
 
 // CreateContainer creates a new container, returning the container instance,
@@ -607,22 +639,47 @@ type CreateContainerOptions struct {
 // The returned container instance contains only the container ID. To get more
 // details about the container after creating it, use InspectContainer.
 //
+// When the client has PruneUnsupportedFields set, the fields of the
+// configuration that the version of the API the client speaks doesn't
+// support are not sent, see PruneForAPIVersion.
+//
 // See https://goo.gl/tyzwVM for more details.
 func (c *Client) CreateContainer(opts CreateContainerOptions) (*Container, error) {
+	if version := c.getServerAPIVersion(); opts.HostConfig != nil && len(opts.HostConfig.Annotations) > 0 && version != nil && version.LessThan(apiVersion143) {
+		return nil, errors.New("container configuration Annotations is only supported in API#1.43 and above")
+	}
+	if opts.Config != nil && opts.Config.Healthcheck != nil {
+		if err := opts.Config.Healthcheck.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.VerifyPlatform && opts.Config != nil {
+		if err := c.VerifyImagePlatform(opts.Config.Image); err != nil {
+			return nil, err
+		}
+	}
 	path := "/containers/create?" + queryString(opts)
+	var data interface{} = struct {
+		*Config
+		HostConfig       *HostConfig       `json:"HostConfig,omitempty" yaml:"HostConfig,omitempty" toml:"HostConfig,omitempty"`
+		NetworkingConfig *NetworkingConfig `json:"NetworkingConfig,omitempty" yaml:"NetworkingConfig,omitempty" toml:"NetworkingConfig,omitempty"`
+	}{
+		opts.Config,
+		c.mapHostConfigPaths(opts.HostConfig),
+		opts.NetworkingConfig,
+	}
+	if c.PruneUnsupportedFields {
+		version, err := c.pruneAPIVersion()
+		if err != nil {
+			return nil, err
+		}
+		data, _ = PruneForAPIVersion(data, version)
+	}
 	resp, err := c.do(
 		"POST",
 		path,
 		doOptions{
-			data: struct {
-				*Config
-				HostConfig       *HostConfig       `json:"HostConfig,omitempty" yaml:"HostConfig,omitempty" toml:"HostConfig,omitempty"`
-				NetworkingConfig *NetworkingConfig `json:"NetworkingConfig,omitempty" yaml:"NetworkingConfig,omitempty" toml:"NetworkingConfig,omitempty"`
-			}{
-				opts.Config,
-				opts.HostConfig,
-				opts.NetworkingConfig,
-			},
+			data:    data,
 			context: opts.Context,
 		},
 	)
@@ -723,37 +780,55 @@ type BlockLimit struct {
 	Rate int64  `json:"Rate,omitempty"`
 }
 
+// Isolation represents the isolation technology of a container. It's only
+// supported by Windows daemons.
+type Isolation string
+
+const (
+	// IsolationDefault uses the default isolation of the daemon.
+	IsolationDefault Isolation = "default"
+	// IsolationProcess runs the container as a process sharing the kernel
+	// with the host.
+	IsolationProcess Isolation = "process"
+	// IsolationHyperV runs the container in a Hyper-V virtual machine.
+	IsolationHyperV Isolation = "hyperv"
+)
+
 // HostConfig contains the container options related to starting a container on
 // a given host
+//
+// Fields added after the version 1.19 of the API declare the first version
+// supporting them with a docker:"min=..." tag.
 type HostConfig struct {
 	Binds                []string               `json:"Binds,omitempty" yaml:"Binds,omitempty" toml:"Binds,omitempty"`
 	CapAdd               []string               `json:"CapAdd,omitempty" yaml:"CapAdd,omitempty" toml:"CapAdd,omitempty"`
 	CapDrop              []string               `json:"CapDrop,omitempty" yaml:"CapDrop,omitempty" toml:"CapDrop,omitempty"`
-	GroupAdd             []string               `json:"GroupAdd,omitempty" yaml:"GroupAdd,omitempty" toml:"GroupAdd,omitempty"`
+	GroupAdd             []string               `json:"GroupAdd,omitempty" yaml:"GroupAdd,omitempty" toml:"GroupAdd,omitempty" docker:"min=1.20"`
 	ContainerIDFile      string                 `json:"ContainerIDFile,omitempty" yaml:"ContainerIDFile,omitempty" toml:"ContainerIDFile,omitempty"`
 	LxcConf              []KeyValuePair         `json:"LxcConf,omitempty" yaml:"LxcConf,omitempty" toml:"LxcConf,omitempty"`
 	PortBindings         map[Port][]PortBinding `json:"PortBindings,omitempty" yaml:"PortBindings,omitempty" toml:"PortBindings,omitempty"`
 	Links                []string               `json:"Links,omitempty" yaml:"Links,omitempty" toml:"Links,omitempty"`
 	DNS                  []string               `json:"Dns,omitempty" yaml:"Dns,omitempty" toml:"Dns,omitempty"` // For Docker API v1.10 and above only
-	DNSOptions           []string               `json:"DnsOptions,omitempty" yaml:"DnsOptions,omitempty" toml:"DnsOptions,omitempty"`
+	DNSOptions           []string               `json:"DnsOptions,omitempty" yaml:"DnsOptions,omitempty" toml:"DnsOptions,omitempty" docker:"min=1.21"`
 	DNSSearch            []string               `json:"DnsSearch,omitempty" yaml:"DnsSearch,omitempty" toml:"DnsSearch,omitempty"`
 	ExtraHosts           []string               `json:"ExtraHosts,omitempty" yaml:"ExtraHosts,omitempty" toml:"ExtraHosts,omitempty"`
 	VolumesFrom          []string               `json:"VolumesFrom,omitempty" yaml:"VolumesFrom,omitempty" toml:"VolumesFrom,omitempty"`
-	UsernsMode           string                 `json:"UsernsMode,omitempty" yaml:"UsernsMode,omitempty" toml:"UsernsMode,omitempty"`
+	UsernsMode           string                 `json:"UsernsMode,omitempty" yaml:"UsernsMode,omitempty" toml:"UsernsMode,omitempty" docker:"min=1.23"`
 	NetworkMode          string                 `json:"NetworkMode,omitempty" yaml:"NetworkMode,omitempty" toml:"NetworkMode,omitempty"`
 	IpcMode              string                 `json:"IpcMode,omitempty" yaml:"IpcMode,omitempty" toml:"IpcMode,omitempty"`
 	PidMode              string                 `json:"PidMode,omitempty" yaml:"PidMode,omitempty" toml:"PidMode,omitempty"`
 	UTSMode              string                 `json:"UTSMode,omitempty" yaml:"UTSMode,omitempty" toml:"UTSMode,omitempty"`
 	RestartPolicy        RestartPolicy          `json:"RestartPolicy,omitempty" yaml:"RestartPolicy,omitempty" toml:"RestartPolicy,omitempty"`
 	Devices              []Device               `json:"Devices,omitempty" yaml:"Devices,omitempty" toml:"Devices,omitempty"`
-	DeviceCgroupRules    []string               `json:"DeviceCgroupRules,omitempty" yaml:"DeviceCgroupRules,omitempty" toml:"DeviceCgroupRules,omitempty"`
+	DeviceCgroupRules    []string               `json:"DeviceCgroupRules,omitempty" yaml:"DeviceCgroupRules,omitempty" toml:"DeviceCgroupRules,omitempty" docker:"min=1.28"`
+	DeviceRequests       []DeviceRequest        `json:"DeviceRequests,omitempty" yaml:"DeviceRequests,omitempty" toml:"DeviceRequests,omitempty" docker:"min=1.40"`
 	LogConfig            LogConfig              `json:"LogConfig,omitempty" yaml:"LogConfig,omitempty" toml:"LogConfig,omitempty"`
 	SecurityOpt          []string               `json:"SecurityOpt,omitempty" yaml:"SecurityOpt,omitempty" toml:"SecurityOpt,omitempty"`
 	Cgroup               string                 `json:"Cgroup,omitempty" yaml:"Cgroup,omitempty" toml:"Cgroup,omitempty"`
 	CgroupParent         string                 `json:"CgroupParent,omitempty" yaml:"CgroupParent,omitempty" toml:"CgroupParent,omitempty"`
 	Memory               int64                  `json:"Memory,omitempty" yaml:"Memory,omitempty" toml:"Memory,omitempty"`
-	MemoryReservation    int64                  `json:"MemoryReservation,omitempty" yaml:"MemoryReservation,omitempty" toml:"MemoryReservation,omitempty"`
-	KernelMemory         int64                  `json:"KernelMemory,omitempty" yaml:"KernelMemory,omitempty" toml:"KernelMemory,omitempty"`
+	MemoryReservation    int64                  `json:"MemoryReservation,omitempty" yaml:"MemoryReservation,omitempty" toml:"MemoryReservation,omitempty" docker:"min=1.21"`
+	KernelMemory         int64                  `json:"KernelMemory,omitempty" yaml:"KernelMemory,omitempty" toml:"KernelMemory,omitempty" docker:"min=1.21"`
 	MemorySwap           int64                  `json:"MemorySwap,omitempty" yaml:"MemorySwap,omitempty" toml:"MemorySwap,omitempty"`
 	CPUShares            int64                  `json:"CpuShares,omitempty" yaml:"CpuShares,omitempty" toml:"CpuShares,omitempty"`
 	CPUSet               string                 `json:"Cpuset,omitempty" yaml:"Cpuset,omitempty" toml:"Cpuset,omitempty"`
@@ -761,35 +836,37 @@ type HostConfig struct {
 	CPUSetMEMs           string                 `json:"CpusetMems,omitempty" yaml:"CpusetMems,omitempty" toml:"CpusetMems,omitempty"`
 	CPUQuota             int64                  `json:"CpuQuota,omitempty" yaml:"CpuQuota,omitempty" toml:"CpuQuota,omitempty"`
 	CPUPeriod            int64                  `json:"CpuPeriod,omitempty" yaml:"CpuPeriod,omitempty" toml:"CpuPeriod,omitempty"`
-	CPURealtimePeriod    int64                  `json:"CpuRealtimePeriod,omitempty" yaml:"CpuRealtimePeriod,omitempty" toml:"CpuRealtimePeriod,omitempty"`
-	CPURealtimeRuntime   int64                  `json:"CpuRealtimeRuntime,omitempty" yaml:"CpuRealtimeRuntime,omitempty" toml:"CpuRealtimeRuntime,omitempty"`
+	CPURealtimePeriod    int64                  `json:"CpuRealtimePeriod,omitempty" yaml:"CpuRealtimePeriod,omitempty" toml:"CpuRealtimePeriod,omitempty" docker:"min=1.25"`
+	CPURealtimeRuntime   int64                  `json:"CpuRealtimeRuntime,omitempty" yaml:"CpuRealtimeRuntime,omitempty" toml:"CpuRealtimeRuntime,omitempty" docker:"min=1.25"`
 	BlkioWeight          int64                  `json:"BlkioWeight,omitempty" yaml:"BlkioWeight,omitempty" toml:"BlkioWeight,omitempty"`
-	BlkioWeightDevice    []BlockWeight          `json:"BlkioWeightDevice,omitempty" yaml:"BlkioWeightDevice,omitempty" toml:"BlkioWeightDevice,omitempty"`
-	BlkioDeviceReadBps   []BlockLimit           `json:"BlkioDeviceReadBps,omitempty" yaml:"BlkioDeviceReadBps,omitempty" toml:"BlkioDeviceReadBps,omitempty"`
-	BlkioDeviceReadIOps  []BlockLimit           `json:"BlkioDeviceReadIOps,omitempty" yaml:"BlkioDeviceReadIOps,omitempty" toml:"BlkioDeviceReadIOps,omitempty"`
-	BlkioDeviceWriteBps  []BlockLimit           `json:"BlkioDeviceWriteBps,omitempty" yaml:"BlkioDeviceWriteBps,omitempty" toml:"BlkioDeviceWriteBps,omitempty"`
-	BlkioDeviceWriteIOps []BlockLimit           `json:"BlkioDeviceWriteIOps,omitempty" yaml:"BlkioDeviceWriteIOps,omitempty" toml:"BlkioDeviceWriteIOps,omitempty"`
+	BlkioWeightDevice    []BlockWeight          `json:"BlkioWeightDevice,omitempty" yaml:"BlkioWeightDevice,omitempty" toml:"BlkioWeightDevice,omitempty" docker:"min=1.22"`
+	BlkioDeviceReadBps   []BlockLimit           `json:"BlkioDeviceReadBps,omitempty" yaml:"BlkioDeviceReadBps,omitempty" toml:"BlkioDeviceReadBps,omitempty" docker:"min=1.22"`
+	BlkioDeviceReadIOps  []BlockLimit           `json:"BlkioDeviceReadIOps,omitempty" yaml:"BlkioDeviceReadIOps,omitempty" toml:"BlkioDeviceReadIOps,omitempty" docker:"min=1.22"`
+	BlkioDeviceWriteBps  []BlockLimit           `json:"BlkioDeviceWriteBps,omitempty" yaml:"BlkioDeviceWriteBps,omitempty" toml:"BlkioDeviceWriteBps,omitempty" docker:"min=1.22"`
+	BlkioDeviceWriteIOps []BlockLimit           `json:"BlkioDeviceWriteIOps,omitempty" yaml:"BlkioDeviceWriteIOps,omitempty" toml:"BlkioDeviceWriteIOps,omitempty" docker:"min=1.22"`
 	Ulimits              []ULimit               `json:"Ulimits,omitempty" yaml:"Ulimits,omitempty" toml:"Ulimits,omitempty"`
 	VolumeDriver         string                 `json:"VolumeDriver,omitempty" yaml:"VolumeDriver,omitempty" toml:"VolumeDriver,omitempty"`
-	OomScoreAdj          int                    `json:"OomScoreAdj,omitempty" yaml:"OomScoreAdj,omitempty" toml:"OomScoreAdj,omitempty"`
-	MemorySwappiness     *int64                 `json:"MemorySwappiness,omitempty" yaml:"MemorySwappiness,omitempty" toml:"MemorySwappiness,omitempty"`
-	PidsLimit            *int64                 `json:"PidsLimit,omitempty" yaml:"PidsLimit,omitempty" toml:"PidsLimit,omitempty"`
-	OOMKillDisable       *bool                  `json:"OomKillDisable,omitempty" yaml:"OomKillDisable,omitempty" toml:"OomKillDisable,omitempty"`
-	ShmSize              int64                  `json:"ShmSize,omitempty" yaml:"ShmSize,omitempty" toml:"ShmSize,omitempty"`
-	Tmpfs                map[string]string      `json:"Tmpfs,omitempty" yaml:"Tmpfs,omitempty" toml:"Tmpfs,omitempty"`
-	StorageOpt           map[string]string      `json:"StorageOpt,omitempty" yaml:"StorageOpt,omitempty" toml:"StorageOpt,omitempty"`
-	Sysctls              map[string]string      `json:"Sysctls,omitempty" yaml:"Sysctls,omitempty" toml:"Sysctls,omitempty"`
-	CPUCount             int64                  `json:"CpuCount,omitempty" yaml:"CpuCount,omitempty"`
-	CPUPercent           int64                  `json:"CpuPercent,omitempty" yaml:"CpuPercent,omitempty"`
-	IOMaximumBandwidth   int64                  `json:"IOMaximumBandwidth,omitempty" yaml:"IOMaximumBandwidth,omitempty"`
-	IOMaximumIOps        int64                  `json:"IOMaximumIOps,omitempty" yaml:"IOMaximumIOps,omitempty"`
-	Mounts               []HostMount            `json:"Mounts,omitempty" yaml:"Mounts,omitempty" toml:"Mounts,omitempty"`
-	Runtime              string                 `json:"Runtime,omitempty" yaml:"Runtime,omitempty" toml:"Runtime,omitempty"`
-	Init                 bool                   `json:",omitempty" yaml:",omitempty"`
+	OomScoreAdj          int                    `json:"OomScoreAdj,omitempty" yaml:"OomScoreAdj,omitempty" toml:"OomScoreAdj,omitempty" docker:"min=1.20"`
+	MemorySwappiness     *int64                 `json:"MemorySwappiness,omitempty" yaml:"MemorySwappiness,omitempty" toml:"MemorySwappiness,omitempty" docker:"min=1.20"`
+	PidsLimit            *int64                 `json:"PidsLimit,omitempty" yaml:"PidsLimit,omitempty" toml:"PidsLimit,omitempty" docker:"min=1.23"`
+	OOMKillDisable       *bool                  `json:"OomKillDisable,omitempty" yaml:"OomKillDisable,omitempty" toml:"OomKillDisable,omitempty" docker:"min=1.20"`
+	ShmSize              int64                  `json:"ShmSize,omitempty" yaml:"ShmSize,omitempty" toml:"ShmSize,omitempty" docker:"min=1.22"`
+	Tmpfs                map[string]string      `json:"Tmpfs,omitempty" yaml:"Tmpfs,omitempty" toml:"Tmpfs,omitempty" docker:"min=1.22"`
+	StorageOpt           map[string]string      `json:"StorageOpt,omitempty" yaml:"StorageOpt,omitempty" toml:"StorageOpt,omitempty" docker:"min=1.24"`
+	Sysctls              map[string]string      `json:"Sysctls,omitempty" yaml:"Sysctls,omitempty" toml:"Sysctls,omitempty" docker:"min=1.24"`
+	CPUCount             int64                  `json:"CpuCount,omitempty" yaml:"CpuCount,omitempty" toml:"CpuCount,omitempty" docker:"min=1.24"`
+	CPUPercent           int64                  `json:"CpuPercent,omitempty" yaml:"CpuPercent,omitempty" toml:"CpuPercent,omitempty" docker:"min=1.24"`
+	IOMaximumBandwidth   int64                  `json:"IOMaximumBandwidth,omitempty" yaml:"IOMaximumBandwidth,omitempty" toml:"IOMaximumBandwidth,omitempty" docker:"min=1.24"`
+	IOMaximumIOps        int64                  `json:"IOMaximumIOps,omitempty" yaml:"IOMaximumIOps,omitempty" toml:"IOMaximumIOps,omitempty" docker:"min=1.24"`
+	Isolation            Isolation              `json:"Isolation,omitempty" yaml:"Isolation,omitempty" toml:"Isolation,omitempty" docker:"min=1.22"`
+	Mounts               []HostMount            `json:"Mounts,omitempty" yaml:"Mounts,omitempty" toml:"Mounts,omitempty" docker:"min=1.25"`
+	Runtime              string                 `json:"Runtime,omitempty" yaml:"Runtime,omitempty" toml:"Runtime,omitempty" docker:"min=1.25"`
+	Annotations          map[string]string      `json:"Annotations,omitempty" yaml:"Annotations,omitempty" toml:"Annotations,omitempty" docker:"min=1.43"` // For Docker API v1.43 and above only
+	Init                 bool                   `json:",omitempty" yaml:",omitempty" docker:"min=1.25"`
 	Privileged           bool                   `json:"Privileged,omitempty" yaml:"Privileged,omitempty" toml:"Privileged,omitempty"`
 	PublishAllPorts      bool                   `json:"PublishAllPorts,omitempty" yaml:"PublishAllPorts,omitempty" toml:"PublishAllPorts,omitempty"`
 	ReadonlyRootfs       bool                   `json:"ReadonlyRootfs,omitempty" yaml:"ReadonlyRootfs,omitempty" toml:"ReadonlyRootfs,omitempty"`
-	AutoRemove           bool                   `json:"AutoRemove,omitempty" yaml:"AutoRemove,omitempty" toml:"AutoRemove,omitempty"`
+	AutoRemove           bool                   `json:"AutoRemove,omitempty" yaml:"AutoRemove,omitempty" toml:"AutoRemove,omitempty" docker:"min=1.25"`
 }
 
 // NetworkingConfig represents the container's networking configuration for each of its interfaces
@@ -826,17 +903,25 @@ func (c *Client) StartContainerWithContext(id string, hostConfig *HostConfig, ct
 
 func (c *Client) startContainer(id string, hostConfig *HostConfig, opts doOptions) error {
 	path := "/containers/" + id + "/start"
-	if c.serverAPIVersion == nil {
+	if c.getServerAPIVersion() == nil {
 		c.checkAPIVersion()
 	}
-	if c.serverAPIVersion != nil && c.serverAPIVersion.LessThan(apiVersion124) {
+	if version := c.getServerAPIVersion(); version != nil && version.LessThan(apiVersion124) {
+		hostConfig = c.mapHostConfigPaths(hostConfig)
 		opts.data = hostConfig
+		if c.PruneUnsupportedFields {
+			pruneVersion, err := c.pruneAPIVersion()
+			if err != nil {
+				return err
+			}
+			opts.data, _ = PruneForAPIVersion(hostConfig, pruneVersion)
+		}
 		opts.forceJSON = true
 	}
 	resp, err := c.do("POST", path, opts)
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchContainer{ID: id, Err: err}
+			return &NoSuchContainer{ID: id, Err: err, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -866,10 +951,11 @@ func (c *Client) StopContainerWithContext(id string, timeout uint, ctx context.C
 
 func (c *Client) stopContainer(id string, timeout uint, opts doOptions) error {
 	path := fmt.Sprintf("/containers/%s/stop?t=%d", id, timeout)
+	opts.timeoutGrace = time.Duration(timeout) * time.Second
 	resp, err := c.do("POST", path, opts)
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchContainer{ID: id}
+			return &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -886,10 +972,10 @@ func (c *Client) stopContainer(id string, timeout uint, opts doOptions) error {
 // See https://goo.gl/MrAKQ5 for more details.
 func (c *Client) RestartContainer(id string, timeout uint) error {
 	path := fmt.Sprintf("/containers/%s/restart?t=%d", id, timeout)
-	resp, err := c.do("POST", path, doOptions{})
+	resp, err := c.do("POST", path, doOptions{timeoutGrace: time.Duration(timeout) * time.Second})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchContainer{ID: id}
+			return &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -905,7 +991,7 @@ func (c *Client) PauseContainer(id string) error {
 	resp, err := c.do("POST", path, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchContainer{ID: id}
+			return &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -921,7 +1007,7 @@ func (c *Client) UnpauseContainer(id string) error {
 	resp, err := c.do("POST", path, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchContainer{ID: id}
+			return &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -951,7 +1037,7 @@ func (c *Client) TopContainer(id string, psArgs string) (TopResult, error) {
 	resp, err := c.do("GET", path, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return result, &NoSuchContainer{ID: id}
+			return result, &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return result, err
 	}
@@ -1005,14 +1091,35 @@ type Stats struct {
 			TotalPgpgin             uint64 `json:"total_pgpgin,omitempty" yaml:"total_pgpgin,omitempty" toml:"total_pgpgin,omitempty"`
 			HierarchicalMemswLimit  uint64 `json:"hierarchical_memsw_limit,omitempty" yaml:"hierarchical_memsw_limit,omitempty" toml:"hierarchical_memsw_limit,omitempty"`
 			Swap                    uint64 `json:"swap,omitempty" yaml:"swap,omitempty" toml:"swap,omitempty"`
+
+			// cgroup v2 only
+			Anon               uint64 `json:"anon,omitempty" yaml:"anon,omitempty" toml:"anon,omitempty"`
+			File               uint64 `json:"file,omitempty" yaml:"file,omitempty" toml:"file,omitempty"`
+			KernelStack        uint64 `json:"kernel_stack,omitempty" yaml:"kernel_stack,omitempty" toml:"kernel_stack,omitempty"`
+			Slab               uint64 `json:"slab,omitempty" yaml:"slab,omitempty" toml:"slab,omitempty"`
+			SlabReclaimable    uint64 `json:"slab_reclaimable,omitempty" yaml:"slab_reclaimable,omitempty" toml:"slab_reclaimable,omitempty"`
+			SlabUnreclaimable  uint64 `json:"slab_unreclaimable,omitempty" yaml:"slab_unreclaimable,omitempty" toml:"slab_unreclaimable,omitempty"`
+			Sock               uint64 `json:"sock,omitempty" yaml:"sock,omitempty" toml:"sock,omitempty"`
+			Shmem              uint64 `json:"shmem,omitempty" yaml:"shmem,omitempty" toml:"shmem,omitempty"`
+			FileMapped         uint64 `json:"file_mapped,omitempty" yaml:"file_mapped,omitempty" toml:"file_mapped,omitempty"`
+			FileDirty          uint64 `json:"file_dirty,omitempty" yaml:"file_dirty,omitempty" toml:"file_dirty,omitempty"`
+			FileWriteback      uint64 `json:"file_writeback,omitempty" yaml:"file_writeback,omitempty" toml:"file_writeback,omitempty"`
+			AnonThp            uint64 `json:"anon_thp,omitempty" yaml:"anon_thp,omitempty" toml:"anon_thp,omitempty"`
+			Pgscan             uint64 `json:"pgscan,omitempty" yaml:"pgscan,omitempty" toml:"pgscan,omitempty"`
+			Pgsteal            uint64 `json:"pgsteal,omitempty" yaml:"pgsteal,omitempty" toml:"pgsteal,omitempty"`
+			Pgactivate         uint64 `json:"pgactivate,omitempty" yaml:"pgactivate,omitempty" toml:"pgactivate,omitempty"`
+			Pgdeactivate       uint64 `json:"pgdeactivate,omitempty" yaml:"pgdeactivate,omitempty" toml:"pgdeactivate,omitempty"`
+			WorkingsetRefault  uint64 `json:"workingset_refault,omitempty" yaml:"workingset_refault,omitempty" toml:"workingset_refault,omitempty"`
+			WorkingsetActivate uint64 `json:"workingset_activate,omitempty" yaml:"workingset_activate,omitempty" toml:"workingset_activate,omitempty"`
 		} `json:"stats,omitempty" yaml:"stats,omitempty" toml:"stats,omitempty"`
-		MaxUsage          uint64 `json:"max_usage,omitempty" yaml:"max_usage,omitempty" toml:"max_usage,omitempty"`
-		Usage             uint64 `json:"usage,omitempty" yaml:"usage,omitempty" toml:"usage,omitempty"`
-		Failcnt           uint64 `json:"failcnt,omitempty" yaml:"failcnt,omitempty" toml:"failcnt,omitempty"`
-		Limit             uint64 `json:"limit,omitempty" yaml:"limit,omitempty" toml:"limit,omitempty"`
-		Commit            uint64 `json:"commitbytes,omitempty" yaml:"commitbytes,omitempty" toml:"privateworkingset,omitempty"`
-		CommitPeak        uint64 `json:"commitpeakbytes,omitempty" yaml:"commitpeakbytes,omitempty" toml:"commitpeakbytes,omitempty"`
-		PrivateWorkingSet uint64 `json:"privateworkingset,omitempty" yaml:"privateworkingset,omitempty" toml:"privateworkingset,omitempty"`
+		MaxUsage          uint64    `json:"max_usage,omitempty" yaml:"max_usage,omitempty" toml:"max_usage,omitempty"`
+		Usage             uint64    `json:"usage,omitempty" yaml:"usage,omitempty" toml:"usage,omitempty"`
+		Failcnt           uint64    `json:"failcnt,omitempty" yaml:"failcnt,omitempty" toml:"failcnt,omitempty"`
+		Limit             uint64    `json:"limit,omitempty" yaml:"limit,omitempty" toml:"limit,omitempty"`
+		Commit            uint64    `json:"commitbytes,omitempty" yaml:"commitbytes,omitempty" toml:"privateworkingset,omitempty"`
+		CommitPeak        uint64    `json:"commitpeakbytes,omitempty" yaml:"commitpeakbytes,omitempty" toml:"commitpeakbytes,omitempty"`
+		PrivateWorkingSet uint64    `json:"privateworkingset,omitempty" yaml:"privateworkingset,omitempty" toml:"privateworkingset,omitempty"`
+		PSI               *PSIStats `json:"psi,omitempty" yaml:"psi,omitempty" toml:"psi,omitempty"`
 	} `json:"memory_stats,omitempty" yaml:"memory_stats,omitempty" toml:"memory_stats,omitempty"`
 	BlkioStats struct {
 		IOServiceBytesRecursive []BlkioStatsEntry `json:"io_service_bytes_recursive,omitempty" yaml:"io_service_bytes_recursive,omitempty" toml:"io_service_bytes_recursive,omitempty"`
@@ -1023,6 +1130,7 @@ type Stats struct {
 		IOMergedRecursive       []BlkioStatsEntry `json:"io_merged_recursive,omitempty" yaml:"io_merged_recursive,omitempty" toml:"io_merged_recursive,omitempty"`
 		IOTimeRecursive         []BlkioStatsEntry `json:"io_time_recursive,omitempty" yaml:"io_time_recursive,omitempty" toml:"io_time_recursive,omitempty"`
 		SectorsRecursive        []BlkioStatsEntry `json:"sectors_recursive,omitempty" yaml:"sectors_recursive,omitempty" toml:"sectors_recursive,omitempty"`
+		PSI                     *PSIStats         `json:"psi,omitempty" yaml:"psi,omitempty" toml:"psi,omitempty"`
 	} `json:"blkio_stats,omitempty" yaml:"blkio_stats,omitempty" toml:"blkio_stats,omitempty"`
 	CPUStats     CPUStats `json:"cpu_stats,omitempty" yaml:"cpu_stats,omitempty" toml:"cpu_stats,omitempty"`
 	PreCPUStats  CPUStats `json:"precpu_stats,omitempty"`
@@ -1034,6 +1142,65 @@ type Stats struct {
 	} `json:"storage_stats,omitempty" yaml:"storage_stats,omitempty" toml:"storage_stats,omitempty"`
 }
 
+// TotalNetworkRx returns the bytes received by the container across all its
+// network interfaces. It supports both the per-interface "networks" layout and
+// the legacy "network" layout of API versions older than 1.21.
+func (s *Stats) TotalNetworkRx() uint64 {
+	if len(s.Networks) == 0 {
+		return s.Network.RxBytes
+	}
+	var total uint64
+	for _, network := range s.Networks {
+		total += network.RxBytes
+	}
+	return total
+}
+
+// TotalNetworkTx returns the bytes sent by the container across all its
+// network interfaces. See TotalNetworkRx for the layouts supported.
+func (s *Stats) TotalNetworkTx() uint64 {
+	if len(s.Networks) == 0 {
+		return s.Network.TxBytes
+	}
+	var total uint64
+	for _, network := range s.Networks {
+		total += network.TxBytes
+	}
+	return total
+}
+
+// TotalBlkioRead returns the bytes read by the container across all block
+// devices. On Windows, where there are no blkio stats, it returns the bytes
+// read from storage.
+func (s *Stats) TotalBlkioRead() uint64 {
+	if len(s.BlkioStats.IOServiceBytesRecursive) == 0 {
+		return s.StorageStats.ReadSizeBytes
+	}
+	return s.sumBlkioServiceBytes("read")
+}
+
+// TotalBlkioWrite returns the bytes written by the container across all
+// block devices. On Windows, where there are no blkio stats, it returns the
+// bytes written to storage.
+func (s *Stats) TotalBlkioWrite() uint64 {
+	if len(s.BlkioStats.IOServiceBytesRecursive) == 0 {
+		return s.StorageStats.WriteSizeBytes
+	}
+	return s.sumBlkioServiceBytes("write")
+}
+
+// sumBlkioServiceBytes sums the entries of the given operation, which is
+// capitalized on cgroup v1 ("Read") and lowercase on cgroup v2 ("read").
+func (s *Stats) sumBlkioServiceBytes(op string) uint64 {
+	var total uint64
+	for _, entry := range s.BlkioStats.IOServiceBytesRecursive {
+		if strings.EqualFold(entry.Op, op) {
+			total += entry.Value
+		}
+	}
+	return total
+}
+
 // NetworkStats is a stats entry for network stats
 type NetworkStats struct {
 	RxDropped uint64 `json:"rx_dropped,omitempty" yaml:"rx_dropped,omitempty" toml:"rx_dropped,omitempty"`
@@ -1061,6 +1228,24 @@ type CPUStats struct {
 		ThrottledPeriods uint64 `json:"throttled_periods,omitempty"`
 		ThrottledTime    uint64 `json:"throttled_time,omitempty"`
 	} `json:"throttling_data,omitempty" yaml:"throttling_data,omitempty" toml:"throttling_data,omitempty"`
+	PSI *PSIStats `json:"psi,omitempty" yaml:"psi,omitempty" toml:"psi,omitempty"`
+}
+
+// PSIStats is the pressure stall information of a resource, reported by
+// daemons running on cgroup v2 hosts with PSI enabled.
+type PSIStats struct {
+	Some PSIData `json:"some,omitempty" yaml:"some,omitempty" toml:"some,omitempty"`
+	Full PSIData `json:"full,omitempty" yaml:"full,omitempty" toml:"full,omitempty"`
+}
+
+// PSIData is the share of time in which some (or all) tasks were stalled on
+// a resource, averaged over 10, 60 and 300 seconds, and the total stall time
+// in microseconds.
+type PSIData struct {
+	Avg10  float64 `json:"avg10" yaml:"avg10" toml:"avg10"`
+	Avg60  float64 `json:"avg60" yaml:"avg60" toml:"avg60"`
+	Avg300 float64 `json:"avg300" yaml:"avg300" toml:"avg300"`
+	Total  uint64  `json:"total" yaml:"total" toml:"total"`
 }
 
 // BlkioStatsEntry is a stats entry for blkio_stats
@@ -1129,7 +1314,7 @@ func (c *Client) Stats(opts StatsOptions) (retErr error) {
 			dockerError, ok := err.(*Error)
 			if ok {
 				if dockerError.Status == http.StatusNotFound {
-					err = &NoSuchContainer{ID: opts.ID}
+					err = &NoSuchContainer{ID: opts.ID, errorMetadata: metadataOf(dockerError)}
 				}
 			}
 		}
@@ -1192,9 +1377,9 @@ func (c *Client) KillContainer(opts KillContainerOptions) error {
 		}
 		switch e.Status {
 		case http.StatusNotFound:
-			return &NoSuchContainer{ID: opts.ID}
+			return &NoSuchContainer{ID: opts.ID, errorMetadata: metadataOf(e)}
 		case http.StatusConflict:
-			return &ContainerNotRunning{ID: opts.ID}
+			return &ContainerNotRunning{ID: opts.ID, errorMetadata: metadataOf(e)}
 		default:
 			return err
 		}
@@ -1228,7 +1413,7 @@ func (c *Client) RemoveContainer(opts RemoveContainerOptions) error {
 	resp, err := c.do("DELETE", path, doOptions{context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchContainer{ID: opts.ID}
+			return &NoSuchContainer{ID: opts.ID, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -1244,7 +1429,22 @@ type UploadToContainerOptions struct {
 	InputStream          io.Reader `json:"-" qs:"-"`
 	Path                 string    `qs:"path"`
 	NoOverwriteDirNonDir bool      `qs:"noOverwriteDirNonDir"`
-	Context              context.Context
+
+	// CopyUIDGID keeps the owners of the files in the archive, like
+	// "docker cp -a", instead of giving them to the user of the container.
+	// It's only supported in API 1.31 and above.
+	CopyUIDGID bool `qs:"copyUIDGID"`
+
+	// Chown, in the form "uid:gid", changes the owner of every entry of the
+	// archive, and implies CopyUIDGID.
+	Chown string `qs:"-"`
+
+	// Chmod and DirChmod, when not zero, replace the permissions of the
+	// regular files and directories of the archive.
+	Chmod    os.FileMode `qs:"-"`
+	DirChmod os.FileMode `qs:"-"`
+
+	Context context.Context
 }
 
 // UploadToContainer uploads a tar archive to be extracted to a path in the
@@ -1252,10 +1452,31 @@ type UploadToContainerOptions struct {
 //
 // See https://goo.gl/g25o7u for more details.
 func (c *Client) UploadToContainer(id string, opts UploadToContainerOptions) error {
+	rewrite, err := newTarRewrite(opts.Chown, opts.Chmod, opts.DirChmod)
+	if err != nil {
+		return err
+	}
+	if rewrite.chown {
+		opts.CopyUIDGID = true
+	}
+	if opts.CopyUIDGID {
+		if version := c.getServerAPIVersion(); version != nil && version.LessThan(apiVersion131) {
+			return errors.New("copyUIDGID is only supported in API#1.31 and above")
+		}
+	}
 	url := fmt.Sprintf("/containers/%s/archive?", id) + queryString(opts)
+	in := opts.InputStream
+	if rewrite.active() {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go func() {
+			pw.CloseWithError(rewrite.copy(pw, opts.InputStream))
+		}()
+		in = pr
+	}
 
 	return c.stream("PUT", url, streamOptions{
-		in:      opts.InputStream,
+		in:      in,
 		context: opts.Context,
 	})
 }
@@ -1268,21 +1489,72 @@ type DownloadFromContainerOptions struct {
 	OutputStream      io.Writer     `json:"-" qs:"-"`
 	Path              string        `qs:"path"`
 	InactivityTimeout time.Duration `qs:"-"`
-	Context           context.Context
+
+	// FollowLink downloads the target of Path when it's a symbolic link,
+	// like "docker cp -L", with the name of the link in the archive.
+	// Otherwise the link itself is downloaded.
+	FollowLink bool `qs:"-"`
+
+	// Chown, in the form "uid:gid", changes the owner of every entry of the
+	// archive, and Chmod and DirChmod, when not zero, replace the permissions
+	// of its regular files and directories.
+	Chown    string      `qs:"-"`
+	Chmod    os.FileMode `qs:"-"`
+	DirChmod os.FileMode `qs:"-"`
+
+	Context context.Context
 }
 
 // DownloadFromContainer downloads a tar archive of files or folders in a container.
 //
 // See https://goo.gl/W49jxK for more details.
 func (c *Client) DownloadFromContainer(id string, opts DownloadFromContainerOptions) error {
+	rewrite, err := newTarRewrite(opts.Chown, opts.Chmod, opts.DirChmod)
+	if err != nil {
+		return err
+	}
+	if opts.FollowLink {
+		ctx := opts.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		stat, err := c.StatContainerPath(ctx, id, opts.Path)
+		if err != nil {
+			return err
+		}
+		if stat.Mode&os.ModeSymlink != 0 && stat.LinkTarget != "" {
+			rewrite.renameFrom = path.Base(stat.LinkTarget)
+			rewrite.renameTo = path.Base(opts.Path)
+			opts.Path = stat.LinkTarget
+		}
+	}
 	url := fmt.Sprintf("/containers/%s/archive?", id) + queryString(opts)
-
-	return c.stream("GET", url, streamOptions{
+	if !rewrite.active() {
+		return c.stream("GET", url, streamOptions{
+			setRawTerminal:    true,
+			stdout:            opts.OutputStream,
+			inactivityTimeout: opts.InactivityTimeout,
+			context:           opts.Context,
+		})
+	}
+	pr, pw := io.Pipe()
+	copyErr := make(chan error, 1)
+	go func() {
+		err := rewrite.copy(opts.OutputStream, pr)
+		pr.CloseWithError(err)
+		copyErr <- err
+	}()
+	err = c.stream("GET", url, streamOptions{
 		setRawTerminal:    true,
-		stdout:            opts.OutputStream,
+		stdout:            pw,
 		inactivityTimeout: opts.InactivityTimeout,
 		context:           opts.Context,
 	})
+	pw.CloseWithError(err)
+	if cerr := <-copyErr; err == nil {
+		err = cerr
+	}
+	return err
 }
 
 // CopyFromContainerOptions contains the set of options used for copying
@@ -1303,10 +1575,10 @@ func (c *Client) CopyFromContainer(opts CopyFromContainerOptions) error {
 	if opts.Container == "" {
 		return &NoSuchContainer{ID: opts.Container}
 	}
-	if c.serverAPIVersion == nil {
+	if c.getServerAPIVersion() == nil {
 		c.checkAPIVersion()
 	}
-	if c.serverAPIVersion != nil && c.serverAPIVersion.GreaterThanOrEqualTo(apiVersion124) {
+	if version := c.getServerAPIVersion(); version != nil && version.GreaterThanOrEqualTo(apiVersion124) {
 		return errors.New("go-dockerclient: CopyFromContainer is no longer available in Docker >= 1.12, use DownloadFromContainer instead")
 	}
 	url := fmt.Sprintf("/containers/%s/copy", opts.Container)
@@ -1316,7 +1588,7 @@ func (c *Client) CopyFromContainer(opts CopyFromContainerOptions) error {
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchContainer{ID: opts.Container}
+			return &NoSuchContainer{ID: opts.Container, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -1346,7 +1618,7 @@ func (c *Client) waitContainer(id string, opts doOptions) (int, error) {
 	resp, err := c.do("POST", "/containers/"+id+"/wait", opts)
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return 0, &NoSuchContainer{ID: id}
+			return 0, &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return 0, err
 	}
@@ -1383,7 +1655,7 @@ func (c *Client) CommitContainer(opts CommitContainerOptions) (*Image, error) {
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchContainer{ID: opts.Container}
+			return nil, &NoSuchContainer{ID: opts.Container, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -1406,7 +1678,9 @@ type AttachToContainerOptions struct {
 	ErrorStream  io.Writer `qs:"-"`
 
 	// If set, after a successful connect, a sentinel will be sent and then the
-	// client will block on receive before continuing.
+	// client will block on receive before continuing. The sentinel is also
+	// sent when the daemon rejects the request, whose error is then returned
+	// by Wait.
 	//
 	// It must be an unbuffered channel. Using a buffered channel can lead
 	// to unexpected behavior.
@@ -1429,6 +1703,10 @@ type AttachToContainerOptions struct {
 
 	// Attach to stderr, and use ErrorStream.
 	Stderr bool
+
+	// Cancelling the context closes the connection to the daemon, ending
+	// the attach session. Wait then returns the error of the context.
+	Context context.Context
 }
 
 // AttachToContainer attaches to a container, using the given options.
@@ -1457,6 +1735,7 @@ func (c *Client) AttachToContainerNonBlocking(opts AttachToContainerOptions) (Cl
 		in:             opts.InputStream,
 		stdout:         opts.OutputStream,
 		stderr:         opts.ErrorStream,
+		context:        opts.Context,
 	})
 }
 
@@ -1484,6 +1763,10 @@ type LogsOptions struct {
 
 // Logs gets stdout and stderr logs from the specified container.
 //
+// When the logging driver of the container doesn't support reading the logs,
+// it returns an *Error with a 501 status, which ExplainLogsError turns into a
+// *LogsNotSupported. See also CanReadLogs.
+//
 // When LogsOptions.RawTerminal is set to false, go-dockerclient will multiplex
 // the streams and send the containers stdout to LogsOptions.OutputStream, and
 // stderr to LogsOptions.ErrorStream.
@@ -1559,6 +1842,10 @@ func (c *Client) ExportContainer(opts ExportContainerOptions) error {
 type PruneContainersOptions struct {
 	Filters map[string][]string
 	Context context.Context
+
+	// DryRun lists the containers that would be removed without removing
+	// them. See PruneContainers.
+	DryRun bool `qs:"-"`
 }
 
 // PruneContainersResults specify results from the PruneContainers function.
@@ -1571,8 +1858,15 @@ type PruneContainersResults struct {
 
 // PruneContainers deletes containers which are stopped.
 //
+// As the daemon has no dry run, with opts.DryRun the stopped containers
+// matching the filters are listed client-side and returned without being
+// removed, along with the size of their writable layers.
+//
 // See https://goo.gl/wnkgDT for more details.
 func (c *Client) PruneContainers(opts PruneContainersOptions) (*PruneContainersResults, error) {
+	if opts.DryRun {
+		return c.pruneContainersDryRun(opts)
+	}
 	path := "/containers/prune?" + queryString(opts)
 	resp, err := c.do("POST", path, doOptions{context: opts.Context})
 	if err != nil {
@@ -1590,6 +1884,8 @@ func (c *Client) PruneContainers(opts PruneContainersOptions) (*PruneContainersR
 type NoSuchContainer struct {
 	ID  string
 	Err error
+
+	errorMetadata
 }
 
 func (err *NoSuchContainer) Error() string {
@@ -1613,6 +1909,8 @@ func (err *ContainerAlreadyRunning) Error() string {
 // running.
 type ContainerNotRunning struct {
 	ID string
+
+	errorMetadata
 }
 
 func (err *ContainerNotRunning) Error() string {
@@ -0,0 +1,150 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContainerPathStat describes a path in the filesystem of a container, as
+// returned by StatContainerPath.
+type ContainerPathStat struct {
+	Name  string      `json:"name"`
+	Size  int64       `json:"size"`
+	Mode  os.FileMode `json:"mode"`
+	Mtime time.Time   `json:"mtime"`
+
+	// LinkTarget is the absolute path the path resolves to, when it's a
+	// symbolic link.
+	LinkTarget string `json:"linkTarget"`
+}
+
+// StatContainerPath returns information about a path in the filesystem of a
+// container.
+//
+// See https://goo.gl/W49jxK for more details.
+func (c *Client) StatContainerPath(ctx context.Context, id, path string) (*ContainerPathStat, error) {
+	resp, err := c.do(http.MethodHead, fmt.Sprintf("/containers/%s/archive?path=%s", id, url.QueryEscape(path)), doOptions{context: ctx})
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
+			return nil, &NoSuchContainer{ID: id, Err: fmt.Errorf("no such container or path: %s", path), errorMetadata: metadataOf(e)}
+		}
+		return nil, err
+	}
+	resp.Body.Close()
+	data, err := base64.StdEncoding.DecodeString(resp.Header.Get("X-Docker-Container-Path-Stat"))
+	if err != nil {
+		return nil, err
+	}
+	var stat ContainerPathStat
+	if err := json.Unmarshal(data, &stat); err != nil {
+		return nil, err
+	}
+	return &stat, nil
+}
+
+// tarRewrite describes the changes applied to the headers of the archives
+// copied to and from containers.
+type tarRewrite struct {
+	chown    bool
+	uid, gid int
+	chmod    os.FileMode
+	dirChmod os.FileMode
+
+	// renameFrom is the name of the root entry of the archive, replaced by
+	// renameTo, when a symbolic link is followed.
+	renameFrom, renameTo string
+}
+
+func newTarRewrite(chown string, chmod, dirChmod os.FileMode) (*tarRewrite, error) {
+	rewrite := tarRewrite{chmod: chmod, dirChmod: dirChmod}
+	if chown == "" {
+		return &rewrite, nil
+	}
+	parts := strings.Split(chown, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid chown %q: must be in the form uid:gid", chown)
+	}
+	var err error
+	if rewrite.uid, err = strconv.Atoi(parts[0]); err != nil || rewrite.uid < 0 {
+		return nil, fmt.Errorf("invalid chown %q: invalid uid", chown)
+	}
+	if rewrite.gid, err = strconv.Atoi(parts[1]); err != nil || rewrite.gid < 0 {
+		return nil, fmt.Errorf("invalid chown %q: invalid gid", chown)
+	}
+	rewrite.chown = true
+	return &rewrite, nil
+}
+
+func (r *tarRewrite) active() bool {
+	return r.chown || r.chmod != 0 || r.dirChmod != 0 || r.renameFrom != r.renameTo
+}
+
+func (r *tarRewrite) apply(hdr *tar.Header) {
+	if r.chown {
+		hdr.Uid, hdr.Gid = r.uid, r.gid
+		hdr.Uname, hdr.Gname = "", ""
+	}
+	switch {
+	case hdr.Typeflag == tar.TypeDir && r.dirChmod != 0:
+		hdr.Mode = hdr.Mode&^int64(os.ModePerm) | int64(r.dirChmod&os.ModePerm)
+	case hdr.Typeflag == tar.TypeReg && r.chmod != 0:
+		hdr.Mode = hdr.Mode&^int64(os.ModePerm) | int64(r.chmod&os.ModePerm)
+	}
+	if r.renameFrom != r.renameTo {
+		hdr.Name = renameTarEntry(hdr.Name, r.renameFrom, r.renameTo)
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = renameTarEntry(hdr.Linkname, r.renameFrom, r.renameTo)
+		}
+	}
+}
+
+// renameTarEntry replaces the first element of name when it's from.
+func renameTarEntry(name, from, to string) string {
+	if name == from || strings.HasPrefix(name, from+"/") {
+		return to + name[len(from):]
+	}
+	return name
+}
+
+// copy copies the archive read from src to dst, rewriting its headers. The
+// rest of src, after the end of the archive, is discarded.
+func (r *tarRewrite) copy(dst io.Writer, src io.Reader) error {
+	tr := tar.NewReader(src)
+	tw := tar.NewWriter(dst)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		r.apply(hdr)
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err = io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	_, err := io.Copy(ioutil.Discard, src)
+	return err
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type copyTarEntry struct {
+	Name     string
+	Type     byte
+	Mode     int64
+	Uid, Gid int
+	Content  string
+}
+
+func buildCopyTar(t *testing.T, entries []copyTarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		hdr := tar.Header{
+			Name:     entry.Name,
+			Typeflag: entry.Type,
+			Mode:     entry.Mode,
+			Uid:      entry.Uid,
+			Gid:      entry.Gid,
+			Size:     int64(len(entry.Content)),
+			Uname:    "app",
+		}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(entry.Content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readCopyTar(t *testing.T, r io.Reader) []copyTarEntry {
+	var entries []copyTarEntry
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := ioutil.ReadAll(tr)
+		entries = append(entries, copyTarEntry{
+			Name:    hdr.Name,
+			Type:    hdr.Typeflag,
+			Mode:    hdr.Mode,
+			Uid:     hdr.Uid,
+			Gid:     hdr.Gid,
+			Content: string(content),
+		})
+	}
+}
+
+func TestUploadToContainerChownChmod(t *testing.T) {
+	t.Parallel()
+	archive := buildCopyTar(t, []copyTarEntry{
+		{Name: "conf", Type: tar.TypeDir, Mode: 0700, Uid: 1000, Gid: 1000},
+		{Name: "conf/app.conf", Type: tar.TypeReg, Mode: 0600, Uid: 1000, Gid: 1000, Content: "debug = true\n"},
+		{Name: "conf/current", Type: tar.TypeSymlink, Mode: 0777, Uid: 1000, Gid: 1000},
+	})
+	var query map[string][]string
+	var entries []copyTarEntry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		entries = readCopyTar(t, r.Body)
+	}))
+	defer server.Close()
+	client, _ := NewClient(server.URL)
+	err := client.UploadToContainer("web", UploadToContainerOptions{
+		InputStream: bytes.NewReader(archive),
+		Path:        "/etc",
+		Chown:       "33:33",
+		Chmod:       0640,
+		DirChmod:    0750,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedQuery := map[string][]string{"path": {"/etc"}, "copyUIDGID": {"1"}}
+	if !reflect.DeepEqual(query, expectedQuery) {
+		t.Errorf("UploadToContainer: wrong query string. Want %#v. Got %#v.", expectedQuery, query)
+	}
+	expected := []copyTarEntry{
+		{Name: "conf", Type: tar.TypeDir, Mode: 0750, Uid: 33, Gid: 33},
+		{Name: "conf/app.conf", Type: tar.TypeReg, Mode: 0640, Uid: 33, Gid: 33, Content: "debug = true\n"},
+		{Name: "conf/current", Type: tar.TypeSymlink, Mode: 0777, Uid: 33, Gid: 33},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("UploadToContainer: wrong archive. Want %#v. Got %#v.", expected, entries)
+	}
+}
+
+func TestUploadToContainerInvalidOptions(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	tests := []struct {
+		opts     UploadToContainerOptions
+		expected string
+	}{
+		{UploadToContainerOptions{Path: "/", Chown: "www-data"}, `invalid chown "www-data": must be in the form uid:gid`},
+		{UploadToContainerOptions{Path: "/", Chown: "www-data:33"}, `invalid chown "www-data:33": invalid uid`},
+		{UploadToContainerOptions{Path: "/", Chown: "33:-1"}, `invalid chown "33:-1": invalid gid`},
+		{UploadToContainerOptions{Path: "/", CopyUIDGID: true}, "copyUIDGID is only supported in API#1.31 and above"},
+	}
+	for _, tt := range tests {
+		err := client.UploadToContainer("web", tt.opts)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("UploadToContainer(%#v): wrong error. Want %q. Got %v.", tt.opts, tt.expected, err)
+		}
+	}
+	if len(fakeRT.requests) > 0 {
+		t.Errorf("UploadToContainer: unexpected requests: %d", len(fakeRT.requests))
+	}
+}
+
+func TestDownloadFromContainerFollowLink(t *testing.T) {
+	t.Parallel()
+	archive := buildCopyTar(t, []copyTarEntry{
+		{Name: "app-1.2.conf", Type: tar.TypeReg, Mode: 0644, Uid: 1000, Gid: 1000, Content: "debug = true\n"},
+	})
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Query().Get("path"))
+		if r.Method == http.MethodHead {
+			stat, _ := json.Marshal(ContainerPathStat{
+				Name:       "app.conf",
+				Mode:       os.ModeSymlink | 0777,
+				LinkTarget: "/etc/app/app-1.2.conf",
+			})
+			w.Header().Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(stat))
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+	client, _ := NewClient(server.URL)
+	var out bytes.Buffer
+	err := client.DownloadFromContainer("web", DownloadFromContainerOptions{
+		OutputStream: &out,
+		Path:         "/etc/app.conf",
+		FollowLink:   true,
+		Chown:        "0:0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPaths := []string{"HEAD /etc/app.conf", "GET /etc/app/app-1.2.conf"}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("DownloadFromContainer: wrong requests. Want %#v. Got %#v.", expectedPaths, paths)
+	}
+	expected := []copyTarEntry{
+		{Name: "app.conf", Type: tar.TypeReg, Mode: 0644, Content: "debug = true\n"},
+	}
+	if entries := readCopyTar(t, &out); !reflect.DeepEqual(entries, expected) {
+		t.Errorf("DownloadFromContainer: wrong archive. Want %#v. Got %#v.", expected, entries)
+	}
+}
+
+func TestStatContainerPathNotFound(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "not found", status: http.StatusNotFound})
+	_, err := client.StatContainerPath(context.Background(), "web", "/missing")
+	if e, ok := err.(*NoSuchContainer); !ok || e.ID != "web" {
+		t.Errorf("StatContainerPath: wrong error. Want *NoSuchContainer. Got %#v.", err)
+	}
+}
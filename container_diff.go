@@ -0,0 +1,230 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abrechon/go-dockerclient/reference"
+)
+
+// ConfigDifference represents a difference between the desired configuration
+// of a container and the configuration of an existing container.
+//
+// Field is one of "Image", "Env", "Mounts", "Ports" or "Labels", and Key
+// identifies the item that differs within the field (the name of the
+// environment variable, the mount destination, the container port or the
+// label). Missing values are represented by empty strings.
+type ConfigDifference struct {
+	Field   string
+	Key     string
+	Desired string
+	Actual  string
+}
+
+func (d ConfigDifference) String() string {
+	if d.Key == "" {
+		return fmt.Sprintf("%s: %q != %q", d.Field, d.Desired, d.Actual)
+	}
+	return fmt.Sprintf("%s[%s]: %q != %q", d.Field, d.Key, d.Desired, d.Actual)
+}
+
+// DiffContainerConfig compares the options used to create a container with
+// an existing (inspected) container, returning the list of differences in the
+// image, environment, mounts, port bindings and labels. An empty list means
+// that the container doesn't need to be recreated.
+//
+// Values populated by the daemon are normalized before the comparison:
+// labels inherited from the image, anonymous volumes declared by the image,
+// the implicit "latest" tag, the default "tcp" protocol and the "0.0.0.0"
+// host IP are not reported as differences.
+//
+// Environment variables are compared in both directions: variables of the
+// existing container that are not in the desired configuration are reported,
+// unless they were inherited from image, the inspected image of the existing
+// container. When image is nil, all of them are reported.
+func DiffContainerConfig(desired CreateContainerOptions, actual *Container, image *Image) []ConfigDifference {
+	var desiredConfig, actualConfig Config
+	if desired.Config != nil {
+		desiredConfig = *desired.Config
+	}
+	if actual.Config != nil {
+		actualConfig = *actual.Config
+	}
+	var desiredHostConfig, actualHostConfig HostConfig
+	if desired.HostConfig != nil {
+		desiredHostConfig = *desired.HostConfig
+	}
+	if actual.HostConfig != nil {
+		actualHostConfig = *actual.HostConfig
+	}
+	var diffs []ConfigDifference
+	if normalizeImageName(desiredConfig.Image) != normalizeImageName(actualConfig.Image) {
+		diffs = append(diffs, ConfigDifference{Field: "Image", Desired: desiredConfig.Image, Actual: actualConfig.Image})
+	}
+	var imageEnv []string
+	if image != nil && image.Config != nil {
+		imageEnv = image.Config.Env
+	}
+	diffs = append(diffs, diffEnv(envMap(desiredConfig.Env), envMap(actualConfig.Env), envMap(imageEnv))...)
+	diffs = append(diffs, diffMounts(&desiredHostConfig, actual.Mounts, actualConfig.Volumes)...)
+	diffs = append(diffs, diffPorts(desiredHostConfig.PortBindings, actualHostConfig.PortBindings)...)
+	desiredLabels := make(map[string]string, len(desiredConfig.Labels))
+	for k, v := range desiredConfig.Labels {
+		if k != ConfigHashLabel {
+			desiredLabels[k] = v
+		}
+	}
+	diffs = append(diffs, diffSubset("Labels", desiredLabels, actualConfig.Labels)...)
+	return diffs
+}
+
+func normalizeImageName(image string) string {
+	if normalized, err := reference.Normalize(image); err == nil {
+		return normalized
+	}
+	return image
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 1 {
+			m[parts[0]] = ""
+		} else {
+			m[parts[0]] = parts[1]
+		}
+	}
+	return m
+}
+
+// diffSubset reports the keys in desired that are missing or have a different
+// value in actual. Extra keys in actual are ignored, as they may have been
+// inherited from the image.
+func diffSubset(field string, desired, actual map[string]string) []ConfigDifference {
+	var diffs []ConfigDifference
+	for _, key := range sortedKeys(desired) {
+		if value, ok := actual[key]; !ok || value != desired[key] {
+			diffs = append(diffs, ConfigDifference{Field: field, Key: key, Desired: desired[key], Actual: value})
+		}
+	}
+	return diffs
+}
+
+// diffEnv reports the differences between the desired and the actual
+// environment, in both directions. Variables in actual that aren't desired
+// are ignored when they have the value set in the image.
+func diffEnv(desired, actual, image map[string]string) []ConfigDifference {
+	diffs := diffSubset("Env", desired, actual)
+	for _, key := range sortedKeys(actual) {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if value, ok := image[key]; ok && value == actual[key] {
+			continue
+		}
+		diffs = append(diffs, ConfigDifference{Field: "Env", Key: key, Actual: actual[key]})
+	}
+	return diffs
+}
+
+func diffMounts(desired *HostConfig, actual []Mount, imageVolumes map[string]struct{}) []ConfigDifference {
+	desiredMounts := make(map[string]string)
+	for _, bind := range desired.Binds {
+		source, destination, options, ok := splitBind(bind)
+		if !ok {
+			continue
+		}
+		mode := "rw"
+		if hasOption(options, "ro") {
+			mode = "ro"
+		}
+		desiredMounts[destination] = source + ":" + mode
+	}
+	for _, m := range desired.Mounts {
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		desiredMounts[m.Target] = m.Source + ":" + mode
+	}
+	actualMounts := make(map[string]string, len(actual))
+	for _, m := range actual {
+		source := m.Source
+		if m.Name != "" {
+			source = m.Name
+		}
+		mode := "rw"
+		if !m.RW {
+			mode = "ro"
+		}
+		actualMounts[m.Destination] = source + ":" + mode
+	}
+	diffs := diffSubset("Mounts", desiredMounts, actualMounts)
+	for _, destination := range sortedKeys(actualMounts) {
+		if _, ok := desiredMounts[destination]; ok {
+			continue
+		}
+		if _, ok := imageVolumes[destination]; ok {
+			continue
+		}
+		diffs = append(diffs, ConfigDifference{Field: "Mounts", Key: destination, Actual: actualMounts[destination]})
+	}
+	return diffs
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func diffPorts(desired, actual map[Port][]PortBinding) []ConfigDifference {
+	desiredPorts := normalizePortBindings(desired)
+	actualPorts := normalizePortBindings(actual)
+	diffs := diffSubset("Ports", desiredPorts, actualPorts)
+	for _, port := range sortedKeys(actualPorts) {
+		if _, ok := desiredPorts[port]; !ok {
+			diffs = append(diffs, ConfigDifference{Field: "Ports", Key: port, Actual: actualPorts[port]})
+		}
+	}
+	return diffs
+}
+
+func normalizePortBindings(bindings map[Port][]PortBinding) map[string]string {
+	m := make(map[string]string, len(bindings))
+	for port, portBindings := range bindings {
+		key := string(port)
+		if !strings.Contains(key, "/") {
+			key += "/tcp"
+		}
+		values := make([]string, len(portBindings))
+		for i, binding := range portBindings {
+			hostIP := binding.HostIP
+			if hostIP == "" {
+				hostIP = "0.0.0.0"
+			}
+			values[i] = hostIP + ":" + binding.HostPort
+		}
+		sort.Strings(values)
+		m[key] = strings.Join(values, ",")
+	}
+	return m
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffContainerConfigNoDifferences(t *testing.T) {
+	t.Parallel()
+	desired := CreateContainerOptions{
+		Config: &Config{
+			Image:  "nginx",
+			Env:    []string{"APP=web"},
+			Labels: map[string]string{"app": "web", ConfigHashLabel: "abc"},
+		},
+		HostConfig: &HostConfig{
+			Binds:        []string{"/srv/www:/usr/share/nginx/html:ro"},
+			Mounts:       []HostMount{{Type: "volume", Source: "cache", Target: "/var/cache/nginx"}},
+			PortBindings: map[Port][]PortBinding{"80": {{HostPort: "8080"}}},
+		},
+	}
+	actual := &Container{
+		Config: &Config{
+			Image:   "nginx:latest",
+			Env:     []string{"PATH=/usr/local/sbin:/usr/local/bin", "APP=web", "NGINX_VERSION=1.17.0"},
+			Labels:  map[string]string{"app": "web", "maintainer": "NGINX"},
+			Volumes: map[string]struct{}{"/data": {}},
+		},
+		HostConfig: &HostConfig{
+			PortBindings: map[Port][]PortBinding{"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}}},
+		},
+		Mounts: []Mount{
+			{Source: "/srv/www", Destination: "/usr/share/nginx/html", RW: false},
+			{Name: "cache", Source: "/var/lib/docker/volumes/cache/_data", Destination: "/var/cache/nginx", RW: true},
+			{Name: "0123abcd", Source: "/var/lib/docker/volumes/0123abcd/_data", Destination: "/data", RW: true},
+		},
+	}
+	image := &Image{
+		Config: &Config{Env: []string{"PATH=/usr/local/sbin:/usr/local/bin", "NGINX_VERSION=1.17.0"}},
+	}
+	if diffs := DiffContainerConfig(desired, actual, image); len(diffs) != 0 {
+		t.Errorf("DiffContainerConfig: unexpected differences: %v", diffs)
+	}
+}
+
+func TestDiffContainerConfig(t *testing.T) {
+	t.Parallel()
+	desired := CreateContainerOptions{
+		Config: &Config{
+			Image:  "nginx:1.17",
+			Env:    []string{"APP=web", "DEBUG=1"},
+			Labels: map[string]string{"app": "web"},
+		},
+		HostConfig: &HostConfig{
+			Binds:        []string{"/srv/www:/usr/share/nginx/html"},
+			PortBindings: map[Port][]PortBinding{"80/tcp": {{HostPort: "8080"}}},
+		},
+	}
+	actual := &Container{
+		Config: &Config{
+			Image:  "nginx:1.16",
+			Env:    []string{"APP=api", "NGINX_VERSION=1.16.1", "OLD=1", "PATH=/usr/bin"},
+			Labels: map[string]string{"app": "web"},
+		},
+		HostConfig: &HostConfig{
+			PortBindings: map[Port][]PortBinding{
+				"80/tcp":  {{HostIP: "127.0.0.1", HostPort: "8080"}},
+				"443/tcp": {{HostPort: "8443"}},
+			},
+		},
+		Mounts: []Mount{
+			{Source: "/srv/www", Destination: "/usr/share/nginx/html", RW: false},
+			{Source: "/tmp", Destination: "/tmp", RW: true},
+		},
+	}
+	image := &Image{
+		Config: &Config{Env: []string{"NGINX_VERSION=1.16.1", "PATH=/usr/local/bin:/usr/bin"}},
+	}
+	expected := []ConfigDifference{
+		{Field: "Image", Desired: "nginx:1.17", Actual: "nginx:1.16"},
+		{Field: "Env", Key: "APP", Desired: "web", Actual: "api"},
+		{Field: "Env", Key: "DEBUG", Desired: "1"},
+		{Field: "Env", Key: "OLD", Actual: "1"},
+		{Field: "Env", Key: "PATH", Actual: "/usr/bin"},
+		{Field: "Mounts", Key: "/usr/share/nginx/html", Desired: "/srv/www:rw", Actual: "/srv/www:ro"},
+		{Field: "Mounts", Key: "/tmp", Actual: "/tmp:rw"},
+		{Field: "Ports", Key: "80/tcp", Desired: "0.0.0.0:8080", Actual: "127.0.0.1:8080"},
+		{Field: "Ports", Key: "443/tcp", Actual: "0.0.0.0:8443"},
+	}
+	diffs := DiffContainerConfig(desired, actual, image)
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("DiffContainerConfig: wrong differences.\nWant %v.\nGot  %v.", expected, diffs)
+	}
+}
+
+func TestDiffContainerConfigWithoutImage(t *testing.T) {
+	t.Parallel()
+	desired := CreateContainerOptions{Config: &Config{Image: "nginx", Env: []string{"APP=web"}}}
+	actual := &Container{Config: &Config{Image: "nginx", Env: []string{"APP=web", "PATH=/usr/bin"}}}
+	expected := []ConfigDifference{{Field: "Env", Key: "PATH", Actual: "/usr/bin"}}
+	diffs := DiffContainerConfig(desired, actual, nil)
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("DiffContainerConfig: wrong differences.\nWant %v.\nGot  %v.", expected, diffs)
+	}
+}
+
+func TestDiffContainerConfigWindowsBinds(t *testing.T) {
+	t.Parallel()
+	desired := CreateContainerOptions{
+		Config: &Config{Image: "app"},
+		HostConfig: &HostConfig{
+			Binds: []string{`C:\data:/data:ro`, `D:\logs:C:\logs`},
+		},
+	}
+	actual := &Container{
+		Config: &Config{Image: "app"},
+		Mounts: []Mount{
+			{Source: `C:\data`, Destination: "/data", RW: false},
+			{Source: `D:\logs`, Destination: `C:\logs`, RW: true},
+		},
+	}
+	if diffs := DiffContainerConfig(desired, actual, nil); len(diffs) != 0 {
+		t.Errorf("DiffContainerConfig: unexpected differences: %v", diffs)
+	}
+}
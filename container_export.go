@@ -0,0 +1,168 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultExportProgressInterval = time.Second
+
+// ExportProgress is an update of the progress of ExportContainerWithProgress.
+type ExportProgress struct {
+	// Written is the number of bytes of the archive exported so far, before
+	// compression.
+	Written int64
+
+	// Total is the estimated size of the archive, before compression, from
+	// the size of the filesystem of the container. It's zero when unknown,
+	// and it grows with Written when the estimate is exceeded.
+	Total int64
+
+	// BytesPerSecond is the average rate of the export, and ETA the
+	// estimated time to complete it, zero when unknown.
+	BytesPerSecond float64
+	ETA            time.Duration
+}
+
+// ExportContainerProgressOptions is the set of parameters to the
+// ExportContainerWithProgress method.
+type ExportContainerProgressOptions struct {
+	ID           string
+	OutputStream io.Writer
+
+	// Progress receives the progress of the export, every ProgressInterval
+	// (one second by default), and once at the end.
+	Progress         func(ExportProgress)
+	ProgressInterval time.Duration
+
+	// Compress compresses the archive with gzip, with the given
+	// CompressionLevel, or gzip.DefaultCompression when zero.
+	Compress         bool
+	CompressionLevel int
+
+	InactivityTimeout time.Duration
+	Context           context.Context
+}
+
+// ExportContainerWithProgress exports the contents of a container as a tar
+// archive, like ExportContainer, reporting the progress of the export.
+//
+// The container is inspected first with its size, to estimate the size of
+// the archive and the time remaining. The estimate doesn't account for the
+// headers of the archive, so Total may be exceeded slightly.
+func (c *Client) ExportContainerWithProgress(opts ExportContainerProgressOptions) error {
+	if opts.ID == "" {
+		return &NoSuchContainer{ID: opts.ID}
+	}
+	total, err := c.containerSizeRootFs(opts.Context, opts.ID)
+	if err != nil {
+		return err
+	}
+	out := opts.OutputStream
+	var gz *gzip.Writer
+	if opts.Compress {
+		level := opts.CompressionLevel
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		if gz, err = gzip.NewWriterLevel(out, level); err != nil {
+			return err
+		}
+		out = gz
+	}
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = defaultExportProgressInterval
+	}
+	w := exportProgressWriter{
+		w:        out,
+		fn:       opts.Progress,
+		interval: interval,
+		total:    total,
+		start:    time.Now(),
+	}
+	w.last = w.start
+	err = c.ExportContainer(ExportContainerOptions{
+		ID:                opts.ID,
+		OutputStream:      &w,
+		InactivityTimeout: opts.InactivityTimeout,
+		Context:           opts.Context,
+	})
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		return err
+	}
+	w.report(true)
+	return nil
+}
+
+// containerSizeRootFs returns the size of the filesystem of the container,
+// inspecting it with its size.
+func (c *Client) containerSizeRootFs(ctx context.Context, id string) (int64, error) {
+	resp, err := c.do("GET", "/containers/"+id+"/json?size=1", doOptions{context: ctx})
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
+			return 0, &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var container Container
+	if err := json.NewDecoder(resp.Body).Decode(&container); err != nil {
+		return 0, err
+	}
+	return container.SizeRootFs, nil
+}
+
+// exportProgressWriter counts the bytes written to w, reporting the progress
+// to fn every interval.
+type exportProgressWriter struct {
+	w        io.Writer
+	fn       func(ExportProgress)
+	interval time.Duration
+	total    int64
+
+	written int64
+	start   time.Time
+	last    time.Time
+}
+
+func (w *exportProgressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	w.report(false)
+	return n, err
+}
+
+func (w *exportProgressWriter) report(final bool) {
+	if w.fn == nil {
+		return
+	}
+	now := time.Now()
+	if !final && now.Sub(w.last) < w.interval {
+		return
+	}
+	w.last = now
+	progress := ExportProgress{Written: w.written, Total: w.total}
+	if progress.Total > 0 && progress.Written > progress.Total {
+		progress.Total = progress.Written
+	}
+	if elapsed := now.Sub(w.start); elapsed > 0 {
+		progress.BytesPerSecond = float64(progress.Written) / elapsed.Seconds()
+	}
+	if progress.Total > 0 && progress.BytesPerSecond > 0 {
+		remaining := float64(progress.Total - progress.Written)
+		progress.ETA = time.Duration(remaining / progress.BytesPerSecond * float64(time.Second))
+	}
+	w.fn(progress)
+}
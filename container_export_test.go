@@ -0,0 +1,128 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newExportHandler(sizeRootFs int64, archive []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/4fa6e0f0/json":
+			if r.URL.Query().Get("size") != "1" {
+				http.Error(w, "size not requested", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"Id":"4fa6e0f0","SizeRw":12,"SizeRootFs":` + strconv.FormatInt(sizeRootFs, 10) + `}`))
+		case "/containers/4fa6e0f0/export":
+			for i := 0; i < len(archive); i += 1024 {
+				end := i + 1024
+				if end > len(archive) {
+					end = len(archive)
+				}
+				w.Write(archive[i:end])
+				w.(http.Flusher).Flush()
+				time.Sleep(time.Millisecond)
+			}
+		default:
+			http.Error(w, "no such container", http.StatusNotFound)
+		}
+	})
+}
+
+func TestExportContainerWithProgress(t *testing.T) {
+	t.Parallel()
+	archive := bytes.Repeat([]byte("layer"), 4096)
+	client := NewClientFromHandler(newExportHandler(int64(len(archive))/2, archive))
+	var buf bytes.Buffer
+	var progress []ExportProgress
+	err := client.ExportContainerWithProgress(ExportContainerProgressOptions{
+		ID:               "4fa6e0f0",
+		OutputStream:     &buf,
+		ProgressInterval: time.Millisecond,
+		Progress: func(p ExportProgress) {
+			progress = append(progress, p)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), archive) {
+		t.Errorf("ExportContainerWithProgress: wrong archive. Want %d bytes. Got %d.", len(archive), buf.Len())
+	}
+	if len(progress) < 2 {
+		t.Fatalf("ExportContainerWithProgress: want several progress updates. Got %#v.", progress)
+	}
+	first := progress[0]
+	if first.Total != int64(len(archive))/2 || first.Written == 0 || first.BytesPerSecond <= 0 || first.ETA <= 0 {
+		t.Errorf("ExportContainerWithProgress: wrong first progress: %#v", first)
+	}
+	last := progress[len(progress)-1]
+	if last.Written != int64(len(archive)) || last.Total != last.Written || last.ETA != 0 {
+		t.Errorf("ExportContainerWithProgress: wrong last progress: %#v", last)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i].Written < progress[i-1].Written {
+			t.Errorf("ExportContainerWithProgress: progress went backwards: %#v", progress)
+			break
+		}
+	}
+}
+
+func TestExportContainerWithProgressCompress(t *testing.T) {
+	t.Parallel()
+	archive := bytes.Repeat([]byte("layer"), 4096)
+	client := NewClientFromHandler(newExportHandler(0, archive))
+	var buf bytes.Buffer
+	var last ExportProgress
+	err := client.ExportContainerWithProgress(ExportContainerProgressOptions{
+		ID:               "4fa6e0f0",
+		OutputStream:     &buf,
+		Compress:         true,
+		CompressionLevel: gzip.BestSpeed,
+		Progress: func(p ExportProgress) {
+			last = p
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() >= len(archive) {
+		t.Errorf("ExportContainerWithProgress: archive not compressed: %d bytes", buf.Len())
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, archive) {
+		t.Errorf("ExportContainerWithProgress: wrong archive. Want %d bytes. Got %d.", len(archive), len(data))
+	}
+	if last.Written != int64(len(archive)) || last.Total != 0 || last.ETA != 0 {
+		t.Errorf("ExportContainerWithProgress: wrong last progress: %#v", last)
+	}
+}
+
+func TestExportContainerWithProgressNotFound(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newExportHandler(0, nil))
+	err := client.ExportContainerWithProgress(ExportContainerProgressOptions{ID: "abef348", OutputStream: ioutil.Discard})
+	expected := &NoSuchContainer{ID: "abef348"}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("ExportContainerWithProgress: wrong error. Want %#v. Got %#v.", expected, err)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ConfigHashLabel is the label used by CreateContainerIdempotent to store the
+// hash of the configuration a container was created with.
+const ConfigHashLabel = "io.go-dockerclient.config-hash"
+
+// ContainerConfigDrift is the error returned by CreateContainerIdempotent when
+// a container with the requested name already exists, but was created with a
+// different configuration.
+type ContainerConfigDrift struct {
+	ID           string
+	Name         string
+	ExpectedHash string
+	ActualHash   string
+}
+
+func (err *ContainerConfigDrift) Error() string {
+	return fmt.Sprintf("container %s already exists with a different configuration (hash %q, expected %q)", err.Name, err.ActualHash, err.ExpectedHash)
+}
+
+// DefaultHashExclusions is the list of fields that ContainerConfigHash leaves
+// out of the hash. These fields are either volatile or set by the tooling
+// managing the container, rather than part of its specification.
+var DefaultHashExclusions = []string{
+	"Config.Labels." + ConfigHashLabel,
+	"Config.Hostname",
+	"Config.MacAddress",
+	"HostConfig.ContainerIDFile",
+}
+
+// ContainerConfigHash returns a hash of the configuration in the given
+// options (Config, HostConfig and NetworkingConfig), excluding the fields in
+// DefaultHashExclusions. The name of the container is not part of the hash.
+func ContainerConfigHash(opts CreateContainerOptions) (string, error) {
+	return HashContainerSpec(opts, DefaultHashExclusions...)
+}
+
+// HashContainerSpec returns a stable hash of the configuration in the given
+// options (Config, HostConfig and NetworkingConfig), suitable to be stored in
+// a label and compared later to decide whether a container must be recreated.
+//
+// The hash doesn't depend on the order of map keys, and nulls and empty
+// collections are ignored, so that a nil and an empty slice or map produce
+// the same hash. Zero values are part of the hash, as an explicit false or
+// zero may differ from an unset field. Fields listed in exclude are left out of the
+// hash. They're identified by their dot-separated path in the JSON
+// representation of the options, for example "HostConfig.Memory" or
+// "Config.Labels.com.example.build-id".
+func HashContainerSpec(opts CreateContainerOptions, exclude ...string) (string, error) {
+	data, err := json.Marshal(struct {
+		Config           *Config
+		HostConfig       *HostConfig
+		NetworkingConfig *NetworkingConfig
+	}{opts.Config, opts.HostConfig, opts.NetworkingConfig})
+	if err != nil {
+		return "", err
+	}
+	var spec map[string]interface{}
+	if err = json.Unmarshal(data, &spec); err != nil {
+		return "", err
+	}
+	for _, path := range exclude {
+		removePath(spec, path)
+	}
+	data, err = json.Marshal(pruneEmpty(spec))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// removePath removes the value identified by the given dot-separated path.
+// Map keys may contain dots themselves (as label names usually do), so the
+// longest key matching the path is used at each level.
+func removePath(m map[string]interface{}, path string) {
+	if _, ok := m[path]; ok {
+		delete(m, path)
+		return
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] != '.' {
+			continue
+		}
+		if child, ok := m[path[:i]].(map[string]interface{}); ok {
+			removePath(child, path[i+1:])
+			return
+		}
+	}
+}
+
+// pruneEmpty returns the given value without nulls, empty maps and empty
+// slices. Zero values, such as false booleans, are kept, as they may differ
+// from unset fields, e.g. for pointers.
+func pruneEmpty(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item = pruneEmpty(item); item == nil {
+				delete(v, key)
+			} else {
+				v[key] = item
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+// CreateContainerIdempotent creates a container labeled with the hash of its
+// configuration (see ContainerConfigHash and ConfigHashLabel).
+//
+// When a container with the same name already exists, it's returned if it has
+// the same configuration hash. Otherwise, CreateContainerIdempotent returns an
+// error of type *ContainerConfigDrift. Calling it multiple times with the same
+// options is therefore safe, which makes the container name a reservation.
+func (c *Client) CreateContainerIdempotent(opts CreateContainerOptions) (*Container, error) {
+	hash, err := ContainerConfigHash(opts)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if opts.Config != nil {
+		config = *opts.Config
+	}
+	labels := make(map[string]string, len(config.Labels)+1)
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+	labels[ConfigHashLabel] = hash
+	config.Labels = labels
+	opts.Config = &config
+	container, err := c.CreateContainer(opts)
+	if err != ErrContainerAlreadyExists || opts.Name == "" {
+		return container, err
+	}
+	existing, err := c.InspectContainerWithContext(opts.Name, opts.Context)
+	if err != nil {
+		return nil, err
+	}
+	var actual string
+	if existing.Config != nil {
+		actual = existing.Config.Labels[ConfigHashLabel]
+	}
+	if actual != hash {
+		return nil, &ContainerConfigDrift{ID: existing.ID, Name: opts.Name, ExpectedHash: hash, ActualHash: actual}
+	}
+	return existing, nil
+}
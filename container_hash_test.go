@@ -0,0 +1,152 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContainerConfigHash(t *testing.T) {
+	t.Parallel()
+	opts := CreateContainerOptions{
+		Name:       "web",
+		Config:     &Config{Image: "nginx", Labels: map[string]string{"app": "web"}},
+		HostConfig: &HostConfig{Memory: 1024},
+	}
+	hash, err := ContainerConfigHash(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed := opts
+	renamed.Name = "other"
+	renamed.Config = &Config{Image: "nginx", Labels: map[string]string{"app": "web", ConfigHashLabel: "abc"}}
+	if other, _ := ContainerConfigHash(renamed); other != hash {
+		t.Errorf("ContainerConfigHash: name and hash label should not change the hash. Want %q. Got %q.", hash, other)
+	}
+	changed := opts
+	changed.HostConfig = &HostConfig{Memory: 2048}
+	if other, _ := ContainerConfigHash(changed); other == hash {
+		t.Error("ContainerConfigHash: different configurations should have different hashes")
+	}
+}
+
+func TestHashContainerSpecStable(t *testing.T) {
+	t.Parallel()
+	opts := CreateContainerOptions{
+		Config:     &Config{Image: "nginx", Labels: map[string]string{"a": "1", "b": "2"}},
+		HostConfig: &HostConfig{Binds: []string{}},
+	}
+	hash, err := HashContainerSpec(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equivalent := CreateContainerOptions{
+		Config:           &Config{Image: "nginx", Labels: map[string]string{"b": "2", "a": "1"}, Env: []string{}},
+		HostConfig:       &HostConfig{},
+		NetworkingConfig: &NetworkingConfig{},
+	}
+	if other, _ := HashContainerSpec(equivalent); other != hash {
+		t.Errorf("HashContainerSpec: equivalent specs should have the same hash. Want %q. Got %q.", hash, other)
+	}
+	if other, _ := HashContainerSpec(opts); other != hash {
+		t.Errorf("HashContainerSpec: hash is not stable. Want %q. Got %q.", hash, other)
+	}
+	disabled := false
+	different := CreateContainerOptions{
+		Config:     &Config{Image: "nginx", Labels: map[string]string{"a": "1", "b": "2"}},
+		HostConfig: &HostConfig{OOMKillDisable: &disabled},
+	}
+	if other, _ := HashContainerSpec(different); other == hash {
+		t.Error("HashContainerSpec: an explicit false should not hash as an unset field")
+	}
+}
+
+func TestHashContainerSpecExclusions(t *testing.T) {
+	t.Parallel()
+	opts := CreateContainerOptions{
+		Config:     &Config{Image: "nginx", Labels: map[string]string{"com.example.build-id": "1"}},
+		HostConfig: &HostConfig{Memory: 1024},
+	}
+	volatile := CreateContainerOptions{
+		Config:     &Config{Image: "nginx", Labels: map[string]string{"com.example.build-id": "2"}},
+		HostConfig: &HostConfig{Memory: 2048},
+	}
+	exclude := []string{"Config.Labels.com.example.build-id", "HostConfig.Memory"}
+	hash, err := HashContainerSpec(opts, exclude...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := HashContainerSpec(volatile, exclude...); other != hash {
+		t.Errorf("HashContainerSpec: excluded fields should not change the hash. Want %q. Got %q.", hash, other)
+	}
+	if other, _ := HashContainerSpec(volatile); other == hash {
+		t.Error("HashContainerSpec: fields that are not excluded should change the hash")
+	}
+}
+
+func TestCreateContainerIdempotent(t *testing.T) {
+	t.Parallel()
+	opts := CreateContainerOptions{Name: "web", Config: &Config{Image: "nginx"}}
+	hash, _ := ContainerConfigHash(opts)
+	var existingHash string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/create":
+			var config Config
+			json.NewDecoder(r.Body).Decode(&config)
+			if config.Labels[ConfigHashLabel] != hash {
+				http.Error(w, "missing hash label", http.StatusBadRequest)
+				return
+			}
+			if existingHash != "" {
+				http.Error(w, "Conflict. The name is already in use", http.StatusConflict)
+				return
+			}
+			w.Write([]byte(`{"Id":"new-container"}`))
+		case "/containers/web/json":
+			json.NewEncoder(w).Encode(Container{ID: "existing-container", Config: &Config{Labels: map[string]string{ConfigHashLabel: existingHash}}})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container, err := client.CreateContainerIdempotent(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.ID != "new-container" {
+		t.Errorf("CreateContainerIdempotent: wrong container. Want %q. Got %q.", "new-container", container.ID)
+	}
+	if opts.Config.Labels != nil {
+		t.Errorf("CreateContainerIdempotent: should not modify the given options, got labels %v", opts.Config.Labels)
+	}
+
+	existingHash = hash
+	container, err = client.CreateContainerIdempotent(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.ID != "existing-container" {
+		t.Errorf("CreateContainerIdempotent: wrong container. Want %q. Got %q.", "existing-container", container.ID)
+	}
+
+	existingHash = "outdated"
+	_, err = client.CreateContainerIdempotent(opts)
+	drift, ok := err.(*ContainerConfigDrift)
+	if !ok {
+		t.Fatalf("CreateContainerIdempotent: wrong error. Want *ContainerConfigDrift. Got %#v.", err)
+	}
+	expected := ContainerConfigDrift{ID: "existing-container", Name: "web", ExpectedHash: hash, ActualHash: "outdated"}
+	if *drift != expected {
+		t.Errorf("CreateContainerIdempotent: wrong error.\nWant %#v.\nGot %#v.", expected, *drift)
+	}
+}
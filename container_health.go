@@ -0,0 +1,149 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrNoHealthcheck is the error returned by WatchHealth when the container
+// doesn't have a healthcheck.
+var ErrNoHealthcheck = errors.New("container has no healthcheck")
+
+const healthStatusAction = "health_status"
+
+// HealthTransition is a change in the health of a container, as sent by
+// WatchHealth.
+type HealthTransition struct {
+	ContainerID string
+
+	// Status is "starting", "healthy" or "unhealthy".
+	Status        string
+	FailingStreak int
+
+	// Probe is the most recent run of the healthcheck, with its output. It's
+	// nil when the container couldn't be inspected, or no probe has run yet.
+	Probe *HealthCheck
+
+	Time time.Time
+
+	// Err is set in the last value sent before the channel is closed when
+	// watching fails.
+	Err error
+}
+
+// WatchHealth watches the health of the container identified by the given ID
+// or name, returning a channel that receives its current health and then
+// every transition, until the context is done or the container stops, when
+// the channel is closed.
+//
+// Transitions are detected through the health_status events of the daemon,
+// resuming the stream after daemon restarts. The container is inspected on
+// each transition to get the output of the probe, falling back to the status
+// reported by the event when the inspection fails.
+func (c *Client) WatchHealth(ctx context.Context, id string) (<-chan HealthTransition, error) {
+	container, err := c.InspectContainerWithContext(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if container.State.Health.Status == "" {
+		return nil, ErrNoHealthcheck
+	}
+	transitions := make(chan HealthTransition, 1)
+	current := newHealthTransition(container)
+	transitions <- current
+	if !container.State.Running {
+		close(transitions)
+		return transitions, nil
+	}
+	go c.watchHealth(ctx, container.ID, current.Status, transitions)
+	return transitions, nil
+}
+
+func (c *Client) watchHealth(ctx context.Context, id, status string, transitions chan<- HealthTransition) {
+	defer close(transitions)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := make(chan *APIEvents)
+	eventsErr := make(chan error, 1)
+	go func(since int64) {
+		eventsErr <- c.NewStreamSupervisor().Events(ctx, EventsOptions{
+			Since: since,
+			Filters: map[string][]string{
+				"type":      {"container"},
+				"container": {id},
+				"event":     {healthStatusAction, "die", "destroy"},
+			},
+		}, events)
+	}(time.Now().Unix())
+	send := func(transition HealthTransition) bool {
+		select {
+		case transitions <- transition:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-eventsErr:
+			if ctx.Err() == nil {
+				send(HealthTransition{ContainerID: id, Status: status, Time: time.Now(), Err: err})
+			}
+			return
+		case event := <-events:
+			if !strings.HasPrefix(event.Action, healthStatusAction) {
+				// the container stopped.
+				return
+			}
+			transition := c.inspectHealth(ctx, id, event)
+			if transition.Status == status {
+				continue
+			}
+			status = transition.Status
+			if !send(transition) {
+				return
+			}
+		}
+	}
+}
+
+// inspectHealth returns the transition reported by the given health_status
+// event, with the details of the inspected container when available.
+func (c *Client) inspectHealth(ctx context.Context, id string, event *APIEvents) HealthTransition {
+	status := strings.TrimSpace(strings.TrimPrefix(event.Action, healthStatusAction+":"))
+	container, err := c.InspectContainerWithContext(id, ctx)
+	if err == nil && container.State.Health.Status == status {
+		return newHealthTransition(container)
+	}
+	transition := HealthTransition{ContainerID: id, Status: status}
+	if event.TimeNano != 0 {
+		transition.Time = time.Unix(0, event.TimeNano)
+	} else {
+		transition.Time = time.Unix(event.Time, 0)
+	}
+	return transition
+}
+
+func newHealthTransition(container *Container) HealthTransition {
+	health := container.State.Health
+	transition := HealthTransition{
+		ContainerID:   container.ID,
+		Status:        health.Status,
+		FailingStreak: health.FailingStreak,
+		Time:          time.Now(),
+	}
+	if len(health.Log) > 0 {
+		probe := health.Log[len(health.Log)-1]
+		transition.Probe = &probe
+		transition.Time = probe.End
+	}
+	return transition
+}
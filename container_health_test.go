@@ -0,0 +1,180 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// healthDaemon serves the inspection of container c1 with a configurable
+// state, and streams the events sent to its channel.
+type healthDaemon struct {
+	mu          sync.Mutex
+	state       State
+	inspectFail bool
+	events      chan string
+}
+
+func (d *healthDaemon) setState(state State, inspectFail bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state = state
+	d.inspectFail = inspectFail
+}
+
+func (d *healthDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/_ping":
+		w.WriteHeader(http.StatusOK)
+	case "/containers/web/json", "/containers/c1/json":
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.inspectFail {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(Container{ID: "c1", State: d.state})
+	case "/events":
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case event := <-d.events:
+				w.Write([]byte(event))
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func receiveTransition(t *testing.T, transitions <-chan HealthTransition) (HealthTransition, bool) {
+	t.Helper()
+	select {
+	case transition, ok := <-transitions:
+		return transition, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("WatchHealth: timed out waiting for a transition")
+		return HealthTransition{}, false
+	}
+}
+
+func TestWatchHealth(t *testing.T) {
+	t.Parallel()
+	probeEnd := time.Date(2019, 3, 1, 10, 0, 5, 0, time.UTC)
+	daemon := &healthDaemon{
+		state:  State{Running: true, Health: Health{Status: "starting"}},
+		events: make(chan string),
+	}
+	server := httptest.NewServer(daemon)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transitions, err := client.WatchHealth(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transition, _ := receiveTransition(t, transitions)
+	if transition.ContainerID != "c1" || transition.Status != "starting" || transition.Probe != nil {
+		t.Errorf("WatchHealth: wrong initial transition: %#v", transition)
+	}
+
+	daemon.setState(State{Running: true, Health: Health{
+		Status: "healthy",
+		Log:    []HealthCheck{{End: probeEnd, Output: "ok\n"}},
+	}}, false)
+	daemon.events <- `{"Type": "container", "Action": "health_status: healthy", "Actor": {"ID": "c1"}, "time": 1}`
+	transition, _ = receiveTransition(t, transitions)
+	if transition.Status != "healthy" || transition.Probe == nil || transition.Probe.Output != "ok\n" || !transition.Time.Equal(probeEnd) {
+		t.Errorf("WatchHealth: wrong healthy transition: %#v", transition)
+	}
+
+	// repeated statuses aren't transitions.
+	daemon.events <- `{"Type": "container", "Action": "health_status: healthy", "Actor": {"ID": "c1"}, "time": 2}`
+
+	daemon.setState(State{}, true)
+	daemon.events <- `{"Type": "container", "Action": "health_status: unhealthy", "Actor": {"ID": "c1"}, "time": 3, "timeNano": 3000000001}`
+	transition, _ = receiveTransition(t, transitions)
+	if transition.Status != "unhealthy" || transition.Probe != nil || !transition.Time.Equal(time.Unix(0, 3000000001)) {
+		t.Errorf("WatchHealth: wrong unhealthy transition from the event: %#v", transition)
+	}
+
+	daemon.events <- `{"Type": "container", "Action": "die", "Actor": {"ID": "c1"}, "time": 4}`
+	if transition, ok := receiveTransition(t, transitions); ok {
+		t.Errorf("WatchHealth: want channel closed after the container stopped, got %#v", transition)
+	}
+}
+
+func TestWatchHealthContextCancel(t *testing.T) {
+	t.Parallel()
+	daemon := &healthDaemon{
+		state:  State{Running: true, Health: Health{Status: "healthy"}},
+		events: make(chan string),
+	}
+	server := httptest.NewServer(daemon)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	transitions, err := client.WatchHealth(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiveTransition(t, transitions)
+	cancel()
+	if transition, ok := receiveTransition(t, transitions); ok {
+		t.Errorf("WatchHealth: want channel closed after cancel, got %#v", transition)
+	}
+}
+
+func TestWatchHealthStoppedContainer(t *testing.T) {
+	t.Parallel()
+	daemon := &healthDaemon{state: State{Health: Health{Status: "unhealthy", FailingStreak: 3}}}
+	server := httptest.NewServer(daemon)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transitions, err := client.WatchHealth(context.Background(), "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transition, _ := receiveTransition(t, transitions)
+	if transition.Status != "unhealthy" || transition.FailingStreak != 3 {
+		t.Errorf("WatchHealth: wrong transition: %#v", transition)
+	}
+	if _, ok := receiveTransition(t, transitions); ok {
+		t.Error("WatchHealth: want channel closed for a stopped container")
+	}
+}
+
+func TestWatchHealthNoHealthcheck(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(&healthDaemon{state: State{Running: true}})
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.WatchHealth(context.Background(), "c1"); err != ErrNoHealthcheck {
+		t.Errorf("WatchHealth: wrong error. Want %v. Got %v.", ErrNoHealthcheck, err)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrDaemonNotLocal is the error returned by the helpers inspecting the
+// processes of containers on the local host when the client doesn't
+// connect to the daemon through a unix socket or a loopback address, so the
+// daemon may run on another host, or when the process of the container
+// isn't found on the local host, e.g. when the daemon runs in a VM like
+// with Docker Desktop.
+var ErrDaemonNotLocal = errors.New("the daemon doesn't run on the local host")
+
+// defaultRuntimeBundleDirs are the directories holding the bundles of the
+// containers of the daemon, by version of the containerd shim.
+var defaultRuntimeBundleDirs = []string{
+	"/run/containerd/io.containerd.runtime.v2.task/moby",
+	"/run/docker/containerd/daemon/io.containerd.runtime.v1.linux/moby",
+	"/var/run/docker/containerd/daemon/io.containerd.runtime.v1.linux/moby",
+}
+
+// LocalContainer is the low-level view of a running container from the host
+// of the daemon, as read from /proc, for debugging and profiling agents
+// running alongside the daemon.
+type LocalContainer struct {
+	ID string
+
+	// PID is the PID of the init process of the container, in the PID
+	// namespace of the host.
+	PID int
+
+	// Cgroups are the cgroups of the init process, by controller, e.g.
+	// "memory" or "cpu,cpuacct". The unified hierarchy of cgroup v2 has an
+	// empty controller.
+	Cgroups map[string]string
+
+	// Namespaces are the namespaces of the init process, by type (e.g.
+	// "pid", "net" or "mnt"), identified like in /proc/<pid>/ns, e.g.
+	// "pid:[4026532185]".
+	Namespaces map[string]string
+
+	// RuntimeSpecPath is the path of the OCI runtime spec of the container
+	// (its config.json) in the bundle created by containerd, or an empty
+	// string when it wasn't found. The layout of the bundles is an
+	// implementation detail of the daemon, and may change between versions.
+	RuntimeSpecPath string
+
+	procRoot string
+}
+
+// CgroupPath returns the path of the cgroup of the container under
+// /sys/fs/cgroup, for the given controller of cgroup v1 (e.g. "memory"), or
+// for the unified hierarchy of cgroup v2 when controller is empty. It
+// returns an empty string when the container isn't in such a cgroup.
+func (c *LocalContainer) CgroupPath(controller string) string {
+	if path, ok := c.Cgroups[controller]; ok {
+		if controller == "" {
+			return filepath.Join("/sys/fs/cgroup", path)
+		}
+		return filepath.Join("/sys/fs/cgroup", controller, path)
+	}
+	for controllers, path := range c.Cgroups {
+		for _, name := range strings.Split(controllers, ",") {
+			if name == controller && controller != "" {
+				return filepath.Join("/sys/fs/cgroup", controllers, path)
+			}
+		}
+	}
+	return ""
+}
+
+// NamespacePath returns the path of the given namespace of the container,
+// e.g. "/proc/1234/ns/net", usable with setns(2) or nsenter.
+func (c *LocalContainer) NamespacePath(ns string) string {
+	return filepath.Join(c.procRoot, fmt.Sprint(c.PID), "ns", ns)
+}
+
+// ReadRuntimeSpec returns the OCI runtime spec of the container, in JSON.
+func (c *LocalContainer) ReadRuntimeSpec() ([]byte, error) {
+	if c.RuntimeSpecPath == "" {
+		return nil, fmt.Errorf("runtime spec of container %s not found", c.ID)
+	}
+	return ioutil.ReadFile(c.RuntimeSpecPath)
+}
+
+// InspectLocalContainer returns the low-level view of a running container
+// from the local host, for clients running on the host of the daemon. It
+// returns ErrDaemonNotLocal when the daemon may run on another host, or when
+// the process of the container isn't found in /proc, and reading /proc
+// usually requires the privileges of the daemon.
+func (c *Client) InspectLocalContainer(ctx context.Context, id string) (*LocalContainer, error) {
+	if !c.isLocalDaemon() {
+		return nil, ErrDaemonNotLocal
+	}
+	container, err := c.InspectContainerWithContext(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newLocalContainer(c.localProcRoot(), defaultRuntimeBundleDirs, container)
+}
+
+func (c *Client) localProcRoot() string {
+	if c.procRoot == "" {
+		return "/proc"
+	}
+	return c.procRoot
+}
+
+// isLocalDaemon tells whether the client connects to the daemon through a
+// unix socket, a named pipe or a loopback address.
+func (c *Client) isLocalDaemon() bool {
+	if c.endpointURL == nil {
+		return false
+	}
+	switch c.endpointURL.Scheme {
+	case unixProtocol, namedPipeProtocol:
+		return true
+	}
+	host := c.endpointURL.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newLocalContainer(procRoot string, bundleDirs []string, container *Container) (*LocalContainer, error) {
+	if !container.State.Running || container.State.Pid == 0 {
+		return nil, &ContainerNotRunning{ID: container.ID}
+	}
+	local := LocalContainer{ID: container.ID, PID: container.State.Pid, procRoot: procRoot}
+	procDir := filepath.Join(procRoot, fmt.Sprint(local.PID))
+	cgroups, err := readLocalCgroups(procRoot, local.PID, container.ID)
+	if err != nil {
+		return nil, err
+	}
+	local.Cgroups = cgroups
+	entries, err := ioutil.ReadDir(filepath.Join(procDir, "ns"))
+	if err != nil {
+		return nil, err
+	}
+	local.Namespaces = make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if target, err := os.Readlink(filepath.Join(procDir, "ns", entry.Name())); err == nil {
+			local.Namespaces[entry.Name()] = target
+		}
+	}
+	for _, dir := range bundleDirs {
+		path := filepath.Join(dir, container.ID, "config.json")
+		if _, err := os.Stat(path); err == nil {
+			local.RuntimeSpecPath = path
+			break
+		}
+	}
+	return &local, nil
+}
+
+// readLocalCgroups returns the cgroups of the process of a container on the
+// local host, checking that they're the cgroups of the container: a unix
+// socket may lead to a daemon running in a VM, whose PIDs are meaningless on
+// the local host.
+func readLocalCgroups(procRoot string, pid int, id string) (map[string]string, error) {
+	cgroups, err := readCgroups(filepath.Join(procRoot, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrDaemonNotLocal
+		}
+		return nil, err
+	}
+	for _, path := range cgroups {
+		if strings.Contains(path, id) {
+			return cgroups, nil
+		}
+	}
+	return nil, ErrDaemonNotLocal
+}
+
+// readCgroups parses /proc/<pid>/cgroup, made of
+// hierarchy-ID:controller-list:cgroup-path lines.
+func readCgroups(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cgroups := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		controllers := strings.TrimPrefix(parts[1], "name=")
+		cgroups[controllers] = parts[2]
+	}
+	return cgroups, scanner.Err()
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewLocalContainerNotRunning(t *testing.T) {
+	t.Parallel()
+	container := Container{ID: "abc123"}
+	_, err := newLocalContainer("/proc", nil, &container)
+	if _, ok := err.(*ContainerNotRunning); !ok {
+		t.Errorf("newLocalContainer: wrong error. Want *ContainerNotRunning. Got %#v.", err)
+	}
+}
+
+func TestLocalContainerWithoutRuntimeSpec(t *testing.T) {
+	t.Parallel()
+	local := LocalContainer{ID: "abc123"}
+	if _, err := local.ReadRuntimeSpec(); err == nil {
+		t.Error("ReadRuntimeSpec: unexpected <nil> error")
+	}
+}
+
+func TestIsLocalDaemon(t *testing.T) {
+	t.Parallel()
+	tests := map[string]bool{
+		"unix:///var/run/docker.sock": true,
+		"tcp://127.0.0.1:2375":        true,
+		"http://localhost:2375":       true,
+		"tcp://[::1]:2375":            true,
+		"tcp://10.0.0.1:2375":         false,
+		"https://docker.example.com":  false,
+	}
+	for endpoint, expected := range tests {
+		client, err := NewClient(endpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if local := client.isLocalDaemon(); local != expected {
+			t.Errorf("isLocalDaemon(%q): want %v. Got %v.", endpoint, expected, local)
+		}
+	}
+}
+
+func TestInspectLocalContainerRemoteDaemon(t *testing.T) {
+	t.Parallel()
+	client, err := NewClient("tcp://10.0.0.1:2375")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.InspectLocalContainer(context.Background(), "abc123"); err != ErrDaemonNotLocal {
+		t.Errorf("InspectLocalContainer: wrong error. Want %#v. Got %#v.", ErrDaemonNotLocal, err)
+	}
+}
+
+func TestContainerLowLevelFields(t *testing.T) {
+	t.Parallel()
+	var container Container
+	data := `{"Id":"abc123","Platform":"linux","ProcessLabel":"system_u:system_r:container_t:s0","MountLabel":"system_u:object_r:container_file_t:s0",
+	"GraphDriver":{"Name":"overlay2","Data":{"MergedDir":"/var/lib/docker/overlay2/x/merged"}},"SizeRootFs":1024}`
+	if err := json.Unmarshal([]byte(data), &container); err != nil {
+		t.Fatal(err)
+	}
+	if container.Platform != "linux" || container.ProcessLabel == "" || container.MountLabel == "" || container.SizeRootFs != 1024 {
+		t.Errorf("Container: wrong fields: %#v", container)
+	}
+	if container.GraphDriver.Data["MergedDir"] != "/var/lib/docker/overlay2/x/merged" {
+		t.Errorf("Container: wrong graph driver: %#v", container.GraphDriver)
+	}
+}
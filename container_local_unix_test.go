@@ -0,0 +1,105 @@
+// +build !windows
+
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newFakeProc(t *testing.T, pid, cgroup string) string {
+	dir, err := ioutil.TempDir("", "go-dockerclient-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nsDir := filepath.Join(dir, "proc", pid, "ns")
+	if err := os.MkdirAll(nsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "proc", pid, "cgroup"), []byte(cgroup), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for ns, target := range map[string]string{"pid": "pid:[4026532185]", "net": "net:[4026532188]"} {
+		if err := os.Symlink(target, filepath.Join(nsDir, ns)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestNewLocalContainer(t *testing.T) {
+	t.Parallel()
+	dir := newFakeProc(t, "1234", "12:memory:/docker/abc123\n4:cpu,cpuacct:/docker/abc123\n1:name=systemd:/docker/abc123\n0::/system.slice/docker-abc123.scope\n")
+	defer os.RemoveAll(dir)
+	bundle := filepath.Join(dir, "bundles", "abc123")
+	if err := os.MkdirAll(bundle, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(bundle, "config.json"), []byte(`{"ociVersion":"1.0.1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	container := Container{ID: "abc123", State: State{Running: true, Pid: 1234}}
+	bundleDirs := []string{filepath.Join(dir, "missing"), filepath.Join(dir, "bundles")}
+	local, err := newLocalContainer(filepath.Join(dir, "proc"), bundleDirs, &container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local.PID != 1234 || local.ID != "abc123" {
+		t.Errorf("newLocalContainer: wrong container: %#v", local)
+	}
+	expectedCgroups := map[string]string{
+		"memory":      "/docker/abc123",
+		"cpu,cpuacct": "/docker/abc123",
+		"systemd":     "/docker/abc123",
+		"":            "/system.slice/docker-abc123.scope",
+	}
+	if !reflect.DeepEqual(local.Cgroups, expectedCgroups) {
+		t.Errorf("newLocalContainer: wrong cgroups. Want %#v. Got %#v.", expectedCgroups, local.Cgroups)
+	}
+	paths := map[string]string{
+		"memory":  "/sys/fs/cgroup/memory/docker/abc123",
+		"cpuacct": "/sys/fs/cgroup/cpu,cpuacct/docker/abc123",
+		"":        "/sys/fs/cgroup/system.slice/docker-abc123.scope",
+		"blkio":   "",
+	}
+	for controller, expected := range paths {
+		if path := local.CgroupPath(controller); path != expected {
+			t.Errorf("CgroupPath(%q): want %q. Got %q.", controller, expected, path)
+		}
+	}
+	expectedNamespaces := map[string]string{"pid": "pid:[4026532185]", "net": "net:[4026532188]"}
+	if !reflect.DeepEqual(local.Namespaces, expectedNamespaces) {
+		t.Errorf("newLocalContainer: wrong namespaces. Want %#v. Got %#v.", expectedNamespaces, local.Namespaces)
+	}
+	if expected := filepath.Join(dir, "proc", "1234", "ns", "net"); local.NamespacePath("net") != expected {
+		t.Errorf("NamespacePath: want %q. Got %q.", expected, local.NamespacePath("net"))
+	}
+	spec, err := local.ReadRuntimeSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(spec) != `{"ociVersion":"1.0.1"}` {
+		t.Errorf("ReadRuntimeSpec: wrong spec: %s", spec)
+	}
+}
+
+func TestNewLocalContainerOtherProcess(t *testing.T) {
+	t.Parallel()
+	dir := newFakeProc(t, "1234", "0::/user.slice/user-1000.slice\n")
+	defer os.RemoveAll(dir)
+	container := Container{ID: "abc123", State: State{Running: true, Pid: 1234}}
+	if _, err := newLocalContainer(filepath.Join(dir, "proc"), nil, &container); err != ErrDaemonNotLocal {
+		t.Errorf("newLocalContainer: wrong error. Want %#v. Got %#v.", ErrDaemonNotLocal, err)
+	}
+	container.State.Pid = 5678
+	if _, err := newLocalContainer(filepath.Join(dir, "proc"), nil, &container); err != ErrDaemonNotLocal {
+		t.Errorf("newLocalContainer: wrong error without the process. Want %#v. Got %#v.", ErrDaemonNotLocal, err)
+	}
+}
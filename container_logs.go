@@ -0,0 +1,81 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// readableLogDrivers are the logging drivers the daemon can read the logs
+// from, regardless of its version.
+var readableLogDrivers = map[string]bool{
+	"json-file": true,
+	"local":     true,
+	"journald":  true,
+}
+
+// LogsNotSupported is the error returned by ExplainLogsError when the logging
+// driver of a container doesn't support reading the logs back, which the
+// daemon reports to Logs with a 501 status.
+type LogsNotSupported struct {
+	Container string
+
+	// Driver is the logging driver of the container, or empty when the
+	// container couldn't be inspected.
+	Driver string
+
+	Err *Error
+}
+
+func (err *LogsNotSupported) Error() string {
+	if err.Driver == "" {
+		return fmt.Sprintf("the logging driver of container %s does not support reading logs", err.Container)
+	}
+	return fmt.Sprintf("the %s logging driver of container %s does not support reading logs", err.Driver, err.Container)
+}
+
+// ExplainLogsError returns a *LogsNotSupported, naming the logging driver of
+// the container, when err is the *Error returned by Logs for a container
+// whose logging driver doesn't support reading, and err otherwise.
+func (c *Client) ExplainLogsError(ctx context.Context, id string, err error) error {
+	apiErr, ok := err.(*Error)
+	if !ok || apiErr.Status != http.StatusNotImplemented {
+		return err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logsErr := LogsNotSupported{Container: id, Err: apiErr}
+	if container, err := c.InspectContainerWithContext(id, ctx); err == nil && container.HostConfig != nil {
+		logsErr.Driver = container.HostConfig.LogConfig.Type
+	}
+	return &logsErr
+}
+
+// CanReadLogs returns whether the logs of the given container, as returned by
+// InspectContainer, can be read with Logs.
+//
+// The json-file, local and journald drivers always support reading. Since
+// API 1.41, the daemon also caches the logs of the other drivers, unless the
+// cache is disabled with the cache-disabled option, so CanReadLogs returns
+// false for them when the version of the daemon is unknown.
+func (c *Client) CanReadLogs(container *Container) bool {
+	if container.HostConfig == nil {
+		return false
+	}
+	logConfig := container.HostConfig.LogConfig
+	switch {
+	case readableLogDrivers[logConfig.Type]:
+		return true
+	case logConfig.Type == "none":
+		return false
+	case logConfig.Config["cache-disabled"] == "true":
+		return false
+	}
+	version := c.getServerAPIVersion()
+	return version != nil && version.GreaterThanOrEqualTo(apiVersion141)
+}
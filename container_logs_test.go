@@ -0,0 +1,91 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestLogsNotSupported(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/web/logs", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "configured logging driver does not support reading", http.StatusNotImplemented)
+	})
+	mux.HandleFunc("/containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Id": "web", "HostConfig": {"LogConfig": {"Type": "splunk"}}}`))
+	})
+	mux.HandleFunc("/containers/db/logs", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "configured logging driver does not support reading", http.StatusNotImplemented)
+	})
+	mux.HandleFunc("/containers/db/json", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such container", http.StatusNotFound)
+	})
+	client := NewClientFromHandler(mux)
+	var stdout bytes.Buffer
+	err := client.Logs(LogsOptions{Container: "web", OutputStream: &stdout, Stdout: true})
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusNotImplemented {
+		t.Fatalf("Logs: wrong error. Want *Error with status 501. Got %#v.", err)
+	}
+	err = client.ExplainLogsError(context.Background(), "web", err)
+	logsErr, ok := err.(*LogsNotSupported)
+	if !ok {
+		t.Fatalf("ExplainLogsError: wrong error. Want *LogsNotSupported. Got %#v.", err)
+	}
+	if logsErr.Container != "web" || logsErr.Driver != "splunk" || logsErr.Err.Status != http.StatusNotImplemented {
+		t.Errorf("ExplainLogsError: wrong error: %#v", logsErr)
+	}
+	expectedMsg := "the splunk logging driver of container web does not support reading logs"
+	if logsErr.Error() != expectedMsg {
+		t.Errorf("ExplainLogsError: wrong error message. Want %q. Got %q.", expectedMsg, logsErr.Error())
+	}
+	err = client.Logs(LogsOptions{Container: "db", OutputStream: &stdout, Stdout: true})
+	err = client.ExplainLogsError(nil, "db", err)
+	if logsErr, ok := err.(*LogsNotSupported); !ok || logsErr.Driver != "" {
+		t.Errorf("ExplainLogsError: wrong error. Want *LogsNotSupported without driver. Got %#v.", err)
+	}
+}
+
+func TestExplainLogsErrorOtherErrors(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{status: http.StatusOK})
+	for _, err := range []error{nil, &Error{Status: http.StatusInternalServerError}, &NoSuchContainer{ID: "web"}} {
+		if got := client.ExplainLogsError(context.Background(), "web", err); got != err {
+			t.Errorf("ExplainLogsError(%#v): want the error unchanged. Got %#v.", err, got)
+		}
+	}
+}
+
+func TestCanReadLogs(t *testing.T) {
+	t.Parallel()
+	container := func(driver string, config map[string]string) *Container {
+		return &Container{HostConfig: &HostConfig{LogConfig: LogConfig{Type: driver, Config: config}}}
+	}
+	tests := []struct {
+		container *Container
+		version   APIVersion
+		expected  bool
+	}{
+		{container("json-file", nil), nil, true},
+		{container("local", nil), nil, true},
+		{container("journald", nil), apiVersion119, true},
+		{container("none", nil), apiVersion143, false},
+		{container("splunk", nil), nil, false},
+		{container("splunk", nil), apiVersion139, false},
+		{container("splunk", nil), apiVersion141, true},
+		{container("awslogs", map[string]string{"cache-disabled": "true"}), apiVersion143, false},
+		{&Container{}, apiVersion143, false},
+	}
+	for _, tt := range tests {
+		client := newTestClient(&FakeRoundTripper{status: http.StatusOK})
+		client.serverAPIVersion = tt.version
+		if got := client.CanReadLogs(tt.container); got != tt.expected {
+			t.Errorf("CanReadLogs(%#v) with API %v: want %v. Got %v.", tt.container.HostConfig, tt.version, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+// ErrLocalPrivilegedDisabled is the error returned by the helpers running
+// commands in the namespaces of containers when the client doesn't have
+// LocalPrivileged set.
+var ErrLocalPrivilegedDisabled = errors.New("running commands in the namespaces of containers requires LocalPrivileged")
+
+// defaultNsenterNamespaces are the namespaces entered by default, like
+// docker exec does.
+var defaultNsenterNamespaces = []string{"mnt", "uts", "ipc", "net", "pid"}
+
+// nsenterFlags are the flags of nsenter entering the namespaces, by type of
+// namespace, as named in /proc/<pid>/ns.
+var nsenterFlags = map[string]string{
+	"mnt":    "--mount",
+	"uts":    "--uts",
+	"ipc":    "--ipc",
+	"net":    "--net",
+	"pid":    "--pid",
+	"user":   "--user",
+	"cgroup": "--cgroup",
+}
+
+// ContainerPID returns the PID of the init process of a running container,
+// in the PID namespace of the host of the daemon. It returns
+// ErrDaemonNotLocal when the daemon may run on another host, where the PID
+// would be meaningless, and when the cgroups of the process, read from
+// /proc, aren't the ones of the container.
+func (c *Client) ContainerPID(ctx context.Context, id string) (int, error) {
+	if !c.isLocalDaemon() {
+		return 0, ErrDaemonNotLocal
+	}
+	container, err := c.InspectContainerWithContext(id, ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !container.State.Running || container.State.Pid == 0 {
+		return 0, &ContainerNotRunning{ID: container.ID}
+	}
+	if _, err := readLocalCgroups(c.localProcRoot(), container.State.Pid, container.ID); err != nil {
+		return 0, err
+	}
+	return container.State.Pid, nil
+}
+
+// NsenterOptions specify parameters to the NsenterCommand and Nsenter
+// functions.
+type NsenterOptions struct {
+	Container string
+
+	// Cmd is the command to run, looked up in the PATH of the host, or of
+	// the container when entering its mount namespace.
+	Cmd []string
+
+	// Namespaces are the namespaces of the container to enter, as named in
+	// /proc/<pid>/ns: mnt, uts, ipc, net, pid, user or cgroup. Defaults to
+	// the mnt, uts, ipc, net and pid namespaces.
+	Namespaces []string
+
+	// NsenterPath is the path of the nsenter binary. Defaults to nsenter,
+	// looked up in the PATH.
+	NsenterPath string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	Context context.Context
+}
+
+// NsenterCommand returns the command running opts.Cmd in the namespaces of
+// a container with nsenter(1), on the host of the daemon, e.g. to run the
+// debugging tools of the host against the container, which docker exec
+// can't do. The command isn't started.
+//
+// It's only available to clients with LocalPrivileged set, and returns
+// ErrLocalPrivilegedDisabled otherwise, as well as ErrDaemonNotLocal when
+// the daemon may run on another host. Entering the namespaces of a
+// container requires the privileges of the daemon.
+func (c *Client) NsenterCommand(opts NsenterOptions) (*exec.Cmd, error) {
+	if !c.LocalPrivileged {
+		return nil, ErrLocalPrivilegedDisabled
+	}
+	if len(opts.Cmd) == 0 {
+		return nil, errors.New("nsenter: missing command")
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pid, err := c.ContainerPID(ctx, opts.Container)
+	if err != nil {
+		return nil, err
+	}
+	args, err := nsenterArgs(pid, opts.Namespaces, opts.Cmd)
+	if err != nil {
+		return nil, err
+	}
+	path := opts.NsenterPath
+	if path == "" {
+		path = "nsenter"
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = opts.Stdin
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+	return cmd, nil
+}
+
+// Nsenter runs opts.Cmd in the namespaces of a container, waiting for it to
+// exit. See NsenterCommand for more details.
+func (c *Client) Nsenter(opts NsenterOptions) error {
+	cmd, err := c.NsenterCommand(opts)
+	if err != nil {
+		return err
+	}
+	return cmd.Run()
+}
+
+// nsenterArgs returns the arguments of nsenter running cmd in the given
+// namespaces of the process.
+func nsenterArgs(pid int, namespaces, cmd []string) ([]string, error) {
+	if len(namespaces) == 0 {
+		namespaces = defaultNsenterNamespaces
+	}
+	args := []string{"--target", strconv.Itoa(pid)}
+	for _, ns := range namespaces {
+		flag, ok := nsenterFlags[ns]
+		if !ok {
+			return nil, fmt.Errorf("nsenter: unknown namespace %q", ns)
+		}
+		args = append(args, flag)
+	}
+	args = append(args, "--")
+	return append(args, cmd...), nil
+}
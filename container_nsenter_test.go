@@ -0,0 +1,129 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newLocalDaemon serves the given container, with the cgroup of the process
+// 1234 in the proc filesystem of the client.
+func newLocalDaemon(t *testing.T, container, cgroup string) (*Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(container))
+	}))
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SkipServerVersionCheck = true
+	dir, err := ioutil.TempDir("", "go-dockerclient-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "1234"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "1234", "cgroup"), []byte(cgroup), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client.procRoot = dir
+	return client, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestContainerPID(t *testing.T) {
+	t.Parallel()
+	client, done := newLocalDaemon(t, `{"Id":"abc123","State":{"Running":true,"Pid":1234}}`, "0::/system.slice/docker-abc123.scope\n")
+	defer done()
+	pid, err := client.ContainerPID(context.Background(), "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid != 1234 {
+		t.Errorf("ContainerPID: want 1234. Got %d.", pid)
+	}
+}
+
+func TestContainerPIDNotRunning(t *testing.T) {
+	t.Parallel()
+	client, done := newLocalDaemon(t, `{"Id":"abc123","State":{"Running":false}}`, "")
+	defer done()
+	_, err := client.ContainerPID(context.Background(), "abc123")
+	if _, ok := err.(*ContainerNotRunning); !ok {
+		t.Errorf("ContainerPID: wrong error. Want *ContainerNotRunning. Got %#v.", err)
+	}
+}
+
+func TestContainerPIDNotLocalProcess(t *testing.T) {
+	t.Parallel()
+	client, done := newLocalDaemon(t, `{"Id":"abc123","State":{"Running":true,"Pid":1234}}`, "0::/user.slice/user-1000.slice\n")
+	defer done()
+	if _, err := client.ContainerPID(context.Background(), "abc123"); err != ErrDaemonNotLocal {
+		t.Errorf("ContainerPID: wrong error. Want %#v. Got %#v.", ErrDaemonNotLocal, err)
+	}
+	client.procRoot = filepath.Join(client.procRoot, "missing")
+	if _, err := client.ContainerPID(context.Background(), "abc123"); err != ErrDaemonNotLocal {
+		t.Errorf("ContainerPID: wrong error without the process. Want %#v. Got %#v.", ErrDaemonNotLocal, err)
+	}
+}
+
+func TestContainerPIDRemoteDaemon(t *testing.T) {
+	t.Parallel()
+	client, err := NewClient("tcp://10.0.0.1:2375")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ContainerPID(context.Background(), "abc123"); err != ErrDaemonNotLocal {
+		t.Errorf("ContainerPID: wrong error. Want %#v. Got %#v.", ErrDaemonNotLocal, err)
+	}
+}
+
+func TestNsenterCommand(t *testing.T) {
+	t.Parallel()
+	client, done := newLocalDaemon(t, `{"Id":"abc123","State":{"Running":true,"Pid":1234}}`, "0::/system.slice/docker-abc123.scope\n")
+	defer done()
+	opts := NsenterOptions{Container: "abc123", Cmd: []string{"ss", "-tlnp"}, Namespaces: []string{"net"}, NsenterPath: "/usr/bin/nsenter"}
+	if _, err := client.NsenterCommand(opts); err != ErrLocalPrivilegedDisabled {
+		t.Errorf("NsenterCommand: wrong error. Want %#v. Got %#v.", ErrLocalPrivilegedDisabled, err)
+	}
+	client.LocalPrivileged = true
+	cmd, err := client.NsenterCommand(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/usr/bin/nsenter", "--target", "1234", "--net", "--", "ss", "-tlnp"}
+	if !reflect.DeepEqual(cmd.Args, expected) {
+		t.Errorf("NsenterCommand: wrong command. Want %q. Got %q.", expected, cmd.Args)
+	}
+	opts.Cmd = nil
+	if _, err := client.NsenterCommand(opts); err == nil {
+		t.Error("NsenterCommand: unexpected <nil> error without a command")
+	}
+}
+
+func TestNsenterArgs(t *testing.T) {
+	t.Parallel()
+	args, err := nsenterArgs(42, nil, []string{"sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"--target", "42", "--mount", "--uts", "--ipc", "--net", "--pid", "--", "sh"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("nsenterArgs: want %q. Got %q.", expected, args)
+	}
+	if _, err := nsenterArgs(42, []string{"time"}, []string{"sh"}); err == nil {
+		t.Error("nsenterArgs: unexpected <nil> error for an unknown namespace")
+	}
+}
@@ -0,0 +1,33 @@
+// +build !windows
+
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNsenter(t *testing.T) {
+	t.Parallel()
+	client, done := newLocalDaemon(t, `{"Id":"abc123","State":{"Running":true,"Pid":1234}}`, "0::/system.slice/docker-abc123.scope\n")
+	defer done()
+	client.LocalPrivileged = true
+	var stdout bytes.Buffer
+	err := client.Nsenter(NsenterOptions{
+		Container:   "abc123",
+		Cmd:         []string{"hostname"},
+		Namespaces:  []string{"uts"},
+		NsenterPath: "echo",
+		Stdout:      &stdout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "--target 1234 --uts -- hostname\n"; stdout.String() != expected {
+		t.Errorf("Nsenter: wrong output. Want %q. Got %q.", expected, stdout.String())
+	}
+}
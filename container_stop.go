@@ -0,0 +1,137 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StopContainerOptions specify parameters to the StopContainerWithOptions
+// method.
+//
+// See https://goo.gl/R9dZcV for more details.
+type StopContainerOptions struct {
+	// Timeout is how long the daemon waits for the container to exit after
+	// sending the signal, before killing it, e.g. DurationPtr(time.Minute).
+	// It's rounded up to the second, zero kills the container right away
+	// and a negative timeout waits forever. When nil, the stop timeout of
+	// the container is used (10 seconds unless set in its configuration).
+	Timeout *time.Duration
+
+	// Signal is the signal sent to stop the container, e.g. "SIGINT".
+	// Defaults to the stop signal of the container. Only supported in API
+	// 1.42 and above.
+	Signal string
+
+	Context context.Context
+}
+
+// StopContainerWithOptions stops a container, killing it after
+// opts.Timeout.
+//
+// The daemon's wait is accounted for in the timeout of the call: the Long
+// timeout of the client's Timeouts is extended by opts.Timeout, so the call
+// isn't interrupted while the container stops. When opts.Timeout is nil, the
+// wait isn't known to the client, so the Long timeout doesn't apply.
+//
+// It returns a *NoSuchContainer error when the container doesn't exist, and
+// a *ContainerNotRunning error when it's not running.
+//
+// See https://goo.gl/R9dZcV for more details.
+func (c *Client) StopContainerWithOptions(id string, opts StopContainerOptions) error {
+	query, grace, err := c.stopQuery(opts.Timeout, opts.Signal)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do("POST", "/containers/"+id+"/stop?"+query, doOptions{context: opts.Context, timeoutGrace: grace})
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
+			return &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return &ContainerNotRunning{ID: id}
+	}
+	return nil
+}
+
+// stopQuery returns the query string of the calls stopping a container,
+// with the time the daemon may wait before answering, negative when it may
+// wait forever or when it's not known.
+func (c *Client) stopQuery(timeout *time.Duration, signal string) (string, time.Duration, error) {
+	if version := c.getServerAPIVersion(); signal != "" && version != nil && version.LessThan(apiVersion142) {
+		return "", 0, errors.New("signal is only supported in API#1.42 and above")
+	}
+	params := url.Values{}
+	var grace time.Duration
+	switch {
+	case timeout == nil:
+		// the daemon waits for the stop timeout of the container, which
+		// may be longer than the default.
+		grace = -1
+	case *timeout < 0:
+		params.Set("t", "-1")
+		grace = -1
+	default:
+		seconds := (*timeout + time.Second - 1) / time.Second
+		params.Set("t", strconv.FormatInt(int64(seconds), 10))
+		grace = seconds * time.Second
+	}
+	if signal != "" {
+		params.Set("signal", signal)
+	}
+	return params.Encode(), grace, nil
+}
+
+// RestartContainerOptions specify parameters to the
+// RestartContainerWithOptions method.
+//
+// See https://goo.gl/MrAKQ5 for more details.
+type RestartContainerOptions struct {
+	// Timeout is how long the daemon waits for the container to exit
+	// before killing it, during the stop, like in StopContainerOptions.
+	Timeout *time.Duration
+
+	// Signal is the signal sent to stop the container. Defaults to the
+	// stop signal of the container. Only supported in API 1.42 and above.
+	Signal string
+
+	Context context.Context
+}
+
+// RestartContainerWithOptions restarts a container, killing it after
+// opts.Timeout during the stop. Like in StopContainerWithOptions, the timeout
+// of the call is extended by opts.Timeout.
+//
+// It returns a *NoSuchContainer error when the container doesn't exist, and
+// a *ContainerNotRunning error when the daemon reports the container as not
+// modified.
+//
+// See https://goo.gl/MrAKQ5 for more details.
+func (c *Client) RestartContainerWithOptions(id string, opts RestartContainerOptions) error {
+	query, grace, err := c.stopQuery(opts.Timeout, opts.Signal)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do("POST", "/containers/"+id+"/restart?"+query, doOptions{context: opts.Context, timeoutGrace: grace})
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
+			return &NoSuchContainer{ID: id, errorMetadata: metadataOf(e)}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return &ContainerNotRunning{ID: id}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStopContainerWithOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		opts     StopContainerOptions
+		expected string
+	}{
+		{StopContainerOptions{}, ""},
+		{StopContainerOptions{Timeout: DurationPtr(0)}, "t=0"},
+		{StopContainerOptions{Timeout: DurationPtr(30 * time.Second)}, "t=30"},
+		{StopContainerOptions{Timeout: DurationPtr(1500 * time.Millisecond)}, "t=2"},
+		{StopContainerOptions{Timeout: DurationPtr(-1)}, "t=-1"},
+		{StopContainerOptions{Timeout: DurationPtr(5 * time.Second), Signal: "SIGINT"}, "signal=SIGINT&t=5"},
+	}
+	for _, tt := range tests {
+		fakeRT := &FakeRoundTripper{message: "", status: http.StatusNoContent}
+		client := newTestClient(fakeRT)
+		client.serverAPIVersion = apiVersion142
+		if err := client.StopContainerWithOptions("4fa6e0f0", tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		req := fakeRT.requests[0]
+		if req.Method != http.MethodPost || req.URL.Path != "/containers/4fa6e0f0/stop" {
+			t.Errorf("StopContainerWithOptions: wrong request %s %s", req.Method, req.URL.Path)
+		}
+		if req.URL.RawQuery != tt.expected {
+			t.Errorf("StopContainerWithOptions(%#v): wrong query. Want %q. Got %q.", tt.opts, tt.expected, req.URL.RawQuery)
+		}
+	}
+}
+
+func TestStopContainerWithOptionsErrors(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "no such container", status: http.StatusNotFound})
+	err := client.StopContainerWithOptions("a2334", StopContainerOptions{})
+	if expected := (&NoSuchContainer{ID: "a2334"}); !reflect.DeepEqual(err, expected) {
+		t.Errorf("StopContainerWithOptions: wrong error. Want %#v. Got %#v.", expected, err)
+	}
+	client = newTestClient(&FakeRoundTripper{message: "", status: http.StatusNotModified})
+	err = client.StopContainerWithOptions("a2334", StopContainerOptions{})
+	if expected := (&ContainerNotRunning{ID: "a2334"}); !reflect.DeepEqual(err, expected) {
+		t.Errorf("StopContainerWithOptions: wrong error. Want %#v. Got %#v.", expected, err)
+	}
+}
+
+func TestStopContainerWithOptionsSignalVersion(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "", status: http.StatusNoContent}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion141
+	err := client.StopContainerWithOptions("4fa6e0f0", StopContainerOptions{Signal: "SIGINT"})
+	if err == nil || err.Error() != "signal is only supported in API#1.42 and above" {
+		t.Errorf("StopContainerWithOptions: wrong error: %v", err)
+	}
+	if len(fakeRT.requests) > 0 {
+		t.Errorf("StopContainerWithOptions: unexpected requests: %d", len(fakeRT.requests))
+	}
+}
+
+func TestStopContainerWithOptionsTimeout(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(slowDaemon(100 * time.Millisecond))
+	client.Timeouts = RequestTimeouts{Long: 50 * time.Millisecond}
+	if err := client.StopContainerWithOptions("4fa6e0f0", StopContainerOptions{Timeout: DurationPtr(time.Second)}); err != nil {
+		t.Errorf("StopContainerWithOptions: the call must wait for the stop timeout. Got %v.", err)
+	}
+	if err := client.StopContainer("4fa6e0f0", 1); err != nil {
+		t.Errorf("StopContainer: the call must wait for the stop timeout. Got %v.", err)
+	}
+	if err := client.StopContainer("4fa6e0f0", 0); err == nil {
+		t.Error("StopContainer: want the Long timeout to apply. Got <nil> error.")
+	}
+	if err := client.StopContainerWithOptions("4fa6e0f0", StopContainerOptions{}); err != nil {
+		t.Errorf("StopContainerWithOptions: the call must wait for the stop timeout of the container. Got %v.", err)
+	}
+}
+
+func TestRestartContainerWithOptions(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "", status: http.StatusNoContent}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion142
+	if err := client.RestartContainerWithOptions("4fa6e0f0", RestartContainerOptions{Timeout: DurationPtr(3 * time.Second), Signal: "SIGTERM"}); err != nil {
+		t.Fatal(err)
+	}
+	req := fakeRT.requests[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/containers/4fa6e0f0/restart" {
+		t.Errorf("RestartContainerWithOptions: wrong request %s %s", req.Method, req.URL.Path)
+	}
+	if expected := "signal=SIGTERM&t=3"; req.URL.RawQuery != expected {
+		t.Errorf("RestartContainerWithOptions: wrong query. Want %q. Got %q.", expected, req.URL.RawQuery)
+	}
+	client.serverAPIVersion = apiVersion141
+	err := client.RestartContainerWithOptions("4fa6e0f0", RestartContainerOptions{Signal: "SIGTERM"})
+	if err == nil || err.Error() != "signal is only supported in API#1.42 and above" {
+		t.Errorf("RestartContainerWithOptions: wrong error: %v", err)
+	}
+}
+
+func TestRestartContainerWithOptionsErrors(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "no such container", status: http.StatusNotFound})
+	err := client.RestartContainerWithOptions("a2334", RestartContainerOptions{})
+	if expected := (&NoSuchContainer{ID: "a2334"}); !reflect.DeepEqual(err, expected) {
+		t.Errorf("RestartContainerWithOptions: wrong error. Want %#v. Got %#v.", expected, err)
+	}
+	client = newTestClient(&FakeRoundTripper{message: "", status: http.StatusNotModified})
+	err = client.RestartContainerWithOptions("a2334", RestartContainerOptions{})
+	if expected := (&ContainerNotRunning{ID: "a2334"}); !reflect.DeepEqual(err, expected) {
+		t.Errorf("RestartContainerWithOptions: wrong error. Want %#v. Got %#v.", expected, err)
+	}
+}
+
+func TestRestartContainerWithOptionsTimeout(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(slowDaemon(100 * time.Millisecond))
+	client.Timeouts = RequestTimeouts{Long: 50 * time.Millisecond}
+	if err := client.RestartContainerWithOptions("4fa6e0f0", RestartContainerOptions{Timeout: DurationPtr(time.Second)}); err != nil {
+		t.Errorf("RestartContainerWithOptions: the call must wait for the stop timeout. Got %v.", err)
+	}
+	if err := client.RestartContainer("4fa6e0f0", 0); err == nil {
+		t.Error("RestartContainer: want the Long timeout to apply. Got <nil> error.")
+	}
+}
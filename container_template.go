@@ -0,0 +1,331 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// InterpolationError is the error returned when a template can't be
+// interpolated, because of a syntax error or a required variable that isn't
+// set.
+type InterpolationError struct {
+	Template string
+	Variable string
+	Message  string
+}
+
+func (e *InterpolationError) Error() string {
+	if e.Variable == "" {
+		return fmt.Sprintf("invalid template %q: %s", e.Template, e.Message)
+	}
+	return fmt.Sprintf("required variable %s is missing a value: %s", e.Variable, e.Message)
+}
+
+// Interpolate substitutes the variables in s with their values, as returned
+// by lookup, following the rules of compose files:
+//
+//   - $VAR and ${VAR} are replaced by the value of VAR, or an empty string
+//   - ${VAR:-default} is replaced by default when VAR is unset or empty, and
+//     ${VAR-default} only when it's unset
+//   - ${VAR:?message} fails with the message when VAR is unset or empty, and
+//     ${VAR?message} only when it's unset
+//   - ${VAR:+alternative} is replaced by alternative when VAR is set and not
+//     empty, and ${VAR+alternative} whenever it's set
+//   - $$ is replaced by a single $
+//
+// Defaults, messages and alternatives may reference other variables. When
+// lookup is nil, the variables are looked up in the environment.
+func Interpolate(s string, lookup func(name string) (string, bool)) (string, error) {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	i := interpolator{template: s, lookup: lookup}
+	return i.interpolate(s)
+}
+
+type interpolator struct {
+	template string
+	lookup   func(name string) (string, bool)
+}
+
+func (i *interpolator) interpolate(s string) (string, error) {
+	var buf strings.Builder
+	for {
+		pos := strings.IndexByte(s, '$')
+		if pos < 0 {
+			buf.WriteString(s)
+			return buf.String(), nil
+		}
+		buf.WriteString(s[:pos])
+		s = s[pos+1:]
+		switch {
+		case strings.HasPrefix(s, "$"):
+			buf.WriteByte('$')
+			s = s[1:]
+		case strings.HasPrefix(s, "{"):
+			end := matchingBrace(s)
+			if end < 0 {
+				return "", &InterpolationError{Template: i.template, Message: "missing closing brace"}
+			}
+			value, err := i.substitute(s[1:end])
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(value)
+			s = s[end+1:]
+		default:
+			n := variableNameLength(s)
+			if n == 0 {
+				return "", &InterpolationError{Template: i.template, Message: "a $ must be followed by a variable name or another $"}
+			}
+			value, _ := i.lookup(s[:n])
+			buf.WriteString(value)
+			s = s[n:]
+		}
+	}
+}
+
+// substitute returns the value of the expression between the braces of
+// ${...}.
+func (i *interpolator) substitute(expr string) (string, error) {
+	n := variableNameLength(expr)
+	if n == 0 {
+		return "", &InterpolationError{Template: i.template, Message: fmt.Sprintf("invalid variable name in ${%s}", expr)}
+	}
+	name, op := expr[:n], expr[n:]
+	value, ok := i.lookup(name)
+	if op == "" {
+		return value, nil
+	}
+	ifEmpty := strings.HasPrefix(op, ":")
+	op = strings.TrimPrefix(op, ":")
+	if op == "" {
+		return "", &InterpolationError{Template: i.template, Message: fmt.Sprintf("invalid expression ${%s}", expr)}
+	}
+	isSet := ok && (!ifEmpty || value != "")
+	arg := op[1:]
+	switch op[0] {
+	case '-':
+		if isSet {
+			return value, nil
+		}
+		return i.interpolate(arg)
+	case '?':
+		if isSet {
+			return value, nil
+		}
+		message, err := i.interpolate(arg)
+		if err != nil {
+			return "", err
+		}
+		return "", &InterpolationError{Template: i.template, Variable: name, Message: message}
+	case '+':
+		if isSet {
+			return i.interpolate(arg)
+		}
+		return "", nil
+	}
+	return "", &InterpolationError{Template: i.template, Message: fmt.Sprintf("invalid expression ${%s}", expr)}
+}
+
+// matchingBrace returns the index of the brace that closes the one at the
+// start of s, or -1.
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func variableNameLength(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return i
+	}
+	return len(s)
+}
+
+// ContainerTemplate is a declarative spec of a container, in JSON, whose
+// string values may reference variables, as in:
+//
+//	{
+//	    "Name": "web-${ENV:-dev}",
+//	    "Config": {"Image": "nginx:${NGINX_VERSION:?the version is required}"},
+//	    "HostConfig": {"Memory": "${WEB_MEMORY:-268435456}"}
+//	}
+//
+// See Interpolate for the syntax of the references.
+type ContainerTemplate []byte
+
+// Render interpolates the variables in the template, looking up their values
+// with lookup (or in the environment when it's nil), and returns the options
+// to create the container.
+//
+// Only values are interpolated, not keys. Strings are converted to numbers
+// and booleans where the fields of Config, HostConfig and NetworkingConfig
+// expect them, so these fields can be set from variables too.
+func (t ContainerTemplate) Render(lookup func(name string) (string, bool)) (CreateContainerOptions, error) {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	var spec interface{}
+	decoder := json.NewDecoder(bytes.NewReader(t))
+	decoder.UseNumber()
+	if err := decoder.Decode(&spec); err != nil {
+		return CreateContainerOptions{}, err
+	}
+	var rendered struct {
+		Name             string
+		Config           *Config
+		HostConfig       *HostConfig
+		NetworkingConfig *NetworkingConfig
+	}
+	spec, err := renderTemplateValue(spec, reflect.TypeOf(rendered), lookup)
+	if err != nil {
+		return CreateContainerOptions{}, err
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return CreateContainerOptions{}, err
+	}
+	if err := json.Unmarshal(data, &rendered); err != nil {
+		return CreateContainerOptions{}, err
+	}
+	return CreateContainerOptions{
+		Name:             rendered.Name,
+		Config:           rendered.Config,
+		HostConfig:       rendered.HostConfig,
+		NetworkingConfig: rendered.NetworkingConfig,
+	}, nil
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// renderTemplateValue interpolates the strings in the decoded JSON value v,
+// converting them to the kind expected by typ, the type v is decoded into.
+func renderTemplateValue(v interface{}, typ reflect.Type, lookup func(string) (string, bool)) (interface{}, error) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ != nil && reflect.PtrTo(typ).Implements(jsonUnmarshalerType) {
+		typ = nil
+	}
+	switch value := v.(type) {
+	case string:
+		s, err := Interpolate(value, lookup)
+		if err != nil {
+			return nil, err
+		}
+		return convertTemplateString(s, typ)
+	case []interface{}:
+		var elem reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elem = typ.Elem()
+		}
+		for i := range value {
+			rendered, err := renderTemplateValue(value[i], elem, lookup)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = rendered
+		}
+	case map[string]interface{}:
+		for key := range value {
+			var field reflect.Type
+			if typ != nil && typ.Kind() == reflect.Map {
+				field = typ.Elem()
+			} else if typ != nil && typ.Kind() == reflect.Struct {
+				field = jsonFieldType(typ, key)
+			}
+			rendered, err := renderTemplateValue(value[key], field, lookup)
+			if err != nil {
+				return nil, err
+			}
+			value[key] = rendered
+		}
+	}
+	return v, nil
+}
+
+// convertTemplateString converts the interpolated string s to the kind
+// expected by typ.
+func convertTemplateString(s string, typ reflect.Type) (interface{}, error) {
+	if typ == nil {
+		return s, nil
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", s)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		return json.Number(s), nil
+	}
+	return s, nil
+}
+
+// jsonFieldType returns the type of the field of the struct typ that the
+// JSON key is decoded into, matching names like encoding/json, or nil.
+func jsonFieldType(typ reflect.Type, key string) reflect.Type {
+	var fold reflect.Type
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if t := jsonFieldType(embedded, key); t != nil {
+					return t
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return field.Type
+		}
+		if fold == nil && strings.EqualFold(name, key) {
+			fold = field.Type
+		}
+	}
+	return fold
+}
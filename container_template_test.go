@@ -0,0 +1,165 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func templateLookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+	lookup := templateLookup(map[string]string{"NAME": "web", "EMPTY": "", "PORT": "8080"})
+	var tests = []struct {
+		template string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"$NAME-1", "web-1"},
+		{"${NAME}1", "web1"},
+		{"${UNSET}", ""},
+		{"${UNSET:-default}", "default"},
+		{"${EMPTY:-default}", "default"},
+		{"${EMPTY-default}", ""},
+		{"${UNSET-default}", "default"},
+		{"${NAME:-default}", "web"},
+		{"${NAME:+set}", "set"},
+		{"${EMPTY:+set}", ""},
+		{"${EMPTY+set}", "set"},
+		{"${UNSET+set}", ""},
+		{"${UNSET:-${NAME}:${PORT}}", "web:8080"},
+		{"${NAME:?missing}", "web"},
+		{"$$NAME costs $$5", "$NAME costs $5"},
+	}
+	for _, test := range tests {
+		got, err := Interpolate(test.template, lookup)
+		if err != nil {
+			t.Errorf("Interpolate(%q): unexpected error: %s", test.template, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("Interpolate(%q): Want %q. Got %q.", test.template, test.expected, got)
+		}
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	t.Parallel()
+	lookup := templateLookup(map[string]string{"EMPTY": "", "NAME": "web"})
+	var tests = []struct {
+		template string
+		expected string
+	}{
+		{"${UNSET:?set UNSET for $NAME}", "required variable UNSET is missing a value: set UNSET for web"},
+		{"${EMPTY:?}", "required variable EMPTY is missing a value: "},
+		{"${NAME", `invalid template "${NAME": missing closing brace`},
+		{"${1A}", `invalid template "${1A}": invalid variable name in ${1A}`},
+		{"${NAME:}", `invalid template "${NAME:}": invalid expression ${NAME:}`},
+		{"${NAME/a/b}", `invalid template "${NAME/a/b}": invalid expression ${NAME/a/b}`},
+		{"5$", `invalid template "5$": a $ must be followed by a variable name or another $`},
+	}
+	for _, test := range tests {
+		_, err := Interpolate(test.template, lookup)
+		if _, ok := err.(*InterpolationError); !ok {
+			t.Errorf("Interpolate(%q): wrong error. Want *InterpolationError. Got %#v.", test.template, err)
+			continue
+		}
+		if err.Error() != test.expected {
+			t.Errorf("Interpolate(%q): wrong error message. Want %q. Got %q.", test.template, test.expected, err.Error())
+		}
+	}
+}
+
+func TestContainerTemplateRender(t *testing.T) {
+	t.Parallel()
+	template := ContainerTemplate(`{
+		"Name": "web-${ENV:-dev}",
+		"Config": {
+			"Image": "nginx:${NGINX_VERSION:?the version is required}",
+			"Env": ["ENV=${ENV:-dev}", "PRICE=$$5"],
+			"Tty": "${TTY:-false}",
+			"ExposedPorts": {"80/tcp": {}},
+			"Labels": {"owner": "${OWNER}"}
+		},
+		"HostConfig": {
+			"Memory": "${WEB_MEMORY:-268435456}",
+			"CpuShares": 512,
+			"PortBindings": {"80/tcp": [{"HostPort": "${WEB_PORT}"}]},
+			"RestartPolicy": {"Name": "on-failure", "MaximumRetryCount": "${RETRIES:-3}"},
+			"Privileged": "${PRIVILEGED}"
+		}
+	}`)
+	opts, err := template.Render(templateLookup(map[string]string{
+		"ENV":           "prod",
+		"NGINX_VERSION": "1.17",
+		"OWNER":         "team-web",
+		"WEB_PORT":      "8080",
+		"PRIVILEGED":    "true",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := CreateContainerOptions{
+		Name: "web-prod",
+		Config: &Config{
+			Image:        "nginx:1.17",
+			Env:          []string{"ENV=prod", "PRICE=$5"},
+			ExposedPorts: map[Port]struct{}{"80/tcp": {}},
+			Labels:       map[string]string{"owner": "team-web"},
+		},
+		HostConfig: &HostConfig{
+			Memory:        268435456,
+			CPUShares:     512,
+			PortBindings:  map[Port][]PortBinding{"80/tcp": {{HostPort: "8080"}}},
+			RestartPolicy: RestartOnFailure(3),
+			Privileged:    true,
+		},
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Errorf("ContainerTemplate.Render: wrong options.\nWant %#v.\nGot  %#v.", expected, opts)
+	}
+}
+
+func TestContainerTemplateRenderErrors(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		template ContainerTemplate
+		expected string
+	}{
+		{
+			name:     "required variable",
+			template: ContainerTemplate(`{"Config": {"Image": "${IMAGE:?the image is required}"}}`),
+			expected: "required variable IMAGE is missing a value: the image is required",
+		},
+		{
+			name:     "invalid number",
+			template: ContainerTemplate(`{"HostConfig": {"Memory": "${MEMORY:-lots}"}}`),
+			expected: `invalid number "lots"`,
+		},
+		{
+			name:     "invalid boolean",
+			template: ContainerTemplate(`{"HostConfig": {"Privileged": "${PRIVILEGED}"}}`),
+			expected: `invalid boolean ""`,
+		},
+	}
+	for _, test := range tests {
+		_, err := test.template.Render(templateLookup(nil))
+		if err == nil {
+			t.Errorf("%s: expected error, got <nil>", test.name)
+			continue
+		}
+		if err.Error() != test.expected {
+			t.Errorf("%s: wrong error. Want %q. Got %q.", test.name, test.expected, err.Error())
+		}
+	}
+}
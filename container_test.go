@@ -972,6 +972,74 @@ func TestCreateContainerWithHostConfig(t *testing.T) {
 	}
 }
 
+func TestCreateContainerWithAnnotations(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "{}", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion143
+	hostConfig := HostConfig{Annotations: map[string]string{"io.katacontainers.config.hypervisor.default_vcpus": "2"}}
+	opts := CreateContainerOptions{Config: &Config{Image: "base"}, HostConfig: &hostConfig}
+	if _, err := client.CreateContainer(opts); err != nil {
+		t.Fatal(err)
+	}
+	var gotBody struct {
+		HostConfig HostConfig
+	}
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&gotBody); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotBody.HostConfig.Annotations, hostConfig.Annotations) {
+		t.Errorf("CreateContainer: wrong annotations. Want %#v. Got %#v.", hostConfig.Annotations, gotBody.HostConfig.Annotations)
+	}
+}
+
+func TestCreateContainerWithAnnotationsOldAPI(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "{}", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	hostConfig := HostConfig{Annotations: map[string]string{"dev.gvisor.spec.mount": "shared"}}
+	opts := CreateContainerOptions{Config: &Config{Image: "base"}, HostConfig: &hostConfig}
+	if _, err := client.CreateContainer(opts); err == nil {
+		t.Error("CreateContainer: unexpected <nil> error")
+	}
+	if len(fakeRT.requests) != 0 {
+		t.Errorf("CreateContainer: unexpected requests: %v", fakeRT.requests)
+	}
+}
+
+func TestInspectContainerAnnotations(t *testing.T) {
+	t.Parallel()
+	jsonContainer := `{"Id":"4fa6e0f0c678","HostConfig":{"Runtime":"kata","Annotations":{"io.katacontainers.config.hypervisor.default_vcpus":"2"}}}`
+	client := newTestClient(&FakeRoundTripper{message: jsonContainer, status: http.StatusOK})
+	container, err := client.InspectContainer("4fa6e0f0c678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"io.katacontainers.config.hypervisor.default_vcpus": "2"}
+	if !reflect.DeepEqual(container.HostConfig.Annotations, expected) {
+		t.Errorf("InspectContainer: wrong annotations. Want %#v. Got %#v.", expected, container.HostConfig.Annotations)
+	}
+}
+
+func TestCreateContainerWithRuntime(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "{}", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	opts := CreateContainerOptions{Config: &Config{Image: "base"}, HostConfig: &HostConfig{Runtime: "runsc"}}
+	if _, err := client.CreateContainer(opts); err != nil {
+		t.Fatal(err)
+	}
+	var gotBody struct {
+		HostConfig map[string]interface{}
+	}
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&gotBody); err != nil {
+		t.Fatal(err)
+	}
+	if runtime := gotBody.HostConfig["Runtime"]; runtime != "runsc" {
+		t.Errorf("CreateContainer: wrong runtime. Want %q. Got %v.", "runsc", runtime)
+	}
+}
+
 func TestUpdateContainer(t *testing.T) {
 	t.Parallel()
 	fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
@@ -2669,6 +2737,160 @@ func TestStatsContainerNotFound(t *testing.T) {
 	}
 }
 
+func TestStatsCgroupV2(t *testing.T) {
+	t.Parallel()
+	const jsonStats = `{
+		"read": "2021-03-01T10:00:00Z",
+		"memory_stats": {
+			"usage": 8290304,
+			"limit": 2083807232,
+			"stats": {
+				"anon": 1347584,
+				"file": 5406720,
+				"kernel_stack": 16384,
+				"slab": 479584,
+				"sock": 4096,
+				"shmem": 8192,
+				"file_mapped": 2973696,
+				"inactive_file": 4325376,
+				"pgscan": 12,
+				"pgsteal": 10,
+				"workingset_refault": 3
+			},
+			"psi": {
+				"some": {"avg10": 1.5, "avg60": 0.75, "avg300": 0.25, "total": 123456},
+				"full": {"avg10": 0.5, "avg60": 0, "avg300": 0, "total": 4567}
+			}
+		},
+		"cpu_stats": {
+			"cpu_usage": {"total_usage": 100},
+			"psi": {"some": {"avg10": 2, "avg60": 1, "avg300": 0.5, "total": 999}}
+		},
+		"blkio_stats": {
+			"io_service_bytes_recursive": [{"major": 8, "minor": 0, "op": "read", "value": 4096}],
+			"psi": {"some": {"total": 10}, "full": {"total": 5}}
+		}
+	}`
+	var stats Stats
+	if err := json.Unmarshal([]byte(jsonStats), &stats); err != nil {
+		t.Fatal(err)
+	}
+	mem := stats.MemoryStats.Stats
+	if mem.Anon != 1347584 || mem.File != 5406720 || mem.KernelStack != 16384 || mem.Slab != 479584 ||
+		mem.Sock != 4096 || mem.Shmem != 8192 || mem.FileMapped != 2973696 || mem.InactiveFile != 4325376 ||
+		mem.Pgscan != 12 || mem.Pgsteal != 10 || mem.WorkingsetRefault != 3 {
+		t.Errorf("Stats: wrong cgroup v2 memory stats: %+v", mem)
+	}
+	if mem.Cache != 0 || mem.Rss != 0 || mem.TotalInactiveFile != 0 {
+		t.Errorf("Stats: cgroup v1 memory stats should be zero: %+v", mem)
+	}
+	expectedMemoryPSI := &PSIStats{
+		Some: PSIData{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25, Total: 123456},
+		Full: PSIData{Avg10: 0.5, Total: 4567},
+	}
+	if !reflect.DeepEqual(stats.MemoryStats.PSI, expectedMemoryPSI) {
+		t.Errorf("Stats: wrong memory PSI. Want %+v. Got %+v.", expectedMemoryPSI, stats.MemoryStats.PSI)
+	}
+	if psi := stats.CPUStats.PSI; psi == nil || psi.Some.Total != 999 || psi.Full.Total != 0 {
+		t.Errorf("Stats: wrong CPU PSI: %+v", psi)
+	}
+	if psi := stats.BlkioStats.PSI; psi == nil || psi.Some.Total != 10 || psi.Full.Total != 5 {
+		t.Errorf("Stats: wrong blkio PSI: %+v", psi)
+	}
+	if stats.PreCPUStats.PSI != nil {
+		t.Errorf("Stats: absent PSI should be nil, got %+v", stats.PreCPUStats.PSI)
+	}
+}
+
+func TestStatsCgroupV1Unchanged(t *testing.T) {
+	t.Parallel()
+	const jsonStats = `{
+		"memory_stats": {"usage": 100, "stats": {"cache": 10, "rss": 90, "total_inactive_file": 5}},
+		"cpu_stats": {"cpu_usage": {"total_usage": 100}}
+	}`
+	var got Stats
+	if err := json.Unmarshal([]byte(jsonStats), &got); err != nil {
+		t.Fatal(err)
+	}
+	var expected Stats
+	expected.MemoryStats.Usage = 100
+	expected.MemoryStats.Stats.Cache = 10
+	expected.MemoryStats.Stats.Rss = 90
+	expected.MemoryStats.Stats.TotalInactiveFile = 5
+	expected.CPUStats.CPUUsage.TotalUsage = 100
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Stats: cgroup v1 sample decoded differently.\nWant %+v\nGot  %+v", expected, got)
+	}
+}
+
+func TestStatsTotals(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name                          string
+		json                          string
+		rx, tx, blkioRead, blkioWrite uint64
+	}{
+		{
+			name: "networks",
+			json: `{"network": {"rx_bytes": 1000, "tx_bytes": 1000},
+				"networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 5, "tx_bytes": 7}}}`,
+			rx: 15, tx: 27,
+		},
+		{
+			name: "legacy network",
+			json: `{"network": {"rx_bytes": 10, "tx_bytes": 20}}`,
+			rx:   10, tx: 20,
+		},
+		{
+			name: "cgroup v1 blkio",
+			json: `{"blkio_stats": {"io_service_bytes_recursive": [
+				{"major": 8, "minor": 0, "op": "Read", "value": 100},
+				{"major": 8, "minor": 0, "op": "Write", "value": 200},
+				{"major": 8, "minor": 0, "op": "Sync", "value": 300},
+				{"major": 8, "minor": 0, "op": "Total", "value": 300},
+				{"major": 8, "minor": 16, "op": "Read", "value": 1},
+				{"major": 8, "minor": 16, "op": "Write", "value": 2}
+			]}}`,
+			blkioRead: 101, blkioWrite: 202,
+		},
+		{
+			name: "cgroup v2 blkio",
+			json: `{"blkio_stats": {"io_service_bytes_recursive": [
+				{"major": 259, "minor": 0, "op": "read", "value": 4096},
+				{"major": 259, "minor": 0, "op": "write", "value": 8192}
+			]}}`,
+			blkioRead: 4096, blkioWrite: 8192,
+		},
+		{
+			name:      "windows storage",
+			json:      `{"storage_stats": {"read_size_bytes": 30, "write_size_bytes": 40}}`,
+			blkioRead: 30, blkioWrite: 40,
+		},
+		{
+			name: "empty",
+			json: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		var stats Stats
+		if err := json.Unmarshal([]byte(tt.json), &stats); err != nil {
+			t.Fatal(err)
+		}
+		if got := stats.TotalNetworkRx(); got != tt.rx {
+			t.Errorf("%s: TotalNetworkRx: want %d, got %d", tt.name, tt.rx, got)
+		}
+		if got := stats.TotalNetworkTx(); got != tt.tx {
+			t.Errorf("%s: TotalNetworkTx: want %d, got %d", tt.name, tt.tx, got)
+		}
+		if got := stats.TotalBlkioRead(); got != tt.blkioRead {
+			t.Errorf("%s: TotalBlkioRead: want %d, got %d", tt.name, tt.blkioRead, got)
+		}
+		if got := stats.TotalBlkioWrite(); got != tt.blkioWrite {
+			t.Errorf("%s: TotalBlkioWrite: want %d, got %d", tt.name, tt.blkioWrite, got)
+		}
+	}
+}
+
 func TestRenameContainer(t *testing.T) {
 	t.Parallel()
 	fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
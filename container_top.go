@@ -0,0 +1,105 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TopProcess is a process running inside a container, with the columns of
+// the ps output mapped to fields. Fields not reported by ps, because of the
+// ps_args given to TopContainerTyped, are left empty.
+type TopProcess struct {
+	PID     int
+	PPID    int
+	User    string
+	CPU     float64
+	Command string
+
+	// Fields are all the columns of the process, including the ones not
+	// mapped to a field, by title.
+	Fields map[string]string
+}
+
+// TypedTopResult is the result of TopContainerTyped: the processes running
+// in the container, and the raw result, for the columns not mapped by
+// TopProcess.
+type TypedTopResult struct {
+	Processes []TopProcess
+	Raw       TopResult
+}
+
+// the titles of the columns mapped to the fields of TopProcess, with the
+// variations of ps (e.g. "ps -ef", "ps aux" and "ps -o ...") and of the
+// Windows daemon.
+var (
+	topPIDTitles     = []string{"PID"}
+	topPPIDTitles    = []string{"PPID"}
+	topUserTitles    = []string{"USER", "UID", "EUSER", "RUSER", "UNAME"}
+	topCPUTitles     = []string{"%CPU", "C", "PCPU", "CP"}
+	topCommandTitles = []string{"CMD", "COMMAND", "ARGS", "COMM", "UCMD", "UCOMM", "NAME"}
+)
+
+// TopContainerTyped returns the processes running inside a container, like
+// TopContainer, with the columns of the ps output mapped to the fields of
+// TopProcess.
+//
+// The mapping is best effort: numeric columns that can't be parsed, like
+// the CPU time reported by Windows daemons, are left empty.
+func (c *Client) TopContainerTyped(id string, psArgs string) (*TypedTopResult, error) {
+	raw, err := c.TopContainer(id, psArgs)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedTopResult{Processes: ParseTopResult(raw), Raw: raw}, nil
+}
+
+// ParseTopResult maps the columns of a TopResult to the fields of
+// TopProcess. See TopContainerTyped for details.
+func ParseTopResult(result TopResult) []TopProcess {
+	pid := topColumn(result.Titles, topPIDTitles)
+	ppid := topColumn(result.Titles, topPPIDTitles)
+	user := topColumn(result.Titles, topUserTitles)
+	cpu := topColumn(result.Titles, topCPUTitles)
+	command := topColumn(result.Titles, topCommandTitles)
+	processes := make([]TopProcess, 0, len(result.Processes))
+	for _, row := range result.Processes {
+		column := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return row[i]
+		}
+		process := TopProcess{
+			User:    column(user),
+			Command: column(command),
+			Fields:  make(map[string]string, len(row)),
+		}
+		process.PID, _ = strconv.Atoi(column(pid))
+		process.PPID, _ = strconv.Atoi(column(ppid))
+		process.CPU, _ = strconv.ParseFloat(column(cpu), 64)
+		for i, title := range result.Titles {
+			if i < len(row) {
+				process.Fields[title] = row[i]
+			}
+		}
+		processes = append(processes, process)
+	}
+	return processes
+}
+
+// topColumn returns the index of the first of the titles found in the
+// titles of the columns, ignoring case, or -1.
+func topColumn(columns []string, titles []string) int {
+	for _, title := range titles {
+		for i, column := range columns {
+			if strings.EqualFold(column, title) {
+				return i
+			}
+		}
+	}
+	return -1
+}
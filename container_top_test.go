@@ -0,0 +1,100 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseTopResult(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		result   TopResult
+		expected []TopProcess
+	}{
+		{
+			"ps -ef",
+			TopResult{
+				Titles:    []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"},
+				Processes: [][]string{{"root", "3087", "815", "2", "01:44", "?", "00:00:00", "nginx -g daemon off;"}},
+			},
+			[]TopProcess{{
+				PID: 3087, PPID: 815, User: "root", CPU: 2, Command: "nginx -g daemon off;",
+				Fields: map[string]string{"UID": "root", "PID": "3087", "PPID": "815", "C": "2", "STIME": "01:44", "TTY": "?", "TIME": "00:00:00", "CMD": "nginx -g daemon off;"},
+			}},
+		},
+		{
+			"ps aux",
+			TopResult{
+				Titles:    []string{"USER", "PID", "%CPU", "%MEM", "VSZ", "RSS", "TTY", "STAT", "START", "TIME", "COMMAND"},
+				Processes: [][]string{{"redis", "12", "0.3", "0.1", "52812", "7560", "?", "Ssl", "10:01", "0:02", "redis-server *:6379"}},
+			},
+			[]TopProcess{{
+				PID: 12, User: "redis", CPU: 0.3, Command: "redis-server *:6379",
+				Fields: map[string]string{"USER": "redis", "PID": "12", "%CPU": "0.3", "%MEM": "0.1", "VSZ": "52812", "RSS": "7560", "TTY": "?", "STAT": "Ssl", "START": "10:01", "TIME": "0:02", "COMMAND": "redis-server *:6379"},
+			}},
+		},
+		{
+			"windows",
+			TopResult{
+				Titles:    []string{"Name", "PID", "CPU", "Private Working Set"},
+				Processes: [][]string{{"cmd.exe", "1584", "00:00:00.015", "1.8MB"}},
+			},
+			[]TopProcess{{
+				PID: 1584, Command: "cmd.exe",
+				Fields: map[string]string{"Name": "cmd.exe", "PID": "1584", "CPU": "00:00:00.015", "Private Working Set": "1.8MB"},
+			}},
+		},
+		{
+			"short rows",
+			TopResult{
+				Titles:    []string{"PID", "PPID", "ARGS"},
+				Processes: [][]string{{"1"}},
+			},
+			[]TopProcess{{PID: 1, Fields: map[string]string{"PID": "1"}}},
+		},
+	}
+	for _, tt := range tests {
+		processes := ParseTopResult(tt.result)
+		if !reflect.DeepEqual(processes, tt.expected) {
+			t.Errorf("ParseTopResult(%s): wrong processes.\nWant %#v.\nGot  %#v.", tt.name, tt.expected, processes)
+		}
+	}
+}
+
+func TestTopContainerTyped(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{
+		message: `{"Titles":["UID","PID","PPID","C","STIME","TTY","TIME","CMD"],"Processes":[["root","1","0","0","01:44","?","00:00:00","sh"]]}`,
+		status:  http.StatusOK,
+	}
+	client := newTestClient(fakeRT)
+	result, err := client.TopContainerTyped("4fa6e0f0", "-ef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Processes) != 1 || result.Processes[0].PID != 1 || result.Processes[0].Command != "sh" {
+		t.Errorf("TopContainerTyped: wrong processes: %#v", result.Processes)
+	}
+	if len(result.Raw.Processes) != 1 || len(result.Raw.Titles) != 8 {
+		t.Errorf("TopContainerTyped: wrong raw result: %#v", result.Raw)
+	}
+	if query := fakeRT.requests[0].URL.Query().Get("ps_args"); query != "-ef" {
+		t.Errorf("TopContainerTyped: wrong ps_args. Want %q. Got %q.", "-ef", query)
+	}
+}
+
+func TestTopContainerTypedNotFound(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "no such container", status: http.StatusNotFound})
+	_, err := client.TopContainerTyped("abef348", "")
+	expected := &NoSuchContainer{ID: "abef348"}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("TopContainerTyped: wrong error. Want %#v. Got %#v.", expected, err)
+	}
+}
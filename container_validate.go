@@ -0,0 +1,310 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// minimumMemoryLimit is the lowest memory limit accepted by the daemon.
+const minimumMemoryLimit = 6 * 1024 * 1024
+
+// ConfigFindingSeverity tells whether a ConfigFinding prevents the container
+// from being created.
+type ConfigFindingSeverity string
+
+const (
+	// ConfigFindingError means that the daemon would refuse to create the
+	// container.
+	ConfigFindingError = ConfigFindingSeverity("error")
+
+	// ConfigFindingWarning means that the container can be created, but part
+	// of its configuration is ignored or likely a mistake.
+	ConfigFindingWarning = ConfigFindingSeverity("warning")
+)
+
+// ConfigFinding is a problem found by ValidateContainerConfig.
+type ConfigFinding struct {
+	Severity ConfigFindingSeverity
+
+	// Field is the path of the offending field, e.g.
+	// "HostConfig.MemoryReservation". It's empty for the problems reported
+	// by the daemon, that don't identify the field.
+	Field   string
+	Message string
+}
+
+func (f ConfigFinding) String() string {
+	if f.Field == "" {
+		return string(f.Severity) + ": " + f.Message
+	}
+	return string(f.Severity) + ": " + f.Field + ": " + f.Message
+}
+
+// ValidateContainerConfigOptions specify parameters to the
+// ValidateContainerConfig method.
+type ValidateContainerConfigOptions struct {
+	CreateContainerOptions
+
+	// CheckDaemon also has the daemon check the configuration, by creating
+	// the container and removing it right away, when no error is found on
+	// the client side. The container is created without a name, so it
+	// doesn't hold the name of opts.Name, nor check it's available.
+	CheckDaemon bool
+}
+
+// ValidateContainerConfig checks the configuration of a container before
+// creating it, returning the problems found, if any. The error is only set
+// when the configuration couldn't be checked.
+//
+// The checks done on the client side cover the most common mistakes, like
+// conflicting fields, invalid port specs, restart policies or memory limits,
+// but not everything the daemon checks, see opts.CheckDaemon.
+func (c *Client) ValidateContainerConfig(opts ValidateContainerConfigOptions) ([]ConfigFinding, error) {
+	findings := validateContainerConfig(opts.Config, opts.HostConfig)
+	if !opts.CheckDaemon {
+		return findings, nil
+	}
+	for _, finding := range findings {
+		if finding.Severity == ConfigFindingError {
+			return findings, nil
+		}
+	}
+	createOpts := opts.CreateContainerOptions
+	createOpts.Name = ""
+	container, err := c.CreateContainer(createOpts)
+	if err != nil {
+		if err == ErrNoSuchImage {
+			return append(findings, ConfigFinding{Severity: ConfigFindingError, Field: "Config.Image", Message: "no such image"}), nil
+		}
+		if e, ok := err.(*Error); ok && isClientError(e.Status) {
+			return append(findings, ConfigFinding{Severity: ConfigFindingError, Message: strings.TrimSpace(e.Message)}), nil
+		}
+		return findings, err
+	}
+	err = c.RemoveContainer(RemoveContainerOptions{ID: container.ID, Force: true, RemoveVolumes: true, Context: opts.Context})
+	if err != nil {
+		return findings, fmt.Errorf("failed to remove the container %s created to check the configuration: %s", container.ID, err)
+	}
+	return findings, nil
+}
+
+type configFindings []ConfigFinding
+
+func (f *configFindings) errorf(field, format string, args ...interface{}) {
+	*f = append(*f, ConfigFinding{Severity: ConfigFindingError, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (f *configFindings) warnf(field, format string, args ...interface{}) {
+	*f = append(*f, ConfigFinding{Severity: ConfigFindingWarning, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func validateContainerConfig(config *Config, hostConfig *HostConfig) []ConfigFinding {
+	var findings configFindings
+	if config == nil {
+		findings.errorf("Config", "missing configuration")
+		config = &Config{}
+	} else if config.Image == "" {
+		findings.errorf("Config.Image", "the image is required")
+	}
+	for _, port := range sortedPorts(config.ExposedPorts) {
+		if err := validatePortSpec(port); err != nil {
+			findings.errorf("Config.ExposedPorts", "%s", err)
+		}
+	}
+	if hostConfig == nil {
+		return findings
+	}
+	validatePortBindings(&findings, hostConfig.PortBindings)
+	validateRestartPolicy(&findings, hostConfig)
+	validateMemory(&findings, hostConfig)
+	validateCPU(&findings, hostConfig)
+	validateNetworkMode(&findings, config, hostConfig)
+	return findings
+}
+
+// validatePortSpec checks a port in the form "port/proto" or
+// "start-end/proto".
+func validatePortSpec(port Port) error {
+	parts := strings.Split(string(port), "/")
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	if proto := port.Proto(); proto != "tcp" && proto != "udp" && proto != "sctp" {
+		return fmt.Errorf("invalid protocol %q in port %q", proto, port)
+	}
+	if _, _, err := parsePortRange(parts[0]); err != nil {
+		return fmt.Errorf("invalid port %q: %s", port, err)
+	}
+	return nil
+}
+
+func sortedPorts(ports map[Port]struct{}) []Port {
+	sorted := make([]Port, 0, len(ports))
+	for port := range ports {
+		sorted = append(sorted, port)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func parsePortRange(spec string) (start, end int, err error) {
+	parts := strings.SplitN(spec, "-", 2)
+	start, err = parsePortNumber(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end = start
+	if len(parts) == 2 {
+		end, err = parsePortNumber(parts[1])
+		if err != nil {
+			return 0, 0, err
+		}
+		if end < start {
+			return 0, 0, fmt.Errorf("the range %s is reversed", spec)
+		}
+	}
+	return start, end, nil
+}
+
+func parsePortNumber(spec string) (int, error) {
+	port, err := strconv.Atoi(spec)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a port number between 1 and 65535", spec)
+	}
+	return port, nil
+}
+
+func validatePortBindings(findings *configFindings, bindings map[Port][]PortBinding) {
+	ports := make(map[Port]struct{}, len(bindings))
+	for port := range bindings {
+		ports[port] = struct{}{}
+	}
+	for _, port := range sortedPorts(ports) {
+		portBindings := bindings[port]
+		if err := validatePortSpec(port); err != nil {
+			findings.errorf("HostConfig.PortBindings", "%s", err)
+			continue
+		}
+		containerStart, containerEnd, _ := parsePortRange(port.Port())
+		for _, binding := range portBindings {
+			if binding.HostIP != "" && net.ParseIP(binding.HostIP) == nil {
+				findings.errorf("HostConfig.PortBindings", "invalid host IP %q for port %s", binding.HostIP, port)
+			}
+			if binding.HostPort == "" {
+				continue
+			}
+			hostStart, hostEnd, err := parsePortRange(binding.HostPort)
+			if err != nil {
+				findings.errorf("HostConfig.PortBindings", "invalid host port for port %s: %s", port, err)
+				continue
+			}
+			if containerEnd > containerStart && hostEnd > hostStart && hostEnd-hostStart != containerEnd-containerStart {
+				findings.errorf("HostConfig.PortBindings", "the host port range %s doesn't have the size of the range of port %s", binding.HostPort, port)
+			}
+		}
+	}
+}
+
+func validateRestartPolicy(findings *configFindings, hostConfig *HostConfig) {
+	policy := hostConfig.RestartPolicy
+	switch policy.Name {
+	case "", "no", "always", "unless-stopped":
+		if policy.MaximumRetryCount != 0 {
+			findings.errorf("HostConfig.RestartPolicy.MaximumRetryCount", "the maximum retry count can only be set with the on-failure restart policy")
+		}
+	case "on-failure":
+		if policy.MaximumRetryCount < 0 {
+			findings.errorf("HostConfig.RestartPolicy.MaximumRetryCount", "the maximum retry count can't be negative")
+		}
+	default:
+		findings.errorf("HostConfig.RestartPolicy.Name", "invalid restart policy %q", policy.Name)
+		return
+	}
+	if hostConfig.AutoRemove && policy.Name != "" && policy.Name != "no" {
+		findings.errorf("HostConfig.AutoRemove", "auto-remove conflicts with the %s restart policy", policy.Name)
+	}
+}
+
+func validateMemory(findings *configFindings, hostConfig *HostConfig) {
+	if hostConfig.Memory < 0 {
+		findings.errorf("HostConfig.Memory", "the memory limit can't be negative")
+	} else if hostConfig.Memory > 0 && hostConfig.Memory < minimumMemoryLimit {
+		findings.errorf("HostConfig.Memory", "the minimum memory limit is 6MB")
+	}
+	if hostConfig.Memory > 0 && hostConfig.MemoryReservation > hostConfig.Memory {
+		findings.errorf("HostConfig.MemoryReservation", "the memory reservation (%d) must be lower than the memory limit (%d)", hostConfig.MemoryReservation, hostConfig.Memory)
+	}
+	if hostConfig.MemorySwap > 0 {
+		if hostConfig.Memory == 0 {
+			findings.errorf("HostConfig.MemorySwap", "the memory limit must be set along with the memory+swap limit")
+		} else if hostConfig.MemorySwap < hostConfig.Memory {
+			findings.errorf("HostConfig.MemorySwap", "the memory+swap limit (%d) must be greater than the memory limit (%d)", hostConfig.MemorySwap, hostConfig.Memory)
+		}
+	}
+	if swappiness := hostConfig.MemorySwappiness; swappiness != nil && (*swappiness < -1 || *swappiness > 100) {
+		findings.errorf("HostConfig.MemorySwappiness", "the memory swappiness must be between 0 and 100")
+	}
+	if disabled := hostConfig.OOMKillDisable; disabled != nil && *disabled && hostConfig.Memory == 0 {
+		findings.warnf("HostConfig.OOMKillDisable", "disabling the OOM killer without a memory limit can make the host run out of memory")
+	}
+}
+
+func validateCPU(findings *configFindings, hostConfig *HostConfig) {
+	if period := hostConfig.CPUPeriod; period != 0 && (period < 1000 || period > 1000000) {
+		findings.errorf("HostConfig.CPUPeriod", "the CPU period must be between 1ms and 1s (1000-1000000)")
+	}
+	if quota := hostConfig.CPUQuota; quota > 0 && quota < 1000 {
+		findings.errorf("HostConfig.CPUQuota", "the CPU quota must be at least 1ms (1000)")
+	}
+	if hostConfig.CPUShares < 0 {
+		findings.errorf("HostConfig.CPUShares", "the CPU shares can't be negative")
+	}
+}
+
+func validateNetworkMode(findings *configFindings, config *Config, hostConfig *HostConfig) {
+	mode := hostConfig.NetworkMode
+	switch {
+	case mode == "host":
+		if len(hostConfig.PortBindings) > 0 || hostConfig.PublishAllPorts {
+			findings.warnf("HostConfig.PortBindings", "published ports are ignored with the host network mode")
+		}
+	case mode == "none":
+		if len(hostConfig.PortBindings) > 0 || hostConfig.PublishAllPorts {
+			findings.warnf("HostConfig.PortBindings", "published ports are ignored with the none network mode")
+		}
+	case strings.HasPrefix(mode, "container:"):
+		if strings.TrimPrefix(mode, "container:") == "" {
+			findings.errorf("HostConfig.NetworkMode", "missing container in network mode %q", mode)
+		}
+		conflicts := []struct {
+			field string
+			set   bool
+		}{
+			{"Config.Hostname", config.Hostname != ""},
+			{"Config.MacAddress", config.MacAddress != ""},
+			{"Config.ExposedPorts", len(config.ExposedPorts) > 0},
+			{"HostConfig.PortBindings", len(hostConfig.PortBindings) > 0 || hostConfig.PublishAllPorts},
+			{"HostConfig.DNS", len(hostConfig.DNS) > 0},
+			{"HostConfig.ExtraHosts", len(hostConfig.ExtraHosts) > 0},
+		}
+		for _, conflict := range conflicts {
+			if conflict.set {
+				findings.errorf(conflict.field, "conflicts with the network mode %q", mode)
+			}
+		}
+	}
+}
+
+// isClientError reports whether the daemon refused the request because of
+// the request itself.
+func isClientError(status int) bool {
+	return status >= http.StatusBadRequest && status < http.StatusInternalServerError
+}
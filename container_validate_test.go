@@ -0,0 +1,237 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestValidateContainerConfigFindings(t *testing.T) {
+	t.Parallel()
+	oomKillDisable := true
+	swappiness := int64(120)
+	var tests = []struct {
+		name       string
+		config     *Config
+		hostConfig *HostConfig
+		expected   []string
+	}{
+		{
+			name:   "valid",
+			config: &Config{Image: "nginx", ExposedPorts: map[Port]struct{}{"80/tcp": {}, "53/udp": {}}},
+			hostConfig: &HostConfig{
+				PortBindings:      map[Port][]PortBinding{"80/tcp": {{HostIP: "127.0.0.1", HostPort: "8080"}}, "9000-9001/tcp": {{HostPort: "19000-19001"}}},
+				RestartPolicy:     RestartOnFailure(3),
+				Memory:            64 << 20,
+				MemoryReservation: 32 << 20,
+				MemorySwap:        128 << 20,
+			},
+		},
+		{
+			name:     "missing config",
+			expected: []string{"error: Config: missing configuration"},
+		},
+		{
+			name:   "ports",
+			config: &Config{ExposedPorts: map[Port]struct{}{"80/http": {}, "70000": {}}},
+			hostConfig: &HostConfig{PortBindings: map[Port][]PortBinding{
+				"80/tcp":        {{HostIP: "localhost", HostPort: "x"}},
+				"9000-9002/tcp": {{HostPort: "19000-19001"}},
+			}},
+			expected: []string{
+				"error: Config.Image: the image is required",
+				`error: Config.ExposedPorts: invalid port "70000": "70000" is not a port number between 1 and 65535`,
+				`error: Config.ExposedPorts: invalid protocol "http" in port "80/http"`,
+				`error: HostConfig.PortBindings: invalid host IP "localhost" for port 80/tcp`,
+				`error: HostConfig.PortBindings: invalid host port for port 80/tcp: "x" is not a port number between 1 and 65535`,
+				"error: HostConfig.PortBindings: the host port range 19000-19001 doesn't have the size of the range of port 9000-9002/tcp",
+			},
+		},
+		{
+			name:       "restart policy",
+			config:     &Config{Image: "nginx"},
+			hostConfig: &HostConfig{RestartPolicy: RestartPolicy{Name: "always", MaximumRetryCount: 2}, AutoRemove: true},
+			expected: []string{
+				"error: HostConfig.RestartPolicy.MaximumRetryCount: the maximum retry count can only be set with the on-failure restart policy",
+				"error: HostConfig.AutoRemove: auto-remove conflicts with the always restart policy",
+			},
+		},
+		{
+			name:       "unknown restart policy",
+			config:     &Config{Image: "nginx"},
+			hostConfig: &HostConfig{RestartPolicy: RestartPolicy{Name: "sometimes"}},
+			expected:   []string{`error: HostConfig.RestartPolicy.Name: invalid restart policy "sometimes"`},
+		},
+		{
+			name:   "memory",
+			config: &Config{Image: "nginx"},
+			hostConfig: &HostConfig{
+				Memory:            1 << 20,
+				MemoryReservation: 2 << 20,
+				MemorySwap:        512 << 10,
+				MemorySwappiness:  &swappiness,
+			},
+			expected: []string{
+				"error: HostConfig.Memory: the minimum memory limit is 6MB",
+				"error: HostConfig.MemoryReservation: the memory reservation (2097152) must be lower than the memory limit (1048576)",
+				"error: HostConfig.MemorySwap: the memory+swap limit (524288) must be greater than the memory limit (1048576)",
+				"error: HostConfig.MemorySwappiness: the memory swappiness must be between 0 and 100",
+			},
+		},
+		{
+			name:       "swap without memory",
+			config:     &Config{Image: "nginx"},
+			hostConfig: &HostConfig{MemorySwap: 64 << 20, OOMKillDisable: &oomKillDisable},
+			expected: []string{
+				"error: HostConfig.MemorySwap: the memory limit must be set along with the memory+swap limit",
+				"warning: HostConfig.OOMKillDisable: disabling the OOM killer without a memory limit can make the host run out of memory",
+			},
+		},
+		{
+			name:       "cpu",
+			config:     &Config{Image: "nginx"},
+			hostConfig: &HostConfig{CPUPeriod: 100, CPUQuota: 10},
+			expected: []string{
+				"error: HostConfig.CPUPeriod: the CPU period must be between 1ms and 1s (1000-1000000)",
+				"error: HostConfig.CPUQuota: the CPU quota must be at least 1ms (1000)",
+			},
+		},
+		{
+			name:       "host network",
+			config:     &Config{Image: "nginx"},
+			hostConfig: &HostConfig{NetworkMode: "host", PublishAllPorts: true},
+			expected:   []string{"warning: HostConfig.PortBindings: published ports are ignored with the host network mode"},
+		},
+		{
+			name:       "container network",
+			config:     &Config{Image: "nginx", Hostname: "web"},
+			hostConfig: &HostConfig{NetworkMode: "container:db", DNS: []string{"8.8.8.8"}},
+			expected: []string{
+				`error: Config.Hostname: conflicts with the network mode "container:db"`,
+				`error: HostConfig.DNS: conflicts with the network mode "container:db"`,
+			},
+		},
+	}
+	client := newTestClient(&FakeRoundTripper{status: http.StatusInternalServerError})
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			findings, err := client.ValidateContainerConfig(ValidateContainerConfigOptions{
+				CreateContainerOptions: CreateContainerOptions{Config: test.config, HostConfig: test.hostConfig},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, finding := range findings {
+				got = append(got, finding.String())
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("ValidateContainerConfig: wrong findings.\nWant %#v.\nGot  %#v.", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateContainerConfigCheckDaemon(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name         string
+		createStatus int
+		createBody   string
+		expected     []ConfigFinding
+		removed      bool
+	}{
+		{
+			name:         "valid",
+			createStatus: http.StatusCreated,
+			createBody:   `{"Id": "abc"}`,
+			removed:      true,
+		},
+		{
+			name:         "invalid",
+			createStatus: http.StatusBadRequest,
+			createBody:   "invalid mount config for type \"bind\": bind source path does not exist\n",
+			expected: []ConfigFinding{
+				{Severity: ConfigFindingError, Message: `invalid mount config for type "bind": bind source path does not exist`},
+			},
+		},
+		{
+			name:         "no such image",
+			createStatus: http.StatusNotFound,
+			createBody:   "No such image: nginx:latest",
+			expected: []ConfigFinding{
+				{Severity: ConfigFindingError, Field: "Config.Image", Message: "no such image"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var removed bool
+			var createName string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/containers/create":
+					createName = r.URL.Query().Get("name")
+					w.WriteHeader(test.createStatus)
+					w.Write([]byte(test.createBody))
+				case r.URL.Path == "/containers/abc" && r.Method == http.MethodDelete:
+					if r.URL.Query().Get("force") != "1" || r.URL.Query().Get("v") != "1" {
+						t.Errorf("ValidateContainerConfig: wrong removal query: %s", r.URL.RawQuery)
+					}
+					removed = true
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings, err := client.ValidateContainerConfig(ValidateContainerConfigOptions{
+				CreateContainerOptions: CreateContainerOptions{Name: "web", Config: &Config{Image: "nginx"}, HostConfig: &HostConfig{}},
+				CheckDaemon:            true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(findings, test.expected) {
+				t.Errorf("ValidateContainerConfig: wrong findings. Want %#v. Got %#v.", test.expected, findings)
+			}
+			if createName != "" {
+				t.Errorf("ValidateContainerConfig: the container was created with the name %q", createName)
+			}
+			if removed != test.removed {
+				t.Errorf("ValidateContainerConfig: wrong removal. Want %v. Got %v.", test.removed, removed)
+			}
+		})
+	}
+}
+
+func TestValidateContainerConfigCheckDaemonSkippedOnErrors(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id": "abc"}`, status: http.StatusCreated}
+	client := newTestClient(fakeRT)
+	findings, err := client.ValidateContainerConfig(ValidateContainerConfigOptions{
+		CreateContainerOptions: CreateContainerOptions{Config: &Config{}},
+		CheckDaemon:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 {
+		t.Errorf("ValidateContainerConfig: wrong findings: %#v", findings)
+	}
+	if len(fakeRT.requests) > 0 {
+		t.Errorf("ValidateContainerConfig: unexpected requests to the daemon: %d", len(fakeRT.requests))
+	}
+}
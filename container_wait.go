@@ -0,0 +1,202 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrWaitHubClosed is the error delivered to pending waiters when their
+// WaitHub is closed.
+var ErrWaitHubClosed = errors.New("wait hub closed")
+
+const (
+	waitHubBufferSize = 1024
+
+	// waitHubCheckInterval is the interval between two inspections of the
+	// containers still awaited, resolving those whose die event was missed.
+	waitHubCheckInterval = 30 * time.Second
+)
+
+// WaitResult is the outcome of waiting for a container using a WaitHub.
+type WaitResult struct {
+	ExitCode int
+	Err      error
+}
+
+// WaitHub waits for many containers to exit using a single subscription to
+// the events API, instead of one WaitContainer request per container. The
+// containers still awaited are inspected periodically as well, as events are
+// dropped when the hub falls behind.
+//
+// A WaitHub is safe for concurrent use, and must be closed when it's no longer
+// needed.
+type WaitHub struct {
+	client *Client
+	done   chan struct{}
+
+	mu       sync.Mutex
+	listener chan *APIEvents
+	waiters  map[string][]chan WaitResult
+	closed   bool
+}
+
+// NewWaitHub creates a WaitHub and subscribes it to the events of the Docker
+// daemon.
+func (c *Client) NewWaitHub() (*WaitHub, error) {
+	return c.newWaitHub(waitHubCheckInterval)
+}
+
+func (c *Client) newWaitHub(checkInterval time.Duration) (*WaitHub, error) {
+	h := WaitHub{
+		client:   c,
+		done:     make(chan struct{}),
+		listener: make(chan *APIEvents, waitHubBufferSize),
+		waiters:  make(map[string][]chan WaitResult),
+	}
+	if err := c.AddEventListener(h.listener); err != nil {
+		return nil, err
+	}
+	go h.loop(h.listener, checkInterval)
+	return &h, nil
+}
+
+// Wait returns a channel that receives the result once the given container
+// (identified by its ID or name) exits. Containers that are not running
+// resolve immediately with their last exit code.
+func (h *WaitHub) Wait(id string) <-chan WaitResult {
+	result := make(chan WaitResult, 1)
+	container, err := h.client.InspectContainer(id)
+	if err != nil {
+		result <- WaitResult{Err: err}
+		return result
+	}
+	if !container.State.Running {
+		result <- WaitResult{ExitCode: container.State.ExitCode}
+		return result
+	}
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		result <- WaitResult{Err: ErrWaitHubClosed}
+		return result
+	}
+	h.waiters[container.ID] = append(h.waiters[container.ID], result)
+	h.mu.Unlock()
+
+	// the container may have exited between the inspect and the
+	// registration of the waiter.
+	h.check(container.ID)
+	return result
+}
+
+// Close unsubscribes the hub from the events API. Pending waiters receive
+// ErrWaitHubClosed.
+func (h *WaitHub) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.done)
+	listener := h.listener
+	h.mu.Unlock()
+	h.failAll(ErrWaitHubClosed)
+	return h.client.RemoveEventListener(listener)
+}
+
+func (h *WaitHub) loop(listener chan *APIEvents, checkInterval time.Duration) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			h.checkAll()
+		case event, ok := <-listener:
+			if !ok {
+				// event monitoring was interrupted: subscribe again
+				// and check containers that may have exited meanwhile.
+				var err error
+				if listener, err = h.resubscribe(); err != nil {
+					h.failAll(err)
+					return
+				}
+				h.checkAll()
+				continue
+			}
+			if event.Type == "container" && event.Action == "die" {
+				exitCode, _ := strconv.Atoi(event.Actor.Attributes["exitCode"])
+				h.resolve(event.Actor.ID, WaitResult{ExitCode: exitCode})
+			}
+		}
+	}
+}
+
+func (h *WaitHub) resubscribe() (chan *APIEvents, error) {
+	listener := make(chan *APIEvents, waitHubBufferSize)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrWaitHubClosed
+	}
+	if err := h.client.AddEventListener(listener); err != nil {
+		return nil, err
+	}
+	h.listener = listener
+	return listener, nil
+}
+
+func (h *WaitHub) check(id string) {
+	container, err := h.client.InspectContainer(id)
+	if err != nil {
+		if _, ok := err.(*NoSuchContainer); ok {
+			h.resolve(id, WaitResult{Err: err})
+		}
+		return
+	}
+	if !container.State.Running {
+		h.resolve(id, WaitResult{ExitCode: container.State.ExitCode})
+	}
+}
+
+func (h *WaitHub) checkAll() {
+	h.mu.Lock()
+	ids := make([]string, 0, len(h.waiters))
+	for id := range h.waiters {
+		ids = append(ids, id)
+	}
+	h.mu.Unlock()
+	for _, id := range ids {
+		h.check(id)
+	}
+}
+
+func (h *WaitHub) resolve(id string, result WaitResult) {
+	h.mu.Lock()
+	waiters := h.waiters[id]
+	delete(h.waiters, id)
+	h.mu.Unlock()
+	for _, w := range waiters {
+		w <- result
+	}
+}
+
+func (h *WaitHub) failAll(err error) {
+	h.mu.Lock()
+	waiters := h.waiters
+	h.waiters = make(map[string][]chan WaitResult)
+	h.mu.Unlock()
+	for _, ws := range waiters {
+		for _, w := range ws {
+			w <- WaitResult{Err: err}
+		}
+	}
+}
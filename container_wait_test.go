@@ -0,0 +1,150 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newWaitHubServer(die <-chan string, stop <-chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case id := <-die:
+					fmt.Fprintf(w, `{"Type":"container","Action":"die","Actor":{"ID":%q,"Attributes":{"exitCode":"3"}},"time":%d}`+"\n", id, time.Now().Unix())
+					w.(http.Flusher).Flush()
+				case <-stop:
+					return
+				}
+			}
+		case "/containers/running/json", "/containers/running-full-id/json":
+			w.Write([]byte(`{"Id":"running-full-id","State":{"Running":true}}`))
+		case "/containers/exited/json":
+			w.Write([]byte(`{"Id":"exited-full-id","State":{"Running":false,"ExitCode":7}}`))
+		default:
+			http.Error(w, "no such container", http.StatusNotFound)
+		}
+	}))
+}
+
+func waitResult(t *testing.T, ch <-chan WaitResult) WaitResult {
+	select {
+	case result := <-ch:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for result")
+	}
+	return WaitResult{}
+}
+
+func TestWaitHub(t *testing.T) {
+	t.Parallel()
+	die := make(chan string, 1)
+	stop := make(chan struct{})
+	server := newWaitHubServer(die, stop)
+	defer server.Close()
+	defer close(stop)
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub, err := client.NewWaitHub()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hub.Close()
+
+	exited := waitResult(t, hub.Wait("exited"))
+	if exited.Err != nil || exited.ExitCode != 7 {
+		t.Errorf("WaitHub: wrong result for exited container. Want exit code 7. Got %#v.", exited)
+	}
+	missing := waitResult(t, hub.Wait("missing"))
+	if _, ok := missing.Err.(*NoSuchContainer); !ok {
+		t.Errorf("WaitHub: wrong error for missing container. Want NoSuchContainer. Got %#v.", missing.Err)
+	}
+
+	first := hub.Wait("running")
+	second := hub.Wait("running")
+	die <- "running-full-id"
+	for _, ch := range []<-chan WaitResult{first, second} {
+		result := waitResult(t, ch)
+		if result.Err != nil || result.ExitCode != 3 {
+			t.Errorf("WaitHub: wrong result for running container. Want exit code 3. Got %#v.", result)
+		}
+	}
+}
+
+func TestWaitHubClose(t *testing.T) {
+	t.Parallel()
+	stop := make(chan struct{})
+	server := newWaitHubServer(nil, stop)
+	defer server.Close()
+	defer close(stop)
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub, err := client.NewWaitHub()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := hub.Wait("running")
+	if err := hub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if result := waitResult(t, pending); result.Err != ErrWaitHubClosed {
+		t.Errorf("WaitHub: wrong error after Close. Want %#v. Got %#v.", ErrWaitHubClosed, result.Err)
+	}
+	if result := waitResult(t, hub.Wait("running")); result.Err != ErrWaitHubClosed {
+		t.Errorf("WaitHub: wrong error after Close. Want %#v. Got %#v.", ErrWaitHubClosed, result.Err)
+	}
+}
+
+func TestWaitHubMissedEvent(t *testing.T) {
+	t.Parallel()
+	stop := make(chan struct{})
+	var inspections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-stop
+		case "/containers/running/json":
+			// the container exits after the registration of the waiter,
+			// without a die event.
+			if atomic.AddInt32(&inspections, 1) <= 2 {
+				w.Write([]byte(`{"Id":"running","State":{"Running":true}}`))
+				return
+			}
+			w.Write([]byte(`{"Id":"running","State":{"Running":false,"ExitCode":5}}`))
+		default:
+			http.Error(w, "no such container", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer close(stop)
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub, err := client.newWaitHub(10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hub.Close()
+	if result := waitResult(t, hub.Wait("running")); result.Err != nil || result.ExitCode != 5 {
+		t.Errorf("WaitHub: wrong result for missed die event. Want exit code 5. Got %#v.", result)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// ErrNotWindowsDaemon is the error returned when a feature that is only
+// supported by Windows daemons is used with a daemon running another
+// operating system.
+var ErrNotWindowsDaemon = errors.New("feature only supported by windows daemons")
+
+// SetCredentialSpecFromFile loads the gMSA credential spec in the given file
+// and adds it to the security options in hostConfig, so containers created
+// with it run with the identity of the group managed service account.
+//
+// The file is read by the client, so it doesn't need to be in the
+// CredentialSpecs directory of the daemon. It returns ErrNotWindowsDaemon if
+// the daemon is not running on Windows.
+func (c *Client) SetCredentialSpecFromFile(hostConfig *HostConfig, path string) error {
+	info, err := c.Info()
+	if err != nil {
+		return err
+	}
+	if info.OSType != "windows" {
+		return ErrNotWindowsDaemon
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var spec bytes.Buffer
+	if err = json.Compact(&spec, data); err != nil {
+		return fmt.Errorf("invalid credential spec %s: %s", path, err)
+	}
+	hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "credentialspec=raw://"+spec.String())
+	return nil
+}
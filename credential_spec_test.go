@@ -0,0 +1,67 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeCredentialSpec(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "credspec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "gmsa.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSetCredentialSpecFromFile(t *testing.T) {
+	t.Parallel()
+	path := writeCredentialSpec(t, "{\n  \"CmsPlugins\": [\"ActiveDirectory\"],\n  \"DomainJoinConfig\": {\"Sid\": \"S-1-5-21\"}\n}\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	client := newTestClient(&FakeRoundTripper{message: `{"OSType":"windows"}`, status: http.StatusOK})
+	hostConfig := HostConfig{SecurityOpt: []string{"no-new-privileges"}}
+	if err := client.SetCredentialSpecFromFile(&hostConfig, path); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"no-new-privileges",
+		`credentialspec=raw://{"CmsPlugins":["ActiveDirectory"],"DomainJoinConfig":{"Sid":"S-1-5-21"}}`,
+	}
+	if !reflect.DeepEqual(hostConfig.SecurityOpt, expected) {
+		t.Errorf("SetCredentialSpecFromFile: wrong security options.\nWant %#v.\nGot %#v.", expected, hostConfig.SecurityOpt)
+	}
+}
+
+func TestSetCredentialSpecFromFileInvalid(t *testing.T) {
+	t.Parallel()
+	path := writeCredentialSpec(t, "not json")
+	defer os.RemoveAll(filepath.Dir(path))
+	client := newTestClient(&FakeRoundTripper{message: `{"OSType":"windows"}`, status: http.StatusOK})
+	var hostConfig HostConfig
+	if err := client.SetCredentialSpecFromFile(&hostConfig, path); err == nil {
+		t.Error("SetCredentialSpecFromFile: unexpected <nil> error")
+	}
+	if len(hostConfig.SecurityOpt) != 0 {
+		t.Errorf("SetCredentialSpecFromFile: unexpected security options: %#v", hostConfig.SecurityOpt)
+	}
+}
+
+func TestSetCredentialSpecFromFileNotWindows(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: `{"OSType":"linux"}`, status: http.StatusOK})
+	var hostConfig HostConfig
+	if err := client.SetCredentialSpecFromFile(&hostConfig, "gmsa.json"); err != ErrNotWindowsDaemon {
+		t.Errorf("SetCredentialSpecFromFile: wrong error. Want %#v. Got %#v.", ErrNotWindowsDaemon, err)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultMonitorInterval         = 5 * time.Second
+	defaultMonitorFailureThreshold = 3
+)
+
+// DaemonState is the liveness of the daemon, as seen by a Monitor.
+type DaemonState int
+
+const (
+	// DaemonUp means the last ping succeeded.
+	DaemonUp DaemonState = iota
+
+	// DaemonDegraded means the last pings failed, but not enough of them to
+	// consider the daemon down.
+	DaemonDegraded
+
+	// DaemonDown means at least FailureThreshold consecutive pings failed.
+	DaemonDown
+)
+
+func (s DaemonState) String() string {
+	switch s {
+	case DaemonUp:
+		return "up"
+	case DaemonDegraded:
+		return "degraded"
+	case DaemonDown:
+		return "down"
+	}
+	return "unknown"
+}
+
+// DaemonStatus is a change in the state of the daemon, as sent by
+// Monitor.Watch.
+type DaemonStatus struct {
+	State DaemonState
+
+	// ConsecutiveFailures is the number of pings that failed in a row, and
+	// Err the error of the last one. Both are zero when the daemon is up.
+	ConsecutiveFailures int
+	Err                 error
+
+	Time time.Time
+}
+
+// Monitor periodically pings the daemon to track its liveness, like the
+// health checks of gRPC, which agents can use to pause their work while the
+// daemon restarts.
+type Monitor struct {
+	// Interval is the interval between two pings. Defaults to five seconds.
+	Interval time.Duration
+
+	// Timeout is the timeout of each ping. Defaults to Interval.
+	Timeout time.Duration
+
+	// FailureThreshold is the number of consecutive failed pings after
+	// which the daemon is considered down instead of degraded. Defaults to
+	// three.
+	FailureThreshold int
+
+	client *Client
+}
+
+// NewMonitor returns a Monitor that pings the daemon using the client.
+func (c *Client) NewMonitor() *Monitor {
+	return &Monitor{client: c}
+}
+
+// Watch starts pinging the daemon, returning a channel that receives its
+// state after the first ping and then every change of state, until the
+// context is done, when the channel is closed.
+//
+// Changes of state are sent as they happen: a slow receiver delays the next
+// pings rather than missing a change.
+func (m *Monitor) Watch(ctx context.Context) <-chan DaemonStatus {
+	statuses := make(chan DaemonStatus, 1)
+	go m.watch(ctx, statuses)
+	return statuses
+}
+
+func (m *Monitor) watch(ctx context.Context, statuses chan<- DaemonStatus) {
+	defer close(statuses)
+	interval := m.Interval
+	if interval <= 0 {
+		interval = defaultMonitorInterval
+	}
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+	threshold := m.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultMonitorFailureThreshold
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var failures int
+	last := DaemonState(-1)
+	for {
+		err := m.ping(ctx, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+		status := DaemonStatus{State: DaemonUp, Time: time.Now()}
+		if err != nil {
+			failures++
+			status = DaemonStatus{State: DaemonDegraded, ConsecutiveFailures: failures, Err: err, Time: status.Time}
+			if failures >= threshold {
+				status.State = DaemonDown
+			}
+		} else {
+			failures = 0
+		}
+		if status.State != last {
+			select {
+			case statuses <- status:
+				last = status.State
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Monitor) ping(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return m.client.PingWithContext(ctx)
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMonitorWatch(t *testing.T) {
+	t.Parallel()
+	var down int32
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			http.Error(w, "restarting", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	monitor := client.NewMonitor()
+	monitor.Interval = 5 * time.Millisecond
+	monitor.FailureThreshold = 2
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	statuses := monitor.Watch(ctx)
+	next := func() DaemonStatus {
+		select {
+		case status := <-statuses:
+			return status
+		case <-ctx.Done():
+			t.Fatal("Monitor: timed out waiting for a status")
+		}
+		return DaemonStatus{}
+	}
+	if status := next(); status.State != DaemonUp || status.Err != nil {
+		t.Fatalf("Monitor: wrong initial status: %#v", status)
+	}
+	atomic.StoreInt32(&down, 1)
+	status := next()
+	if status.State != DaemonDegraded || status.ConsecutiveFailures != 1 {
+		t.Fatalf("Monitor: wrong status. Want degraded after one failure. Got %#v.", status)
+	}
+	if e, ok := status.Err.(*Error); !ok || e.Status != http.StatusServiceUnavailable {
+		t.Errorf("Monitor: wrong error: %#v", status.Err)
+	}
+	if status = next(); status.State != DaemonDown || status.ConsecutiveFailures != 2 {
+		t.Fatalf("Monitor: wrong status. Want down after two failures. Got %#v.", status)
+	}
+	atomic.StoreInt32(&down, 0)
+	if status = next(); status.State != DaemonUp || status.ConsecutiveFailures != 0 || status.Err != nil {
+		t.Fatalf("Monitor: wrong status. Want up. Got %#v.", status)
+	}
+	cancel()
+	for range statuses {
+	}
+}
+
+func TestDaemonStateString(t *testing.T) {
+	t.Parallel()
+	tests := map[DaemonState]string{
+		DaemonUp:       "up",
+		DaemonDegraded: "degraded",
+		DaemonDown:     "down",
+		DaemonState(9): "unknown",
+	}
+	for state, expected := range tests {
+		if got := state.String(); got != expected {
+			t.Errorf("DaemonState.String(): want %q. Got %q.", expected, got)
+		}
+	}
+}
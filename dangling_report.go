@@ -0,0 +1,89 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"time"
+)
+
+// DanglingReportOptions specify parameters to the DanglingReport function.
+type DanglingReportOptions struct {
+	// CreatedBefore restricts the stopped containers reported to the ones
+	// created before the given time, like the until filter of
+	// PruneContainers. Every stopped container is reported when it's zero.
+	CreatedBefore time.Time
+
+	Context context.Context
+}
+
+// DanglingReport lists the resources of the daemon nothing uses anymore,
+// which the prune functions would remove.
+type DanglingReport struct {
+	// Images are the dangling images: the untagged images no other image
+	// depends on.
+	Images []APIImages
+
+	// Volumes are the volumes not used by any container.
+	Volumes []Volume
+
+	// Containers are the created, exited and dead containers.
+	Containers []APIContainers
+
+	// Networks are the custom local networks no container is connected
+	// to. Swarm-scoped networks are left out, as they may be used by
+	// services.
+	Networks []Network
+}
+
+// DanglingReport returns the dangling images, unused volumes, stopped
+// containers and unused networks of the daemon, computed with the filters of
+// the list endpoints. It's the read-only companion to the prune functions.
+func (c *Client) DanglingReport(opts DanglingReportOptions) (*DanglingReport, error) {
+	var report DanglingReport
+	var err error
+	report.Images, err = c.ListImages(ListImagesOptions{
+		Filters: map[string][]string{"dangling": {"true"}},
+		Context: opts.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Volumes, err = c.ListVolumes(ListVolumesOptions{
+		Filters: map[string][]string{"dangling": {"true"}},
+		Context: opts.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.ListContainers(ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"status": {"created", "exited", "dead"}},
+		Context: opts.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		if !opts.CreatedBefore.IsZero() && !time.Unix(container.Created, 0).Before(opts.CreatedBefore) {
+			continue
+		}
+		report.Containers = append(report.Containers, container)
+	}
+	networks, err := c.filteredListNetworks(opts.Context, NetworkFilterOpts{
+		"dangling": {"true": true},
+		"type":     {"custom": true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, network := range networks {
+		if network.Scope == "swarm" {
+			continue
+		}
+		report.Networks = append(report.Networks, network)
+	}
+	return &report, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDanglingReport(t *testing.T) {
+	t.Parallel()
+	old := time.Now().Add(-48 * time.Hour).Unix()
+	recent := time.Now().Add(-time.Hour).Unix()
+	filters := make(map[string]map[string][]string)
+	recordFilters := func(r *http.Request) {
+		var f map[string][]string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("filters")), &f); err != nil {
+			t.Errorf("DanglingReport: invalid filters of %s: %v", r.URL.Path, err)
+		}
+		filters[r.URL.Path] = f
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/json", func(w http.ResponseWriter, r *http.Request) {
+		recordFilters(r)
+		w.Write([]byte(`[{"Id":"sha256:untagged","RepoTags":["<none>:<none>"]}]`))
+	})
+	mux.HandleFunc("/volumes", func(w http.ResponseWriter, r *http.Request) {
+		recordFilters(r)
+		w.Write([]byte(`{"Volumes":[{"Name":"orphan"}]}`))
+	})
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		recordFilters(r)
+		if r.URL.Query().Get("all") != "1" {
+			t.Errorf("DanglingReport: want all the containers listed. Got %s.", r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `[{"Id":"old","State":"exited","Created":%d},{"Id":"recent","State":"exited","Created":%d}]`, old, recent)
+	})
+	mux.HandleFunc("/networks", func(w http.ResponseWriter, r *http.Request) {
+		var f map[string]map[string]bool
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("filters")), &f); err != nil {
+			t.Errorf("DanglingReport: invalid filters of %s: %v", r.URL.Path, err)
+		}
+		expected := map[string]map[string]bool{"dangling": {"true": true}, "type": {"custom": true}}
+		if !reflect.DeepEqual(f, expected) {
+			t.Errorf("DanglingReport: wrong network filters. Want %#v. Got %#v.", expected, f)
+		}
+		w.Write([]byte(`[{"Name":"unused","Id":"n1","Scope":"local"},{"Name":"overlay","Id":"n2","Scope":"swarm"}]`))
+	})
+	client := NewClientFromHandler(mux)
+	report, err := client.DanglingReport(DanglingReportOptions{CreatedBefore: time.Now().Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFilters := map[string]map[string][]string{
+		"/images/json":     {"dangling": {"true"}},
+		"/volumes":         {"dangling": {"true"}},
+		"/containers/json": {"status": {"created", "exited", "dead"}},
+	}
+	if !reflect.DeepEqual(filters, expectedFilters) {
+		t.Errorf("DanglingReport: wrong filters. Want %#v. Got %#v.", expectedFilters, filters)
+	}
+	if len(report.Images) != 1 || report.Images[0].ID != "sha256:untagged" {
+		t.Errorf("DanglingReport: wrong images. Got %#v.", report.Images)
+	}
+	if len(report.Volumes) != 1 || report.Volumes[0].Name != "orphan" {
+		t.Errorf("DanglingReport: wrong volumes. Got %#v.", report.Volumes)
+	}
+	if len(report.Containers) != 1 || report.Containers[0].ID != "old" {
+		t.Errorf("DanglingReport: wrong containers. Got %#v.", report.Containers)
+	}
+	if len(report.Networks) != 1 || report.Networks[0].ID != "n1" {
+		t.Errorf("DanglingReport: wrong networks. Got %#v.", report.Networks)
+	}
+}
+
+func TestDanglingReportAllStoppedContainers(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("/volumes", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Volumes":null}`))
+	})
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"Id":"just-exited","State":"exited","Created":%d}]`, time.Now().Unix())
+	})
+	mux.HandleFunc("/networks", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	client := NewClientFromHandler(mux)
+	report, err := client.DanglingReport(DanglingReportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Containers) != 1 || report.Containers[0].ID != "just-exited" {
+		t.Errorf("DanglingReport: wrong containers. Got %#v.", report.Containers)
+	}
+	if len(report.Images) != 0 || len(report.Volumes) != 0 || len(report.Networks) != 0 {
+		t.Errorf("DanglingReport: want nothing else reported. Got %#v.", report)
+	}
+}
+
+func TestDanglingReportFailure(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "oops", status: http.StatusInternalServerError})
+	_, err := client.DanglingReport(DanglingReportOptions{})
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusInternalServerError {
+		t.Errorf("DanglingReport: wrong error. Got %#v.", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// CDIDriver is the driver of device requests for Container Device Interface
+// (CDI) devices.
+const CDIDriver = "cdi"
+
+// ErrCDINotSupported is the error returned by AddCDIDevices when the daemon
+// doesn't support CDI devices.
+var ErrCDINotSupported = errors.New("daemon doesn't support CDI devices")
+
+// cdiDeviceName matches fully-qualified CDI device names, in the form
+// vendor.com/class=name.
+var cdiDeviceName = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?/[a-zA-Z0-9]([a-zA-Z0-9_-]*[a-zA-Z0-9])?=[a-zA-Z0-9]([a-zA-Z0-9_.:-]*[a-zA-Z0-9])?$`)
+
+// DeviceRequest represents a request for devices from a device driver, like
+// GPUs (API 1.40+).
+type DeviceRequest struct {
+	Driver       string            `json:"Driver,omitempty" yaml:"Driver,omitempty" toml:"Driver,omitempty"`
+	Count        int               `json:"Count,omitempty" yaml:"Count,omitempty" toml:"Count,omitempty"`
+	DeviceIDs    []string          `json:"DeviceIDs,omitempty" yaml:"DeviceIDs,omitempty" toml:"DeviceIDs,omitempty"`
+	Capabilities [][]string        `json:"Capabilities,omitempty" yaml:"Capabilities,omitempty" toml:"Capabilities,omitempty"`
+	Options      map[string]string `json:"Options,omitempty" yaml:"Options,omitempty" toml:"Options,omitempty"`
+}
+
+// CDIDeviceRequest returns a request for the given CDI devices, which must be
+// fully-qualified, e.g. "nvidia.com/gpu=0" or "nvidia.com/gpu=all".
+func CDIDeviceRequest(devices ...string) (DeviceRequest, error) {
+	if len(devices) == 0 {
+		return DeviceRequest{}, errors.New("no CDI devices requested")
+	}
+	for _, device := range devices {
+		if !cdiDeviceName.MatchString(device) {
+			return DeviceRequest{}, fmt.Errorf("invalid CDI device %q: must be in the form vendor.com/class=name", device)
+		}
+	}
+	return DeviceRequest{
+		Driver:    CDIDriver,
+		DeviceIDs: append([]string(nil), devices...),
+	}, nil
+}
+
+// AddCDIDevices adds a request for the given CDI devices to hostConfig, after
+// checking that the daemon supports CDI, i.e. it has CDI spec directories
+// configured. It returns ErrCDINotSupported otherwise.
+func (c *Client) AddCDIDevices(hostConfig *HostConfig, devices ...string) error {
+	request, err := CDIDeviceRequest(devices...)
+	if err != nil {
+		return err
+	}
+	info, err := c.Info()
+	if err != nil {
+		return err
+	}
+	if len(info.CDISpecDirs) == 0 {
+		return ErrCDINotSupported
+	}
+	hostConfig.DeviceRequests = append(hostConfig.DeviceRequests, request)
+	return nil
+}
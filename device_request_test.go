@@ -0,0 +1,76 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCDIDeviceRequest(t *testing.T) {
+	t.Parallel()
+	request, err := CDIDeviceRequest("nvidia.com/gpu=0", "vendor.example.com/fpga=all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DeviceRequest{Driver: "cdi", DeviceIDs: []string{"nvidia.com/gpu=0", "vendor.example.com/fpga=all"}}
+	if !reflect.DeepEqual(request, expected) {
+		t.Errorf("CDIDeviceRequest: wrong request. Want %#v. Got %#v.", expected, request)
+	}
+}
+
+func TestCDIDeviceRequestInvalid(t *testing.T) {
+	t.Parallel()
+	tests := [][]string{
+		nil,
+		{"gpu=0"},
+		{"nvidia.com/gpu"},
+		{"nvidia.com/gpu="},
+		{"nvidia.com/gpu=0", "/dev/nvidia0"},
+	}
+	for _, devices := range tests {
+		if _, err := CDIDeviceRequest(devices...); err == nil {
+			t.Errorf("CDIDeviceRequest(%q): unexpected <nil> error", devices)
+		}
+	}
+}
+
+func TestAddCDIDevices(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"CDISpecDirs":["/etc/cdi","/var/run/cdi"]}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	var hostConfig HostConfig
+	if err := client.AddCDIDevices(&hostConfig, "nvidia.com/gpu=all"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(hostConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		DeviceRequests []map[string]interface{}
+	}
+	if err = json.Unmarshal(data, &body); err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{{"Driver": "cdi", "DeviceIDs": []interface{}{"nvidia.com/gpu=all"}}}
+	if !reflect.DeepEqual(body.DeviceRequests, expected) {
+		t.Errorf("AddCDIDevices: wrong device requests. Want %#v. Got %#v.", expected, body.DeviceRequests)
+	}
+}
+
+func TestAddCDIDevicesNotSupported(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: `{"OSType":"linux"}`, status: http.StatusOK})
+	var hostConfig HostConfig
+	if err := client.AddCDIDevices(&hostConfig, "nvidia.com/gpu=all"); err != ErrCDINotSupported {
+		t.Errorf("AddCDIDevices: wrong error. Want %#v. Got %#v.", ErrCDINotSupported, err)
+	}
+	if len(hostConfig.DeviceRequests) != 0 {
+		t.Errorf("AddCDIDevices: unexpected device requests: %#v", hostConfig.DeviceRequests)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// minHealthcheckDuration is the shortest non-zero duration accepted by the
+// daemon in healthcheck settings.
+const minHealthcheckDuration = time.Millisecond
+
+// StopTimeoutDuration returns the StopTimeout of the config, which the API
+// represents as an integer number of seconds, as a time.Duration.
+func (c *Config) StopTimeoutDuration() time.Duration {
+	return time.Duration(c.StopTimeout) * time.Second
+}
+
+// SetStopTimeout sets the StopTimeout of the config from a time.Duration,
+// which must be a non-negative whole number of seconds.
+func (c *Config) SetStopTimeout(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("invalid stop timeout %s: must not be negative", d)
+	}
+	if d%time.Second != 0 {
+		return fmt.Errorf("invalid stop timeout %s: must be a whole number of seconds", d)
+	}
+	c.StopTimeout = int(d / time.Second)
+	return nil
+}
+
+// Validate checks the healthcheck settings with the same rules as the daemon:
+// durations must be zero (to inherit them) or at least one millisecond, and
+// retries must not be negative. CreateContainer and CreateService validate
+// the healthcheck before sending it.
+func (h HealthConfig) Validate() error {
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"Interval", h.Interval},
+		{"Timeout", h.Timeout},
+		{"StartPeriod", h.StartPeriod},
+	}
+	for _, d := range durations {
+		if d.value != 0 && d.value < minHealthcheckDuration {
+			return fmt.Errorf("invalid healthcheck %s %s: must be zero or at least %s", d.name, d.value, minHealthcheckDuration)
+		}
+	}
+	if h.Retries < 0 {
+		return fmt.Errorf("invalid healthcheck Retries %d: must not be negative", h.Retries)
+	}
+	return nil
+}
+
+// validateServiceHealthcheck checks the healthcheck of the containers of a
+// service with the rules of HealthConfig.Validate.
+func validateServiceHealthcheck(spec swarm.ServiceSpec) error {
+	containerSpec := spec.TaskTemplate.ContainerSpec
+	if containerSpec == nil || containerSpec.Healthcheck == nil {
+		return nil
+	}
+	h := containerSpec.Healthcheck
+	return HealthConfig{
+		Test:        h.Test,
+		Interval:    h.Interval,
+		Timeout:     h.Timeout,
+		StartPeriod: h.StartPeriod,
+		Retries:     h.Retries,
+	}.Validate()
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func TestConfigStopTimeoutDuration(t *testing.T) {
+	t.Parallel()
+	var config Config
+	if err := config.SetStopTimeout(30 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if config.StopTimeout != 30 {
+		t.Errorf("SetStopTimeout: wrong StopTimeout. Want 30. Got %d.", config.StopTimeout)
+	}
+	if d := config.StopTimeoutDuration(); d != 30*time.Second {
+		t.Errorf("StopTimeoutDuration: want %s. Got %s.", 30*time.Second, d)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wire map[string]interface{}
+	if err = json.Unmarshal(data, &wire); err != nil {
+		t.Fatal(err)
+	}
+	if timeout := wire["StopTimeout"]; timeout != float64(30) {
+		t.Errorf("StopTimeout: wrong wire value. Want 30. Got %v.", timeout)
+	}
+}
+
+func TestConfigSetStopTimeoutInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []time.Duration{
+		10,
+		1500 * time.Millisecond,
+		-time.Second,
+	}
+	for _, d := range tests {
+		config := Config{StopTimeout: 5}
+		if err := config.SetStopTimeout(d); err == nil {
+			t.Errorf("SetStopTimeout(%s): unexpected <nil> error", d)
+		}
+		if config.StopTimeout != 5 {
+			t.Errorf("SetStopTimeout(%s): StopTimeout changed to %d", d, config.StopTimeout)
+		}
+	}
+}
+
+func TestHealthConfigValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		config HealthConfig
+		valid  bool
+	}{
+		{HealthConfig{}, true},
+		{HealthConfig{Test: []string{"CMD", "true"}, Interval: 5 * time.Second, Timeout: time.Second, StartPeriod: time.Millisecond, Retries: 3}, true},
+		{HealthConfig{Interval: 5}, false},
+		{HealthConfig{Timeout: time.Microsecond}, false},
+		{HealthConfig{StartPeriod: -time.Second}, false},
+		{HealthConfig{Retries: -1}, false},
+	}
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%#v): want valid=%v. Got error %v.", tt.config, tt.valid, err)
+		}
+	}
+}
+
+func TestHealthConfigJSON(t *testing.T) {
+	t.Parallel()
+	data, err := json.Marshal(HealthConfig{Test: []string{"CMD", "true"}, Interval: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"Test":["CMD","true"],"Interval":10000000000}`; string(data) != expected {
+		t.Errorf("HealthConfig: wrong JSON. Want %s. Got %s.", expected, data)
+	}
+	// encoding doesn't validate, e.g. to log an invalid config.
+	if _, err := json.Marshal(HealthConfig{Interval: 5}); err != nil {
+		t.Errorf("HealthConfig: unexpected error encoding an invalid config: %v", err)
+	}
+}
+
+func TestCreateContainerInvalidDurations(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "{}", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	configs := []Config{
+		{Image: "base", Healthcheck: &HealthConfig{Test: []string{"CMD", "true"}, Interval: 30}},
+	}
+	for _, config := range configs {
+		config := config
+		if _, err := client.CreateContainer(CreateContainerOptions{Config: &config}); err == nil {
+			t.Errorf("CreateContainer(%#v): unexpected <nil> error", config)
+		}
+	}
+	if len(fakeRT.requests) != 0 {
+		t.Errorf("CreateContainer: unexpected requests: %v", fakeRT.requests)
+	}
+}
+
+func TestCreateServiceInvalidHealthcheck(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "{}", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	opts := CreateServiceOptions{}
+	opts.TaskTemplate.ContainerSpec = &swarm.ContainerSpec{
+		Image:       "base",
+		Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}, Retries: -1},
+	}
+	if _, err := client.CreateService(opts); err == nil {
+		t.Error("CreateService: unexpected <nil> error")
+	}
+	if len(fakeRT.requests) != 0 {
+		t.Errorf("CreateService: unexpected requests: %v", fakeRT.requests)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// requestIDHeaders are the headers correlating requests, as set by the
+// proxies and load balancers in front of managed daemons, in order of
+// preference.
+var requestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Correlation-Id",
+	"Request-Id",
+	"X-Amzn-Trace-Id",
+	"X-Cloud-Trace-Context",
+}
+
+// ResponseMetadata is the metadata of the response of the daemon to a
+// failed request, identifying the daemon and the request, e.g. for support
+// tickets against managed hosts.
+type ResponseMetadata struct {
+	// Server is the Server header of the response, e.g.
+	// "Docker/18.09.0 (linux)".
+	Server string
+
+	// APIVersion is the version of the API of the daemon, from the
+	// Api-Version header.
+	APIVersion string
+
+	// Experimental tells whether the experimental features of the daemon
+	// are enabled, from the Docker-Experimental header.
+	Experimental bool
+
+	// RequestID is the first of the request correlation headers found in
+	// the response, e.g. X-Request-Id, and Headers are all of them.
+	RequestID string
+	Headers   http.Header
+}
+
+// newResponseMetadata returns the metadata of the response, or nil when it
+// has none.
+func newResponseMetadata(resp *http.Response) *ResponseMetadata {
+	metadata := ResponseMetadata{
+		Server:     resp.Header.Get("Server"),
+		APIVersion: resp.Header.Get("Api-Version"),
+	}
+	metadata.Experimental, _ = strconv.ParseBool(resp.Header.Get("Docker-Experimental"))
+	for _, key := range requestIDHeaders {
+		if value := resp.Header.Get(key); value != "" {
+			if metadata.Headers == nil {
+				metadata.Headers = make(http.Header)
+				metadata.RequestID = value
+			}
+			metadata.Headers.Set(key, value)
+		}
+	}
+	if reflect.DeepEqual(metadata, ResponseMetadata{}) {
+		return nil
+	}
+	return &metadata
+}
+
+// errorMetadata carries the metadata of the response in the typed errors
+// converted from an *Error, e.g. *NoSuchContainer.
+type errorMetadata struct {
+	metadata *ResponseMetadata
+}
+
+// metadataOf returns the metadata carried by err, to embed in the typed
+// error converted from it.
+func metadataOf(err *Error) errorMetadata {
+	return errorMetadata{metadata: err.metadata}
+}
+
+func (m errorMetadata) responseMetadata() *ResponseMetadata {
+	return m.metadata
+}
+
+// ErrorMetadata returns the metadata of the response of the daemon carried
+// by err, and whether err carries one. It's found in *Error values, as well
+// as in the typed errors converted from them, e.g. *NoSuchContainer and
+// *ImageConflict.
+func ErrorMetadata(err error) (*ResponseMetadata, bool) {
+	var metadata *ResponseMetadata
+	switch e := err.(type) {
+	case *Error:
+		metadata = e.metadata
+	case *ImageConflict:
+		if e.Err != nil {
+			metadata = e.Err.metadata
+		}
+	case *LogsNotSupported:
+		if e.Err != nil {
+			metadata = e.Err.metadata
+		}
+	case interface{ responseMetadata() *ResponseMetadata }:
+		metadata = e.responseMetadata()
+	}
+	return metadata, metadata != nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestErrorMetadata(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Docker/18.09.0 (linux)")
+		w.Header().Set("Api-Version", "1.39")
+		w.Header().Set("Docker-Experimental", "true")
+		w.Header().Set("X-Correlation-Id", "corr-1")
+		w.Header().Set("X-Amzn-Trace-Id", "Root=1-abc")
+		http.Error(w, `{"message":"server error"}`, http.StatusInternalServerError)
+	}))
+	_, err := client.InspectContainer("web")
+	if e, ok := err.(*Error); !ok || e.Message != "server error" {
+		t.Fatalf("InspectContainer: wrong error: %#v", err)
+	}
+	metadata, ok := ErrorMetadata(err)
+	if !ok {
+		t.Fatal("ErrorMetadata: want the metadata of the response")
+	}
+	expected := ResponseMetadata{
+		Server:       "Docker/18.09.0 (linux)",
+		APIVersion:   "1.39",
+		Experimental: true,
+		RequestID:    "corr-1",
+		Headers:      http.Header{"X-Correlation-Id": {"corr-1"}, "X-Amzn-Trace-Id": {"Root=1-abc"}},
+	}
+	if !reflect.DeepEqual(*metadata, expected) {
+		t.Errorf("ErrorMetadata: wrong metadata. Want %#v. Got %#v.", expected, *metadata)
+	}
+}
+
+func TestErrorMetadataTypedErrors(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		if r.Method == http.MethodDelete {
+			http.Error(w, "conflict: unable to delete 1b6b37e8d7c5 (must be forced)", http.StatusConflict)
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	_, inspectErr := client.InspectContainer("web")
+	_, networkErr := client.NetworkInfo("net")
+	tests := []error{
+		inspectErr,
+		client.KillContainer(KillContainerOptions{ID: "web"}),
+		networkErr,
+		client.RemoveImage("app"),
+	}
+	for _, err := range tests {
+		metadata, ok := ErrorMetadata(err)
+		if !ok || metadata.RequestID != "req-1" {
+			t.Errorf("ErrorMetadata(%#v): want the metadata of the response. Got %#v.", err, metadata)
+		}
+	}
+	if _, ok := inspectErr.(*NoSuchContainer); !ok {
+		t.Errorf("InspectContainer: wrong error. Want *NoSuchContainer. Got %#v.", inspectErr)
+	}
+}
+
+func TestErrorMetadataNone(t *testing.T) {
+	t.Parallel()
+	tests := []error{
+		nil,
+		errors.New("some error"),
+		&Error{Status: 500, Message: "server error"},
+		&NoSuchContainer{ID: "web"},
+		ErrNoSuchImage,
+	}
+	for _, err := range tests {
+		if metadata, ok := ErrorMetadata(err); ok || metadata != nil {
+			t.Errorf("ErrorMetadata(%#v): want no metadata. Got %#v.", err, metadata)
+		}
+	}
+}
@@ -306,7 +306,7 @@ func (eventState *eventMonitoringState) updateLastSeen(e *APIEvents) {
 }
 
 func (c *Client) eventHijack(startTime int64, eventChan chan *APIEvents, errChan chan error) error {
-	uri := "/events"
+	uri := c.basePath + "/events"
 	if startTime != 0 {
 		uri += fmt.Sprintf("?since=%d", startTime)
 	}
@@ -330,12 +330,14 @@ func (c *Client) eventHijack(startTime int64, eventChan chan *APIEvents, errChan
 	if err != nil {
 		return err
 	}
+	c.enableKeepAlive(dial)
 	//lint:ignore SA1019 this is needed here
 	conn := httputil.NewClientConn(dial, nil)
 	req, err := http.NewRequest("GET", uri, nil)
 	if err != nil {
 		return err
 	}
+	c.setHeaders(req)
 	res, err := conn.Do(req)
 	if err != nil {
 		return err
@@ -357,7 +359,10 @@ func (c *Client) eventHijack(startTime int64, eventChan chan *APIEvents, errChan
 					c.eventMonitor.RUnlock()
 					break
 				}
+				// the rest of the stream can't be decoded once a message
+				// is malformed.
 				errChan <- err
+				break
 			}
 			if event.Time == 0 {
 				continue
@@ -0,0 +1,122 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloudEventsContentType is the media type of CloudEvents in structured
+// mode, for the Content-Type header of the requests carrying them.
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEvent is a daemon event converted to a CloudEvent 1.0, marshaled to
+// JSON in structured mode.
+//
+// See https://github.com/cloudevents/spec/blob/v1.0/spec.md for more
+// details.
+type CloudEvent struct {
+	SpecVersion string `json:"specversion"`
+
+	// ID identifies the event, from its time, actor and action, so
+	// consumers can discard the events delivered twice.
+	ID string `json:"id"`
+
+	// Source identifies the daemon that emitted the event.
+	Source string `json:"source"`
+
+	// Type is "docker.<type>.<action>", e.g. "docker.container.start" or
+	// "docker.network.connect". The details of actions like
+	// "exec_start: sh" or "health_status: healthy" are in the data.
+	Type string `json:"type"`
+
+	// Subject is the ID of the object of the event, e.g. the ID of the
+	// container.
+	Subject string `json:"subject,omitempty"`
+
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+
+	// Data are the attributes of the actor of the event, e.g. the name and
+	// image of the container, along with the full action in "action".
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// NewCloudEvent converts a daemon event to a CloudEvent emitted by the given
+// source, usually the address of the daemon.
+func NewCloudEvent(event *APIEvents, source string) *CloudEvent {
+	eventType := event.Type
+	if eventType == "" {
+		eventType = "container"
+	}
+	action := event.Action
+	if action == "" {
+		action = event.Status
+	}
+	subject := event.Actor.ID
+	if subject == "" {
+		subject = event.ID
+	}
+	var t time.Time
+	if event.TimeNano != 0 {
+		t = time.Unix(0, event.TimeNano).UTC()
+	} else {
+		t = time.Unix(event.Time, 0).UTC()
+	}
+	data := make(map[string]string, len(event.Actor.Attributes)+1)
+	for key, value := range event.Actor.Attributes {
+		data[key] = value
+	}
+	if event.From != "" && data["image"] == "" {
+		data["image"] = event.From
+	}
+	data["action"] = action
+	name := action
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              strconv.FormatInt(t.UnixNano(), 10) + "-" + eventType + "-" + subject + "-" + name,
+		Source:          source,
+		Type:            "docker." + eventType + "." + name,
+		Subject:         subject,
+		Time:            t,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// CloudEvents streams the events of the daemon converted to CloudEvents,
+// like StreamSupervisor.Events, until the context is done. The source of the
+// events is the given one, or the endpoint of the client when empty. The
+// channel is not closed.
+func (c *Client) CloudEvents(ctx context.Context, opts EventsOptions, source string, events chan<- *CloudEvent) error {
+	if source == "" {
+		source = c.endpoint
+	}
+	apiEvents := make(chan *APIEvents)
+	eventsErr := make(chan error, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		eventsErr <- c.NewStreamSupervisor().Events(ctx, opts, apiEvents)
+	}()
+	for {
+		select {
+		case err := <-eventsErr:
+			return err
+		case event := <-apiEvents:
+			select {
+			case events <- NewCloudEvent(event, source):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
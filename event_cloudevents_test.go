@@ -0,0 +1,130 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewCloudEvent(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		event    APIEvents
+		expected CloudEvent
+	}{
+		{
+			APIEvents{
+				Type:     "container",
+				Action:   "start",
+				Actor:    APIActor{ID: "4fa6e0f0", Attributes: map[string]string{"name": "web", "image": "nginx"}},
+				Time:     1556000000,
+				TimeNano: 1556000000123456789,
+			},
+			CloudEvent{
+				SpecVersion:     "1.0",
+				ID:              "1556000000123456789-container-4fa6e0f0-start",
+				Source:          "unix:///var/run/docker.sock",
+				Type:            "docker.container.start",
+				Subject:         "4fa6e0f0",
+				Time:            time.Unix(0, 1556000000123456789).UTC(),
+				DataContentType: "application/json",
+				Data:            map[string]string{"name": "web", "image": "nginx", "action": "start"},
+			},
+		},
+		{
+			APIEvents{
+				Type:   "container",
+				Action: "health_status: healthy",
+				Actor:  APIActor{ID: "4fa6e0f0"},
+				Time:   1556000000,
+			},
+			CloudEvent{
+				SpecVersion:     "1.0",
+				ID:              "1556000000000000000-container-4fa6e0f0-health_status",
+				Source:          "unix:///var/run/docker.sock",
+				Type:            "docker.container.health_status",
+				Subject:         "4fa6e0f0",
+				Time:            time.Unix(1556000000, 0).UTC(),
+				DataContentType: "application/json",
+				Data:            map[string]string{"action": "health_status: healthy"},
+			},
+		},
+		{
+			APIEvents{Status: "die", ID: "4fa6e0f0", From: "redis", Time: 1556000000},
+			CloudEvent{
+				SpecVersion:     "1.0",
+				ID:              "1556000000000000000-container-4fa6e0f0-die",
+				Source:          "unix:///var/run/docker.sock",
+				Type:            "docker.container.die",
+				Subject:         "4fa6e0f0",
+				Time:            time.Unix(1556000000, 0).UTC(),
+				DataContentType: "application/json",
+				Data:            map[string]string{"image": "redis", "action": "die"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		event := NewCloudEvent(&tt.event, "unix:///var/run/docker.sock")
+		if !reflect.DeepEqual(*event, tt.expected) {
+			t.Errorf("NewCloudEvent: wrong event.\nWant %#v.\nGot  %#v.", tt.expected, *event)
+		}
+	}
+}
+
+func TestCloudEventJSON(t *testing.T) {
+	t.Parallel()
+	event := NewCloudEvent(&APIEvents{Type: "network", Action: "connect", Actor: APIActor{ID: "n1"}, TimeNano: 1556000000500000000}, "tcp://docker:2376")
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "1556000000500000000-network-n1-connect",
+		"source":          "tcp://docker:2376",
+		"type":            "docker.network.connect",
+		"subject":         "n1",
+		"time":            "2019-04-23T06:13:20.5Z",
+		"datacontenttype": "application/json",
+		"data":            map[string]interface{}{"action": "connect"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("CloudEvent: wrong JSON.\nWant %#v.\nGot  %#v.", expected, got)
+	}
+}
+
+func TestClientCloudEvents(t *testing.T) {
+	t.Parallel()
+	client, daemon, closeDaemon := newEventsDaemonClient(t)
+	defer closeDaemon()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan *CloudEvent)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- client.CloudEvents(ctx, EventsOptions{}, "", events)
+	}()
+	daemon <- `{"Type": "container", "Action": "start", "Actor": {"ID": "c1"}, "time": 1}`
+	select {
+	case event := <-events:
+		if event.Type != "docker.container.start" || event.Subject != "c1" || event.Source != client.endpoint {
+			t.Errorf("CloudEvents: wrong event: %#v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+	cancel()
+	if err := <-errC; err != context.Canceled {
+		t.Errorf("CloudEvents: wrong error. Want %v. Got %v.", context.Canceled, err)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// EventsSignatureHeader is the header that carries the HMAC-SHA256 signature
+// of the batches POSTed by ForwardEvents, in the form "sha256=<hex digest>".
+const EventsSignatureHeader = "X-Docker-Events-Signature"
+
+const (
+	defaultForwardBatchSize     = 100
+	defaultForwardFlushInterval = time.Second
+	defaultForwardMaxRetries    = 3
+	defaultForwardRetryBackoff  = time.Second
+)
+
+// ErrMissingWebhookURL is the error returned by ForwardEvents when the URL of
+// the webhook isn't set.
+var ErrMissingWebhookURL = errors.New("missing webhook URL")
+
+// ForwardEventsOptions specify parameters to the ForwardEvents method.
+type ForwardEventsOptions struct {
+	// URL is the endpoint the batches of events are POSTed to.
+	URL string
+
+	// Since and Filters select the events to forward, see EventsOptions.
+	Since   int64
+	Filters map[string][]string
+
+	// Secret is the key used to sign the batches with HMAC-SHA256. When
+	// empty, batches are not signed.
+	Secret []byte
+
+	// Headers are added to each request, e.g. for authentication.
+	Headers map[string]string
+
+	// BatchSize is the maximum number of events in a batch. Defaults to
+	// 100.
+	BatchSize int
+
+	// FlushInterval is how long events wait for the batch to fill before
+	// being sent. Defaults to one second.
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times a batch is sent again after a
+	// failure, waiting RetryBackoff before the first retry and doubling the
+	// wait after each one. Defaults to 3 and one second, a negative
+	// MaxRetries disables retries. Batches are retried on network errors,
+	// 429 and 5xx responses.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// HTTPClient is used to send the batches. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// ForwardEventsError is the error returned by ForwardEvents when a batch
+// couldn't be delivered.
+type ForwardEventsError struct {
+	// Since is the unix timestamp of the first event that wasn't
+	// delivered, to resume forwarding from it with
+	// ForwardEventsOptions.Since.
+	Since int64
+
+	// StatusCode is the status of the last response of the webhook, or
+	// zero when it couldn't be reached.
+	StatusCode int
+	Err        error
+}
+
+func (e *ForwardEventsError) Error() string {
+	return "failed to forward events: " + e.Err.Error()
+}
+
+// ForwardEvents subscribes to the events of the daemon and POSTs them, in
+// batches encoded as JSON arrays, to the webhook at opts.URL, until the
+// context is done or a batch can't be delivered. The event stream is resumed
+// after daemon restarts, see StreamSupervisor.Events.
+//
+// When opts.Secret is set, each request carries the signature of its body in
+// the EventsSignatureHeader header, which receivers can check with
+// VerifyEventsSignature. Events that are still waiting for their batch to be
+// sent when the context is done are discarded.
+func (c *Client) ForwardEvents(ctx context.Context, opts ForwardEventsOptions) error {
+	if opts.URL == "" {
+		return ErrMissingWebhookURL
+	}
+	f := eventForwarder{opts: opts}
+	if f.opts.BatchSize <= 0 {
+		f.opts.BatchSize = defaultForwardBatchSize
+	}
+	if f.opts.FlushInterval <= 0 {
+		f.opts.FlushInterval = defaultForwardFlushInterval
+	}
+	if f.opts.MaxRetries == 0 {
+		f.opts.MaxRetries = defaultForwardMaxRetries
+	}
+	if f.opts.RetryBackoff <= 0 {
+		f.opts.RetryBackoff = defaultForwardRetryBackoff
+	}
+	if f.opts.HTTPClient == nil {
+		f.opts.HTTPClient = http.DefaultClient
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := make(chan *APIEvents)
+	eventsErr := make(chan error, 1)
+	go func() {
+		eventsErr <- c.NewStreamSupervisor().Events(ctx, EventsOptions{Since: opts.Since, Filters: opts.Filters}, events)
+	}()
+	ticker := time.NewTicker(f.opts.FlushInterval)
+	defer ticker.Stop()
+	var batch []*APIEvents
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-eventsErr:
+			return err
+		case event := <-events:
+			batch = append(batch, event)
+			if len(batch) < f.opts.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if err := f.send(ctx, batch); err != nil {
+			return err
+		}
+		batch = nil
+	}
+}
+
+// SignEventsPayload returns the signature of a batch of events sent by
+// ForwardEvents, as set in the EventsSignatureHeader header.
+func SignEventsPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyEventsSignature reports whether signature is the valid signature of
+// the body of a request sent by ForwardEvents, for the given secret.
+func VerifyEventsSignature(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignEventsPayload(secret, body)), []byte(signature))
+}
+
+type eventForwarder struct {
+	opts ForwardEventsOptions
+}
+
+// send delivers the batch, retrying on failures.
+func (f *eventForwarder) send(ctx context.Context, batch []*APIEvents) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	backoff := f.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		status, err := f.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		retriable := status == 0 || status == http.StatusTooManyRequests || status >= 500
+		if !retriable || attempt >= f.opts.MaxRetries {
+			return &ForwardEventsError{Since: batch[0].Time, StatusCode: status, Err: err}
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (f *eventForwarder) post(ctx context.Context, body []byte) (int, error) {
+	req, err := http.NewRequest("POST", f.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	for key, value := range f.opts.Headers {
+		req.Header.Set(key, value)
+	}
+	if len(f.opts.Secret) > 0 {
+		req.Header.Set(EventsSignatureHeader, SignEventsPayload(f.opts.Secret, body))
+	}
+	resp, err := f.opts.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// eventsDaemon streams the events sent to its channel.
+type eventsDaemon chan string
+
+func (d eventsDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/events" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case event := <-d:
+			w.Write([]byte(event))
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newEventsDaemonClient(t *testing.T) (*Client, eventsDaemon, func()) {
+	daemon := make(eventsDaemon)
+	server := httptest.NewServer(daemon)
+	client, err := NewClient(server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return client, daemon, server.Close
+}
+
+func TestForwardEventsBatches(t *testing.T) {
+	t.Parallel()
+	client, daemon, closeDaemon := newEventsDaemonClient(t)
+	defer closeDaemon()
+	requests := make(chan webhookRequest, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- webhookRequest{header: r.Header, body: body}
+	}))
+	defer webhook.Close()
+	secret := []byte("s3cr3t")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- client.ForwardEvents(ctx, ForwardEventsOptions{
+			URL:           webhook.URL,
+			Secret:        secret,
+			Headers:       map[string]string{"Authorization": "Bearer token"},
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		})
+	}()
+	daemon <- `{"Type": "container", "Action": "start", "Actor": {"ID": "c1"}, "time": 1}`
+	daemon <- `{"Type": "container", "Action": "die", "Actor": {"ID": "c1"}, "time": 2}`
+	daemon <- `{"Type": "container", "Action": "destroy", "Actor": {"ID": "c1"}, "time": 3}`
+	var req webhookRequest
+	select {
+	case req = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ForwardEvents: timed out waiting for the batch")
+	}
+	var batch []APIEvents
+	if err := json.Unmarshal(req.body, &batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 2 || batch[0].Action != "start" || batch[1].Action != "die" {
+		t.Errorf("ForwardEvents: wrong batch: %#v", batch)
+	}
+	if batch[0].Status != "start" || batch[0].ID != "c1" {
+		t.Errorf("ForwardEvents: events should be normalized, got %#v", batch[0])
+	}
+	if !VerifyEventsSignature(secret, req.body, req.header.Get(EventsSignatureHeader)) {
+		t.Errorf("ForwardEvents: invalid signature %q", req.header.Get(EventsSignatureHeader))
+	}
+	if VerifyEventsSignature([]byte("other"), req.body, req.header.Get(EventsSignatureHeader)) {
+		t.Error("ForwardEvents: signature verified with the wrong secret")
+	}
+	if got := req.header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("ForwardEvents: wrong Authorization header: %q", got)
+	}
+	if got := req.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("ForwardEvents: wrong Content-Type header: %q", got)
+	}
+	select {
+	case req = <-requests:
+		t.Errorf("ForwardEvents: unexpected batch before the flush interval: %s", req.body)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	if err := <-errC; err != context.Canceled {
+		t.Errorf("ForwardEvents: wrong error. Want %v. Got %v.", context.Canceled, err)
+	}
+}
+
+func TestForwardEventsFlushInterval(t *testing.T) {
+	t.Parallel()
+	client, daemon, closeDaemon := newEventsDaemonClient(t)
+	defer closeDaemon()
+	requests := make(chan webhookRequest, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- webhookRequest{header: r.Header, body: body}
+	}))
+	defer webhook.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.ForwardEvents(ctx, ForwardEventsOptions{URL: webhook.URL, FlushInterval: 20 * time.Millisecond})
+	daemon <- `{"Type": "network", "Action": "create", "Actor": {"ID": "n1"}, "time": 1}`
+	select {
+	case req := <-requests:
+		var batch []APIEvents
+		if err := json.Unmarshal(req.body, &batch); err != nil {
+			t.Fatal(err)
+		}
+		if len(batch) != 1 || batch[0].Actor.ID != "n1" {
+			t.Errorf("ForwardEvents: wrong batch: %#v", batch)
+		}
+		if sig := req.header.Get(EventsSignatureHeader); sig != "" {
+			t.Errorf("ForwardEvents: unexpected signature without secret: %q", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ForwardEvents: timed out waiting for the batch")
+	}
+}
+
+func TestForwardEventsRetries(t *testing.T) {
+	t.Parallel()
+	client, daemon, closeDaemon := newEventsDaemonClient(t)
+	defer closeDaemon()
+	var attempts int32
+	delivered := make(chan struct{})
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case 2:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			close(delivered)
+		}
+	}))
+	defer webhook.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.ForwardEvents(ctx, ForwardEventsOptions{URL: webhook.URL, BatchSize: 1, RetryBackoff: time.Millisecond})
+	daemon <- `{"Type": "container", "Action": "start", "Actor": {"ID": "c1"}, "time": 1}`
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ForwardEvents: batch not delivered after %d attempts", atomic.LoadInt32(&attempts))
+	}
+}
+
+func TestForwardEventsFailure(t *testing.T) {
+	t.Parallel()
+	client, daemon, closeDaemon := newEventsDaemonClient(t)
+	defer closeDaemon()
+	var attempts int32
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer webhook.Close()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- client.ForwardEvents(context.Background(), ForwardEventsOptions{URL: webhook.URL, BatchSize: 1, RetryBackoff: time.Millisecond})
+	}()
+	daemon <- `{"Type": "container", "Action": "start", "Actor": {"ID": "c1"}, "time": 42}`
+	select {
+	case err := <-errC:
+		e, ok := err.(*ForwardEventsError)
+		if !ok {
+			t.Fatalf("ForwardEvents: wrong error. Want *ForwardEventsError. Got %#v.", err)
+		}
+		if e.StatusCode != http.StatusBadRequest || e.Since != 42 {
+			t.Errorf("ForwardEvents: wrong error: %#v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ForwardEvents: didn't fail")
+	}
+	if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Errorf("ForwardEvents: client errors shouldn't be retried, got %d attempts", n)
+	}
+}
+
+func TestForwardEventsMissingURL(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{status: http.StatusOK})
+	if err := client.ForwardEvents(context.Background(), ForwardEventsOptions{}); err != ErrMissingWebhookURL {
+		t.Errorf("ForwardEvents: wrong error. Want %v. Got %v.", ErrMissingWebhookURL, err)
+	}
+}
@@ -301,3 +301,71 @@ func TestEventListenerReAdding(t *testing.T) {
 	// Give the goroutine of the first eventHijack() time to handle the EOF.
 	time.Sleep(10 * time.Millisecond)
 }
+
+func TestEventHijackKeepAlive(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"create","id":"dfdf82bd3881","from":"base:latest","time":1374067924}`))
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialer := keepAliveDialer{conns: make(chan *keepAliveConn, 1)}
+	client.Dialer = dialer
+	client.KeepAlive = 10 * time.Second
+	if err = client.eventHijack(0, make(chan *APIEvents, 10), make(chan error, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if keepAlive, period := (<-dialer.conns).settings(); !keepAlive || period != client.KeepAlive {
+		t.Errorf("eventHijack: wrong keep-alive settings. Want true and %s. Got %v and %s.", client.KeepAlive, keepAlive, period)
+	}
+}
+
+func TestEventHijackBasePath(t *testing.T) {
+	t.Parallel()
+	paths := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetBasePath("/dockerapi")
+	if err = client.eventHijack(0, make(chan *APIEvents, 10), make(chan error, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if path := <-paths; path != "/dockerapi/events" {
+		t.Errorf("eventHijack: wrong path. Want %q. Got %q.", "/dockerapi/events", path)
+	}
+}
+
+func TestEventHijackMalformedEvent(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"create","id":"dfdf82bd3881","time":1374067924}{"status":`))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`]`))
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan *APIEvents, 10)
+	errs := make(chan error, 10)
+	if err = client.eventHijack(0, events, errs); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err == nil {
+		t.Fatal("eventHijack: want a non-nil error for a malformed event")
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("eventHijack: want the stream dropped after the first error. Got %v.", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -5,6 +5,7 @@
 package docker
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -13,6 +14,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 )
 
 // Exec is the type representing a `docker exec` instance and containing the
@@ -43,17 +45,17 @@ type CreateExecOptions struct {
 //
 // See https://goo.gl/60TeBP for more details
 func (c *Client) CreateExec(opts CreateExecOptions) (*Exec, error) {
-	if len(opts.Env) > 0 && c.serverAPIVersion.LessThan(apiVersion125) {
+	if len(opts.Env) > 0 && c.getServerAPIVersion().LessThan(apiVersion125) {
 		return nil, errors.New("exec configuration Env is only supported in API#1.25 and above")
 	}
-	if len(opts.WorkingDir) > 0 && c.serverAPIVersion.LessThan(apiVersion135) {
+	if len(opts.WorkingDir) > 0 && c.getServerAPIVersion().LessThan(apiVersion135) {
 		return nil, errors.New("exec configuration WorkingDir is only supported in API#1.35 and above")
 	}
 	path := fmt.Sprintf("/containers/%s/exec", opts.Container)
 	resp, err := c.do("POST", path, doOptions{data: opts, context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchContainer{ID: opts.Container}
+			return nil, &NoSuchContainer{ID: opts.Container, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -81,13 +83,20 @@ type StartExecOptions struct {
 	RawTerminal bool `qs:"-"`
 
 	// If set, after a successful connect, a sentinel will be sent and then the
-	// client will block on receive before continuing.
+	// client will block on receive before continuing. The sentinel is also
+	// sent when the daemon rejects the request, whose error is then returned
+	// by Wait.
 	//
 	// It must be an unbuffered channel. Using a buffered channel can lead
 	// to unexpected behavior.
 	Success chan struct{} `json:"-"`
 
+	// Cancelling the context closes the connection to the daemon, ending
+	// the interactive session. Wait then returns the error of the context.
 	Context context.Context `json:"-"`
+	// Recorder, when set, records the interactive session in the asciicast
+	// v2 format: its output and, if the recorder was created to, its input.
+	Recorder *AsciicastRecorder `json:"-" qs:"-"`
 }
 
 // StartExec starts a previously set up exec instance id. If opts.Detach is
@@ -122,7 +131,7 @@ func (c *Client) StartExecNonBlocking(id string, opts StartExecOptions) (CloseWa
 		resp, err := c.do("POST", path, doOptions{data: opts, context: opts.Context})
 		if err != nil {
 			if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-				return nil, &NoSuchExec{ID: id}
+				return nil, &NoSuchExec{ID: id, errorMetadata: metadataOf(e)}
 			}
 			return nil, err
 		}
@@ -130,16 +139,137 @@ func (c *Client) StartExecNonBlocking(id string, opts StartExecOptions) (CloseWa
 		return nil, nil
 	}
 
+	in, stdout, stderr := opts.InputStream, opts.OutputStream, opts.ErrorStream
+	if opts.Recorder != nil {
+		in = opts.Recorder.input(in)
+		stdout = opts.Recorder.output(stdout)
+		stderr = opts.Recorder.output(stderr)
+	}
 	return c.hijack("POST", path, hijackOptions{
 		success:        opts.Success,
 		setRawTerminal: opts.RawTerminal,
-		in:             opts.InputStream,
-		stdout:         opts.OutputStream,
-		stderr:         opts.ErrorStream,
+		in:             in,
+		stdout:         stdout,
+		stderr:         stderr,
 		data:           opts,
+		context:        opts.Context,
 	})
 }
 
+// execInspectInterval is the interval between the inspections of an exec
+// waiting for its exit code.
+const execInspectInterval = 100 * time.Millisecond
+
+// ErrExecOutputLimitExceeded is the error returned by ExecCapture when the
+// output of the command exceeds ExecCaptureOptions.MaxOutputSize.
+var ErrExecOutputLimitExceeded = errors.New("exec output exceeds the size limit")
+
+// ExecCaptureOptions specify parameters to the ExecCapture function.
+type ExecCaptureOptions struct {
+	Env         []string
+	User        string
+	WorkingDir  string
+	Privileged  bool
+	InputStream io.Reader
+
+	// MaxOutputSize is the maximum number of bytes captured from each of
+	// stdout and stderr. Zero means no limit.
+	MaxOutputSize int
+}
+
+// ExecCapture runs cmd in the given container and waits for it to finish,
+// returning its standard output, standard error and exit code.
+//
+// When opts.MaxOutputSize is set, ExecCapture keeps running the command
+// after the limit is reached, but discards the remaining output and returns
+// the truncated output and exit code along with ErrExecOutputLimitExceeded.
+//
+// If ctx is canceled before the command finishes, the connection to the
+// command is closed and ctx.Err() is returned. Notice that this doesn't stop
+// the command inside the container.
+func (c *Client) ExecCapture(ctx context.Context, containerID string, cmd []string, opts ExecCaptureOptions) (stdout, stderr []byte, exitCode int, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	exec, err := c.CreateExec(CreateExecOptions{
+		Container:    containerID,
+		Cmd:          cmd,
+		Env:          opts.Env,
+		User:         opts.User,
+		WorkingDir:   opts.WorkingDir,
+		Privileged:   opts.Privileged,
+		AttachStdin:  opts.InputStream != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Context:      ctx,
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	outBuf := &limitedBuffer{limit: opts.MaxOutputSize}
+	errBuf := &limitedBuffer{limit: opts.MaxOutputSize}
+	cw, err := c.StartExecNonBlocking(exec.ID, StartExecOptions{
+		InputStream:  opts.InputStream,
+		OutputStream: outBuf,
+		ErrorStream:  errBuf,
+		Context:      ctx,
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- cw.Wait()
+	}()
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		cw.Close()
+		<-errs
+		return outBuf.Bytes(), errBuf.Bytes(), 0, ctx.Err()
+	}
+	if err != nil {
+		return outBuf.Bytes(), errBuf.Bytes(), 0, err
+	}
+	// the output may end before the daemon records the exit code of the
+	// command.
+	inspect, err := c.inspectExec(ctx, exec.ID)
+	for err == nil && inspect.Running {
+		timer := time.NewTimer(execInspectInterval)
+		select {
+		case <-timer.C:
+			inspect, err = c.inspectExec(ctx, exec.ID)
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		}
+	}
+	if err != nil {
+		return outBuf.Bytes(), errBuf.Bytes(), 0, err
+	}
+	if outBuf.truncated || errBuf.truncated {
+		err = ErrExecOutputLimitExceeded
+	}
+	return outBuf.Bytes(), errBuf.Bytes(), inspect.ExitCode, err
+}
+
+// limitedBuffer is a bytes.Buffer that silently discards data written after
+// the limit is reached. A limit of zero means no limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 && b.Len()+len(p) > b.limit {
+		b.truncated = true
+		b.Buffer.Write(p[:b.limit-b.Len()])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
 // ResizeExecTTY resizes the tty session used by the exec command id. This API
 // is valid only if Tty was specified as part of creating and starting the exec
 // command.
@@ -191,11 +321,15 @@ type ExecInspect struct {
 //
 // See https://goo.gl/ctMUiW for more details
 func (c *Client) InspectExec(id string) (*ExecInspect, error) {
+	return c.inspectExec(context.Background(), id)
+}
+
+func (c *Client) inspectExec(ctx context.Context, id string) (*ExecInspect, error) {
 	path := fmt.Sprintf("/exec/%s/json", id)
-	resp, err := c.do("GET", path, doOptions{})
+	resp, err := c.do("GET", path, doOptions{context: ctx})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchExec{ID: id}
+			return nil, &NoSuchExec{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -210,6 +344,8 @@ func (c *Client) InspectExec(id string) (*ExecInspect, error) {
 // NoSuchExec is the error returned when a given exec instance does not exist.
 type NoSuchExec struct {
 	ID string
+
+	errorMetadata
 }
 
 func (err *NoSuchExec) Error() string {
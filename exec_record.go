@@ -0,0 +1,174 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// AsciicastHeader is the header of an asciicast v2 recording.
+//
+// See https://docs.asciinema.org/manual/asciicast/v2/ for more details.
+type AsciicastHeader struct {
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// AsciicastRecorder records an exec session in the asciicast v2 format, as a
+// header line followed by a line per frame, timestamped relatively to the
+// creation of the recorder. It's set in StartExecOptions.Recorder.
+//
+// Failing to write the recording doesn't interrupt the session: the recorder
+// stops recording, and the error is returned by Err.
+type AsciicastRecorder struct {
+	mu          sync.Mutex
+	w           io.Writer
+	start       time.Time
+	recordInput bool
+	err         error
+}
+
+// NewAsciicastRecorder returns a recorder writing to w, after writing the
+// given header. When the timestamp of the header is zero, it's set to the
+// current time. When recordInput is true, the input sent to the session is
+// recorded along with its output, which may include passwords typed in the
+// terminal.
+func NewAsciicastRecorder(w io.Writer, header AsciicastHeader, recordInput bool) (*AsciicastRecorder, error) {
+	r := AsciicastRecorder{
+		w:           w,
+		start:       time.Now(),
+		recordInput: recordInput,
+	}
+	if header.Timestamp == 0 {
+		header.Timestamp = r.start.Unix()
+	}
+	line, err := json.Marshal(struct {
+		Version int `json:"version"`
+		AsciicastHeader
+	}{2, header})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Resize records a change of the size of the terminal, to be called along
+// with ResizeExecTTY.
+func (r *AsciicastRecorder) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeEvent(time.Now(), "r", strconv.Itoa(width)+"x"+strconv.Itoa(height))
+}
+
+// Err returns the error that stopped the recording, if any.
+func (r *AsciicastRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// record writes the frame of the given type. Frames hold valid UTF-8, so an
+// incomplete character at the end of data is kept in pending until the next
+// call for the same stream.
+func (r *AsciicastRecorder) record(code string, pending *[]byte, data []byte) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data = append(*pending, data...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	*pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.writeEvent(now, code, string(data[:cut]))
+	}
+}
+
+func (r *AsciicastRecorder) writeEvent(now time.Time, code, data string) {
+	if r.err != nil {
+		return
+	}
+	line, err := json.Marshal([]interface{}{
+		now.Sub(r.start).Seconds(),
+		code,
+		data,
+	})
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	r.err = err
+}
+
+// output returns a writer that writes to w, which may be nil, and records
+// what it writes as output frames.
+func (r *AsciicastRecorder) output(w io.Writer) io.Writer {
+	return &recordedWriter{recorder: r, w: w}
+}
+
+// input returns a reader that reads from in and records what it reads as
+// input frames, if the input is recorded.
+func (r *AsciicastRecorder) input(in io.Reader) io.Reader {
+	if in == nil || !r.recordInput {
+		return in
+	}
+	return &recordedReader{recorder: r, r: in}
+}
+
+type recordedWriter struct {
+	recorder *AsciicastRecorder
+	w        io.Writer
+	pending  []byte
+}
+
+func (w *recordedWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	var err error
+	if w.w != nil {
+		n, err = w.w.Write(p)
+	}
+	w.recorder.record("o", &w.pending, p[:n])
+	return n, err
+}
+
+type recordedReader struct {
+	recorder *AsciicastRecorder
+	r        io.Reader
+	pending  []byte
+}
+
+func (r *recordedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.recorder.record("i", &r.pending, p[:n])
+	}
+	return n, err
+}
+
+// Close closes the underlying reader, if it's a Closer, as the hijacked
+// session closes its input stream when the output ends.
+func (r *recordedReader) Close() error {
+	if closer, ok := r.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type asciicastEvent struct {
+	Code string
+	Data string
+}
+
+func parseAsciicast(t *testing.T, data []byte) (map[string]interface{}, []asciicastEvent) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	var header map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	var events []asciicastEvent
+	for _, line := range lines[1:] {
+		var event []interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatal(err)
+		}
+		if len(event) != 3 {
+			t.Fatalf("asciicast: wrong event %s", line)
+		}
+		if _, ok := event[0].(float64); !ok {
+			t.Errorf("asciicast: wrong event time %s", line)
+		}
+		events = append(events, asciicastEvent{Code: event[1].(string), Data: event[2].(string)})
+	}
+	return header, events
+}
+
+func TestAsciicastRecorder(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	recorder, err := NewAsciicastRecorder(&buf, AsciicastHeader{Width: 80, Height: 24, Title: "break-glass"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	out := recorder.output(&stdout)
+	euro := []byte("€")
+	out.Write(append([]byte("price: "), euro[:2]...))
+	out.Write(append(euro[2:], '\n'))
+	recorder.Resize(100, 30)
+	in, _ := ioutil.ReadAll(recorder.input(strings.NewReader("exit\n")))
+	if err := recorder.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "price: €\n" {
+		t.Errorf("AsciicastRecorder: wrong output. Want %q. Got %q.", "price: €\n", stdout.String())
+	}
+	if string(in) != "exit\n" {
+		t.Errorf("AsciicastRecorder: wrong input. Want %q. Got %q.", "exit\n", in)
+	}
+	header, events := parseAsciicast(t, buf.Bytes())
+	if header["version"] != 2.0 || header["width"] != 80.0 || header["height"] != 24.0 || header["title"] != "break-glass" {
+		t.Errorf("AsciicastRecorder: wrong header: %#v", header)
+	}
+	if timestamp, _ := header["timestamp"].(float64); timestamp == 0 {
+		t.Errorf("AsciicastRecorder: missing timestamp in header: %#v", header)
+	}
+	expected := []asciicastEvent{
+		{Code: "o", Data: "price: "},
+		{Code: "o", Data: "€\n"},
+		{Code: "r", Data: "100x30"},
+		{Code: "i", Data: "exit\n"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("AsciicastRecorder: wrong events. Want %#v. Got %#v.", expected, events)
+	}
+}
+
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestAsciicastRecorderWriteError(t *testing.T) {
+	t.Parallel()
+	recorder, err := NewAsciicastRecorder(&failingWriter{n: 1}, AsciicastHeader{Width: 80, Height: 24}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	out := recorder.output(&stdout)
+	if _, err := out.Write([]byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write([]byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "onetwo" {
+		t.Errorf("AsciicastRecorder: wrong output. Want %q. Got %q.", "onetwo", stdout.String())
+	}
+	if err := recorder.Err(); err == nil || err.Error() != "disk full" {
+		t.Errorf("AsciicastRecorder: wrong error. Want %q. Got %v.", "disk full", err)
+	}
+	if in := strings.NewReader("x"); recorder.input(in) != in {
+		t.Error("AsciicastRecorder: input recorded when disabled")
+	}
+}
+
+func TestStartExecRecorder(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n"))
+		line, _ := bufio.NewReader(rw).ReadString('\n')
+		conn.Write([]byte("$ " + line))
+	}))
+	defer server.Close()
+	client, _ := NewClient(server.URL)
+	client.SkipServerVersionCheck = true
+	var recording, stdout bytes.Buffer
+	recorder, err := NewAsciicastRecorder(&recording, AsciicastHeader{Width: 80, Height: 24}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.StartExec("exec-id", StartExecOptions{
+		InputStream:  strings.NewReader("whoami\n"),
+		OutputStream: &stdout,
+		RawTerminal:  true,
+		Tty:          true,
+		Recorder:     recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "$ whoami\n" {
+		t.Errorf("StartExec: wrong output. Want %q. Got %q.", "$ whoami\n", stdout.String())
+	}
+	_, frames := parseAsciicast(t, recording.Bytes())
+	// the output may be read in several frames.
+	var events []asciicastEvent
+	for _, frame := range frames {
+		if n := len(events); n > 0 && events[n-1].Code == frame.Code {
+			events[n-1].Data += frame.Data
+			continue
+		}
+		events = append(events, frame)
+	}
+	expected := []asciicastEvent{
+		{Code: "i", Data: "whoami\n"},
+		{Code: "o", Data: "$ whoami\n"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("StartExec: wrong recording. Want %#v. Got %#v.", expected, events)
+	}
+}
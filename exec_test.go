@@ -6,6 +6,7 @@ package docker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net"
 	"net/http"
@@ -13,7 +14,9 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestExecCreate(t *testing.T) {
@@ -306,3 +309,90 @@ func TestExecInspect(t *testing.T) {
 		t.Errorf("ExecInspect: Wrong path in request. Want %q. Got %q.", expectedURL.Path, gotPath)
 	}
 }
+
+// newExecCaptureServer serves an exec writing stream, reported as running by
+// the first running inspections.
+func newExecCaptureServer(t *testing.T, stream []byte, exitCode, running int) *httptest.Server {
+	var inspections int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/web/exec":
+			var opts CreateExecOptions
+			json.NewDecoder(r.Body).Decode(&opts)
+			if !reflect.DeepEqual(opts.Cmd, []string{"cat", "/etc/hostname"}) || !opts.AttachStdout || !opts.AttachStderr {
+				t.Errorf("ExecCapture: wrong exec options: %#v", opts)
+			}
+			w.Write([]byte(`{"Id":"exec-id"}`))
+		case "/exec/exec-id/start":
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n"))
+			conn.Write(stream)
+			conn.Close()
+		case "/exec/exec-id/json":
+			if int(atomic.AddInt32(&inspections, 1)) <= running {
+				json.NewEncoder(w).Encode(ExecInspect{ID: "exec-id", Running: true})
+				return
+			}
+			json.NewEncoder(w).Encode(ExecInspect{ID: "exec-id", ExitCode: exitCode})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+}
+
+func execFrame(stream byte, data string) []byte {
+	return append([]byte{stream, 0, 0, 0, 0, 0, 0, byte(len(data))}, data...)
+}
+
+func TestExecCapture(t *testing.T) {
+	t.Parallel()
+	stream := append(execFrame(1, "web-1\n"), execFrame(2, "warning\n")...)
+	server := newExecCaptureServer(t, stream, 3, 2)
+	defer server.Close()
+	client, _ := NewClient(server.URL)
+	stdout, stderr, exitCode, err := client.ExecCapture(context.Background(), "web", []string{"cat", "/etc/hostname"}, ExecCaptureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stdout) != "web-1\n" {
+		t.Errorf("ExecCapture: wrong stdout. Want %q. Got %q.", "web-1\n", stdout)
+	}
+	if string(stderr) != "warning\n" {
+		t.Errorf("ExecCapture: wrong stderr. Want %q. Got %q.", "warning\n", stderr)
+	}
+	if exitCode != 3 {
+		t.Errorf("ExecCapture: wrong exit code. Want 3. Got %d.", exitCode)
+	}
+}
+
+func TestExecCaptureCancelWhileRunning(t *testing.T) {
+	t.Parallel()
+	server := newExecCaptureServer(t, execFrame(1, "web-1\n"), 0, 1000)
+	defer server.Close()
+	client, _ := NewClient(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, _, _, err := client.ExecCapture(ctx, "web", []string{"cat", "/etc/hostname"}, ExecCaptureOptions{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("ExecCapture: wrong error. Want %#v. Got %#v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestExecCaptureOutputLimit(t *testing.T) {
+	t.Parallel()
+	stream := append(execFrame(1, "0123456789"), execFrame(1, "abcdef")...)
+	server := newExecCaptureServer(t, stream, 0, 0)
+	defer server.Close()
+	client, _ := NewClient(server.URL)
+	stdout, _, _, err := client.ExecCapture(context.Background(), "web", []string{"cat", "/etc/hostname"}, ExecCaptureOptions{MaxOutputSize: 12})
+	if err != ErrExecOutputLimitExceeded {
+		t.Errorf("ExecCapture: wrong error. Want %#v. Got %#v.", ErrExecOutputLimitExceeded, err)
+	}
+	if string(stdout) != "0123456789ab" {
+		t.Errorf("ExecCapture: wrong stdout. Want %q. Got %q.", "0123456789ab", stdout)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ExecUserStrategy is the way ExecAsUser switched to the user running the
+// command.
+type ExecUserStrategy string
+
+const (
+	// ExecUserField sets the User of the exec instance.
+	ExecUserField ExecUserStrategy = "user"
+
+	// ExecUserRunuser wraps the command with runuser.
+	ExecUserRunuser ExecUserStrategy = "runuser"
+
+	// ExecUserSu wraps the command with su -c.
+	ExecUserSu ExecUserStrategy = "su"
+)
+
+// ExecUserResult is the result of a command run by ExecAsUser.
+type ExecUserResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+
+	// Strategy is the way the command was run as the user.
+	Strategy ExecUserStrategy
+}
+
+// ExecUserAttempt is a failed attempt of ExecAsUser to run a command as a
+// user.
+type ExecUserAttempt struct {
+	Strategy ExecUserStrategy
+	Err      error
+}
+
+// ExecUserError is the error returned by ExecAsUser when the command couldn't
+// be run as the user, with every strategy attempted.
+type ExecUserError struct {
+	Container string
+	User      string
+	Attempts  []ExecUserAttempt
+}
+
+func (err *ExecUserError) Error() string {
+	attempts := make([]string, len(err.Attempts))
+	for i, attempt := range err.Attempts {
+		attempts[i] = fmt.Sprintf("%s: %s", attempt.Strategy, strings.TrimSpace(attempt.Err.Error()))
+	}
+	return fmt.Sprintf("cannot exec as %s in container %s (%s)", err.User, err.Container, strings.Join(attempts, "; "))
+}
+
+// ExecAsRoot runs cmd as root in the given container. See ExecAsUser.
+func (c *Client) ExecAsRoot(ctx context.Context, containerID string, cmd []string, opts ExecCaptureOptions) (*ExecUserResult, error) {
+	return c.ExecAsUser(ctx, containerID, "root", cmd, opts)
+}
+
+// ExecAsUser runs cmd as the given user, in the form "user", "uid",
+// "user:group" or "uid:gid", in the given container, and waits for it to
+// finish like ExecCapture. opts.User is ignored.
+//
+// The user is set on the exec instance. When the daemon doesn't support it,
+// because its API is older than 1.19, or rejects it, the command is wrapped
+// with runuser and then su -c, as the default user of the container, which
+// has to be root for these fallbacks to work. A fallback is skipped when its
+// command isn't found in the container. The result tells which strategy was
+// used, and the error every strategy attempted.
+//
+// As with ExecCapture, a command that runs and exits with a non-zero status
+// isn't an error.
+func (c *Client) ExecAsUser(ctx context.Context, containerID, user string, cmd []string, opts ExecCaptureOptions) (*ExecUserResult, error) {
+	userErr := ExecUserError{Container: containerID, User: user}
+	if version := c.getServerAPIVersion(); version == nil || version.GreaterThanOrEqualTo(apiVersion119) {
+		opts.User = user
+		result, err := c.execAsUser(ctx, containerID, ExecUserField, cmd, opts)
+		if !execUserRejected(err) {
+			return result, err
+		}
+		userErr.Attempts = append(userErr.Attempts, ExecUserAttempt{Strategy: ExecUserField, Err: err})
+	} else {
+		userErr.Attempts = append(userErr.Attempts, ExecUserAttempt{
+			Strategy: ExecUserField,
+			Err:      errors.New("exec user is only supported in API#1.19 and above"),
+		})
+	}
+	opts.User = ""
+	name, group := user, ""
+	if i := strings.Index(user, ":"); i > -1 {
+		name, group = user[:i], user[i+1:]
+	}
+	runuser := []string{"runuser", "-u", name}
+	if group != "" {
+		runuser = append(runuser, "-g", group)
+	}
+	runuser = append(append(runuser, "--"), cmd...)
+	result, err := c.execAsUser(ctx, containerID, ExecUserRunuser, runuser, opts)
+	if err != nil || !execCommandNotFound(result, "runuser") {
+		return result, err
+	}
+	userErr.Attempts = append(userErr.Attempts, ExecUserAttempt{Strategy: ExecUserRunuser, Err: errors.New("runuser not found in the container")})
+	if group != "" {
+		userErr.Attempts = append(userErr.Attempts, ExecUserAttempt{Strategy: ExecUserSu, Err: fmt.Errorf("su can't switch to group %s", group)})
+		return nil, &userErr
+	}
+	su := []string{"su", "-s", "/bin/sh", "-c", shellQuote(cmd), name}
+	result, err = c.execAsUser(ctx, containerID, ExecUserSu, su, opts)
+	if err != nil || !execCommandNotFound(result, "su") {
+		return result, err
+	}
+	userErr.Attempts = append(userErr.Attempts, ExecUserAttempt{Strategy: ExecUserSu, Err: errors.New("su not found in the container")})
+	return nil, &userErr
+}
+
+func (c *Client) execAsUser(ctx context.Context, containerID string, strategy ExecUserStrategy, cmd []string, opts ExecCaptureOptions) (*ExecUserResult, error) {
+	stdout, stderr, exitCode, err := c.ExecCapture(ctx, containerID, cmd, opts)
+	if err != nil && err != ErrExecOutputLimitExceeded {
+		return nil, err
+	}
+	return &ExecUserResult{Stdout: stdout, Stderr: stderr, ExitCode: exitCode, Strategy: strategy}, err
+}
+
+// execUserRejected returns whether the daemon refused to create or start an
+// exec instance because of its user. Missing and stopped containers aren't
+// rejections.
+func execUserRejected(err error) bool {
+	e, ok := err.(*Error)
+	return ok && (e.Status == http.StatusBadRequest || e.Status == http.StatusInternalServerError)
+}
+
+// execCommandNotFound returns whether a command wrapped with the given
+// program failed to run because the program doesn't exist in the container.
+func execCommandNotFound(result *ExecUserResult, program string) bool {
+	if result.ExitCode != 126 && result.ExitCode != 127 {
+		return false
+	}
+	output := string(result.Stdout) + string(result.Stderr)
+	return strings.Contains(output, program) && strings.Contains(output, "not found")
+}
+
+// shellQuote joins the arguments in a command line for sh, quoting each of
+// them.
+func shellQuote(args []string) string {
+	var buf bytes.Buffer
+	for i, arg := range args {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteByte('\'')
+		buf.WriteString(strings.Replace(arg, "'", `'\''`, -1))
+		buf.WriteByte('\'')
+	}
+	return buf.String()
+}
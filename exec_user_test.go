@@ -0,0 +1,182 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// execUserDaemon is a fake daemon for ExecAsUser, which runs each command
+// with the given function, returning its output and exit code.
+type execUserDaemon struct {
+	mu    sync.Mutex
+	execs []CreateExecOptions
+	run   func(opts CreateExecOptions) (stdout string, exitCode int, err *Error)
+}
+
+func (d *execUserDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var id int
+	switch {
+	case r.URL.Path == "/containers/web/exec":
+		var opts CreateExecOptions
+		json.NewDecoder(r.Body).Decode(&opts)
+		if _, _, err := d.run(opts); err != nil {
+			http.Error(w, err.Message, err.Status)
+			return
+		}
+		d.execs = append(d.execs, opts)
+		fmt.Fprintf(w, `{"Id":"%d"}`, len(d.execs)-1)
+	case strings.HasSuffix(r.URL.Path, "/start"):
+		fmt.Sscanf(r.URL.Path, "/exec/%d/start", &id)
+		stdout, _, _ := d.run(d.execs[id])
+		ioutil.ReadAll(r.Body)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n"))
+		conn.Write(execFrame(1, stdout))
+		conn.Close()
+	case strings.HasSuffix(r.URL.Path, "/json"):
+		fmt.Sscanf(r.URL.Path, "/exec/%d/json", &id)
+		_, exitCode, _ := d.run(d.execs[id])
+		json.NewEncoder(w).Encode(ExecInspect{ExitCode: exitCode})
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (d *execUserDaemon) commands() [][]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var cmds [][]string
+	for _, exec := range d.execs {
+		cmds = append(cmds, append([]string{"user=" + exec.User}, exec.Cmd...))
+	}
+	return cmds
+}
+
+func TestExecAsUser(t *testing.T) {
+	t.Parallel()
+	daemon := &execUserDaemon{run: func(opts CreateExecOptions) (string, int, *Error) {
+		return "www-data\n", 0, nil
+	}}
+	client := NewClientFromHandler(daemon)
+	result, err := client.ExecAsUser(context.Background(), "web", "www-data", []string{"whoami"}, ExecCaptureOptions{User: "nobody"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Stdout) != "www-data\n" || result.ExitCode != 0 || result.Strategy != ExecUserField {
+		t.Errorf("ExecAsUser: wrong result: %#v", result)
+	}
+	expectedCmds := [][]string{{"user=www-data", "whoami"}}
+	if cmds := daemon.commands(); !reflect.DeepEqual(cmds, expectedCmds) {
+		t.Errorf("ExecAsUser: wrong commands. Want %#v. Got %#v.", expectedCmds, cmds)
+	}
+}
+
+func TestExecAsUserFallback(t *testing.T) {
+	t.Parallel()
+	daemon := &execUserDaemon{run: func(opts CreateExecOptions) (string, int, *Error) {
+		switch {
+		case opts.User != "":
+			return "", 0, &Error{Status: http.StatusBadRequest, Message: "user is not supported"}
+		case opts.Cmd[0] == "runuser":
+			return `exec: "runuser": executable file not found in $PATH`, 127, nil
+		}
+		return "hello world\n", 2, nil
+	}}
+	client := NewClientFromHandler(daemon)
+	result, err := client.ExecAsUser(context.Background(), "web", "app", []string{"echo", "it's", "hello world"}, ExecCaptureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Stdout) != "hello world\n" || result.ExitCode != 2 || result.Strategy != ExecUserSu {
+		t.Errorf("ExecAsUser: wrong result: %#v", result)
+	}
+	expectedCmds := [][]string{
+		{"user=", "runuser", "-u", "app", "--", "echo", "it's", "hello world"},
+		{"user=", "su", "-s", "/bin/sh", "-c", `'echo' 'it'\''s' 'hello world'`, "app"},
+	}
+	if cmds := daemon.commands(); !reflect.DeepEqual(cmds, expectedCmds) {
+		t.Errorf("ExecAsUser: wrong commands. Want %#v. Got %#v.", expectedCmds, cmds)
+	}
+}
+
+func TestExecAsRootOldAPI(t *testing.T) {
+	t.Parallel()
+	daemon := &execUserDaemon{run: func(opts CreateExecOptions) (string, int, *Error) {
+		if opts.User != "" {
+			t.Errorf("ExecAsRoot: unexpected user %q", opts.User)
+		}
+		return "root\n", 0, nil
+	}}
+	client := NewClientFromHandler(daemon)
+	client.serverAPIVersion = apiVersion112
+	result, err := client.ExecAsRoot(context.Background(), "web", []string{"id", "-un"}, ExecCaptureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Stdout) != "root\n" || result.Strategy != ExecUserRunuser {
+		t.Errorf("ExecAsRoot: wrong result: %#v", result)
+	}
+}
+
+func TestExecAsUserError(t *testing.T) {
+	t.Parallel()
+	daemon := &execUserDaemon{run: func(opts CreateExecOptions) (string, int, *Error) {
+		if opts.User != "" {
+			return "", 0, &Error{Status: http.StatusInternalServerError, Message: "no matching entries in passwd file"}
+		}
+		return fmt.Sprintf(`exec: %q: executable file not found in $PATH`, opts.Cmd[0]), 127, nil
+	}}
+	client := NewClientFromHandler(daemon)
+	tests := []struct {
+		user     string
+		expected string
+	}{
+		{"app", "cannot exec as app in container web (user: API error (500): no matching entries in passwd file; runuser: runuser not found in the container; su: su not found in the container)"},
+		{"app:staff", "cannot exec as app:staff in container web (user: API error (500): no matching entries in passwd file; runuser: runuser not found in the container; su: su can't switch to group staff)"},
+	}
+	for _, tt := range tests {
+		result, err := client.ExecAsUser(context.Background(), "web", tt.user, []string{"true"}, ExecCaptureOptions{})
+		if result != nil {
+			t.Errorf("ExecAsUser(%q): unexpected result: %#v", tt.user, result)
+		}
+		userErr, ok := err.(*ExecUserError)
+		if !ok {
+			t.Errorf("ExecAsUser(%q): wrong error. Want *ExecUserError. Got %#v.", tt.user, err)
+			continue
+		}
+		if userErr.Error() != tt.expected {
+			t.Errorf("ExecAsUser(%q): wrong error message.\nWant %q.\nGot  %q.", tt.user, tt.expected, userErr.Error())
+		}
+	}
+}
+
+func TestExecAsUserContainerNotRunning(t *testing.T) {
+	t.Parallel()
+	daemon := &execUserDaemon{run: func(opts CreateExecOptions) (string, int, *Error) {
+		return "", 0, &Error{Status: http.StatusConflict, Message: "container web is not running"}
+	}}
+	client := NewClientFromHandler(daemon)
+	_, err := client.ExecAsUser(context.Background(), "web", "app", []string{"true"}, ExecCaptureOptions{})
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusConflict {
+		t.Errorf("ExecAsUser: wrong error. Want the API error. Got %#v.", err)
+	}
+	if cmds := daemon.commands(); len(cmds) > 0 {
+		t.Errorf("ExecAsUser: unexpected fallbacks: %#v", cmds)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fixture manages the Docker resources used by integration tests:
+// ephemeral user-defined networks and the containers connected to them, which
+// can reach each other by their network aliases. All the resources created
+// within a Session are removed when the session is closed, and the ones left
+// behind by sessions that were never closed can be removed by Session.Reap.
+package fixture
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	docker "github.com/abrechon/go-dockerclient"
+)
+
+// Session tracks the networks and containers created for a test, so they can
+// be removed in the proper order once the test is done.
+//
+// All the resources created by the session are labeled with its ID, so the
+// ones left behind by sessions that were never closed can be found and
+// removed by Reap.
+type Session struct {
+	ID      string
+	Created time.Time
+
+	client *docker.Client
+
+	mu         sync.Mutex
+	containers []string
+	networks   []string
+}
+
+// Network is an ephemeral user-defined network created by a Session.
+type Network struct {
+	ID   string
+	Name string
+}
+
+// ContainerOptions specify the container started by RunContainer.
+type ContainerOptions struct {
+	// Name of the container. Leave it empty to let the daemon generate a
+	// name, which avoids conflicts between concurrent test runs.
+	Name string
+
+	Config     *docker.Config
+	HostConfig *docker.HostConfig
+
+	// Networks the container is connected to. The container is reachable
+	// from other containers in these networks using any of the Aliases.
+	Networks []*Network
+	Aliases  []string
+}
+
+// NewSession returns a session that creates its resources using the given
+// client.
+func NewSession(client *docker.Client) *Session {
+	return &Session{
+		ID:      randomID(),
+		Created: time.Now().UTC(),
+		client:  client,
+	}
+}
+
+// Labels returns the labels that identify the resources of the session. They
+// are added to all the resources created by the session, and may be added to
+// other resources that should be reaped along with them.
+func (s *Session) Labels() map[string]string {
+	return map[string]string{
+		SessionLabel:        s.ID,
+		SessionCreatedLabel: s.Created.Format(time.RFC3339),
+	}
+}
+
+// CreateNetwork creates a bridge network to be used by the containers of the
+// session. A random suffix is appended to the name, so tests running
+// concurrently don't share networks.
+func (s *Session) CreateNetwork(ctx context.Context, name string) (*Network, error) {
+	if name == "" {
+		name = "fixture"
+	}
+	name += "-" + randomID()
+	network, err := s.client.CreateNetwork(docker.CreateNetworkOptions{
+		Name:           name,
+		Driver:         "bridge",
+		Labels:         s.Labels(),
+		CheckDuplicate: true,
+		Context:        ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.networks = append(s.networks, network.ID)
+	s.mu.Unlock()
+	return &Network{ID: network.ID, Name: name}, nil
+}
+
+// RunContainer creates and starts a container, connecting it to the networks
+// listed in opts. It returns the inspected container after it's started.
+//
+// The name of the container, when given, is also used as an alias in all the
+// networks.
+func (s *Session) RunContainer(ctx context.Context, opts ContainerOptions) (*docker.Container, error) {
+	var aliases []string
+	if opts.Name != "" {
+		aliases = append(aliases, opts.Name)
+	}
+	aliases = append(aliases, opts.Aliases...)
+	var config docker.Config
+	if opts.Config != nil {
+		config = *opts.Config
+	}
+	config.Labels = make(map[string]string, len(config.Labels)+2)
+	if opts.Config != nil {
+		for k, v := range opts.Config.Labels {
+			config.Labels[k] = v
+		}
+	}
+	for k, v := range s.Labels() {
+		config.Labels[k] = v
+	}
+	var hostConfig docker.HostConfig
+	if opts.HostConfig != nil {
+		hostConfig = *opts.HostConfig
+	}
+	createOpts := docker.CreateContainerOptions{
+		Name:       opts.Name,
+		Config:     &config,
+		HostConfig: &hostConfig,
+		Context:    ctx,
+	}
+	if len(opts.Networks) > 0 {
+		first := opts.Networks[0]
+		if hostConfig.NetworkMode == "" {
+			hostConfig.NetworkMode = first.Name
+		}
+		createOpts.NetworkingConfig = &docker.NetworkingConfig{
+			EndpointsConfig: map[string]*docker.EndpointConfig{
+				first.Name: {Aliases: aliases},
+			},
+		}
+	}
+	container, err := s.client.CreateContainer(createOpts)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.containers = append(s.containers, container.ID)
+	s.mu.Unlock()
+	for i := 1; i < len(opts.Networks); i++ {
+		err = s.client.ConnectNetwork(opts.Networks[i].ID, docker.NetworkConnectionOptions{
+			Container:      container.ID,
+			EndpointConfig: &docker.EndpointConfig{Aliases: aliases},
+			Context:        ctx,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = s.client.StartContainerWithContext(container.ID, nil, ctx); err != nil {
+		return nil, err
+	}
+	return s.client.InspectContainerWithContext(container.ID, ctx)
+}
+
+// Close removes all the resources created by the session. Containers are
+// removed first, most recent first, along with their anonymous volumes, and
+// then the networks they were connected to. Resources that were already
+// removed are ignored. It returns the first error found, after trying to
+// remove all the resources.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	containers, networks := s.containers, s.networks
+	s.containers, s.networks = nil, nil
+	s.mu.Unlock()
+	var firstErr error
+	for i := len(containers) - 1; i >= 0; i-- {
+		err := s.client.RemoveContainer(docker.RemoveContainerOptions{
+			ID:            containers[i],
+			RemoveVolumes: true,
+			Force:         true,
+		})
+		if _, ok := err.(*docker.NoSuchContainer); err != nil && !ok && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for i := len(networks) - 1; i >= 0; i-- {
+		err := s.client.RemoveNetwork(networks[i])
+		if _, ok := err.(*docker.NoSuchNetwork); err != nil && !ok && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func randomID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,226 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	docker "github.com/abrechon/go-dockerclient"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	body   []byte
+}
+
+type fakeDaemon struct {
+	mu         sync.Mutex
+	requests   []recordedRequest
+	containers int
+	networks   int
+	missing    map[string]bool
+}
+
+func (d *fakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, recordedRequest{method: r.Method, path: r.URL.Path, body: body})
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/version":
+		w.Write([]byte(`{"ApiVersion":"1.25"}`))
+	case r.URL.Path == "/networks/create":
+		d.networks++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(docker.Network{ID: "net" + strconv.Itoa(d.networks)})
+	case r.URL.Path == "/containers/create":
+		d.containers++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(docker.Container{ID: "c" + strconv.Itoa(d.containers)})
+	case r.Method == http.MethodDelete && d.missing[r.URL.Path]:
+		http.Error(w, "no such object", http.StatusNotFound)
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasSuffix(r.URL.Path, "/json"):
+		id := strings.Split(r.URL.Path, "/")[2]
+		json.NewEncoder(w).Encode(docker.Container{ID: id, State: docker.State{Running: true}})
+	case strings.HasSuffix(r.URL.Path, "/start"):
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (d *fakeDaemon) paths(method string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var paths []string
+	for _, r := range d.requests {
+		if r.method == method {
+			paths = append(paths, r.path)
+		}
+	}
+	return paths
+}
+
+func (d *fakeDaemon) body(method, path string, v interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, r := range d.requests {
+		if r.method == method && r.path == path {
+			return json.Unmarshal(r.body, v)
+		}
+	}
+	return nil
+}
+
+func newSession(t *testing.T, d *fakeDaemon) (*Session, *httptest.Server) {
+	server := httptest.NewServer(d)
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return NewSession(client), server
+}
+
+func TestSessionNetworksAndAliases(t *testing.T) {
+	t.Parallel()
+	daemon := &fakeDaemon{}
+	session, server := newSession(t, daemon)
+	defer server.Close()
+	ctx := context.Background()
+	backend, err := session.CreateNetwork(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.ID != "net1" || !strings.HasPrefix(backend.Name, "backend-") || len(backend.Name) != len("backend-")+12 {
+		t.Errorf("CreateNetwork: unexpected network %#v", backend)
+	}
+	frontend, err := session.CreateNetwork(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(frontend.Name, "fixture-") {
+		t.Errorf("CreateNetwork: wrong default name %q", frontend.Name)
+	}
+	container, err := session.RunContainer(ctx, ContainerOptions{
+		Name:     "db",
+		Config:   &docker.Config{Image: "postgres", Labels: map[string]string{"app": "db", SessionLabel: "forged"}},
+		Networks: []*Network{backend, frontend},
+		Aliases:  []string{"postgres"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.ID != "c1" || !container.State.Running {
+		t.Errorf("RunContainer: unexpected container %#v", container)
+	}
+	var network docker.CreateNetworkOptions
+	if err = daemon.body(http.MethodPost, "/networks/create", &network); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(network.Labels, session.Labels()) {
+		t.Errorf("CreateNetwork: wrong labels. Want %#v. Got %#v.", session.Labels(), network.Labels)
+	}
+	var created struct {
+		Labels           map[string]string
+		HostConfig       docker.HostConfig
+		NetworkingConfig docker.NetworkingConfig
+	}
+	if err = daemon.body(http.MethodPost, "/containers/create", &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Labels[SessionLabel] != session.ID || created.Labels["app"] != "db" {
+		t.Errorf("RunContainer: wrong labels %#v", created.Labels)
+	}
+	if created.HostConfig.NetworkMode != backend.Name {
+		t.Errorf("RunContainer: wrong network mode. Want %q. Got %q.", backend.Name, created.HostConfig.NetworkMode)
+	}
+	expectedAliases := []string{"db", "postgres"}
+	endpoint := created.NetworkingConfig.EndpointsConfig[backend.Name]
+	if endpoint == nil || !reflect.DeepEqual(endpoint.Aliases, expectedAliases) {
+		t.Errorf("RunContainer: wrong endpoint config %#v", created.NetworkingConfig.EndpointsConfig)
+	}
+	var connect docker.NetworkConnectionOptions
+	if err = daemon.body(http.MethodPost, "/networks/net2/connect", &connect); err != nil {
+		t.Fatal(err)
+	}
+	if connect.Container != "c1" || connect.EndpointConfig == nil || !reflect.DeepEqual(connect.EndpointConfig.Aliases, expectedAliases) {
+		t.Errorf("RunContainer: wrong connect options %#v", connect)
+	}
+	expectedPosts := []string{"/networks/create", "/networks/create", "/containers/create", "/networks/net2/connect", "/containers/c1/start"}
+	if posts := daemon.paths(http.MethodPost); !reflect.DeepEqual(posts, expectedPosts) {
+		t.Errorf("RunContainer: wrong requests.\nWant %q.\nGot  %q.", expectedPosts, posts)
+	}
+}
+
+func TestSessionClose(t *testing.T) {
+	t.Parallel()
+	daemon := &fakeDaemon{missing: map[string]bool{"/containers/c1": true}}
+	session, server := newSession(t, daemon)
+	defer server.Close()
+	ctx := context.Background()
+	network, err := session.CreateNetwork(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"db", "app"} {
+		_, err = session.RunContainer(ctx, ContainerOptions{Name: name, Config: &docker.Config{Image: name}, Networks: []*Network{network}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/containers/c2", "/containers/c1", "/networks/net1"}
+	if deletes := daemon.paths(http.MethodDelete); !reflect.DeepEqual(deletes, expected) {
+		t.Errorf("Close: wrong teardown order. Want %q. Got %q.", expected, deletes)
+	}
+	if err = session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if deletes := daemon.paths(http.MethodDelete); len(deletes) != len(expected) {
+		t.Errorf("Close: resources removed twice: %q", deletes)
+	}
+}
+
+func TestSessionCloseError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"Id":"net1"}`))
+		default:
+			http.Error(w, "network has active endpoints", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := NewSession(client)
+	if _, err = session.CreateNetwork(context.Background(), "test"); err != nil {
+		t.Fatal(err)
+	}
+	err = session.Close()
+	if e, ok := err.(*docker.Error); !ok || e.Status != http.StatusForbidden {
+		t.Errorf("Close: wrong error. Want *docker.Error with status 403. Got %#v.", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fixture
+
+import (
+	"context"
+	"time"
+
+	docker "github.com/abrechon/go-dockerclient"
+)
+
+const (
+	// SessionLabel is the label that holds the ID of the session that
+	// created a resource. It's distinct from docker.SessionLabel, as Reap
+	// removes the resources without a valid SessionCreatedLabel.
+	SessionLabel = "io.github.go-dockerclient.fixture.session"
+
+	// SessionCreatedLabel is the label that holds the time (in RFC 3339
+	// format) when the session that created a resource was started.
+	SessionCreatedLabel = "io.github.go-dockerclient.fixture.session-created"
+)
+
+// Reap removes the containers, networks and volumes left behind by other
+// sessions, usually test runs that crashed or were interrupted before closing
+// their sessions. It's meant to be called right after starting a session.
+//
+// Only the resources of sessions started more than minAge ago are removed, so
+// minAge should be larger than the duration of a test run, to avoid removing
+// the resources of sessions running concurrently. Resources of the current
+// session are never removed.
+func (s *Session) Reap(ctx context.Context, minAge time.Duration) error {
+	cutoff := time.Now().Add(-minAge)
+	orphan := func(labels map[string]string) bool {
+		session, ok := labels[SessionLabel]
+		if !ok || session == s.ID {
+			return false
+		}
+		created, err := time.Parse(time.RFC3339, labels[SessionCreatedLabel])
+		return err != nil || created.Before(cutoff)
+	}
+	containers, err := s.client.ListContainers(docker.ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"label": {SessionLabel}},
+		Context: ctx,
+	})
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if !orphan(container.Labels) {
+			continue
+		}
+		err = s.client.RemoveContainer(docker.RemoveContainerOptions{
+			ID:            container.ID,
+			RemoveVolumes: true,
+			Force:         true,
+			Context:       ctx,
+		})
+		if _, ok := err.(*docker.NoSuchContainer); err != nil && !ok {
+			return err
+		}
+	}
+	networks, err := s.client.FilteredListNetworks(docker.NetworkFilterOpts{
+		"label": {SessionLabel: true},
+	})
+	if err != nil {
+		return err
+	}
+	for _, network := range networks {
+		if !orphan(network.Labels) {
+			continue
+		}
+		err = s.client.RemoveNetwork(network.ID)
+		if _, ok := err.(*docker.NoSuchNetwork); err != nil && !ok {
+			return err
+		}
+	}
+	volumes, err := s.client.ListVolumes(docker.ListVolumesOptions{
+		Filters: map[string][]string{"label": {SessionLabel}},
+		Context: ctx,
+	})
+	if err != nil {
+		return err
+	}
+	for _, volume := range volumes {
+		if !orphan(volume.Labels) {
+			continue
+		}
+		err = s.client.RemoveVolumeWithOptions(docker.RemoveVolumeOptions{
+			Name:    volume.Name,
+			Force:   true,
+			Context: ctx,
+		})
+		if err != nil && err != docker.ErrNoSuchVolume {
+			return err
+		}
+	}
+	return nil
+}
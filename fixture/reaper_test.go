@@ -0,0 +1,99 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	docker "github.com/abrechon/go-dockerclient"
+)
+
+func TestSessionReap(t *testing.T) {
+	t.Parallel()
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().UTC().Format(time.RFC3339)
+	labels := func(session, created string) map[string]string {
+		return map[string]string{SessionLabel: session, SessionCreatedLabel: created}
+	}
+	var session *Session
+	var mu sync.Mutex
+	var removed, filters []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			removed = append(removed, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		filters = append(filters, r.URL.Query().Get("filters"))
+		switch r.URL.Path {
+		case "/containers/json":
+			json.NewEncoder(w).Encode([]docker.APIContainers{
+				{ID: "crashed", Labels: labels("s1", old)},
+				{ID: "concurrent", Labels: labels("s2", recent)},
+				{ID: "current", Labels: labels(session.ID, old)},
+				{ID: "no-timestamp", Labels: map[string]string{SessionLabel: "s3"}},
+			})
+		case "/networks":
+			json.NewEncoder(w).Encode([]docker.Network{
+				{ID: "crashed-net", Labels: labels("s1", old)},
+				{ID: "concurrent-net", Labels: labels("s2", recent)},
+			})
+		case "/volumes":
+			json.NewEncoder(w).Encode(map[string][]docker.Volume{"Volumes": {
+				{Name: "crashed-vol", Labels: labels("s1", old)},
+				{Name: "current-vol", Labels: labels(session.ID, recent)},
+			}})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session = NewSession(client)
+	if err = session.Reap(context.Background(), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/containers/crashed", "/containers/no-timestamp", "/networks/crashed-net", "/volumes/crashed-vol"}
+	if !reflect.DeepEqual(removed, expected) {
+		t.Errorf("Reap: wrong resources removed. Want %q. Got %q.", expected, removed)
+	}
+	expectedFilters := []string{
+		`{"label":["` + SessionLabel + `"]}`,
+		`{"label":{"` + SessionLabel + `":true}}`,
+		`{"label":["` + SessionLabel + `"]}`,
+	}
+	if !reflect.DeepEqual(filters, expectedFilters) {
+		t.Errorf("Reap: wrong filters. Want %q. Got %q.", expectedFilters, filters)
+	}
+}
+
+func TestSessionReapError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "daemon unavailable", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewSession(client).Reap(context.Background(), time.Hour)
+	if e, ok := err.(*docker.Error); !ok || e.Status != http.StatusInternalServerError {
+		t.Errorf("Reap: wrong error. Want *docker.Error with status 500. Got %#v.", err)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+// The hand-written types of the package are reconciled with the swagger
+// spec of the pinned version of the API, vendored in internal/apigen from
+// the version of github.com/docker/docker required by go.mod: go generate
+// reports the properties of the spec they miss, and "make apicheck" fails on
+// them. Definitions without a hand-written type are generated to
+// api_generated.go, listed in the -types flag of apigen.
+//
+//go:generate go run ./internal/apigen -spec internal/apigen/swagger.yaml -version 1.41 -map ContainerConfig=Config,HostConfig=HostConfig,HealthConfig=HealthConfig,RestartPolicy=RestartPolicy,Image=Image,Network=Network,Volume=Volume -types BuildCache -out api_generated.go
@@ -14,7 +14,10 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -28,6 +31,13 @@ type APIImages struct {
 	ParentID    string            `json:"ParentId,omitempty" yaml:"ParentId,omitempty" toml:"ParentId,omitempty"`
 	RepoDigests []string          `json:"RepoDigests,omitempty" yaml:"RepoDigests,omitempty" toml:"RepoDigests,omitempty"`
 	Labels      map[string]string `json:"Labels,omitempty" yaml:"Labels,omitempty" toml:"Labels,omitempty"`
+
+	// SharedSize is the size of the layers the image shares with other
+	// images, and Containers the number of containers using the image.
+	// They're only computed when requested with ListImagesOptions.SharedSize
+	// (API 1.42+), and are -1 when they aren't.
+	SharedSize int64 `json:"SharedSize,omitempty" yaml:"SharedSize,omitempty" toml:"SharedSize,omitempty"`
+	Containers int64 `json:"Containers,omitempty" yaml:"Containers,omitempty" toml:"Containers,omitempty"`
 }
 
 // RootFS represents the underlying layers used by an image
@@ -102,6 +112,11 @@ type ListImagesOptions struct {
 	Digests bool
 	Filter  string
 	Context context.Context
+
+	// SharedSize makes the daemon compute the SharedSize and the
+	// Containers of the images, e.g. for disk usage reports. It's costly
+	// on hosts with many images.
+	SharedSize bool `qs:"shared-size"`
 }
 
 // ListImages returns the list of available images in the server.
@@ -115,7 +130,7 @@ func (c *Client) ListImages(opts ListImagesOptions) ([]APIImages, error) {
 	}
 	defer resp.Body.Close()
 	var images []APIImages
-	if err := json.NewDecoder(resp.Body).Decode(&images); err != nil {
+	if err := decodeJSONList(resp.Body, &images); err != nil {
 		return nil, err
 	}
 	return images, nil
@@ -135,7 +150,11 @@ type ImageHistory struct {
 //
 // See https://goo.gl/fYtxQa for more details.
 func (c *Client) ImageHistory(name string) ([]ImageHistory, error) {
-	resp, err := c.do("GET", "/images/"+name+"/history", doOptions{})
+	return c.imageHistory(context.Background(), name)
+}
+
+func (c *Client) imageHistory(ctx context.Context, name string) ([]ImageHistory, error) {
+	resp, err := c.do("GET", "/images/"+name+"/history", doOptions{context: ctx})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
 			return nil, ErrNoSuchImage
@@ -152,14 +171,24 @@ func (c *Client) ImageHistory(name string) ([]ImageHistory, error) {
 
 // RemoveImage removes an image by its name or ID.
 //
+// When the client has ValidateImageReferences set, the name is validated
+// with the reference package, returning an *InvalidImageReference when it's
+// malformed. Removing a repo@digest reference untags the digest. When
+// containers use the image, the error is an *ImageConflict listing them,
+// whose Err field holds the *Error returned by the daemon.
+//
 // See https://goo.gl/Vd2Pck for more details.
 func (c *Client) RemoveImage(name string) error {
+	name, err := c.imageReference(name)
+	if err != nil {
+		return err
+	}
 	resp, err := c.do("DELETE", "/images/"+name, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
 			return ErrNoSuchImage
 		}
-		return err
+		return newImageConflict(name, err)
 	}
 	resp.Body.Close()
 	return nil
@@ -180,16 +209,69 @@ type RemoveImageOptions struct {
 //
 // See https://goo.gl/Vd2Pck for more details.
 func (c *Client) RemoveImageExtended(name string, opts RemoveImageOptions) error {
+	_, err := c.RemoveImageWithResults(name, opts)
+	return err
+}
+
+// RemoveImageResult is an entry of the response of the daemon to the removal
+// of an image. Each entry has either Untagged, with a reference that was
+// removed from the image, or Deleted, with the ID of an image (or parent
+// layer) that was deleted.
+type RemoveImageResult struct {
+	Untagged string `json:"Untagged,omitempty" yaml:"Untagged,omitempty" toml:"Untagged,omitempty"`
+	Deleted  string `json:"Deleted,omitempty" yaml:"Deleted,omitempty" toml:"Deleted,omitempty"`
+}
+
+// RemoveImageResults is the list of references untagged and images deleted by
+// the removal of an image, in the order reported by the daemon.
+type RemoveImageResults []RemoveImageResult
+
+// Untagged returns the references that were untagged.
+func (r RemoveImageResults) Untagged() []string {
+	var refs []string
+	for _, result := range r {
+		if result.Untagged != "" {
+			refs = append(refs, result.Untagged)
+		}
+	}
+	return refs
+}
+
+// Deleted returns the IDs of the images that were deleted.
+func (r RemoveImageResults) Deleted() []string {
+	var ids []string
+	for _, result := range r {
+		if result.Deleted != "" {
+			ids = append(ids, result.Deleted)
+		}
+	}
+	return ids
+}
+
+// RemoveImageWithResults removes an image by its name or ID, like
+// RemoveImageExtended, returning what the daemon untagged and deleted. See
+// RemoveImage for how the name is validated and the errors returned.
+//
+// See https://goo.gl/Vd2Pck for more details.
+func (c *Client) RemoveImageWithResults(name string, opts RemoveImageOptions) (RemoveImageResults, error) {
+	name, err := c.imageReference(name)
+	if err != nil {
+		return nil, err
+	}
 	uri := fmt.Sprintf("/images/%s?%s", name, queryString(&opts))
 	resp, err := c.do("DELETE", uri, doOptions{context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return ErrNoSuchImage
+			return nil, ErrNoSuchImage
 		}
-		return err
+		return nil, newImageConflict(name, err)
 	}
-	resp.Body.Close()
-	return nil
+	defer resp.Body.Close()
+	var results RemoveImageResults
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return results, nil
 }
 
 // InspectImage returns an image by its name or ID.
@@ -208,7 +290,7 @@ func (c *Client) InspectImage(name string) (*Image, error) {
 	var image Image
 
 	// if the caller elected to skip checking the server's version, assume it's the latest
-	if c.SkipServerVersionCheck || c.expectedAPIVersion.GreaterThanOrEqualTo(apiVersion112) {
+	if c.SkipServerVersionCheck || c.getExpectedAPIVersion().GreaterThanOrEqualTo(apiVersion112) {
 		if err := json.NewDecoder(resp.Body).Decode(&image); err != nil {
 			return nil, err
 		}
@@ -251,6 +333,17 @@ type PushImageOptions struct {
 	RawJSONStream     bool          `qs:"-"`
 	InactivityTimeout time.Duration `qs:"-"`
 
+	// AuxCallback receives the aux messages of the stream, e.g. the digest
+	// of the image pushed (see AuxMessage.PushResult). When it's set, errors
+	// reported in the stream are returned even with RawJSONStream.
+	AuxCallback func(AuxMessage) `qs:"-"`
+
+	// PostPushHook is called once the image is pushed, for each tag pushed,
+	// e.g. to sign the image. Its errors fail the push, as a
+	// *PostPushHookError, unless IgnorePostPushHookErrors is set.
+	PostPushHook             PostPushHook `qs:"-"`
+	IgnorePostPushHookErrors bool         `qs:"-"`
+
 	Context context.Context
 }
 
@@ -269,16 +362,38 @@ func (c *Client) PushImage(opts PushImageOptions, auth AuthConfiguration) error
 		return err
 	}
 	name := opts.Name
+	if opts.Tag == "" {
+		if repository, tag, digest, ok := splitReference(name); ok && digest == "" {
+			name, opts.Tag = repository, tag
+		}
+	}
 	opts.Name = ""
 	path := "/images/" + name + "/push?" + queryString(&opts)
-	return c.stream("POST", path, streamOptions{
+	var pushed []PushResult
+	if opts.PostPushHook != nil {
+		auxCallback := opts.AuxCallback
+		opts.AuxCallback = func(aux AuxMessage) {
+			if result, ok := aux.PushResult(); ok {
+				pushed = append(pushed, *result)
+			}
+			if auxCallback != nil {
+				auxCallback(aux)
+			}
+		}
+	}
+	err = c.stream("POST", path, streamOptions{
 		setRawTerminal:    true,
 		rawJSONStream:     opts.RawJSONStream,
 		headers:           headers,
 		stdout:            opts.OutputStream,
 		inactivityTimeout: opts.InactivityTimeout,
 		context:           opts.Context,
+		auxCallback:       opts.AuxCallback,
 	})
+	if err != nil || opts.PostPushHook == nil {
+		return err
+	}
+	return runPostPushHook(name, pushed, opts)
 }
 
 // PullImageOptions present the set of options available for pulling an image
@@ -303,6 +418,14 @@ type PullImageOptions struct {
 // PullImage pulls an image from a remote registry, logging progress to
 // opts.OutputStream.
 //
+// Concurrent calls pulling the same reference with the same credentials are
+// deduplicated: only one request is sent to the daemon, and all callers
+// receive the progress stream from the moment they joined, as well as the
+// result of the pull. The shared pull doesn't run on the context of any of
+// the callers: each caller stops waiting when its own context is done, and
+// the pull is only cancelled once every caller stopped waiting. Calls with
+// different inactivity timeouts are not deduplicated.
+//
 // See https://goo.gl/qkoSsn for more details.
 func (c *Client) PullImage(opts PullImageOptions, auth AuthConfiguration) error {
 	if opts.Repository == "" {
@@ -313,12 +436,127 @@ func (c *Client) PullImage(opts PullImageOptions, auth AuthConfiguration) error
 	if err != nil {
 		return err
 	}
-	if opts.Tag == "" && strings.Contains(opts.Repository, "@") {
-		parts := strings.SplitN(opts.Repository, "@", 2)
-		opts.Repository = parts[0]
-		opts.Tag = parts[1]
+	if opts.Tag == "" {
+		if repository, tag, digest, ok := splitReference(opts.Repository); ok && digest == "" {
+			opts.Repository, opts.Tag = repository, tag
+		} else if strings.Contains(opts.Repository, "@") {
+			parts := strings.SplitN(opts.Repository, "@", 2)
+			opts.Repository = parts[0]
+			opts.Tag = parts[1]
+		}
+	}
+	qs := queryString(&opts)
+	if c.pulls == nil {
+		return c.createImage(qs, headers, nil, opts.OutputStream, opts.RawJSONStream, opts.InactivityTimeout, opts.Context)
 	}
-	return c.createImage(queryString(&opts), headers, nil, opts.OutputStream, opts.RawJSONStream, opts.InactivityTimeout, opts.Context)
+	key := fmt.Sprintf("%s\x00%s\x00%t\x00%s", qs, headers["X-Registry-Auth"], opts.RawJSONStream, opts.InactivityTimeout)
+	return c.pulls.do(key, opts.OutputStream, opts.Context, func(ctx context.Context, w io.Writer) error {
+		return c.createImage(qs, headers, nil, w, opts.RawJSONStream, opts.InactivityTimeout, ctx)
+	})
+}
+
+// pullGroup deduplicates concurrent pulls of the same image.
+type pullGroup struct {
+	mu    sync.Mutex
+	calls map[string]*pullCall
+}
+
+// pullCall is an in-flight pull. It's also the writer for the progress of the
+// pull, forwarding it to the output stream of every caller waiting on it.
+type pullCall struct {
+	done   chan struct{}
+	err    error
+	cancel context.CancelFunc
+
+	// waiters is the number of callers waiting on the pull, guarded by the
+	// mutex of the group.
+	waiters int
+
+	mu      sync.Mutex
+	writers []io.Writer
+}
+
+// do runs fn, or joins the call already running it for the key, and waits
+// for its result. The call runs on a context of its own, so every caller
+// stops waiting when its own context is done, and the call is only
+// cancelled once all the callers stopped waiting.
+func (g *pullGroup) do(key string, w io.Writer, ctx context.Context, fn func(context.Context, io.Writer) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*pullCall)
+	}
+	call, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.Background())
+		call = &pullCall{done: make(chan struct{}), cancel: cancel}
+		call.addWriter(w)
+		g.calls[key] = call
+		go func() {
+			err := fn(callCtx, call)
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			call.err = err
+			close(call.done)
+			cancel()
+		}()
+	} else {
+		call.addWriter(w)
+	}
+	call.waiters++
+	g.mu.Unlock()
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		call.removeWriter(w)
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			call.cancel()
+		}
+		g.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (c *pullCall) addWriter(w io.Writer) {
+	if w == nil {
+		return
+	}
+	c.mu.Lock()
+	c.writers = append(c.writers, w)
+	c.mu.Unlock()
+}
+
+func (c *pullCall) removeWriter(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, writer := range c.writers {
+		if writer == w {
+			c.writers = append(c.writers[:i], c.writers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Write sends the progress to all the callers. Errors from individual writers
+// are ignored so one caller can't interrupt the pull for the others.
+func (c *pullCall) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.writers {
+		w.Write(p)
+	}
+	return len(p), nil
 }
 
 func (c *Client) createImage(qs string, headers map[string]string, in io.Reader, w io.Writer, rawJSONStream bool, timeout time.Duration, context context.Context) error {
@@ -409,6 +647,11 @@ type ImportImageOptions struct {
 	Repository string `qs:"repo"`
 	Source     string `qs:"fromSrc"`
 	Tag        string `qs:"tag"`
+	Message    string `qs:"message"`
+
+	// Dockerfile instructions to apply to the imported image, e.g.
+	// "ENV DEBUG=true" or `CMD ["/bin/sh"]`.
+	Changes []string `qs:"changes"`
 
 	InputStream       io.Reader     `qs:"-"`
 	OutputStream      io.Writer     `qs:"-"`
@@ -438,6 +681,117 @@ func (c *Client) ImportImage(opts ImportImageOptions) error {
 	return c.createImage(queryString(&opts), nil, opts.InputStream, opts.OutputStream, opts.RawJSONStream, opts.InactivityTimeout, opts.Context)
 }
 
+// FlattenImage squashes all the layers of the given image into a single layer,
+// storing the result in newTag (in the form <repository>[:<tag>]).
+//
+// The image is flattened by creating a container from it, exporting the
+// filesystem of the container and importing it back as a new image. The
+// configuration of the original image (environment, entrypoint, command,
+// working directory, user, exposed ports, volumes, labels and stop signal) is
+// restored in the new image.
+func (c *Client) FlattenImage(name, newTag string) (*Image, error) {
+	image, err := c.InspectImage(name)
+	if err != nil {
+		return nil, err
+	}
+	repository, tag := ParseRepositoryTag(newTag)
+	if repository == "" {
+		return nil, ErrMissingRepo
+	}
+	// the container is never started, but the daemon refuses to create
+	// containers without a command
+	container, err := c.CreateContainer(CreateContainerOptions{
+		Config: &Config{Image: image.ID, Cmd: []string{"flatten"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer c.RemoveContainer(RemoveContainerOptions{ID: container.ID, Force: true})
+	r, w := io.Pipe()
+	errs := make(chan error, 1)
+	go func() {
+		err := c.ExportContainer(ExportContainerOptions{ID: container.ID, OutputStream: w})
+		w.CloseWithError(err)
+		errs <- err
+	}()
+	err = c.ImportImage(ImportImageOptions{
+		Repository:  repository,
+		Tag:         tag,
+		Source:      "-",
+		Message:     "flattened from " + name,
+		Changes:     configChanges(image.Config),
+		InputStream: r,
+	})
+	r.CloseWithError(err)
+	if exportErr := <-errs; exportErr != nil {
+		return nil, exportErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.InspectImage(newTag)
+}
+
+// configChanges returns the list of Dockerfile instructions that restore the
+// given configuration when importing an image.
+func configChanges(config *Config) []string {
+	if config == nil {
+		return nil
+	}
+	var changes []string
+	for _, env := range config.Env {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 {
+			changes = append(changes, fmt.Sprintf("ENV %s=%s", parts[0], strconv.Quote(parts[1])))
+		}
+	}
+	if config.Entrypoint != nil {
+		changes = append(changes, "ENTRYPOINT "+jsonArray(config.Entrypoint))
+	}
+	if config.Cmd != nil {
+		changes = append(changes, "CMD "+jsonArray(config.Cmd))
+	}
+	if config.WorkingDir != "" {
+		changes = append(changes, "WORKDIR "+config.WorkingDir)
+	}
+	if config.User != "" {
+		changes = append(changes, "USER "+config.User)
+	}
+	if config.StopSignal != "" {
+		changes = append(changes, "STOPSIGNAL "+config.StopSignal)
+	}
+	ports := make([]string, 0, len(config.ExposedPorts))
+	for port := range config.ExposedPorts {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+	for _, port := range ports {
+		changes = append(changes, "EXPOSE "+port)
+	}
+	volumes := make([]string, 0, len(config.Volumes))
+	for volume := range config.Volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+	for _, volume := range volumes {
+		changes = append(changes, "VOLUME "+jsonArray([]string{volume}))
+	}
+	labels := make([]string, 0, len(config.Labels))
+	for key := range config.Labels {
+		labels = append(labels, key)
+	}
+	sort.Strings(labels)
+	for _, key := range labels {
+		changes = append(changes, fmt.Sprintf("LABEL %s=%s", strconv.Quote(key), strconv.Quote(config.Labels[key])))
+	}
+	return changes
+}
+
+func jsonArray(values []string) string {
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
 // BuildImageOptions present the set of informations available for building an
 // image from a tarfile with a Dockerfile in it.
 //
@@ -474,6 +828,11 @@ type BuildImageOptions struct {
 	RmTmpContainer      bool               `qs:"rm"`
 	ForceRmTmpContainer bool               `qs:"forcerm"`
 	RawJSONStream       bool               `qs:"-"`
+
+	// AuxCallback receives the aux messages of the stream, e.g. the ID of
+	// the image built (see AuxMessage.ImageID). When it's set, errors
+	// reported in the stream are returned even with RawJSONStream.
+	AuxCallback func(AuxMessage) `qs:"-"`
 }
 
 // BuildArg represents arguments that can be passed to the image when building
@@ -518,7 +877,7 @@ func (c *Client) BuildImage(opts BuildImageOptions) error {
 	}
 	qs := queryString(&opts)
 
-	if c.serverAPIVersion.GreaterThanOrEqualTo(apiVersion125) && len(opts.CacheFrom) > 0 {
+	if c.getServerAPIVersion().GreaterThanOrEqualTo(apiVersion125) && len(opts.CacheFrom) > 0 {
 		if b, err := json.Marshal(opts.CacheFrom); err == nil {
 			item := url.Values(map[string][]string{})
 			item.Add("cachefrom", string(b))
@@ -554,14 +913,15 @@ func (c *Client) BuildImage(opts BuildImageOptions) error {
 		stdout:            opts.OutputStream,
 		inactivityTimeout: opts.InactivityTimeout,
 		context:           opts.Context,
+		auxCallback:       opts.AuxCallback,
 	})
 }
 
 func (c *Client) versionedAuthConfigs(authConfigs AuthConfigurations) registryAuth {
-	if c.serverAPIVersion == nil {
+	if c.getServerAPIVersion() == nil {
 		c.checkAPIVersion()
 	}
-	if c.serverAPIVersion != nil && c.serverAPIVersion.GreaterThanOrEqualTo(apiVersion119) {
+	if version := c.getServerAPIVersion(); version != nil && version.GreaterThanOrEqualTo(apiVersion119) {
 		return AuthConfigurations119(authConfigs.Configs)
 	}
 	return authConfigs
@@ -579,11 +939,23 @@ type TagImageOptions struct {
 
 // TagImage adds a tag to the image identified by the given name.
 //
+// When the client has ValidateImageReferences set, both the name of the image
+// and the target repository and tag are validated with the reference
+// package, returning an *InvalidImageReference when malformed, or
+// ErrTagWithDigest when the repository has a digest.
+//
 // See https://goo.gl/prHrvo for more details.
 func (c *Client) TagImage(name string, opts TagImageOptions) error {
 	if name == "" {
 		return ErrNoSuchImage
 	}
+	name, err := c.imageReference(name)
+	if err != nil {
+		return err
+	}
+	if err = c.validateTagTarget(opts.Repo, opts.Tag); err != nil {
+		return err
+	}
 	resp, err := c.do("POST", "/images/"+name+"/tag?"+queryString(&opts), doOptions{
 		context: opts.Context,
 	})
@@ -684,6 +1056,10 @@ func (c *Client) SearchImagesEx(term string, auth AuthConfiguration) ([]APIImage
 type PruneImagesOptions struct {
 	Filters map[string][]string
 	Context context.Context
+
+	// DryRun lists the images that would be removed without removing them.
+	// See PruneImages.
+	DryRun bool `qs:"-"`
 }
 
 // PruneImagesResults specify results from the PruneImages function.
@@ -696,8 +1072,16 @@ type PruneImagesResults struct {
 
 // PruneImages deletes images which are unused.
 //
+// As the daemon has no dry run, with opts.DryRun the images not used by any
+// container and matching the filters are listed client-side and returned
+// without being removed. Their SpaceReclaimed is an upper bound, as layers
+// shared with other images are counted.
+//
 // See https://goo.gl/qfZlbZ for more details.
 func (c *Client) PruneImages(opts PruneImagesOptions) (*PruneImagesResults, error) {
+	if opts.DryRun {
+		return c.pruneImagesDryRun(opts)
+	}
 	path := "/images/prune?" + queryString(opts)
 	resp, err := c.do("POST", path, doOptions{context: opts.Context})
 	if err != nil {
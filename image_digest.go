@@ -0,0 +1,94 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"strings"
+
+	"github.com/abrechon/go-dockerclient/internal/jsonmessage"
+)
+
+// PushResult is the result of a push, as reported by the daemon at the end
+// of the progress stream.
+type PushResult struct {
+	// Tag is the tag that was pushed.
+	Tag string
+
+	// Digest is the digest of the manifest pushed to the registry, e.g.
+	// "sha256:...", which identifies the image pushed regardless of later
+	// pushes to the same tag.
+	Digest string
+
+	// Size is the size of the manifest, in bytes.
+	Size int
+}
+
+// PushImageWithResult pushes an image like PushImage, returning the digest
+// of the image pushed, so it can be recorded without inspecting the image
+// afterwards. Progress is still sent to opts.OutputStream, as requested by
+// opts.RawJSONStream.
+//
+// The result is nil when the daemon doesn't report the digest, e.g. for
+// pushes to a registry v1.
+func (c *Client) PushImageWithResult(opts PushImageOptions, auth AuthConfiguration) (*PushResult, error) {
+	var result *PushResult
+	auxCallback := opts.AuxCallback
+	opts.AuxCallback = func(aux AuxMessage) {
+		if r, ok := aux.PushResult(); ok {
+			result = r
+		}
+		if auxCallback != nil {
+			auxCallback(aux)
+		}
+	}
+	if err := c.PushImage(opts, auth); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PullResult is the result of a pull, as reported by the daemon at the end
+// of the progress stream.
+type PullResult struct {
+	// Digest is the digest of the manifest pulled from the registry, e.g.
+	// "sha256:...".
+	Digest string
+
+	// Updated tells whether a new image was downloaded, or whether the
+	// local image was already up to date.
+	Updated bool
+}
+
+// PullImageWithResult pulls an image like PullImage, returning the digest
+// of the image pulled. Progress is still sent to opts.OutputStream, as
+// requested by opts.RawJSONStream.
+//
+// The result is nil when the daemon doesn't report the digest, e.g. for
+// images pulled from a registry v1.
+func (c *Client) PullImageWithResult(opts PullImageOptions, auth AuthConfiguration) (*PullResult, error) {
+	var result PullResult
+	tap := newJSONStreamTap(opts.OutputStream, opts.RawJSONStream, func(msg *jsonmessage.JSONMessage) {
+		switch {
+		case msg.ID != "":
+		case strings.HasPrefix(msg.Status, "Digest: "):
+			result.Digest = strings.TrimPrefix(msg.Status, "Digest: ")
+		case strings.HasPrefix(msg.Status, "Status: Downloaded newer image for "):
+			result.Updated = true
+		}
+	})
+	opts.OutputStream = tap
+	opts.RawJSONStream = true
+	err := c.PullImage(opts, auth)
+	if tapErr := tap.Close(); err == nil {
+		err = tapErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.Digest == "" {
+		return nil, nil
+	}
+	return &result, nil
+}
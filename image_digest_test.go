@@ -0,0 +1,128 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newJSONStreamHandler(path string, stream ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		for _, msg := range stream {
+			w.Write([]byte(msg + "\r\n"))
+		}
+	})
+}
+
+var pushStream = []string{
+	`{"status":"The push refers to repository [localhost:5000/app]"}`,
+	`{"status":"Pushed","progressDetail":{},"id":"aaa"}`,
+	`{"status":"v1: digest: sha256:4f1d2e size: 528"}`,
+	`{"progressDetail":{},"aux":{"Tag":"v1","Digest":"sha256:4f1d2e","Size":528}}`,
+}
+
+func TestPushImageWithResult(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newJSONStreamHandler("/images/localhost:5000/app/push", pushStream...))
+	var buf bytes.Buffer
+	result, err := client.PushImageWithResult(PushImageOptions{Name: "localhost:5000/app", Tag: "v1", OutputStream: &buf}, AuthConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := PushResult{Tag: "v1", Digest: "sha256:4f1d2e", Size: 528}
+	if result == nil || *result != expected {
+		t.Errorf("PushImageWithResult: wrong result. Want %#v. Got %#v.", expected, result)
+	}
+	if out := buf.String(); !strings.Contains(out, "v1: digest: sha256:4f1d2e size: 528") || strings.Contains(out, "{") {
+		t.Errorf("PushImageWithResult: wrong output: %q", out)
+	}
+}
+
+func TestPushImageWithResultRawJSONStream(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newJSONStreamHandler("/images/localhost:5000/app/push", pushStream...))
+	var buf bytes.Buffer
+	result, err := client.PushImageWithResult(PushImageOptions{Name: "localhost:5000/app", OutputStream: &buf, RawJSONStream: true}, AuthConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.Digest != "sha256:4f1d2e" {
+		t.Errorf("PushImageWithResult: wrong result: %#v", result)
+	}
+	expectedOut := strings.Join(pushStream, "\r\n") + "\r\n"
+	if buf.String() != expectedOut {
+		t.Errorf("PushImageWithResult: wrong output.\nWant %q.\nGot  %q.", expectedOut, buf.String())
+	}
+}
+
+func TestPushImageWithResultStreamError(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newJSONStreamHandler("/images/localhost:5000/app/push",
+		`{"status":"The push refers to repository [localhost:5000/app]"}`,
+		`{"errorDetail":{"message":"denied: requested access to the resource is denied"},"error":"denied: requested access to the resource is denied"}`,
+	))
+	for _, raw := range []bool{false, true} {
+		result, err := client.PushImageWithResult(PushImageOptions{Name: "localhost:5000/app", RawJSONStream: raw}, AuthConfiguration{})
+		if err == nil || err.Error() != "denied: requested access to the resource is denied" {
+			t.Errorf("PushImageWithResult(raw=%v): wrong error: %v", raw, err)
+		}
+		if result != nil {
+			t.Errorf("PushImageWithResult(raw=%v): unexpected result: %#v", raw, result)
+		}
+	}
+}
+
+func TestPullImageWithResult(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		stream   []string
+		expected *PullResult
+	}{
+		{
+			[]string{
+				`{"status":"Pulling from library/busybox","id":"latest"}`,
+				`{"status":"Pull complete","progressDetail":{},"id":"aaa"}`,
+				`{"status":"Digest: sha256:061ca9"}`,
+				`{"status":"Status: Downloaded newer image for busybox:latest"}`,
+			},
+			&PullResult{Digest: "sha256:061ca9", Updated: true},
+		},
+		{
+			[]string{
+				`{"status":"Pulling from library/busybox","id":"latest"}`,
+				`{"status":"Digest: sha256:061ca9"}`,
+				`{"status":"Status: Image is up to date for busybox:latest"}`,
+			},
+			&PullResult{Digest: "sha256:061ca9"},
+		},
+		{
+			[]string{`{"status":"Status: Downloaded newer image for busybox:latest"}`},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		client := NewClientFromHandler(newJSONStreamHandler("/images/create", tt.stream...))
+		var buf bytes.Buffer
+		result, err := client.PullImageWithResult(PullImageOptions{Repository: "busybox", OutputStream: &buf}, AuthConfiguration{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("PullImageWithResult: wrong result. Want %#v. Got %#v.", tt.expected, result)
+		}
+		if !strings.Contains(buf.String(), "Status: ") {
+			t.Errorf("PullImageWithResult: wrong output: %q", buf.String())
+		}
+	}
+}
@@ -0,0 +1,395 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/abrechon/go-dockerclient/reference"
+	digest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// dockerArchiveManifest is an entry in the manifest.json file of the
+// archives generated by ExportImage (docker save) and accepted by LoadImage
+// (docker load).
+type dockerArchiveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// ExportImageOCI exports the given image to dir as an OCI image layout
+// (oci-layout, index.json and blobs/), so it can be consumed by tools that
+// don't talk to Docker, like skopeo or containerd. The directory is created
+// if it doesn't exist.
+//
+// Each tag of the image is added to index.json with the
+// "org.opencontainers.image.ref.name" annotation. Layers are stored
+// uncompressed, as exported by the daemon.
+func (c *Client) ExportImageOCI(name, dir string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.ExportImage(ExportImageOptions{Name: name, OutputStream: pw}))
+	}()
+	err := writeOCILayout(pr, dir)
+	pr.CloseWithError(err)
+	return err
+}
+
+func writeOCILayout(r io.Reader, dir string) error {
+	blobsDir := filepath.Join(dir, "blobs", "sha256")
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return err
+	}
+	blobs := make(map[string]ocispec.Descriptor)
+	links := make(map[string]string)
+	var manifests []dockerArchiveManifest
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(header.Name)
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			links[name] = path.Join(path.Dir(name), header.Linkname)
+		case tar.TypeReg, tar.TypeRegA:
+			if name == "manifest.json" {
+				if err = json.NewDecoder(tr).Decode(&manifests); err != nil {
+					return err
+				}
+			} else if path.Base(name) == "layer.tar" || (path.Dir(name) == "." && strings.HasSuffix(name, ".json")) {
+				desc, err := writeOCIBlob(blobsDir, tr)
+				if err != nil {
+					return err
+				}
+				blobs[name] = desc
+			}
+		}
+	}
+	if len(manifests) == 0 {
+		return fmt.Errorf("invalid image archive: missing manifest.json")
+	}
+	index := ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}}
+	for _, m := range manifests {
+		manifest := ocispec.Manifest{Versioned: specs.Versioned{SchemaVersion: 2}}
+		var ok bool
+		if manifest.Config, ok = blobs[m.Config]; !ok {
+			return fmt.Errorf("invalid image archive: missing config %s", m.Config)
+		}
+		manifest.Config.MediaType = ocispec.MediaTypeImageConfig
+		for _, layer := range m.Layers {
+			if target, isLink := links[layer]; isLink {
+				layer = target
+			}
+			desc, ok := blobs[layer]
+			if !ok {
+				return fmt.Errorf("invalid image archive: missing layer %s", layer)
+			}
+			desc.MediaType = ocispec.MediaTypeImageLayer
+			manifest.Layers = append(manifest.Layers, desc)
+		}
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		desc, err := writeOCIBlob(blobsDir, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		desc.MediaType = ocispec.MediaTypeImageManifest
+		if len(m.RepoTags) == 0 {
+			index.Manifests = append(index.Manifests, desc)
+		}
+		for _, tag := range m.RepoTags {
+			tagged := desc
+			tagged.Annotations = map[string]string{ocispec.AnnotationRefName: tag}
+			index.Manifests = append(index.Manifests, tagged)
+		}
+	}
+	if err := writeJSONFile(filepath.Join(dir, "index.json"), index); err != nil {
+		return err
+	}
+	return writeJSONFile(filepath.Join(dir, ocispec.ImageLayoutFile), ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+}
+
+// writeOCIBlob copies r to a file in blobsDir named after its digest.
+func writeOCIBlob(blobsDir string, r io.Reader) (ocispec.Descriptor, error) {
+	f, err := ioutil.TempFile(blobsDir, ".tmp-")
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer os.Remove(f.Name())
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(io.MultiWriter(f, digester.Hash()), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{Digest: digester.Digest(), Size: size}
+	return desc, os.Rename(f.Name(), filepath.Join(blobsDir, desc.Digest.Hex()))
+}
+
+func writeJSONFile(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0644)
+}
+
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerConfig       = "application/vnd.docker.container.image.v1+json"
+	mediaTypeDockerLayer        = "application/vnd.docker.image.rootfs.diff.tar"
+	mediaTypeDockerLayerGzip    = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+// LoadImageFromOCILayout loads the images in an OCI image layout into the
+// daemon. path is either a directory containing the layout or a tar archive
+// of it. Archives that are already in the format generated by ExportImage
+// (docker save) are loaded as is.
+//
+// Both OCI and Docker media types are accepted for manifests, configs and
+// layers, compressed or not. Images are tagged with the
+// "org.opencontainers.image.ref.name" annotation of their manifest when it's
+// a complete reference, like "busybox:latest". For image indexes (or
+// manifest lists) nested in index.json, only the first manifest is loaded.
+func (c *Client) LoadImageFromOCILayout(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	dir := path
+	if !info.IsDir() {
+		isDockerArchive, err := hasDockerArchiveManifest(path)
+		if err != nil {
+			return err
+		}
+		if isDockerArchive {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return c.LoadImage(LoadImageOptions{InputStream: f})
+		}
+		if dir, err = ioutil.TempDir("", "oci-layout"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		if err = extractTarFile(path, dir); err != nil {
+			return err
+		}
+	}
+	manifests, err := dockerArchiveManifests(dir)
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeDockerArchive(pw, dir, manifests))
+	}()
+	err = c.LoadImage(LoadImageOptions{InputStream: pr})
+	pr.CloseWithError(err)
+	return err
+}
+
+func hasDockerArchiveManifest(name string) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if path.Clean(header.Name) == "manifest.json" {
+			return true, nil
+		}
+	}
+}
+
+func extractTarFile(name, dir string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		clean := path.Clean("/" + header.Name)
+		target := filepath.Join(dir, filepath.FromSlash(clean))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg, tar.TypeRegA:
+			err = extractFile(target, tr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(name string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// dockerArchiveManifests converts the manifests referenced by the index.json
+// of the OCI layout in dir to the manifest.json format used by docker load,
+// referencing the blobs by their path in the layout.
+func dockerArchiveManifests(dir string) ([]dockerArchiveManifest, error) {
+	var index ocispec.Index
+	if err := readOCIJSON(filepath.Join(dir, "index.json"), &index); err != nil {
+		return nil, err
+	}
+	var manifests []dockerArchiveManifest
+	for _, desc := range index.Manifests {
+		if desc.MediaType == ocispec.MediaTypeImageIndex || desc.MediaType == mediaTypeDockerManifestList {
+			var nested ocispec.Index
+			if err := readOCIJSON(ociBlobPath(dir, desc), &nested); err != nil {
+				return nil, err
+			}
+			if len(nested.Manifests) == 0 {
+				continue
+			}
+			annotations := desc.Annotations
+			desc = nested.Manifests[0]
+			desc.Annotations = annotations
+		}
+		if desc.MediaType != ocispec.MediaTypeImageManifest && desc.MediaType != mediaTypeDockerManifest {
+			return nil, fmt.Errorf("unsupported manifest media type %q", desc.MediaType)
+		}
+		var manifest ocispec.Manifest
+		if err := readOCIJSON(ociBlobPath(dir, desc), &manifest); err != nil {
+			return nil, err
+		}
+		if t := manifest.Config.MediaType; t != ocispec.MediaTypeImageConfig && t != mediaTypeDockerConfig {
+			return nil, fmt.Errorf("unsupported config media type %q", t)
+		}
+		m := dockerArchiveManifest{Config: ociBlobName(manifest.Config)}
+		for _, layer := range manifest.Layers {
+			switch layer.MediaType {
+			case ocispec.MediaTypeImageLayer, ocispec.MediaTypeImageLayerGzip, mediaTypeDockerLayer, mediaTypeDockerLayerGzip:
+			default:
+				return nil, fmt.Errorf("unsupported layer media type %q", layer.MediaType)
+			}
+			m.Layers = append(m.Layers, ociBlobName(layer))
+		}
+		if ref, err := reference.ParseReference(desc.Annotations[ocispec.AnnotationRefName]); err == nil && ref.Tag != "" {
+			m.RepoTags = []string{ref.FamiliarName() + ":" + ref.Tag}
+		}
+		manifests = append(manifests, m)
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no images found in %s", dir)
+	}
+	return manifests, nil
+}
+
+func ociBlobName(desc ocispec.Descriptor) string {
+	return path.Join("blobs", desc.Digest.Algorithm().String(), desc.Digest.Hex())
+}
+
+func ociBlobPath(dir string, desc ocispec.Descriptor) string {
+	return filepath.Join(dir, filepath.FromSlash(ociBlobName(desc)))
+}
+
+func readOCIJSON(name string, v interface{}) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeDockerArchive writes a tar archive in the format accepted by docker
+// load, with the given manifests and the blobs they reference.
+func writeDockerArchive(w io.Writer, dir string, manifests []dockerArchiveManifest) error {
+	tw := tar.NewWriter(w)
+	written := make(map[string]bool)
+	for _, m := range manifests {
+		for _, name := range append([]string{m.Config}, m.Layers...) {
+			if written[name] {
+				continue
+			}
+			written[name] = true
+			if err := addFileToTar(tw, filepath.Join(dir, filepath.FromSlash(name)), name); err != nil {
+				return err
+			}
+		}
+	}
+	data, err := json.Marshal(manifests)
+	if err != nil {
+		return err
+	}
+	if err = tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0644, Size: int64(len(data))}); err != nil {
+		return err
+	}
+	if _, err = tw.Write(data); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func addFileToTar(tw *tar.Writer, name, tarName string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err = tw.WriteHeader(&tar.Header{Name: tarName, Mode: 0644, Size: info.Size()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
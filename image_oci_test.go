@@ -0,0 +1,295 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type tarEntry struct {
+	name     string
+	content  string
+	linkname string
+}
+
+func buildTar(t *testing.T, entries []tarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if e.linkname != "" {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = e.linkname
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	testImageConfig = `{"architecture":"amd64","os":"linux","rootfs":{"type":"layers"}}`
+	testImageLayer  = "layer contents"
+)
+
+func dockerArchive(t *testing.T) []byte {
+	configName := sha256Hex(testImageConfig) + ".json"
+	return buildTar(t, []tarEntry{
+		{name: "aaa/layer.tar", content: testImageLayer},
+		{name: "aaa/json", content: "{}"},
+		{name: "bbb/layer.tar", linkname: "../aaa/layer.tar"},
+		{name: configName, content: testImageConfig},
+		{name: "manifest.json", content: `[{"Config":"` + configName + `","RepoTags":["busybox:latest"],"Layers":["aaa/layer.tar","bbb/layer.tar"]}]`},
+	})
+}
+
+func readJSONFile(t *testing.T, name string, v interface{}) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImageOCI(t *testing.T) {
+	t.Parallel()
+	archive := dockerArchive(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/busybox/get" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = client.ExportImageOCI("busybox", dir); err != nil {
+		t.Fatal(err)
+	}
+	var layout ocispec.ImageLayout
+	readJSONFile(t, filepath.Join(dir, "oci-layout"), &layout)
+	if layout.Version != "1.0.0" {
+		t.Errorf("ExportImageOCI: wrong layout version: %q", layout.Version)
+	}
+	var index ocispec.Index
+	readJSONFile(t, filepath.Join(dir, "index.json"), &index)
+	if len(index.Manifests) != 1 {
+		t.Fatalf("ExportImageOCI: wrong number of manifests. Want 1. Got %d.", len(index.Manifests))
+	}
+	desc := index.Manifests[0]
+	if desc.MediaType != ocispec.MediaTypeImageManifest || desc.Annotations[ocispec.AnnotationRefName] != "busybox:latest" {
+		t.Errorf("ExportImageOCI: wrong manifest descriptor: %#v", desc)
+	}
+	var manifest ocispec.Manifest
+	readJSONFile(t, filepath.Join(dir, "blobs", "sha256", desc.Digest.Hex()), &manifest)
+	if manifest.Config.Digest.Hex() != sha256Hex(testImageConfig) || manifest.Config.MediaType != ocispec.MediaTypeImageConfig {
+		t.Errorf("ExportImageOCI: wrong config descriptor: %#v", manifest.Config)
+	}
+	if len(manifest.Layers) != 2 {
+		t.Fatalf("ExportImageOCI: wrong number of layers. Want 2. Got %d.", len(manifest.Layers))
+	}
+	for _, layer := range manifest.Layers {
+		if layer.Digest.Hex() != sha256Hex(testImageLayer) || layer.Size != int64(len(testImageLayer)) || layer.MediaType != ocispec.MediaTypeImageLayer {
+			t.Errorf("ExportImageOCI: wrong layer descriptor: %#v", layer)
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "blobs", "sha256", sha256Hex(testImageLayer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testImageLayer {
+		t.Errorf("ExportImageOCI: wrong layer contents: %q", data)
+	}
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "blobs", "sha256"))
+	if len(files) != 3 {
+		t.Errorf("ExportImageOCI: wrong number of blobs. Want 3. Got %d.", len(files))
+	}
+}
+
+func TestExportImageOCINoSuchImage(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "no such image", status: http.StatusNotFound})
+	dir, err := ioutil.TempDir("", "oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = client.ExportImageOCI("busybox", dir); err == nil {
+		t.Error("ExportImageOCI: unexpected <nil> error")
+	}
+}
+
+func newLoadServer(t *testing.T) (*httptest.Server, *[]byte) {
+	var loaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/load" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		loaded, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"stream":"Loaded image: busybox:latest\n"}`))
+	}))
+	return server, &loaded
+}
+
+func readTar(t *testing.T, data []byte) map[string]string {
+	files := make(map[string]string)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := ioutil.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+}
+
+func TestLoadImageFromOCILayout(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = writeOCILayout(bytes.NewReader(dockerArchive(t)), dir); err != nil {
+		t.Fatal(err)
+	}
+	server, loaded := newLoadServer(t)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.LoadImageFromOCILayout(dir); err != nil {
+		t.Fatal(err)
+	}
+	files := readTar(t, *loaded)
+	var manifests []dockerArchiveManifest
+	if err = json.Unmarshal([]byte(files["manifest.json"]), &manifests); err != nil {
+		t.Fatal(err)
+	}
+	configName := "blobs/sha256/" + sha256Hex(testImageConfig)
+	layerName := "blobs/sha256/" + sha256Hex(testImageLayer)
+	expected := []dockerArchiveManifest{{
+		Config:   configName,
+		RepoTags: []string{"busybox:latest"},
+		Layers:   []string{layerName, layerName},
+	}}
+	if !reflect.DeepEqual(manifests, expected) {
+		t.Errorf("LoadImageFromOCILayout: wrong manifest.\nWant %#v.\nGot %#v.", expected, manifests)
+	}
+	if files[configName] != testImageConfig || files[layerName] != testImageLayer {
+		t.Errorf("LoadImageFromOCILayout: wrong blobs in archive: %#v", files)
+	}
+	if len(files) != 3 {
+		t.Errorf("LoadImageFromOCILayout: wrong number of files. Want 3. Got %d.", len(files))
+	}
+}
+
+func TestLoadImageFromOCILayoutTar(t *testing.T) {
+	t.Parallel()
+	configDigest := sha256Hex(testImageConfig)
+	layerDigest := sha256Hex(testImageLayer)
+	manifest := `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json",` +
+		`"config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"sha256:` + configDigest + `","size":1},` +
+		`"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"sha256:` + layerDigest + `","size":1}]}`
+	index := `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.docker.distribution.manifest.v2+json",` +
+		`"digest":"sha256:` + sha256Hex(manifest) + `","size":1,"annotations":{"org.opencontainers.image.ref.name":"example.com/app:v1"}}]}`
+	archive := buildTar(t, []tarEntry{
+		{name: "oci-layout", content: `{"imageLayoutVersion":"1.0.0"}`},
+		{name: "index.json", content: index},
+		{name: "blobs/sha256/" + sha256Hex(manifest), content: manifest},
+		{name: "blobs/sha256/" + configDigest, content: testImageConfig},
+		{name: "blobs/sha256/" + layerDigest, content: testImageLayer},
+	})
+	f, err := ioutil.TempFile("", "oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(archive)
+	f.Close()
+	server, loaded := newLoadServer(t)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.LoadImageFromOCILayout(f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	var manifests []dockerArchiveManifest
+	if err = json.Unmarshal([]byte(readTar(t, *loaded)["manifest.json"]), &manifests); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 1 || !reflect.DeepEqual(manifests[0].RepoTags, []string{"example.com/app:v1"}) {
+		t.Errorf("LoadImageFromOCILayout: wrong manifests: %#v", manifests)
+	}
+}
+
+func TestLoadImageFromOCILayoutDockerArchive(t *testing.T) {
+	t.Parallel()
+	archive := dockerArchive(t)
+	f, err := ioutil.TempFile("", "docker-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(archive)
+	f.Close()
+	server, loaded := newLoadServer(t)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.LoadImageFromOCILayout(f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(*loaded, archive) {
+		t.Error("LoadImageFromOCILayout: docker archives should be loaded as is")
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// PullWindow is a daily time window in which images may be pulled, given by
+// its start and end as offsets from midnight. A window ending before it
+// starts spans midnight, e.g. {22 * time.Hour, 6 * time.Hour}.
+type PullWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// PrePullScheduler pulls images in advance, in off-peak windows, for the
+// deployments where pulling images saturates the network.
+//
+// The daemon downloads the layers of the images itself, so the bandwidth of
+// registry pulls can't be limited by the client: the scheduler confines them
+// to the windows, and the daemon limits the parallel downloads of each pull
+// (see its max-concurrent-downloads option). The images transferred through
+// the client, with LoadImage or ImportImage, can be throttled with
+// NewThrottledReader.
+type PrePullScheduler struct {
+	// Windows are the windows in which pulls may run. Pulls still running
+	// at the end of a window are canceled, and resumed in the next window,
+	// reusing the layers already downloaded. No windows means pulling right
+	// away.
+	Windows []PullWindow
+
+	// Location is the time zone of the windows. Defaults to the local time
+	// zone.
+	Location *time.Location
+
+	// Options are the options of the pulls. Their Context is ignored in
+	// favor of the one given to Run.
+	Options PullImagesOptions
+
+	client *Client
+	now    func() time.Time
+}
+
+// NewPrePullScheduler returns a PrePullScheduler that pulls using the client.
+func (c *Client) NewPrePullScheduler() *PrePullScheduler {
+	return &PrePullScheduler{client: c, now: time.Now}
+}
+
+// Run pulls the images with PullImages in the windows of the scheduler,
+// waiting for the next window when none is open. It returns once every
+// image is pulled or failed, with a *PullImagesError for the failed ones, or
+// when the context is done.
+func (s *PrePullScheduler) Run(ctx context.Context, images []string) error {
+	failed := PullImagesError{Errors: make(map[string]error)}
+	pending := images
+	for len(pending) > 0 {
+		end, err := s.waitForWindow(ctx)
+		if err != nil {
+			return err
+		}
+		var windowCtx context.Context
+		var cancel context.CancelFunc
+		if end.IsZero() {
+			windowCtx, cancel = context.WithCancel(ctx)
+		} else {
+			windowCtx, cancel = context.WithTimeout(ctx, end.Sub(s.now()))
+		}
+		opts := s.Options
+		opts.Context = windowCtx
+		err = s.client.PullImages(pending, opts)
+		windowClosed := windowCtx.Err() != nil
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		pullErr, ok := err.(*PullImagesError)
+		if !ok {
+			break
+		}
+		var next []string
+		for _, image := range pullErr.images {
+			e, ok := pullErr.Errors[image]
+			switch {
+			case !ok:
+			case windowClosed:
+				next = append(next, image)
+			default:
+				failed.images = append(failed.images, image)
+				failed.Errors[image] = e
+			}
+		}
+		pending = next
+	}
+	if len(failed.Errors) > 0 {
+		return &failed
+	}
+	return nil
+}
+
+// waitForWindow waits until a window is open, returning its end, or the zero
+// time when the scheduler has no windows.
+func (s *PrePullScheduler) waitForWindow(ctx context.Context) (time.Time, error) {
+	for {
+		if ctx.Err() != nil {
+			return time.Time{}, ctx.Err()
+		}
+		now := s.now()
+		open, start, end := s.window(now)
+		if open {
+			return end, nil
+		}
+		timer := time.NewTimer(start.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Time{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// window returns whether a window is open at the given time, and the start
+// and end of the open window, or of the next one. When several windows are
+// open, the one ending last is returned.
+func (s *PrePullScheduler) window(now time.Time) (open bool, start, end time.Time) {
+	if len(s.Windows) == 0 {
+		return true, now, time.Time{}
+	}
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+	for _, days := range []int{-1, 0, 1} {
+		day := now.AddDate(0, 0, days)
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		for _, w := range s.Windows {
+			wStart := midnight.Add(w.Start)
+			wEnd := midnight.Add(w.End)
+			if w.End <= w.Start {
+				wEnd = wEnd.Add(24 * time.Hour)
+			}
+			switch {
+			case !now.Before(wStart) && now.Before(wEnd):
+				if !open || wEnd.After(end) {
+					start, end = wStart, wEnd
+				}
+				open = true
+			case !open && wStart.After(now) && (start.IsZero() || wStart.Before(start)):
+				start, end = wStart, wEnd
+			}
+		}
+	}
+	return open, start, end
+}
+
+// NewThrottledReader returns a reader that reads from r at no more than the
+// given number of bytes per second, to limit the bandwidth used by the images
+// sent to the daemon, e.g. with LoadImage.
+func NewThrottledReader(r io.Reader, bytesPerSecond int64) io.Reader {
+	return &throttledReader{r: r, rate: bytesPerSecond}
+}
+
+type throttledReader struct {
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if t.rate <= 0 {
+		return t.r.Read(p)
+	}
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	if int64(len(p)) > t.rate {
+		p = p[:t.rate]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+	expected := time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
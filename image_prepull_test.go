@@ -0,0 +1,125 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPrePullSchedulerWindow(t *testing.T) {
+	t.Parallel()
+	loc := time.FixedZone("edge", 2*60*60)
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2019, time.May, day, hour, min, 0, 0, loc)
+	}
+	scheduler := PrePullScheduler{
+		Windows: []PullWindow{
+			{Start: 22 * time.Hour, End: 6 * time.Hour},
+			{Start: 12 * time.Hour, End: 13 * time.Hour},
+			{Start: 5 * time.Hour, End: 7 * time.Hour},
+		},
+		Location: loc,
+	}
+	tests := []struct {
+		now   time.Time
+		open  bool
+		start time.Time
+		end   time.Time
+	}{
+		{at(10, 23, 0), true, at(10, 22, 0), at(11, 6, 0)},
+		{at(10, 1, 30), true, at(9, 22, 0), at(10, 6, 0)},
+		{at(10, 5, 30), true, at(10, 5, 0), at(10, 7, 0)},
+		{at(10, 7, 0), false, at(10, 12, 0), at(10, 13, 0)},
+		{at(10, 13, 0), false, at(10, 22, 0), at(11, 6, 0)},
+		{at(10, 12, 59), true, at(10, 12, 0), at(10, 13, 0)},
+		{at(10, 8, 0).UTC(), false, at(10, 12, 0), at(10, 13, 0)},
+	}
+	for _, tt := range tests {
+		open, start, end := scheduler.window(tt.now)
+		if open != tt.open || !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("window(%s): want (%v, %s, %s). Got (%v, %s, %s).", tt.now, tt.open, tt.start, tt.end, open, start, end)
+		}
+	}
+}
+
+func TestPrePullSchedulerRun(t *testing.T) {
+	t.Parallel()
+	streams := map[string][]string{
+		"busybox:latest": {`{"status":"Status: Downloaded newer image for busybox:latest"}`},
+	}
+	var pulls []string
+	client := NewClientFromHandler(newPullImagesHandler(streams, &pulls))
+	scheduler := client.NewPrePullScheduler()
+	err := scheduler.Run(context.Background(), []string{"busybox", "redis"})
+	pullErr, ok := err.(*PullImagesError)
+	if !ok {
+		t.Fatalf("Run: wrong error. Want *PullImagesError. Got %#v.", err)
+	}
+	if _, ok := pullErr.Errors["redis"]; !ok || len(pullErr.Errors) != 1 {
+		t.Errorf("Run: wrong errors: %#v", pullErr.Errors)
+	}
+	expectedPulls := []string{"busybox:latest", "redis:latest"}
+	if !reflect.DeepEqual(pulls, expectedPulls) {
+		t.Errorf("Run: wrong pulls. Want %#v. Got %#v.", expectedPulls, pulls)
+	}
+}
+
+func TestPrePullSchedulerRunWindowEnd(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var pulls int
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		pulls++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"Pulling from library/busybox","id":"latest"}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	// the window ends 100ms after the scheduler starts.
+	now := time.Date(2019, time.May, 10, 23, 59, 59, int(900*time.Millisecond), time.UTC)
+	started := time.Now()
+	scheduler := client.NewPrePullScheduler()
+	scheduler.Location = time.UTC
+	scheduler.Windows = []PullWindow{{Start: 23 * time.Hour, End: 0}}
+	scheduler.now = func() time.Time {
+		return now.Add(time.Since(started))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := scheduler.Run(ctx, []string{"busybox"})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Run: wrong error. Want %v. Got %v.", context.DeadlineExceeded, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if pulls != 1 {
+		t.Errorf("Run: wrong number of pulls. Want 1. Got %d.", pulls)
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	t.Parallel()
+	data := bytes.Repeat([]byte("x"), 300)
+	start := time.Now()
+	read, err := ioutil.ReadAll(NewThrottledReader(bytes.NewReader(data), 1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Errorf("ThrottledReader: wrong data. Want %d bytes. Got %d.", len(data), len(read))
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("ThrottledReader: read too fast: %s", elapsed)
+	}
+}
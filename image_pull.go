@@ -0,0 +1,240 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abrechon/go-dockerclient/internal/jsonmessage"
+	"github.com/abrechon/go-dockerclient/reference"
+)
+
+const defaultPullParallelism = 3
+
+// PullImagesOptions specify parameters to the PullImages function.
+type PullImagesOptions struct {
+	// Parallelism is the maximum number of images pulled at the same time.
+	// Defaults to three, like the concurrent downloads of the daemon.
+	Parallelism int
+
+	// Auths are the credentials of the registries, looked up by the domain
+	// of each image.
+	Auths AuthConfigurations
+
+	// Progress receives the progress of all the pulls. Calls are
+	// serialized, so the function doesn't need to be safe for concurrent
+	// use, but it should return quickly as it blocks the pulls.
+	Progress func(PullProgress)
+
+	InactivityTimeout time.Duration
+	Context           context.Context
+}
+
+// PullProgress is an update of the progress of PullImages.
+type PullProgress struct {
+	// Image is the reference, as given to PullImages, of the pull that
+	// reported the update.
+	Image string
+
+	// Layer is the ID of the layer the update is about, or empty for
+	// updates about the whole image, e.g. "Digest: sha256:..." and
+	// "Status: Downloaded newer image for ...".
+	Layer string
+
+	// Status is the status reported by the daemon, e.g. "Downloading",
+	// "Pull complete" or "Already exists", and Current and Total the bytes
+	// processed, when known.
+	Status         string
+	Current, Total int64
+
+	// SharedWith is set when the layer is shared with another image being
+	// pulled, to the reference of that image. The progress of a shared
+	// layer is reported only once, by the pull that reported it first: the
+	// other pulls only report it once, with SharedWith set.
+	SharedWith string
+}
+
+// PullImagesError is the error returned by PullImages when some of the
+// images couldn't be pulled, with the error of each of them.
+type PullImagesError struct {
+	Errors map[string]error
+	images []string
+}
+
+func (err *PullImagesError) Error() string {
+	var msgs []string
+	for _, image := range err.images {
+		if e, ok := err.Errors[image]; ok {
+			msgs = append(msgs, image+": "+e.Error())
+		}
+	}
+	return "failed to pull " + strings.Join(msgs, "; ")
+}
+
+// PullImages pulls several images, in the form used by docker pull (e.g.
+// "busybox", "quay.io/prometheus/prometheus:v2.9.2" or
+// "alpine@sha256:..."), with up to opts.Parallelism pulls at the same time.
+// Images without a tag nor a digest are pulled with the latest tag.
+//
+// The progress of all the pulls is merged and sent to opts.Progress,
+// reporting the layers shared by several images only once.
+//
+// A failed pull doesn't stop the others: PullImages then returns a
+// *PullImagesError with the errors of the images that couldn't be pulled.
+// Images not pulled yet when the context is done fail with the error of the
+// context.
+func (c *Client) PullImages(images []string, opts PullImagesOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultPullParallelism
+	}
+	progress := pullProgressMerger{fn: opts.Progress, owners: make(map[string]string)}
+	pullErr := PullImagesError{Errors: make(map[string]error)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for image := range queue {
+				err := ctx.Err()
+				if err == nil {
+					err = c.pullWithProgress(image, opts, &progress)
+				}
+				if err != nil {
+					mu.Lock()
+					pullErr.Errors[image] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	seen := make(map[string]bool, len(images))
+	for _, image := range images {
+		if seen[image] {
+			continue
+		}
+		seen[image] = true
+		pullErr.images = append(pullErr.images, image)
+		queue <- image
+	}
+	close(queue)
+	wg.Wait()
+	if len(pullErr.Errors) > 0 {
+		return &pullErr
+	}
+	return nil
+}
+
+// pullWithProgress pulls the image, decoding the progress stream to report it
+// to the merger.
+func (c *Client) pullWithProgress(image string, opts PullImagesOptions, progress *pullProgressMerger) error {
+	ref, err := reference.ParseReference(image)
+	if err != nil {
+		return &InvalidImageReference{Reference: image, Err: err}
+	}
+	tag := ref.Tag
+	if ref.Digest != "" {
+		tag = ref.Digest
+	} else if tag == "" {
+		tag = "latest"
+	}
+	auth, _ := opts.Auths.For(ref.Domain)
+	r, w := io.Pipe()
+	streamErr := make(chan error, 1)
+	go func() {
+		var pullErr error
+		decoder := json.NewDecoder(r)
+		for {
+			var msg jsonmessage.JSONMessage
+			if err := decoder.Decode(&msg); err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				r.CloseWithError(err)
+				if pullErr == nil {
+					pullErr = err
+				}
+				streamErr <- pullErr
+				return
+			}
+			switch {
+			case msg.Error != nil:
+				pullErr = msg.Error
+			case msg.ErrorMessage != "":
+				pullErr = errors.New(msg.ErrorMessage)
+			default:
+				progress.report(image, &msg)
+			}
+		}
+	}()
+	err = c.PullImage(PullImageOptions{
+		Repository:        ref.FamiliarName(),
+		Tag:               tag,
+		OutputStream:      w,
+		RawJSONStream:     true,
+		InactivityTimeout: opts.InactivityTimeout,
+		Context:           opts.Context,
+	}, auth)
+	w.CloseWithError(err)
+	if decodeErr := <-streamErr; err == nil {
+		err = decodeErr
+	}
+	return err
+}
+
+// pullProgressMerger merges the progress of concurrent pulls, reporting each
+// layer on behalf of the first image that reported it.
+type pullProgressMerger struct {
+	fn func(PullProgress)
+
+	mu     sync.Mutex
+	owners map[string]string
+	shared map[string]bool
+}
+
+func (m *pullProgressMerger) report(image string, msg *jsonmessage.JSONMessage) {
+	if m.fn == nil {
+		return
+	}
+	progress := PullProgress{Image: image, Status: msg.Status}
+	if msg.ID != "" && !strings.HasPrefix(msg.Status, "Pulling from ") {
+		progress.Layer = msg.ID
+	}
+	if msg.Progress != nil {
+		progress.Current = msg.Progress.Current
+		progress.Total = msg.Progress.Total
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if progress.Layer != "" {
+		owner, ok := m.owners[progress.Layer]
+		if !ok {
+			m.owners[progress.Layer] = image
+		} else if owner != image {
+			key := image + "\x00" + progress.Layer
+			if m.shared[key] {
+				return
+			}
+			if m.shared == nil {
+				m.shared = make(map[string]bool)
+			}
+			m.shared[key] = true
+			progress.SharedWith = owner
+		}
+	}
+	m.fn(progress)
+}
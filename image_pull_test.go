@@ -0,0 +1,160 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newPullImagesHandler(streams map[string][]string, pulls *[]string) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		image := query.Get("fromImage") + ":" + query.Get("tag")
+		if auth := r.Header.Get("X-Registry-Auth"); auth != "" {
+			var conf AuthConfiguration
+			data, _ := base64.URLEncoding.DecodeString(auth)
+			json.Unmarshal(data, &conf)
+			image += " as " + conf.Username
+		}
+		mu.Lock()
+		*pulls = append(*pulls, image)
+		mu.Unlock()
+		stream, ok := streams[image]
+		if !ok {
+			http.Error(w, "manifest unknown", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		for _, msg := range stream {
+			w.Write([]byte(msg + "\n"))
+		}
+	})
+}
+
+func TestPullImages(t *testing.T) {
+	t.Parallel()
+	streams := map[string][]string{
+		"busybox:latest": {
+			`{"status":"Pulling from library/busybox","id":"latest"}`,
+			`{"status":"Downloading","progressDetail":{"current":512,"total":1024},"id":"aaa"}`,
+			`{"status":"Pull complete","progressDetail":{},"id":"aaa"}`,
+			`{"status":"Status: Downloaded newer image for busybox:latest"}`,
+		},
+		"quay.io/app/web:v1 as robot": {
+			`{"status":"Pulling from app/web","id":"v1"}`,
+			`{"status":"Downloading","progressDetail":{"current":512,"total":1024},"id":"aaa"}`,
+			`{"status":"Pull complete","progressDetail":{},"id":"aaa"}`,
+			`{"status":"Pull complete","progressDetail":{},"id":"bbb"}`,
+		},
+		"alpine:3.9": {
+			`{"status":"Pulling from library/alpine","id":"3.9"}`,
+			`{"errorDetail":{"message":"unauthorized: authentication required"},"error":"unauthorized: authentication required"}`,
+		},
+	}
+	var pulls []string
+	client := NewClientFromHandler(newPullImagesHandler(streams, &pulls))
+	var progress []PullProgress
+	err := client.PullImages([]string{"busybox", "quay.io/app/web:v1", "alpine:3.9", "busybox", "redis:5"}, PullImagesOptions{
+		Parallelism: 1,
+		Auths: AuthConfigurations{Configs: map[string]AuthConfiguration{
+			"quay.io": {Username: "robot", Password: "secret"},
+		}},
+		Progress: func(p PullProgress) {
+			progress = append(progress, p)
+		},
+	})
+	pullErr, ok := err.(*PullImagesError)
+	if !ok {
+		t.Fatalf("PullImages: wrong error. Want *PullImagesError. Got %#v.", err)
+	}
+	expectedMsg := "failed to pull alpine:3.9: unauthorized: authentication required; redis:5: API error (404): manifest unknown\n"
+	if pullErr.Error() != expectedMsg {
+		t.Errorf("PullImages: wrong error message.\nWant %q.\nGot  %q.", expectedMsg, pullErr.Error())
+	}
+	if len(pullErr.Errors) != 2 {
+		t.Errorf("PullImages: wrong errors: %#v", pullErr.Errors)
+	}
+	expectedPulls := []string{"busybox:latest", "quay.io/app/web:v1 as robot", "alpine:3.9", "redis:5"}
+	if !reflect.DeepEqual(pulls, expectedPulls) {
+		t.Errorf("PullImages: wrong pulls. Want %#v. Got %#v.", expectedPulls, pulls)
+	}
+	expectedProgress := []PullProgress{
+		{Image: "busybox", Status: "Pulling from library/busybox"},
+		{Image: "busybox", Layer: "aaa", Status: "Downloading", Current: 512, Total: 1024},
+		{Image: "busybox", Layer: "aaa", Status: "Pull complete"},
+		{Image: "busybox", Status: "Status: Downloaded newer image for busybox:latest"},
+		{Image: "quay.io/app/web:v1", Status: "Pulling from app/web"},
+		{Image: "quay.io/app/web:v1", Layer: "aaa", Status: "Downloading", Current: 512, Total: 1024, SharedWith: "busybox"},
+		{Image: "quay.io/app/web:v1", Layer: "bbb", Status: "Pull complete"},
+		{Image: "alpine:3.9", Status: "Pulling from library/alpine"},
+	}
+	if !reflect.DeepEqual(progress, expectedProgress) {
+		t.Errorf("PullImages: wrong progress.\nWant %#v.\nGot  %#v.", expectedProgress, progress)
+	}
+}
+
+func TestPullImagesParallelism(t *testing.T) {
+	t.Parallel()
+	var running, maxRunning int32
+	var mu sync.Mutex
+	var pulled []string
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		pulled = append(pulled, r.URL.Query().Get("fromImage"))
+		mu.Unlock()
+		w.Write([]byte(`{"status":"done"}`))
+	}))
+	images := []string{"a", "b", "c", "d", "e", "f"}
+	if err := client.PullImages(images, PullImagesOptions{Parallelism: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if max := atomic.LoadInt32(&maxRunning); max > 2 {
+		t.Errorf("PullImages: too many concurrent pulls. Want at most 2. Got %d.", max)
+	}
+	sort.Strings(pulled)
+	if !reflect.DeepEqual(pulled, images) {
+		t.Errorf("PullImages: wrong pulls. Want %#v. Got %#v.", images, pulled)
+	}
+}
+
+func TestPullImagesCanceled(t *testing.T) {
+	t.Parallel()
+	var pulls []string
+	client := NewClientFromHandler(newPullImagesHandler(nil, &pulls))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := client.PullImages([]string{"busybox", "alpine"}, PullImagesOptions{Context: ctx})
+	pullErr, ok := err.(*PullImagesError)
+	if !ok {
+		t.Fatalf("PullImages: wrong error. Want *PullImagesError. Got %#v.", err)
+	}
+	for _, image := range []string{"busybox", "alpine"} {
+		if pullErr.Errors[image] != context.Canceled {
+			t.Errorf("PullImages: wrong error for %s. Want %v. Got %v.", image, context.Canceled, pullErr.Errors[image])
+		}
+	}
+	if len(pulls) > 0 {
+		t.Errorf("PullImages: unexpected pulls: %#v", pulls)
+	}
+}
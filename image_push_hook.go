@@ -0,0 +1,90 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPushDigestUnknown is the error of the *PostPushHookError returned when
+// the daemon doesn't report the digest of the image pushed, e.g. for pushes
+// to a registry v1, so the post-push hook can't be called.
+var ErrPushDigestUnknown = errors.New("the daemon didn't report the digest of the image pushed")
+
+// PushedImage is an image pushed to a registry, as handed to a PostPushHook.
+type PushedImage struct {
+	// Name is the name of the image, including its registry, e.g.
+	// "localhost:5000/app".
+	Name string
+
+	// Tag is the tag pushed.
+	Tag string
+
+	// Digest is the digest of the manifest pushed, e.g. "sha256:...".
+	Digest string
+
+	// Size is the size of the manifest, in bytes.
+	Size int
+}
+
+// Reference returns the reference of the tag pushed, e.g.
+// "localhost:5000/app:v1".
+func (img PushedImage) Reference() string {
+	return img.Name + ":" + img.Tag
+}
+
+// DigestReference returns the reference of the manifest pushed, e.g.
+// "localhost:5000/app@sha256:...", which is what signatures are usually
+// attached to.
+func (img PushedImage) DigestReference() string {
+	return img.Name + "@" + img.Digest
+}
+
+// PostPushHook is a function called once an image is pushed, e.g. to sign
+// it, so it doesn't have to resolve the digest of the image again.
+type PostPushHook func(ctx context.Context, img PushedImage) error
+
+// PostPushHookError is the error returned by PushImage when the post-push
+// hook fails.
+type PostPushHookError struct {
+	Image PushedImage
+
+	// Err is the error returned by the hook, or ErrPushDigestUnknown.
+	Err error
+}
+
+func (err *PostPushHookError) Error() string {
+	return fmt.Sprintf("post-push hook of %s failed: %v", err.Image.Reference(), err.Err)
+}
+
+// runPostPushHook calls the post-push hook with each tag pushed, stopping at
+// the first error.
+func runPostPushHook(name string, pushed []PushResult, opts PushImageOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(pushed) == 0 {
+		pushed = []PushResult{{Tag: opts.Tag}}
+	}
+	for _, result := range pushed {
+		img := PushedImage{Name: name, Tag: result.Tag, Digest: result.Digest, Size: result.Size}
+		if img.Tag == "" {
+			img.Tag = "latest"
+		}
+		var err error
+		if img.Digest == "" {
+			err = ErrPushDigestUnknown
+		} else {
+			err = opts.PostPushHook(ctx, img)
+		}
+		if err != nil && !opts.IgnorePostPushHookErrors {
+			return &PostPushHookError{Image: img, Err: err}
+		}
+	}
+	return nil
+}
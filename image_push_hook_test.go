@@ -0,0 +1,95 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPushImagePostPushHook(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newJSONStreamHandler("/images/localhost:5000/app/push", pushStream...))
+	var pushed []PushedImage
+	var auxCalls int
+	err := client.PushImage(PushImageOptions{
+		Name:         "localhost:5000/app",
+		Tag:          "v1",
+		OutputStream: &bytes.Buffer{},
+		AuxCallback:  func(AuxMessage) { auxCalls++ },
+		PostPushHook: func(ctx context.Context, img PushedImage) error {
+			pushed = append(pushed, img)
+			return nil
+		},
+	}, AuthConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PushedImage{{Name: "localhost:5000/app", Tag: "v1", Digest: "sha256:4f1d2e", Size: 528}}
+	if !reflect.DeepEqual(pushed, expected) {
+		t.Errorf("PushImage: wrong images passed to the hook. Want %#v. Got %#v.", expected, pushed)
+	}
+	if auxCalls != 1 {
+		t.Errorf("PushImage: want the aux callback called once. Got %d calls.", auxCalls)
+	}
+	if ref := pushed[0].DigestReference(); ref != "localhost:5000/app@sha256:4f1d2e" {
+		t.Errorf("DigestReference: wrong reference: %q", ref)
+	}
+	if ref := pushed[0].Reference(); ref != "localhost:5000/app:v1" {
+		t.Errorf("Reference: wrong reference: %q", ref)
+	}
+}
+
+func TestPushImagePostPushHookError(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newJSONStreamHandler("/images/localhost:5000/app/push", pushStream...))
+	errSign := errors.New("signing failed")
+	opts := PushImageOptions{
+		Name:         "localhost:5000/app",
+		Tag:          "v1",
+		OutputStream: &bytes.Buffer{},
+		PostPushHook: func(ctx context.Context, img PushedImage) error {
+			return errSign
+		},
+	}
+	err := client.PushImage(opts, AuthConfiguration{})
+	hookErr, ok := err.(*PostPushHookError)
+	if !ok {
+		t.Fatalf("PushImage: wrong error. Want *PostPushHookError. Got %#v.", err)
+	}
+	if hookErr.Err != errSign || hookErr.Image.Digest != "sha256:4f1d2e" {
+		t.Errorf("PushImage: wrong error: %#v", hookErr)
+	}
+	if expected := "post-push hook of localhost:5000/app:v1 failed: signing failed"; err.Error() != expected {
+		t.Errorf("PushImage: wrong error message. Want %q. Got %q.", expected, err.Error())
+	}
+	opts.IgnorePostPushHookErrors = true
+	if err := client.PushImage(opts, AuthConfiguration{}); err != nil {
+		t.Errorf("PushImage: want hook errors ignored. Got %#v.", err)
+	}
+}
+
+func TestPushImagePostPushHookNoDigest(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newJSONStreamHandler("/images/app/push", `{"status":"Pushed"}`))
+	err := client.PushImage(PushImageOptions{
+		Name:         "app",
+		OutputStream: &bytes.Buffer{},
+		PostPushHook: func(ctx context.Context, img PushedImage) error {
+			t.Error("PushImage: unexpected call to the hook without a digest")
+			return nil
+		},
+	}, AuthConfiguration{})
+	hookErr, ok := err.(*PostPushHookError)
+	if !ok {
+		t.Fatalf("PushImage: wrong error. Want *PostPushHookError. Got %#v.", err)
+	}
+	if hookErr.Err != ErrPushDigestUnknown || hookErr.Image.Reference() != "app:latest" {
+		t.Errorf("PushImage: wrong error: %#v", hookErr)
+	}
+}
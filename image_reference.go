@@ -0,0 +1,139 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/abrechon/go-dockerclient/reference"
+)
+
+// ErrTagWithDigest is the error returned by TagImage when the target
+// repository is a digest reference, as tags can't point to digests.
+var ErrTagWithDigest = errors.New("refusing to create a tag with a digest reference")
+
+var conflictContainerID = regexp.MustCompile(`container ([0-9a-f]{12,64})`)
+
+// InvalidImageReference is the error returned when an image reference can't
+// be parsed.
+type InvalidImageReference struct {
+	Reference string
+	Err       error
+}
+
+func (err *InvalidImageReference) Error() string {
+	return fmt.Sprintf("invalid image reference %q: %s", err.Reference, err.Err)
+}
+
+// ImageConflict is the error returned when the daemon refuses to untag or
+// remove an image, typically because containers use it. Containers holds the
+// IDs of the blocking containers found in the message of the daemon.
+type ImageConflict struct {
+	Image      string
+	Containers []string
+
+	// Running tells whether the image is used by a running container, in
+	// which case it can't be removed even with Force.
+	Running bool
+
+	Message string
+
+	// Err is the error returned by the daemon, holding the status and the
+	// raw message.
+	Err *Error
+}
+
+func (err *ImageConflict) Error() string {
+	return err.Message
+}
+
+// newImageConflict returns an *ImageConflict when err is a conflict response
+// from the daemon, and err otherwise.
+func newImageConflict(name string, err error) error {
+	e, ok := err.(*Error)
+	if !ok || e.Status != http.StatusConflict {
+		return err
+	}
+	conflict := ImageConflict{
+		Image:   name,
+		Running: strings.Contains(e.Message, "running container"),
+		Message: strings.TrimSpace(e.Message),
+		Err:     e,
+	}
+	for _, match := range conflictContainerID.FindAllStringSubmatch(e.Message, -1) {
+		conflict.Containers = append(conflict.Containers, match[1])
+	}
+	return &conflict
+}
+
+// splitReference splits the given image reference in the repository, as
+// written, its tag and its digest, parsing it with the reference package, so
+// that the port of a registry isn't taken for a tag. ok is false when s isn't
+// a valid reference.
+func splitReference(s string) (repository, tag, digest string, ok bool) {
+	ref, err := reference.ParseReference(s)
+	if err != nil {
+		return "", "", "", false
+	}
+	repository = s
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository = repository[:i]
+	}
+	if ref.Tag != "" {
+		repository = strings.TrimSuffix(repository, ":"+ref.Tag)
+	}
+	return repository, ref.Tag, ref.Digest, true
+}
+
+// imageReference validates the reference to an existing image, that may be
+// an ID, a tag or a digest, returning the form to send to the daemon, when
+// the client has ValidateImageReferences set. The reference is returned
+// unchanged otherwise.
+//
+// A reference with both a tag and a digest identifies the image by its
+// digest, so the tag is dropped, matching the digest reference the daemon
+// stores (and untags on removal).
+func (c *Client) imageReference(name string) (string, error) {
+	if !c.ValidateImageReferences {
+		return name, nil
+	}
+	ref, err := reference.ParseReference(name)
+	if err != nil {
+		return "", &InvalidImageReference{Reference: name, Err: err}
+	}
+	if ref.Tag != "" && ref.Digest != "" {
+		return ref.FamiliarName() + "@" + ref.Digest, nil
+	}
+	return name, nil
+}
+
+// validateTagTarget checks the repository and tag an image is about to be
+// tagged as, when the client has ValidateImageReferences set.
+func (c *Client) validateTagTarget(repo, tag string) error {
+	if !c.ValidateImageReferences {
+		return nil
+	}
+	ref, err := reference.ParseReference(repo)
+	if err != nil {
+		return &InvalidImageReference{Reference: repo, Err: err}
+	}
+	if ref.Digest != "" {
+		return ErrTagWithDigest
+	}
+	if tag == "" {
+		return nil
+	}
+	if ref.Tag != "" {
+		return &InvalidImageReference{Reference: repo, Err: errors.New("repository already has a tag")}
+	}
+	if _, err = ref.WithTag(tag); err != nil {
+		return &InvalidImageReference{Reference: repo + ":" + tag, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRemoveImageInvalidReference(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "", status: http.StatusNoContent}
+	client := newTestClient(fakeRT)
+	client.ValidateImageReferences = true
+	for _, name := range []string{"test:", "Test", "test@sha256:123"} {
+		err := client.RemoveImage(name)
+		if e, ok := err.(*InvalidImageReference); !ok || e.Reference != name {
+			t.Errorf("RemoveImage(%q): wrong error. Want *InvalidImageReference. Got %#v.", name, err)
+		}
+	}
+	if len(fakeRT.requests) != 0 {
+		t.Errorf("RemoveImage: want no requests, got %d", len(fakeRT.requests))
+	}
+}
+
+func TestImageReferencesNotValidatedByDefault(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	if err := client.RemoveImage("test:"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.TagImage("base", TagImageOptions{Repo: "testImage"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeRT.requests) != 2 {
+		t.Fatalf("want the references sent to the daemon. Got %d requests.", len(fakeRT.requests))
+	}
+	if path := fakeRT.requests[0].URL.Path; path != "/images/test:" {
+		t.Errorf("RemoveImage: wrong path. Want %q. Got %q.", "/images/test:", path)
+	}
+}
+
+func TestRemoveImageDigestReference(t *testing.T) {
+	t.Parallel()
+	const digest = "sha256:ee4d5d0a4a8d0fbe2c8a6ab6d4e6e1f1d1c3f5a5b87d1b1e4e0b6bce5e6f1b2a"
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"test@" + digest, "test@" + digest},
+		{"test:latest@" + digest, "test@" + digest},
+		{"docker.io/library/test@" + digest, "docker.io/library/test@" + digest},
+		{"quay.io/org/test:1.0@" + digest, "quay.io/org/test@" + digest},
+	}
+	for _, tt := range tests {
+		fakeRT := &FakeRoundTripper{message: `[{"Untagged": "` + tt.want + `"}]`, status: http.StatusOK}
+		client := newTestClient(fakeRT)
+		client.ValidateImageReferences = true
+		results, err := client.RemoveImageWithResults(tt.name, RemoveImageOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path := fakeRT.requests[0].URL.Path; path != "/images/"+tt.want {
+			t.Errorf("RemoveImageWithResults(%q): wrong path. Want %q. Got %q.", tt.name, "/images/"+tt.want, path)
+		}
+		if untagged := results.Untagged(); !reflect.DeepEqual(untagged, []string{tt.want}) {
+			t.Errorf("RemoveImageWithResults(%q): wrong untagged: %#v", tt.name, untagged)
+		}
+	}
+}
+
+func TestRemoveImageConflict(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		message    string
+		containers []string
+		running    bool
+	}{
+		{
+			message:    "conflict: unable to delete 1b6b37e8d7c5 (cannot be forced) - image is being used by running container 3c5b1d9cba8a\n",
+			containers: []string{"3c5b1d9cba8a"},
+			running:    true,
+		},
+		{
+			message:    `conflict: unable to remove repository reference "test" (must force) - container 0a1b2c3d4e5f is using its referenced image 1b6b37e8d7c5`,
+			containers: []string{"0a1b2c3d4e5f"},
+		},
+		{
+			message: "conflict: unable to delete 1b6b37e8d7c5 (must be forced) - image is referenced in multiple repositories",
+		},
+	}
+	for _, tt := range tests {
+		client := newTestClient(&FakeRoundTripper{message: tt.message, status: http.StatusConflict})
+		err := client.RemoveImage("test")
+		conflict, ok := err.(*ImageConflict)
+		if !ok {
+			t.Errorf("RemoveImage: wrong error. Want *ImageConflict. Got %#v.", err)
+			continue
+		}
+		if conflict.Image != "test" {
+			t.Errorf("RemoveImage: wrong image. Want %q. Got %q.", "test", conflict.Image)
+		}
+		if !reflect.DeepEqual(conflict.Containers, tt.containers) {
+			t.Errorf("RemoveImage: wrong containers. Want %#v. Got %#v.", tt.containers, conflict.Containers)
+		}
+		if conflict.Running != tt.running {
+			t.Errorf("RemoveImage: wrong running. Want %v. Got %v.", tt.running, conflict.Running)
+		}
+		if conflict.Error() == "" {
+			t.Error("RemoveImage: empty error message")
+		}
+		if conflict.Err == nil || conflict.Err.Status != http.StatusConflict || conflict.Err.Message != tt.message {
+			t.Errorf("RemoveImage: wrong underlying error. Got %#v.", conflict.Err)
+		}
+	}
+}
+
+func TestRemoveImageExtendedConflict(t *testing.T) {
+	t.Parallel()
+	const message = "conflict: unable to delete 1b6b37e8d7c5 (cannot be forced) - image is being used by running container 3c5b1d9cba8a"
+	client := newTestClient(&FakeRoundTripper{message: message, status: http.StatusConflict})
+	err := client.RemoveImageExtended("1b6b37e8d7c5", RemoveImageOptions{Force: true})
+	if conflict, ok := err.(*ImageConflict); !ok || !conflict.Running {
+		t.Errorf("RemoveImageExtended: wrong error. Want running *ImageConflict. Got %#v.", err)
+	}
+}
+
+func TestTagImageValidation(t *testing.T) {
+	t.Parallel()
+	const digest = "sha256:ee4d5d0a4a8d0fbe2c8a6ab6d4e6e1f1d1c3f5a5b87d1b1e4e0b6bce5e6f1b2a"
+	tests := []struct {
+		name string
+		opts TagImageOptions
+		err  error
+	}{
+		{"base", TagImageOptions{Repo: "Test"}, &InvalidImageReference{}},
+		{"base", TagImageOptions{Repo: "test", Tag: "bad tag"}, &InvalidImageReference{}},
+		{"base", TagImageOptions{Repo: "test:1.0", Tag: "2.0"}, &InvalidImageReference{}},
+		{"Base", TagImageOptions{Repo: "test"}, &InvalidImageReference{}},
+		{"base", TagImageOptions{Repo: "test@" + digest}, ErrTagWithDigest},
+	}
+	for _, tt := range tests {
+		fakeRT := &FakeRoundTripper{message: "", status: http.StatusCreated}
+		client := newTestClient(fakeRT)
+		client.ValidateImageReferences = true
+		err := client.TagImage(tt.name, tt.opts)
+		if reflect.TypeOf(err) != reflect.TypeOf(tt.err) || (tt.err == ErrTagWithDigest && err != tt.err) {
+			t.Errorf("TagImage(%q, %#v): wrong error. Want %T. Got %#v.", tt.name, tt.opts, tt.err, err)
+		}
+		if len(fakeRT.requests) != 0 {
+			t.Errorf("TagImage(%q, %#v): want no requests, got %d", tt.name, tt.opts, len(fakeRT.requests))
+		}
+	}
+}
+
+func TestTagImageFromDigest(t *testing.T) {
+	t.Parallel()
+	const digest = "sha256:ee4d5d0a4a8d0fbe2c8a6ab6d4e6e1f1d1c3f5a5b87d1b1e4e0b6bce5e6f1b2a"
+	fakeRT := &FakeRoundTripper{message: "", status: http.StatusCreated}
+	client := newTestClient(fakeRT)
+	client.ValidateImageReferences = true
+	err := client.TagImage("quay.io/org/base@"+digest, TagImageOptions{Repo: "quay.io/org/test", Tag: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := fakeRT.requests[0]
+	if want := "/images/quay.io/org/base@" + digest + "/tag"; req.URL.Path != want {
+		t.Errorf("TagImage: wrong path. Want %q. Got %q.", want, req.URL.Path)
+	}
+	if want := "repo=quay.io%2Forg%2Ftest&tag=1.0"; req.URL.RawQuery != want {
+		t.Errorf("TagImage: wrong query. Want %q. Got %q.", want, req.URL.RawQuery)
+	}
+}
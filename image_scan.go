@@ -0,0 +1,138 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ScanTarget identifies an image handed to a Scanner.
+type ScanTarget struct {
+	// Reference is the reference the image was pulled or built as, e.g.
+	// "nginx:1.17". It's empty for untagged builds.
+	Reference string
+
+	// ID is the ID of the image, e.g. "sha256:...".
+	ID string
+
+	// Digest is the digest of the manifest of the image in its registry,
+	// for pulled images. It's empty for builds, and for pulls from
+	// registries that don't report it.
+	Digest string
+}
+
+// Scanner scans images once they're pulled or built, e.g. for known
+// vulnerabilities, to keep the images that don't pass a security gate from
+// being used. Any error returned by Scan rejects the image.
+type Scanner interface {
+	Scan(ctx context.Context, target ScanTarget) error
+}
+
+// ScannerFunc is a function implementing Scanner.
+type ScannerFunc func(ctx context.Context, target ScanTarget) error
+
+// Scan calls f(ctx, target).
+func (f ScannerFunc) Scan(ctx context.Context, target ScanTarget) error {
+	return f(ctx, target)
+}
+
+// ImageRejected is the error returned by PullAndScan and BuildAndScan when
+// the scanner rejects the image. The image is left on the daemon, so it can
+// be inspected or removed.
+type ImageRejected struct {
+	Target ScanTarget
+
+	// Err is the error returned by the scanner.
+	Err error
+}
+
+func (err *ImageRejected) Error() string {
+	name := err.Target.Reference
+	if name == "" {
+		name = err.Target.ID
+	}
+	return fmt.Sprintf("image %s rejected by the scanner: %v", name, err.Err)
+}
+
+// PullAndScan pulls an image like PullImageWithResult, then hands it to the
+// scanner, returning an *ImageRejected error when the scanner rejects it.
+func (c *Client) PullAndScan(opts PullImageOptions, auth AuthConfiguration, scanner Scanner) (*ScanTarget, error) {
+	result, err := c.PullImageWithResult(opts, auth)
+	if err != nil {
+		return nil, err
+	}
+	target := ScanTarget{Reference: pulledReference(opts.Repository, opts.Tag)}
+	if result != nil {
+		target.Digest = result.Digest
+	}
+	image, err := c.InspectImage(target.Reference)
+	if err != nil {
+		return nil, err
+	}
+	target.ID = image.ID
+	return c.scan(opts.Context, scanner, target)
+}
+
+// pulledReference returns the reference of the image pulled from the
+// repository with the given tag or digest.
+func pulledReference(repository, tag string) string {
+	switch {
+	case tag == "":
+		if strings.Contains(repository, "@") || strings.LastIndex(repository, ":") > strings.LastIndex(repository, "/") {
+			return repository
+		}
+		return repository + ":latest"
+	case strings.Contains(tag, ":"):
+		return repository + "@" + tag
+	default:
+		return repository + ":" + tag
+	}
+}
+
+// BuildAndScan builds an image like BuildImage, then hands it to the
+// scanner, returning an *ImageRejected error when the scanner rejects it.
+//
+// The ID of the image is taken from the aux message reporting it at the end
+// of the build, and from the inspection of opts.Name when the daemon
+// doesn't report it.
+func (c *Client) BuildAndScan(opts BuildImageOptions, scanner Scanner) (*ScanTarget, error) {
+	target := ScanTarget{Reference: opts.Name}
+	auxCallback := opts.AuxCallback
+	opts.AuxCallback = func(aux AuxMessage) {
+		if id, ok := aux.ImageID(); ok {
+			target.ID = id
+		}
+		if auxCallback != nil {
+			auxCallback(aux)
+		}
+	}
+	if err := c.BuildImage(opts); err != nil {
+		return nil, err
+	}
+	if target.ID == "" {
+		if opts.Name == "" {
+			return nil, errors.New("the daemon didn't report the ID of the image built")
+		}
+		image, err := c.InspectImage(opts.Name)
+		if err != nil {
+			return nil, err
+		}
+		target.ID = image.ID
+	}
+	return c.scan(opts.Context, scanner, target)
+}
+
+func (c *Client) scan(ctx context.Context, scanner Scanner, target ScanTarget) (*ScanTarget, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := scanner.Scan(ctx, target); err != nil {
+		return nil, &ImageRejected{Target: target, Err: err}
+	}
+	return &target, nil
+}
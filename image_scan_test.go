@@ -0,0 +1,155 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newScanHandler(imageJSON string, stream ...string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/images/create", newJSONStreamHandler("/images/create", stream...))
+	mux.Handle("/build", newJSONStreamHandler("/build", stream...))
+	mux.HandleFunc("/images/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(imageJSON))
+	})
+	return mux
+}
+
+func TestPullAndScan(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newScanHandler(`{"Id":"sha256:abc"}`,
+		`{"status":"Pulling from library/nginx","id":"1.17"}`,
+		`{"status":"Digest: sha256:def"}`,
+		`{"status":"Status: Downloaded newer image for nginx:1.17"}`,
+	))
+	var scanned []ScanTarget
+	scanner := ScannerFunc(func(ctx context.Context, target ScanTarget) error {
+		scanned = append(scanned, target)
+		return nil
+	})
+	target, err := client.PullAndScan(PullImageOptions{Repository: "nginx", Tag: "1.17", OutputStream: &bytes.Buffer{}}, AuthConfiguration{}, scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ScanTarget{Reference: "nginx:1.17", ID: "sha256:abc", Digest: "sha256:def"}
+	if !reflect.DeepEqual(*target, expected) {
+		t.Errorf("PullAndScan: wrong target. Want %#v. Got %#v.", expected, *target)
+	}
+	if !reflect.DeepEqual(scanned, []ScanTarget{expected}) {
+		t.Errorf("PullAndScan: wrong scanned targets: %#v", scanned)
+	}
+}
+
+func TestPullAndScanRejected(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newScanHandler(`{"Id":"sha256:abc"}`, `{"status":"Digest: sha256:def"}`))
+	errVulnerable := errors.New("CVE-2019-0001")
+	scanner := ScannerFunc(func(ctx context.Context, target ScanTarget) error {
+		return errVulnerable
+	})
+	target, err := client.PullAndScan(PullImageOptions{Repository: "nginx", OutputStream: &bytes.Buffer{}}, AuthConfiguration{}, scanner)
+	if target != nil {
+		t.Errorf("PullAndScan: want no target for a rejected image. Got %#v.", target)
+	}
+	rejected, ok := err.(*ImageRejected)
+	if !ok {
+		t.Fatalf("PullAndScan: wrong error. Want *ImageRejected. Got %#v.", err)
+	}
+	if rejected.Err != errVulnerable || rejected.Target.Reference != "nginx:latest" || rejected.Target.ID != "sha256:abc" {
+		t.Errorf("PullAndScan: wrong error: %#v", rejected)
+	}
+	if expected := "image nginx:latest rejected by the scanner: CVE-2019-0001"; err.Error() != expected {
+		t.Errorf("PullAndScan: wrong error message. Want %q. Got %q.", expected, err.Error())
+	}
+}
+
+func TestPullAndScanPullFailure(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newScanHandler(`{}`, `{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}`))
+	scanner := ScannerFunc(func(ctx context.Context, target ScanTarget) error {
+		t.Error("PullAndScan: unexpected scan of a failed pull")
+		return nil
+	})
+	_, err := client.PullAndScan(PullImageOptions{Repository: "nginx", OutputStream: &bytes.Buffer{}}, AuthConfiguration{}, scanner)
+	if err == nil {
+		t.Error("PullAndScan: want a non-nil error for a failed pull")
+	}
+}
+
+func TestBuildAndScan(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newScanHandler(`{"Id":"sha256:inspected"}`,
+		`{"stream":"Step 1/1 : FROM busybox"}`,
+		`{"aux":{"ID":"sha256:built"}}`,
+		`{"stream":"Successfully built built"}`,
+	))
+	var auxCalls int
+	var scanned ScanTarget
+	target, err := client.BuildAndScan(BuildImageOptions{
+		Name:         "app",
+		Remote:       "github.com/app/app",
+		OutputStream: &bytes.Buffer{},
+		AuxCallback:  func(AuxMessage) { auxCalls++ },
+	}, ScannerFunc(func(ctx context.Context, target ScanTarget) error {
+		scanned = target
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ScanTarget{Reference: "app", ID: "sha256:built"}
+	if *target != expected || scanned != expected {
+		t.Errorf("BuildAndScan: wrong target. Want %#v. Got %#v (scanned %#v).", expected, *target, scanned)
+	}
+	if auxCalls != 1 {
+		t.Errorf("BuildAndScan: want the aux callback called once. Got %d calls.", auxCalls)
+	}
+}
+
+func TestBuildAndScanNoAuxID(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(newScanHandler(`{"Id":"sha256:inspected"}`, `{"stream":"Successfully built inspected"}`))
+	scanner := ScannerFunc(func(ctx context.Context, target ScanTarget) error {
+		return errors.New("rejected")
+	})
+	_, err := client.BuildAndScan(BuildImageOptions{Name: "app", Remote: "github.com/app/app", OutputStream: &bytes.Buffer{}}, scanner)
+	rejected, ok := err.(*ImageRejected)
+	if !ok {
+		t.Fatalf("BuildAndScan: wrong error. Want *ImageRejected. Got %#v.", err)
+	}
+	if rejected.Target.ID != "sha256:inspected" {
+		t.Errorf("BuildAndScan: wrong image ID. Want %q. Got %q.", "sha256:inspected", rejected.Target.ID)
+	}
+	_, err = client.BuildAndScan(BuildImageOptions{Remote: "github.com/app/app", OutputStream: &bytes.Buffer{}}, scanner)
+	if err == nil {
+		t.Error("BuildAndScan: want a non-nil error for an untagged build without an image ID")
+	}
+}
+
+func TestPulledReference(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		repository, tag, expected string
+	}{
+		{"nginx", "", "nginx:latest"},
+		{"nginx", "1.17", "nginx:1.17"},
+		{"nginx:1.17", "", "nginx:1.17"},
+		{"localhost:5000/app", "", "localhost:5000/app:latest"},
+		{"localhost:5000/app", "v1", "localhost:5000/app:v1"},
+		{"nginx", "sha256:abc", "nginx@sha256:abc"},
+		{"nginx@sha256:abc", "", "nginx@sha256:abc"},
+	}
+	for _, tt := range tests {
+		if got := pulledReference(tt.repository, tt.tag); got != tt.expected {
+			t.Errorf("pulledReference(%q, %q): want %q. Got %q.", tt.repository, tt.tag, tt.expected, got)
+		}
+	}
+}
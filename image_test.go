@@ -6,15 +6,19 @@ package docker
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -146,6 +150,35 @@ func TestListImagesParameters(t *testing.T) {
 	}
 }
 
+func TestListImagesDigestsAndSharedSize(t *testing.T) {
+	t.Parallel()
+	body := `[{"Id":"sha256:abc","RepoDigests":["nginx@sha256:def"],"Size":100,"SharedSize":60,"Containers":2},{"Id":"sha256:ghi","SharedSize":-1,"Containers":-1}]`
+	fakeRT := &FakeRoundTripper{message: body, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	images, err := client.ListImages(ListImagesOptions{Digests: true, SharedSize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := fakeRT.requests[0].URL.Query()
+	if query.Get("digests") != "1" || query.Get("shared-size") != "1" {
+		t.Errorf("ListImages: wrong parameters. Want digests=1 and shared-size=1. Got %s.", fakeRT.requests[0].URL.RawQuery)
+	}
+	expected := []APIImages{
+		{ID: "sha256:abc", RepoDigests: []string{"nginx@sha256:def"}, Size: 100, SharedSize: 60, Containers: 2},
+		{ID: "sha256:ghi", SharedSize: -1, Containers: -1},
+	}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("ListImages: wrong images. Want %#v. Got %#v.", expected, images)
+	}
+	fakeRT.Reset()
+	if _, err := client.ListImages(ListImagesOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if query := fakeRT.requests[0].URL.Query(); query.Get("digests") != "" || query.Get("shared-size") != "" {
+		t.Errorf("ListImages: unexpected parameters: %s", fakeRT.requests[0].URL.RawQuery)
+	}
+}
+
 func TestImageHistory(t *testing.T) {
 	t.Parallel()
 	body := `[
@@ -242,6 +275,62 @@ func TestRemoveImageExtended(t *testing.T) {
 	}
 }
 
+func TestRemoveImageWithResults(t *testing.T) {
+	t.Parallel()
+	body := `[
+		{"Untagged": "test:latest"},
+		{"Untagged": "test@sha256:ee4d5d0a4a8d"},
+		{"Deleted": "sha256:1b6b37e8d7c5"},
+		{"Deleted": "sha256:0a2d9fa6b6aa"}
+	]`
+	fakeRT := &FakeRoundTripper{message: body, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	results, err := client.RemoveImageWithResults("test", RemoveImageOptions{NoPrune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("RemoveImageWithResults: want 4 results, got %d: %#v", len(results), results)
+	}
+	expectedUntagged := []string{"test:latest", "test@sha256:ee4d5d0a4a8d"}
+	if untagged := results.Untagged(); !reflect.DeepEqual(untagged, expectedUntagged) {
+		t.Errorf("RemoveImageWithResults: wrong untagged. Want %#v. Got %#v.", expectedUntagged, untagged)
+	}
+	expectedDeleted := []string{"sha256:1b6b37e8d7c5", "sha256:0a2d9fa6b6aa"}
+	if deleted := results.Deleted(); !reflect.DeepEqual(deleted, expectedDeleted) {
+		t.Errorf("RemoveImageWithResults: wrong deleted. Want %#v. Got %#v.", expectedDeleted, deleted)
+	}
+	req := fakeRT.requests[0]
+	if req.Method != "DELETE" {
+		t.Errorf("RemoveImageWithResults: Wrong HTTP method. Want DELETE. Got %s.", req.Method)
+	}
+	expectedQuery := "noprune=1"
+	if query := req.URL.Query().Encode(); query != expectedQuery {
+		t.Errorf("RemoveImageWithResults: Wrong query string. Want %q. Got %q.", expectedQuery, query)
+	}
+}
+
+func TestRemoveImageWithResultsNoContent(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "", status: http.StatusNoContent})
+	results, err := client.RemoveImageWithResults("test", RemoveImageOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("RemoveImageWithResults: want no results, got %#v", results)
+	}
+}
+
+func TestRemoveImageWithResultsNotFound(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "no such image", status: http.StatusNotFound})
+	_, err := client.RemoveImageWithResults("test", RemoveImageOptions{})
+	if err != ErrNoSuchImage {
+		t.Errorf("RemoveImageWithResults: wrong error. Want %#v. Got %#v.", ErrNoSuchImage, err)
+	}
+}
+
 func TestInspectImage(t *testing.T) {
 	t.Parallel()
 	body := `{
@@ -464,6 +553,138 @@ func TestPullImage(t *testing.T) {
 	}
 }
 
+func TestPullImageConcurrentDeduplication(t *testing.T) {
+	t.Parallel()
+	const callers = 5
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte("Pulling 1/100"))
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	bufs := make([]bytes.Buffer, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = client.PullImage(PullImageOptions{Repository: "base", OutputStream: &bufs[i]}, AuthConfiguration{})
+		}(i)
+	}
+	for waiting := 0; waiting < callers; {
+		time.Sleep(10 * time.Millisecond)
+		client.pulls.mu.Lock()
+		for _, call := range client.pulls.calls {
+			call.mu.Lock()
+			waiting = len(call.writers)
+			call.mu.Unlock()
+		}
+		client.pulls.mu.Unlock()
+	}
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("PullImage: expected a single request to the daemon, got %d", n)
+	}
+	for i := 0; i < callers; i++ {
+		if errs[i] != nil {
+			t.Errorf("PullImage: unexpected error for caller %d: %v", i, errs[i])
+		}
+		if out := bufs[i].String(); out != "Pulling 1/100" {
+			t.Errorf("PullImage: wrong output for caller %d. Want %q. Got %q.", i, "Pulling 1/100", out)
+		}
+	}
+}
+
+// waitForPullWaiters waits until n callers wait on the pulls of the client.
+func waitForPullWaiters(client *Client, n int) {
+	for waiting := 0; waiting < n; {
+		time.Sleep(10 * time.Millisecond)
+		client.pulls.mu.Lock()
+		for _, call := range client.pulls.calls {
+			waiting = call.waiters
+		}
+		client.pulls.mu.Unlock()
+	}
+}
+
+func TestPullImageConcurrentCancelLeader(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("Pulling 1/100"))
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		leaderErr <- client.PullImage(PullImageOptions{Repository: "base", Context: ctx}, AuthConfiguration{})
+	}()
+	waitForPullWaiters(client, 1)
+	var buf bytes.Buffer
+	followerErr := make(chan error, 1)
+	go func() {
+		followerErr <- client.PullImage(PullImageOptions{Repository: "base", OutputStream: &buf}, AuthConfiguration{})
+	}()
+	waitForPullWaiters(client, 2)
+	cancel()
+	if err := <-leaderErr; err != context.Canceled {
+		t.Errorf("PullImage: wrong error for the cancelled caller. Want %v. Got %v.", context.Canceled, err)
+	}
+	close(release)
+	if err := <-followerErr; err != nil {
+		t.Errorf("PullImage: unexpected error for the other caller: %v", err)
+	}
+	if out := buf.String(); out != "Pulling 1/100" {
+		t.Errorf("PullImage: wrong output. Want %q. Got %q.", "Pulling 1/100", out)
+	}
+}
+
+func TestPullImageConcurrentCancelAll(t *testing.T) {
+	t.Parallel()
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- client.PullImage(PullImageOptions{Repository: "base", Context: ctx}, AuthConfiguration{})
+		}()
+	}
+	waitForPullWaiters(client, 2)
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != context.Canceled {
+			t.Errorf("PullImage: wrong error. Want %v. Got %v.", context.Canceled, err)
+		}
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Error("PullImage: the pull wasn't cancelled after every caller stopped waiting")
+	}
+}
+
 func TestPullImageWithDigest(t *testing.T) {
 	t.Parallel()
 	fakeRT := &FakeRoundTripper{message: "Pulling 1/100", status: http.StatusOK}
@@ -534,6 +755,42 @@ func TestPullImageWithDigestAndTag(t *testing.T) {
 	}
 }
 
+func TestPullImageWithRegistryPort(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "Pulling 1/100", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	err := client.PullImage(PullImageOptions{Repository: "localhost:5000/app:1"}, AuthConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedQuery := url.Values{
+		"fromImage": {"localhost:5000/app"},
+		"tag":       {"1"},
+	}
+	if query := fakeRT.requests[0].URL.Query(); !reflect.DeepEqual(query, expectedQuery) {
+		t.Errorf("PullImage: Wrong query string\nWant %#v\nGot  %#v", expectedQuery, query)
+	}
+}
+
+func TestPushImageWithRegistryPort(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "Pushing 1/100", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	err := client.PushImage(PushImageOptions{Name: "localhost:5000/app:1"}, AuthConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := fakeRT.requests[0]
+	u, _ := url.Parse(client.getURL("/images/localhost:5000/app/push"))
+	if req.URL.Path != u.Path {
+		t.Errorf("PushImage: Wrong request path. Want %q. Got %q.", u.Path, req.URL.Path)
+	}
+	expectedQuery := url.Values{"tag": {"1"}}
+	if query := req.URL.Query(); !reflect.DeepEqual(query, expectedQuery) {
+		t.Errorf("PushImage: Wrong query string\nWant %#v\nGot  %#v", expectedQuery, query)
+	}
+}
+
 func TestPullImageWithRawJSON(t *testing.T) {
 	t.Parallel()
 	body := `
@@ -691,6 +948,75 @@ func TestImportImageFromInput(t *testing.T) {
 	}
 }
 
+func TestFlattenImage(t *testing.T) {
+	t.Parallel()
+	var importQuery url.Values
+	var importBody string
+	var removed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/images/base/json":
+			w.Write([]byte(`{"Id":"base-id","Config":{"Env":["PATH=/bin","GREETING=hello world"],"Entrypoint":["/entrypoint.sh"],"Cmd":["serve"],"WorkingDir":"/app","ExposedPorts":{"80/tcp":{}},"Labels":{"version":"1"}}}`))
+		case r.Method == "POST" && r.URL.Path == "/containers/create":
+			w.Write([]byte(`{"Id":"flatten-container"}`))
+		case r.Method == "GET" && r.URL.Path == "/containers/flatten-container/export":
+			w.Write([]byte("tar content"))
+		case r.Method == "POST" && r.URL.Path == "/images/create":
+			importQuery = r.URL.Query()
+			body, _ := ioutil.ReadAll(r.Body)
+			importBody = string(body)
+		case r.Method == "DELETE" && r.URL.Path == "/containers/flatten-container":
+			removed = true
+		case r.Method == "GET" && r.URL.Path == "/images/myimage:flat/json":
+			w.Write([]byte(`{"Id":"flat-id"}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, err := client.FlattenImage("base", "myimage:flat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.ID != "flat-id" {
+		t.Errorf("FlattenImage: wrong image ID. Want %q. Got %q.", "flat-id", image.ID)
+	}
+	if importBody != "tar content" {
+		t.Errorf("FlattenImage: wrong imported content. Want %q. Got %q.", "tar content", importBody)
+	}
+	if repo, tag := importQuery.Get("repo"), importQuery.Get("tag"); repo != "myimage" || tag != "flat" {
+		t.Errorf("FlattenImage: wrong import target. Want myimage:flat. Got %s:%s.", repo, tag)
+	}
+	expectedChanges := []string{
+		`ENV PATH="/bin"`,
+		`ENV GREETING="hello world"`,
+		`ENTRYPOINT ["/entrypoint.sh"]`,
+		`CMD ["serve"]`,
+		`WORKDIR /app`,
+		`EXPOSE 80/tcp`,
+		`LABEL "version"="1"`,
+	}
+	if changes := importQuery["changes"]; !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("FlattenImage: wrong changes.\nWant %#v.\nGot %#v.", expectedChanges, changes)
+	}
+	if !removed {
+		t.Error("FlattenImage: temporary container was not removed")
+	}
+}
+
+func TestFlattenImageNoSuchImage(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "no such image", status: http.StatusNotFound})
+	_, err := client.FlattenImage("base", "myimage:flat")
+	if err != ErrNoSuchImage {
+		t.Errorf("FlattenImage: wrong error. Want %#v. Got %#v.", ErrNoSuchImage, err)
+	}
+}
+
 func TestImportImageDoesNotPassInputIfSourceIsNotDash(t *testing.T) {
 	t.Parallel()
 	fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
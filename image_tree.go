@@ -0,0 +1,131 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"sort"
+)
+
+// ImageTreeNode is an image of the tree of the local images, with the images
+// built on top of it as children.
+type ImageTreeNode struct {
+	Image    APIImages
+	Children []*ImageTreeNode
+}
+
+// Walk calls fn with the node and its descendants, depth first, with their
+// depth in the tree, starting at 0 for the node.
+func (n *ImageTreeNode) Walk(fn func(node *ImageTreeNode, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *ImageTreeNode) walk(fn func(*ImageTreeNode, int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// ImageTree returns the local images (including the intermediate ones) as a
+// forest, with each image under its closest local ancestor, like the
+// removed docker images --tree did, e.g. for cleanup tools.
+//
+// The parent of an image is its ParentID when the daemon reports it, as it
+// does for the images built locally by the classic builder. Otherwise it's
+// the most recent entry of the history of the image that's a local image,
+// identified by its ID or by one of its tags. Images without local
+// ancestors are roots. Children and roots are sorted by creation time, and
+// then by ID.
+func (c *Client) ImageTree(ctx context.Context) ([]*ImageTreeNode, error) {
+	images, err := c.ListImages(ListImagesOptions{All: true, Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*ImageTreeNode, len(images))
+	tags := make(map[string]string)
+	for _, image := range images {
+		nodes[image.ID] = &ImageTreeNode{Image: image}
+		for _, tag := range image.RepoTags {
+			tags[tag] = image.ID
+		}
+	}
+	parents := make(map[string]string, len(images))
+	for _, image := range images {
+		parent := image.ParentID
+		if _, ok := nodes[parent]; !ok {
+			parent, err = c.localAncestor(ctx, image.ID, nodes, tags)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if parent != "" && !isAncestor(parents, image.ID, parent) {
+			parents[image.ID] = parent
+		}
+	}
+	var roots []*ImageTreeNode
+	for _, image := range images {
+		node := nodes[image.ID]
+		if parent, ok := parents[image.ID]; ok {
+			nodes[parent].Children = append(nodes[parent].Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	sortImageTreeNodes(roots)
+	for _, node := range nodes {
+		sortImageTreeNodes(node.Children)
+	}
+	return roots, nil
+}
+
+// localAncestor returns the ID of the closest ancestor of the image in its
+// history that's a local image, or an empty string when there's none.
+func (c *Client) localAncestor(ctx context.Context, id string, nodes map[string]*ImageTreeNode, tags map[string]string) (string, error) {
+	history, err := c.imageHistory(ctx, id)
+	if err == ErrNoSuchImage {
+		// removed since it was listed.
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range history {
+		if entry.ID == id {
+			continue
+		}
+		if _, ok := nodes[entry.ID]; ok {
+			return entry.ID, nil
+		}
+		for _, tag := range entry.Tags {
+			if ancestor, ok := tags[tag]; ok && ancestor != id {
+				return ancestor, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// isAncestor tells whether id is an ancestor of image, or the image itself,
+// in the tree described by parents, so linking image under id would make a
+// cycle.
+func isAncestor(parents map[string]string, id, image string) bool {
+	for seen := 0; image != "" && seen <= len(parents); seen++ {
+		if image == id {
+			return true
+		}
+		image = parents[image]
+	}
+	return false
+}
+
+func sortImageTreeNodes(nodes []*ImageTreeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Image.Created != nodes[j].Image.Created {
+			return nodes[i].Image.Created < nodes[j].Image.Created
+		}
+		return nodes[i].Image.ID < nodes[j].Image.ID
+	})
+}
@@ -0,0 +1,118 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImageTree(t *testing.T) {
+	t.Parallel()
+	histories := map[string]string{
+		// pulled on top of a local base image, known by its tag.
+		"sha256:app": `[{"Id":"sha256:app"},{"Id":"<missing>"},{"Id":"<missing>","Tags":["debian:10"]}]`,
+		// pulled, with a local ancestor known by its ID.
+		"sha256:tool":   `[{"Id":"sha256:tool"},{"Id":"sha256:debian"}]`,
+		"sha256:debian": `[{"Id":"sha256:debian","Tags":["debian:10"]}]`,
+		"sha256:alpine": `[{"Id":"sha256:alpine"},{"Id":"<missing>"}]`,
+	}
+	var historyCalls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			t.Errorf("ImageTree: want all the images listed. Got %s.", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[
+			{"Id":"sha256:app","RepoTags":["app:1"],"Created":30},
+			{"Id":"sha256:built","ParentId":"sha256:layer","Created":50},
+			{"Id":"sha256:layer","ParentId":"sha256:debian","Created":40},
+			{"Id":"sha256:tool","Created":20},
+			{"Id":"sha256:debian","RepoTags":["debian:10"],"Created":10},
+			{"Id":"sha256:alpine","RepoTags":["alpine:3.10"],"Created":5}
+		]`))
+	})
+	mux.HandleFunc("/images/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/images/"), "/history")
+		historyCalls = append(historyCalls, id)
+		w.Write([]byte(histories[id]))
+	})
+	client := NewClientFromHandler(mux)
+	roots, err := client.ImageTree(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, root := range roots {
+		root.Walk(func(node *ImageTreeNode, depth int) {
+			lines = append(lines, fmt.Sprintf("%s%s", strings.Repeat("  ", depth), node.Image.ID))
+		})
+	}
+	expected := []string{
+		"sha256:alpine",
+		"sha256:debian",
+		"  sha256:tool",
+		"  sha256:app",
+		"  sha256:layer",
+		"    sha256:built",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("ImageTree: wrong tree.\nWant:\n%s\nGot:\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+	for _, id := range historyCalls {
+		if id == "sha256:built" || id == "sha256:layer" {
+			t.Errorf("ImageTree: unexpected history of %s, which has a local parent", id)
+		}
+	}
+}
+
+func TestImageTreeCycle(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id":"a","ParentId":"b"},{"Id":"b","ParentId":"a"}]`))
+	})
+	client := NewClientFromHandler(mux)
+	roots, err := client.ImageTree(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0].Image.ID != "b" || len(roots[0].Children) != 1 {
+		t.Errorf("ImageTree: want the cycle broken. Got %#v.", roots)
+	}
+}
+
+func TestImageTreeRemovedImage(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id":"a"}]`))
+	})
+	mux.HandleFunc("/images/a/history", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such image", http.StatusNotFound)
+	})
+	client := NewClientFromHandler(mux)
+	roots, err := client.ImageTree(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0].Image.ID != "a" {
+		t.Errorf("ImageTree: wrong roots: %#v", roots)
+	}
+}
+
+func TestImageTreeListError(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "server error", http.StatusInternalServerError)
+	}))
+	if _, err := client.ImageTree(context.Background()); err == nil {
+		t.Error("ImageTree: want a non-nil error")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// InspectErrors is the error returned by InspectContainers and InspectImages
+// when some of the objects could not be inspected. It maps the ID of each
+// failed object to the error returned by the daemon.
+type InspectErrors map[string]error
+
+func (e InspectErrors) Error() string {
+	ids := make([]string, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("%s: %s", id, e[id])
+	}
+	return fmt.Sprintf("failed to inspect %d object(s): %s", len(e), strings.Join(msgs, "; "))
+}
+
+// InspectContainers inspects the given containers, running at most
+// concurrency requests in parallel (a non-positive concurrency means one
+// request at a time).
+//
+// The result is keyed by the IDs (or names) given as input. When some of the
+// inspects fail, the successful ones are still returned, along with an error
+// of type InspectErrors.
+func (c *Client) InspectContainers(ids []string, concurrency int) (map[string]*Container, error) {
+	containers := make(map[string]*Container, len(ids))
+	var mu sync.Mutex
+	err := inspectMany(ids, concurrency, func(id string) error {
+		container, err := c.InspectContainer(id)
+		if err == nil {
+			mu.Lock()
+			containers[id] = container
+			mu.Unlock()
+		}
+		return err
+	})
+	return containers, err
+}
+
+// InspectImages inspects the given images, running at most concurrency
+// requests in parallel (a non-positive concurrency means one request at a
+// time).
+//
+// The result is keyed by the IDs (or names) given as input. When some of the
+// inspects fail, the successful ones are still returned, along with an error
+// of type InspectErrors.
+func (c *Client) InspectImages(ids []string, concurrency int) (map[string]*Image, error) {
+	images := make(map[string]*Image, len(ids))
+	var mu sync.Mutex
+	err := inspectMany(ids, concurrency, func(id string) error {
+		image, err := c.InspectImage(id)
+		if err == nil {
+			mu.Lock()
+			images[id] = image
+			mu.Unlock()
+		}
+		return err
+	})
+	return images, err
+}
+
+func inspectMany(ids []string, concurrency int, inspect func(string) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = InspectErrors{}
+		sem  = make(chan struct{}, concurrency)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := inspect(id); err != nil {
+				mu.Lock()
+				errs[id] = err
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// MarshalInspect renders the given objects (containers, images, networks,
+// volumes and the like) as `docker inspect` does: a JSON array indented with
+// four spaces and followed by a newline.
+//
+// Unlike json.Marshal, it doesn't omit booleans, numbers, strings and structs
+// that have their zero value, as the daemon always reports them (e.g.
+// "Running": false or "ExitCode": 0). Nil pointers, slices and maps are still
+// omitted when their field is tagged with omitempty, as the daemon omits
+// fields like "Node" and "Health" when they don't apply.
+func MarshalInspect(objects ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, object := range objects {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeInspect(&buf, reflect.ValueOf(object)); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte(']')
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "    "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// UnmarshalInspect parses the output of `docker inspect` into v, which must be
+// a pointer to a slice (e.g. *[]Container) or, when the output describes a
+// single object, a pointer to it (e.g. *Container).
+func UnmarshalInspect(data []byte, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("UnmarshalInspect: v must be a non-nil pointer")
+	}
+	if value.Elem().Kind() == reflect.Slice {
+		return json.Unmarshal(data, v)
+	}
+	var objects []json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return err
+	}
+	if len(objects) != 1 {
+		return fmt.Errorf("UnmarshalInspect: want one object, got %d", len(objects))
+	}
+	return json.Unmarshal(objects[0], v)
+}
+
+func encodeInspect(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	// types that know how to encode themselves (like time.Time) are left to
+	// encoding/json.
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(jsonMarshalerType) {
+		return encodeJSON(buf, v.Addr())
+	}
+	if v.Type().Implements(jsonMarshalerType) && !isNilValue(v) {
+		return encodeJSON(buf, v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeInspect(buf, v.Elem())
+	case reflect.Struct:
+		return encodeInspectStruct(buf, v)
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return encodeJSON(buf, v)
+		}
+		fallthrough
+	case reflect.Array:
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeInspect(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeInspectMap(buf, v)
+	default:
+		return encodeJSON(buf, v)
+	}
+}
+
+func encodeJSON(buf *bytes.Buffer, v reflect.Value) error {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+func encodeInspectStruct(buf *bytes.Buffer, v reflect.Value) error {
+	buf.WriteByte('{')
+	first := true
+	var encodeFields func(v reflect.Value) error
+	encodeFields = func(v reflect.Value) error {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts := tag, ""
+			if idx := strings.Index(tag, ","); idx >= 0 {
+				name, opts = tag[:idx], tag[idx+1:]
+			}
+			value := v.Field(i)
+			if field.Anonymous && name == "" {
+				for value.Kind() == reflect.Ptr {
+					if value.IsNil() {
+						break
+					}
+					value = value.Elem()
+				}
+				if value.Kind() == reflect.Struct {
+					if err := encodeFields(value); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if strings.Contains(opts, "omitempty") && isNilValue(value) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			key, _ := json.Marshal(name)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := encodeInspect(buf, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := encodeFields(v); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func encodeInspectMap(buf *bytes.Buffer, v reflect.Value) error {
+	// keys are encoded like encoding/json does, and sorted by their encoded
+	// form.
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		var name string
+		if key.Kind() == reflect.String {
+			name = key.String()
+		} else {
+			data, err := json.Marshal(key.Interface())
+			if err != nil {
+				return err
+			}
+			name = strings.Trim(string(data), `"`)
+		}
+		entries = append(entries, entry{name, v.MapIndex(key)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	buf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := encodeInspect(buf, e.value); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// isNilValue reports whether v is a nil pointer, interface, slice or map.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalInspect(t *testing.T) {
+	t.Parallel()
+	container := Container{
+		ID:     "abc123",
+		Name:   "/web",
+		Config: &Config{Image: "nginx", StopTimeout: 10},
+		State: State{
+			Status:    "exited",
+			StartedAt: time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	data, err := MarshalInspect(container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !strings.HasPrefix(out, "[\n    {\n        \"") || !strings.HasSuffix(out, "}\n]\n") {
+		t.Errorf("MarshalInspect: wrong layout:\n%s", out)
+	}
+	for _, want := range []string{
+		`"Id": "abc123"`,
+		`"Running": false`,
+		`"ExitCode": 0`,
+		`"StartedAt": "2019-03-01T10:00:00Z"`,
+		`"FinishedAt": "0001-01-01T00:00:00Z"`,
+		`"StopTimeout": 10`,
+		`"Image": "nginx"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("MarshalInspect: missing %s in:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{`"Node"`, `"HostConfig"`, `"NetworkSettings"`} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("MarshalInspect: unexpected %s in:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestMarshalInspectMultiple(t *testing.T) {
+	t.Parallel()
+	data, err := MarshalInspect(&Image{ID: "sha256:1"}, &Image{ID: "sha256:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var images []Image
+	if err = json.Unmarshal(data, &images); err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 || images[0].ID != "sha256:1" || images[1].ID != "sha256:2" {
+		t.Errorf("MarshalInspect: wrong images: %#v", images)
+	}
+}
+
+func TestMarshalInspectEmpty(t *testing.T) {
+	t.Parallel()
+	data, err := MarshalInspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]\n" {
+		t.Errorf("MarshalInspect: want %q, got %q", "[]\n", data)
+	}
+}
+
+func TestMarshalInspectRoundTrip(t *testing.T) {
+	t.Parallel()
+	container := Container{
+		ID:    "abc123",
+		Image: "sha256:def",
+		State: State{Running: true, Pid: 42, StartedAt: time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)},
+		Config: &Config{
+			Env:    []string{"A=1"},
+			Labels: map[string]string{"b": "2", "a": "1"},
+		},
+		HostConfig: &HostConfig{Memory: 1024, RestartPolicy: AlwaysRestart()},
+	}
+	data, err := MarshalInspect(container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Container
+	if err = UnmarshalInspect(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, container) {
+		t.Errorf("UnmarshalInspect: round trip mismatch\nwant %#v\ngot  %#v", container, got)
+	}
+	if strings.Index(string(data), `"a": "1"`) > strings.Index(string(data), `"b": "2"`) {
+		t.Errorf("MarshalInspect: map keys not sorted:\n%s", data)
+	}
+}
+
+func TestUnmarshalInspect(t *testing.T) {
+	t.Parallel()
+	const output = `[
+    {
+        "Id": "abc123",
+        "State": {"Status": "running", "Running": true, "Pid": 42}
+    },
+    {
+        "Id": "def456",
+        "State": {"Status": "exited", "ExitCode": 1}
+    }
+]
+`
+	var containers []Container
+	if err := UnmarshalInspect([]byte(output), &containers); err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 2 || !containers[0].State.Running || containers[1].State.ExitCode != 1 {
+		t.Errorf("UnmarshalInspect: wrong containers: %#v", containers)
+	}
+	var container Container
+	err := UnmarshalInspect([]byte(output), &container)
+	if err == nil || !strings.Contains(err.Error(), "got 2") {
+		t.Errorf("UnmarshalInspect: want error about the number of objects, got %v", err)
+	}
+	if err = UnmarshalInspect([]byte(`[{"Id": "abc123"}]`), &container); err != nil {
+		t.Fatal(err)
+	}
+	if container.ID != "abc123" {
+		t.Errorf("UnmarshalInspect: wrong ID: %q", container.ID)
+	}
+}
+
+func TestUnmarshalInspectInvalidTarget(t *testing.T) {
+	t.Parallel()
+	var container Container
+	if err := UnmarshalInspect([]byte(`[]`), container); err == nil {
+		t.Error("UnmarshalInspect: want error for non-pointer target, got <nil>")
+	}
+}
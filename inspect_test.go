@@ -0,0 +1,77 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInspectContainers(t *testing.T) {
+	t.Parallel()
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/containers/"), "/json")
+		if id == "missing" {
+			http.Error(w, "no such container", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"Id":"` + id + `-full"}`))
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{"a", "b", "missing", "c", "d", "e"}
+	containers, err := client.InspectContainers(ids, 2)
+	errs, ok := err.(InspectErrors)
+	if !ok {
+		t.Fatalf("InspectContainers: wrong error type. Want InspectErrors. Got %#v.", err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("InspectContainers: wrong number of errors. Want 1. Got %d.", len(errs))
+	}
+	if _, ok := errs["missing"].(*NoSuchContainer); !ok {
+		t.Errorf("InspectContainers: wrong error for missing container. Want NoSuchContainer. Got %#v.", errs["missing"])
+	}
+	if len(containers) != 5 {
+		t.Errorf("InspectContainers: wrong number of containers. Want 5. Got %d.", len(containers))
+	}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		if container := containers[id]; container == nil || container.ID != id+"-full" {
+			t.Errorf("InspectContainers: wrong container for %q: %#v", id, container)
+		}
+	}
+	if max := atomic.LoadInt32(&maxInFlight); max > 2 {
+		t.Errorf("InspectContainers: concurrency not respected. Want at most 2 requests in flight. Got %d.", max)
+	}
+}
+
+func TestInspectImages(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id":"b750fe79269d"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	images, err := client.InspectImages([]string{"base"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := images["base"]; image == nil || image.ID != "b750fe79269d" {
+		t.Errorf("InspectImages: wrong image: %#v", image)
+	}
+}
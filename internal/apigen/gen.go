@@ -0,0 +1,373 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// spec is the swagger spec of the Docker Engine API.
+type spec struct {
+	version     string
+	definitions *yamlMap
+}
+
+func newSpec(data string) (*spec, error) {
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := root.(*yamlMap)
+	if !ok || m.getMap("definitions") == nil {
+		return nil, fmt.Errorf("invalid spec: missing definitions")
+	}
+	return &spec{
+		version:     m.getMap("info").getString("version"),
+		definitions: m.getMap("definitions"),
+	}, nil
+}
+
+// properties returns the properties of a schema, including the ones of the
+// schemas it's composed of (allOf), in the order of the spec.
+func (s *spec) properties(schema *yamlMap) ([]string, map[string]*yamlMap) {
+	var names []string
+	props := make(map[string]*yamlMap)
+	var collect func(schema *yamlMap, depth int)
+	collect = func(schema *yamlMap, depth int) {
+		if schema == nil || depth > 10 {
+			return
+		}
+		if ref := schema.getString("$ref"); ref != "" {
+			collect(s.definitions.getMap(refName(ref)), depth+1)
+			return
+		}
+		if all, ok := schema.get("allOf").([]interface{}); ok {
+			for _, sub := range all {
+				sub, _ := sub.(*yamlMap)
+				collect(sub, depth+1)
+			}
+		}
+		properties := schema.getMap("properties")
+		if properties == nil {
+			return
+		}
+		for _, name := range properties.keys {
+			if _, ok := props[name]; !ok {
+				names = append(names, name)
+			}
+			props[name] = properties.getMap(name)
+		}
+	}
+	collect(schema, 0)
+	return names, props
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
+
+// generator writes the Go types of the definitions of the spec.
+type generator struct {
+	spec *spec
+	pkg  string
+
+	// names are the Go names of the definitions, either hand-written
+	// types or generated ones.
+	names map[string]string
+
+	buf       bytes.Buffer
+	generated map[string]bool
+	pending   []pendingType
+	usesTime  bool
+}
+
+type pendingType struct {
+	name   string
+	schema *yamlMap
+}
+
+func newGenerator(s *spec, pkg string, mapping map[string]string) *generator {
+	g := generator{spec: s, pkg: pkg, names: make(map[string]string), generated: make(map[string]bool)}
+	for def, name := range mapping {
+		g.names[def] = name
+	}
+	return &g
+}
+
+// generate returns the source of the types of the given definitions, and of
+// the inline types they use.
+func (g *generator) generate(definitions []string) ([]byte, error) {
+	for _, def := range definitions {
+		schema := g.spec.definitions.getMap(def)
+		if schema == nil {
+			return nil, fmt.Errorf("definition %s not found in the spec", def)
+		}
+		name := goName(def)
+		g.names[def] = name
+		g.pending = append(g.pending, pendingType{name: name, schema: schema})
+	}
+	var body bytes.Buffer
+	for len(g.pending) > 0 {
+		t := g.pending[0]
+		g.pending = g.pending[1:]
+		if g.generated[t.name] {
+			continue
+		}
+		g.generated[t.name] = true
+		g.writeType(&body, t.name, t.schema)
+	}
+	fmt.Fprintf(&g.buf, "// Code generated by apigen from the Docker Engine API v%s. DO NOT EDIT.\n\n", g.spec.version)
+	fmt.Fprintf(&g.buf, "package %s\n\n", g.pkg)
+	if g.usesTime {
+		g.buf.WriteString("import \"time\"\n\n")
+	}
+	g.buf.Write(body.Bytes())
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %v\n%s", err, g.buf.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) writeType(w *bytes.Buffer, name string, schema *yamlMap) {
+	writeComment(w, "", schema.getString("description"))
+	names, props := g.spec.properties(schema)
+	if len(names) == 0 {
+		fmt.Fprintf(w, "type %s %s\n\n", name, g.goType(name, schema))
+		return
+	}
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for i, prop := range names {
+		field := goName(prop)
+		if i > 0 && props[prop].getString("description") != "" {
+			w.WriteString("\n")
+		}
+		writeComment(w, "\t", props[prop].getString("description"))
+		tag := prop + ",omitempty"
+		fmt.Fprintf(w, "\t%s %s `json:%q yaml:%q toml:%q`\n", field, g.goType(name+field, props[prop]), tag, tag, tag)
+	}
+	w.WriteString("}\n\n")
+}
+
+// goType returns the Go type of a schema, queuing the inline object types,
+// named after the given name.
+func (g *generator) goType(name string, schema *yamlMap) string {
+	if schema == nil {
+		return "interface{}"
+	}
+	if ref := schema.getString("$ref"); ref != "" {
+		def := refName(ref)
+		typeName, ok := g.names[def]
+		if !ok {
+			typeName = goName(def)
+			g.names[def] = typeName
+			g.pending = append(g.pending, pendingType{name: typeName, schema: g.spec.definitions.getMap(def)})
+		}
+		if schema.getBool("x-nullable") {
+			return "*" + typeName
+		}
+		return typeName
+	}
+	switch schema.getString("type") {
+	case "string":
+		if schema.getString("format") == "date-time" {
+			g.usesTime = true
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		switch schema.getString("format") {
+		case "int64":
+			return "int64"
+		case "uint64":
+			return "uint64"
+		case "uint32":
+			return "uint32"
+		case "uint16":
+			return "uint16"
+		case "int32":
+			return "int32"
+		}
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(name, schema.getMap("items"))
+	}
+	if additional, ok := schema.get("additionalProperties").(*yamlMap); ok {
+		return "map[string]" + g.goType(name, additional)
+	}
+	if names, _ := g.spec.properties(schema); len(names) > 0 {
+		g.pending = append(g.pending, pendingType{name: name, schema: schema})
+		return name
+	}
+	if schema.getString("type") == "object" {
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+// writeComment writes the description as the doc comment of a type or field.
+func writeComment(w *bytes.Buffer, indent, description string) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return
+	}
+	words := strings.Fields(description)
+	line := indent + "//"
+	for _, word := range words {
+		if len(line)+1+len(word) > 77 && line != indent+"//" {
+			w.WriteString(line + "\n")
+			line = indent + "//"
+		}
+		line += " " + word
+	}
+	w.WriteString(line + "\n")
+}
+
+var initialisms = map[string]string{
+	"Id": "ID", "Ip": "IP", "Url": "URL", "Uri": "URI", "Api": "API",
+	"Cpu": "CPU", "Tls": "TLS", "Ttl": "TTL", "Uid": "UID", "Gid": "GID",
+}
+
+// goName returns the exported Go name of a definition or property.
+func goName(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 && unicode.IsLower(word[len(word)-1]) {
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	var b strings.Builder
+	for _, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		w = string(runes)
+		if initialism, ok := initialisms[w]; ok {
+			w = initialism
+		}
+		b.WriteString(w)
+	}
+	result := b.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+// handWrittenFields returns the JSON names of the fields of the struct types
+// declared in the Go files of the directory, by type name, skipping tests
+// and the given generated file.
+func handWrittenFields(dir, generated string) (map[string]map[string]bool, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != filepath.Base(generated)
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(node ast.Node) bool {
+				spec, ok := node.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					return false
+				}
+				fields := make(map[string]bool)
+				for _, field := range st.Fields.List {
+					for _, name := range jsonNames(field) {
+						// encoding/json matches the names ignoring case.
+						fields[strings.ToLower(name)] = true
+					}
+				}
+				types[spec.Name.Name] = fields
+				return false
+			})
+		}
+	}
+	return types, nil
+}
+
+func jsonNames(field *ast.Field) []string {
+	if field.Tag != nil {
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err == nil {
+			if name := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]; name != "" {
+				if name == "-" {
+					return nil
+				}
+				return []string{name}
+			}
+		}
+	}
+	var names []string
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	return names
+}
+
+// drift lists the properties of the definitions missing from the
+// hand-written types they're mapped to.
+func drift(s *spec, mapping map[string]string, types map[string]map[string]bool) []string {
+	var defs []string
+	for def := range mapping {
+		defs = append(defs, def)
+	}
+	sort.Strings(defs)
+	var report []string
+	for _, def := range defs {
+		typeName := mapping[def]
+		schema := s.definitions.getMap(def)
+		if schema == nil {
+			report = append(report, fmt.Sprintf("%s: definition %s not found in the spec", typeName, def))
+			continue
+		}
+		fields, ok := types[typeName]
+		if !ok {
+			report = append(report, fmt.Sprintf("%s: type not found", typeName))
+			continue
+		}
+		names, _ := s.properties(schema)
+		for _, name := range names {
+			if !fields[strings.ToLower(name)] {
+				report = append(report, fmt.Sprintf("%s: missing field %s (%s.%s)", typeName, name, def, name))
+			}
+		}
+	}
+	return report
+}
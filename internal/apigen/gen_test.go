@@ -0,0 +1,174 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func readTestSpec(t *testing.T) *spec {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newSpec(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	s := readTestSpec(t)
+	src, err := newGenerator(s, "docker", map[string]string{"ContainerConfig": "Config"}).generate([]string{"Plugin", "ImageID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "generated.golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != string(expected) {
+		t.Errorf("generate: wrong code.\nWant:\n%s\nGot:\n%s", expected, src)
+	}
+}
+
+// TestGenerateVendoredSpec checks that the types generated by go generate
+// from the vendored spec are up to date.
+func TestGenerateVendoredSpec(t *testing.T) {
+	t.Parallel()
+	data, err := ioutil.ReadFile("swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newSpec(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.version != "1.41" {
+		t.Errorf("newSpec: wrong version of the vendored spec. Want 1.41. Got %s.", s.version)
+	}
+	src, err := newGenerator(s, "docker", nil).generate([]string{"BuildCache"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile(filepath.Join("..", "..", "api_generated.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != string(expected) {
+		t.Errorf("generate: api_generated.go is out of date, run go generate.\nWant:\n%s\nGot:\n%s", src, expected)
+	}
+}
+
+func TestGenerateUnknownDefinition(t *testing.T) {
+	t.Parallel()
+	s := readTestSpec(t)
+	if _, err := newGenerator(s, "docker", nil).generate([]string{"Volume"}); err == nil {
+		t.Error("generate: want an error for an unknown definition. Got <nil>.")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"Id":                 "ID",
+		"HostConfig":         "HostConfig",
+		"ipv4_address":       "Ipv4Address",
+		"com.docker.network": "ComDockerNetwork",
+		"Cpu":                "CPU",
+		"CpuShares":          "CPUShares",
+		"2fa":                "X2fa",
+	}
+	for name, expected := range tests {
+		if got := goName(name); got != expected {
+			t.Errorf("goName(%q): want %q. Got %q.", name, expected, got)
+		}
+	}
+}
+
+func TestDrift(t *testing.T) {
+	t.Parallel()
+	s := readTestSpec(t)
+	dir, err := ioutil.TempDir("", "apigen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := `package docker
+
+type Config struct {
+	Hostname string ` + "`json:\"Hostname,omitempty\"`" + `
+	Labels   map[string]string
+	Ignored  string ` + "`json:\"-\"`" + `
+}
+
+type HealthConfig struct {
+	Test     []string
+	Interval int64
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "types.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fields, err := handWrittenFields(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := drift(s, map[string]string{"ContainerConfig": "Config", "HealthConfig": "HealthConfig", "Port": "APIPort", "Volume": "Volume"}, fields)
+	expected := []string{
+		"Config: missing field Healthcheck (ContainerConfig.Healthcheck)",
+		"APIPort: type not found",
+		"Volume: definition Volume not found in the spec",
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("drift: wrong report.\nWant %#v.\nGot  %#v.", expected, report)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "apigen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "api_generated.go")
+	err = run(ioutil.Discard, []string{"-spec", "testdata/swagger.yaml", "-version", "1.41", "-types", "HealthConfig", "-out", out, "-dir", dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "type HealthConfig struct") {
+		t.Errorf("run: wrong generated code:\n%s", data)
+	}
+	err = run(ioutil.Discard, []string{"-spec", "testdata/swagger.yaml", "-version", "1.40"})
+	if err == nil || err.Error() != "spec is for API v1.41, want v1.40" {
+		t.Errorf("run: wrong error for a version mismatch: %v", err)
+	}
+	err = run(ioutil.Discard, []string{"-spec", "testdata/swagger.yaml", "-map", "ContainerConfig=Config", "-dir", dir, "-out", out, "-check"})
+	if err == nil {
+		t.Error("run: want an error for the missing type with -check. Got <nil>.")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command apigen derives Go types from the swagger spec of the Docker Engine
+// API, for a pinned version of the API, and reconciles the spec with the
+// hand-written types of the package.
+//
+// It generates the types of the definitions given with -types, along with
+// the inline types they use, to the file given with -out. Definitions mapped
+// to hand-written types with -map aren't generated: the generated types
+// refer to the hand-written ones instead, and apigen reports the properties
+// of the definitions that are missing from them. With -check, apigen fails
+// when properties are missing, e.g. in CI, so new daemon fields don't go
+// unnoticed.
+//
+// Usage:
+//
+//	go run ./internal/apigen -spec swagger.yaml -version 1.41 \
+//		-map ContainerConfig=Config,HostConfig=HostConfig \
+//		-types Plugin -out api_generated.go -check
+//
+// The spec is read from a file or from an http(s) URL.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Stderr, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "apigen:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs apigen with the given arguments, writing the drift report to
+// report.
+func run(report io.Writer, args []string) error {
+	flags := flag.NewFlagSet("apigen", flag.ContinueOnError)
+	specPath := flags.String("spec", "", "path or URL of the swagger spec")
+	version := flags.String("version", "", "expected version of the API (e.g. 1.41)")
+	pkg := flags.String("package", "docker", "package of the generated file")
+	out := flags.String("out", "", "generated file")
+	dir := flags.String("dir", ".", "directory of the hand-written types")
+	types := flags.String("types", "", "comma separated definitions to generate")
+	mapping := flags.String("map", "", "comma separated Definition=Type pairs of hand-written types")
+	check := flags.Bool("check", false, "fail when hand-written types miss properties")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *specPath == "" {
+		return errors.New("missing -spec")
+	}
+	data, err := readSpec(*specPath)
+	if err != nil {
+		return err
+	}
+	s, err := newSpec(data)
+	if err != nil {
+		return err
+	}
+	if *version != "" && s.version != *version {
+		return fmt.Errorf("spec is for API v%s, want v%s", s.version, *version)
+	}
+	handWritten, err := parseMapping(*mapping)
+	if err != nil {
+		return err
+	}
+	if *types != "" {
+		if *out == "" {
+			return errors.New("missing -out")
+		}
+		src, err := newGenerator(s, *pkg, handWritten).generate(strings.Split(*types, ","))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+			return err
+		}
+	}
+	if len(handWritten) == 0 {
+		return nil
+	}
+	fields, err := handWrittenFields(*dir, *out)
+	if err != nil {
+		return err
+	}
+	missing := drift(s, handWritten, fields)
+	for _, line := range missing {
+		fmt.Fprintln(report, line)
+	}
+	if *check && len(missing) > 0 {
+		return fmt.Errorf("%d drifts between the spec and the hand-written types", len(missing))
+	}
+	return nil
+}
+
+func readSpec(path string) (string, error) {
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		data, err := ioutil.ReadFile(path)
+		return string(data), err
+	}
+	resp, err := http.Get(path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", path, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	return string(data), err
+}
+
+// parseMapping parses the Definition=Type pairs of -map.
+func parseMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if value == "" {
+		return mapping, nil
+	}
+	pairs := strings.Split(value, ",")
+	sort.Strings(pairs)
+	for _, pair := range pairs {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid mapping %q, want Definition=Type", pair)
+		}
+		mapping[parts[0]] = parts[1]
+	}
+	return mapping, nil
+}
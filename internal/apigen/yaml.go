@@ -0,0 +1,545 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlMap is a YAML mapping that keeps the order of its keys, so the fields
+// of the generated structs follow the order of the spec.
+type yamlMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newYAMLMap() *yamlMap {
+	return &yamlMap{values: make(map[string]interface{})}
+}
+
+func (m *yamlMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *yamlMap) get(key string) interface{} {
+	if m == nil {
+		return nil
+	}
+	return m.values[key]
+}
+
+func (m *yamlMap) getMap(key string) *yamlMap {
+	value, _ := m.get(key).(*yamlMap)
+	return value
+}
+
+func (m *yamlMap) getString(key string) string {
+	switch value := m.get(key).(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func (m *yamlMap) getBool(key string) bool {
+	value, _ := m.get(key).(bool)
+	return value
+}
+
+// parseYAML parses the subset of YAML used by the swagger spec of the Docker
+// Engine API: block mappings and sequences, flow collections, quoted and
+// plain scalars, and literal and folded block scalars. Anchors, tags and
+// multiple documents aren't supported.
+func parseYAML(data string) (interface{}, error) {
+	p := yamlParser{}
+	for i, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "...") {
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(line) - len(trimmed), text: trimmed})
+	}
+	p.skipEmpty()
+	if p.done() {
+		return nil, nil
+	}
+	value, err := p.parseNode(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	p.skipEmpty()
+	if !p.done() {
+		return nil, p.errorf("unexpected content")
+	}
+	return value, nil
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) done() bool {
+	return p.pos >= len(p.lines)
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	num := 0
+	if !p.done() {
+		num = p.lines[p.pos].num
+	} else if len(p.lines) > 0 {
+		num = p.lines[len(p.lines)-1].num
+	}
+	return fmt.Errorf("yaml: line %d: %s", num, fmt.Sprintf(format, args...))
+}
+
+// content returns the text of the current line without its comment.
+func (p *yamlParser) content() string {
+	return stripComment(p.lines[p.pos].text)
+}
+
+// skipEmpty skips the blank lines and the comments.
+func (p *yamlParser) skipEmpty() {
+	for !p.done() && p.content() == "" {
+		p.pos++
+	}
+}
+
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	text := p.content()
+	if isSequenceItem(text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitKey(text); ok {
+		return p.parseMapping(indent)
+	}
+	value, err := p.parseScalarLines(indent - 1)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (*yamlMap, error) {
+	m := newYAMLMap()
+	for {
+		p.skipEmpty()
+		if p.done() || p.lines[p.pos].indent != indent {
+			break
+		}
+		text := p.content()
+		if isSequenceItem(text) {
+			break
+		}
+		key, rest, ok := splitKey(text)
+		if !ok {
+			return nil, p.errorf("expected a key")
+		}
+		value, err := p.parseValue(indent, rest, true)
+		if err != nil {
+			return nil, err
+		}
+		m.set(key, value)
+	}
+	if !p.done() && p.lines[p.pos].indent > indent && p.content() != "" {
+		return nil, p.errorf("bad indentation")
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	var seq []interface{}
+	for {
+		p.skipEmpty()
+		if p.done() || p.lines[p.pos].indent != indent || !isSequenceItem(p.content()) {
+			break
+		}
+		line := &p.lines[p.pos]
+		item := strings.TrimPrefix(line.text, "-")
+		trimmed := strings.TrimLeft(item, " ")
+		if _, _, ok := splitKey(stripComment(trimmed)); ok && !isFlow(trimmed) && !isQuoted(trimmed) {
+			// "- key: value": a mapping starting on the line of the item.
+			line.indent += 1 + len(item) - len(trimmed)
+			line.text = trimmed
+			value, err := p.parseMapping(line.indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, value)
+			continue
+		}
+		value, err := p.parseValue(indent, stripComment(trimmed), false)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, value)
+	}
+	return seq, nil
+}
+
+// parseValue parses the value of a key or a sequence item at the given
+// indent, rest being the text following the key or the dash on its line.
+func (p *yamlParser) parseValue(indent int, rest string, inMapping bool) (interface{}, error) {
+	switch {
+	case rest == "":
+		p.pos++
+		p.skipEmpty()
+		if p.done() {
+			return nil, nil
+		}
+		next := p.lines[p.pos]
+		if next.indent > indent || (inMapping && next.indent == indent && isSequenceItem(p.content())) {
+			return p.parseNode(next.indent)
+		}
+		return nil, nil
+	case strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">"):
+		return p.parseBlockScalar(indent, rest)
+	}
+	p.lines[p.pos].text = rest
+	p.lines[p.pos].indent = indent + 1
+	return p.parseScalarLines(indent)
+}
+
+// parseScalarLines parses a scalar or flow collection starting on the
+// current line, continued on the following lines indented more than indent.
+func (p *yamlParser) parseScalarLines(indent int) (interface{}, error) {
+	text := p.content()
+	p.pos++
+	// quoted scalars go on up to their closing quote, whatever the
+	// indentation of their lines, which are folded.
+	breaks := 0
+	for !p.done() && isQuoted(text) && quotedEnd(text) < 0 {
+		line := strings.TrimRight(p.lines[p.pos].text, " \t")
+		p.pos++
+		if line == "" {
+			breaks++
+			continue
+		}
+		text += foldQuotedBreaks(text[0], breaks) + line
+		breaks = 0
+	}
+	for !p.done() && p.lines[p.pos].indent > indent && p.content() != "" && !isComplete(text) {
+		text += " " + p.content()
+		p.pos++
+	}
+	for !p.done() && p.lines[p.pos].indent > indent && p.content() != "" && !isFlow(text) && !isQuoted(text) {
+		// continuation of a plain scalar.
+		if _, _, ok := splitKey(p.content()); ok || isSequenceItem(p.content()) {
+			return nil, p.errorf("bad indentation")
+		}
+		text += " " + p.content()
+		p.pos++
+	}
+	if isFlow(text) {
+		value, rest, err := parseFlow(text)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, p.errorf("unexpected %q after flow collection", rest)
+		}
+		return value, nil
+	}
+	return parseScalar(text)
+}
+
+func (p *yamlParser) parseBlockScalar(indent int, header string) (string, error) {
+	folded := header[0] == '>'
+	chomp := strings.TrimSpace(stripComment(header[1:]))
+	p.pos++
+	var lines []string
+	blockIndent := -1
+	for !p.done() {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.text) == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			break
+		}
+		lines = append(lines, strings.Repeat(" ", line.indent-blockIndent)+line.text)
+		p.pos++
+	}
+	// trailing blank lines belong to the block only for the chomping.
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	var text string
+	if folded {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case i == 0, lines[i-1] == "":
+				// the line break before a blank line is folded.
+			case line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(lines[i-1], " "):
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		text = b.String()
+	} else {
+		text = strings.Join(lines, "\n")
+	}
+	switch chomp {
+	case "-":
+	case "+":
+		text += "\n" + strings.Repeat("\n", trailing)
+	default:
+		if len(lines) > 0 {
+			text += "\n"
+		}
+	}
+	return text, nil
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isFlow(text string) bool {
+	return strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{")
+}
+
+func isQuoted(text string) bool {
+	return strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'")
+}
+
+// isComplete tells whether a flow collection or a quoted scalar is closed.
+func isComplete(text string) bool {
+	if !isFlow(text) && !isQuoted(text) {
+		return true
+	}
+	if isQuoted(text) {
+		_, rest, err := scanQuoted(text)
+		return err == nil && strings.TrimSpace(rest) == ""
+	}
+	_, _, err := parseFlow(text)
+	return err == nil
+}
+
+// splitKey splits a "key: value" line, returning the unquoted key and the
+// value.
+func splitKey(text string) (string, string, bool) {
+	if isQuoted(text) {
+		key, rest, err := scanQuoted(text)
+		if err != nil || !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		rest = rest[1:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest), true
+	}
+	if isFlow(text) {
+		return "", "", false
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i == len(text)-1 || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes the comment at the end of the line, if any.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" [{,:", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return strings.TrimRight(text, " \t")
+}
+
+// foldQuotedBreaks returns what the line breaks between two lines of a
+// quoted scalar fold to: a space for a single one, and a line feed for each
+// blank line otherwise.
+func foldQuotedBreaks(quote byte, blankLines int) string {
+	switch {
+	case blankLines == 0:
+		return " "
+	case quote == '"':
+		// escaped, as the string is unquoted by strconv.
+		return strings.Repeat(`\n`, blankLines)
+	}
+	return strings.Repeat("\n", blankLines)
+}
+
+// quotedEnd returns the index of the quote closing the quoted string at the
+// start of text, or -1 when it's unterminated.
+func quotedEnd(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// scanQuoted reads the quoted string at the start of text, returning it
+// unquoted and the rest of the text.
+func scanQuoted(text string) (string, string, error) {
+	i := quotedEnd(text)
+	if i < 0 {
+		return "", "", fmt.Errorf("unterminated string %s", text)
+	}
+	if text[0] == '\'' {
+		return strings.Replace(text[1:i], "''", "'", -1), text[i+1:], nil
+	}
+	s, err := strconv.Unquote(text[:i+1])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid string %s: %v", text[:i+1], err)
+	}
+	return s, text[i+1:], nil
+}
+
+// parseFlow parses the flow collection at the start of text, returning the
+// rest of the text.
+func parseFlow(text string) (interface{}, string, error) {
+	text = strings.TrimLeft(text, " ")
+	if text == "" {
+		return nil, "", fmt.Errorf("unexpected end of flow collection")
+	}
+	switch text[0] {
+	case '[':
+		var seq []interface{}
+		text = strings.TrimLeft(text[1:], " ")
+		for {
+			if strings.HasPrefix(text, "]") {
+				return seq, text[1:], nil
+			}
+			value, rest, err := parseFlowValue(text, "],")
+			if err != nil {
+				return nil, "", err
+			}
+			seq = append(seq, value)
+			text = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(text, ",") {
+				text = strings.TrimLeft(text[1:], " ")
+			} else if !strings.HasPrefix(text, "]") {
+				return nil, "", fmt.Errorf("expected , or ] in flow sequence")
+			}
+		}
+	case '{':
+		m := newYAMLMap()
+		text = strings.TrimLeft(text[1:], " ")
+		for {
+			if strings.HasPrefix(text, "}") {
+				return m, text[1:], nil
+			}
+			key, rest, err := parseFlowValue(text, ":,}")
+			if err != nil {
+				return nil, "", err
+			}
+			rest = strings.TrimLeft(rest, " ")
+			if !strings.HasPrefix(rest, ":") {
+				return nil, "", fmt.Errorf("expected : in flow mapping")
+			}
+			value, rest, err := parseFlowValue(strings.TrimLeft(rest[1:], " "), ",}")
+			if err != nil {
+				return nil, "", err
+			}
+			m.set(fmt.Sprint(key), value)
+			text = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(text, ",") {
+				text = strings.TrimLeft(text[1:], " ")
+			} else if !strings.HasPrefix(text, "}") {
+				return nil, "", fmt.Errorf("expected , or } in flow mapping")
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("not a flow collection")
+}
+
+func parseFlowValue(text, terminators string) (interface{}, string, error) {
+	switch {
+	case isFlow(text):
+		return parseFlow(text)
+	case isQuoted(text):
+		return scanQuoted(text)
+	}
+	end := strings.IndexAny(text, terminators)
+	if end < 0 {
+		return nil, "", fmt.Errorf("unterminated flow collection")
+	}
+	value, err := parseScalar(strings.TrimSpace(text[:end]))
+	return value, text[end:], err
+}
+
+// parseScalar parses a scalar, resolving the plain ones to booleans,
+// numbers and null.
+func parseScalar(text string) (interface{}, error) {
+	if isQuoted(text) {
+		value, rest, err := scanQuoted(text)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("unexpected %q after string", rest)
+		}
+		return value, nil
+	}
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && strings.ContainsAny(text, ".eE") {
+		return f, nil
+	}
+	return text, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// plain converts the parsed YAML to plain maps, for comparisons.
+func plain(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *yamlMap:
+		m := make(map[string]interface{}, len(v.keys))
+		for _, key := range v.keys {
+			m[key] = plain(v.values[key])
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = plain(item)
+		}
+		return s
+	}
+	return value
+}
+
+func TestParseYAML(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{
+			"mappings and scalars",
+			"a: 1\nb: \"two # not a comment\" # comment\nc: 'it''s'\nd: true\ne: 1.5\nf: ~\ng: plain text\n",
+			map[string]interface{}{"a": int64(1), "b": "two # not a comment", "c": "it's", "d": true, "e": 1.5, "f": nil, "g": "plain text"},
+		},
+		{
+			"nested",
+			"a:\n  b:\n    c: x\n  d: y\n",
+			map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": "x"}, "d": "y"}},
+		},
+		{
+			"sequences",
+			"a:\n  - x\n  - y\nb:\n- z\nc:\n  - k: v\n    l: w\n  - m: n\n",
+			map[string]interface{}{
+				"a": []interface{}{"x", "y"},
+				"b": []interface{}{"z"},
+				"c": []interface{}{map[string]interface{}{"k": "v", "l": "w"}, map[string]interface{}{"m": "n"}},
+			},
+		},
+		{
+			"flow collections",
+			"a: [x, \"y, z\", 3]\nb: {k: v, \"$ref\": '#/x'}\nc: []\nd: {}\ne: [\n  x,\n  y]\n",
+			map[string]interface{}{
+				"a": []interface{}{"x", "y, z", int64(3)},
+				"b": map[string]interface{}{"k": "v", "$ref": "#/x"},
+				"c": []interface{}{},
+				"d": map[string]interface{}{},
+				"e": []interface{}{"x", "y"},
+			},
+		},
+		{
+			"block scalars",
+			"a: |\n  line 1\n\n    indented\nb: >\n  folded\n  text\n\n  paragraph\nc: |-\n  stripped\nd: x\n",
+			map[string]interface{}{"a": "line 1\n\n  indented\n", "b": "folded text\nparagraph\n", "c": "stripped", "d": "x"},
+		},
+		{
+			"multi-line plain scalar",
+			"a:\n  first line,\n  second line\nb: x\n",
+			map[string]interface{}{"a": "first line, second line", "b": "x"},
+		},
+		{
+			"multi-line quoted scalars",
+			"a:\n  b: \"first line\n  # not a comment\n\n  paragraph\"\n  c: 'x\n  y'\nd: z\n",
+			map[string]interface{}{"a": map[string]interface{}{"b": "first line # not a comment\nparagraph", "c": "x y"}, "d": "z"},
+		},
+		{
+			"quoted keys and paths",
+			"\"200\":\n  description: ok\n/containers/{id}/json:\n  get: {}\n",
+			map[string]interface{}{"200": map[string]interface{}{"description": "ok"}, "/containers/{id}/json": map[string]interface{}{"get": map[string]interface{}{}}},
+		},
+	}
+	for _, tt := range tests {
+		value, err := parseYAML(tt.input)
+		if err != nil {
+			t.Errorf("parseYAML(%s): %v", tt.name, err)
+			continue
+		}
+		if got := plain(value); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseYAML(%s): wrong value.\nWant %#v.\nGot  %#v.", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestParseYAMLOrder(t *testing.T) {
+	t.Parallel()
+	value, err := parseYAML("z: 1\na: 2\nm: 3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"z", "a", "m"}
+	if keys := value.(*yamlMap).keys; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("parseYAML: wrong order. Want %#v. Got %#v.", expected, keys)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{
+		"a: [x, y\n",
+		"a: \"unterminated\n",
+		"a: 1\n  b: 2\n",
+	} {
+		if _, err := parseYAML(input); err == nil {
+			t.Errorf("parseYAML(%q): want an error. Got <nil>.", input)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/abrechon/go-dockerclient/internal/jsonmessage"
+)
+
+// Known IDs of aux messages.
+const (
+	// AuxBuildKitTrace is the ID of the aux messages with the progress of
+	// a BuildKit build.
+	AuxBuildKitTrace = "moby.buildkit.trace"
+
+	// AuxImageID is the ID of the aux message with the ID of the image
+	// built by BuildKit. The classic builder sends it without an ID.
+	AuxImageID = "moby.image.id"
+)
+
+// AuxMessage is an aux message of a JSON stream, as sent by the daemon, in
+// {"aux": {...}} messages, for the data meant for programs rather than for
+// display, e.g. the ID of the image built or the digest of the image pushed.
+//
+// The payload of the known messages is decoded by the typed methods, and
+// other payloads can be decoded with Decode.
+type AuxMessage struct {
+	// ID identifies the payload of some aux messages, e.g.
+	// AuxBuildKitTrace. It's empty for most of them.
+	ID string
+
+	// Raw is the payload of the message, undecoded.
+	Raw json.RawMessage
+}
+
+// Decode decodes the payload of the message into v.
+func (m AuxMessage) Decode(v interface{}) error {
+	return json.Unmarshal(m.Raw, v)
+}
+
+// ImageID returns the ID of the image built, sent at the end of builds, and
+// whether the message carries it.
+func (m AuxMessage) ImageID() (string, bool) {
+	if m.ID != "" && m.ID != AuxImageID {
+		return "", false
+	}
+	var aux struct{ ID string }
+	if err := m.Decode(&aux); err != nil || aux.ID == "" {
+		return "", false
+	}
+	return aux.ID, true
+}
+
+// PushResult returns the result of a push, sent at the end of pushes to
+// registries v2, and whether the message carries it.
+func (m AuxMessage) PushResult() (*PushResult, bool) {
+	if m.ID != "" {
+		return nil, false
+	}
+	var result PushResult
+	if err := m.Decode(&result); err != nil || result.Digest == "" {
+		return nil, false
+	}
+	return &result, true
+}
+
+// BuildKitTrace returns the progress of a BuildKit build, as a serialized
+// StatusResponse of the BuildKit control API (a protocol buffer), and
+// whether the message carries it.
+func (m AuxMessage) BuildKitTrace() ([]byte, bool) {
+	if m.ID != AuxBuildKitTrace {
+		return nil, false
+	}
+	var trace []byte
+	if err := m.Decode(&trace); err != nil {
+		return nil, false
+	}
+	return trace, true
+}
+
+// auxMessages returns a function passing the aux messages of a stream to fn.
+func auxMessages(fn func(AuxMessage)) func(*jsonmessage.JSONMessage) {
+	return func(msg *jsonmessage.JSONMessage) {
+		if msg.Aux != nil {
+			fn(AuxMessage{ID: msg.ID, Raw: *msg.Aux})
+		}
+	}
+}
+
+// jsonStreamTap is the writer of a raw JSON stream, as sent by the daemon
+// for pulls, pushes and builds, that hands each message to a function and
+// forwards the stream to an output, either raw or displayed like it's done
+// without RawJSONStream.
+type jsonStreamTap struct {
+	w    *io.PipeWriter
+	done chan error
+}
+
+func newJSONStreamTap(out io.Writer, raw bool, fn func(*jsonmessage.JSONMessage)) *jsonStreamTap {
+	if out == nil {
+		out = ioutil.Discard
+	}
+	r, w := io.Pipe()
+	tap := jsonStreamTap{w: w, done: make(chan error, 1)}
+	if raw {
+		go func() {
+			tap.done <- decodeJSONStream(io.TeeReader(r, out), r, fn)
+		}()
+		return &tap
+	}
+	displayR, displayW := io.Pipe()
+	displayErr := make(chan error, 1)
+	go func() {
+		var err error
+		if st, ok := out.(stream); ok {
+			err = jsonmessage.DisplayJSONMessagesToStream(displayR, st, nil)
+		} else {
+			err = jsonmessage.DisplayJSONMessagesStream(displayR, out, 0, false, nil)
+		}
+		// keep reading, so the stream isn't blocked when the display ends
+		// early, on the first error.
+		io.Copy(ioutil.Discard, displayR)
+		displayErr <- err
+	}()
+	go func() {
+		err := decodeJSONStream(io.TeeReader(r, displayW), r, fn)
+		displayW.Close()
+		if derr := <-displayErr; err == nil {
+			err = derr
+		}
+		tap.done <- err
+	}()
+	return &tap
+}
+
+// decodeJSONStream decodes the messages read from in, passing them to fn,
+// until the end of the stream, returning the first error reported in the
+// stream. Once the stream can't be decoded, the rest of r is discarded.
+func decodeJSONStream(in io.Reader, r *io.PipeReader, fn func(*jsonmessage.JSONMessage)) error {
+	var streamErr error
+	decoder := json.NewDecoder(in)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return streamErr
+			}
+			io.Copy(ioutil.Discard, r)
+			return err
+		}
+		if msg.Error != nil && streamErr == nil {
+			streamErr = msg.Error
+		}
+		fn(&msg)
+	}
+}
+
+func (t *jsonStreamTap) Write(p []byte) (int, error) {
+	return t.w.Write(p)
+}
+
+// Close ends the stream, returning once every message is handled, with the
+// first error reported by the daemon in the stream.
+func (t *jsonStreamTap) Close() error {
+	t.w.Close()
+	return <-t.done
+}
+
+// decodeJSONList decodes a JSON array read from r into the slice pointed to
+// by v, one element at a time, so only the element being decoded is
+// buffered, rather than the whole array like json.Decoder.Decode does.
+func decodeJSONList(r io.Reader, v interface{}) error {
+	slice := reflect.ValueOf(v).Elem()
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		slice.Set(reflect.Zero(slice.Type()))
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("json: cannot unmarshal %v into Go value of type %s", token, slice.Type())
+	}
+	list := reflect.MakeSlice(slice.Type(), 0, 0)
+	for decoder.More() {
+		elem := reflect.New(slice.Type().Elem())
+		if err := decoder.Decode(elem.Interface()); err != nil {
+			return err
+		}
+		list = reflect.Append(list, elem.Elem())
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	slice.Set(list)
+	return nil
+}
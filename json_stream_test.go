@@ -0,0 +1,172 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abrechon/go-dockerclient/internal/jsonmessage"
+)
+
+func TestJSONStreamTap(t *testing.T) {
+	t.Parallel()
+	stream := `{"status":"Downloading","id":"aaa"}{"stream":"Step 1/2"}` + "\n" + `{"aux":{"ID":"sha256:abc"}}` + "\n"
+	for _, raw := range []bool{false, true} {
+		var buf bytes.Buffer
+		var msgs []string
+		tap := newJSONStreamTap(&buf, raw, func(msg *jsonmessage.JSONMessage) {
+			msgs = append(msgs, msg.Status+msg.Stream)
+		})
+		// writes aren't aligned with the messages.
+		for i := 0; i < len(stream); i += 7 {
+			end := i + 7
+			if end > len(stream) {
+				end = len(stream)
+			}
+			if _, err := tap.Write([]byte(stream[i:end])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tap.Close(); err != nil {
+			t.Fatal(err)
+		}
+		expectedMsgs := []string{"Downloading", "Step 1/2", ""}
+		if !reflect.DeepEqual(msgs, expectedMsgs) {
+			t.Errorf("jsonStreamTap(raw=%v): wrong messages. Want %#v. Got %#v.", raw, expectedMsgs, msgs)
+		}
+		expectedOut := "aaa: Downloading\nStep 1/2"
+		if raw {
+			expectedOut = stream
+		}
+		if buf.String() != expectedOut {
+			t.Errorf("jsonStreamTap(raw=%v): wrong output.\nWant %q.\nGot  %q.", raw, expectedOut, buf.String())
+		}
+	}
+}
+
+func TestJSONStreamTapInvalidStream(t *testing.T) {
+	t.Parallel()
+	for _, raw := range []bool{false, true} {
+		tap := newJSONStreamTap(nil, raw, func(*jsonmessage.JSONMessage) {})
+		if _, err := tap.Write([]byte(`{"status":"ok"}not json{"status":"more"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tap.Write(bytes.Repeat([]byte("x"), 1<<16)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tap.Close(); err == nil {
+			t.Errorf("jsonStreamTap(raw=%v): unexpected <nil> error", raw)
+		}
+	}
+}
+
+func TestAuxMessage(t *testing.T) {
+	t.Parallel()
+	trace := []byte{0x0a, 0x12, 0x34}
+	tests := []struct {
+		msg       AuxMessage
+		imageID   string
+		push      *PushResult
+		traceData []byte
+	}{
+		{msg: AuxMessage{Raw: []byte(`{"ID":"sha256:abc"}`)}, imageID: "sha256:abc"},
+		{msg: AuxMessage{ID: AuxImageID, Raw: []byte(`{"ID":"sha256:abc"}`)}, imageID: "sha256:abc"},
+		{msg: AuxMessage{Raw: []byte(`{"Tag":"v1","Digest":"sha256:def","Size":528}`)}, push: &PushResult{Tag: "v1", Digest: "sha256:def", Size: 528}},
+		{msg: AuxMessage{ID: AuxBuildKitTrace, Raw: []byte(`"ChI0"`)}, traceData: trace},
+		{msg: AuxMessage{ID: "moby.other", Raw: []byte(`{"ID":"sha256:abc"}`)}},
+		{msg: AuxMessage{Raw: []byte(`"not an object"`)}},
+	}
+	for _, tt := range tests {
+		if id, ok := tt.msg.ImageID(); id != tt.imageID || ok != (tt.imageID != "") {
+			t.Errorf("ImageID(%s): want %q. Got %q (%v).", tt.msg.Raw, tt.imageID, id, ok)
+		}
+		if push, ok := tt.msg.PushResult(); !reflect.DeepEqual(push, tt.push) || ok != (tt.push != nil) {
+			t.Errorf("PushResult(%s): want %#v. Got %#v (%v).", tt.msg.Raw, tt.push, push, ok)
+		}
+		if data, ok := tt.msg.BuildKitTrace(); !bytes.Equal(data, tt.traceData) || ok != (tt.traceData != nil) {
+			t.Errorf("BuildKitTrace(%s): want %v. Got %v (%v).", tt.msg.Raw, tt.traceData, data, ok)
+		}
+	}
+	var payload map[string]interface{}
+	if err := tests[2].msg.Decode(&payload); err != nil || payload["Tag"] != "v1" {
+		t.Errorf("Decode: wrong payload %#v (%v)", payload, err)
+	}
+}
+
+func TestBuildImageAuxCallback(t *testing.T) {
+	t.Parallel()
+	stream := []string{
+		`{"stream":"Step 1/1 : FROM busybox"}`,
+		`{"id":"moby.buildkit.trace","aux":"ChI0"}`,
+		`{"aux":{"ID":"sha256:abc"}}`,
+		`{"stream":"Successfully built abc"}`,
+	}
+	client := NewClientFromHandler(newJSONStreamHandler("/build", stream...))
+	for _, raw := range []bool{false, true} {
+		var buf bytes.Buffer
+		var ids []string
+		var imageID string
+		err := client.BuildImage(BuildImageOptions{
+			Name:          "app",
+			Remote:        "github.com/app/app",
+			OutputStream:  &buf,
+			RawJSONStream: raw,
+			AuxCallback: func(aux AuxMessage) {
+				ids = append(ids, aux.ID)
+				if id, ok := aux.ImageID(); ok {
+					imageID = id
+				}
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedIDs := []string{AuxBuildKitTrace, ""}
+		if !reflect.DeepEqual(ids, expectedIDs) {
+			t.Errorf("BuildImage(raw=%v): wrong aux messages. Want %#v. Got %#v.", raw, expectedIDs, ids)
+		}
+		if imageID != "sha256:abc" {
+			t.Errorf("BuildImage(raw=%v): wrong image ID. Want %q. Got %q.", raw, "sha256:abc", imageID)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("Successfully built abc")) {
+			t.Errorf("BuildImage(raw=%v): wrong output: %q", raw, buf.String())
+		}
+	}
+}
+
+func TestDecodeJSONList(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input    string
+		expected []Network
+	}{
+		{`[{"Id":"a","Name":"bridge"},{"Id":"b","Labels":{"x":"y"}}]`, []Network{{ID: "a", Name: "bridge"}, {ID: "b", Labels: map[string]string{"x": "y"}}}},
+		{`[]`, []Network{}},
+		{`null`, nil},
+	}
+	for _, tt := range tests {
+		networks := []Network{{ID: "stale"}}
+		if err := decodeJSONList(strings.NewReader(tt.input), &networks); err != nil {
+			t.Errorf("decodeJSONList(%q): unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(networks, tt.expected) {
+			t.Errorf("decodeJSONList(%q): want %#v. Got %#v.", tt.input, tt.expected, networks)
+		}
+	}
+}
+
+func TestDecodeJSONListErrors(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{``, `{"Id":"a"}`, `[{"Id":"a"},`, `[{"Id":1}]`, `"networks"`} {
+		var networks []Network
+		if err := decodeJSONList(strings.NewReader(input), &networks); err == nil {
+			t.Errorf("decodeJSONList(%q): want a non-nil error. Got %#v.", input, networks)
+		}
+	}
+}
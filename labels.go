@@ -0,0 +1,197 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Object types supported by FindByLabel.
+const (
+	ObjectContainer = "container"
+	ObjectImage     = "image"
+	ObjectNetwork   = "network"
+	ObjectVolume    = "volume"
+	ObjectService   = "service"
+)
+
+// LabeledObject is the summary of an object returned by FindByLabel.
+type LabeledObject struct {
+	Type   string
+	ID     string
+	Name   string
+	Labels map[string]string
+}
+
+// LabelSelector is a parsed label selector. See ParseLabelSelector for the
+// syntax.
+type LabelSelector []LabelRequirement
+
+// LabelRequirement is a single requirement of a LabelSelector.
+type LabelRequirement struct {
+	Key   string
+	Value string
+
+	// HasValue indicates whether the requirement is about the value of the
+	// label (k=v or k!=v), instead of its presence (k).
+	HasValue bool
+
+	// Negate indicates a k!=v requirement.
+	Negate bool
+}
+
+// ParseLabelSelector parses a comma-separated list of label requirements.
+// Each requirement can be in the form "key" (the label is present),
+// "key=value" (the label has the given value) or "key!=value" (the label is
+// absent or has a different value).
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var s LabelSelector
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var req LabelRequirement
+		if i := strings.Index(part, "!="); i > -1 {
+			req = LabelRequirement{Key: part[:i], Value: part[i+2:], HasValue: true, Negate: true}
+		} else if i := strings.Index(part, "="); i > -1 {
+			req = LabelRequirement{Key: part[:i], Value: part[i+1:], HasValue: true}
+		} else {
+			req = LabelRequirement{Key: part}
+		}
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if req.Key == "" {
+			return nil, fmt.Errorf("invalid label selector %q: empty key", part)
+		}
+		s = append(s, req)
+	}
+	return s, nil
+}
+
+// Matches returns whether the given labels satisfy all the requirements of
+// the selector.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.Key]
+		switch {
+		case req.Negate:
+			if ok && value == req.Value {
+				return false
+			}
+		case req.HasValue:
+			if !ok || value != req.Value {
+				return false
+			}
+		default:
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// filters returns the "label" filters understood by the daemon. Negated
+// requirements can't be expressed in list filters, so they're left to
+// Matches.
+func (s LabelSelector) filters() []string {
+	var filters []string
+	for _, req := range s {
+		switch {
+		case req.Negate:
+		case req.HasValue:
+			filters = append(filters, req.Key+"="+req.Value)
+		default:
+			filters = append(filters, req.Key)
+		}
+	}
+	return filters
+}
+
+// FindByLabel returns the objects of the given type (one of ObjectContainer,
+// ObjectImage, ObjectNetwork, ObjectVolume or ObjectService) whose labels
+// match the given selector. See ParseLabelSelector for the syntax of the
+// selector.
+//
+// All containers are considered, including the ones that are not running.
+func (c *Client) FindByLabel(objectType, selector string) ([]LabeledObject, error) {
+	s, err := ParseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	var filters map[string][]string
+	if labels := s.filters(); len(labels) > 0 {
+		filters = map[string][]string{"label": labels}
+	}
+	var objects []LabeledObject
+	switch objectType {
+	case ObjectContainer:
+		containers, err := c.ListContainers(ListContainersOptions{All: true, Filters: filters})
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range containers {
+			var name string
+			if len(container.Names) > 0 {
+				name = strings.TrimPrefix(container.Names[0], "/")
+			}
+			objects = append(objects, LabeledObject{Type: objectType, ID: container.ID, Name: name, Labels: container.Labels})
+		}
+	case ObjectImage:
+		images, err := c.ListImages(ListImagesOptions{Filters: filters})
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range images {
+			var name string
+			if len(image.RepoTags) > 0 {
+				name = image.RepoTags[0]
+			}
+			objects = append(objects, LabeledObject{Type: objectType, ID: image.ID, Name: name, Labels: image.Labels})
+		}
+	case ObjectNetwork:
+		opts := NetworkFilterOpts{}
+		if labels := s.filters(); len(labels) > 0 {
+			opts["label"] = make(map[string]bool, len(labels))
+			for _, label := range labels {
+				opts["label"][label] = true
+			}
+		}
+		networks, err := c.FilteredListNetworks(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, network := range networks {
+			objects = append(objects, LabeledObject{Type: objectType, ID: network.ID, Name: network.Name, Labels: network.Labels})
+		}
+	case ObjectVolume:
+		volumes, err := c.ListVolumes(ListVolumesOptions{Filters: filters})
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range volumes {
+			objects = append(objects, LabeledObject{Type: objectType, ID: volume.Name, Name: volume.Name, Labels: volume.Labels})
+		}
+	case ObjectService:
+		services, err := c.ListServices(ListServicesOptions{Filters: filters})
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range services {
+			objects = append(objects, LabeledObject{Type: objectType, ID: service.ID, Name: service.Spec.Name, Labels: service.Spec.Labels})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported object type %q", objectType)
+	}
+	result := objects[:0]
+	for _, object := range objects {
+		if s.Matches(object.Labels) {
+			result = append(result, object)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	t.Parallel()
+	selector, err := ParseLabelSelector("app, env=prod,tier!=db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := LabelSelector{
+		{Key: "app"},
+		{Key: "env", Value: "prod", HasValue: true},
+		{Key: "tier", Value: "db", HasValue: true, Negate: true},
+	}
+	if !reflect.DeepEqual(selector, expected) {
+		t.Errorf("ParseLabelSelector: wrong result.\nWant %#v.\nGot %#v.", expected, selector)
+	}
+	if _, err := ParseLabelSelector("=value"); err == nil {
+		t.Error("ParseLabelSelector: unexpected <nil> error for empty key")
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	t.Parallel()
+	selector, _ := ParseLabelSelector("app,env=prod,tier!=db")
+	tests := []struct {
+		labels   map[string]string
+		expected bool
+	}{
+		{map[string]string{"app": "web", "env": "prod"}, true},
+		{map[string]string{"app": "web", "env": "prod", "tier": "frontend"}, true},
+		{map[string]string{"app": "web", "env": "prod", "tier": "db"}, false},
+		{map[string]string{"app": "web", "env": "dev"}, false},
+		{map[string]string{"env": "prod"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := selector.Matches(tt.labels); got != tt.expected {
+			t.Errorf("Matches(%v): want %v. Got %v.", tt.labels, tt.expected, got)
+		}
+	}
+}
+
+func TestFindByLabelContainers(t *testing.T) {
+	t.Parallel()
+	body := `[
+     {"Id": "8dfafdbc3a40", "Names": ["/web"], "Labels": {"app": "web", "env": "prod"}},
+     {"Id": "9cd87474be90", "Names": ["/db"], "Labels": {"app": "web", "env": "prod", "tier": "db"}}
+]`
+	fakeRT := &FakeRoundTripper{message: body, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	objects, err := client.FindByLabel(ObjectContainer, "app,env=prod,tier!=db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []LabeledObject{
+		{Type: ObjectContainer, ID: "8dfafdbc3a40", Name: "web", Labels: map[string]string{"app": "web", "env": "prod"}},
+	}
+	if !reflect.DeepEqual(objects, expected) {
+		t.Errorf("FindByLabel: wrong result.\nWant %#v.\nGot %#v.", expected, objects)
+	}
+	req := fakeRT.requests[0]
+	if req.URL.Path != "/containers/json" {
+		t.Errorf("FindByLabel: wrong request path. Want %q. Got %q.", "/containers/json", req.URL.Path)
+	}
+	expectedFilters := `{"label":["app","env=prod"]}`
+	if filters := req.URL.Query().Get("filters"); filters != expectedFilters {
+		t.Errorf("FindByLabel: wrong filters. Want %q. Got %q.", expectedFilters, filters)
+	}
+	if all := req.URL.Query().Get("all"); all != "1" {
+		t.Errorf("FindByLabel: expected all containers to be listed, got all=%q", all)
+	}
+}
+
+func TestFindByLabelVolumes(t *testing.T) {
+	t.Parallel()
+	body := `{"Volumes": [{"Name": "data", "Labels": {"app": "web"}}]}`
+	client := newTestClient(&FakeRoundTripper{message: body, status: http.StatusOK})
+	objects, err := client.FindByLabel(ObjectVolume, "app=web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []LabeledObject{
+		{Type: ObjectVolume, ID: "data", Name: "data", Labels: map[string]string{"app": "web"}},
+	}
+	if !reflect.DeepEqual(objects, expected) {
+		t.Errorf("FindByLabel: wrong result.\nWant %#v.\nGot %#v.", expected, objects)
+	}
+}
+
+func TestFindByLabelUnsupportedType(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{status: http.StatusOK})
+	if _, err := client.FindByLabel("plugin", "app"); err == nil {
+		t.Error("FindByLabel: unexpected <nil> error for unsupported type")
+	}
+}
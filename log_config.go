@@ -0,0 +1,455 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	units "github.com/docker/go-units"
+)
+
+// LogDriverOptions is implemented by the typed options of the logging
+// drivers, which build the LogConfig of a container:
+//
+//	logConfig, err := JSONFileLogOptions{MaxSize: "10m", MaxFile: 3}.LogConfig()
+type LogDriverOptions interface {
+	LogConfig() (LogConfig, error)
+}
+
+// JSONFileLogOptions are the options of the json-file logging driver.
+type JSONFileLogOptions struct {
+	// MaxSize is the maximum size of the log file before it's rotated, e.g.
+	// "10m". MaxFile is the maximum number of log files kept, which can
+	// only be greater than one when MaxSize is set.
+	MaxSize  string
+	MaxFile  int
+	Compress bool
+
+	Labels []string
+	Env    []string
+	Tag    string
+}
+
+// LogConfig returns the LogConfig of the json-file driver.
+func (o JSONFileLogOptions) LogConfig() (LogConfig, error) {
+	config := make(map[string]string)
+	setLogOption(config, "max-size", o.MaxSize)
+	if o.MaxFile != 0 {
+		config["max-file"] = strconv.Itoa(o.MaxFile)
+	}
+	if o.Compress {
+		config["compress"] = "true"
+	}
+	setLogOption(config, "labels", strings.Join(o.Labels, ","))
+	setLogOption(config, "env", strings.Join(o.Env, ","))
+	setLogOption(config, "tag", o.Tag)
+	return newLogConfig("json-file", config)
+}
+
+// SyslogOptions are the options of the syslog logging driver.
+type SyslogOptions struct {
+	// Address of the syslog server, e.g. "udp://1.2.3.4:514" or
+	// "unix:///dev/log". Defaults to the local syslog daemon.
+	Address  string
+	Facility string
+
+	// Format is "rfc5424", "rfc5424micro" or "rfc3164".
+	Format string
+
+	TLSCACert     string
+	TLSCert       string
+	TLSKey        string
+	TLSSkipVerify bool
+
+	Labels []string
+	Env    []string
+	Tag    string
+}
+
+// LogConfig returns the LogConfig of the syslog driver.
+func (o SyslogOptions) LogConfig() (LogConfig, error) {
+	config := make(map[string]string)
+	setLogOption(config, "syslog-address", o.Address)
+	setLogOption(config, "syslog-facility", o.Facility)
+	setLogOption(config, "syslog-format", o.Format)
+	setLogOption(config, "syslog-tls-ca-cert", o.TLSCACert)
+	setLogOption(config, "syslog-tls-cert", o.TLSCert)
+	setLogOption(config, "syslog-tls-key", o.TLSKey)
+	if o.TLSSkipVerify {
+		config["syslog-tls-skip-verify"] = "true"
+	}
+	setLogOption(config, "labels", strings.Join(o.Labels, ","))
+	setLogOption(config, "env", strings.Join(o.Env, ","))
+	setLogOption(config, "tag", o.Tag)
+	return newLogConfig("syslog", config)
+}
+
+// FluentdOptions are the options of the fluentd logging driver.
+type FluentdOptions struct {
+	// Address of the fluentd daemon, e.g. "localhost:24224" or
+	// "unix:///var/run/fluentd.sock". Defaults to localhost:24224.
+	Address string
+
+	// Async makes the container send its logs in the background, without
+	// blocking when fluentd is unavailable.
+	Async              bool
+	BufferLimit        int
+	RetryWait          time.Duration
+	MaxRetries         int
+	SubSecondPrecision bool
+
+	Labels []string
+	Env    []string
+	Tag    string
+}
+
+// LogConfig returns the LogConfig of the fluentd driver.
+func (o FluentdOptions) LogConfig() (LogConfig, error) {
+	config := make(map[string]string)
+	setLogOption(config, "fluentd-address", o.Address)
+	if o.Async {
+		config["fluentd-async"] = "true"
+	}
+	if o.BufferLimit != 0 {
+		config["fluentd-buffer-limit"] = strconv.Itoa(o.BufferLimit)
+	}
+	if o.RetryWait != 0 {
+		config["fluentd-retry-wait"] = o.RetryWait.String()
+	}
+	if o.MaxRetries != 0 {
+		config["fluentd-max-retries"] = strconv.Itoa(o.MaxRetries)
+	}
+	if o.SubSecondPrecision {
+		config["fluentd-sub-second-precision"] = "true"
+	}
+	setLogOption(config, "labels", strings.Join(o.Labels, ","))
+	setLogOption(config, "env", strings.Join(o.Env, ","))
+	setLogOption(config, "tag", o.Tag)
+	return newLogConfig("fluentd", config)
+}
+
+// AWSLogsOptions are the options of the awslogs logging driver, which sends
+// the logs to Amazon CloudWatch Logs.
+type AWSLogsOptions struct {
+	Region string
+
+	// Group is the log group, which is required. It's created when
+	// CreateGroup is true. Stream defaults to the ID of the container.
+	Group       string
+	Stream      string
+	CreateGroup bool
+
+	// DatetimeFormat and MultilinePattern, which are mutually exclusive,
+	// delimit multiline messages.
+	DatetimeFormat   string
+	MultilinePattern string
+
+	// CredentialsEndpoint is the path of the credentials endpoint, relative
+	// to 169.254.170.2, used on ECS.
+	CredentialsEndpoint string
+
+	Tag string
+}
+
+// LogConfig returns the LogConfig of the awslogs driver.
+func (o AWSLogsOptions) LogConfig() (LogConfig, error) {
+	config := make(map[string]string)
+	setLogOption(config, "awslogs-region", o.Region)
+	setLogOption(config, "awslogs-group", o.Group)
+	setLogOption(config, "awslogs-stream", o.Stream)
+	if o.CreateGroup {
+		config["awslogs-create-group"] = "true"
+	}
+	setLogOption(config, "awslogs-datetime-format", o.DatetimeFormat)
+	setLogOption(config, "awslogs-multiline-pattern", o.MultilinePattern)
+	setLogOption(config, "awslogs-credentials-endpoint", o.CredentialsEndpoint)
+	setLogOption(config, "tag", o.Tag)
+	return newLogConfig("awslogs", config)
+}
+
+// GELFOptions are the options of the gelf logging driver, which sends the
+// logs to Graylog or Logstash.
+type GELFOptions struct {
+	// Address of the GELF server, e.g. "udp://1.2.3.4:12201", which is
+	// required.
+	Address string
+
+	// CompressionType is "gzip", "zlib" or "none", for UDP addresses.
+	CompressionType string
+
+	Labels []string
+	Env    []string
+	Tag    string
+}
+
+// LogConfig returns the LogConfig of the gelf driver.
+func (o GELFOptions) LogConfig() (LogConfig, error) {
+	config := make(map[string]string)
+	setLogOption(config, "gelf-address", o.Address)
+	setLogOption(config, "gelf-compression-type", o.CompressionType)
+	setLogOption(config, "labels", strings.Join(o.Labels, ","))
+	setLogOption(config, "env", strings.Join(o.Env, ","))
+	setLogOption(config, "tag", o.Tag)
+	return newLogConfig("gelf", config)
+}
+
+func setLogOption(config map[string]string, key, value string) {
+	if value != "" {
+		config[key] = value
+	}
+}
+
+func newLogConfig(driver string, config map[string]string) (LogConfig, error) {
+	logConfig := LogConfig{Type: driver}
+	if len(config) > 0 {
+		logConfig.Config = config
+	}
+	return logConfig, ValidateLogConfig(logConfig)
+}
+
+// logDriverValidation describes the options of a logging driver.
+type logDriverValidation struct {
+	options  []string
+	required []string
+	validate func(config map[string]string) error
+}
+
+// logOptions are the options supported by every driver, handled by the
+// daemon, including the ones of the cache that keeps the logs readable.
+var logOptions = []string{
+	"mode", "max-buffer-size",
+	"cache-disabled", "cache-max-size", "cache-max-file", "cache-compress",
+}
+
+// logAttributesOptions are the options of the drivers that can add the
+// labels and environment variables of the container to the messages.
+var logAttributesOptions = []string{"labels", "labels-regex", "env", "env-regex", "tag"}
+
+var logDrivers = map[string]logDriverValidation{
+	"none": {},
+	"json-file": {
+		options:  append([]string{"max-size", "max-file", "compress"}, logAttributesOptions...),
+		validate: validateJSONFileLogConfig,
+	},
+	"local": {
+		options:  []string{"max-size", "max-file", "compress"},
+		validate: validateJSONFileLogConfig,
+	},
+	"syslog": {
+		options: append([]string{
+			"syslog-address", "syslog-facility", "syslog-format", "syslog-tls-ca-cert",
+			"syslog-tls-cert", "syslog-tls-key", "syslog-tls-skip-verify",
+		}, logAttributesOptions...),
+		validate: validateSyslogLogConfig,
+	},
+	"journald": {
+		options: logAttributesOptions,
+	},
+	"fluentd": {
+		options: append([]string{
+			"fluentd-address", "fluentd-async", "fluentd-async-connect", "fluentd-buffer-limit",
+			"fluentd-retry-wait", "fluentd-max-retries", "fluentd-sub-second-precision",
+			"fluentd-request-ack",
+		}, logAttributesOptions...),
+		validate: validateFluentdLogConfig,
+	},
+	"awslogs": {
+		options: []string{
+			"awslogs-region", "awslogs-endpoint", "awslogs-group", "awslogs-stream",
+			"awslogs-create-group", "awslogs-datetime-format", "awslogs-multiline-pattern",
+			"awslogs-credentials-endpoint", "awslogs-force-flush-interval-seconds",
+			"awslogs-max-buffered-events", "awslogs-format", "tag",
+		},
+		required: []string{"awslogs-group"},
+		validate: validateAWSLogsLogConfig,
+	},
+	"gelf": {
+		options: append([]string{
+			"gelf-address", "gelf-compression-type", "gelf-compression-level",
+			"gelf-tcp-max-reconnect", "gelf-tcp-reconnect-delay",
+		}, logAttributesOptions...),
+		required: []string{"gelf-address"},
+		validate: validateGELFLogConfig,
+	},
+	"splunk": {
+		options: append([]string{
+			"splunk-token", "splunk-url", "splunk-source", "splunk-sourcetype",
+			"splunk-index", "splunk-capath", "splunk-caname", "splunk-insecureskipverify",
+			"splunk-format", "splunk-verify-connection", "splunk-gzip",
+			"splunk-gzip-level", "splunk-index-acknowledgment",
+		}, logAttributesOptions...),
+		required: []string{"splunk-token", "splunk-url"},
+	},
+}
+
+// ValidateLogConfig checks the configuration of a logging driver before it's
+// sent to the daemon: that the options are supported by the driver, that the
+// required ones are set and that the values are well-formed. The options of
+// unknown drivers, like the ones provided by plugins, are not checked.
+func ValidateLogConfig(logConfig LogConfig) error {
+	driver, ok := logDrivers[logConfig.Type]
+	if !ok {
+		return nil
+	}
+	var unknown []string
+	for key := range logConfig.Config {
+		if !containsString(driver.options, key) && !containsString(logOptions, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("log driver %s: unknown log opts %s", logConfig.Type, strings.Join(unknown, ", "))
+	}
+	for _, key := range driver.required {
+		if logConfig.Config[key] == "" {
+			return fmt.Errorf("log driver %s: %s is required", logConfig.Type, key)
+		}
+	}
+	if err := validateLogMode(logConfig.Config); err != nil {
+		return fmt.Errorf("log driver %s: %s", logConfig.Type, err)
+	}
+	if driver.validate != nil {
+		if err := driver.validate(logConfig.Config); err != nil {
+			return fmt.Errorf("log driver %s: %s", logConfig.Type, err)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateLogMode(config map[string]string) error {
+	switch mode := config["mode"]; mode {
+	case "", "blocking":
+		if config["max-buffer-size"] != "" {
+			return fmt.Errorf("max-buffer-size requires mode non-blocking")
+		}
+	case "non-blocking":
+	default:
+		return fmt.Errorf("invalid mode %q", mode)
+	}
+	if size := config["max-buffer-size"]; size != "" {
+		if _, err := units.RAMInBytes(size); err != nil {
+			return fmt.Errorf("invalid max-buffer-size %q", size)
+		}
+	}
+	return nil
+}
+
+func validateJSONFileLogConfig(config map[string]string) error {
+	if size := config["max-size"]; size != "" {
+		if _, err := units.RAMInBytes(size); err != nil {
+			return fmt.Errorf("invalid max-size %q", size)
+		}
+	}
+	if value := config["max-file"]; value != "" {
+		files, err := strconv.Atoi(value)
+		if err != nil || files < 1 {
+			return fmt.Errorf("invalid max-file %q: must be a positive number", value)
+		}
+		if files > 1 && config["max-size"] == "" {
+			return fmt.Errorf("max-file can only be set if max-size is set")
+		}
+	}
+	return validateLogBool(config, "compress")
+}
+
+func validateSyslogLogConfig(config map[string]string) error {
+	if address := config["syslog-address"]; address != "" {
+		if err := validateLogAddress(address, "tcp", "udp", "tcp+tls", "unix", "unixgram"); err != nil {
+			return fmt.Errorf("invalid syslog-address %q: %s", address, err)
+		}
+	}
+	switch format := config["syslog-format"]; format {
+	case "", "rfc5424", "rfc5424micro", "rfc3164":
+	default:
+		return fmt.Errorf("invalid syslog-format %q", format)
+	}
+	return validateLogBool(config, "syslog-tls-skip-verify")
+}
+
+func validateFluentdLogConfig(config map[string]string) error {
+	if address := config["fluentd-address"]; address != "" && strings.Contains(address, "://") {
+		if err := validateLogAddress(address, "tcp", "unix"); err != nil {
+			return fmt.Errorf("invalid fluentd-address %q: %s", address, err)
+		}
+	}
+	for _, key := range []string{"fluentd-buffer-limit", "fluentd-max-retries"} {
+		if value := config[key]; value != "" {
+			if n, err := strconv.Atoi(value); err != nil || n < 0 {
+				return fmt.Errorf("invalid %s %q", key, value)
+			}
+		}
+	}
+	if value := config["fluentd-retry-wait"]; value != "" {
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid fluentd-retry-wait %q", value)
+		}
+	}
+	for _, key := range []string{"fluentd-async", "fluentd-async-connect", "fluentd-sub-second-precision", "fluentd-request-ack"} {
+		if err := validateLogBool(config, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAWSLogsLogConfig(config map[string]string) error {
+	if config["awslogs-datetime-format"] != "" && config["awslogs-multiline-pattern"] != "" {
+		return fmt.Errorf("awslogs-datetime-format and awslogs-multiline-pattern are mutually exclusive")
+	}
+	return validateLogBool(config, "awslogs-create-group")
+}
+
+func validateGELFLogConfig(config map[string]string) error {
+	address := config["gelf-address"]
+	if err := validateLogAddress(address, "udp", "tcp"); err != nil {
+		return fmt.Errorf("invalid gelf-address %q: %s", address, err)
+	}
+	switch compression := config["gelf-compression-type"]; compression {
+	case "", "gzip", "zlib", "none":
+	default:
+		return fmt.Errorf("invalid gelf-compression-type %q", compression)
+	}
+	return nil
+}
+
+// validateLogAddress checks that the address is a URL with one of the given
+// schemes.
+func validateLogAddress(address string, schemes ...string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+	if !containsString(schemes, u.Scheme) {
+		return fmt.Errorf("the scheme must be one of %s", strings.Join(schemes, ", "))
+	}
+	if u.Scheme != "unix" && u.Scheme != "unixgram" && u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+func validateLogBool(config map[string]string, key string) error {
+	if value := config[key]; value != "" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid %s %q", key, value)
+		}
+	}
+	return nil
+}
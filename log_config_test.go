@@ -0,0 +1,128 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLogDriverOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		opts     LogDriverOptions
+		expected LogConfig
+	}{
+		{
+			JSONFileLogOptions{MaxSize: "10m", MaxFile: 3, Compress: true, Labels: []string{"app", "env"}},
+			LogConfig{Type: "json-file", Config: map[string]string{"max-size": "10m", "max-file": "3", "compress": "true", "labels": "app,env"}},
+		},
+		{
+			JSONFileLogOptions{},
+			LogConfig{Type: "json-file"},
+		},
+		{
+			SyslogOptions{Address: "tcp+tls://logs.example.com:6514", Format: "rfc5424", TLSSkipVerify: true, Tag: "{{.Name}}"},
+			LogConfig{Type: "syslog", Config: map[string]string{
+				"syslog-address":         "tcp+tls://logs.example.com:6514",
+				"syslog-format":          "rfc5424",
+				"syslog-tls-skip-verify": "true",
+				"tag":                    "{{.Name}}",
+			}},
+		},
+		{
+			FluentdOptions{Address: "fluentd:24224", Async: true, RetryWait: 2 * time.Second, MaxRetries: 10},
+			LogConfig{Type: "fluentd", Config: map[string]string{
+				"fluentd-address":     "fluentd:24224",
+				"fluentd-async":       "true",
+				"fluentd-retry-wait":  "2s",
+				"fluentd-max-retries": "10",
+			}},
+		},
+		{
+			AWSLogsOptions{Region: "us-east-1", Group: "web", CreateGroup: true},
+			LogConfig{Type: "awslogs", Config: map[string]string{
+				"awslogs-region":       "us-east-1",
+				"awslogs-group":        "web",
+				"awslogs-create-group": "true",
+			}},
+		},
+		{
+			GELFOptions{Address: "udp://graylog:12201", CompressionType: "gzip"},
+			LogConfig{Type: "gelf", Config: map[string]string{
+				"gelf-address":          "udp://graylog:12201",
+				"gelf-compression-type": "gzip",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		logConfig, err := tt.opts.LogConfig()
+		if err != nil {
+			t.Errorf("%#v: unexpected error: %v", tt.opts, err)
+			continue
+		}
+		if !reflect.DeepEqual(logConfig, tt.expected) {
+			t.Errorf("%#v: wrong LogConfig. Want %#v. Got %#v.", tt.opts, tt.expected, logConfig)
+		}
+	}
+}
+
+func TestLogDriverOptionsInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		opts     LogDriverOptions
+		expected string
+	}{
+		{JSONFileLogOptions{MaxFile: 3}, "log driver json-file: max-file can only be set if max-size is set"},
+		{JSONFileLogOptions{MaxSize: "ten", MaxFile: 3}, `log driver json-file: invalid max-size "ten"`},
+		{JSONFileLogOptions{MaxSize: "10m", MaxFile: -1}, `log driver json-file: invalid max-file "-1": must be a positive number`},
+		{SyslogOptions{Address: "http://logs"}, `log driver syslog: invalid syslog-address "http://logs": the scheme must be one of tcp, udp, tcp+tls, unix, unixgram`},
+		{SyslogOptions{Format: "json"}, `log driver syslog: invalid syslog-format "json"`},
+		{FluentdOptions{Address: "udp://fluentd:24224"}, `log driver fluentd: invalid fluentd-address "udp://fluentd:24224": the scheme must be one of tcp, unix`},
+		{AWSLogsOptions{Region: "us-east-1"}, "log driver awslogs: awslogs-group is required"},
+		{AWSLogsOptions{Group: "web", DatetimeFormat: "%Y-%m-%d", MultilinePattern: "^INFO"}, "log driver awslogs: awslogs-datetime-format and awslogs-multiline-pattern are mutually exclusive"},
+		{GELFOptions{}, "log driver gelf: gelf-address is required"},
+		{GELFOptions{Address: "udp://"}, `log driver gelf: invalid gelf-address "udp://": missing host`},
+		{GELFOptions{Address: "udp://graylog:12201", CompressionType: "lz4"}, `log driver gelf: invalid gelf-compression-type "lz4"`},
+	}
+	for _, tt := range tests {
+		_, err := tt.opts.LogConfig()
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%#v: wrong error. Want %q. Got %v.", tt.opts, tt.expected, err)
+		}
+	}
+}
+
+func TestValidateLogConfig(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		logConfig LogConfig
+		expected  string
+	}{
+		{LogConfig{}, ""},
+		{LogConfig{Type: "none"}, ""},
+		{LogConfig{Type: "journald", Config: map[string]string{"tag": "web", "mode": "non-blocking", "max-buffer-size": "4m"}}, ""},
+		{LogConfig{Type: "loki", Config: map[string]string{"loki-url": "http://loki:3100"}}, ""},
+		{LogConfig{Type: "splunk", Config: map[string]string{"splunk-url": "https://splunk:8088", "splunk-token": "secret"}}, ""},
+		{LogConfig{Type: "splunk", Config: map[string]string{"splunk-url": "https://splunk:8088"}}, "log driver splunk: splunk-token is required"},
+		{LogConfig{Type: "json-file", Config: map[string]string{"max-size": "10m", "maxfile": "3", "rotate": "1"}}, "log driver json-file: unknown log opts maxfile, rotate"},
+		{LogConfig{Type: "local", Config: map[string]string{"compress": "yes"}}, `log driver local: invalid compress "yes"`},
+		{LogConfig{Type: "json-file", Config: map[string]string{"mode": "async"}}, `log driver json-file: invalid mode "async"`},
+		{LogConfig{Type: "json-file", Config: map[string]string{"max-buffer-size": "4m"}}, "log driver json-file: max-buffer-size requires mode non-blocking"},
+	}
+	for _, tt := range tests {
+		err := ValidateLogConfig(tt.logConfig)
+		if tt.expected == "" {
+			if err != nil {
+				t.Errorf("ValidateLogConfig(%#v): unexpected error: %v", tt.logConfig, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("ValidateLogConfig(%#v): wrong error. Want %q. Got %v.", tt.logConfig, tt.expected, err)
+		}
+	}
+}
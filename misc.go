@@ -92,6 +92,8 @@ type DockerInfo struct {
 	Debug              bool
 	OomKillDisable     bool
 	ExperimentalBuild  bool
+	CgroupVersion      string
+	CDISpecDirs        []string `json:"CDISpecDirs"`
 }
 
 // Runtime describes an OCI runtime
@@ -100,6 +102,12 @@ type DockerInfo struct {
 type Runtime struct {
 	Path string
 	Args []string `json:"runtimeArgs"`
+
+	// Type is the containerd runtime type, e.g. "io.containerd.runsc.v1",
+	// and Options are the options passed to it, as configured in the daemon
+	// (API 1.44+).
+	Type    string                 `json:"runtimeType,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
 }
 
 // PluginsInfo is a struct with the plugins registered with the docker daemon
@@ -174,9 +182,55 @@ func (c *Client) Info() (*DockerInfo, error) {
 	return &info, nil
 }
 
+// IsRootless returns whether the Docker daemon is running in rootless mode,
+// as reported in the security options of the Info endpoint.
+func (c *Client) IsRootless() (bool, error) {
+	info, err := c.Info()
+	if err != nil {
+		return false, err
+	}
+	return info.hasSecurityOption("rootless"), nil
+}
+
+// UserNSRemapActive returns whether the Docker daemon has user namespace
+// remapping enabled (dockerd --userns-remap), as reported in the security
+// options of the Info endpoint.
+func (c *Client) UserNSRemapActive() (bool, error) {
+	info, err := c.Info()
+	if err != nil {
+		return false, err
+	}
+	return info.hasSecurityOption("userns"), nil
+}
+
+// AvailableRuntimes returns the OCI runtimes configured in the daemon, by
+// name, and the name of the default one. The names are the values accepted in
+// HostConfig.Runtime.
+func (c *Client) AvailableRuntimes() (map[string]Runtime, string, error) {
+	info, err := c.Info()
+	if err != nil {
+		return nil, "", err
+	}
+	return info.Runtimes, info.DefaultRuntime, nil
+}
+
+// hasSecurityOption checks whether the given option is present in the list of
+// security options. It understands both the legacy format ("seccomp") and the
+// key/value format used since API 1.30 ("name=seccomp,profile=default").
+func (info *DockerInfo) hasSecurityOption(name string) bool {
+	for _, opt := range info.SecurityOptions {
+		for _, field := range strings.Split(opt, ",") {
+			if field == name || field == "name="+name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ParseRepositoryTag gets the name of the repository and returns it splitted
 // in two parts: the repository and the tag. It ignores the digest when it is
-// present.
+// present. Valid references are parsed with the reference package.
 //
 // Some examples:
 //
@@ -184,6 +238,9 @@ func (c *Client) Info() (*DockerInfo, error) {
 //     localhost.localdomain:5000/samalba/hipache -> localhost.localdomain:5000/samalba/hipache, ""
 //     busybox:latest@sha256:4a731fb46adc5cefe3ae374a8b6020fc1b6ad667a279647766e9a3cd89f6fa92 -> busybox, latest
 func ParseRepositoryTag(repoTag string) (repository string, tag string) {
+	if repository, tag, _, ok := splitReference(repoTag); ok {
+		return repository, tag
+	}
 	parts := strings.SplitN(repoTag, "@", 2)
 	repoTag = parts[0]
 	n := strings.LastIndex(repoTag, ":")
@@ -182,6 +182,85 @@ func TestInfoError(t *testing.T) {
 	}
 }
 
+func TestIsRootless(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		body     string
+		rootless bool
+		userns   bool
+	}{
+		{`{"SecurityOptions":["name=seccomp,profile=default","name=rootless"]}`, true, false},
+		{`{"SecurityOptions":["name=apparmor","name=userns"]}`, false, true},
+		{`{"SecurityOptions":["apparmor","seccomp"]}`, false, false},
+		{`{}`, false, false},
+	}
+	for _, tt := range tests {
+		client := newTestClient(&FakeRoundTripper{message: tt.body, status: http.StatusOK})
+		rootless, err := client.IsRootless()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rootless != tt.rootless {
+			t.Errorf("IsRootless(%s): want %v. Got %v.", tt.body, tt.rootless, rootless)
+		}
+		userns, err := client.UserNSRemapActive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if userns != tt.userns {
+			t.Errorf("UserNSRemapActive(%s): want %v. Got %v.", tt.body, tt.userns, userns)
+		}
+	}
+}
+
+func TestIsRootlessError(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "internal error", status: http.StatusInternalServerError})
+	if _, err := client.IsRootless(); err == nil {
+		t.Error("IsRootless(): unexpected <nil> error")
+	}
+}
+
+func TestAvailableRuntimes(t *testing.T) {
+	t.Parallel()
+	body := `{
+	 "DefaultRuntime": "runc",
+	 "Runtimes": {
+		"runc": {"path": "runc"},
+		"runsc": {
+		  "runtimeType": "io.containerd.runsc.v1",
+		  "options": {"TypeUrl": "io.containerd.runsc.v1.options", "ConfigPath": "/etc/runsc.toml"}
+		}
+	  }
+}`
+	client := newTestClient(&FakeRoundTripper{message: body, status: http.StatusOK})
+	runtimes, defaultRuntime, err := client.AvailableRuntimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaultRuntime != "runc" {
+		t.Errorf("AvailableRuntimes: wrong default runtime. Want %q. Got %q.", "runc", defaultRuntime)
+	}
+	expected := map[string]Runtime{
+		"runc": {Path: "runc"},
+		"runsc": {
+			Type:    "io.containerd.runsc.v1",
+			Options: map[string]interface{}{"TypeUrl": "io.containerd.runsc.v1.options", "ConfigPath": "/etc/runsc.toml"},
+		},
+	}
+	if !reflect.DeepEqual(runtimes, expected) {
+		t.Errorf("AvailableRuntimes: wrong runtimes.\nWant %#v.\nGot %#v.", expected, runtimes)
+	}
+}
+
+func TestAvailableRuntimesError(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "internal error", status: http.StatusInternalServerError})
+	if _, _, err := client.AvailableRuntimes(); err == nil {
+		t.Error("AvailableRuntimes(): unexpected <nil> error")
+	}
+}
+
 func TestParseRepositoryTag(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -199,6 +278,16 @@ func TestParseRepositoryTag(t *testing.T) {
 			"localhost.localdomain:5000/samalba/hipache",
 			"",
 		},
+		{
+			"localhost:5000/app:1",
+			"localhost:5000/app",
+			"1",
+		},
+		{
+			"localhost:5000/app",
+			"localhost:5000/app",
+			"",
+		},
 		{
 			"tsuru/python",
 			"tsuru/python",
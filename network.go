@@ -9,8 +9,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 // ErrNetworkAlreadyExists is the error returned by CreateNetwork when the
@@ -31,6 +33,34 @@ type Network struct {
 	Internal   bool
 	EnableIPv6 bool `json:"EnableIPv6"`
 	Labels     map[string]string
+
+	// Peers and Services are only available in swarm-scoped networks
+	// inspected with NetworkInfoOptions.Verbose.
+	Peers    []NetworkPeer                 `json:"Peers,omitempty"`
+	Services map[string]NetworkServiceInfo `json:"Services,omitempty"`
+}
+
+// NetworkPeer represents a node of the swarm cluster attached to a network.
+type NetworkPeer struct {
+	Name string
+	IP   string
+}
+
+// NetworkServiceInfo represents the service-level information of a network,
+// including the virtual IP and the tasks of a swarm service attached to it.
+type NetworkServiceInfo struct {
+	VIP          string
+	Ports        []string
+	LocalLBIndex int
+	Tasks        []NetworkTask
+}
+
+// NetworkTask represents a task of a swarm service attached to a network.
+type NetworkTask struct {
+	Name       string
+	EndpointID string
+	EndpointIP string
+	Info       map[string]string
 }
 
 // Endpoint contains network resources allocated and used for a container in a network
@@ -54,7 +84,7 @@ func (c *Client) ListNetworks() ([]Network, error) {
 	}
 	defer resp.Body.Close()
 	var networks []Network
-	if err := json.NewDecoder(resp.Body).Decode(&networks); err != nil {
+	if err := decodeJSONList(resp.Body, &networks); err != nil {
 		return nil, err
 	}
 	return networks, nil
@@ -68,6 +98,10 @@ type NetworkFilterOpts map[string]map[string]bool
 //
 // See goo.gl/zd2mx4 for more details.
 func (c *Client) FilteredListNetworks(opts NetworkFilterOpts) ([]Network, error) {
+	return c.filteredListNetworks(context.Background(), opts)
+}
+
+func (c *Client) filteredListNetworks(ctx context.Context, opts NetworkFilterOpts) ([]Network, error) {
 	params, err := json.Marshal(opts)
 	if err != nil {
 		return nil, err
@@ -75,13 +109,13 @@ func (c *Client) FilteredListNetworks(opts NetworkFilterOpts) ([]Network, error)
 	qs := make(url.Values)
 	qs.Add("filters", string(params))
 	path := "/networks?" + qs.Encode()
-	resp, err := c.do("GET", path, doOptions{})
+	resp, err := c.do("GET", path, doOptions{context: ctx})
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	var networks []Network
-	if err := json.NewDecoder(resp.Body).Decode(&networks); err != nil {
+	if err := decodeJSONList(resp.Body, &networks); err != nil {
 		return nil, err
 	}
 	return networks, nil
@@ -91,11 +125,38 @@ func (c *Client) FilteredListNetworks(opts NetworkFilterOpts) ([]Network, error)
 //
 // See https://goo.gl/6GugX3 for more details.
 func (c *Client) NetworkInfo(id string) (*Network, error) {
+	return c.NetworkInfoWithOptions(id, NetworkInfoOptions{})
+}
+
+// NetworkInfoOptions specify parameters to the NetworkInfoWithOptions
+// function.
+//
+// See https://goo.gl/6GugX3 for more details.
+type NetworkInfoOptions struct {
+	// Verbose includes the peers and the service-level information of
+	// swarm-scoped networks.
+	Verbose bool `qs:"verbose"`
+
+	// Scope restricts the lookup to networks with the given scope ("swarm",
+	// "global" or "local").
+	Scope string `qs:"scope"`
+
+	Context context.Context
+}
+
+// NetworkInfoWithOptions returns information about a network by its ID,
+// taking extra parameters.
+//
+// See https://goo.gl/6GugX3 for more details.
+func (c *Client) NetworkInfoWithOptions(id string, opts NetworkInfoOptions) (*Network, error) {
 	path := "/networks/" + id
-	resp, err := c.do("GET", path, doOptions{})
+	if qs := queryString(opts); qs != "" {
+		path += "?" + qs
+	}
+	resp, err := c.do("GET", path, doOptions{context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchNetwork{ID: id}
+			return nil, &NoSuchNetwork{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -196,7 +257,7 @@ func (c *Client) RemoveNetwork(id string) error {
 	resp, err := c.do("DELETE", "/networks/"+id, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchNetwork{ID: id}
+			return &NoSuchNetwork{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -259,7 +320,7 @@ func (c *Client) ConnectNetwork(id string, opts NetworkConnectionOptions) error
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchNetworkOrContainer{NetworkID: id, ContainerID: opts.Container}
+			return &NoSuchNetworkOrContainer{NetworkID: id, ContainerID: opts.Container, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -275,7 +336,7 @@ func (c *Client) DisconnectNetwork(id string, opts NetworkConnectionOptions) err
 	resp, err := c.do("POST", "/networks/"+id+"/disconnect", doOptions{data: opts})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchNetworkOrContainer{NetworkID: id, ContainerID: opts.Container}
+			return &NoSuchNetworkOrContainer{NetworkID: id, ContainerID: opts.Container, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -283,6 +344,71 @@ func (c *Client) DisconnectNetwork(id string, opts NetworkConnectionOptions) err
 	return nil
 }
 
+// UpdateNetworkEndpoint changes the settings (like aliases or static IP
+// addresses) of the endpoint connecting the given container to the given
+// network. The Docker API doesn't support updating endpoints, so the
+// container is disconnected from the network and connected again with the
+// new settings.
+//
+// Before disconnecting, UpdateNetworkEndpoint checks that the container is
+// connected to the network, that it doesn't share the network stack of the
+// host or of another container and that the static IP addresses in settings
+// are valid. If connecting with the new settings fails, it tries to connect
+// the container again with its previous aliases and links, and its previous
+// IP addresses when they were static.
+func (c *Client) UpdateNetworkEndpoint(containerID, networkID string, settings *EndpointConfig) error {
+	container, err := c.InspectContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if container.HostConfig != nil {
+		mode := container.HostConfig.NetworkMode
+		if mode == "host" || mode == "none" || strings.HasPrefix(mode, "container:") {
+			return fmt.Errorf("cannot update network endpoints of container %s using network mode %q", containerID, mode)
+		}
+	}
+	var current *ContainerNetwork
+	if container.NetworkSettings != nil {
+		for name, network := range container.NetworkSettings.Networks {
+			if name == networkID || network.NetworkID == networkID {
+				network := network
+				current = &network
+				break
+			}
+		}
+	}
+	if current == nil {
+		return &NoSuchNetworkOrContainer{NetworkID: networkID, ContainerID: containerID}
+	}
+	if settings != nil && settings.IPAMConfig != nil {
+		if addr := settings.IPAMConfig.IPv4Address; addr != "" && net.ParseIP(addr).To4() == nil {
+			return fmt.Errorf("invalid IPv4 address: %s", addr)
+		}
+		if addr := settings.IPAMConfig.IPv6Address; addr != "" && net.ParseIP(addr) == nil {
+			return fmt.Errorf("invalid IPv6 address: %s", addr)
+		}
+	}
+	err = c.DisconnectNetwork(networkID, NetworkConnectionOptions{Container: container.ID})
+	if err != nil {
+		return err
+	}
+	err = c.ConnectNetwork(networkID, NetworkConnectionOptions{Container: container.ID, EndpointConfig: settings})
+	if err == nil {
+		return nil
+	}
+	// the addresses are only restored when they were static, as pinning an
+	// address allocated by the daemon would keep it from being reused.
+	previous := &EndpointConfig{
+		Aliases:    current.Aliases,
+		Links:      current.Links,
+		IPAMConfig: current.IPAMConfig,
+	}
+	if rollbackErr := c.ConnectNetwork(networkID, NetworkConnectionOptions{Container: container.ID, EndpointConfig: previous}); rollbackErr != nil {
+		return fmt.Errorf("failed to update network endpoint: %s (restoring the previous endpoint also failed: %s)", err, rollbackErr)
+	}
+	return err
+}
+
 // PruneNetworksOptions specify parameters to the PruneNetworks function.
 //
 // See https://goo.gl/kX0S9h for more details.
@@ -318,6 +444,8 @@ func (c *Client) PruneNetworks(opts PruneNetworksOptions) (*PruneNetworksResults
 // NoSuchNetwork is the error returned when a given network does not exist.
 type NoSuchNetwork struct {
 	ID string
+
+	errorMetadata
 }
 
 func (err *NoSuchNetwork) Error() string {
@@ -329,6 +457,8 @@ func (err *NoSuchNetwork) Error() string {
 type NoSuchNetworkOrContainer struct {
 	NetworkID   string
 	ContainerID string
+
+	errorMetadata
 }
 
 func (err *NoSuchNetworkOrContainer) Error() string {
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
@@ -109,6 +110,49 @@ func TestNetworkInfo(t *testing.T) {
 	}
 }
 
+func TestNetworkInfoWithOptions(t *testing.T) {
+	t.Parallel()
+	jsonNetwork := `{
+             "Id": "8dfafdbc3a40",
+             "Name": "ingress",
+             "Scope": "swarm",
+             "Peers": [{"Name": "node-1", "IP": "10.0.0.2"}],
+             "Services": {
+                     "web": {
+                             "VIP": "10.255.0.5",
+                             "Ports": ["Target: 80, Publish: 8080"],
+                             "LocalLBIndex": 257,
+                             "Tasks": [{"Name": "web.1.abc", "EndpointID": "e1", "EndpointIP": "10.255.0.6", "Info": {"Host IP": "10.0.0.2"}}]
+                     }
+             }
+        }`
+	fakeRT := &FakeRoundTripper{message: jsonNetwork, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	network, err := client.NetworkInfoWithOptions("ingress", NetworkInfoOptions{Verbose: true, Scope: "swarm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedServices := map[string]NetworkServiceInfo{
+		"web": {
+			VIP:          "10.255.0.5",
+			Ports:        []string{"Target: 80, Publish: 8080"},
+			LocalLBIndex: 257,
+			Tasks:        []NetworkTask{{Name: "web.1.abc", EndpointID: "e1", EndpointIP: "10.255.0.6", Info: map[string]string{"Host IP": "10.0.0.2"}}},
+		},
+	}
+	if !reflect.DeepEqual(network.Services, expectedServices) {
+		t.Errorf("NetworkInfoWithOptions: wrong services.\nWant %#v.\nGot %#v.", expectedServices, network.Services)
+	}
+	if expectedPeers := []NetworkPeer{{Name: "node-1", IP: "10.0.0.2"}}; !reflect.DeepEqual(network.Peers, expectedPeers) {
+		t.Errorf("NetworkInfoWithOptions: wrong peers.\nWant %#v.\nGot %#v.", expectedPeers, network.Peers)
+	}
+	req := fakeRT.requests[0]
+	expectedQuery := url.Values{"verbose": {"1"}, "scope": {"swarm"}}
+	if query := req.URL.Query(); !reflect.DeepEqual(query, expectedQuery) {
+		t.Errorf("NetworkInfoWithOptions: wrong query string. Want %#v. Got %#v.", expectedQuery, query)
+	}
+}
+
 func TestNetworkCreate(t *testing.T) {
 	jsonID := `{"ID": "8dfafdbc3a40"}`
 	jsonNetwork := `{
@@ -276,3 +320,128 @@ func TestPruneNetworks(t *testing.T) {
 		t.Errorf("PruneNetworks: Expected %#v. Got %#v.", expected, got)
 	}
 }
+
+type endpointUpdateServer struct {
+	calls     []string
+	endpoints []*EndpointConfig
+	failFirst bool
+	network   string
+}
+
+func (s *endpointUpdateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.calls = append(s.calls, r.URL.Path)
+	switch r.URL.Path {
+	case "/containers/web/json":
+		network := s.network
+		if network == "" {
+			network = `{"NetworkID":"net-id","Aliases":["web","a1b2"],"IPAddress":"10.0.0.5"}`
+		}
+		w.Write([]byte(`{"Id":"web-id","HostConfig":{"NetworkMode":"backend"},"NetworkSettings":{"Networks":{"backend":` + network + `}}}`))
+	case "/networks/net-id/disconnect":
+		w.WriteHeader(http.StatusOK)
+	case "/networks/net-id/connect":
+		var opts NetworkConnectionOptions
+		json.NewDecoder(r.Body).Decode(&opts)
+		s.endpoints = append(s.endpoints, opts.EndpointConfig)
+		if s.failFirst && len(s.endpoints) == 1 {
+			http.Error(w, "address already in use", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func TestUpdateNetworkEndpoint(t *testing.T) {
+	t.Parallel()
+	handler := &endpointUpdateServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := &EndpointConfig{Aliases: []string{"web", "www"}, IPAMConfig: &EndpointIPAMConfig{IPv4Address: "10.0.0.10"}}
+	if err = client.UpdateNetworkEndpoint("web", "net-id", settings); err != nil {
+		t.Fatal(err)
+	}
+	expectedCalls := []string{"/containers/web/json", "/networks/net-id/disconnect", "/networks/net-id/connect"}
+	if !reflect.DeepEqual(handler.calls, expectedCalls) {
+		t.Errorf("UpdateNetworkEndpoint: wrong calls.\nWant %#v.\nGot %#v.", expectedCalls, handler.calls)
+	}
+	if !reflect.DeepEqual(handler.endpoints, []*EndpointConfig{settings}) {
+		t.Errorf("UpdateNetworkEndpoint: wrong endpoint settings: %#v", handler.endpoints)
+	}
+}
+
+func TestUpdateNetworkEndpointRollback(t *testing.T) {
+	t.Parallel()
+	handler := &endpointUpdateServer{failFirst: true}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := &EndpointConfig{IPAMConfig: &EndpointIPAMConfig{IPv4Address: "10.0.0.10"}}
+	if err = client.UpdateNetworkEndpoint("web", "net-id", settings); err == nil {
+		t.Fatal("UpdateNetworkEndpoint: unexpected <nil> error")
+	}
+	expected := []*EndpointConfig{
+		settings,
+		{Aliases: []string{"web", "a1b2"}},
+	}
+	if !reflect.DeepEqual(handler.endpoints, expected) {
+		t.Errorf("UpdateNetworkEndpoint: wrong endpoint settings.\nWant %#v.\nGot %#v.", expected, handler.endpoints)
+	}
+}
+
+func TestUpdateNetworkEndpointRollbackStaticAddress(t *testing.T) {
+	t.Parallel()
+	handler := &endpointUpdateServer{
+		failFirst: true,
+		network:   `{"NetworkID":"net-id","IPAMConfig":{"IPv4Address":"10.0.0.5"},"Links":["db:db"],"Aliases":["web"],"IPAddress":"10.0.0.5"}`,
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := &EndpointConfig{IPAMConfig: &EndpointIPAMConfig{IPv4Address: "10.0.0.10"}}
+	if err = client.UpdateNetworkEndpoint("web", "net-id", settings); err == nil {
+		t.Fatal("UpdateNetworkEndpoint: unexpected <nil> error")
+	}
+	expected := []*EndpointConfig{
+		settings,
+		{Aliases: []string{"web"}, Links: []string{"db:db"}, IPAMConfig: &EndpointIPAMConfig{IPv4Address: "10.0.0.5"}},
+	}
+	if !reflect.DeepEqual(handler.endpoints, expected) {
+		t.Errorf("UpdateNetworkEndpoint: wrong endpoint settings.\nWant %#v.\nGot %#v.", expected, handler.endpoints)
+	}
+}
+
+func TestUpdateNetworkEndpointSafetyChecks(t *testing.T) {
+	t.Parallel()
+	handler := &endpointUpdateServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.UpdateNetworkEndpoint("web", "frontend", &EndpointConfig{})
+	if _, ok := err.(*NoSuchNetworkOrContainer); !ok {
+		t.Errorf("UpdateNetworkEndpoint: wrong error. Want *NoSuchNetworkOrContainer. Got %#v.", err)
+	}
+	err = client.UpdateNetworkEndpoint("web", "net-id", &EndpointConfig{IPAMConfig: &EndpointIPAMConfig{IPv4Address: "10.0.0"}})
+	if err == nil {
+		t.Error("UpdateNetworkEndpoint: unexpected <nil> error for invalid address")
+	}
+	for _, call := range handler.calls {
+		if call != "/containers/web/json" {
+			t.Errorf("UpdateNetworkEndpoint: unexpected call to %s", call)
+		}
+	}
+}
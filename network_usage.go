@@ -0,0 +1,132 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"math"
+	"net"
+	"sort"
+	"strings"
+)
+
+// NetworkUsage is a report of the address usage of a network, as returned by
+// the NetworkUsage method.
+type NetworkUsage struct {
+	NetworkID string
+	Name      string
+	Subnets   []SubnetUsage
+
+	// Unassigned lists the addresses of containers that are not in any of
+	// the subnets configured in the network.
+	Unassigned []string
+}
+
+// SubnetUsage is the address usage of one of the subnets of a network.
+//
+// Size is the number of addresses in the subnet, saturated at the maximum
+// uint64 for large IPv6 subnets. Reserved counts the addresses that can't be
+// allocated to containers: the gateway, the auxiliary addresses and, for IPv4,
+// the network and broadcast addresses.
+type SubnetUsage struct {
+	Subnet       string
+	IPRange      string
+	Gateway      string
+	AuxAddresses map[string]string
+	Allocated    []string
+	Size         uint64
+	Reserved     uint64
+	Available    uint64
+	Utilization  float64
+}
+
+// NetworkUsage inspects the given network and computes the utilization of
+// each of its subnets, comparing the addresses allocated to containers with
+// the size of the subnet.
+func (c *Client) NetworkUsage(id string) (*NetworkUsage, error) {
+	network, err := c.NetworkInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	return networkUsage(network), nil
+}
+
+func networkUsage(network *Network) *NetworkUsage {
+	usage := NetworkUsage{NetworkID: network.ID, Name: network.Name}
+	var subnets []*net.IPNet
+	for _, config := range network.IPAM.Config {
+		_, subnet, err := net.ParseCIDR(config.Subnet)
+		if err != nil {
+			continue
+		}
+		subnets = append(subnets, subnet)
+		su := SubnetUsage{
+			Subnet:       config.Subnet,
+			IPRange:      config.IPRange,
+			Gateway:      config.Gateway,
+			AuxAddresses: config.AuxAddress,
+			Size:         subnetSize(subnet),
+		}
+		reserved := make(map[string]bool)
+		if subnet.IP.To4() != nil && su.Size > 2 {
+			reserved["network"] = true
+			reserved["broadcast"] = true
+		}
+		if ip := net.ParseIP(config.Gateway); ip != nil && subnet.Contains(ip) {
+			reserved[ip.String()] = true
+		}
+		for _, addr := range config.AuxAddress {
+			if ip := net.ParseIP(addr); ip != nil && subnet.Contains(ip) {
+				reserved[ip.String()] = true
+			}
+		}
+		su.Reserved = uint64(len(reserved))
+		usage.Subnets = append(usage.Subnets, su)
+	}
+	for _, endpoint := range network.Containers {
+		for _, addr := range []string{endpoint.IPv4Address, endpoint.IPv6Address} {
+			if addr == "" {
+				continue
+			}
+			ip := net.ParseIP(strings.SplitN(addr, "/", 2)[0])
+			if ip == nil {
+				continue
+			}
+			assigned := false
+			for i, subnet := range subnets {
+				if subnet.Contains(ip) {
+					usage.Subnets[i].Allocated = append(usage.Subnets[i].Allocated, ip.String())
+					assigned = true
+					break
+				}
+			}
+			if !assigned {
+				usage.Unassigned = append(usage.Unassigned, ip.String())
+			}
+		}
+	}
+	sort.Strings(usage.Unassigned)
+	for i := range usage.Subnets {
+		su := &usage.Subnets[i]
+		sort.Strings(su.Allocated)
+		used := su.Reserved + uint64(len(su.Allocated))
+		if used < su.Size {
+			su.Available = su.Size - used
+		}
+		if su.Size > 0 {
+			su.Utilization = float64(used) / float64(su.Size)
+		}
+	}
+	return &usage
+}
+
+// subnetSize returns the number of addresses in the subnet, saturated at the
+// maximum uint64.
+func subnetSize(subnet *net.IPNet) uint64 {
+	ones, bits := subnet.Mask.Size()
+	if bits-ones >= 64 {
+		return math.MaxUint64
+	}
+	return 1 << uint(bits-ones)
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNetworkUsage(t *testing.T) {
+	t.Parallel()
+	jsonNetwork := `{
+	"Id": "8dfafdbc3a40",
+	"Name": "backend",
+	"IPAM": {
+		"Driver": "default",
+		"Config": [
+			{"Subnet": "172.20.0.0/29", "Gateway": "172.20.0.1", "AuxiliaryAddresses": {"router": "172.20.0.2"}},
+			{"Subnet": "fd00::/64", "Gateway": "fd00::1"}
+		]
+	},
+	"Containers": {
+		"c1": {"Name": "web", "IPv4Address": "172.20.0.4/29", "IPv6Address": "fd00::4/64"},
+		"c2": {"Name": "db", "IPv4Address": "172.20.0.3/29"},
+		"c3": {"Name": "legacy", "IPv4Address": "10.0.0.9/24"}
+	}
+}`
+	client := newTestClient(&FakeRoundTripper{message: jsonNetwork, status: http.StatusOK})
+	usage, err := client.NetworkUsage("backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &NetworkUsage{
+		NetworkID: "8dfafdbc3a40",
+		Name:      "backend",
+		Subnets: []SubnetUsage{
+			{
+				Subnet:       "172.20.0.0/29",
+				Gateway:      "172.20.0.1",
+				AuxAddresses: map[string]string{"router": "172.20.0.2"},
+				Allocated:    []string{"172.20.0.3", "172.20.0.4"},
+				Size:         8,
+				Reserved:     4,
+				Available:    2,
+				Utilization:  0.75,
+			},
+			{
+				Subnet:      "fd00::/64",
+				Gateway:     "fd00::1",
+				Allocated:   []string{"fd00::4"},
+				Size:        math.MaxUint64,
+				Reserved:    1,
+				Available:   math.MaxUint64 - 2,
+				Utilization: 2 / float64(math.MaxUint64),
+			},
+		},
+		Unassigned: []string{"10.0.0.9"},
+	}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("NetworkUsage: wrong report.\nWant %#v.\nGot  %#v.", expected, usage)
+	}
+}
+
+func TestNetworkUsageNoSuchNetwork(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "no such network", status: http.StatusNotFound})
+	_, err := client.NetworkUsage("backend")
+	if _, ok := err.(*NoSuchNetwork); !ok {
+		t.Errorf("NetworkUsage: wrong error. Want *NoSuchNetwork. Got %#v.", err)
+	}
+}
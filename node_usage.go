@@ -0,0 +1,111 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"sort"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// NodeResourceUsage is the amount of the resources of a swarm node that are
+// reserved by the tasks scheduled on it, as returned by the
+// NodeResourceUsage method. CPUs are expressed in units of 1e-9 CPUs.
+type NodeResourceUsage struct {
+	NodeID   string
+	Hostname string
+
+	// Tasks is the number of active tasks on the node.
+	Tasks int
+
+	TotalNanoCPUs    int64
+	TotalMemoryBytes int64
+
+	ReservedNanoCPUs    int64
+	ReservedMemoryBytes int64
+
+	// AvailableNanoCPUs and AvailableMemoryBytes are the resources left for
+	// the reservations of new tasks, never less than zero.
+	AvailableNanoCPUs    int64
+	AvailableMemoryBytes int64
+}
+
+// NodeResourceUsage returns, for each node of the swarm, the resources
+// reserved by the tasks scheduled on it and the resources still available
+// for new reservations, sorted by hostname.
+//
+// Only active tasks are counted: tasks that the orchestrator wants running
+// and that haven't reached a terminal state.
+func (c *Client) NodeResourceUsage(ctx context.Context) ([]NodeResourceUsage, error) {
+	nodes, err := c.ListNodes(ListNodesOptions{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := c.ListTasks(ListTasksOptions{
+		Filters: map[string][]string{"desired-state": {"running"}},
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodeResourceUsage(nodes, tasks), nil
+}
+
+func nodeResourceUsage(nodes []swarm.Node, tasks []swarm.Task) []NodeResourceUsage {
+	usage := make([]NodeResourceUsage, len(nodes))
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		index[node.ID] = i
+		usage[i] = NodeResourceUsage{
+			NodeID:           node.ID,
+			Hostname:         node.Description.Hostname,
+			TotalNanoCPUs:    node.Description.Resources.NanoCPUs,
+			TotalMemoryBytes: node.Description.Resources.MemoryBytes,
+		}
+	}
+	for _, task := range tasks {
+		i, ok := index[task.NodeID]
+		if !ok || !isActiveTask(task) {
+			continue
+		}
+		usage[i].Tasks++
+		if resources := task.Spec.Resources; resources != nil && resources.Reservations != nil {
+			usage[i].ReservedNanoCPUs += resources.Reservations.NanoCPUs
+			usage[i].ReservedMemoryBytes += resources.Reservations.MemoryBytes
+		}
+	}
+	for i := range usage {
+		usage[i].AvailableNanoCPUs = nonNegative(usage[i].TotalNanoCPUs - usage[i].ReservedNanoCPUs)
+		usage[i].AvailableMemoryBytes = nonNegative(usage[i].TotalMemoryBytes - usage[i].ReservedMemoryBytes)
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].Hostname != usage[j].Hostname {
+			return usage[i].Hostname < usage[j].Hostname
+		}
+		return usage[i].NodeID < usage[j].NodeID
+	})
+	return usage
+}
+
+// isActiveTask reports whether the task holds its reservations on the node.
+func isActiveTask(task swarm.Task) bool {
+	if task.DesiredState != swarm.TaskStateRunning {
+		return false
+	}
+	switch task.Status.State {
+	case swarm.TaskStateComplete, swarm.TaskStateShutdown, swarm.TaskStateFailed,
+		swarm.TaskStateRejected, swarm.TaskStateRemove, swarm.TaskStateOrphaned:
+		return false
+	}
+	return true
+}
+
+func nonNegative(n int64) int64 {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
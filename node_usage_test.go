@@ -0,0 +1,91 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNodeResourceUsage(t *testing.T) {
+	t.Parallel()
+	var tasksFilters string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nodes":
+			w.Write([]byte(`[
+				{"ID": "n2", "Description": {"Hostname": "worker", "Resources": {"NanoCPUs": 2000000000, "MemoryBytes": 4096}}},
+				{"ID": "n1", "Description": {"Hostname": "manager", "Resources": {"NanoCPUs": 4000000000, "MemoryBytes": 8192}}}
+			]`))
+		case "/tasks":
+			tasksFilters = r.URL.Query().Get("filters")
+			w.Write([]byte(`[
+				{"ID": "t1", "NodeID": "n1", "DesiredState": "running", "Status": {"State": "running"},
+				 "Spec": {"Resources": {"Reservations": {"NanoCPUs": 500000000, "MemoryBytes": 1024}, "Limits": {"NanoCPUs": 3000000000}}}},
+				{"ID": "t2", "NodeID": "n1", "DesiredState": "running", "Status": {"State": "preparing"},
+				 "Spec": {"Resources": {"Reservations": {"NanoCPUs": 1000000000, "MemoryBytes": 2048}}}},
+				{"ID": "t3", "NodeID": "n1", "DesiredState": "running", "Status": {"State": "failed"},
+				 "Spec": {"Resources": {"Reservations": {"NanoCPUs": 1000000000}}}},
+				{"ID": "t4", "NodeID": "n2", "DesiredState": "running", "Status": {"State": "running"},
+				 "Spec": {"Resources": {"Reservations": {"MemoryBytes": 8192}}}},
+				{"ID": "t5", "NodeID": "n2", "DesiredState": "running", "Status": {"State": "running"}, "Spec": {}},
+				{"ID": "t6", "NodeID": "", "DesiredState": "running", "Status": {"State": "pending"},
+				 "Spec": {"Resources": {"Reservations": {"NanoCPUs": 1000000000}}}}
+			]`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err := client.NodeResourceUsage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"desired-state":["running"]}`; tasksFilters != expected {
+		t.Errorf("NodeResourceUsage: wrong task filters. Want %s. Got %s.", expected, tasksFilters)
+	}
+	expected := []NodeResourceUsage{
+		{
+			NodeID:               "n1",
+			Hostname:             "manager",
+			Tasks:                2,
+			TotalNanoCPUs:        4000000000,
+			TotalMemoryBytes:     8192,
+			ReservedNanoCPUs:     1500000000,
+			ReservedMemoryBytes:  3072,
+			AvailableNanoCPUs:    2500000000,
+			AvailableMemoryBytes: 5120,
+		},
+		{
+			NodeID:               "n2",
+			Hostname:             "worker",
+			Tasks:                2,
+			TotalNanoCPUs:        2000000000,
+			TotalMemoryBytes:     4096,
+			ReservedMemoryBytes:  8192,
+			AvailableNanoCPUs:    2000000000,
+			AvailableMemoryBytes: 0,
+		},
+	}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("NodeResourceUsage: wrong usage.\nWant %#v\nGot  %#v", expected, usage)
+	}
+}
+
+func TestNodeResourceUsageNotSwarm(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "This node is not a swarm manager.", status: http.StatusServiceUnavailable})
+	_, err := client.NodeResourceUsage(context.Background())
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusServiceUnavailable {
+		t.Errorf("NodeResourceUsage: wrong error. Want *Error with status 503. Got %#v.", err)
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import "time"
+
+// Int64Ptr returns a pointer to the given value, for settings where zero and
+// unset differ, like HostConfig.MemorySwappiness and HostConfig.PidsLimit: nil
+// leaves the default of the daemon (or, in updates, the current value) in
+// place, while a pointer to zero sets the value to zero.
+func Int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// BoolPtr returns a pointer to the given value, for settings where false and
+// unset differ, like HostConfig.OOMKillDisable. See Int64Ptr.
+func BoolPtr(v bool) *bool {
+	return &v
+}
+
+// DurationPtr returns a pointer to the given value, for settings where zero
+// and unset differ, like StopContainerOptions.Timeout. See Int64Ptr.
+func DurationPtr(v time.Duration) *time.Duration {
+	return &v
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOptionalHostConfigFields(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		config   HostConfig
+		expected map[string]interface{}
+	}{
+		{HostConfig{}, map[string]interface{}{}},
+		{
+			HostConfig{MemorySwappiness: Int64Ptr(0), PidsLimit: Int64Ptr(0), OOMKillDisable: BoolPtr(false)},
+			map[string]interface{}{"MemorySwappiness": float64(0), "PidsLimit": float64(0), "OomKillDisable": false},
+		},
+		{
+			HostConfig{MemorySwappiness: Int64Ptr(60), PidsLimit: Int64Ptr(-1), OOMKillDisable: BoolPtr(true)},
+			map[string]interface{}{"MemorySwappiness": float64(60), "PidsLimit": float64(-1), "OomKillDisable": true},
+		},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]interface{}
+		if err = json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		delete(got, "RestartPolicy")
+		delete(got, "LogConfig")
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("HostConfig: wrong JSON. Want %#v. Got %#v.", tt.expected, got)
+		}
+	}
+}
+
+func TestUpdateContainerPidsLimit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pidsLimit *int64
+		expected  interface{}
+		sent      bool
+	}{
+		{nil, nil, false},
+		{Int64Ptr(0), float64(0), true},
+		{Int64Ptr(100), float64(100), true},
+	}
+	for _, tt := range tests {
+		fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
+		client := newTestClient(fakeRT)
+		if err := client.UpdateContainer("abc", UpdateContainerOptions{PidsLimit: tt.pidsLimit}); err != nil {
+			t.Fatal(err)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		value, sent := body["PidsLimit"]
+		if sent != tt.sent || value != tt.expected {
+			t.Errorf("UpdateContainer: wrong PidsLimit. Want %v (sent: %v). Got %v (sent: %v).", tt.expected, tt.sent, value, sent)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Labels of the ownership scheme shared by the tools built on this package,
+// so the resources created by one of them can be garbage collected by any
+// other.
+const (
+	// OwnerLabel is the name of the tool or user owning the resource.
+	OwnerLabel = "io.github.go-dockerclient.owner"
+
+	// SessionLabel identifies the session of the owner the resource was
+	// created in, e.g. a CI job.
+	SessionLabel = "io.github.go-dockerclient.session"
+
+	// ExpiresLabel is the time after which the resource may be removed,
+	// formatted as RFC 3339.
+	ExpiresLabel = "io.github.go-dockerclient.expires"
+)
+
+// Ownership is the owner, session and expiry of resources, stored in their
+// labels.
+type Ownership struct {
+	Owner   string
+	Session string
+
+	// Expires is the time after which the resources may be removed by
+	// SweepExpired. Resources without an expiry are never swept.
+	Expires time.Time
+}
+
+// NewOwnership returns the ownership of resources expiring after ttl. They
+// never expire when ttl is 0.
+func NewOwnership(owner, session string, ttl time.Duration) Ownership {
+	ownership := Ownership{Owner: owner, Session: session}
+	if ttl > 0 {
+		ownership.Expires = time.Now().Add(ttl)
+	}
+	return ownership
+}
+
+// Labels returns the labels of the ownership.
+func (o Ownership) Labels() map[string]string {
+	return o.Stamp(nil)
+}
+
+// Stamp returns a copy of the given labels with the labels of the ownership
+// added, for the creation of resources:
+//
+//	opts.Config.Labels = ownership.Stamp(opts.Config.Labels)
+//	client.CreateContainer(opts)
+func (o Ownership) Stamp(labels map[string]string) map[string]string {
+	stamped := make(map[string]string, len(labels)+3)
+	for key, value := range labels {
+		stamped[key] = value
+	}
+	stamped[OwnerLabel] = o.Owner
+	if o.Session != "" {
+		stamped[SessionLabel] = o.Session
+	}
+	if !o.Expires.IsZero() {
+		stamped[ExpiresLabel] = o.Expires.UTC().Format(time.RFC3339)
+	}
+	return stamped
+}
+
+// Expired tells whether the resources have expired at the given time.
+func (o Ownership) Expired(now time.Time) bool {
+	return !o.Expires.IsZero() && !now.Before(o.Expires)
+}
+
+// OwnershipFromLabels returns the ownership stored in the labels of a
+// resource, and whether it has one. An invalid expiry is ignored, so the
+// resource is never swept.
+func OwnershipFromLabels(labels map[string]string) (Ownership, bool) {
+	owner, ok := labels[OwnerLabel]
+	if !ok {
+		return Ownership{}, false
+	}
+	ownership := Ownership{Owner: owner, Session: labels[SessionLabel]}
+	if expires, err := time.Parse(time.RFC3339, labels[ExpiresLabel]); err == nil {
+		ownership.Expires = expires
+	}
+	return ownership, true
+}
+
+// SweepOptions specify parameters to the SweepExpired function.
+type SweepOptions struct {
+	// Owner and Session restrict the sweep to the resources of the given
+	// owner and session. The expired resources of every owner are swept
+	// when they're empty.
+	Owner   string
+	Session string
+
+	// Now is the time the expiry of the resources is compared to. Defaults
+	// to the current time.
+	Now time.Time
+
+	// DryRun lists the expired resources without removing them.
+	DryRun bool
+
+	Context context.Context
+}
+
+// SweepResults are the resources removed by SweepExpired, or the ones it
+// would remove in a dry run.
+type SweepResults struct {
+	Containers []string
+	Networks   []string
+	Volumes    []string
+	Images     []string
+}
+
+// SweepErrors is the error returned by SweepExpired when some of the expired
+// resources could not be removed. It maps the kind and ID of each resource,
+// e.g. "volume/data", to the error returned by the daemon.
+type SweepErrors map[string]error
+
+func (e SweepErrors) Error() string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	msgs := make([]string, len(keys))
+	for i, key := range keys {
+		msgs[i] = fmt.Sprintf("%s: %s", key, e[key])
+	}
+	return fmt.Sprintf("failed to remove %d expired resource(s): %s", len(e), strings.Join(msgs, "; "))
+}
+
+// SweepExpired removes the expired resources stamped with an Ownership:
+// containers, along with their anonymous volumes, then networks, volumes and
+// images, with all their tags. Resources already gone are ignored. When some
+// of the removals fail, the other resources are still removed, and an error
+// of type SweepErrors is returned along with the results.
+func (c *Client) SweepExpired(opts SweepOptions) (*SweepResults, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	labelFilters := []string{OwnerLabel}
+	if opts.Owner != "" {
+		labelFilters[0] = OwnerLabel + "=" + opts.Owner
+	}
+	if opts.Session != "" {
+		labelFilters = append(labelFilters, SessionLabel+"="+opts.Session)
+	}
+	expired := func(labels map[string]string) bool {
+		ownership, ok := OwnershipFromLabels(labels)
+		return ok && ownership.Expired(now)
+	}
+	var results SweepResults
+	errs := make(SweepErrors)
+	// sweep removes a resource, unless in a dry run, and returns whether
+	// it's gone. remove returns nil for the resources already gone.
+	sweep := func(kind, id string, remove func() error) bool {
+		if opts.DryRun {
+			return true
+		}
+		err := remove()
+		if err == nil {
+			return true
+		}
+		errs[kind+"/"+id] = err
+		return false
+	}
+
+	containers, err := c.ListContainers(ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"label": labelFilters},
+		Context: opts.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		if !expired(container.Labels) {
+			continue
+		}
+		removed := sweep("container", container.ID, func() error {
+			err := c.RemoveContainer(RemoveContainerOptions{
+				ID:            container.ID,
+				RemoveVolumes: true,
+				Force:         true,
+				Context:       opts.Context,
+			})
+			if _, ok := err.(*NoSuchContainer); ok {
+				return nil
+			}
+			return err
+		})
+		if removed {
+			results.Containers = append(results.Containers, container.ID)
+		}
+	}
+
+	networkFilters := NetworkFilterOpts{"label": make(map[string]bool)}
+	for _, filter := range labelFilters {
+		networkFilters["label"][filter] = true
+	}
+	networks, err := c.filteredListNetworks(opts.Context, networkFilters)
+	if err != nil {
+		return &results, err
+	}
+	for _, network := range networks {
+		if !expired(network.Labels) {
+			continue
+		}
+		removed := sweep("network", network.ID, func() error {
+			err := c.RemoveNetwork(network.ID)
+			if _, ok := err.(*NoSuchNetwork); ok {
+				return nil
+			}
+			return err
+		})
+		if removed {
+			results.Networks = append(results.Networks, network.ID)
+		}
+	}
+
+	volumes, err := c.ListVolumes(ListVolumesOptions{
+		Filters: map[string][]string{"label": labelFilters},
+		Context: opts.Context,
+	})
+	if err != nil {
+		return &results, err
+	}
+	for _, volume := range volumes {
+		if !expired(volume.Labels) {
+			continue
+		}
+		removed := sweep("volume", volume.Name, func() error {
+			err := c.RemoveVolumeWithOptions(RemoveVolumeOptions{Name: volume.Name, Context: opts.Context})
+			if err == ErrNoSuchVolume {
+				return nil
+			}
+			return err
+		})
+		if removed {
+			results.Volumes = append(results.Volumes, volume.Name)
+		}
+	}
+
+	images, err := c.ListImages(ListImagesOptions{
+		Filters: map[string][]string{"label": labelFilters},
+		Context: opts.Context,
+	})
+	if err != nil {
+		return &results, err
+	}
+	for _, image := range images {
+		if !expired(image.Labels) {
+			continue
+		}
+		removed := sweep("image", image.ID, func() error {
+			// images tagged in several repositories can only be removed
+			// by ID when forced, which would remove them from under the
+			// containers using them, so each tag is removed instead, the
+			// last one deleting the image.
+			for _, tag := range image.RepoTags {
+				if tag == "<none>:<none>" {
+					continue
+				}
+				results, err := c.RemoveImageWithResults(tag, RemoveImageOptions{Context: opts.Context})
+				if err == ErrNoSuchImage {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if len(results.Deleted()) > 0 {
+					return nil
+				}
+			}
+			err := c.RemoveImageExtended(image.ID, RemoveImageOptions{Context: opts.Context})
+			if err == ErrNoSuchImage {
+				return nil
+			}
+			return err
+		})
+		if removed {
+			results.Images = append(results.Images, image.ID)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &results, errs
+	}
+	return &results, nil
+}
@@ -0,0 +1,240 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOwnershipStamp(t *testing.T) {
+	t.Parallel()
+	expires := time.Date(2019, 6, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	ownership := Ownership{Owner: "ci", Session: "job-42", Expires: expires}
+	labels := map[string]string{"app": "web"}
+	stamped := ownership.Stamp(labels)
+	expected := map[string]string{
+		"app":        "web",
+		OwnerLabel:   "ci",
+		SessionLabel: "job-42",
+		ExpiresLabel: "2019-06-01T10:00:00Z",
+	}
+	if !reflect.DeepEqual(stamped, expected) {
+		t.Errorf("Stamp: wrong labels. Want %#v. Got %#v.", expected, stamped)
+	}
+	if len(labels) != 1 {
+		t.Errorf("Stamp: the given labels were modified: %#v", labels)
+	}
+	parsed, ok := OwnershipFromLabels(stamped)
+	if !ok {
+		t.Fatal("OwnershipFromLabels: want an ownership")
+	}
+	if parsed.Owner != "ci" || parsed.Session != "job-42" || !parsed.Expires.Equal(expires) {
+		t.Errorf("OwnershipFromLabels: wrong ownership. Got %#v.", parsed)
+	}
+	if parsed.Expired(expires.Add(-time.Second)) {
+		t.Error("Expired: want the ownership not expired before its expiry")
+	}
+	if !parsed.Expired(expires) {
+		t.Error("Expired: want the ownership expired at its expiry")
+	}
+}
+
+func TestOwnershipWithoutExpiry(t *testing.T) {
+	t.Parallel()
+	labels := NewOwnership("ci", "", 0).Labels()
+	expected := map[string]string{OwnerLabel: "ci"}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Labels: wrong labels. Want %#v. Got %#v.", expected, labels)
+	}
+	ownership, ok := OwnershipFromLabels(labels)
+	if !ok || ownership.Expired(time.Now().Add(1000*time.Hour)) {
+		t.Errorf("OwnershipFromLabels: want an ownership never expiring. Got %#v.", ownership)
+	}
+	ownership, ok = OwnershipFromLabels(map[string]string{OwnerLabel: "ci", ExpiresLabel: "tomorrow"})
+	if !ok || !ownership.Expires.IsZero() {
+		t.Errorf("OwnershipFromLabels: want an invalid expiry ignored. Got %#v.", ownership)
+	}
+	if _, ok := OwnershipFromLabels(map[string]string{"app": "web"}); ok {
+		t.Error("OwnershipFromLabels: want no ownership without the owner label")
+	}
+}
+
+// newSweepServer returns a handler serving the given resources, recording the
+// filters of the lists and the paths of the removals.
+func newSweepServer(t *testing.T, expiry string, fail map[string]int) (http.Handler, func() ([]string, []string)) {
+	var mu sync.Mutex
+	var filters, removed []string
+	labels := func(owner string) string {
+		return `{"` + OwnerLabel + `":"` + owner + `","` + ExpiresLabel + `":"` + expiry + `"}`
+	}
+	recordFilters := func(r *http.Request) {
+		mu.Lock()
+		filters = append(filters, r.URL.Path+" "+r.URL.Query().Get("filters"))
+		mu.Unlock()
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		recordFilters(r)
+		w.Write([]byte(`[{"Id":"c1","Labels":` + labels("ci") + `},{"Id":"c2","Labels":{"` + OwnerLabel + `":"ci"}}]`))
+	})
+	mux.HandleFunc("/networks", func(w http.ResponseWriter, r *http.Request) {
+		recordFilters(r)
+		w.Write([]byte(`[{"Id":"n1","Labels":` + labels("ci") + `}]`))
+	})
+	mux.HandleFunc("/volumes", func(w http.ResponseWriter, r *http.Request) {
+		recordFilters(r)
+		w.Write([]byte(`{"Volumes":[{"Name":"v1","Labels":` + labels("ci") + `}]}`))
+	})
+	mux.HandleFunc("/images/json", func(w http.ResponseWriter, r *http.Request) {
+		recordFilters(r)
+		w.Write([]byte(`[{"Id":"sha256:i1","RepoTags":["app:1","registry.example.com:5000/app:1"],"Labels":` + labels("ci") + `},` +
+			`{"Id":"sha256:i2","RepoTags":["<none>:<none>"],"Labels":` + labels("ci") + `}]`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("SweepExpired: unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/images/") && r.URL.Query().Get("force") == "1" {
+			t.Errorf("SweepExpired: unexpected forced removal of %s.", r.URL.Path)
+		}
+		mu.Lock()
+		removed = append(removed, r.URL.Path)
+		mu.Unlock()
+		if status, ok := fail[r.URL.Path]; ok {
+			http.Error(w, "cannot remove", status)
+			return
+		}
+		// removing the last tag of an image deletes it.
+		if r.URL.Path == "/images/registry.example.com:5000/app:1" {
+			w.Write([]byte(`[{"Untagged":"registry.example.com:5000/app:1"},{"Deleted":"sha256:i1"}]`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux, func() ([]string, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return filters, removed
+	}
+}
+
+func TestSweepExpired(t *testing.T) {
+	t.Parallel()
+	handler, requests := newSweepServer(t, "2019-06-01T10:00:00Z", nil)
+	client := NewClientFromHandler(handler)
+	results, err := client.SweepExpired(SweepOptions{
+		Owner:   "ci",
+		Session: "job-42",
+		Now:     time.Date(2019, 6, 2, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &SweepResults{
+		Containers: []string{"c1"},
+		Networks:   []string{"n1"},
+		Volumes:    []string{"v1"},
+		Images:     []string{"sha256:i1", "sha256:i2"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("SweepExpired: wrong results. Want %#v. Got %#v.", expected, results)
+	}
+	filters, removed := requests()
+	expectedRemoved := []string{
+		"/containers/c1", "/networks/n1", "/volumes/v1",
+		"/images/app:1", "/images/registry.example.com:5000/app:1", "/images/sha256:i2",
+	}
+	if !reflect.DeepEqual(removed, expectedRemoved) {
+		t.Errorf("SweepExpired: wrong removals. Want %#v. Got %#v.", expectedRemoved, removed)
+	}
+	labelFilters := []string{OwnerLabel + "=ci", SessionLabel + "=job-42"}
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, " ", 2)
+		var got []string
+		if parts[0] == "/networks" {
+			var f map[string]map[string]bool
+			json.Unmarshal([]byte(parts[1]), &f)
+			for label := range f["label"] {
+				got = append(got, label)
+			}
+			sort.Strings(got)
+		} else {
+			var f map[string][]string
+			json.Unmarshal([]byte(parts[1]), &f)
+			got = f["label"]
+		}
+		if !reflect.DeepEqual(got, labelFilters) {
+			t.Errorf("SweepExpired: wrong label filters of %s. Want %#v. Got %#v.", parts[0], labelFilters, got)
+		}
+	}
+}
+
+func TestSweepExpiredNotExpired(t *testing.T) {
+	t.Parallel()
+	handler, requests := newSweepServer(t, "2019-06-01T10:00:00Z", nil)
+	client := NewClientFromHandler(handler)
+	results, err := client.SweepExpired(SweepOptions{Now: time.Date(2019, 6, 1, 9, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(results, &SweepResults{}) {
+		t.Errorf("SweepExpired: want nothing swept. Got %#v.", results)
+	}
+	if _, removed := requests(); len(removed) != 0 {
+		t.Errorf("SweepExpired: want nothing removed. Got %#v.", removed)
+	}
+}
+
+func TestSweepExpiredDryRun(t *testing.T) {
+	t.Parallel()
+	handler, requests := newSweepServer(t, "2019-06-01T10:00:00Z", nil)
+	client := NewClientFromHandler(handler)
+	results, err := client.SweepExpired(SweepOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Containers) != 1 || len(results.Networks) != 1 || len(results.Volumes) != 1 || len(results.Images) != 2 {
+		t.Errorf("SweepExpired: wrong dry run results. Got %#v.", results)
+	}
+	if _, removed := requests(); len(removed) != 0 {
+		t.Errorf("SweepExpired: want nothing removed in a dry run. Got %#v.", removed)
+	}
+}
+
+func TestSweepExpiredErrors(t *testing.T) {
+	t.Parallel()
+	handler, _ := newSweepServer(t, "2019-06-01T10:00:00Z", map[string]int{
+		"/containers/c1":    http.StatusNotFound,
+		"/volumes/v1":       http.StatusConflict,
+		"/images/app:1":     http.StatusNotFound,
+		"/images/sha256:i2": http.StatusNotFound,
+	})
+	client := NewClientFromHandler(handler)
+	results, err := client.SweepExpired(SweepOptions{})
+	errs, ok := err.(SweepErrors)
+	if !ok {
+		t.Fatalf("SweepExpired: wrong error. Want SweepErrors. Got %#v.", err)
+	}
+	if len(errs) != 1 || errs["volume/v1"] != ErrVolumeInUse {
+		t.Errorf("SweepExpired: wrong errors. Got %#v.", errs)
+	}
+	expected := &SweepResults{
+		Containers: []string{"c1"},
+		Networks:   []string{"n1"},
+		Images:     []string{"sha256:i1", "sha256:i2"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("SweepExpired: wrong results. Want %#v. Got %#v.", expected, results)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"strings"
+)
+
+// PathMapper translates paths in the machine running the client to paths in
+// the machine running the Docker daemon.
+//
+// When Client.PathMapper is set, the source of bind mounts in
+// HostConfig.Binds and HostConfig.Mounts is translated before creating
+// containers. This is useful when the daemon runs in a virtual machine (as
+// with Docker Desktop) or in a remote host that shares directories with the
+// client under a different path.
+type PathMapper interface {
+	MapPath(path string) string
+}
+
+// PathMapperFunc is a function that implements the PathMapper interface.
+type PathMapperFunc func(path string) string
+
+// MapPath calls fn(path).
+func (fn PathMapperFunc) MapPath(path string) string {
+	return fn(path)
+}
+
+// PrefixPathMapper is a PathMapper that replaces path prefixes in the client
+// (the keys) with path prefixes in the daemon (the values). Prefixes only
+// match complete path elements, and the longest matching prefix is used.
+// Paths that don't match any prefix are not modified.
+type PrefixPathMapper map[string]string
+
+// MapPath translates the given path using the longest matching prefix.
+func (m PrefixPathMapper) MapPath(path string) string {
+	var prefix string
+	for p := range m {
+		if len(p) > len(prefix) && hasPathPrefix(path, p) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return path
+	}
+	return m[prefix] + path[len(prefix):]
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	if len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || strings.HasSuffix(prefix, `\`) {
+		return true
+	}
+	return path[len(prefix)] == '/' || path[len(prefix)] == '\\'
+}
+
+// DrivePathMapper is a PathMapper that translates Windows paths to the paths
+// used by Docker Desktop and Docker Toolbox, for example "C:\Users\gopher"
+// becomes "/c/Users/gopher". Other paths are not modified.
+type DrivePathMapper struct{}
+
+// MapPath translates the given Windows path.
+func (DrivePathMapper) MapPath(path string) string {
+	if !isDrivePath(path) {
+		return path
+	}
+	return "/" + strings.ToLower(path[:1]) + strings.Replace(path[2:], `\`, "/", -1)
+}
+
+func isDrivePath(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	letter := path[0] | 0x20
+	return letter >= 'a' && letter <= 'z' && (len(path) == 2 || path[2] == '\\' || path[2] == '/')
+}
+
+// mapHostConfigPaths returns a copy of the given HostConfig with the source
+// of bind mounts translated by the client's PathMapper.
+func (c *Client) mapHostConfigPaths(hostConfig *HostConfig) *HostConfig {
+	if c.PathMapper == nil || hostConfig == nil {
+		return hostConfig
+	}
+	mapped := *hostConfig
+	if hostConfig.Binds != nil {
+		mapped.Binds = make([]string, len(hostConfig.Binds))
+		for i, bind := range hostConfig.Binds {
+			mapped.Binds[i] = c.mapBind(bind)
+		}
+	}
+	if hostConfig.Mounts != nil {
+		mapped.Mounts = make([]HostMount, len(hostConfig.Mounts))
+		for i, mount := range hostConfig.Mounts {
+			if mount.Type == "bind" {
+				mount.Source = c.PathMapper.MapPath(mount.Source)
+			}
+			mapped.Mounts[i] = mount
+		}
+	}
+	return &mapped
+}
+
+// mapBind translates the source of a bind in the format
+// source:destination[:options]. Named volumes are not translated.
+func (c *Client) mapBind(bind string) string {
+	source, rest, ok := cutBindPath(bind)
+	if !ok {
+		return bind
+	}
+	if !strings.HasPrefix(source, "/") && !isDrivePath(source) && !strings.HasPrefix(source, `\\`) {
+		return bind
+	}
+	return c.PathMapper.MapPath(source) + ":" + rest
+}
+
+// splitBind splits a bind in the format source:destination[:options]. The
+// source and the destination may be Windows paths starting with a drive
+// letter, such as "C:\data". ok is false when there's no destination.
+func splitBind(bind string) (source, destination, options string, ok bool) {
+	source, rest, ok := cutBindPath(bind)
+	if !ok {
+		return "", "", "", false
+	}
+	destination, options, _ = cutBindPath(rest)
+	return source, destination, options, true
+}
+
+// cutBindPath cuts s around the colon that ends the path at its start,
+// skipping the colon of a drive letter.
+func cutBindPath(s string) (path, rest string, ok bool) {
+	start := 0
+	if isDrivePath(s) {
+		start = 2
+	}
+	i := strings.Index(s[start:], ":")
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:start+i], s[start+i+1:], true
+}
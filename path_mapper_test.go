@@ -0,0 +1,118 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPrefixPathMapper(t *testing.T) {
+	t.Parallel()
+	mapper := PrefixPathMapper{
+		"/home":              "/mnt/home",
+		"/home/gopher/src":   "/src",
+		"/Users/":            "/host/Users/",
+		`D:\projects`:        "/projects",
+		"/home/gopher/other": "/other",
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/home/gopher/src/app", "/src/app"},
+		{"/home/gopher/srcs", "/mnt/home/gopher/srcs"},
+		{"/home", "/mnt/home"},
+		{"/homes", "/homes"},
+		{"/Users/gopher", "/host/Users/gopher"},
+		{`D:\projects\app`, `/projects\app`},
+		{"/var/lib", "/var/lib"},
+	}
+	for _, tt := range tests {
+		if got := mapper.MapPath(tt.input); got != tt.expected {
+			t.Errorf("PrefixPathMapper.MapPath(%q): want %q. Got %q.", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestDrivePathMapper(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`C:\Users\gopher`, "/c/Users/gopher"},
+		{"d:/data", "/d/data"},
+		{"E:", "/e"},
+		{"/var/lib", "/var/lib"},
+		{"data", "data"},
+	}
+	var mapper DrivePathMapper
+	for _, tt := range tests {
+		if got := mapper.MapPath(tt.input); got != tt.expected {
+			t.Errorf("DrivePathMapper.MapPath(%q): want %q. Got %q.", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestCreateContainerPathMapper(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id":"4fa6e0f0c678"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.PathMapper = DrivePathMapper{}
+	hostConfig := HostConfig{
+		Binds: []string{`C:\src:/src:ro`, "data:/data", "/tmp:/tmp"},
+		Mounts: []HostMount{
+			{Type: "bind", Source: `C:\cache`, Target: "/cache"},
+			{Type: "volume", Source: "logs", Target: "/logs"},
+		},
+	}
+	_, err := client.CreateContainer(CreateContainerOptions{Config: &Config{Image: "golang"}, HostConfig: &hostConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct{ HostConfig HostConfig }
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	expectedBinds := []string{"/c/src:/src:ro", "data:/data", "/tmp:/tmp"}
+	if !reflect.DeepEqual(body.HostConfig.Binds, expectedBinds) {
+		t.Errorf("CreateContainer: wrong binds. Want %#v. Got %#v.", expectedBinds, body.HostConfig.Binds)
+	}
+	expectedMounts := []HostMount{
+		{Type: "bind", Source: "/c/cache", Target: "/cache"},
+		{Type: "volume", Source: "logs", Target: "/logs"},
+	}
+	if !reflect.DeepEqual(body.HostConfig.Mounts, expectedMounts) {
+		t.Errorf("CreateContainer: wrong mounts. Want %#v. Got %#v.", expectedMounts, body.HostConfig.Mounts)
+	}
+	if hostConfig.Binds[0] != `C:\src:/src:ro` || hostConfig.Mounts[0].Source != `C:\cache` {
+		t.Errorf("CreateContainer: should not modify the given HostConfig, got %#v", hostConfig)
+	}
+}
+
+func TestStartContainerPathMapper(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.PathMapper = DrivePathMapper{}
+	hostConfig := HostConfig{Binds: []string{`C:\src:/src:ro`, "data:/data"}}
+	if err := client.StartContainer("4fa6e0f0c678", &hostConfig); err != nil {
+		t.Fatal(err)
+	}
+	var body HostConfig
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	expectedBinds := []string{"/c/src:/src:ro", "data:/data"}
+	if !reflect.DeepEqual(body.Binds, expectedBinds) {
+		t.Errorf("StartContainer: wrong binds. Want %#v. Got %#v.", expectedBinds, body.Binds)
+	}
+	if hostConfig.Binds[0] != `C:\src:/src:ro` {
+		t.Errorf("StartContainer: should not modify the given HostConfig, got %#v", hostConfig)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import "fmt"
+
+// ErrPlatformMismatch is the error returned when an image was built for an
+// operating system or architecture that the daemon can't run.
+type ErrPlatformMismatch struct {
+	Image              string
+	ImageOS            string
+	ImageArchitecture  string
+	DaemonOS           string
+	DaemonArchitecture string
+}
+
+func (err *ErrPlatformMismatch) Error() string {
+	return fmt.Sprintf("image %s is built for %s/%s, but the daemon runs on %s/%s", err.Image, err.ImageOS, err.ImageArchitecture, err.DaemonOS, err.DaemonArchitecture)
+}
+
+// VerifyImagePlatform checks whether the given image can run on the daemon,
+// comparing the operating system and architecture of the image with the ones
+// reported by the daemon. It returns an error of type *ErrPlatformMismatch
+// when they don't match.
+//
+// Linux images are accepted by Windows daemons with experimental features
+// enabled, as they may run them using Linux Containers on Windows (LCOW).
+// Images that don't declare their platform are always accepted.
+func (c *Client) VerifyImagePlatform(image string) error {
+	img, err := c.InspectImage(image)
+	if err != nil {
+		return err
+	}
+	info, err := c.Info()
+	if err != nil {
+		return err
+	}
+	return verifyPlatform(image, img, info)
+}
+
+func verifyPlatform(name string, image *Image, info *DockerInfo) error {
+	mismatch := &ErrPlatformMismatch{
+		Image:              name,
+		ImageOS:            image.OS,
+		ImageArchitecture:  image.Architecture,
+		DaemonOS:           info.OSType,
+		DaemonArchitecture: info.Architecture,
+	}
+	if image.OS != "" && info.OSType != "" && image.OS != info.OSType {
+		lcow := info.OSType == "windows" && image.OS == "linux" && info.ExperimentalBuild
+		if !lcow {
+			return mismatch
+		}
+		// LCOW runs Linux containers in a virtual machine, the host
+		// architecture doesn't apply.
+		return nil
+	}
+	if image.Architecture != "" && info.Architecture != "" && normalizeArchitecture(image.Architecture) != normalizeArchitecture(info.Architecture) {
+		return mismatch
+	}
+	return nil
+}
+
+// normalizeArchitecture converts the architecture names reported by the
+// kernel (and thus by the daemon) to the names used in images.
+func normalizeArchitecture(arch string) string {
+	switch arch {
+	case "x86_64", "x86-64":
+		return "amd64"
+	case "aarch64", "armv8", "armv8l":
+		return "arm64"
+	case "armhf", "armel", "armv6l", "armv7l":
+		return "arm"
+	case "i386", "i486", "i586", "i686", "x86":
+		return "386"
+	}
+	return arch
+}
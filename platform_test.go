@@ -0,0 +1,78 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyPlatform(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		image    Image
+		info     DockerInfo
+		mismatch bool
+	}{
+		{Image{OS: "linux", Architecture: "amd64"}, DockerInfo{OSType: "linux", Architecture: "x86_64"}, false},
+		{Image{OS: "linux", Architecture: "arm64"}, DockerInfo{OSType: "linux", Architecture: "aarch64"}, false},
+		{Image{OS: "linux", Architecture: "arm64"}, DockerInfo{OSType: "linux", Architecture: "x86_64"}, true},
+		{Image{OS: "windows", Architecture: "amd64"}, DockerInfo{OSType: "linux", Architecture: "x86_64"}, true},
+		{Image{OS: "linux", Architecture: "amd64"}, DockerInfo{OSType: "windows", Architecture: "x86_64"}, true},
+		{Image{OS: "linux", Architecture: "arm64"}, DockerInfo{OSType: "windows", Architecture: "x86_64", ExperimentalBuild: true}, false},
+		{Image{}, DockerInfo{OSType: "linux", Architecture: "x86_64"}, false},
+	}
+	for _, tt := range tests {
+		err := verifyPlatform("img", &tt.image, &tt.info)
+		if tt.mismatch {
+			if _, ok := err.(*ErrPlatformMismatch); !ok {
+				t.Errorf("verifyPlatform(%#v, %#v): want *ErrPlatformMismatch. Got %#v.", tt.image, tt.info, err)
+			}
+		} else if err != nil {
+			t.Errorf("verifyPlatform(%#v, %#v): unexpected error: %s", tt.image, tt.info, err)
+		}
+	}
+}
+
+func TestCreateContainerVerifyPlatform(t *testing.T) {
+	t.Parallel()
+	var created bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/images/mcr.microsoft.com/windows/nanoserver/json":
+			w.Write([]byte(`{"Id":"sha256:abc","Os":"windows","Architecture":"amd64"}`))
+		case "/info":
+			w.Write([]byte(`{"OSType":"linux","Architecture":"x86_64"}`))
+		case "/containers/create":
+			created = true
+			w.Write([]byte(`{"Id":"abc"}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateContainer(CreateContainerOptions{
+		Config:         &Config{Image: "mcr.microsoft.com/windows/nanoserver"},
+		VerifyPlatform: true,
+	})
+	expected := &ErrPlatformMismatch{
+		Image:              "mcr.microsoft.com/windows/nanoserver",
+		ImageOS:            "windows",
+		ImageArchitecture:  "amd64",
+		DaemonOS:           "linux",
+		DaemonArchitecture: "x86_64",
+	}
+	if e, ok := err.(*ErrPlatformMismatch); !ok || *e != *expected {
+		t.Errorf("CreateContainer: wrong error. Want %#v. Got %#v.", expected, err)
+	}
+	if created {
+		t.Error("CreateContainer: should not create the container when the platform doesn't match")
+	}
+}
@@ -160,7 +160,7 @@ func (c *Client) ListPlugins(ctx context.Context) ([]PluginDetail, error) {
 	}
 	defer resp.Body.Close()
 	pluginDetails := make([]PluginDetail, 0)
-	if err := json.NewDecoder(resp.Body).Decode(&pluginDetails); err != nil {
+	if err := decodeJSONList(resp.Body, &pluginDetails); err != nil {
 		return nil, err
 	}
 	return pluginDetails, nil
@@ -187,7 +187,7 @@ func (c *Client) ListFilteredPlugins(opts ListFilteredPluginsOptions) ([]PluginD
 	}
 	defer resp.Body.Close()
 	pluginDetails := make([]PluginDetail, 0)
-	if err := json.NewDecoder(resp.Body).Decode(&pluginDetails); err != nil {
+	if err := decodeJSONList(resp.Body, &pluginDetails); err != nil {
 		return nil, err
 	}
 	return pluginDetails, nil
@@ -224,7 +224,7 @@ func (c *Client) InspectPlugins(name string, ctx context.Context) (*PluginDetail
 	defer resp.Body.Close()
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchPlugin{ID: name}
+			return nil, &NoSuchPlugin{ID: name, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -259,7 +259,7 @@ func (c *Client) RemovePlugin(opts RemovePluginOptions) (*PluginDetail, error) {
 	defer resp.Body.Close()
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchPlugin{ID: opts.Name}
+			return nil, &NoSuchPlugin{ID: opts.Name, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -395,7 +395,7 @@ func (c *Client) ConfigurePlugin(opts ConfigurePluginOptions) error {
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchPlugin{ID: opts.Name}
+			return &NoSuchPlugin{ID: opts.Name, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -407,6 +407,8 @@ func (c *Client) ConfigurePlugin(opts ConfigurePluginOptions) error {
 type NoSuchPlugin struct {
 	ID  string
 	Err error
+
+	errorMetadata
 }
 
 func (err *NoSuchPlugin) Error() string {
@@ -0,0 +1,200 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// PreflightReason identifies why Preflight couldn't use the endpoint.
+type PreflightReason string
+
+const (
+	// PreflightSocketNotFound means that there's no unix socket or named pipe
+	// at the path of the endpoint.
+	PreflightSocketNotFound = PreflightReason("socket not found")
+
+	// PreflightNotASocket means that the path of the endpoint exists, but
+	// it's not a unix socket.
+	PreflightNotASocket = PreflightReason("not a socket")
+
+	// PreflightPermissionDenied means that the current user isn't allowed
+	// to connect to the socket.
+	PreflightPermissionDenied = PreflightReason("permission denied")
+
+	// PreflightDaemonNotRunning means that nothing is listening on the
+	// endpoint.
+	PreflightDaemonNotRunning = PreflightReason("daemon not running")
+
+	// PreflightWrongProtocol means that something answered on the endpoint,
+	// but it doesn't speak the Docker API, or expects TLS when the client
+	// doesn't use it (or vice versa).
+	PreflightWrongProtocol = PreflightReason("wrong protocol")
+
+	// PreflightUnreachable means that the endpoint couldn't be reached for
+	// any other reason, like a timeout.
+	PreflightUnreachable = PreflightReason("unreachable")
+)
+
+var preflightHints = map[PreflightReason]string{
+	PreflightSocketNotFound:   "make sure the Docker daemon is running, it creates the socket on start",
+	PreflightNotASocket:       "check the endpoint, DOCKER_HOST may point to the wrong path",
+	PreflightPermissionDenied: "add the user to the docker group (and log in again), or run as a user that can access the socket",
+	PreflightDaemonNotRunning: "start the Docker daemon, or check the endpoint",
+	PreflightWrongProtocol:    "check the endpoint and whether the daemon requires TLS",
+	PreflightUnreachable:      "check the endpoint and the network connectivity to it",
+}
+
+// PreflightError is the error returned by Preflight when the endpoint can't
+// be used.
+type PreflightError struct {
+	Endpoint string
+	Reason   PreflightReason
+
+	// Hint is a suggestion of how to fix the problem.
+	Hint string
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *PreflightError) Error() string {
+	msg := fmt.Sprintf("cannot use Docker endpoint %s: %s", e.Endpoint, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg + " (" + e.Hint + ")"
+}
+
+// Preflight checks that the endpoint of the client is reachable and serves
+// the Docker API, returning a *PreflightError that tells what's wrong and how
+// to fix it otherwise. On unix sockets, it tells apart missing sockets,
+// permission errors and daemons that are not running.
+func (c *Client) Preflight(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	protocol := c.endpointURL.Scheme
+	address := c.endpointURL.Path
+	if protocol == unixProtocol {
+		info, err := os.Stat(address)
+		if err != nil {
+			return c.preflightError(classifyDialError(err), err)
+		}
+		if info.Mode()&os.ModeSocket == 0 {
+			return c.preflightError(PreflightNotASocket, nil)
+		}
+	}
+	if protocol != unixProtocol && protocol != namedPipeProtocol {
+		protocol = "tcp"
+		address = c.endpointURL.Host
+	}
+	var conn net.Conn
+	var err error
+	if c.TLSConfig != nil && protocol == "tcp" {
+		netDialer, ok := c.Dialer.(*net.Dialer)
+		if !ok {
+			return ErrTLSNotSupported
+		}
+		conn, err = tlsDialWithDialer(netDialer, protocol, address, c.TLSConfig)
+	} else {
+		conn, err = c.Dialer.Dial(protocol, address)
+	}
+	if err != nil {
+		return c.preflightError(classifyDialError(err), err)
+	}
+	defer conn.Close()
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-finished:
+		}
+	}()
+	if err = c.preflightPing(conn); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return c.preflightError(PreflightWrongProtocol, err)
+	}
+	return nil
+}
+
+// preflightPing pings the daemon over the given connection, checking that
+// the response comes from the Docker API.
+func (c *Client) preflightPing(conn net.Conn) error {
+	u := c.getURL("/_ping")
+	if c.endpointURL.Scheme == unixProtocol || c.endpointURL.Scheme == namedPipeProtocol {
+		u = c.getFakeNativeURL("/_ping")
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if err = req.Write(conn); err != nil {
+		return err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if resp.Header.Get("Api-Version") == "" && strings.TrimSpace(string(body)) != "OK" {
+		return fmt.Errorf("unexpected response to ping: %q", body)
+	}
+	return nil
+}
+
+func (c *Client) preflightError(reason PreflightReason, err error) *PreflightError {
+	return &PreflightError{
+		Endpoint: c.endpoint,
+		Reason:   reason,
+		Hint:     preflightHints[reason],
+		Err:      err,
+	}
+}
+
+// classifyDialError returns the reason of a failure to connect to the
+// endpoint.
+func classifyDialError(err error) PreflightReason {
+	if _, ok := err.(tls.RecordHeaderError); ok {
+		return PreflightWrongProtocol
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	switch {
+	case os.IsNotExist(err):
+		return PreflightSocketNotFound
+	case os.IsPermission(err):
+		return PreflightPermissionDenied
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	// on Windows the error number of refused connections differs from
+	// syscall.ECONNREFUSED.
+	if err == syscall.ECONNREFUSED || strings.Contains(err.Error(), "refused") {
+		return PreflightDaemonNotRunning
+	}
+	return PreflightUnreachable
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "api version header",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Api-Version", "1.40")
+				w.Write([]byte("OK"))
+			},
+		},
+		{
+			name: "old daemon",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("OK"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(tt.handler)
+		client, err := NewClient(srv.URL)
+		if err != nil {
+			srv.Close()
+			t.Fatal(err)
+		}
+		if err = client.Preflight(context.Background()); err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestPreflightWrongProtocol(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		server func(http.Handler) *httptest.Server
+	}{
+		{name: "not docker", server: httptest.NewServer},
+		{name: "client without tls", server: httptest.NewTLSServer},
+	}
+	for _, tt := range tests {
+		srv := tt.server(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>hello</html>"))
+		}))
+		client, err := NewClient("tcp://" + srv.Listener.Addr().String())
+		if err != nil {
+			srv.Close()
+			t.Fatal(err)
+		}
+		err = client.Preflight(context.Background())
+		if e, ok := err.(*PreflightError); !ok || e.Reason != PreflightWrongProtocol {
+			t.Errorf("%s: wrong error. Want *PreflightError with reason %q. Got %#v.", tt.name, PreflightWrongProtocol, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestPreflightDaemonNotRunning(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	client, err := NewClient("tcp://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Preflight(context.Background())
+	e, ok := err.(*PreflightError)
+	if !ok || e.Reason != PreflightDaemonNotRunning {
+		t.Fatalf("Preflight: wrong error. Want *PreflightError with reason %q. Got %#v.", PreflightDaemonNotRunning, err)
+	}
+	if e.Hint == "" || e.Endpoint != "tcp://"+addr {
+		t.Errorf("Preflight: wrong error details: %#v", e)
+	}
+}
+
+func TestClassifyDialError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err      error
+		expected PreflightReason
+	}{
+		{&net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.EACCES}}, PreflightPermissionDenied},
+		{&net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ENOENT}}, PreflightSocketNotFound},
+		{&net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}, PreflightDaemonNotRunning},
+		{&os.PathError{Op: "stat", Path: "/var/run/docker.sock", Err: syscall.EACCES}, PreflightPermissionDenied},
+		{&os.PathError{Op: "stat", Path: "/var/run/docker.sock", Err: syscall.ENOENT}, PreflightSocketNotFound},
+		{&net.OpError{Op: "dial", Err: &timeoutError{}}, PreflightUnreachable},
+	}
+	for _, tt := range tests {
+		if reason := classifyDialError(tt.err); reason != tt.expected {
+			t.Errorf("classifyDialError(%v): wrong reason. Want %q. Got %q.", tt.err, tt.expected, reason)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,80 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !windows
+
+package docker
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreflightUnixSocket(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "preflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	serve := func(path string, handler http.HandlerFunc) *net.UnixListener {
+		l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if handler != nil {
+			go http.Serve(l, handler)
+		}
+		return l
+	}
+
+	ok := serve(filepath.Join(dir, "ok.sock"), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.40")
+		w.Write([]byte("OK"))
+	})
+	defer ok.Close()
+	wrong := serve(filepath.Join(dir, "wrong.sock"), func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	defer wrong.Close()
+	stale := serve(filepath.Join(dir, "stale.sock"), nil)
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	if err = ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		expected PreflightReason
+	}{
+		{"ok.sock", ""},
+		{"missing.sock", PreflightSocketNotFound},
+		{"file", PreflightNotASocket},
+		{"stale.sock", PreflightDaemonNotRunning},
+		{"wrong.sock", PreflightWrongProtocol},
+	}
+	for _, tt := range tests {
+		client, err := NewClient("unix://" + filepath.Join(dir, tt.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Preflight(context.Background())
+		if tt.expected == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tt.path, err)
+			}
+			continue
+		}
+		if e, ok := err.(*PreflightError); !ok || e.Reason != tt.expected {
+			t.Errorf("%s: wrong error. Want *PreflightError with reason %q. Got %#v.", tt.path, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// anonymousVolumeLabel is the label the daemon sets on anonymous volumes,
+// which are the only ones pruned by default since API 1.42.
+const anonymousVolumeLabel = "com.docker.volume.anonymous"
+
+// pruneFilters are the filters of the prune endpoints, applied client-side by
+// the dry runs.
+type pruneFilters struct {
+	until     time.Time
+	labels    []string
+	notLabels []string
+	dangling  *bool
+	all       bool
+}
+
+func parsePruneFilters(filters map[string][]string) (pruneFilters, error) {
+	var f pruneFilters
+	for name, values := range filters {
+		switch name {
+		case "until":
+			for _, value := range values {
+				until, err := parsePruneUntil(value)
+				if err != nil {
+					return f, err
+				}
+				f.until = until
+			}
+		case "label":
+			f.labels = append(f.labels, values...)
+		case "label!":
+			f.notLabels = append(f.notLabels, values...)
+		case "dangling", "all":
+			for _, value := range values {
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return f, fmt.Errorf("invalid %s filter %q", name, value)
+				}
+				if name == "all" {
+					f.all = b
+				} else {
+					f.dangling = &b
+				}
+			}
+		default:
+			return f, fmt.Errorf("invalid filter %q", name)
+		}
+	}
+	return f, nil
+}
+
+// parsePruneUntil parses the value of the until filter, that can be a
+// duration relative to now, a unix timestamp or a RFC 3339 date.
+func parsePruneUntil(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Unix(0, int64(secs*float64(time.Second))), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid until filter %q", value)
+}
+
+// match returns whether an object created at the given time, with the given
+// labels, is selected by the filters.
+func (f pruneFilters) match(created time.Time, labels map[string]string) bool {
+	if !f.until.IsZero() && !created.Before(f.until) {
+		return false
+	}
+	for _, label := range f.labels {
+		if !matchPruneLabel(labels, label) {
+			return false
+		}
+	}
+	for _, label := range f.notLabels {
+		if matchPruneLabel(labels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPruneLabel returns whether the labels have the given label, in the
+// form "key" or "key=value".
+func matchPruneLabel(labels map[string]string, label string) bool {
+	parts := strings.SplitN(label, "=", 2)
+	value, ok := labels[parts[0]]
+	if len(parts) == 1 {
+		return ok
+	}
+	return ok && value == parts[1]
+}
+
+// pruneContainersDryRun lists the stopped containers PruneContainers would
+// remove.
+func (c *Client) pruneContainersDryRun(opts PruneContainersOptions) (*PruneContainersResults, error) {
+	filters, err := parsePruneFilters(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.ListContainers(ListContainersOptions{All: true, Size: true, Context: opts.Context})
+	if err != nil {
+		return nil, err
+	}
+	var results PruneContainersResults
+	for _, container := range containers {
+		switch container.State {
+		case "created", "exited", "dead":
+		default:
+			continue
+		}
+		if !filters.match(time.Unix(container.Created, 0), container.Labels) {
+			continue
+		}
+		results.ContainersDeleted = append(results.ContainersDeleted, container.ID)
+		results.SpaceReclaimed += container.SizeRw
+	}
+	return &results, nil
+}
+
+// pruneImagesDryRun lists the images PruneImages would remove: the dangling
+// ones by default, or all the images not used by a container when the
+// dangling filter is false.
+func (c *Client) pruneImagesDryRun(opts PruneImagesOptions) (*PruneImagesResults, error) {
+	filters, err := parsePruneFilters(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	danglingOnly := filters.dangling == nil || *filters.dangling
+	images, err := c.ListImages(ListImagesOptions{Digests: true, Context: opts.Context})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.ListContainers(ListContainersOptions{All: true, Context: opts.Context})
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		used[container.ImageID] = true
+	}
+	var results PruneImagesResults
+	for _, image := range images {
+		if used[image.ID] {
+			continue
+		}
+		var refs []string
+		for _, tag := range image.RepoTags {
+			if tag != "<none>:<none>" {
+				refs = append(refs, tag)
+			}
+		}
+		if danglingOnly && len(refs) > 0 {
+			continue
+		}
+		if !filters.match(time.Unix(image.Created, 0), image.Labels) {
+			continue
+		}
+		for _, digest := range image.RepoDigests {
+			if digest != "<none>@<none>" {
+				refs = append(refs, digest)
+			}
+		}
+		for _, ref := range refs {
+			results.ImagesDeleted = append(results.ImagesDeleted, struct{ Untagged, Deleted string }{Untagged: ref})
+		}
+		results.ImagesDeleted = append(results.ImagesDeleted, struct{ Untagged, Deleted string }{Deleted: image.ID})
+		results.SpaceReclaimed += image.Size
+	}
+	return &results, nil
+}
+
+// pruneVolumesDryRun lists the unused volumes PruneVolumes would remove.
+func (c *Client) pruneVolumesDryRun(opts PruneVolumesOptions) (*PruneVolumesResults, error) {
+	filters, err := parsePruneFilters(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := c.ListVolumes(ListVolumesOptions{
+		Filters: map[string][]string{"dangling": {"true"}},
+		Context: opts.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the daemon prunes by the version of the API of the request, not its
+	// own.
+	version, err := c.pruneAPIVersion()
+	if err != nil {
+		return nil, err
+	}
+	anonymousOnly := !filters.all && version != nil && version.GreaterThanOrEqualTo(apiVersion142)
+	var results PruneVolumesResults
+	for _, volume := range volumes {
+		if anonymousOnly {
+			if _, ok := volume.Labels[anonymousVolumeLabel]; !ok {
+				continue
+			}
+		}
+		if !filters.match(volume.CreatedAt, volume.Labels) {
+			continue
+		}
+		results.VolumesDeleted = append(results.VolumesDeleted, volume.Name)
+	}
+	if len(results.VolumesDeleted) == 0 {
+		return &results, nil
+	}
+	usage, err := c.DiskUsage(DiskUsageOptions{Context: opts.Context})
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[string]bool, len(results.VolumesDeleted))
+	for _, name := range results.VolumesDeleted {
+		candidates[name] = true
+	}
+	for _, volume := range usage.Volumes {
+		if candidates[volume.Name] && volume.UsageData != nil && volume.UsageData.Size > 0 {
+			results.SpaceReclaimed += volume.UsageData.Size
+		}
+	}
+	return &results, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPruneDryRunServer(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("dry run: unexpected request %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		path := r.URL.Path
+		if strings.HasPrefix(path, "/v1.") {
+			path = path[strings.Index(path[1:], "/")+1:]
+		}
+		body, ok := responses[path]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestPruneContainersDryRun(t *testing.T) {
+	t.Parallel()
+	old := strconv.FormatInt(time.Now().Add(-48*time.Hour).Unix(), 10)
+	recent := strconv.FormatInt(time.Now().Unix(), 10)
+	server := newPruneDryRunServer(t, map[string]string{
+		"/containers/json": `[
+			{"Id": "running", "State": "running", "Created": ` + old + `, "SizeRw": 1},
+			{"Id": "paused", "State": "paused", "Created": ` + old + `, "SizeRw": 2},
+			{"Id": "exited", "State": "exited", "Created": ` + old + `, "SizeRw": 4},
+			{"Id": "created", "State": "created", "Created": ` + old + `, "SizeRw": 8, "Labels": {"keep": "yes"}},
+			{"Id": "dead", "State": "dead", "Created": ` + old + `, "SizeRw": 16},
+			{"Id": "recent", "State": "exited", "Created": ` + recent + `, "SizeRw": 32}
+		]`,
+	})
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := client.PruneContainers(PruneContainersOptions{
+		DryRun:  true,
+		Filters: map[string][]string{"until": {"24h"}, "label!": {"keep=yes"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PruneContainersResults{ContainersDeleted: []string{"exited", "dead"}, SpaceReclaimed: 20}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("PruneContainers: wrong dry run results. Want %#v. Got %#v.", expected, results)
+	}
+}
+
+func TestPruneImagesDryRun(t *testing.T) {
+	t.Parallel()
+	server := newPruneDryRunServer(t, map[string]string{
+		"/images/json": `[
+			{"Id": "sha256:tagged", "RepoTags": ["app:latest"], "Size": 1},
+			{"Id": "sha256:dangling", "RepoTags": ["<none>:<none>"], "RepoDigests": ["app@sha256:abc"], "Size": 2},
+			{"Id": "sha256:used", "Size": 4},
+			{"Id": "sha256:labeled", "Size": 8, "Labels": {"keep": ""}}
+		]`,
+		"/containers/json": `[{"Id": "c1", "ImageID": "sha256:used"}]`,
+	})
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name     string
+		filters  map[string][]string
+		expected *PruneImagesResults
+	}{
+		{
+			name: "dangling",
+			expected: &PruneImagesResults{
+				ImagesDeleted: []struct{ Untagged, Deleted string }{
+					{Untagged: "app@sha256:abc"},
+					{Deleted: "sha256:dangling"},
+					{Deleted: "sha256:labeled"},
+				},
+				SpaceReclaimed: 10,
+			},
+		},
+		{
+			name:    "all",
+			filters: map[string][]string{"dangling": {"false"}, "label!": {"keep"}},
+			expected: &PruneImagesResults{
+				ImagesDeleted: []struct{ Untagged, Deleted string }{
+					{Untagged: "app:latest"},
+					{Deleted: "sha256:tagged"},
+					{Untagged: "app@sha256:abc"},
+					{Deleted: "sha256:dangling"},
+				},
+				SpaceReclaimed: 3,
+			},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			results, err := client.PruneImages(PruneImagesOptions{DryRun: true, Filters: test.filters})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(results, test.expected) {
+				t.Errorf("PruneImages: wrong dry run results. Want %#v. Got %#v.", test.expected, results)
+			}
+		})
+	}
+}
+
+func TestPruneVolumesDryRun(t *testing.T) {
+	t.Parallel()
+	server := newPruneDryRunServer(t, map[string]string{
+		"/volumes": `{"Volumes": [
+			{"Name": "named"},
+			{"Name": "anonymous", "Labels": {"com.docker.volume.anonymous": ""}},
+			{"Name": "backup", "Labels": {"com.docker.volume.anonymous": "", "backup": "true"}}
+		]}`,
+		"/system/df": `{"Volumes": [
+			{"Name": "named", "UsageData": {"RefCount": 0, "Size": 1}},
+			{"Name": "anonymous", "UsageData": {"RefCount": 0, "Size": 2}},
+			{"Name": "backup", "UsageData": {"RefCount": 0, "Size": -1}},
+			{"Name": "used", "UsageData": {"RefCount": 1, "Size": 8}}
+		]}`,
+	})
+	defer server.Close()
+	var tests = []struct {
+		name       string
+		apiVersion APIVersion
+		requested  string
+		filters    map[string][]string
+		expected   *PruneVolumesResults
+	}{
+		{
+			name:       "before 1.42",
+			apiVersion: apiVersion139,
+			expected:   &PruneVolumesResults{VolumesDeleted: []string{"named", "anonymous", "backup"}, SpaceReclaimed: 3},
+		},
+		{
+			name:       "requested before 1.42",
+			apiVersion: apiVersion143,
+			requested:  "1.41",
+			expected:   &PruneVolumesResults{VolumesDeleted: []string{"named", "anonymous", "backup"}, SpaceReclaimed: 3},
+		},
+		{
+			name:       "anonymous",
+			apiVersion: apiVersion143,
+			filters:    map[string][]string{"label!": {"backup"}},
+			expected:   &PruneVolumesResults{VolumesDeleted: []string{"anonymous"}, SpaceReclaimed: 2},
+		},
+		{
+			name:       "all",
+			apiVersion: apiVersion143,
+			filters:    map[string][]string{"all": {"true"}, "label": {"backup=true"}},
+			expected:   &PruneVolumesResults{VolumesDeleted: []string{"backup"}},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			client, err := NewVersionedClient(server.URL, test.requested)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.SkipServerVersionCheck = true
+			client.serverAPIVersion = test.apiVersion
+			results, err := client.PruneVolumes(PruneVolumesOptions{DryRun: true, Filters: test.filters})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(results, test.expected) {
+				t.Errorf("PruneVolumes: wrong dry run results. Want %#v. Got %#v.", test.expected, results)
+			}
+		})
+	}
+}
+
+func TestPruneDryRunInvalidFilters(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(&FakeRoundTripper{message: "[]", status: http.StatusOK})
+	for _, filters := range []map[string][]string{
+		{"until": {"yesterday"}},
+		{"dangling": {"maybe"}},
+		{"unknown": {"x"}},
+	} {
+		if _, err := client.PruneImages(PruneImagesOptions{DryRun: true, Filters: filters}); err == nil {
+			t.Errorf("PruneImages: expected error for filters %v, got <nil>", filters)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidRequestPath is the error returned by Do when the path of the
+// request isn't absolute.
+var ErrInvalidRequestPath = errors.New("request path must start with /")
+
+// Request is a request to an arbitrary endpoint of the Docker API, sent with
+// Client.Do.
+type Request struct {
+	// Method is the HTTP method of the request. Defaults to GET.
+	Method string
+
+	// Path is the path of the endpoint, without the API version prefix,
+	// e.g. "/containers/json". The version prefix is added by the client,
+	// as in any other request.
+	Path string
+
+	// Query is encoded as the query string of the request.
+	Query url.Values
+
+	// Body of the request. An io.Reader or a []byte is sent as is, any other
+	// value is encoded as JSON.
+	Body interface{}
+
+	// Headers are added to the request, overriding the ones set by the
+	// client, like Content-Type.
+	Headers map[string]string
+}
+
+// Response is the response to a Request sent with Client.Do.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+
+	// Body is the body of the response. It must be closed by the caller,
+	// unless Decode is used.
+	Body io.ReadCloser
+}
+
+// Decode decodes the JSON body of the response into v, closing the body.
+func (r *Response) Decode(v interface{}) error {
+	defer r.Body.Close()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// Do sends a request to an arbitrary endpoint of the Docker API, going through
+// the same connection, TLS and API version handling as the other methods of
+// the client. It's meant for endpoints that are not wrapped by the package
+// yet.
+//
+// Like in the other methods, failed requests (status code 400 or above) are
+// reported as an *Error, containing the message sent by the daemon.
+func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
+	if !strings.HasPrefix(req.Path, "/") {
+		return nil, ErrInvalidRequestPath
+	}
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	path := req.Path
+	if len(req.Query) > 0 {
+		path += "?" + req.Query.Encode()
+	}
+	opts := doOptions{context: ctx, headers: req.Headers}
+	switch body := req.Body.(type) {
+	case nil:
+	case io.Reader:
+		opts.body = body
+	case []byte:
+		opts.body = bytes.NewReader(body)
+	default:
+		opts.data = body
+	}
+	resp, err := c.do(method, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       resp.Body,
+	}, nil
+}
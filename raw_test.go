@@ -0,0 +1,134 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+	fakeRT := FakeRoundTripper{
+		message: `{"Name":"thing"}`,
+		status:  http.StatusOK,
+		header:  map[string]string{"Docker-Experimental": "true"},
+	}
+	client := newTestClient(&fakeRT)
+	client.requestedAPIVersion, _ = NewAPIVersion("1.40")
+	resp, err := client.Do(context.Background(), Request{
+		Path:    "/things/thing",
+		Query:   url.Values{"verbose": {"1"}},
+		Headers: map[string]string{"X-Registry-Auth": "token"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Do: wrong status. Want %d. Got %d.", http.StatusOK, resp.StatusCode)
+	}
+	if resp.Header.Get("Docker-Experimental") != "true" {
+		t.Errorf("Do: missing response header. Got %v.", resp.Header)
+	}
+	var thing struct{ Name string }
+	if err = resp.Decode(&thing); err != nil {
+		t.Fatal(err)
+	}
+	if thing.Name != "thing" {
+		t.Errorf("Do: wrong body. Want %q. Got %q.", "thing", thing.Name)
+	}
+	req := fakeRT.requests[0]
+	if req.Method != "GET" {
+		t.Errorf("Do: wrong method. Want GET. Got %s.", req.Method)
+	}
+	if req.URL.Path != "/v1.40/things/thing" {
+		t.Errorf("Do: wrong path. Want %q. Got %q.", "/v1.40/things/thing", req.URL.Path)
+	}
+	if req.URL.RawQuery != "verbose=1" {
+		t.Errorf("Do: wrong query. Want %q. Got %q.", "verbose=1", req.URL.RawQuery)
+	}
+	if req.Header.Get("X-Registry-Auth") != "token" {
+		t.Errorf("Do: wrong auth header. Want %q. Got %q.", "token", req.Header.Get("X-Registry-Auth"))
+	}
+}
+
+func TestDoBody(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		body        interface{}
+		headers     map[string]string
+		expected    string
+		contentType string
+	}{
+		{
+			name:        "json",
+			body:        map[string]string{"Name": "thing"},
+			expected:    `{"Name":"thing"}`,
+			contentType: "application/json",
+		},
+		{
+			name:        "reader",
+			body:        strings.NewReader("raw data"),
+			headers:     map[string]string{"Content-Type": "application/x-tar"},
+			expected:    "raw data",
+			contentType: "application/x-tar",
+		},
+		{
+			name:        "bytes",
+			body:        []byte("raw data"),
+			expected:    "raw data",
+			contentType: "plain/text",
+		},
+	}
+	for _, tt := range tests {
+		fakeRT := FakeRoundTripper{message: "", status: http.StatusCreated}
+		client := newTestClient(&fakeRT)
+		resp, err := client.Do(context.Background(), Request{
+			Method:  "POST",
+			Path:    "/things/create",
+			Body:    tt.body,
+			Headers: tt.headers,
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		resp.Body.Close()
+		req := fakeRT.requests[0]
+		body, _ := ioutil.ReadAll(req.Body)
+		if string(body) != tt.expected {
+			t.Errorf("%s: wrong body. Want %q. Got %q.", tt.name, tt.expected, string(body))
+		}
+		if ct := req.Header.Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: wrong content type. Want %q. Got %q.", tt.name, tt.contentType, ct)
+		}
+	}
+}
+
+func TestDoError(t *testing.T) {
+	t.Parallel()
+	fakeRT := FakeRoundTripper{message: `{"message":"page not found"}`, status: http.StatusNotFound}
+	client := newTestClient(&fakeRT)
+	_, err := client.Do(context.Background(), Request{Path: "/things/unknown"})
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusNotFound || e.Message != "page not found" {
+		t.Errorf("Do: wrong error. Want *Error with status 404. Got %#v.", err)
+	}
+}
+
+func TestDoInvalidPath(t *testing.T) {
+	t.Parallel()
+	fakeRT := FakeRoundTripper{status: http.StatusOK}
+	client := newTestClient(&fakeRT)
+	if _, err := client.Do(context.Background(), Request{Path: "things"}); err != ErrInvalidRequestPath {
+		t.Errorf("Do: wrong error. Want %#v. Got %#v.", ErrInvalidRequestPath, err)
+	}
+	if len(fakeRT.requests) != 0 {
+		t.Errorf("Do: unexpected requests: %v", fakeRT.requests)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package readiness provides functions for waiting until a container is ready
+// to serve requests: until a published port accepts connections, an HTTP
+// endpoint responds with the expected status or a line shows up in the logs.
+package readiness
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	docker "github.com/abrechon/go-dockerclient"
+)
+
+// pollInterval is the interval between two attempts of the probes.
+var pollInterval = 100 * time.Millisecond
+
+// ErrLogsClosed is the error returned by WaitForLogLine when the log stream of
+// the container ends before a matching line is found.
+var ErrLogsClosed = errors.New("readiness: log stream ended without a matching line")
+
+// ContainerNotRunningError is the error returned when the container being
+// probed is not running, meaning that it will never become ready.
+type ContainerNotRunningError struct {
+	ID    string
+	State string
+}
+
+func (err *ContainerNotRunningError) Error() string {
+	return fmt.Sprintf("readiness: container %s is not running (state: %s)", err.ID, err.State)
+}
+
+// WaitForPort waits until the given port of the container is published on
+// the host and, for TCP ports, until it accepts connections. The port is the
+// container port number (for example "8080") and proto is either "tcp" or
+// "udp", defaulting to "tcp".
+//
+// It returns the address ("host:port") of the published port, which can be
+// used to reach the service from the machine running the client. Ports
+// published on all interfaces are reachable using the host of the client
+// endpoint, or 127.0.0.1 when connecting through a unix socket.
+func WaitForPort(ctx context.Context, client *docker.Client, containerID, port, proto string) (string, error) {
+	if proto == "" {
+		proto = "tcp"
+	}
+	key := docker.Port(port + "/" + proto)
+	var dialer net.Dialer
+	for {
+		container, err := client.InspectContainerWithContext(containerID, ctx)
+		if err != nil {
+			return "", err
+		}
+		if !container.State.Running {
+			return "", &ContainerNotRunningError{ID: containerID, State: container.State.StateString()}
+		}
+		if container.NetworkSettings != nil {
+			for _, binding := range container.NetworkSettings.Ports[key] {
+				addr := net.JoinHostPort(bindingHost(client, binding.HostIP), binding.HostPort)
+				if proto != "tcp" {
+					return addr, nil
+				}
+				conn, err := dialer.DialContext(ctx, "tcp", addr)
+				if err == nil {
+					conn.Close()
+					return addr, nil
+				}
+			}
+		}
+		if err := sleep(ctx); err != nil {
+			return "", err
+		}
+	}
+}
+
+// WaitForHTTP waits until a GET request to the given URL returns the expected
+// status code. The URL is usually built from the address returned by
+// WaitForPort. Connection errors and unexpected status codes are retried
+// until the context is done.
+func WaitForHTTP(ctx context.Context, url string, expectedStatus int) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode == expectedStatus {
+				return nil
+			}
+		}
+		if err := sleep(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitForLogLine follows the logs of the container, from the beginning, until
+// a line in either stdout or stderr matches the given regular expression. It
+// returns ErrLogsClosed if the container exits before a matching line is
+// found.
+func WaitForLogLine(ctx context.Context, client *docker.Client, containerID string, re *regexp.Regexp) error {
+	container, err := client.InspectContainerWithContext(containerID, ctx)
+	if err != nil {
+		return err
+	}
+	tty := container.Config != nil && container.Config.Tty
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r, w := io.Pipe()
+	errs := make(chan error, 1)
+	go func() {
+		err := client.Logs(docker.LogsOptions{
+			Context:      ctx,
+			Container:    containerID,
+			OutputStream: w,
+			ErrorStream:  w,
+			Follow:       true,
+			Stdout:       true,
+			Stderr:       true,
+			RawTerminal:  tty,
+		})
+		w.CloseWithError(err)
+		errs <- err
+	}()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if re.MatchString(strings.TrimSuffix(scanner.Text(), "\r")) {
+			cancel()
+			r.Close()
+			<-errs
+			return nil
+		}
+	}
+	r.Close()
+	if err := <-errs; err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return ErrLogsClosed
+}
+
+// bindingHost returns the host that should be used to connect to a port
+// published on the given host IP.
+func bindingHost(client *docker.Client, hostIP string) string {
+	if hostIP != "" && hostIP != "0.0.0.0" && hostIP != "::" {
+		return hostIP
+	}
+	u, err := url.Parse(client.Endpoint())
+	if err != nil {
+		return "127.0.0.1"
+	}
+	switch u.Scheme {
+	case "unix", "npipe":
+		return "127.0.0.1"
+	}
+	if host := u.Hostname(); host != "" {
+		return host
+	}
+	return "127.0.0.1"
+}
+
+func sleep(ctx context.Context) error {
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package readiness
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	docker "github.com/abrechon/go-dockerclient"
+)
+
+func init() {
+	pollInterval = 5 * time.Millisecond
+}
+
+type fakeDaemon struct {
+	container func() docker.Container
+	logs      []byte
+	follow    bool
+}
+
+func (d *fakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/containers/c1/json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d.container())
+	case "/containers/c1/logs":
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+		w.Write(d.logs)
+		if d.follow {
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func newFakeDaemon(t *testing.T, d *fakeDaemon) (*docker.Client, *httptest.Server) {
+	server := httptest.NewServer(d)
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+func logFrame(stream byte, data string) []byte {
+	frame := make([]byte, 8, 8+len(data))
+	frame[0] = stream
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+func TestWaitForPort(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, hostPort, _ := net.SplitHostPort(listener.Addr().String())
+	var calls int32
+	client, server := newFakeDaemon(t, &fakeDaemon{container: func() docker.Container {
+		container := docker.Container{ID: "c1", State: docker.State{Running: true}, NetworkSettings: &docker.NetworkSettings{}}
+		if atomic.AddInt32(&calls, 1) > 2 {
+			container.NetworkSettings.Ports = map[docker.Port][]docker.PortBinding{
+				"80/tcp": {{HostIP: "0.0.0.0", HostPort: hostPort}},
+			}
+		}
+		return container
+	}})
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addr, err := WaitForPort(ctx, client, "c1", "80", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "127.0.0.1:" + hostPort; addr != expected {
+		t.Errorf("WaitForPort: wrong address. Want %q. Got %q.", expected, addr)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("WaitForPort: wrong number of inspections. Want 3. Got %d.", n)
+	}
+}
+
+func TestWaitForPortUDP(t *testing.T) {
+	t.Parallel()
+	client, server := newFakeDaemon(t, &fakeDaemon{container: func() docker.Container {
+		return docker.Container{ID: "c1", State: docker.State{Running: true}, NetworkSettings: &docker.NetworkSettings{
+			Ports: map[docker.Port][]docker.PortBinding{
+				"53/udp": {{HostIP: "10.0.0.1", HostPort: "5353"}},
+			},
+		}}
+	}})
+	defer server.Close()
+	addr, err := WaitForPort(context.Background(), client, "c1", "53", "udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "10.0.0.1:5353"; addr != expected {
+		t.Errorf("WaitForPort: wrong address. Want %q. Got %q.", expected, addr)
+	}
+}
+
+func TestWaitForPortContainerNotRunning(t *testing.T) {
+	t.Parallel()
+	client, server := newFakeDaemon(t, &fakeDaemon{container: func() docker.Container {
+		return docker.Container{ID: "c1", State: docker.State{ExitCode: 1}}
+	}})
+	defer server.Close()
+	_, err := WaitForPort(context.Background(), client, "c1", "80", "tcp")
+	if e, ok := err.(*ContainerNotRunningError); !ok || e.ID != "c1" {
+		t.Errorf("WaitForPort: wrong error. Want *ContainerNotRunningError. Got %#v.", err)
+	}
+}
+
+func TestWaitForPortTimeout(t *testing.T) {
+	t.Parallel()
+	client, server := newFakeDaemon(t, &fakeDaemon{container: func() docker.Container {
+		return docker.Container{ID: "c1", State: docker.State{Running: true}}
+	}})
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := WaitForPort(ctx, client, "c1", "80", "tcp")
+	if err == nil {
+		t.Fatal("WaitForPort: unexpected <nil> error")
+	}
+}
+
+func TestWaitForHTTP(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForHTTP(ctx, server.URL+"/health", http.StatusNoContent); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("WaitForHTTP: wrong number of requests. Want 3. Got %d.", n)
+	}
+}
+
+func TestWaitForHTTPTimeout(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := WaitForHTTP(ctx, server.URL, http.StatusOK); err != context.DeadlineExceeded {
+		t.Errorf("WaitForHTTP: wrong error. Want %#v. Got %#v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWaitForLogLine(t *testing.T) {
+	t.Parallel()
+	var logs []byte
+	logs = append(logs, logFrame(1, "starting\n")...)
+	logs = append(logs, logFrame(2, "listening on ")...)
+	logs = append(logs, logFrame(2, ":8080\n")...)
+	client, server := newFakeDaemon(t, &fakeDaemon{
+		container: func() docker.Container { return docker.Container{ID: "c1", Config: &docker.Config{}} },
+		logs:      logs,
+		follow:    true,
+	})
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForLogLine(ctx, client, "c1", regexp.MustCompile(`^listening on :\d+$`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForLogLineTTY(t *testing.T) {
+	t.Parallel()
+	client, server := newFakeDaemon(t, &fakeDaemon{
+		container: func() docker.Container { return docker.Container{ID: "c1", Config: &docker.Config{Tty: true}} },
+		logs:      []byte("booting\r\nready\r\n"),
+		follow:    true,
+	})
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForLogLine(ctx, client, "c1", regexp.MustCompile(`^ready$`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForLogLineClosed(t *testing.T) {
+	t.Parallel()
+	client, server := newFakeDaemon(t, &fakeDaemon{
+		container: func() docker.Container { return docker.Container{ID: "c1", Config: &docker.Config{}} },
+		logs:      logFrame(1, "exiting\n"),
+	})
+	defer server.Close()
+	err := WaitForLogLine(context.Background(), client, "c1", regexp.MustCompile("ready"))
+	if err != ErrLogsClosed {
+		t.Errorf("WaitForLogLine: wrong error. Want %#v. Got %#v.", ErrLogsClosed, err)
+	}
+}
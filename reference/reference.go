@@ -0,0 +1,179 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package reference provides functions for parsing and normalizing image
+// references, like "ubuntu", "quay.io/coreos/etcd:v3.3" or
+// "busybox@sha256:4a731fb46adc5cefe3ae374a8b6020fc1b6ad667a279647766e9a3cd89f6fa92".
+package reference
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultDomain is the domain of images that don't specify a registry.
+	DefaultDomain = "docker.io"
+
+	// DefaultTag is the tag used when a reference has neither a tag nor a
+	// digest.
+	DefaultTag = "latest"
+
+	legacyDefaultDomain = "index.docker.io"
+	officialRepoPrefix  = "library/"
+)
+
+var (
+	// ErrInvalidReference is returned when the reference doesn't match the
+	// reference grammar.
+	ErrInvalidReference = errors.New("invalid reference format")
+
+	// ErrInvalidTag is returned when the tag is not valid.
+	ErrInvalidTag = errors.New("invalid tag format")
+
+	// ErrInvalidDigest is returned when the digest is not valid.
+	ErrInvalidDigest = errors.New("invalid digest format")
+
+	// ErrNameNotCanonical is returned when the name contains uppercase
+	// characters.
+	ErrNameNotCanonical = errors.New("repository name must be lowercase")
+
+	componentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	domainRegexp    = regexp.MustCompile(`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+	tagRegexp       = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// Reference is a parsed image reference. The name is always fully qualified,
+// that is, Domain is never empty and images in the default domain without a
+// namespace have Path prefixed by "library/".
+type Reference struct {
+	Domain string
+	Path   string
+	Tag    string
+	Digest string
+}
+
+// ParseReference parses the given reference, filling in the default domain
+// (and the "library/" namespace for official images) when they're omitted.
+// It doesn't add the default tag, see Normalize for that.
+func ParseReference(s string) (Reference, error) {
+	var ref Reference
+	if s == "" {
+		return ref, ErrInvalidReference
+	}
+	if i := strings.Index(s, "@"); i >= 0 {
+		ref.Digest = s[i+1:]
+		if !digestRegexp.MatchString(ref.Digest) {
+			return Reference{}, ErrInvalidDigest
+		}
+		s = s[:i]
+	}
+	if i := strings.LastIndex(s, ":"); i >= 0 && !strings.Contains(s[i+1:], "/") {
+		ref.Tag = s[i+1:]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return Reference{}, ErrInvalidTag
+		}
+		s = s[:i]
+	}
+	ref.Domain, ref.Path = splitDomain(s)
+	if !domainRegexp.MatchString(ref.Domain) {
+		return Reference{}, ErrInvalidReference
+	}
+	if strings.ToLower(ref.Path) != ref.Path {
+		return Reference{}, ErrNameNotCanonical
+	}
+	for _, component := range strings.Split(ref.Path, "/") {
+		if !componentRegexp.MatchString(component) {
+			return Reference{}, ErrInvalidReference
+		}
+	}
+	return ref, nil
+}
+
+func splitDomain(name string) (domain, path string) {
+	i := strings.Index(name, "/")
+	if i < 0 || (!strings.ContainsAny(name[:i], ".:") && name[:i] != "localhost" && strings.ToLower(name[:i]) == name[:i]) {
+		domain, path = DefaultDomain, name
+	} else {
+		domain, path = name[:i], name[i+1:]
+	}
+	if domain == legacyDefaultDomain {
+		domain = DefaultDomain
+	}
+	if domain == DefaultDomain && !strings.Contains(path, "/") {
+		path = officialRepoPrefix + path
+	}
+	return domain, path
+}
+
+// Normalize returns the fully qualified form of the given reference, adding
+// the default domain and the default tag when they're omitted. For example,
+// "ubuntu" becomes "docker.io/library/ubuntu:latest".
+func Normalize(s string) (string, error) {
+	ref, err := ParseReference(s)
+	if err != nil {
+		return "", err
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = DefaultTag
+	}
+	return ref.String(), nil
+}
+
+// Name returns the fully qualified repository name, without tag or digest.
+func (r Reference) Name() string {
+	return r.Domain + "/" + r.Path
+}
+
+// String returns the fully qualified reference.
+func (r Reference) String() string {
+	return r.Name() + r.suffix()
+}
+
+// Familiar returns the shortest form of the reference, the one users
+// usually type, omitting the default domain and the "library/" namespace.
+func (r Reference) Familiar() string {
+	return r.FamiliarName() + r.suffix()
+}
+
+// FamiliarName returns the shortest form of the repository name, without tag
+// or digest.
+func (r Reference) FamiliarName() string {
+	if r.Domain != DefaultDomain {
+		return r.Name()
+	}
+	return strings.TrimPrefix(r.Path, officialRepoPrefix)
+}
+
+func (r Reference) suffix() string {
+	var s string
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// WithTag returns a copy of the reference with the given tag.
+func (r Reference) WithTag(tag string) (Reference, error) {
+	if !tagRegexp.MatchString(tag) {
+		return Reference{}, ErrInvalidTag
+	}
+	r.Tag = tag
+	return r, nil
+}
+
+// WithDigest returns a copy of the reference with the given digest, in the
+// form "algorithm:hex".
+func (r Reference) WithDigest(digest string) (Reference, error) {
+	if !digestRegexp.MatchString(digest) {
+		return Reference{}, ErrInvalidDigest
+	}
+	r.Digest = digest
+	return r, nil
+}
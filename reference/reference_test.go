@@ -0,0 +1,111 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reference
+
+import "testing"
+
+const testDigest = "sha256:4a731fb46adc5cefe3ae374a8b6020fc1b6ad667a279647766e9a3cd89f6fa92"
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input    string
+		expected Reference
+		familiar string
+	}{
+		{"ubuntu", Reference{Domain: "docker.io", Path: "library/ubuntu"}, "ubuntu"},
+		{"ubuntu:18.04", Reference{Domain: "docker.io", Path: "library/ubuntu", Tag: "18.04"}, "ubuntu:18.04"},
+		{"tsuru/python", Reference{Domain: "docker.io", Path: "tsuru/python"}, "tsuru/python"},
+		{"index.docker.io/library/busybox", Reference{Domain: "docker.io", Path: "library/busybox"}, "busybox"},
+		{"localhost/app", Reference{Domain: "localhost", Path: "app"}, "localhost/app"},
+		{"localhost:5000/samalba/hipache:v1", Reference{Domain: "localhost:5000", Path: "samalba/hipache", Tag: "v1"}, "localhost:5000/samalba/hipache:v1"},
+		{"quay.io/coreos/etcd@" + testDigest, Reference{Domain: "quay.io", Path: "coreos/etcd", Digest: testDigest}, "quay.io/coreos/etcd@" + testDigest},
+		{"busybox:latest@" + testDigest, Reference{Domain: "docker.io", Path: "library/busybox", Tag: "latest", Digest: testDigest}, "busybox:latest@" + testDigest},
+	}
+	for _, tt := range tests {
+		ref, err := ParseReference(tt.input)
+		if err != nil {
+			t.Errorf("ParseReference(%q): unexpected error: %s", tt.input, err)
+			continue
+		}
+		if ref != tt.expected {
+			t.Errorf("ParseReference(%q): wrong reference. Want %#v. Got %#v.", tt.input, tt.expected, ref)
+		}
+		if familiar := ref.Familiar(); familiar != tt.familiar {
+			t.Errorf("ParseReference(%q).Familiar(): want %q. Got %q.", tt.input, tt.familiar, familiar)
+		}
+	}
+}
+
+func TestParseReferenceInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input    string
+		expected error
+	}{
+		{"", ErrInvalidReference},
+		{"Ubuntu", ErrNameNotCanonical},
+		{"ubuntu:", ErrInvalidTag},
+		{"ubuntu@sha256:123", ErrInvalidDigest},
+		{"library//ubuntu", ErrInvalidReference},
+		{"-ubuntu", ErrInvalidReference},
+	}
+	for _, tt := range tests {
+		if _, err := ParseReference(tt.input); err != tt.expected {
+			t.Errorf("ParseReference(%q): wrong error. Want %v. Got %v.", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ubuntu", "docker.io/library/ubuntu:latest"},
+		{"tsuru/python:2.7", "docker.io/tsuru/python:2.7"},
+		{"gcr.io/project/app", "gcr.io/project/app:latest"},
+		{"busybox@" + testDigest, "docker.io/library/busybox@" + testDigest},
+	}
+	for _, tt := range tests {
+		normalized, err := Normalize(tt.input)
+		if err != nil {
+			t.Errorf("Normalize(%q): unexpected error: %s", tt.input, err)
+			continue
+		}
+		if normalized != tt.expected {
+			t.Errorf("Normalize(%q): want %q. Got %q.", tt.input, tt.expected, normalized)
+		}
+	}
+}
+
+func TestWithTagAndDigest(t *testing.T) {
+	t.Parallel()
+	ref, err := ParseReference("ubuntu:18.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withDigest, err := ref.WithDigest(testDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "docker.io/library/ubuntu:18.04@" + testDigest; withDigest.String() != expected {
+		t.Errorf("WithDigest: want %q. Got %q.", expected, withDigest.String())
+	}
+	if _, err = ref.WithDigest("sha256:xyz"); err != ErrInvalidDigest {
+		t.Errorf("WithDigest: wrong error. Want %v. Got %v.", ErrInvalidDigest, err)
+	}
+	withTag, err := ref.WithTag("19.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "ubuntu:19.04"; withTag.Familiar() != expected {
+		t.Errorf("WithTag: want %q. Got %q.", expected, withTag.Familiar())
+	}
+	if ref.Tag != "18.04" {
+		t.Errorf("WithTag: should not modify the original reference, got tag %q", ref.Tag)
+	}
+}
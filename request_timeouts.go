@@ -0,0 +1,124 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestTimeouts are the timeouts of the calls to the daemon, by class of
+// endpoint, for the clients that can't use a client-wide timeout in
+// HTTPClient: such a timeout interrupts the streams that are meant to last,
+// like followed logs and events, while no timeout lets calls hang forever on
+// an unresponsive daemon.
+//
+// The timeouts only apply to the calls without an earlier deadline in their
+// context. Zero timeouts disable them.
+type RequestTimeouts struct {
+	// Short is the timeout of the calls the daemon answers right away, like
+	// inspecting and listing objects, creating or removing them and pings.
+	Short time.Duration
+
+	// Long is the timeout of the calls that wait on containers or do
+	// heavy work: stopping, restarting and waiting for containers,
+	// committing them, pruning, installing plugins and joining swarms.
+	Long time.Duration
+}
+
+// Streaming calls, like logs, events, stats, attach, pulls, pushes, builds,
+// exports and archive copies, are never subject to the timeouts: their
+// InactivityTimeout limits the time without data instead.
+
+type requestClass int
+
+const (
+	requestShort requestClass = iota
+	requestLong
+	requestStreaming
+)
+
+var (
+	longRequestSuffixes = []string{
+		"/stop", "/restart", "/wait", "/commit", "/prune",
+		"/plugins/pull", "/upgrade", "/plugins/create",
+		"/swarm/init", "/swarm/join", "/swarm/leave",
+	}
+	streamingRequestSuffixes = []string{
+		"/events", "/logs", "/stats", "/attach", "/export", "/get",
+		"/archive", "/images/create", "/push", "/build", "/images/load",
+	}
+)
+
+// classifyRequest returns the class of the call with the given method to the
+// given path, with its query string. Removals are short, whatever the name of
+// the object, e.g. a container named "logs".
+func classifyRequest(method, path string) requestClass {
+	if method == http.MethodDelete {
+		return requestShort
+	}
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimSuffix(path, "/")
+	for _, suffix := range longRequestSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return requestLong
+		}
+	}
+	// starting a container doesn't stream, starting an exec does.
+	if strings.HasPrefix(path, "/exec/") && strings.HasSuffix(path, "/start") {
+		return requestStreaming
+	}
+	for _, suffix := range streamingRequestSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return requestStreaming
+		}
+	}
+	return requestShort
+}
+
+// requestTimeout returns the timeout of the call with the given method to the
+// given path, zero for none.
+func (c *Client) requestTimeout(method, path string) time.Duration {
+	switch classifyRequest(method, path) {
+	case requestShort:
+		return c.Timeouts.Short
+	case requestLong:
+		return c.Timeouts.Long
+	}
+	return 0
+}
+
+// withRequestTimeout returns the context of the call with the given method to
+// the given path, with the timeout of its class, extended by grace, unless the
+// context has an earlier deadline. The returned function must be called once
+// the call is done.
+func (c *Client) withRequestTimeout(ctx context.Context, method, path string, grace time.Duration) (context.Context, context.CancelFunc) {
+	timeout := c.requestTimeout(method, path)
+	if timeout <= 0 || grace < 0 {
+		return ctx, func() {}
+	}
+	timeout += grace
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// cancelReadCloser cancels the context of a request when its body is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
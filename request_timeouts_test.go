@@ -0,0 +1,132 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClassifyRequest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		method   string
+		path     string
+		expected requestClass
+	}{
+		{"GET", "/containers/4fa6e0f0/json", requestShort},
+		{"GET", "/containers/json?all=1", requestShort},
+		{"POST", "/containers/4fa6e0f0/start", requestShort},
+		{"POST", "/containers/4fa6e0f0/stop?t=10", requestLong},
+		{"POST", "/containers/4fa6e0f0/restart?t=10", requestLong},
+		{"POST", "/containers/4fa6e0f0/wait", requestLong},
+		{"POST", "/containers/prune", requestLong},
+		{"POST", "/commit?container=4fa6e0f0", requestLong},
+		{"GET", "/containers/4fa6e0f0/logs?follow=1", requestStreaming},
+		{"GET", "/events?since=1", requestStreaming},
+		{"POST", "/exec/e1/start", requestStreaming},
+		{"POST", "/images/create?fromImage=busybox", requestStreaming},
+		{"POST", "/images/busybox/push?tag=latest", requestStreaming},
+		{"GET", "/containers/4fa6e0f0/archive?path=/etc", requestStreaming},
+		{"DELETE", "/containers/logs?force=1", requestShort},
+		{"DELETE", "/images/registry/stop", requestShort},
+	}
+	for _, tt := range tests {
+		if got := classifyRequest(tt.method, tt.path); got != tt.expected {
+			t.Errorf("classifyRequest(%q, %q): want %d. Got %d.", tt.method, tt.path, tt.expected, got)
+		}
+	}
+}
+
+// slowDaemon answers the requests after the given delay, unless they're
+// canceled before.
+func slowDaemon(delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Id":"4fa6e0f0","StatusCode":0}`))
+	})
+}
+
+func TestRequestTimeoutsShort(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(slowDaemon(time.Second))
+	client.Timeouts = RequestTimeouts{Short: 20 * time.Millisecond}
+	start := time.Now()
+	_, err := client.InspectContainer("4fa6e0f0")
+	if err != context.DeadlineExceeded {
+		t.Errorf("InspectContainer: wrong error. Want %v. Got %v.", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("InspectContainer: timeout not applied, took %s", elapsed)
+	}
+}
+
+func TestRequestTimeoutsLong(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(slowDaemon(50 * time.Millisecond))
+	client.Timeouts = RequestTimeouts{Short: 10 * time.Millisecond, Long: time.Second}
+	if _, err := client.WaitContainer("4fa6e0f0"); err != nil {
+		t.Errorf("WaitContainer: unexpected error: %v", err)
+	}
+	client.Timeouts.Long = 10 * time.Millisecond
+	if _, err := client.WaitContainer("4fa6e0f0"); err != context.DeadlineExceeded {
+		t.Errorf("WaitContainer: wrong error. Want %v. Got %v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRequestTimeoutsStreaming(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		for i := 0; i < 5; i++ {
+			w.Write([]byte("line\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}))
+	client.Timeouts = RequestTimeouts{Short: 5 * time.Millisecond, Long: 5 * time.Millisecond}
+	resp, err := client.Do(context.Background(), Request{Path: "/containers/4fa6e0f0/logs", Query: map[string][]string{"follow": {"1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || len(data) != 25 {
+		t.Errorf("Do: stream interrupted after %d bytes: %v", len(data), err)
+	}
+}
+
+func TestRequestTimeoutsBodyRead(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(slowDaemon(0))
+	client.Timeouts = RequestTimeouts{Short: time.Second}
+	resp, err := client.Do(context.Background(), Request{Path: "/containers/4fa6e0f0/json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var container Container
+	if err := resp.Decode(&container); err != nil || container.ID != "4fa6e0f0" {
+		t.Errorf("Do: wrong container %#v (%v)", container, err)
+	}
+}
+
+func TestRequestTimeoutsEarlierDeadline(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(slowDaemon(time.Second))
+	client.Timeouts = RequestTimeouts{Short: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.InspectContainerWithContext("4fa6e0f0", ctx); err != context.DeadlineExceeded {
+		t.Errorf("InspectContainerWithContext: wrong error. Want %v. Got %v.", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// Keys of the details of the lines of the logs of services, identifying the
+// task the line comes from.
+const (
+	serviceLogNodeKey    = "com.docker.swarm.node.id"
+	serviceLogServiceKey = "com.docker.swarm.service.id"
+	serviceLogTaskKey    = "com.docker.swarm.task.id"
+)
+
+// ServiceLogLine is a line of the logs of a service, along with the task and
+// the node it comes from.
+type ServiceLogLine struct {
+	// Stream is the stream of the line, "stdout" or "stderr".
+	Stream string
+
+	// Timestamp is the time of the line, set when the logs are requested
+	// with timestamps.
+	Timestamp time.Time
+
+	ServiceID string
+	TaskID    string
+	NodeID    string
+
+	// TaskName is the name of the task, as displayed by docker service
+	// logs: the name of the service followed by the slot of the task (or
+	// the ID of its node for global services) and by the ID of the task,
+	// truncated, e.g. "web.1.tq2pncv4mxm5".
+	TaskName string
+
+	// NodeName is the hostname of the node running the task, or its ID
+	// when the node couldn't be resolved.
+	NodeName string
+
+	// Details are the extra attributes of the line, as sent by the
+	// logging driver, including the IDs above.
+	Details map[string]string
+
+	Message string
+}
+
+// Prefix returns the prefix of the line in the output of docker service logs,
+// e.g. "web.1.tq2pncv4mxm5@node-1", or an empty string for lines that don't
+// come from a task.
+func (l ServiceLogLine) Prefix() string {
+	if l.TaskName == "" {
+		return ""
+	}
+	return l.TaskName + "@" + l.NodeName
+}
+
+// String returns the line as displayed by docker service logs.
+func (l ServiceLogLine) String() string {
+	var b strings.Builder
+	if prefix := l.Prefix(); prefix != "" {
+		b.WriteString(prefix)
+		b.WriteString("    | ")
+	}
+	if !l.Timestamp.IsZero() {
+		b.WriteString(l.Timestamp.Format(time.RFC3339Nano))
+		b.WriteString(" ")
+	}
+	b.WriteString(l.Message)
+	return b.String()
+}
+
+// GetServiceLogLines gets the logs of a service like GetServiceLogs, passing
+// each line to fn along with the task and the node it comes from, until the
+// end of the logs or, when following them, until opts.Context is done.
+//
+// When opts.OutputStream and opts.ErrorStream are set, the lines are also
+// written to them prefixed with their task and node, like in the output of
+// docker service logs.
+//
+// The logs are requested with details, which carry the IDs of the tasks
+// and nodes. Their names are resolved with ListTasks and ListNodes, and the
+// IDs are used instead when they can't be resolved.
+func (c *Client) GetServiceLogLines(opts LogsServiceOptions, fn func(ServiceLogLine)) error {
+	if opts.Service == "" {
+		return &NoSuchService{ID: opts.Service}
+	}
+	resolver := serviceLogResolver{client: c, service: opts.Service, ctx: opts.Context}
+	var mu sync.Mutex
+	handle := func(stream string, out io.Writer) func(string) {
+		return func(text string) {
+			line := parseServiceLogLine(text, opts.Timestamps)
+			line.Stream = stream
+			mu.Lock()
+			defer mu.Unlock()
+			resolver.resolve(&line)
+			if fn != nil {
+				fn(line)
+			}
+			if out != nil {
+				fmt.Fprintln(out, line.String())
+			}
+		}
+	}
+	stdout := newLineWriter(handle("stdout", opts.OutputStream))
+	stderr := newLineWriter(handle("stderr", opts.ErrorStream))
+	opts.OutputStream = stdout
+	opts.ErrorStream = stderr
+	if opts.RawTerminal {
+		opts.ErrorStream = nil
+	}
+	opts.Details = true
+	err := c.GetServiceLogs(opts)
+	stdout.Close()
+	stderr.Close()
+	return err
+}
+
+// lineWriter is a writer passing each line written to it to a function.
+type lineWriter struct {
+	w    *io.PipeWriter
+	done chan struct{}
+}
+
+func newLineWriter(fn func(string)) *lineWriter {
+	r, w := io.Pipe()
+	lw := lineWriter{w: w, done: make(chan struct{})}
+	go func() {
+		defer close(lw.done)
+		reader := bufio.NewReader(r)
+		for {
+			text, err := reader.ReadString('\n')
+			if text != "" {
+				fn(strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r"))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return &lw
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	return lw.w.Write(p)
+}
+
+// Close ends the stream, returning once every line is handled.
+func (lw *lineWriter) Close() error {
+	lw.w.Close()
+	<-lw.done
+	return nil
+}
+
+// parseServiceLogLine parses a line of the logs of a service, requested
+// with details, e.g. "2019-06-12T10:00:00.000000000Z
+// com.docker.swarm.node.id=...,com.docker.swarm.service.id=... message".
+func parseServiceLogLine(text string, timestamps bool) ServiceLogLine {
+	var line ServiceLogLine
+	if timestamps {
+		parts := strings.SplitN(text, " ", 2)
+		if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+			line.Timestamp = ts
+			text = ""
+			if len(parts) == 2 {
+				text = parts[1]
+			}
+		}
+	}
+	parts := strings.SplitN(text, " ", 2)
+	if details, ok := parseLogDetails(parts[0]); ok {
+		line.Details = details
+		line.NodeID = details[serviceLogNodeKey]
+		line.ServiceID = details[serviceLogServiceKey]
+		line.TaskID = details[serviceLogTaskKey]
+		text = ""
+		if len(parts) == 2 {
+			text = parts[1]
+		}
+	}
+	line.Message = text
+	return line
+}
+
+// parseLogDetails parses the details of a line of logs, comma separated
+// key=value pairs with URL-encoded keys and values.
+func parseLogDetails(text string) (map[string]string, bool) {
+	if text == "" {
+		return nil, false
+	}
+	details := make(map[string]string)
+	for _, pair := range strings.Split(text, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, false
+		}
+		key, err := url.QueryUnescape(parts[0])
+		if err != nil {
+			return nil, false
+		}
+		value, err := url.QueryUnescape(parts[1])
+		if err != nil {
+			return nil, false
+		}
+		details[key] = value
+	}
+	return details, true
+}
+
+// serviceLogResolver resolves the names of the tasks and nodes of the lines
+// of the logs of a service, listing the tasks and the nodes again when a
+// line comes from an unknown one.
+type serviceLogResolver struct {
+	client  *Client
+	service string
+	ctx     context.Context
+
+	inspected   bool
+	serviceSpec *swarm.ServiceSpec
+	tasks       map[string]swarm.Task
+	nodes       map[string]string
+	listedTasks map[string]bool
+	listedNodes map[string]bool
+}
+
+func (r *serviceLogResolver) resolve(line *ServiceLogLine) {
+	if line.TaskID == "" {
+		return
+	}
+	if !r.inspected {
+		r.inspected = true
+		if service, err := r.client.InspectService(r.service); err == nil {
+			r.serviceSpec = &service.Spec
+		}
+	}
+	task, ok := r.tasks[line.TaskID]
+	if !ok && !r.listedTasks[line.TaskID] {
+		r.listTasks()
+		task, ok = r.tasks[line.TaskID]
+		r.listedTasks[line.TaskID] = true
+	}
+	line.TaskName = r.taskName(line, task, ok)
+	line.NodeName = line.NodeID
+	name, ok := r.nodes[line.NodeID]
+	if !ok && !r.listedNodes[line.NodeID] {
+		r.listNodes()
+		name, ok = r.nodes[line.NodeID]
+		r.listedNodes[line.NodeID] = true
+	}
+	if ok {
+		line.NodeName = name
+	}
+}
+
+func (r *serviceLogResolver) taskName(line *ServiceLogLine, task swarm.Task, ok bool) string {
+	serviceName := line.ServiceID
+	if r.serviceSpec != nil {
+		serviceName = r.serviceSpec.Name
+	}
+	taskID := line.TaskID
+	if len(taskID) > 12 {
+		taskID = taskID[:12]
+	}
+	if !ok {
+		return serviceName + "." + taskID
+	}
+	if r.serviceSpec != nil && r.serviceSpec.Mode.Global != nil {
+		return fmt.Sprintf("%s.%s.%s", serviceName, task.NodeID, taskID)
+	}
+	return fmt.Sprintf("%s.%d.%s", serviceName, task.Slot, taskID)
+}
+
+func (r *serviceLogResolver) listTasks() {
+	if r.tasks == nil {
+		r.tasks = make(map[string]swarm.Task)
+		r.listedTasks = make(map[string]bool)
+	}
+	tasks, err := r.client.ListTasks(ListTasksOptions{
+		Filters: map[string][]string{"service": {r.service}},
+		Context: r.ctx,
+	})
+	if err != nil {
+		return
+	}
+	for _, task := range tasks {
+		r.tasks[task.ID] = task
+	}
+}
+
+func (r *serviceLogResolver) listNodes() {
+	if r.nodes == nil {
+		r.nodes = make(map[string]string)
+		r.listedNodes = make(map[string]bool)
+	}
+	nodes, err := r.client.ListNodes(ListNodesOptions{Context: r.ctx})
+	if err != nil {
+		return
+	}
+	for _, node := range nodes {
+		r.nodes[node.ID] = node.Description.Hostname
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func TestGetServiceLogLines(t *testing.T) {
+	t.Parallel()
+	details := "com.docker.swarm.node.id=node1,com.docker.swarm.service.id=svc1,com.docker.swarm.task.id=task1abcdefghijklm"
+	var taskLists int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/services/web/logs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("details") != "1" {
+			t.Errorf("GetServiceLogLines: logs not requested with details: %s", r.URL.RawQuery)
+		}
+		w.Write(execFrame(1, "2019-06-12T10:00:00Z "+details+" listen"))
+		w.Write(execFrame(1, "ing on :80\n"))
+		w.Write(execFrame(2, "2019-06-12T10:00:01Z "+details+",env=prod%2Ceu warning\n"))
+		w.Write(execFrame(1, "2019-06-12T10:00:02Z com.docker.swarm.node.id=node2,com.docker.swarm.service.id=svc1,com.docker.swarm.task.id=task2 started\n"))
+	})
+	mux.HandleFunc("/services/web", func(w http.ResponseWriter, r *http.Request) {
+		var service swarm.Service
+		service.ID = "svc1"
+		service.Spec.Name = "web"
+		json.NewEncoder(w).Encode(service)
+	})
+	mux.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&taskLists, 1)
+		json.NewEncoder(w).Encode([]swarm.Task{{ID: "task1abcdefghijklm", Slot: 2, NodeID: "node1"}})
+	})
+	mux.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		var node swarm.Node
+		node.ID = "node1"
+		node.Description.Hostname = "worker-1"
+		json.NewEncoder(w).Encode([]swarm.Node{node})
+	})
+	client := NewClientFromHandler(mux)
+	var stdout, stderr bytes.Buffer
+	var lines []ServiceLogLine
+	err := client.GetServiceLogLines(LogsServiceOptions{
+		Service:      "web",
+		OutputStream: &stdout,
+		ErrorStream:  &stderr,
+		Stdout:       true,
+		Stderr:       true,
+		Timestamps:   true,
+	}, func(line ServiceLogLine) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("GetServiceLogLines: wrong number of lines. Want 3. Got %d: %#v.", len(lines), lines)
+	}
+	var stdoutLines []ServiceLogLine
+	var stderrLine ServiceLogLine
+	for _, line := range lines {
+		if line.Stream == "stderr" {
+			stderrLine = line
+		} else {
+			stdoutLines = append(stdoutLines, line)
+		}
+	}
+	first := stdoutLines[0]
+	if first.TaskName != "web.2.task1abcdefg" || first.NodeName != "worker-1" || first.Message != "listening on :80" {
+		t.Errorf("GetServiceLogLines: wrong line: %#v", first)
+	}
+	if expected := time.Date(2019, 6, 12, 10, 0, 0, 0, time.UTC); !first.Timestamp.Equal(expected) {
+		t.Errorf("GetServiceLogLines: wrong timestamp. Want %s. Got %s.", expected, first.Timestamp)
+	}
+	if stderrLine.Details["env"] != "prod,eu" || stderrLine.Message != "warning" {
+		t.Errorf("GetServiceLogLines: wrong stderr line: %#v", stderrLine)
+	}
+	unknown := stdoutLines[1]
+	if unknown.TaskName != "web.task2" || unknown.NodeName != "node2" || unknown.Prefix() != "web.task2@node2" {
+		t.Errorf("GetServiceLogLines: wrong line of an unknown task: %#v", unknown)
+	}
+	if n := atomic.LoadInt32(&taskLists); n != 2 {
+		t.Errorf("GetServiceLogLines: want the tasks listed once per unknown task. Got %d lists.", n)
+	}
+	expectedStderr := "web.2.task1abcdefg@worker-1    | 2019-06-12T10:00:01Z warning\n"
+	if stderr.String() != expectedStderr {
+		t.Errorf("GetServiceLogLines: wrong stderr. Want %q. Got %q.", expectedStderr, stderr.String())
+	}
+	if !bytes.Contains(stdout.Bytes(), []byte("web.2.task1abcdefg@worker-1    | 2019-06-12T10:00:00Z listening on :80\n")) {
+		t.Errorf("GetServiceLogLines: wrong stdout: %q", stdout.String())
+	}
+}
+
+func TestGetServiceLogLinesNoService(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(http.NotFoundHandler())
+	err := client.GetServiceLogLines(LogsServiceOptions{}, nil)
+	if _, ok := err.(*NoSuchService); !ok {
+		t.Errorf("GetServiceLogLines: wrong error. Want *NoSuchService. Got %#v.", err)
+	}
+}
+
+func TestParseServiceLogLine(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		text       string
+		timestamps bool
+		expected   ServiceLogLine
+	}{
+		{"plain message", false, ServiceLogLine{Message: "plain message"}},
+		{"", false, ServiceLogLine{}},
+		{"a=b", false, ServiceLogLine{Details: map[string]string{"a": "b"}}},
+		{
+			"com.docker.swarm.task.id=t1,com.docker.swarm.node.id=n1 hello world",
+			false,
+			ServiceLogLine{
+				TaskID:  "t1",
+				NodeID:  "n1",
+				Details: map[string]string{"com.docker.swarm.task.id": "t1", "com.docker.swarm.node.id": "n1"},
+				Message: "hello world",
+			},
+		},
+		{"not-a-time hello", true, ServiceLogLine{Message: "not-a-time hello"}},
+		{"2019-06-12T10:00:00.5Z x=%zz msg", true, ServiceLogLine{Timestamp: time.Date(2019, 6, 12, 10, 0, 0, 5e8, time.UTC), Message: "x=%zz msg"}},
+	}
+	for _, tt := range tests {
+		line := parseServiceLogLine(tt.text, tt.timestamps)
+		if !reflect.DeepEqual(line, tt.expected) {
+			t.Errorf("parseServiceLogLine(%q): want %#v. Got %#v.", tt.text, tt.expected, line)
+		}
+	}
+}
+
+func TestServiceLogLineString(t *testing.T) {
+	t.Parallel()
+	line := ServiceLogLine{TaskName: "web.1.abc", NodeName: "node-1", Message: "hello"}
+	if expected := "web.1.abc@node-1    | hello"; line.String() != expected {
+		t.Errorf("String: want %q. Got %q.", expected, line.String())
+	}
+	line = ServiceLogLine{Message: "hello"}
+	if line.String() != "hello" {
+		t.Errorf("String: want %q. Got %q.", "hello", line.String())
+	}
+}
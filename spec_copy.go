@@ -0,0 +1,181 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"reflect"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// DeepCopy returns a copy of the config that shares no slices, maps or
+// pointers with it.
+func (c *Config) DeepCopy() *Config {
+	if c == nil {
+		return nil
+	}
+	var copied Config
+	deepCopyValue(reflect.ValueOf(&copied).Elem(), reflect.ValueOf(c).Elem())
+	return &copied
+}
+
+// Equal reports whether the two configs are equivalent. Unlike
+// reflect.DeepEqual, nil and empty slices and maps are considered equal.
+func (c *Config) Equal(other *Config) bool {
+	return specEqual(reflect.ValueOf(c), reflect.ValueOf(other))
+}
+
+// DeepCopy returns a copy of the host config that shares no slices, maps or
+// pointers with it.
+func (h *HostConfig) DeepCopy() *HostConfig {
+	if h == nil {
+		return nil
+	}
+	var copied HostConfig
+	deepCopyValue(reflect.ValueOf(&copied).Elem(), reflect.ValueOf(h).Elem())
+	return &copied
+}
+
+// Equal reports whether the two host configs are equivalent. Unlike
+// reflect.DeepEqual, nil and empty slices and maps are considered equal.
+func (h *HostConfig) Equal(other *HostConfig) bool {
+	return specEqual(reflect.ValueOf(h), reflect.ValueOf(other))
+}
+
+// DeepCopyServiceSpec returns a copy of the service spec that shares no
+// slices, maps or pointers with it.
+func DeepCopyServiceSpec(spec *swarm.ServiceSpec) *swarm.ServiceSpec {
+	if spec == nil {
+		return nil
+	}
+	var copied swarm.ServiceSpec
+	deepCopyValue(reflect.ValueOf(&copied).Elem(), reflect.ValueOf(spec).Elem())
+	return &copied
+}
+
+// ServiceSpecEqual reports whether the two service specs are equivalent.
+// Unlike reflect.DeepEqual, nil and empty slices and maps are considered
+// equal.
+func ServiceSpecEqual(a, b *swarm.ServiceSpec) bool {
+	return specEqual(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+// deepCopyValue copies src into dst, which must be settable and of the same
+// type, allocating new slices, maps and pointers. Unexported fields are
+// copied as is.
+func deepCopyValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		deepCopyValue(dst.Elem(), src.Elem())
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		deepCopyValue(elem, src.Elem())
+		dst.Set(elem)
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopyValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			deepCopyValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopyValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		for _, key := range src.MapKeys() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			deepCopyValue(value, src.MapIndex(key))
+			dst.SetMapIndex(key, value)
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// specEqual compares two values of the same type like reflect.DeepEqual, but
+// considering nil and empty slices and maps equal.
+func specEqual(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Kind() == reflect.Interface && a.Elem().Type() != b.Elem().Type() {
+			return false
+		}
+		return specEqual(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !specEqual(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !specEqual(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, key := range a.MapKeys() {
+			bValue := b.MapIndex(key)
+			if !bValue.IsValid() || !specEqual(a.MapIndex(key), bValue) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.String:
+		return a.String() == b.String()
+	default:
+		// functions, channels and the like don't appear in specs, fall back
+		// to reflect.DeepEqual.
+		return reflect.DeepEqual(valueInterface(a), valueInterface(b))
+	}
+}
+
+func valueInterface(v reflect.Value) interface{} {
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
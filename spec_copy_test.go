@@ -0,0 +1,135 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func sampleHostConfig() *HostConfig {
+	return &HostConfig{
+		Binds:        []string{"/data:/data"},
+		PortBindings: map[Port][]PortBinding{"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}}},
+		LogConfig:    LogConfig{Type: "json-file", Config: map[string]string{"max-size": "10m"}},
+		Mounts: []HostMount{{
+			Target:        "/var/lib/data",
+			Type:          "volume",
+			VolumeOptions: &VolumeOptions{Labels: map[string]string{"a": "b"}, DriverConfig: VolumeDriverConfig{Options: map[string]string{"c": "d"}}},
+		}},
+		DeviceRequests:   []DeviceRequest{{Driver: "cdi", DeviceIDs: []string{"nvidia.com/gpu=0"}, Capabilities: [][]string{{"gpu"}}}},
+		MemorySwappiness: Int64Ptr(0),
+		OOMKillDisable:   BoolPtr(true),
+		Tmpfs:            map[string]string{"/run": "rw"},
+	}
+}
+
+func TestHostConfigDeepCopy(t *testing.T) {
+	t.Parallel()
+	original := sampleHostConfig()
+	copied := original.DeepCopy()
+	if !reflect.DeepEqual(original, copied) {
+		t.Fatalf("DeepCopy: copy differs from the original.\nWant %#v.\nGot %#v.", original, copied)
+	}
+	copied.Binds[0] = "/other:/other"
+	copied.PortBindings["80/tcp"][0].HostPort = "9090"
+	copied.LogConfig.Config["max-size"] = "1m"
+	copied.Mounts[0].VolumeOptions.Labels["a"] = "changed"
+	copied.Mounts[0].VolumeOptions.DriverConfig.Options["c"] = "changed"
+	copied.DeviceRequests[0].Capabilities[0][0] = "compute"
+	*copied.MemorySwappiness = 60
+	*copied.OOMKillDisable = false
+	if expected := sampleHostConfig(); !reflect.DeepEqual(original, expected) {
+		t.Errorf("DeepCopy: changes to the copy affected the original.\nWant %#v.\nGot %#v.", expected, original)
+	}
+	if (*HostConfig)(nil).DeepCopy() != nil {
+		t.Error("DeepCopy: want <nil> copy of <nil> host config")
+	}
+}
+
+func TestHostConfigEqual(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		a, b  *HostConfig
+		equal bool
+	}{
+		{"same", sampleHostConfig(), sampleHostConfig(), true},
+		{"nil", nil, nil, true},
+		{"nil and empty", nil, &HostConfig{}, false},
+		{"nil and empty slices", &HostConfig{Binds: []string{}, Tmpfs: map[string]string{}}, &HostConfig{}, true},
+		{"different pointee", &HostConfig{PidsLimit: Int64Ptr(1)}, &HostConfig{PidsLimit: Int64Ptr(2)}, false},
+		{"nil and zero pointer", &HostConfig{PidsLimit: Int64Ptr(0)}, &HostConfig{}, false},
+		{"different map value", &HostConfig{Tmpfs: map[string]string{"/run": "rw"}}, &HostConfig{Tmpfs: map[string]string{"/run": "ro"}}, false},
+		{"different map keys", &HostConfig{Tmpfs: map[string]string{"/run": "rw"}}, &HostConfig{Tmpfs: map[string]string{"/tmp": "rw"}}, false},
+	}
+	for _, tt := range tests {
+		if equal := tt.a.Equal(tt.b); equal != tt.equal {
+			t.Errorf("%s: Equal: want %v. Got %v.", tt.name, tt.equal, equal)
+		}
+		if equal := tt.b.Equal(tt.a); equal != tt.equal {
+			t.Errorf("%s: Equal (reversed): want %v. Got %v.", tt.name, tt.equal, equal)
+		}
+	}
+}
+
+func TestConfigDeepCopyAndEqual(t *testing.T) {
+	t.Parallel()
+	original := &Config{
+		Image:        "base",
+		Env:          []string{"A=1"},
+		ExposedPorts: map[Port]struct{}{"80/tcp": {}},
+		Labels:       map[string]string{"app": "web"},
+		Healthcheck:  &HealthConfig{Test: []string{"CMD", "true"}, Interval: time.Second},
+		StopTimeout:  10,
+	}
+	copied := original.DeepCopy()
+	if !original.Equal(copied) || !reflect.DeepEqual(original, copied) {
+		t.Fatalf("DeepCopy: copy differs from the original.\nWant %#v.\nGot %#v.", original, copied)
+	}
+	copied.Env[0] = "A=2"
+	copied.Labels["app"] = "db"
+	copied.Healthcheck.Test[1] = "false"
+	if original.Env[0] != "A=1" || original.Labels["app"] != "web" || original.Healthcheck.Test[1] != "true" {
+		t.Errorf("DeepCopy: changes to the copy affected the original: %#v", original)
+	}
+	if original.Equal(copied) {
+		t.Error("Equal: want false for configs that differ")
+	}
+}
+
+func TestServiceSpecDeepCopyAndEqual(t *testing.T) {
+	t.Parallel()
+	replicas := uint64(3)
+	delay := 5 * time.Second
+	original := &swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Name: "web", Labels: map[string]string{"tier": "front"}},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{Image: "nginx", Env: []string{"A=1"}},
+			RestartPolicy: &swarm.RestartPolicy{Delay: &delay},
+		},
+		Mode: swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+	}
+	copied := DeepCopyServiceSpec(original)
+	if !ServiceSpecEqual(original, copied) || !reflect.DeepEqual(original, copied) {
+		t.Fatalf("DeepCopyServiceSpec: copy differs from the original.\nWant %#v.\nGot %#v.", original, copied)
+	}
+	*copied.Mode.Replicated.Replicas = 5
+	*copied.TaskTemplate.RestartPolicy.Delay = time.Second
+	copied.TaskTemplate.ContainerSpec.Env[0] = "A=2"
+	copied.Labels["tier"] = "back"
+	if replicas != 3 || delay != 5*time.Second || original.TaskTemplate.ContainerSpec.Env[0] != "A=1" || original.Labels["tier"] != "front" {
+		t.Errorf("DeepCopyServiceSpec: changes to the copy affected the original: %#v", original)
+	}
+	if ServiceSpecEqual(original, copied) {
+		t.Error("ServiceSpecEqual: want false for specs that differ")
+	}
+	if DeepCopyServiceSpec(nil) != nil {
+		t.Error("DeepCopyServiceSpec: want <nil> copy of <nil> spec")
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultStatsInterval = time.Second
+
+// AggregateStatsOptions specify parameters to the AggregateStats method.
+type AggregateStatsOptions struct {
+	// Filters select the containers to aggregate, using the filters of
+	// ListContainers, e.g. {"label": ["app=web"]}. Only running containers
+	// are aggregated.
+	Filters map[string][]string
+
+	// Interval is the period between snapshots. Defaults to one second.
+	Interval time.Duration
+}
+
+// StatsSnapshot is the aggregation of the latest stats of a set of
+// containers, as sent by AggregateStats.
+type StatsSnapshot struct {
+	Time time.Time
+
+	// CPUPercent is the sum of the CPU usage of the containers, where 100%
+	// is one CPU fully used.
+	CPUPercent  float64
+	MemoryUsage uint64
+	MemoryLimit uint64
+	NetworkRx   uint64
+	NetworkTx   uint64
+	BlkioRead   uint64
+	BlkioWrite  uint64
+
+	// Containers is the breakdown per container, keyed by ID.
+	Containers map[string]ContainerStatsSample
+}
+
+// ContainerStatsSample is the latest sample of the stats of a container in a
+// StatsSnapshot. MemoryUsage excludes the page cache, like `docker stats`.
+type ContainerStatsSample struct {
+	ID          string
+	Name        string
+	CPUPercent  float64
+	MemoryUsage uint64
+	MemoryLimit uint64
+	NetworkRx   uint64
+	NetworkTx   uint64
+	BlkioRead   uint64
+	BlkioWrite  uint64
+
+	// Stats is the raw sample sent by the daemon.
+	Stats *Stats
+}
+
+// AggregateStats streams the stats of all the running containers that match
+// opts.Filters, sending a snapshot of their aggregated usage to the given
+// channel every opts.Interval, until the context is done or an error occurs.
+// The channel is not closed.
+//
+// Containers that start and match the filters are added to the aggregation,
+// and containers that stop are removed from it, as the events of the daemon
+// report them. Containers are included in snapshots once their first sample
+// arrives.
+func (c *Client) AggregateStats(ctx context.Context, opts AggregateStatsOptions, snapshots chan<- *StatsSnapshot) error {
+	interval := opts.Interval
+	if interval == 0 {
+		interval = defaultStatsInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	agg := statsAggregator{
+		client:  c,
+		filters: opts.Filters,
+		streams: make(map[string]*aggregatedStream),
+	}
+	defer func() {
+		cancel()
+		agg.wg.Wait()
+	}()
+	events := make(chan *APIEvents)
+	eventsErr := make(chan error, 1)
+	// the events stream starts before listing the containers, so containers
+	// started in between aren't missed.
+	go func(since int64) {
+		eventsErr <- c.NewStreamSupervisor().Events(ctx, EventsOptions{
+			Since: since,
+			Filters: map[string][]string{
+				"type":  {"container"},
+				"event": {"start", "die"},
+			},
+		}, events)
+	}(time.Now().Unix())
+	if err := agg.sync(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-eventsErr:
+			return err
+		case event := <-events:
+			switch event.Action {
+			case "start":
+				if err := agg.sync(ctx); err != nil {
+					return err
+				}
+			case "die":
+				agg.stop(event.Actor.ID)
+			}
+		case <-ticker.C:
+			select {
+			case snapshots <- agg.snapshot():
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+type statsAggregator struct {
+	client  *Client
+	filters map[string][]string
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	streams map[string]*aggregatedStream
+}
+
+type aggregatedStream struct {
+	name   string
+	latest *Stats
+	cancel context.CancelFunc
+}
+
+// sync lists the containers that match the filters, starting the streams of
+// the new ones and stopping the streams of the ones that no longer match.
+func (a *statsAggregator) sync(ctx context.Context) error {
+	containers, err := a.client.ListContainers(ListContainersOptions{Filters: a.filters, Context: ctx})
+	if err != nil {
+		return err
+	}
+	matching := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		matching[container.ID] = true
+		a.mu.Lock()
+		_, ok := a.streams[container.ID]
+		a.mu.Unlock()
+		if !ok {
+			var name string
+			if len(container.Names) > 0 {
+				name = strings.TrimPrefix(container.Names[0], "/")
+			}
+			a.start(ctx, container.ID, name)
+		}
+	}
+	a.mu.Lock()
+	var stale []string
+	for id := range a.streams {
+		if !matching[id] {
+			stale = append(stale, id)
+		}
+	}
+	a.mu.Unlock()
+	for _, id := range stale {
+		a.stop(id)
+	}
+	return nil
+}
+
+func (a *statsAggregator) start(ctx context.Context, id, name string) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream := &aggregatedStream{name: name, cancel: cancel}
+	a.mu.Lock()
+	a.streams[id] = stream
+	a.mu.Unlock()
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer cancel()
+		stats := make(chan *Stats)
+		errC := make(chan error, 1)
+		go func() {
+			errC <- a.client.Stats(StatsOptions{ID: id, Stats: stats, Stream: true, Context: ctx})
+		}()
+		for s := range stats {
+			a.mu.Lock()
+			stream.latest = s
+			a.mu.Unlock()
+		}
+		<-errC
+		a.mu.Lock()
+		if a.streams[id] == stream {
+			delete(a.streams, id)
+		}
+		a.mu.Unlock()
+	}()
+}
+
+func (a *statsAggregator) stop(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if stream, ok := a.streams[id]; ok {
+		stream.cancel()
+		delete(a.streams, id)
+	}
+}
+
+func (a *statsAggregator) snapshot() *StatsSnapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	snapshot := StatsSnapshot{
+		Time:       time.Now(),
+		Containers: make(map[string]ContainerStatsSample, len(a.streams)),
+	}
+	for id, stream := range a.streams {
+		if stream.latest == nil {
+			continue
+		}
+		sample := newContainerStatsSample(id, stream.name, stream.latest)
+		snapshot.Containers[id] = sample
+		snapshot.CPUPercent += sample.CPUPercent
+		snapshot.MemoryUsage += sample.MemoryUsage
+		snapshot.MemoryLimit += sample.MemoryLimit
+		snapshot.NetworkRx += sample.NetworkRx
+		snapshot.NetworkTx += sample.NetworkTx
+		snapshot.BlkioRead += sample.BlkioRead
+		snapshot.BlkioWrite += sample.BlkioWrite
+	}
+	return &snapshot
+}
+
+func newContainerStatsSample(id, name string, stats *Stats) ContainerStatsSample {
+	sample := ContainerStatsSample{
+		ID:          id,
+		Name:        name,
+		CPUPercent:  statsCPUPercent(stats),
+		MemoryUsage: stats.MemoryStats.Usage,
+		MemoryLimit: stats.MemoryStats.Limit,
+		NetworkRx:   stats.TotalNetworkRx(),
+		NetworkTx:   stats.TotalNetworkTx(),
+		BlkioRead:   stats.TotalBlkioRead(),
+		BlkioWrite:  stats.TotalBlkioWrite(),
+		Stats:       stats,
+	}
+	// like `docker stats`, the inactive page cache isn't counted as used.
+	if cache := stats.MemoryStats.Stats.TotalInactiveFile; cache > 0 && cache < sample.MemoryUsage {
+		sample.MemoryUsage -= cache
+	} else if cache := stats.MemoryStats.Stats.InactiveFile; cache > 0 && cache < sample.MemoryUsage {
+		sample.MemoryUsage -= cache
+	}
+	return sample
+}
+
+// statsCPUPercent computes the CPU usage of a sample like `docker stats`,
+// from the difference with the previous sample.
+func statsCPUPercent(stats *Stats) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemCPUUsage) - float64(stats.PreCPUStats.SystemCPUUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	return cpuDelta / systemDelta * cpus * 100
+}
@@ -0,0 +1,204 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fleetDaemon simulates a daemon running a changing set of containers, each
+// streaming the same stats sample.
+type fleetDaemon struct {
+	mu      sync.Mutex
+	running []string
+	samples map[string]string
+	events  chan string
+}
+
+func (d *fleetDaemon) setRunning(ids ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running = ids
+}
+
+func (d *fleetDaemon) isRunning(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, running := range d.running {
+		if running == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *fleetDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/_ping":
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == "/containers/json":
+		d.mu.Lock()
+		var containers []APIContainers
+		for _, id := range d.running {
+			containers = append(containers, APIContainers{ID: id, Names: []string{"/" + id + "-name"}})
+		}
+		d.mu.Unlock()
+		json.NewEncoder(w).Encode(containers)
+	case r.URL.Path == "/events":
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case event := <-d.events:
+				w.Write([]byte(event))
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	case strings.HasSuffix(r.URL.Path, "/stats"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/containers/"), "/stats")
+		w.WriteHeader(http.StatusOK)
+		for d.isRunning(id) {
+			if _, err := w.Write([]byte(d.samples[id] + "\n")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func TestAggregateStats(t *testing.T) {
+	t.Parallel()
+	daemon := &fleetDaemon{
+		running: []string{"c1"},
+		samples: map[string]string{
+			"c1": `{"memory_stats": {"usage": 300, "limit": 1000, "stats": {"total_inactive_file": 100}},
+				"networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+				"blkio_stats": {"io_service_bytes_recursive": [{"op": "Read", "value": 5}, {"op": "Write", "value": 7}, {"op": "Total", "value": 12}]},
+				"cpu_stats": {"cpu_usage": {"total_usage": 300}, "system_cpu_usage": 2000, "online_cpus": 2},
+				"precpu_stats": {"cpu_usage": {"total_usage": 200}, "system_cpu_usage": 1000}}`,
+			"c2": `{"memory_stats": {"usage": 50, "limit": 500},
+				"network": {"rx_bytes": 3, "tx_bytes": 4},
+				"blkio_stats": {"io_service_bytes_recursive": [{"op": "read", "value": 1}, {"op": "write", "value": 2}]},
+				"cpu_stats": {"cpu_usage": {"total_usage": 150, "percpu_usage": [75, 75]}, "system_cpu_usage": 2000},
+				"precpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 1000}}`,
+		},
+		events: make(chan string),
+	}
+	server := httptest.NewServer(daemon)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	snapshots := make(chan *StatsSnapshot)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- client.AggregateStats(ctx, AggregateStatsOptions{Interval: 20 * time.Millisecond}, snapshots)
+	}()
+	waitFor := func(ids ...string) *StatsSnapshot {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case snapshot := <-snapshots:
+				if len(snapshot.Containers) != len(ids) {
+					continue
+				}
+				found := true
+				for _, id := range ids {
+					_, ok := snapshot.Containers[id]
+					found = found && ok
+				}
+				if found {
+					return snapshot
+				}
+			case err := <-errC:
+				t.Fatalf("AggregateStats: unexpected return: %v", err)
+			case <-timeout:
+				t.Fatalf("AggregateStats: timed out waiting for a snapshot of %v", ids)
+			}
+		}
+	}
+
+	snapshot := waitFor("c1")
+	c1 := snapshot.Containers["c1"]
+	if c1.Name != "c1-name" || c1.Stats == nil {
+		t.Errorf("AggregateStats: wrong sample of c1: %#v", c1)
+	}
+	expected := StatsSnapshot{CPUPercent: 20, MemoryUsage: 200, MemoryLimit: 1000, NetworkRx: 11, NetworkTx: 22, BlkioRead: 5, BlkioWrite: 7}
+	compareSnapshot(t, snapshot, expected)
+
+	daemon.setRunning("c1", "c2")
+	daemon.events <- `{"Type": "container", "Action": "start", "Actor": {"ID": "c2"}, "time": 1}`
+	snapshot = waitFor("c1", "c2")
+	expected = StatsSnapshot{CPUPercent: 30, MemoryUsage: 250, MemoryLimit: 1500, NetworkRx: 14, NetworkTx: 26, BlkioRead: 6, BlkioWrite: 9}
+	compareSnapshot(t, snapshot, expected)
+
+	daemon.setRunning("c2")
+	daemon.events <- `{"Type": "container", "Action": "die", "Actor": {"ID": "c1"}, "time": 2}`
+	waitFor("c2")
+
+	cancel()
+	select {
+	case err := <-errC:
+		if err != context.Canceled {
+			t.Errorf("AggregateStats: wrong error. Want %v. Got %v.", context.Canceled, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("AggregateStats: didn't return after the context was cancelled")
+	}
+}
+
+func TestAggregateStatsListError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			<-r.Context().Done()
+			return
+		}
+		http.Error(w, "invalid filter", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.AggregateStats(context.Background(), AggregateStatsOptions{Filters: map[string][]string{"bogus": {"x"}}}, make(chan *StatsSnapshot))
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusBadRequest {
+		t.Errorf("AggregateStats: wrong error. Want *Error with status 400. Got %#v.", err)
+	}
+}
+
+func compareSnapshot(t *testing.T, got *StatsSnapshot, want StatsSnapshot) {
+	t.Helper()
+	gotValues := fmt.Sprintf("%d %d %d %d %d %d", got.MemoryUsage, got.MemoryLimit, got.NetworkRx, got.NetworkTx, got.BlkioRead, got.BlkioWrite)
+	wantValues := fmt.Sprintf("%d %d %d %d %d %d", want.MemoryUsage, want.MemoryLimit, want.NetworkRx, want.NetworkTx, want.BlkioRead, want.BlkioWrite)
+	if gotValues != wantValues {
+		t.Errorf("wrong snapshot totals (mem, limit, rx, tx, read, write). Want %s. Got %s.", wantValues, gotValues)
+	}
+	if math.Abs(got.CPUPercent-want.CPUPercent) > 1e-9 {
+		t.Errorf("wrong snapshot CPU. Want %f. Got %f.", want.CPUPercent, got.CPUPercent)
+	}
+}
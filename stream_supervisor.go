@@ -0,0 +1,287 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const defaultStreamRetryInterval = time.Second
+
+// EventsOptions specify parameters to the Events method of StreamSupervisor.
+type EventsOptions struct {
+	// Since is the unix timestamp of the first event to stream. When zero,
+	// only events that happen after the call are streamed.
+	Since int64
+
+	// Filters to apply to the events, e.g. {"type": ["container"]}.
+	Filters map[string][]string
+}
+
+// StreamSupervisor runs streaming calls (events, logs and stats), resuming
+// them when they are interrupted by a restart of the daemon.
+//
+// When a stream ends unexpectedly, the supervisor pings the daemon every
+// RetryInterval until it's back, and then opens the stream again. Each method
+// documents how the resumed stream relates to the interrupted one.
+type StreamSupervisor struct {
+	// RetryInterval is the interval between the pings sent to the daemon
+	// while it's unavailable. Defaults to one second.
+	RetryInterval time.Duration
+
+	// MaxDowntime is how long the supervisor waits for the daemon to come
+	// back before giving up and returning the last error. Zero means waiting
+	// until the context is done.
+	MaxDowntime time.Duration
+
+	client *Client
+}
+
+// NewStreamSupervisor returns a StreamSupervisor that streams using the
+// client.
+func (c *Client) NewStreamSupervisor() *StreamSupervisor {
+	return &StreamSupervisor{client: c}
+}
+
+// Events streams the events of the daemon to the given channel until the
+// context is done, resuming the stream after daemon restarts. The channel is
+// not closed.
+//
+// Delivery is at-least-once: the resumed stream starts at the time of the
+// last event received, so no event is lost, but events that happened at that
+// same time may be delivered twice.
+func (s *StreamSupervisor) Events(ctx context.Context, opts EventsOptions, events chan<- *APIEvents) error {
+	var since string
+	if opts.Since != 0 {
+		since = strconv.FormatInt(opts.Since, 10)
+	}
+	for {
+		started := time.Now()
+		last, err := s.streamEvents(ctx, since, opts.Filters, events)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, ok := err.(*Error); ok {
+			return err
+		}
+		switch {
+		case last != nil && last.TimeNano != 0:
+			since = fmt.Sprintf("%d.%09d", last.TimeNano/int64(time.Second), last.TimeNano%int64(time.Second))
+		case last != nil:
+			since = strconv.FormatInt(last.Time, 10)
+		case since == "":
+			since = strconv.FormatInt(started.Unix(), 10)
+		}
+		if err = s.waitForDaemon(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// streamEvents streams events until the connection ends, returning the last
+// event received.
+func (s *StreamSupervisor) streamEvents(ctx context.Context, since string, filters map[string][]string, events chan<- *APIEvents) (*APIEvents, error) {
+	params := make(url.Values)
+	if since != "" {
+		params.Set("since", since)
+	}
+	if len(filters) > 0 {
+		data, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("filters", string(data))
+	}
+	path := "/events"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := s.client.do("GET", path, doOptions{context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var last *APIEvents
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event APIEvents
+		if err = decoder.Decode(&event); err != nil {
+			return last, err
+		}
+		if event.Time == 0 {
+			continue
+		}
+		transformEvent(&event)
+		select {
+		case events <- &event:
+			last = &event
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+	}
+}
+
+// Logs streams the logs of a container, resuming the stream after daemon
+// restarts while the container is running. It returns when the container
+// stops or the context is done. opts.Context is ignored in favor of ctx.
+//
+// Delivery is best-effort: the resumed stream starts at the beginning of the
+// second in which the last output was received, so lines written in that
+// second may be repeated. Lines written while the daemon was down are
+// delivered after it's back, as long as the logging driver kept them.
+// Without opts.Follow, Logs behaves like Client.Logs.
+func (s *StreamSupervisor) Logs(ctx context.Context, opts LogsOptions) error {
+	opts.Context = ctx
+	if !opts.Follow {
+		return s.client.Logs(opts)
+	}
+	var lastOutput int64
+	track := func(w io.Writer) io.Writer {
+		if w == nil {
+			return nil
+		}
+		return writerFunc(func(p []byte) (int, error) {
+			atomic.StoreInt64(&lastOutput, time.Now().Unix())
+			return w.Write(p)
+		})
+	}
+	opts.OutputStream = track(opts.OutputStream)
+	opts.ErrorStream = track(opts.ErrorStream)
+	for {
+		err := s.client.Logs(opts)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if stop, err := s.shouldStop(ctx, opts.Container, err); stop {
+			return err
+		}
+		if last := atomic.LoadInt64(&lastOutput); last != 0 {
+			opts.Since = last
+			opts.Tail = "all"
+		}
+	}
+}
+
+// Stats streams the stats of a container to opts.Stats, resuming the stream
+// after daemon restarts while the container is running. It returns when the
+// container stops or the context is done, closing opts.Stats. opts.Context
+// is ignored in favor of ctx.
+//
+// Delivery is best-effort: stats aren't recorded by the daemon, so there are
+// no samples for the time it was down. Signaling on opts.Done stops the
+// stream for good. Without opts.Stream, Stats behaves like Client.Stats.
+func (s *StreamSupervisor) Stats(ctx context.Context, opts StatsOptions) error {
+	opts.Context = ctx
+	if !opts.Stream {
+		return s.client.Stats(opts)
+	}
+	out := opts.Stats
+	defer close(out)
+	// Client.Stats consumes the signal sent on Done, so it's replaced by a
+	// channel that is closed once, stopping the current and future streams.
+	done := make(chan bool)
+	finished := make(chan struct{})
+	defer close(finished)
+	if opts.Done != nil {
+		go func(userDone <-chan bool) {
+			select {
+			case <-userDone:
+				close(done)
+			case <-finished:
+			}
+		}(opts.Done)
+	}
+	opts.Done = done
+	for {
+		stats := make(chan *Stats)
+		opts.Stats = stats
+		errC := make(chan error, 1)
+		go func(opts StatsOptions) {
+			errC <- s.client.Stats(opts)
+		}(opts)
+		for stat := range stats {
+			select {
+			case out <- stat:
+			case <-ctx.Done():
+			}
+		}
+		err := <-errC
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		select {
+		case <-done:
+			// stopping through Done makes Client.Stats fail with a
+			// closed pipe, but the stream ended as requested.
+			return nil
+		default:
+		}
+		if stop, err := s.shouldStop(ctx, opts.ID, err); stop {
+			return err
+		}
+	}
+}
+
+// shouldStop decides whether a stream of the given container that ended with
+// err should be resumed, waiting for the daemon to be available. Streams end
+// for good on API errors and when the container is no longer running.
+func (s *StreamSupervisor) shouldStop(ctx context.Context, id string, err error) (bool, error) {
+	switch err.(type) {
+	case *Error, *NoSuchContainer:
+		return true, err
+	}
+	if err := s.waitForDaemon(ctx); err != nil {
+		return true, err
+	}
+	container, err := s.client.InspectContainerWithContext(id, ctx)
+	if err != nil {
+		return true, err
+	}
+	return !container.State.Running, nil
+}
+
+// waitForDaemon pings the daemon until it responds, the context is done or
+// MaxDowntime is exceeded.
+func (s *StreamSupervisor) waitForDaemon(ctx context.Context) error {
+	interval := s.RetryInterval
+	if interval == 0 {
+		interval = defaultStreamRetryInterval
+	}
+	var deadline <-chan time.Time
+	if s.MaxDowntime > 0 {
+		timer := time.NewTimer(s.MaxDowntime)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		err := s.client.PingWithContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return err
+		case <-time.After(interval):
+		}
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,265 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// restartingDaemon simulates a daemon that restarts after serving the first
+// stream: the first ping after that fails, and the next ones succeed.
+type restartingDaemon struct {
+	mu       sync.Mutex
+	streams  []*http.Request
+	pings    int32
+	inspects int32
+	stream   func(w http.ResponseWriter, r *http.Request, n int)
+	running  func(n int32) bool
+}
+
+func (d *restartingDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/_ping":
+		if atomic.AddInt32(&d.pings, 1) == 1 {
+			http.Error(w, "daemon is restarting", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == "/containers/c1/json":
+		n := atomic.AddInt32(&d.inspects, 1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Container{ID: "c1", State: State{Running: d.running(n)}})
+	default:
+		d.mu.Lock()
+		d.streams = append(d.streams, r)
+		n := len(d.streams)
+		d.mu.Unlock()
+		d.stream(w, r, n)
+	}
+}
+
+func (d *restartingDaemon) requests() []*http.Request {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*http.Request(nil), d.streams...)
+}
+
+func newStreamSupervisor(t *testing.T, d *restartingDaemon) (*StreamSupervisor, *httptest.Server) {
+	server := httptest.NewServer(d)
+	client, err := NewClient(server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	supervisor := client.NewStreamSupervisor()
+	supervisor.RetryInterval = time.Millisecond
+	return supervisor, server
+}
+
+func TestStreamSupervisorEvents(t *testing.T) {
+	t.Parallel()
+	daemon := &restartingDaemon{stream: func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Header().Set("Content-Type", "application/json")
+		if n == 1 {
+			fmt.Fprint(w, `{"Type":"container","Action":"start","time":1500000001,"timeNano":1500000001000000001}`)
+			fmt.Fprint(w, `{"Type":"container","Action":"die","time":1500000002,"timeNano":1500000002000000005}`)
+			return
+		}
+		fmt.Fprint(w, `{"Type":"container","Action":"destroy","time":1500000003,"timeNano":1500000003000000000}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}}
+	supervisor, server := newStreamSupervisor(t, daemon)
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events := make(chan *APIEvents)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- supervisor.Events(ctx, EventsOptions{Filters: map[string][]string{"type": {"container"}}}, events)
+	}()
+	var actions []string
+	for len(actions) < 3 {
+		select {
+		case event := <-events:
+			actions = append(actions, event.Action)
+		case err := <-errC:
+			t.Fatalf("Events: unexpected return: %v", err)
+		}
+	}
+	cancel()
+	if err := <-errC; err != context.Canceled {
+		t.Errorf("Events: wrong error. Want %#v. Got %#v.", context.Canceled, err)
+	}
+	if expected := []string{"start", "die", "destroy"}; fmt.Sprint(actions) != fmt.Sprint(expected) {
+		t.Errorf("Events: wrong events. Want %v. Got %v.", expected, actions)
+	}
+	reqs := daemon.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("Events: wrong number of streams. Want 2. Got %d.", len(reqs))
+	}
+	if since := reqs[0].URL.Query().Get("since"); since != "" {
+		t.Errorf("Events: unexpected since in the first stream: %q", since)
+	}
+	if since, expected := reqs[1].URL.Query().Get("since"), "1500000002.000000005"; since != expected {
+		t.Errorf("Events: wrong since when resuming. Want %q. Got %q.", expected, since)
+	}
+	if filters, expected := reqs[1].URL.Query().Get("filters"), `{"type":["container"]}`; filters != expected {
+		t.Errorf("Events: wrong filters when resuming. Want %q. Got %q.", expected, filters)
+	}
+	if pings := atomic.LoadInt32(&daemon.pings); pings != 2 {
+		t.Errorf("Events: wrong number of pings. Want 2. Got %d.", pings)
+	}
+}
+
+func TestStreamSupervisorEventsAPIError(t *testing.T) {
+	t.Parallel()
+	daemon := &restartingDaemon{stream: func(w http.ResponseWriter, r *http.Request, n int) {
+		http.Error(w, "invalid filter", http.StatusBadRequest)
+	}}
+	supervisor, server := newStreamSupervisor(t, daemon)
+	defer server.Close()
+	err := supervisor.Events(context.Background(), EventsOptions{}, make(chan *APIEvents))
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusBadRequest {
+		t.Errorf("Events: wrong error. Want *Error with status 400. Got %#v.", err)
+	}
+}
+
+func TestStreamSupervisorMaxDowntime(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_ping" {
+			http.Error(w, "daemon is restarting", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	supervisor := client.NewStreamSupervisor()
+	supervisor.RetryInterval = time.Millisecond
+	supervisor.MaxDowntime = 20 * time.Millisecond
+	err = supervisor.Events(context.Background(), EventsOptions{}, make(chan *APIEvents))
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusServiceUnavailable {
+		t.Errorf("Events: wrong error. Want *Error with status 503. Got %#v.", err)
+	}
+}
+
+func TestStreamSupervisorLogs(t *testing.T) {
+	t.Parallel()
+	daemon := &restartingDaemon{
+		stream: func(w http.ResponseWriter, r *http.Request, n int) {
+			line := fmt.Sprintf("line %d\n", n)
+			w.Write(append([]byte{1, 0, 0, 0, 0, 0, 0, byte(len(line))}, line...))
+		},
+		running: func(n int32) bool { return n == 1 },
+	}
+	supervisor, server := newStreamSupervisor(t, daemon)
+	defer server.Close()
+	var stdout bytes.Buffer
+	err := supervisor.Logs(context.Background(), LogsOptions{
+		Container:    "c1",
+		OutputStream: &stdout,
+		Follow:       true,
+		Stdout:       true,
+		Tail:         "10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "line 1\nline 2\n"; stdout.String() != expected {
+		t.Errorf("Logs: wrong output. Want %q. Got %q.", expected, stdout.String())
+	}
+	reqs := daemon.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("Logs: wrong number of streams. Want 2. Got %d.", len(reqs))
+	}
+	if tail := reqs[0].URL.Query().Get("tail"); tail != "10" {
+		t.Errorf("Logs: wrong tail in the first stream. Want %q. Got %q.", "10", tail)
+	}
+	query := reqs[1].URL.Query()
+	if query.Get("tail") != "all" || query.Get("since") == "" || query.Get("since") == "0" {
+		t.Errorf("Logs: wrong query when resuming: %v", query)
+	}
+}
+
+func TestStreamSupervisorStats(t *testing.T) {
+	t.Parallel()
+	daemon := &restartingDaemon{
+		stream: func(w http.ResponseWriter, r *http.Request, n int) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(Stats{NumProcs: uint32(n)})
+		},
+		running: func(n int32) bool { return n == 1 },
+	}
+	supervisor, server := newStreamSupervisor(t, daemon)
+	defer server.Close()
+	statsC := make(chan *Stats)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- supervisor.Stats(context.Background(), StatsOptions{ID: "c1", Stats: statsC, Stream: true})
+	}()
+	var procs []uint32
+	for stats := range statsC {
+		procs = append(procs, stats.NumProcs)
+	}
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(procs) != "[1 2]" {
+		t.Errorf("Stats: wrong stats. Want [1 2]. Got %v.", procs)
+	}
+}
+
+func TestStreamSupervisorStatsDone(t *testing.T) {
+	t.Parallel()
+	daemon := &restartingDaemon{
+		stream: func(w http.ResponseWriter, r *http.Request, n int) {
+			// like the daemon, send a sample periodically until the
+			// client goes away.
+			w.Header().Set("Content-Type", "application/json")
+			for {
+				json.NewEncoder(w).Encode(Stats{NumProcs: uint32(n)})
+				w.(http.Flusher).Flush()
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(5 * time.Millisecond):
+				}
+			}
+		},
+		running: func(n int32) bool { return true },
+	}
+	supervisor, server := newStreamSupervisor(t, daemon)
+	defer server.Close()
+	statsC := make(chan *Stats)
+	done := make(chan bool)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- supervisor.Stats(context.Background(), StatsOptions{ID: "c1", Stats: statsC, Stream: true, Done: done})
+	}()
+	<-statsC
+	done <- true
+	for range statsC {
+	}
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+	if n := len(daemon.requests()); n != 1 {
+		t.Errorf("Stats: stream resumed after Done. Want 1 stream. Got %d.", n)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/docker/docker/api/types/swarm"
 )
@@ -33,6 +34,13 @@ type InitSwarmOptions struct {
 }
 
 // InitSwarm initializes a new Swarm and returns the node ID.
+//
+// With ForceNewCluster, the node, a manager of a swarm that lost the quorum
+// of its managers, creates a new single-manager swarm keeping the state of
+// the former swarm. The node being part of a swarm is then expected, and
+// the errors of the daemon are returned as is rather than as
+// ErrNodeAlreadyInSwarm, e.g. when the swarm is locked.
+//
 // See https://goo.gl/ZWyG1M for more details.
 func (c *Client) InitSwarm(opts InitSwarmOptions) (string, error) {
 	path := "/swarm/init"
@@ -42,7 +50,7 @@ func (c *Client) InitSwarm(opts InitSwarmOptions) (string, error) {
 		context:   opts.Context,
 	})
 	if err != nil {
-		if e, ok := err.(*Error); ok && (e.Status == http.StatusNotAcceptable || e.Status == http.StatusServiceUnavailable) {
+		if e, ok := err.(*Error); ok && !opts.ForceNewCluster && (e.Status == http.StatusNotAcceptable || e.Status == http.StatusServiceUnavailable) {
 			return "", ErrNodeAlreadyInSwarm
 		}
 		return "", err
@@ -132,9 +140,10 @@ func (c *Client) UpdateSwarm(opts UpdateSwarmOptions) error {
 		if e, ok := err.(*Error); ok && (e.Status == http.StatusNotAcceptable || e.Status == http.StatusServiceUnavailable) {
 			return ErrNodeNotInSwarm
 		}
+		return err
 	}
 	resp.Body.Close()
-	return err
+	return nil
 }
 
 // InspectSwarm inspects a Swarm.
@@ -154,3 +163,37 @@ func (c *Client) InspectSwarm(ctx context.Context) (swarm.Swarm, error) {
 	err = json.NewDecoder(resp.Body).Decode(&response)
 	return response, err
 }
+
+// maxSwarmUpdateAttempts is the number of attempts of the updates of swarm
+// objects done at their current version, when they keep changing meanwhile.
+const maxSwarmUpdateAttempts = 5
+
+// isUpdateOutOfSequence tells whether the update of a swarm object failed
+// because the object changed since the version the update was done at.
+func isUpdateOutOfSequence(err error) bool {
+	e, ok := err.(*Error)
+	return ok && strings.Contains(e.Message, "update out of sequence")
+}
+
+// updateSwarmSpec updates the spec of the swarm with fn, at its current
+// version, retrying when the spec changed meanwhile.
+func (c *Client) updateSwarmSpec(ctx context.Context, fn func(*swarm.Spec)) error {
+	var err error
+	for attempt := 0; attempt < maxSwarmUpdateAttempts; attempt++ {
+		var sw swarm.Swarm
+		sw, err = c.InspectSwarm(ctx)
+		if err != nil {
+			return err
+		}
+		fn(&sw.Spec)
+		err = c.UpdateSwarm(UpdateSwarmOptions{
+			Version: int(sw.Version.Index),
+			Swarm:   sw.Spec,
+			Context: ctx,
+		})
+		if !isUpdateOutOfSequence(err) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+const defaultCARotationPollInterval = time.Second
+
+// RotateSwarmCAOptions specify parameters to the RotateSwarmCA function.
+type RotateSwarmCAOptions struct {
+	// SigningCACert and SigningCAKey are the root CA certificate and key,
+	// in PEM format, the swarm rotates to. When both are empty, the swarm
+	// generates a new root CA, unless ExternalCAs are given, in which case
+	// SigningCACert is required, as the root CA of the external CAs.
+	SigningCACert string
+	SigningCAKey  string
+
+	// ExternalCAs, when set, replace the external CAs that the managers
+	// send the certificate signing requests of the nodes to. Their CACert
+	// defaults to SigningCACert.
+	ExternalCAs []*swarm.ExternalCA
+
+	// NodeCertExpiry, when positive, changes the validity of the node
+	// certificates issued by the new CA.
+	NodeCertExpiry time.Duration
+
+	Context context.Context
+}
+
+// RotateSwarmCA starts the rotation of the root CA of the swarm, either to
+// a new root CA generated by the swarm, to the given one or to external
+// CAs, returning once the rotation has started. Nodes then renew their
+// certificates gradually, use WaitSwarmCARotation to wait for them.
+//
+// The spec of the swarm is updated at its current version, and the update
+// is retried when the spec changed meanwhile.
+func (c *Client) RotateSwarmCA(opts RotateSwarmCAOptions) error {
+	if err := validateSwarmCAOptions(opts); err != nil {
+		return err
+	}
+	return c.updateSwarmSpec(opts.Context, func(spec *swarm.Spec) {
+		ca := &spec.CAConfig
+		ca.SigningCACert = opts.SigningCACert
+		ca.SigningCAKey = opts.SigningCAKey
+		if opts.ExternalCAs != nil {
+			ca.ExternalCAs = make([]*swarm.ExternalCA, len(opts.ExternalCAs))
+			for i, external := range opts.ExternalCAs {
+				externalCA := *external
+				if externalCA.Protocol == "" {
+					externalCA.Protocol = swarm.ExternalCAProtocolCFSSL
+				}
+				if externalCA.CACert == "" {
+					externalCA.CACert = opts.SigningCACert
+				}
+				ca.ExternalCAs[i] = &externalCA
+			}
+		}
+		if opts.NodeCertExpiry > 0 {
+			ca.NodeCertExpiry = opts.NodeCertExpiry
+		}
+		// the swarm rotates its root CA when ForceRotate changes, even
+		// when it's given the certificate it already uses.
+		ca.ForceRotate++
+	})
+}
+
+func validateSwarmCAOptions(opts RotateSwarmCAOptions) error {
+	if opts.SigningCAKey != "" && opts.SigningCACert == "" {
+		return errors.New("swarm CA rotation: SigningCAKey requires SigningCACert")
+	}
+	if len(opts.ExternalCAs) > 0 && opts.SigningCACert == "" {
+		return errors.New("swarm CA rotation: external CAs require SigningCACert")
+	}
+	for _, external := range opts.ExternalCAs {
+		if external == nil {
+			return errors.New("swarm CA rotation: nil external CA")
+		}
+		if external.Protocol != "" && external.Protocol != swarm.ExternalCAProtocolCFSSL {
+			return fmt.Errorf("swarm CA rotation: unsupported external CA protocol %q", external.Protocol)
+		}
+		u, err := url.Parse(external.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("swarm CA rotation: invalid external CA URL %q", external.URL)
+		}
+	}
+	return nil
+}
+
+// SwarmCARotationProgress is the progress of the rotation of the root CA of
+// a swarm, reported by WaitSwarmCARotation.
+type SwarmCARotationProgress struct {
+	// Updated is the number of nodes trusting the new root CA, with a
+	// certificate issued by it.
+	Updated int
+
+	// Total is the number of nodes waited for.
+	Total int
+
+	// InProgress tells whether the swarm still reports the rotation as in
+	// progress.
+	InProgress bool
+}
+
+// WaitSwarmCARotationOptions specify parameters to the WaitSwarmCARotation
+// function.
+type WaitSwarmCARotationOptions struct {
+	// PollInterval is the interval between two checks of the nodes.
+	// Defaults to one second.
+	PollInterval time.Duration
+
+	// Progress, when set, is called after each check.
+	Progress func(SwarmCARotationProgress)
+
+	Context context.Context
+}
+
+// WaitSwarmCARotation waits for the rotation of the root CA of the swarm to
+// complete: the swarm no longer reports the rotation as in progress, and
+// every node trusts the new root CA and has a certificate issued by it.
+// Nodes that are down are not waited for, as they can't renew their
+// certificates until they're back.
+//
+// It returns the swarm as of the end of the rotation.
+func (c *Client) WaitSwarmCARotation(opts WaitSwarmCARotationOptions) (*swarm.Swarm, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultCARotationPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		sw, progress, err := c.swarmCARotationProgress(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		if !progress.InProgress && progress.Updated == progress.Total {
+			return sw, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) swarmCARotationProgress(ctx context.Context) (*swarm.Swarm, SwarmCARotationProgress, error) {
+	var progress SwarmCARotationProgress
+	sw, err := c.InspectSwarm(ctx)
+	if err != nil {
+		return nil, progress, err
+	}
+	nodes, err := c.ListNodes(ListNodesOptions{Context: ctx})
+	if err != nil {
+		return nil, progress, err
+	}
+	progress.InProgress = sw.RootRotationInProgress
+	for _, node := range nodes {
+		if node.Status.State == swarm.NodeStateDown {
+			continue
+		}
+		progress.Total++
+		if equalTLSInfo(node.Description.TLSInfo, sw.TLSInfo) {
+			progress.Updated++
+		}
+	}
+	return &sw, progress, nil
+}
+
+func equalTLSInfo(a, b swarm.TLSInfo) bool {
+	return a.TrustRoot == b.TrustRoot &&
+		string(a.CertIssuerSubject) == string(b.CertIssuerSubject) &&
+		string(a.CertIssuerPublicKey) == string(b.CertIssuerPublicKey)
+}
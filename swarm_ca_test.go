@@ -0,0 +1,225 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// fakeSwarm is a handler serving the swarm and node endpoints of a daemon,
+// rejecting the updates done at stale versions like swarmkit does.
+type fakeSwarm struct {
+	mu    sync.Mutex
+	swarm swarm.Swarm
+	nodes []swarm.Node
+
+	// conflicts is the number of updates rejected as out of sequence
+	// before the next one succeeds.
+	conflicts int
+	updates   int
+}
+
+func (f *fakeSwarm) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/swarm":
+		json.NewEncoder(w).Encode(f.swarm)
+	case r.Method == "POST" && r.URL.Path == "/swarm/update":
+		if !f.checkVersion(w, r, &f.swarm.Version) {
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&f.swarm.Spec)
+	case r.Method == "GET" && r.URL.Path == "/nodes":
+		json.NewEncoder(w).Encode(f.nodes)
+	case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/nodes/") && strings.HasSuffix(r.URL.Path, "/update"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/nodes/"), "/update")
+		for i := range f.nodes {
+			if f.nodes[i].ID == id {
+				if f.checkVersion(w, r, &f.nodes[i].Version) {
+					json.NewDecoder(r.Body).Decode(&f.nodes[i].Spec)
+				}
+				return
+			}
+		}
+		http.Error(w, "node "+id+" not found", http.StatusNotFound)
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/nodes/"):
+		id := strings.TrimPrefix(r.URL.Path, "/nodes/")
+		for _, node := range f.nodes {
+			// like the daemon, nodes are also found by their hostname.
+			if node.ID == id || node.Description.Hostname == id {
+				json.NewEncoder(w).Encode(node)
+				return
+			}
+		}
+		http.Error(w, "node "+id+" not found", http.StatusNotFound)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+func (f *fakeSwarm) checkVersion(w http.ResponseWriter, r *http.Request, version *swarm.Version) bool {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("version"), 10, 64)
+	if f.conflicts > 0 {
+		f.conflicts--
+		// another client updated the object meanwhile.
+		version.Index++
+	}
+	if index != version.Index {
+		http.Error(w, "rpc error: code = Unknown desc = update out of sequence", http.StatusInternalServerError)
+		return false
+	}
+	version.Index++
+	f.updates++
+	return true
+}
+
+func TestRotateSwarmCA(t *testing.T) {
+	t.Parallel()
+	fake := &fakeSwarm{conflicts: 2}
+	fake.swarm.Version.Index = 10
+	fake.swarm.Spec.CAConfig.ForceRotate = 3
+	fake.swarm.Spec.Annotations.Name = "default"
+	client := NewClientFromHandler(fake)
+	err := client.RotateSwarmCA(RotateSwarmCAOptions{
+		SigningCACert:  "cert",
+		SigningCAKey:   "key",
+		ExternalCAs:    []*swarm.ExternalCA{{URL: "https://ca.example.com/sign"}},
+		NodeCertExpiry: 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.updates != 1 {
+		t.Errorf("RotateSwarmCA: want one update. Got %d.", fake.updates)
+	}
+	spec := fake.swarm.Spec
+	if spec.Annotations.Name != "default" {
+		t.Errorf("RotateSwarmCA: spec not preserved: %#v", spec)
+	}
+	ca := spec.CAConfig
+	if ca.ForceRotate != 4 || ca.SigningCACert != "cert" || ca.SigningCAKey != "key" || ca.NodeCertExpiry != 24*time.Hour {
+		t.Errorf("RotateSwarmCA: wrong CA config: %#v", ca)
+	}
+	expected := swarm.ExternalCA{Protocol: swarm.ExternalCAProtocolCFSSL, URL: "https://ca.example.com/sign", CACert: "cert"}
+	if len(ca.ExternalCAs) != 1 || ca.ExternalCAs[0].Protocol != expected.Protocol || ca.ExternalCAs[0].URL != expected.URL || ca.ExternalCAs[0].CACert != expected.CACert {
+		t.Errorf("RotateSwarmCA: wrong external CAs: %#v", ca.ExternalCAs)
+	}
+}
+
+func TestRotateSwarmCAOutOfSequence(t *testing.T) {
+	t.Parallel()
+	fake := &fakeSwarm{conflicts: maxSwarmUpdateAttempts}
+	client := NewClientFromHandler(fake)
+	err := client.RotateSwarmCA(RotateSwarmCAOptions{})
+	if !isUpdateOutOfSequence(err) {
+		t.Errorf("RotateSwarmCA: want an out of sequence error. Got %#v.", err)
+	}
+}
+
+func TestRotateSwarmCAInvalidOptions(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(&fakeSwarm{})
+	tests := []RotateSwarmCAOptions{
+		{SigningCAKey: "key"},
+		{ExternalCAs: []*swarm.ExternalCA{{URL: "https://ca.example.com"}}},
+		{SigningCACert: "cert", ExternalCAs: []*swarm.ExternalCA{nil}},
+		{SigningCACert: "cert", ExternalCAs: []*swarm.ExternalCA{{Protocol: "acme", URL: "https://ca.example.com"}}},
+		{SigningCACert: "cert", ExternalCAs: []*swarm.ExternalCA{{URL: "ca.example.com"}}},
+	}
+	for _, opts := range tests {
+		if err := client.RotateSwarmCA(opts); err == nil {
+			t.Errorf("RotateSwarmCA(%#v): unexpected <nil> error", opts)
+		}
+	}
+}
+
+func TestWaitSwarmCARotation(t *testing.T) {
+	t.Parallel()
+	newTLS := swarm.TLSInfo{TrustRoot: "new", CertIssuerSubject: []byte("subject"), CertIssuerPublicKey: []byte("key")}
+	oldTLS := swarm.TLSInfo{TrustRoot: "old"}
+	fake := &fakeSwarm{}
+	fake.swarm.TLSInfo = newTLS
+	fake.swarm.RootRotationInProgress = true
+	fake.nodes = []swarm.Node{
+		{ID: "n1", Description: swarm.NodeDescription{TLSInfo: newTLS}, Status: swarm.NodeStatus{State: swarm.NodeStateReady}},
+		{ID: "n2", Description: swarm.NodeDescription{TLSInfo: oldTLS}, Status: swarm.NodeStatus{State: swarm.NodeStateReady}},
+		{ID: "n3", Description: swarm.NodeDescription{TLSInfo: oldTLS}, Status: swarm.NodeStatus{State: swarm.NodeStateDown}},
+	}
+	client := NewClientFromHandler(fake)
+	var progress []SwarmCARotationProgress
+	sw, err := client.WaitSwarmCARotation(WaitSwarmCARotationOptions{
+		PollInterval: time.Millisecond,
+		Progress: func(p SwarmCARotationProgress) {
+			progress = append(progress, p)
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			switch len(progress) {
+			case 1:
+				fake.nodes[1].Description.TLSInfo = newTLS
+			case 2:
+				fake.swarm.RootRotationInProgress = false
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sw.TLSInfo.TrustRoot != "new" {
+		t.Errorf("WaitSwarmCARotation: wrong swarm: %#v", sw)
+	}
+	expected := []SwarmCARotationProgress{
+		{Updated: 1, Total: 2, InProgress: true},
+		{Updated: 2, Total: 2, InProgress: true},
+		{Updated: 2, Total: 2},
+	}
+	if len(progress) != len(expected) {
+		t.Fatalf("WaitSwarmCARotation: wrong progress. Want %#v. Got %#v.", expected, progress)
+	}
+	for i := range expected {
+		if progress[i] != expected[i] {
+			t.Errorf("WaitSwarmCARotation: wrong progress %d. Want %#v. Got %#v.", i, expected[i], progress[i])
+		}
+	}
+}
+
+func TestWaitSwarmCARotationContext(t *testing.T) {
+	t.Parallel()
+	fake := &fakeSwarm{}
+	fake.swarm.RootRotationInProgress = true
+	client := NewClientFromHandler(fake)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.WaitSwarmCARotation(WaitSwarmCARotationOptions{PollInterval: time.Millisecond, Context: ctx})
+	if err != context.DeadlineExceeded {
+		t.Errorf("WaitSwarmCARotation: wrong error. Want %#v. Got %#v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestInitSwarmForceNewCluster(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: "swarm is encrypted and needs to be unlocked", status: http.StatusServiceUnavailable}
+	client := newTestClient(fakeRT)
+	_, err := client.InitSwarm(InitSwarmOptions{InitRequest: swarm.InitRequest{ForceNewCluster: true}})
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusServiceUnavailable {
+		t.Errorf("InitSwarm: wrong error. Want the error of the daemon. Got %#v.", err)
+	}
+	var req swarm.InitRequest
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&req); err != nil {
+		t.Fatal(err)
+	}
+	if !req.ForceNewCluster {
+		t.Error("InitSwarm: ForceNewCluster not sent")
+	}
+}
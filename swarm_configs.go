@@ -18,6 +18,8 @@ import (
 type NoSuchConfig struct {
 	ID  string
 	Err error
+
+	errorMetadata
 }
 
 func (err *NoSuchConfig) Error() string {
@@ -39,8 +41,14 @@ type CreateConfigOptions struct {
 // CreateConfig creates a new config, returning the config instance
 // or an error in case of failure.
 //
+// The payload of the config may be evaluated as a template by setting the
+// Templating of the spec (API 1.37 and above).
+//
 // See https://goo.gl/KrVjHz for more details.
 func (c *Client) CreateConfig(opts CreateConfigOptions) (*swarm.Config, error) {
+	if err := c.validateTemplating("config", opts.Templating); err != nil {
+		return nil, err
+	}
 	headers, err := headersWithAuth(opts.Auth)
 	if err != nil {
 		return nil, err
@@ -79,7 +87,7 @@ func (c *Client) RemoveConfig(opts RemoveConfigOptions) error {
 	resp, err := c.do("DELETE", path, doOptions{context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchConfig{ID: opts.ID}
+			return &NoSuchConfig{ID: opts.ID, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -103,6 +111,9 @@ type UpdateConfigOptions struct {
 // https://docs.docker.com/engine/api/v1.33/#operation/ConfigUpdate
 // See https://goo.gl/wu3MmS for more details.
 func (c *Client) UpdateConfig(id string, opts UpdateConfigOptions) error {
+	if err := c.validateTemplating("config", opts.Templating); err != nil {
+		return err
+	}
 	headers, err := headersWithAuth(opts.Auth)
 	if err != nil {
 		return err
@@ -117,7 +128,7 @@ func (c *Client) UpdateConfig(id string, opts UpdateConfigOptions) error {
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchConfig{ID: id}
+			return &NoSuchConfig{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -133,7 +144,7 @@ func (c *Client) InspectConfig(id string) (*swarm.Config, error) {
 	resp, err := c.do("GET", path, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchConfig{ID: id}
+			return nil, &NoSuchConfig{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -164,7 +175,7 @@ func (c *Client) ListConfigs(opts ListConfigsOptions) ([]swarm.Config, error) {
 	}
 	defer resp.Body.Close()
 	var configs []swarm.Config
-	if err := json.NewDecoder(resp.Body).Decode(&configs); err != nil {
+	if err := decodeJSONList(resp.Body, &configs); err != nil {
 		return nil, err
 	}
 	return configs, nil
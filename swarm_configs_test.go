@@ -51,6 +51,44 @@ func TestCreateConfig(t *testing.T) {
 	}
 }
 
+func TestCreateConfigTemplating(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id": "abc"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion139
+	spec := swarm.ConfigSpec{
+		Annotations: swarm.Annotations{Name: "nginx.conf"},
+		Data:        []byte("server_name {{ .Service.Name }};"),
+		Templating:  &swarm.Driver{Name: TemplatingDriverGolang},
+	}
+	if _, err := client.CreateConfig(CreateConfigOptions{ConfigSpec: spec}); err != nil {
+		t.Fatal(err)
+	}
+	var gotSpec swarm.ConfigSpec
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&gotSpec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotSpec, spec) {
+		t.Errorf("CreateConfig: wrong spec sent.\nWant %#v\nGot  %#v", spec, gotSpec)
+	}
+}
+
+func TestCreateConfigTemplatingOldAPI(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id": "abc"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion135
+	opts := CreateConfigOptions{ConfigSpec: swarm.ConfigSpec{Templating: &swarm.Driver{Name: TemplatingDriverGolang}}}
+	expected := "config Templating is only supported in API#1.37 and above"
+	if _, err := client.CreateConfig(opts); err == nil || err.Error() != expected {
+		t.Errorf("CreateConfig: wrong error. Want %q. Got %v.", expected, err)
+	}
+	err := client.UpdateConfig("abc", UpdateConfigOptions{ConfigSpec: opts.ConfigSpec})
+	if err == nil || err.Error() != expected {
+		t.Errorf("UpdateConfig: wrong error. Want %q. Got %v.", expected, err)
+	}
+}
+
 func TestRemoveConfig(t *testing.T) {
 	t.Parallel()
 	fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
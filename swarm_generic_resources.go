@@ -0,0 +1,99 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// DiscreteGenericResource returns a generic resource counted by the node,
+// e.g. DiscreteGenericResource("GPU", 2) to reserve two GPUs of any node
+// advertising them.
+func DiscreteGenericResource(kind string, value int64) swarm.GenericResource {
+	return swarm.GenericResource{DiscreteResourceSpec: &swarm.DiscreteGenericResource{Kind: kind, Value: value}}
+}
+
+// NamedGenericResource returns a generic resource identified by the node,
+// e.g. NamedGenericResource("GPU", "UUID-1") to reserve a specific GPU.
+func NamedGenericResource(kind, value string) swarm.GenericResource {
+	return swarm.GenericResource{NamedResourceSpec: &swarm.NamedGenericResource{Kind: kind, Value: value}}
+}
+
+// ParseGenericResources parses generic resources in the format of the
+// --generic-resource flag of docker service create, kind=value: integer
+// values are discrete resources, e.g. "GPU=2", and other values are named
+// resources, e.g. "GPU=UUID-1".
+func ParseGenericResources(specs []string) ([]swarm.GenericResource, error) {
+	resources := make([]swarm.GenericResource, 0, len(specs))
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid generic resource %q, want kind=value", spec)
+		}
+		if value, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			resources = append(resources, DiscreteGenericResource(parts[0], value))
+		} else {
+			resources = append(resources, NamedGenericResource(parts[0], parts[1]))
+		}
+	}
+	if err := ValidateGenericResources(resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// ValidateGenericResources checks generic resources before they're sent to
+// the swarm: each one is either discrete, with a positive value, or named,
+// discrete kinds appear once, and a kind isn't both discrete and named.
+func ValidateGenericResources(resources []swarm.GenericResource) error {
+	discrete := make(map[string]bool)
+	named := make(map[string]bool)
+	for _, resource := range resources {
+		switch {
+		case resource.DiscreteResourceSpec != nil && resource.NamedResourceSpec != nil:
+			return fmt.Errorf("generic resource %s is both discrete and named", resource.DiscreteResourceSpec.Kind)
+		case resource.DiscreteResourceSpec != nil:
+			spec := resource.DiscreteResourceSpec
+			if spec.Kind == "" {
+				return fmt.Errorf("discrete generic resource without a kind")
+			}
+			if spec.Value <= 0 {
+				return fmt.Errorf("discrete generic resource %s: invalid value %d", spec.Kind, spec.Value)
+			}
+			if discrete[spec.Kind] {
+				return fmt.Errorf("discrete generic resource %s given more than once", spec.Kind)
+			}
+			discrete[spec.Kind] = true
+		case resource.NamedResourceSpec != nil:
+			spec := resource.NamedResourceSpec
+			if spec.Kind == "" || spec.Value == "" {
+				return fmt.Errorf("named generic resource %s=%s: missing kind or value", spec.Kind, spec.Value)
+			}
+			named[spec.Kind] = true
+		default:
+			return fmt.Errorf("empty generic resource")
+		}
+	}
+	for kind := range discrete {
+		if named[kind] {
+			return fmt.Errorf("generic resource %s is both discrete and named", kind)
+		}
+	}
+	return nil
+}
+
+// validateServiceResources checks the generic resources reserved by the
+// tasks of a service.
+func validateServiceResources(spec swarm.ServiceSpec) error {
+	resources := spec.TaskTemplate.Resources
+	if resources == nil || resources.Reservations == nil {
+		return nil
+	}
+	return ValidateGenericResources(resources.Reservations.GenericResources)
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func TestParseGenericResources(t *testing.T) {
+	t.Parallel()
+	resources, err := ParseGenericResources([]string{"GPU=UUID-1", "GPU=UUID-2", "SSD=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []swarm.GenericResource{
+		NamedGenericResource("GPU", "UUID-1"),
+		NamedGenericResource("GPU", "UUID-2"),
+		DiscreteGenericResource("SSD", 2),
+	}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("ParseGenericResources: want %#v. Got %#v.", expected, resources)
+	}
+}
+
+func TestParseGenericResourcesInvalid(t *testing.T) {
+	t.Parallel()
+	tests := [][]string{
+		{"GPU"},
+		{"=2"},
+		{"GPU="},
+		{"GPU=0"},
+		{"GPU=2", "GPU=3"},
+		{"GPU=2", "GPU=UUID-1"},
+	}
+	for _, specs := range tests {
+		if _, err := ParseGenericResources(specs); err == nil {
+			t.Errorf("ParseGenericResources(%q): unexpected <nil> error", specs)
+		}
+	}
+}
+
+func TestValidateGenericResources(t *testing.T) {
+	t.Parallel()
+	both := DiscreteGenericResource("GPU", 1)
+	both.NamedResourceSpec = &swarm.NamedGenericResource{Kind: "GPU", Value: "UUID-1"}
+	tests := []struct {
+		resources []swarm.GenericResource
+		valid     bool
+	}{
+		{nil, true},
+		{[]swarm.GenericResource{DiscreteGenericResource("GPU", 1), NamedGenericResource("FPGA", "/dev/fpga0")}, true},
+		{[]swarm.GenericResource{{}}, false},
+		{[]swarm.GenericResource{both}, false},
+		{[]swarm.GenericResource{DiscreteGenericResource("", 1)}, false},
+		{[]swarm.GenericResource{DiscreteGenericResource("GPU", -1)}, false},
+		{[]swarm.GenericResource{NamedGenericResource("GPU", "")}, false},
+	}
+	for _, tt := range tests {
+		err := ValidateGenericResources(tt.resources)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateGenericResources(%#v): want valid=%v. Got error %v.", tt.resources, tt.valid, err)
+		}
+	}
+}
+
+func TestCreateServiceGenericResources(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"ID": "svc1"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	var opts CreateServiceOptions
+	opts.TaskTemplate.Resources = &swarm.ResourceRequirements{
+		Reservations: &swarm.Resources{GenericResources: []swarm.GenericResource{DiscreteGenericResource("GPU", 2)}},
+	}
+	if _, err := client.CreateService(opts); err != nil {
+		t.Fatal(err)
+	}
+	var spec swarm.ServiceSpec
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	generic := spec.TaskTemplate.Resources.Reservations.GenericResources
+	if len(generic) != 1 || generic[0].DiscreteResourceSpec == nil || generic[0].DiscreteResourceSpec.Value != 2 {
+		t.Errorf("CreateService: wrong generic resources: %#v", generic)
+	}
+
+	opts.TaskTemplate.Resources.Reservations.GenericResources = []swarm.GenericResource{DiscreteGenericResource("GPU", 0)}
+	if _, err := client.CreateService(opts); err == nil {
+		t.Error("CreateService: unexpected <nil> error for an invalid generic resource")
+	}
+	err := client.UpdateService("svc1", UpdateServiceOptions{ServiceSpec: opts.ServiceSpec})
+	if err == nil {
+		t.Error("UpdateService: unexpected <nil> error for an invalid generic resource")
+	}
+	if len(fakeRT.requests) != 1 {
+		t.Errorf("unexpected requests for invalid generic resources: %d", len(fakeRT.requests))
+	}
+}
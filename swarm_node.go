@@ -18,6 +18,8 @@ import (
 type NoSuchNode struct {
 	ID  string
 	Err error
+
+	errorMetadata
 }
 
 func (err *NoSuchNode) Error() string {
@@ -46,7 +48,7 @@ func (c *Client) ListNodes(opts ListNodesOptions) ([]swarm.Node, error) {
 	}
 	defer resp.Body.Close()
 	var nodes []swarm.Node
-	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+	if err := decodeJSONList(resp.Body, &nodes); err != nil {
 		return nil, err
 	}
 	return nodes, nil
@@ -56,10 +58,14 @@ func (c *Client) ListNodes(opts ListNodesOptions) ([]swarm.Node, error) {
 //
 // See http://goo.gl/WjkTOk for more details.
 func (c *Client) InspectNode(id string) (*swarm.Node, error) {
-	resp, err := c.do("GET", "/nodes/"+id, doOptions{})
+	return c.inspectNode(context.Background(), id)
+}
+
+func (c *Client) inspectNode(ctx context.Context, id string) (*swarm.Node, error) {
+	resp, err := c.do("GET", "/nodes/"+id, doOptions{context: ctx})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchNode{ID: id}
+			return nil, &NoSuchNode{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -94,7 +100,7 @@ func (c *Client) UpdateNode(id string, opts UpdateNodeOptions) error {
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchNode{ID: id}
+			return &NoSuchNode{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -121,7 +127,7 @@ func (c *Client) RemoveNode(opts RemoveNodeOptions) error {
 	resp, err := c.do("DELETE", path, doOptions{context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchNode{ID: opts.ID}
+			return &NoSuchNode{ID: opts.ID, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
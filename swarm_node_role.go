@@ -0,0 +1,114 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// ErrQuorumLoss is the error returned by DemoteNode when demoting the node
+// would leave the swarm with fewer reachable managers than its quorum.
+var ErrQuorumLoss = errors.New("demoting the node would break the quorum of the swarm managers")
+
+// NodeRoleOptions specify parameters to the PromoteNode and DemoteNode
+// functions.
+type NodeRoleOptions struct {
+	ID string
+
+	// Force makes DemoteNode demote the node even when it breaks the
+	// quorum of the managers.
+	Force bool
+
+	Context context.Context
+}
+
+// PromoteNode promotes a worker node to a manager. Promoting a manager is a
+// no-op.
+//
+// The spec of the node is updated at its current version, and the update
+// is retried when the node changed meanwhile.
+func (c *Client) PromoteNode(opts NodeRoleOptions) error {
+	return c.setNodeRole(opts.Context, opts.ID, swarm.NodeRoleManager)
+}
+
+// DemoteNode demotes a manager node to a worker. Demoting a worker is a
+// no-op.
+//
+// Unless opts.Force is set, it returns ErrQuorumLoss when the managers that
+// would remain reachable after the demotion are fewer than the quorum of
+// the remaining managers, as the swarm would then be unable to elect a
+// leader and to accept changes.
+//
+// The spec of the node is updated at its current version, and the update
+// is retried when the node changed meanwhile.
+func (c *Client) DemoteNode(opts NodeRoleOptions) error {
+	if !opts.Force {
+		// opts.ID may be a hostname or a short ID: the node is resolved to
+		// find it in the list of managers.
+		node, err := c.inspectNode(opts.Context, opts.ID)
+		if err != nil {
+			return err
+		}
+		nodes, err := c.ListNodes(ListNodesOptions{Context: opts.Context})
+		if err != nil {
+			return err
+		}
+		if demoteBreaksQuorum(nodes, node.ID) {
+			return ErrQuorumLoss
+		}
+	}
+	return c.setNodeRole(opts.Context, opts.ID, swarm.NodeRoleWorker)
+}
+
+// demoteBreaksQuorum tells whether demoting the node would leave the swarm
+// with fewer reachable managers than the raft quorum of its managers.
+func demoteBreaksQuorum(nodes []swarm.Node, id string) bool {
+	var managers, reachable int
+	demoted := false
+	for _, node := range nodes {
+		if node.Spec.Role != swarm.NodeRoleManager {
+			continue
+		}
+		if node.ID == id {
+			demoted = true
+			continue
+		}
+		managers++
+		if node.ManagerStatus != nil && node.ManagerStatus.Reachability == swarm.ReachabilityReachable {
+			reachable++
+		}
+	}
+	if !demoted {
+		return false
+	}
+	return reachable < managers/2+1
+}
+
+func (c *Client) setNodeRole(ctx context.Context, id string, role swarm.NodeRole) error {
+	var err error
+	for attempt := 0; attempt < maxSwarmUpdateAttempts; attempt++ {
+		var node *swarm.Node
+		node, err = c.inspectNode(ctx, id)
+		if err != nil {
+			return err
+		}
+		if node.Spec.Role == role {
+			return nil
+		}
+		node.Spec.Role = role
+		err = c.UpdateNode(node.ID, UpdateNodeOptions{
+			NodeSpec: node.Spec,
+			Version:  node.Version.Index,
+			Context:  ctx,
+		})
+		if !isUpdateOutOfSequence(err) {
+			return err
+		}
+	}
+	return err
+}
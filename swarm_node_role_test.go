@@ -0,0 +1,141 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func swarmManager(id string, reachability swarm.Reachability) swarm.Node {
+	node := swarm.Node{ID: id, ManagerStatus: &swarm.ManagerStatus{Reachability: reachability}}
+	node.Spec.Role = swarm.NodeRoleManager
+	node.Version.Index = 5
+	return node
+}
+
+func swarmWorker(id string) swarm.Node {
+	node := swarm.Node{ID: id}
+	node.Spec.Role = swarm.NodeRoleWorker
+	node.Version.Index = 5
+	return node
+}
+
+func TestPromoteNode(t *testing.T) {
+	t.Parallel()
+	fake := &fakeSwarm{conflicts: 1}
+	fake.nodes = []swarm.Node{swarmManager("m1", swarm.ReachabilityReachable), swarmWorker("w1")}
+	fake.nodes[1].Spec.Labels = map[string]string{"zone": "a"}
+	client := NewClientFromHandler(fake)
+	if err := client.PromoteNode(NodeRoleOptions{ID: "w1"}); err != nil {
+		t.Fatal(err)
+	}
+	node := fake.nodes[1]
+	if node.Spec.Role != swarm.NodeRoleManager || node.Spec.Labels["zone"] != "a" {
+		t.Errorf("PromoteNode: wrong spec: %#v", node.Spec)
+	}
+	if fake.updates != 1 {
+		t.Errorf("PromoteNode: want one update. Got %d.", fake.updates)
+	}
+	if err := client.PromoteNode(NodeRoleOptions{ID: "w1"}); err != nil {
+		t.Fatal(err)
+	}
+	if fake.updates != 1 {
+		t.Errorf("PromoteNode: unexpected update of a manager")
+	}
+}
+
+func TestPromoteNodeNotFound(t *testing.T) {
+	t.Parallel()
+	client := NewClientFromHandler(&fakeSwarm{})
+	err := client.PromoteNode(NodeRoleOptions{ID: "w1"})
+	if _, ok := err.(*NoSuchNode); !ok {
+		t.Errorf("PromoteNode: wrong error. Want *NoSuchNode. Got %#v.", err)
+	}
+}
+
+func TestDemoteNode(t *testing.T) {
+	t.Parallel()
+	fake := &fakeSwarm{}
+	fake.nodes = []swarm.Node{
+		swarmManager("m1", swarm.ReachabilityReachable),
+		swarmManager("m2", swarm.ReachabilityReachable),
+		swarmManager("m3", swarm.ReachabilityReachable),
+	}
+	client := NewClientFromHandler(fake)
+	if err := client.DemoteNode(NodeRoleOptions{ID: "m3"}); err != nil {
+		t.Fatal(err)
+	}
+	if role := fake.nodes[2].Spec.Role; role != swarm.NodeRoleWorker {
+		t.Errorf("DemoteNode: wrong role. Want %q. Got %q.", swarm.NodeRoleWorker, role)
+	}
+}
+
+func TestDemoteNodeQuorumLoss(t *testing.T) {
+	t.Parallel()
+	fake := &fakeSwarm{}
+	fake.nodes = []swarm.Node{
+		swarmManager("m1", swarm.ReachabilityReachable),
+		swarmManager("m2", swarm.ReachabilityReachable),
+		swarmManager("m3", swarm.ReachabilityUnreachable),
+	}
+	client := NewClientFromHandler(fake)
+	if err := client.DemoteNode(NodeRoleOptions{ID: "m1"}); err != ErrQuorumLoss {
+		t.Errorf("DemoteNode: wrong error. Want %#v. Got %#v.", ErrQuorumLoss, err)
+	}
+	if fake.updates != 0 {
+		t.Error("DemoteNode: unexpected update")
+	}
+	if err := client.DemoteNode(NodeRoleOptions{ID: "m1", Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if role := fake.nodes[0].Spec.Role; role != swarm.NodeRoleWorker {
+		t.Errorf("DemoteNode: wrong role. Want %q. Got %q.", swarm.NodeRoleWorker, role)
+	}
+}
+
+func TestDemoteNodeQuorumLossByHostname(t *testing.T) {
+	t.Parallel()
+	fake := &fakeSwarm{}
+	fake.nodes = []swarm.Node{
+		swarmManager("m1", swarm.ReachabilityReachable),
+		swarmManager("m2", swarm.ReachabilityReachable),
+		swarmManager("m3", swarm.ReachabilityUnreachable),
+	}
+	fake.nodes[0].Description.Hostname = "manager-1"
+	client := NewClientFromHandler(fake)
+	if err := client.DemoteNode(NodeRoleOptions{ID: "manager-1"}); err != ErrQuorumLoss {
+		t.Errorf("DemoteNode: wrong error. Want %#v. Got %#v.", ErrQuorumLoss, err)
+	}
+	if fake.updates != 0 {
+		t.Error("DemoteNode: unexpected update")
+	}
+}
+
+func TestDemoteBreaksQuorum(t *testing.T) {
+	t.Parallel()
+	reachable := swarm.ReachabilityReachable
+	unreachable := swarm.ReachabilityUnreachable
+	tests := []struct {
+		name   string
+		nodes  []swarm.Node
+		id     string
+		breaks bool
+	}{
+		{"last manager", []swarm.Node{swarmManager("m1", reachable), swarmWorker("w1")}, "m1", true},
+		{"two managers", []swarm.Node{swarmManager("m1", reachable), swarmManager("m2", reachable)}, "m1", false},
+		{"three managers", []swarm.Node{swarmManager("m1", reachable), swarmManager("m2", reachable), swarmManager("m3", reachable)}, "m1", false},
+		{"unreachable manager", []swarm.Node{swarmManager("m1", reachable), swarmManager("m2", reachable), swarmManager("m3", unreachable)}, "m1", true},
+		{"demote unreachable", []swarm.Node{swarmManager("m1", reachable), swarmManager("m2", reachable), swarmManager("m3", unreachable)}, "m3", false},
+		{"worker", []swarm.Node{swarmManager("m1", reachable), swarmWorker("w1")}, "w1", false},
+		{"four managers", []swarm.Node{swarmManager("m1", reachable), swarmManager("m2", reachable), swarmManager("m3", reachable), swarmManager("m4", unreachable)}, "m1", false},
+	}
+	for _, tt := range tests {
+		if breaks := demoteBreaksQuorum(tt.nodes, tt.id); breaks != tt.breaks {
+			t.Errorf("%s: demoteBreaksQuorum: want %v. Got %v.", tt.name, tt.breaks, breaks)
+		}
+	}
+}
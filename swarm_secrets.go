@@ -7,6 +7,7 @@ package docker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -18,6 +19,8 @@ import (
 type NoSuchSecret struct {
 	ID  string
 	Err error
+
+	errorMetadata
 }
 
 func (err *NoSuchSecret) Error() string {
@@ -27,6 +30,38 @@ func (err *NoSuchSecret) Error() string {
 	return "No such secret: " + err.ID
 }
 
+// TemplatingDriverGolang is the name of the driver that evaluates the payload
+// of secrets and configs as Go templates, see swarm.SecretSpec.Templating and
+// swarm.ConfigSpec.Templating.
+const TemplatingDriverGolang = "golang"
+
+// validateSecretSpec checks that the server supports the external secret
+// driver and the templating driver set in the spec.
+func (c *Client) validateSecretSpec(spec swarm.SecretSpec) error {
+	if spec.Driver != nil {
+		if spec.Driver.Name == "" {
+			return errors.New("secret driver name is required")
+		}
+		if version := c.getServerAPIVersion(); version != nil && version.LessThan(apiVersion131) {
+			return errors.New("secret Driver is only supported in API#1.31 and above")
+		}
+	}
+	return c.validateTemplating("secret", spec.Templating)
+}
+
+func (c *Client) validateTemplating(kind string, templating *swarm.Driver) error {
+	if templating == nil {
+		return nil
+	}
+	if templating.Name == "" {
+		return errors.New(kind + " templating driver name is required")
+	}
+	if version := c.getServerAPIVersion(); version != nil && version.LessThan(apiVersion137) {
+		return errors.New(kind + " Templating is only supported in API#1.37 and above")
+	}
+	return nil
+}
+
 // CreateSecretOptions specify parameters to the CreateSecret function.
 //
 // See https://goo.gl/KrVjHz for more details.
@@ -39,8 +74,16 @@ type CreateSecretOptions struct {
 // CreateSecret creates a new secret, returning the secret instance
 // or an error in case of failure.
 //
+// Secrets may be fetched from an external store by setting the Driver of
+// the spec (API 1.31 and above), in which case Data is usually empty, and
+// their payload may be evaluated as a template by setting its Templating
+// (API 1.37 and above).
+//
 // See https://goo.gl/KrVjHz for more details.
 func (c *Client) CreateSecret(opts CreateSecretOptions) (*swarm.Secret, error) {
+	if err := c.validateSecretSpec(opts.SecretSpec); err != nil {
+		return nil, err
+	}
 	headers, err := headersWithAuth(opts.Auth)
 	if err != nil {
 		return nil, err
@@ -79,7 +122,7 @@ func (c *Client) RemoveSecret(opts RemoveSecretOptions) error {
 	resp, err := c.do("DELETE", path, doOptions{context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchSecret{ID: opts.ID}
+			return &NoSuchSecret{ID: opts.ID, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -103,6 +146,9 @@ type UpdateSecretOptions struct {
 //
 // See https://goo.gl/wu3MmS for more details.
 func (c *Client) UpdateSecret(id string, opts UpdateSecretOptions) error {
+	if err := c.validateSecretSpec(opts.SecretSpec); err != nil {
+		return err
+	}
 	headers, err := headersWithAuth(opts.Auth)
 	if err != nil {
 		return err
@@ -117,7 +163,7 @@ func (c *Client) UpdateSecret(id string, opts UpdateSecretOptions) error {
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchSecret{ID: id}
+			return &NoSuchSecret{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -133,7 +179,7 @@ func (c *Client) InspectSecret(id string) (*swarm.Secret, error) {
 	resp, err := c.do("GET", path, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchSecret{ID: id}
+			return nil, &NoSuchSecret{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -164,7 +210,7 @@ func (c *Client) ListSecrets(opts ListSecretsOptions) ([]swarm.Secret, error) {
 	}
 	defer resp.Body.Close()
 	var secrets []swarm.Secret
-	if err := json.NewDecoder(resp.Body).Decode(&secrets); err != nil {
+	if err := decodeJSONList(resp.Body, &secrets); err != nil {
 		return nil, err
 	}
 	return secrets, nil
@@ -51,6 +51,72 @@ func TestCreateSecret(t *testing.T) {
 	}
 }
 
+func TestCreateSecretExternalDriver(t *testing.T) {
+	t.Parallel()
+	fakeRT := &FakeRoundTripper{message: `{"Id": "abc"}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	client.serverAPIVersion = apiVersion139
+	spec := swarm.SecretSpec{
+		Annotations: swarm.Annotations{Name: "db-password"},
+		Driver:      &swarm.Driver{Name: "vault", Options: map[string]string{"path": "secret/db"}},
+		Templating:  &swarm.Driver{Name: TemplatingDriverGolang},
+	}
+	if _, err := client.CreateSecret(CreateSecretOptions{SecretSpec: spec}); err != nil {
+		t.Fatal(err)
+	}
+	var gotSpec swarm.SecretSpec
+	if err := json.NewDecoder(fakeRT.requests[0].Body).Decode(&gotSpec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotSpec, spec) {
+		t.Errorf("CreateSecret: wrong spec sent.\nWant %#v\nGot  %#v", spec, gotSpec)
+	}
+}
+
+func TestCreateSecretDriverValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		spec       swarm.SecretSpec
+		apiVersion APIVersion
+		err        string
+	}{
+		{
+			spec: swarm.SecretSpec{Driver: &swarm.Driver{}},
+			err:  "secret driver name is required",
+		},
+		{
+			spec:       swarm.SecretSpec{Driver: &swarm.Driver{Name: "vault"}},
+			apiVersion: apiVersion125,
+			err:        "secret Driver is only supported in API#1.31 and above",
+		},
+		{
+			spec: swarm.SecretSpec{Templating: &swarm.Driver{}},
+			err:  "secret templating driver name is required",
+		},
+		{
+			spec:       swarm.SecretSpec{Data: []byte("x"), Templating: &swarm.Driver{Name: TemplatingDriverGolang}},
+			apiVersion: apiVersion135,
+			err:        "secret Templating is only supported in API#1.37 and above",
+		},
+	}
+	for _, tt := range tests {
+		fakeRT := &FakeRoundTripper{message: `{"Id": "abc"}`, status: http.StatusOK}
+		client := newTestClient(fakeRT)
+		client.serverAPIVersion = tt.apiVersion
+		_, err := client.CreateSecret(CreateSecretOptions{SecretSpec: tt.spec})
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("CreateSecret(%#v): wrong error. Want %q. Got %v.", tt.spec, tt.err, err)
+		}
+		err = client.UpdateSecret("abc", UpdateSecretOptions{SecretSpec: tt.spec})
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("UpdateSecret(%#v): wrong error. Want %q. Got %v.", tt.spec, tt.err, err)
+		}
+		if len(fakeRT.requests) != 0 {
+			t.Errorf("CreateSecret(%#v): want no requests, got %d", tt.spec, len(fakeRT.requests))
+		}
+	}
+}
+
 func TestRemoveSecret(t *testing.T) {
 	t.Parallel()
 	fakeRT := &FakeRoundTripper{message: "", status: http.StatusOK}
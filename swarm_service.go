@@ -18,6 +18,8 @@ import (
 type NoSuchService struct {
 	ID  string
 	Err error
+
+	errorMetadata
 }
 
 func (err *NoSuchService) Error() string {
@@ -39,8 +41,17 @@ type CreateServiceOptions struct {
 // CreateService creates a new service, returning the service instance
 // or an error in case of failure.
 //
+// The generic resources reserved by the tasks of the service, e.g. GPUs,
+// are checked with ValidateGenericResources before the service is created.
+//
 // See https://goo.gl/KrVjHz for more details.
 func (c *Client) CreateService(opts CreateServiceOptions) (*swarm.Service, error) {
+	if err := validateServiceResources(opts.ServiceSpec); err != nil {
+		return nil, err
+	}
+	if err := validateServiceHealthcheck(opts.ServiceSpec); err != nil {
+		return nil, err
+	}
 	headers, err := headersWithAuth(opts.Auth)
 	if err != nil {
 		return nil, err
@@ -79,7 +90,7 @@ func (c *Client) RemoveService(opts RemoveServiceOptions) error {
 	resp, err := c.do("DELETE", path, doOptions{context: opts.Context})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchService{ID: opts.ID}
+			return &NoSuchService{ID: opts.ID, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -102,6 +113,9 @@ type UpdateServiceOptions struct {
 //
 // See https://goo.gl/wu3MmS for more details.
 func (c *Client) UpdateService(id string, opts UpdateServiceOptions) error {
+	if err := validateServiceResources(opts.ServiceSpec); err != nil {
+		return err
+	}
 	headers, err := headersWithAuth(opts.Auth)
 	if err != nil {
 		return err
@@ -114,7 +128,7 @@ func (c *Client) UpdateService(id string, opts UpdateServiceOptions) error {
 	})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return &NoSuchService{ID: id}
+			return &NoSuchService{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return err
 	}
@@ -130,7 +144,7 @@ func (c *Client) InspectService(id string) (*swarm.Service, error) {
 	resp, err := c.do("GET", path, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchService{ID: id}
+			return nil, &NoSuchService{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -161,7 +175,7 @@ func (c *Client) ListServices(opts ListServicesOptions) ([]swarm.Service, error)
 	}
 	defer resp.Body.Close()
 	var services []swarm.Service
-	if err := json.NewDecoder(resp.Body).Decode(&services); err != nil {
+	if err := decodeJSONList(resp.Body, &services); err != nil {
 		return nil, err
 	}
 	return services, nil
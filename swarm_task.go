@@ -8,6 +8,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/docker/docker/api/types/swarm"
 )
@@ -16,6 +18,8 @@ import (
 type NoSuchTask struct {
 	ID  string
 	Err error
+
+	errorMetadata
 }
 
 func (err *NoSuchTask) Error() string {
@@ -44,7 +48,7 @@ func (c *Client) ListTasks(opts ListTasksOptions) ([]swarm.Task, error) {
 	}
 	defer resp.Body.Close()
 	var tasks []swarm.Task
-	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
+	if err := decodeJSONList(resp.Body, &tasks); err != nil {
 		return nil, err
 	}
 	return tasks, nil
@@ -57,7 +61,7 @@ func (c *Client) InspectTask(id string) (*swarm.Task, error) {
 	resp, err := c.do("GET", "/tasks/"+id, doOptions{})
 	if err != nil {
 		if e, ok := err.(*Error); ok && e.Status == http.StatusNotFound {
-			return nil, &NoSuchTask{ID: id}
+			return nil, &NoSuchTask{ID: id, errorMetadata: metadataOf(e)}
 		}
 		return nil, err
 	}
@@ -68,3 +72,112 @@ func (c *Client) InspectTask(id string) (*swarm.Task, error) {
 	}
 	return &task, nil
 }
+
+// TaskSlotHistory is the history of the tasks that ran in a slot of a
+// service, as returned by ServiceTaskHistory.
+//
+// Tasks of replicated services are grouped by Slot, while tasks of global
+// services, that don't have slots, are grouped by NodeID.
+type TaskSlotHistory struct {
+	Slot   int
+	NodeID string
+
+	// Current is the task that the orchestrator wants running in the slot,
+	// if any.
+	Current *swarm.Task
+
+	// Tasks lists all the tasks of the slot, the most recent first.
+	Tasks []swarm.Task
+
+	// Failures lists the tasks of the slot that failed, the most recent
+	// first.
+	Failures []TaskFailure
+}
+
+// TaskFailure is the reason why a task failed.
+type TaskFailure struct {
+	TaskID      string
+	Timestamp   time.Time
+	State       swarm.TaskState
+	Err         string
+	ContainerID string
+	ExitCode    int
+}
+
+// ServiceTaskHistory returns the history of the tasks of the given service,
+// grouped by slot and sorted by slot number, along with the reasons of their
+// failures. It helps telling why a service keeps restarting its tasks.
+func (c *Client) ServiceTaskHistory(serviceID string) ([]TaskSlotHistory, error) {
+	tasks, err := c.ListTasks(ListTasksOptions{Filters: map[string][]string{"service": {serviceID}}})
+	if err != nil {
+		return nil, err
+	}
+	return taskSlotHistory(tasks), nil
+}
+
+func taskSlotHistory(tasks []swarm.Task) []TaskSlotHistory {
+	type slotKey struct {
+		slot   int
+		nodeID string
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Status.Timestamp.After(tasks[j].Status.Timestamp)
+	})
+	var history []TaskSlotHistory
+	index := make(map[slotKey]int)
+	for _, task := range tasks {
+		key := slotKey{slot: task.Slot}
+		if task.Slot == 0 {
+			key.nodeID = task.NodeID
+		}
+		pos, ok := index[key]
+		if !ok {
+			pos = len(history)
+			index[key] = pos
+			history = append(history, TaskSlotHistory{Slot: key.slot, NodeID: key.nodeID})
+		}
+		slot := &history[pos]
+		slot.Tasks = append(slot.Tasks, task)
+		if failure, ok := taskFailure(task); ok {
+			slot.Failures = append(slot.Failures, failure)
+		}
+	}
+	for i := range history {
+		for j, task := range history[i].Tasks {
+			if task.DesiredState == swarm.TaskStateRunning {
+				history[i].Current = &history[i].Tasks[j]
+				break
+			}
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		if history[i].Slot != history[j].Slot {
+			return history[i].Slot < history[j].Slot
+		}
+		return history[i].NodeID < history[j].NodeID
+	})
+	return history
+}
+
+// taskFailure returns the failure of the task, if it failed, was rejected by
+// the node or its container exited with a non-zero code.
+func taskFailure(task swarm.Task) (TaskFailure, bool) {
+	failure := TaskFailure{
+		TaskID:    task.ID,
+		Timestamp: task.Status.Timestamp,
+		State:     task.Status.State,
+		Err:       task.Status.Err,
+	}
+	if failure.Err == "" {
+		failure.Err = task.Status.Message
+	}
+	if status := task.Status.ContainerStatus; status != nil {
+		failure.ContainerID = status.ContainerID
+		failure.ExitCode = status.ExitCode
+	}
+	switch task.Status.State {
+	case swarm.TaskStateFailed, swarm.TaskStateRejected:
+		return failure, true
+	}
+	return failure, failure.ExitCode != 0
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types/swarm"
 )
@@ -372,3 +373,130 @@ func TestInspectTaskNotFound(t *testing.T) {
 		t.Errorf("InspectTask: Wrong error returned. Want %#v. Got %#v.", expected, err)
 	}
 }
+
+func TestServiceTaskHistory(t *testing.T) {
+	t.Parallel()
+	jsonTasks := `[
+  {
+    "ID": "task1-old",
+    "ServiceID": "svc1",
+    "Slot": 1,
+    "NodeID": "node1",
+    "Status": {
+      "Timestamp": "2019-06-07T21:00:00Z",
+      "State": "failed",
+      "Message": "started",
+      "Err": "task: non-zero exit (137)",
+      "ContainerStatus": {"ContainerID": "c1", "PID": 0, "ExitCode": 137}
+    },
+    "DesiredState": "shutdown"
+  },
+  {
+    "ID": "task2",
+    "ServiceID": "svc1",
+    "Slot": 2,
+    "NodeID": "node2",
+    "Status": {
+      "Timestamp": "2019-06-07T21:01:00Z",
+      "State": "running",
+      "Message": "started",
+      "ContainerStatus": {"ContainerID": "c2", "PID": 4242}
+    },
+    "DesiredState": "running"
+  },
+  {
+    "ID": "task1-new",
+    "ServiceID": "svc1",
+    "Slot": 1,
+    "NodeID": "node3",
+    "Status": {
+      "Timestamp": "2019-06-07T21:02:00Z",
+      "State": "running",
+      "Message": "started",
+      "ContainerStatus": {"ContainerID": "c3", "PID": 1234}
+    },
+    "DesiredState": "running"
+  },
+  {
+    "ID": "task1-rejected",
+    "ServiceID": "svc1",
+    "Slot": 1,
+    "Status": {
+      "Timestamp": "2019-06-07T21:01:30Z",
+      "State": "rejected",
+      "Message": "no suitable node"
+    },
+    "DesiredState": "shutdown"
+  }
+]`
+	fakeRT := &FakeRoundTripper{message: jsonTasks, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	history, err := client.ServiceTaskHistory("svc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFilters := `{"service":["svc1"]}`
+	if filters := fakeRT.requests[0].URL.Query().Get("filters"); filters != expectedFilters {
+		t.Errorf("ServiceTaskHistory: wrong filters. Want %s. Got %s.", expectedFilters, filters)
+	}
+	if len(history) != 2 {
+		t.Fatalf("ServiceTaskHistory: want 2 slots, got %d: %#v", len(history), history)
+	}
+	slot1, slot2 := history[0], history[1]
+	if slot1.Slot != 1 || slot2.Slot != 2 {
+		t.Errorf("ServiceTaskHistory: wrong slots order: %d, %d", slot1.Slot, slot2.Slot)
+	}
+	var ids []string
+	for _, task := range slot1.Tasks {
+		ids = append(ids, task.ID)
+	}
+	expectedIDs := []string{"task1-new", "task1-rejected", "task1-old"}
+	if !reflect.DeepEqual(ids, expectedIDs) {
+		t.Errorf("ServiceTaskHistory: wrong tasks of slot 1. Want %v. Got %v.", expectedIDs, ids)
+	}
+	if slot1.Current == nil || slot1.Current.ID != "task1-new" || slot1.Current.Status.ContainerStatus.PID != 1234 {
+		t.Errorf("ServiceTaskHistory: wrong current task of slot 1: %#v", slot1.Current)
+	}
+	expectedFailures := []TaskFailure{
+		{
+			TaskID:    "task1-rejected",
+			Timestamp: time.Date(2019, 6, 7, 21, 1, 30, 0, time.UTC),
+			State:     swarm.TaskStateRejected,
+			Err:       "no suitable node",
+		},
+		{
+			TaskID:      "task1-old",
+			Timestamp:   time.Date(2019, 6, 7, 21, 0, 0, 0, time.UTC),
+			State:       swarm.TaskStateFailed,
+			Err:         "task: non-zero exit (137)",
+			ContainerID: "c1",
+			ExitCode:    137,
+		},
+	}
+	if !reflect.DeepEqual(slot1.Failures, expectedFailures) {
+		t.Errorf("ServiceTaskHistory: wrong failures of slot 1.\nWant %#v\nGot  %#v", expectedFailures, slot1.Failures)
+	}
+	if len(slot2.Failures) != 0 || slot2.Current == nil || slot2.Current.ID != "task2" {
+		t.Errorf("ServiceTaskHistory: wrong history of slot 2: %#v", slot2)
+	}
+}
+
+func TestServiceTaskHistoryGlobalService(t *testing.T) {
+	t.Parallel()
+	jsonTasks := `[
+  {"ID": "t1", "NodeID": "nodeB", "Status": {"Timestamp": "2019-06-07T21:00:00Z", "State": "complete", "ContainerStatus": {"ExitCode": 1}}, "DesiredState": "shutdown"},
+  {"ID": "t2", "NodeID": "nodeA", "Status": {"Timestamp": "2019-06-07T21:00:00Z", "State": "running"}, "DesiredState": "running"},
+  {"ID": "t3", "NodeID": "nodeB", "Status": {"Timestamp": "2019-06-07T21:01:00Z", "State": "running"}, "DesiredState": "running"}
+]`
+	client := newTestClient(&FakeRoundTripper{message: jsonTasks, status: http.StatusOK})
+	history, err := client.ServiceTaskHistory("global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].NodeID != "nodeA" || history[1].NodeID != "nodeB" {
+		t.Fatalf("ServiceTaskHistory: wrong grouping by node: %#v", history)
+	}
+	if len(history[1].Tasks) != 2 || len(history[1].Failures) != 1 || history[1].Failures[0].ExitCode != 1 {
+		t.Errorf("ServiceTaskHistory: wrong history of nodeB: %#v", history[1])
+	}
+}
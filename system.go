@@ -3,6 +3,8 @@ package docker
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"strings"
 )
 
 // VolumeUsageData represents usage data from the docker system api
@@ -70,3 +72,98 @@ func (c *Client) DiskUsage(opts DiskUsageOptions) (*DiskUsage, error) {
 	}
 	return du, nil
 }
+
+// SystemPruneOptions specify parameters to the SystemPrune function.
+type SystemPruneOptions struct {
+	// Volumes also prunes the unused volumes.
+	Volumes bool
+
+	// All prunes all the unused images, instead of only the dangling ones.
+	All bool
+
+	// Filters are passed to each of the prune endpoints.
+	Filters map[string][]string
+	Context context.Context
+}
+
+// SystemPruneResults specify results from the SystemPrune function. The
+// results of a category are nil when it was skipped or failed.
+type SystemPruneResults struct {
+	Containers *PruneContainersResults
+	Networks   *PruneNetworksResults
+	Volumes    *PruneVolumesResults
+	Images     *PruneImagesResults
+
+	// SpaceReclaimed is the sum of the space reclaimed by each category.
+	SpaceReclaimed int64
+}
+
+// SystemPruneError is the error returned by SystemPrune when some of the
+// categories couldn't be pruned. Errors is keyed by category: "containers",
+// "networks", "volumes" or "images".
+type SystemPruneError struct {
+	Errors map[string]error
+}
+
+func (err *SystemPruneError) Error() string {
+	var msgs []string
+	for _, category := range systemPruneCategories {
+		if e, ok := err.Errors[category]; ok {
+			msgs = append(msgs, category+": "+e.Error())
+		}
+	}
+	return "failed to prune " + strings.Join(msgs, "; ")
+}
+
+var systemPruneCategories = []string{"containers", "networks", "volumes", "images"}
+
+// SystemPrune deletes the stopped containers, the unused networks, the
+// dangling images and, optionally, the unused volumes, like `docker system
+// prune`. Containers are pruned first, so the networks, volumes and images
+// they used can be pruned next.
+//
+// A failure to prune a category doesn't stop the others from being pruned:
+// SystemPrune then returns the results of the categories that succeeded along
+// with a *SystemPruneError.
+func (c *Client) SystemPrune(opts SystemPruneOptions) (*SystemPruneResults, error) {
+	var results SystemPruneResults
+	errs := make(map[string]error)
+	containers, err := c.PruneContainers(PruneContainersOptions{Filters: opts.Filters, Context: opts.Context})
+	if err != nil {
+		errs["containers"] = err
+	} else {
+		results.Containers = containers
+		results.SpaceReclaimed += containers.SpaceReclaimed
+	}
+	networks, err := c.PruneNetworks(PruneNetworksOptions{Filters: opts.Filters, Context: opts.Context})
+	if err != nil {
+		errs["networks"] = err
+	} else {
+		results.Networks = networks
+	}
+	if opts.Volumes {
+		volumes, err := c.PruneVolumes(PruneVolumesOptions{Filters: opts.Filters, Context: opts.Context})
+		if err != nil {
+			errs["volumes"] = err
+		} else {
+			results.Volumes = volumes
+			results.SpaceReclaimed += volumes.SpaceReclaimed
+		}
+	}
+	imageFilters := make(map[string][]string, len(opts.Filters)+1)
+	for name, values := range opts.Filters {
+		imageFilters[name] = values
+	}
+	imageFilters["dangling"] = []string{strconv.FormatBool(!opts.All)}
+	images, err := c.PruneImages(PruneImagesOptions{Filters: imageFilters, Context: opts.Context})
+	if err != nil {
+		errs["images"] = err
+	} else {
+		results.Images = images
+		results.SpaceReclaimed += images.SpaceReclaimed
+	}
+	if len(errs) > 0 {
+		return &results, &SystemPruneError{Errors: errs}
+	}
+	return &results, nil
+}
@@ -7,7 +7,9 @@ package docker
 import (
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -106,3 +108,92 @@ func TestDiskUsage(t *testing.T) {
 		t.Errorf("DiskUsage: Wrong return value. Want %#v. Got %#v.", expected, du)
 	}
 }
+
+func TestSystemPrune(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	var imageFilters string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/containers/prune":
+			w.Write([]byte(`{"ContainersDeleted": ["c1"], "SpaceReclaimed": 1}`))
+		case "/networks/prune":
+			http.Error(w, "a prune operation is already running", http.StatusConflict)
+		case "/volumes/prune":
+			w.Write([]byte(`{"VolumesDeleted": ["v1"], "SpaceReclaimed": 2}`))
+		case "/images/prune":
+			mu.Lock()
+			imageFilters = r.URL.Query().Get("filters")
+			mu.Unlock()
+			w.Write([]byte(`{"ImagesDeleted": [{"Deleted": "sha256:abc"}], "SpaceReclaimed": 4}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := client.SystemPrune(SystemPruneOptions{
+		Volumes: true,
+		All:     true,
+		Filters: map[string][]string{"label": {"env=test"}},
+	})
+	pruneErr, ok := err.(*SystemPruneError)
+	if !ok {
+		t.Fatalf("SystemPrune: wrong error. Want *SystemPruneError. Got %#v.", err)
+	}
+	if len(pruneErr.Errors) != 1 || pruneErr.Errors["networks"] == nil {
+		t.Errorf("SystemPrune: wrong errors: %#v", pruneErr.Errors)
+	}
+	if expected := "failed to prune networks: API error (409): a prune operation is already running\n"; err.Error() != expected {
+		t.Errorf("SystemPrune: wrong error message. Want %q. Got %q.", expected, err.Error())
+	}
+	expectedCalls := []string{"POST /containers/prune", "POST /networks/prune", "POST /volumes/prune", "POST /images/prune"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("SystemPrune: wrong calls. Want %v. Got %v.", expectedCalls, calls)
+	}
+	if expected := `{"dangling":["false"],"label":["env=test"]}`; imageFilters != expected {
+		t.Errorf("SystemPrune: wrong image filters. Want %s. Got %s.", expected, imageFilters)
+	}
+	if results.Networks != nil {
+		t.Errorf("SystemPrune: unexpected networks results: %#v", results.Networks)
+	}
+	if results.Containers == nil || results.Volumes == nil || results.Images == nil {
+		t.Fatalf("SystemPrune: missing results: %#v", results)
+	}
+	if results.SpaceReclaimed != 7 {
+		t.Errorf("SystemPrune: wrong space reclaimed. Want 7. Got %d.", results.SpaceReclaimed)
+	}
+}
+
+func TestSystemPruneSkipsVolumes(t *testing.T) {
+	t.Parallel()
+	var paths []string
+	fakeRT := &FakeRoundTripper{message: `{}`, status: http.StatusOK}
+	client := newTestClient(fakeRT)
+	results, err := client.SystemPrune(SystemPruneOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range fakeRT.requests {
+		paths = append(paths, req.URL.Path)
+		if req.URL.Path == "/images/prune" {
+			if expected := `{"dangling":["true"]}`; req.URL.Query().Get("filters") != expected {
+				t.Errorf("SystemPrune: wrong image filters. Want %s. Got %s.", expected, req.URL.Query().Get("filters"))
+			}
+		}
+	}
+	expected := []string{"/containers/prune", "/networks/prune", "/images/prune"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("SystemPrune: wrong paths. Want %v. Got %v.", expected, paths)
+	}
+	if results.Volumes != nil {
+		t.Errorf("SystemPrune: unexpected volumes results: %#v", results.Volumes)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultHealthcheckInterval is the interval used by the daemon when the
+// healthcheck of the container doesn't define one.
+const defaultHealthcheckInterval = 30 * time.Second
+
+// Clock is the source of time of the fake server. It's used for the creation,
+// start and finish times of containers, for the timestamps of events and for
+// the healthcheck transitions.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock is a Clock that only moves when told to, so tests can control
+// the times reported by the fake server deterministically:
+//
+//	clock := testing.NewFakeClock(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+//	server.SetClock(clock)
+//	// start a container with a healthcheck
+//	clock.Advance(30 * time.Second)
+//	// the container is now healthy
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by the given duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// Set changes the current time of the clock.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.mu.Unlock()
+}
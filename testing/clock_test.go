@@ -0,0 +1,148 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	if now := clock.Now(); !now.Equal(start) {
+		t.Errorf("FakeClock.Now: wrong time. Want %s. Got %s.", start, now)
+	}
+	clock.Advance(time.Minute)
+	if expected, now := start.Add(time.Minute), clock.Now(); !now.Equal(expected) {
+		t.Errorf("FakeClock.Advance: wrong time. Want %s. Got %s.", expected, now)
+	}
+	clock.Set(start)
+	if now := clock.Now(); !now.Equal(start) {
+		t.Errorf("FakeClock.Set: wrong time. Want %s. Got %s.", start, now)
+	}
+}
+
+func inspectWithClock(t *testing.T, server *DockerServer, id string) docker.Container {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/containers/"+id+"/json", nil)
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("InspectContainer: wrong status. Want %d. Got %d.", http.StatusOK, recorder.Code)
+	}
+	var container docker.Container
+	if err := json.NewDecoder(recorder.Body).Decode(&container); err != nil {
+		t.Fatal(err)
+	}
+	return container
+}
+
+func TestServerClockContainerTimes(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	server := baseDockerServer()
+	server.imgIDs = map[string]string{"base": "a1234"}
+	server.SetClock(clock)
+	server.buildMuxer()
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/containers/create", strings.NewReader(`{"Image":"base","Cmd":["date"]}`))
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("CreateContainer: wrong status. Want %d. Got %d.", http.StatusCreated, recorder.Code)
+	}
+	id := getContainer(&server).ID
+	clock.Advance(time.Minute)
+	for _, action := range []string{"start", "stop"} {
+		recorder = httptest.NewRecorder()
+		request, _ = http.NewRequest("POST", fmt.Sprintf("/containers/%s/%s", id, action), strings.NewReader(""))
+		server.ServeHTTP(recorder, request)
+		if recorder.Code >= 300 {
+			t.Fatalf("%s: wrong status. Got %d.", action, recorder.Code)
+		}
+		clock.Advance(time.Minute)
+	}
+	container := inspectWithClock(t, &server, id)
+	if !container.Created.Equal(start) {
+		t.Errorf("Created: wrong time. Want %s. Got %s.", start, container.Created)
+	}
+	if expected := start.Add(time.Minute); !container.State.StartedAt.Equal(expected) {
+		t.Errorf("StartedAt: wrong time. Want %s. Got %s.", expected, container.State.StartedAt)
+	}
+	if expected := start.Add(2 * time.Minute); !container.State.FinishedAt.Equal(expected) {
+		t.Errorf("FinishedAt: wrong time. Want %s. Got %s.", expected, container.State.FinishedAt)
+	}
+}
+
+func TestServerClockHealthcheck(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	server := baseDockerServer()
+	server.imgIDs = map[string]string{"base": "a1234"}
+	server.SetClock(clock)
+	server.buildMuxer()
+	recorder := httptest.NewRecorder()
+	body := `{"Image":"base","Healthcheck":{"Test":["CMD","true"],"Interval":10000000000}}`
+	request, _ := http.NewRequest("POST", "/containers/create", strings.NewReader(body))
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("CreateContainer: wrong status. Want %d. Got %d.", http.StatusCreated, recorder.Code)
+	}
+	id := getContainer(&server).ID
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/containers/"+id+"/start", strings.NewReader(""))
+	server.ServeHTTP(recorder, request)
+	if status := inspectWithClock(t, &server, id).State.Health.Status; status != "starting" {
+		t.Errorf("Health: wrong status. Want %q. Got %q.", "starting", status)
+	}
+	clock.Advance(9 * time.Second)
+	if status := inspectWithClock(t, &server, id).State.Health.Status; status != "starting" {
+		t.Errorf("Health: wrong status before the interval. Want %q. Got %q.", "starting", status)
+	}
+	clock.Advance(time.Second)
+	health := inspectWithClock(t, &server, id).State.Health
+	if health.Status != "healthy" {
+		t.Errorf("Health: wrong status after the interval. Want %q. Got %q.", "healthy", health.Status)
+	}
+	if expected := start.Add(10 * time.Second); len(health.Log) != 1 || !health.Log[0].End.Equal(expected) {
+		t.Errorf("Health: wrong log. Want one check at %s. Got %#v.", expected, health.Log)
+	}
+}
+
+func TestServerClockHealthcheckDisabled(t *testing.T) {
+	t.Parallel()
+	server := baseDockerServer()
+	server.SetClock(NewFakeClock(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)))
+	addContainers(&server, 1)
+	container := getContainer(&server)
+	container.Config.Healthcheck = &docker.HealthConfig{Test: []string{"NONE"}}
+	server.buildMuxer()
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/containers/"+container.ID+"/start", strings.NewReader(""))
+	server.ServeHTTP(recorder, request)
+	if status := inspectWithClock(t, &server, container.ID).State.Health.Status; status != "" {
+		t.Errorf("Health: wrong status. Want %q. Got %q.", "", status)
+	}
+}
+
+func TestServerClockEvents(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := baseDockerServer()
+	server.SetClock(NewFakeClock(now))
+	server.emitEvent("create", &docker.Container{ID: "abc123"})
+	if event := server.events[0]; event.Time != now.Unix() || event.TimeNano != now.UnixNano() {
+		t.Errorf("emitEvent: wrong time. Want %d. Got %d (%d).", now.Unix(), event.Time, event.TimeNano)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrInjectedReset is the error returned by the bodies of the responses cut
+// by a connection reset injected by FaultTransport.
+var ErrInjectedReset = errors.New("read: connection reset by peer (injected)")
+
+// DefaultStreamPaths matches the paths of the streaming endpoints of the
+// API sent through the transport of the client: events, as streamed by a
+// StreamSupervisor, logs, stats, wait, and the progress of pulls, pushes and
+// builds.
+var DefaultStreamPaths = regexp.MustCompile(`/(events|containers/[^/]+/(logs|stats|wait)|images/create|images/.+/push|build)$`)
+
+// defaultCutAfter is the default of FaultTransport.CutAfter.
+const defaultCutAfter = 512
+
+// FaultTransport is an http.RoundTripper injecting faults in the requests
+// sent through another transport, for testing the resilience of clients,
+// e.g. their retries or a StreamSupervisor:
+//
+//	transport := testing.NewFaultTransport(client.HTTPClient.Transport, 42)
+//	transport.ErrorRate = 0.2
+//	transport.ResetRate = 0.5
+//	client.HTTPClient.Transport = transport
+//
+// Faults are drawn from a random source seeded by NewFaultTransport, so runs
+// with the same seed and the same sequence of requests inject the same
+// faults. A FaultTransport not created by NewFaultTransport seeds its source
+// with the current time. The settings must not be changed while requests
+// are in flight.
+//
+// The calls hijacking their connection, like the attach and the start of
+// execs, and the events monitored by AddEventListener, dial the daemon
+// without going through the transport of the client, so no faults are
+// injected in them. The same goes for the streams of clients connected to a
+// unix socket or a named pipe, which dial the daemon directly as well: logs,
+// stats, pulls, pushes and builds.
+type FaultTransport struct {
+	// Transport is the transport the requests are sent through.
+	Transport http.RoundTripper
+
+	// ErrorRate is the probability, between 0 and 1, that a request fails
+	// with a 500 response, without being sent.
+	ErrorRate float64
+
+	// Latency delays every request, by a random extra duration of up to
+	// LatencyJitter.
+	Latency       time.Duration
+	LatencyJitter time.Duration
+
+	// ResetRate is the probability that the body of a response of a
+	// streaming endpoint (see StreamPaths) is cut by a connection reset,
+	// failing with ErrInjectedReset.
+	ResetRate float64
+
+	// TruncateRate is the probability that the body of a response ends
+	// early, failing with io.ErrUnexpectedEOF, like a truncated chunked
+	// body.
+	TruncateRate float64
+
+	// CutAfter is the maximum number of bytes of the bodies read before
+	// they're reset or truncated. Defaults to 512, and to the length of the
+	// body when it's known and shorter.
+	CutAfter int
+
+	// StreamPaths matches the paths of the streaming endpoints. Defaults to
+	// DefaultStreamPaths.
+	StreamPaths *regexp.Regexp
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	counts FaultCounts
+}
+
+// FaultCounts are the numbers of faults injected by a FaultTransport.
+type FaultCounts struct {
+	Errors      int
+	Resets      int
+	Truncations int
+}
+
+// NewFaultTransport returns a FaultTransport wrapping the given transport,
+// http.DefaultTransport when nil, drawing faults from a random source
+// seeded with seed. It injects no faults until they're configured.
+func NewFaultTransport(transport http.RoundTripper, seed int64) *FaultTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &FaultTransport{Transport: transport, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Counts returns the numbers of faults injected so far.
+func (t *FaultTransport) Counts() FaultCounts {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts
+}
+
+// RoundTrip sends the request through the wrapped transport, injecting the
+// faults drawn for it.
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	if t.rand == nil {
+		t.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	delay := t.Latency
+	if t.LatencyJitter > 0 {
+		delay += time.Duration(t.rand.Int63n(int64(t.LatencyJitter)))
+	}
+	fail := t.draw(t.ErrorRate)
+	streamPaths := t.StreamPaths
+	if streamPaths == nil {
+		streamPaths = DefaultStreamPaths
+	}
+	reset := streamPaths.MatchString(req.URL.Path) && t.draw(t.ResetRate)
+	truncate := !reset && t.draw(t.TruncateRate)
+	var cutAt int
+	if reset || truncate {
+		cutAfter := t.CutAfter
+		if cutAfter <= 0 {
+			cutAfter = defaultCutAfter
+		}
+		cutAt = t.rand.Intn(cutAfter)
+	}
+	if fail {
+		t.counts.Errors++
+	}
+	t.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	if fail {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return injectedError(req), nil
+	}
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil || (!reset && !truncate) {
+		return resp, err
+	}
+	if resp.ContentLength >= 0 && int64(cutAt) >= resp.ContentLength {
+		cutAt = int(resp.ContentLength / 2)
+	}
+	cutErr := io.ErrUnexpectedEOF
+	t.mu.Lock()
+	if reset {
+		cutErr = ErrInjectedReset
+		t.counts.Resets++
+	} else {
+		t.counts.Truncations++
+	}
+	t.mu.Unlock()
+	resp.Body = &cutBody{ReadCloser: resp.Body, remaining: cutAt, err: cutErr}
+	return resp, nil
+}
+
+// draw returns true with the given probability. It must be called with
+// t.mu held.
+func (t *FaultTransport) draw(probability float64) bool {
+	return probability > 0 && t.rand.Float64() < probability
+}
+
+func injectedError(req *http.Request) *http.Response {
+	body := []byte(`{"message":"injected fault"}`)
+	return &http.Response{
+		Status:        "500 Internal Server Error",
+		StatusCode:    http.StatusInternalServerError,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// cutBody is a body failing with err once remaining bytes are read.
+type cutBody struct {
+	io.ReadCloser
+	remaining int
+	err       error
+}
+
+func (b *cutBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, b.err
+	}
+	if len(p) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= n
+	return n, err
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func newFaultClient(t *testing.T, handler http.Handler, seed int64) (*docker.Client, *FaultTransport, func()) {
+	server := httptest.NewServer(handler)
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SkipServerVersionCheck = true
+	transport := NewFaultTransport(client.HTTPClient.Transport, seed)
+	client.HTTPClient.Transport = transport
+	return client, transport, server.Close
+}
+
+func TestFaultTransportErrors(t *testing.T) {
+	t.Parallel()
+	var requests int
+	client, transport, done := newFaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("OK"))
+	}), 1)
+	defer done()
+	transport.ErrorRate = 0.5
+	var failures int
+	for i := 0; i < 100; i++ {
+		err := client.Ping()
+		if err == nil {
+			continue
+		}
+		failures++
+		if e, ok := err.(*docker.Error); !ok || e.Status != http.StatusInternalServerError || e.Message != "injected fault" {
+			t.Fatalf("Ping: wrong error: %#v", err)
+		}
+	}
+	if failures == 0 || failures == 100 {
+		t.Errorf("FaultTransport: want some requests failed. Got %d failures.", failures)
+	}
+	if counts := transport.Counts(); counts.Errors != failures {
+		t.Errorf("Counts: wrong number of errors. Want %d. Got %d.", failures, counts.Errors)
+	}
+	if requests != 100-failures {
+		t.Errorf("FaultTransport: want the failed requests not sent. Got %d requests for %d failures.", requests, failures)
+	}
+}
+
+func TestFaultTransportDeterministic(t *testing.T) {
+	t.Parallel()
+	run := func() []bool {
+		client, transport, done := newFaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), 42)
+		defer done()
+		transport.ErrorRate = 0.3
+		var results []bool
+		for i := 0; i < 20; i++ {
+			results = append(results, client.Ping() == nil)
+		}
+		return results
+	}
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("FaultTransport: want the same faults with the same seed. Got %v and %v.", first, second)
+		}
+	}
+}
+
+func TestFaultTransportLiteral(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client, err := docker.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient.Transport = &FaultTransport{Transport: client.HTTPClient.Transport, TruncateRate: 0.5}
+	for i := 0; i < 10; i++ {
+		if err := client.Ping(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFaultTransportLatency(t *testing.T) {
+	t.Parallel()
+	client, transport, done := newFaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), 1)
+	defer done()
+	transport.Latency = 50 * time.Millisecond
+	start := time.Now()
+	if err := client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < transport.Latency {
+		t.Errorf("FaultTransport: want the request delayed by %s. Took %s.", transport.Latency, elapsed)
+	}
+}
+
+func TestFaultTransportReset(t *testing.T) {
+	t.Parallel()
+	logs := strings.Repeat("line\n", 1000)
+	client, transport, done := newFaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(logs))
+	}), 1)
+	defer done()
+	transport.ResetRate = 1
+	transport.CutAfter = 100
+	var buf bytes.Buffer
+	err := client.Logs(docker.LogsOptions{Container: "web", OutputStream: &buf, Stdout: true, RawTerminal: true})
+	if err == nil || !strings.Contains(err.Error(), ErrInjectedReset.Error()) {
+		t.Errorf("Logs: wrong error. Want %v. Got %v.", ErrInjectedReset, err)
+	}
+	if buf.Len() >= 100 || !strings.HasPrefix(logs, buf.String()) {
+		t.Errorf("Logs: want the stream cut in the first 100 bytes. Got %d bytes.", buf.Len())
+	}
+	if err := client.Ping(); err != nil {
+		t.Errorf("Ping: want only streaming endpoints reset. Got %v.", err)
+	}
+	if counts := transport.Counts(); counts.Resets != 1 {
+		t.Errorf("Counts: wrong number of resets. Want 1. Got %d.", counts.Resets)
+	}
+}
+
+func TestFaultTransportTruncate(t *testing.T) {
+	t.Parallel()
+	client, transport, done := newFaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id":"a","Name":"bridge"},{"Id":"b","Name":"host"}]`))
+	}), 1)
+	defer done()
+	transport.TruncateRate = 1
+	if _, err := client.ListNetworks(); err != io.ErrUnexpectedEOF {
+		t.Errorf("ListNetworks: wrong error. Want %v. Got %v.", io.ErrUnexpectedEOF, err)
+	}
+	if counts := transport.Counts(); counts.Truncations != 1 {
+		t.Errorf("Counts: wrong number of truncations. Want 1. Got %d.", counts.Truncations)
+	}
+}
+
+func TestDefaultStreamPaths(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		path   string
+		stream bool
+	}{
+		{"/events", true},
+		{"/v1.39/events", true},
+		{"/containers/c1/logs", true},
+		{"/images/create", true},
+		{"/images/registry:5000/app/push", true},
+		{"/containers/c1/json", false},
+		{"/_ping", false},
+	}
+	for _, tt := range tests {
+		if got := DefaultStreamPaths.MatchString(tt.path); got != tt.stream {
+			t.Errorf("DefaultStreamPaths(%q): want %v. Got %v.", tt.path, tt.stream, got)
+		}
+	}
+}
+
+func TestCutBody(t *testing.T) {
+	t.Parallel()
+	body := &cutBody{ReadCloser: ioutil.NopCloser(strings.NewReader("abcdef")), remaining: 4, err: ErrInjectedReset}
+	data, err := ioutil.ReadAll(body)
+	if err != ErrInjectedReset || string(data) != "abcd" {
+		t.Errorf("cutBody: want %q and %v. Got %q and %v.", "abcd", ErrInjectedReset, data, err)
+	}
+}
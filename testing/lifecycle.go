@@ -0,0 +1,294 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Actions on containers scripted by lifecycle hooks.
+const (
+	LifecycleCreate = "create"
+	LifecycleStart  = "start"
+	LifecycleStop   = "stop"
+	LifecycleKill   = "kill"
+	LifecycleRemove = "destroy"
+)
+
+// oomExitCode is the exit code of the containers killed by the OOM killer.
+const oomExitCode = 137
+
+// LifecycleHook scripts the behavior of the server for an action on
+// containers, so clients can test their retry and error handling
+// deterministically. For example, to fail the third start of any container:
+//
+//	server.PrepareLifecycle(testing.LifecycleHook{
+//	    Action:      testing.LifecycleStart,
+//	    Nth:         3,
+//	    FailMessage: "cannot start container",
+//	})
+type LifecycleHook struct {
+	// Action is the action hooked, e.g. LifecycleStart.
+	Action string
+
+	// Container restricts the hook to the container with the given ID or
+	// name. The hook applies to every container when it's empty.
+	Container string
+
+	// Nth restricts the hook to the Nth occurrence of the action on the
+	// containers it applies to, counting from 1. The hook applies to every
+	// occurrence when it's 0.
+	Nth int
+
+	// Delay delays the action, e.g. to emulate containers slow to stop.
+	Delay time.Duration
+
+	// FailStatus and FailMessage make the action fail with the given
+	// status, 500 by default, and message.
+	FailStatus  int
+	FailMessage string
+
+	// OOM, for LifecycleStart, makes the container killed by the OOM killer
+	// right after it starts: it exits with code 137 and OOMKilled set, and
+	// the oom and die events are emitted.
+	OOM bool
+
+	count int
+}
+
+func (h *LifecycleHook) fails() bool {
+	return h.FailStatus != 0 || h.FailMessage != ""
+}
+
+func (h *LifecycleHook) matches(action string, names []string) bool {
+	if h.Action != action {
+		return false
+	}
+	if h.Container == "" {
+		return true
+	}
+	for _, name := range names {
+		if name != "" && name == h.Container {
+			return true
+		}
+	}
+	return false
+}
+
+// PrepareLifecycle adds a hook scripting an action on containers. Hooks
+// apply in the order they're added: their delays add up, and the first
+// failing one fails the action.
+func (s *DockerServer) PrepareLifecycle(hook LifecycleHook) {
+	s.lifecycleMut.Lock()
+	s.lifecycleHooks = append(s.lifecycleHooks, &hook)
+	s.lifecycleMut.Unlock()
+}
+
+// ResetLifecycle removes all the lifecycle hooks.
+func (s *DockerServer) ResetLifecycle() {
+	s.lifecycleMut.Lock()
+	s.lifecycleHooks = nil
+	s.lifecycleMut.Unlock()
+}
+
+// SetEventHook changes the function called with the events of the
+// lifecycle of containers (create, start, kill, oom, die, stop and
+// destroy), as they happen. The same events are streamed by the /events
+// endpoint.
+func (s *DockerServer) SetEventHook(hook func(*docker.APIEvents)) {
+	s.lifecycleMut.Lock()
+	s.eventHook = hook
+	s.lifecycleMut.Unlock()
+}
+
+// lifecycle runs the hooks of the action on the container identified by
+// names, sleeping for their delays. It writes the failure of the action to
+// w and returns false when a hook fails it, and returns whether the
+// container must be killed by the OOM killer otherwise.
+func (s *DockerServer) lifecycle(w http.ResponseWriter, action string, names ...string) (oom, ok bool) {
+	var delay time.Duration
+	var failure *LifecycleHook
+	s.lifecycleMut.Lock()
+	for _, hook := range s.lifecycleHooks {
+		if !hook.matches(action, names) {
+			continue
+		}
+		hook.count++
+		if hook.Nth != 0 && hook.Nth != hook.count {
+			continue
+		}
+		delay += hook.Delay
+		oom = oom || hook.OOM
+		if failure == nil && hook.fails() {
+			failure = hook
+		}
+	}
+	s.lifecycleMut.Unlock()
+	time.Sleep(delay)
+	if failure != nil {
+		status := failure.FailStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		http.Error(w, failure.FailMessage, status)
+		return false, false
+	}
+	return oom, true
+}
+
+// eventListenerBuffer is the number of events buffered for each client of
+// the /events endpoint. Events are dropped for the clients that don't keep
+// up.
+const eventListenerBuffer = 100
+
+// emitEvent records an event of the lifecycle of the container, sending it
+// to the clients of the /events endpoint and handing it to the event hook.
+func (s *DockerServer) emitEvent(action string, container *docker.Container) {
+	now := s.clock.Now()
+	event := &docker.APIEvents{
+		Action: action,
+		Type:   "container",
+		Actor: docker.APIActor{
+			ID:         container.ID,
+			Attributes: map[string]string{"image": container.Image, "name": container.Name},
+		},
+		Status:   action,
+		ID:       container.ID,
+		From:     container.Image,
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	}
+	s.lifecycleMut.Lock()
+	s.events = append(s.events, event)
+	for listener := range s.eventListeners {
+		select {
+		case listener <- event:
+		default:
+		}
+	}
+	hook := s.eventHook
+	s.lifecycleMut.Unlock()
+	if hook != nil {
+		hook(event)
+	}
+}
+
+// listEvents streams the events of the lifecycle of containers, like the
+// daemon: the past events are only sent when the since parameter is set, and
+// the stream lasts until the client goes away, or until the time of the
+// until parameter. The type, event and container filters are supported.
+func (s *DockerServer) listEvents(w http.ResponseWriter, r *http.Request) {
+	var since, until int64
+	var err error
+	if value := r.URL.Query().Get("since"); value != "" {
+		if since, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("until"); value != "" {
+		if until, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var filters map[string][]string
+	if value := r.URL.Query().Get("filters"); value != "" {
+		if err := json.Unmarshal([]byte(value), &filters); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	listener := make(chan *docker.APIEvents, eventListenerBuffer)
+	s.lifecycleMut.Lock()
+	var past []*docker.APIEvents
+	if since != 0 {
+		for _, event := range s.events {
+			if event.Time >= since {
+				past = append(past, event)
+			}
+		}
+	}
+	if s.eventListeners == nil {
+		s.eventListeners = make(map[chan *docker.APIEvents]struct{})
+	}
+	s.eventListeners[listener] = struct{}{}
+	s.lifecycleMut.Unlock()
+	defer func() {
+		s.lifecycleMut.Lock()
+		delete(s.eventListeners, listener)
+		s.lifecycleMut.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	send := func(event *docker.APIEvents) bool {
+		if until != 0 && event.Time > until {
+			return false
+		}
+		if matchEvent(event, filters) {
+			encoder.Encode(event)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return true
+	}
+	for _, event := range past {
+		if !send(event) {
+			return
+		}
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	var deadline <-chan time.Time
+	if until != 0 {
+		timer := time.NewTimer(time.Until(time.Unix(until+1, 0)))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		select {
+		case event := <-listener:
+			if !send(event) {
+				return
+			}
+		case <-deadline:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// matchEvent tells whether the event matches the type, event and container
+// filters of the /events endpoint.
+func matchEvent(event *docker.APIEvents, filters map[string][]string) bool {
+	match := func(key string, values ...string) bool {
+		accepted, ok := filters[key]
+		if !ok {
+			return true
+		}
+		for _, a := range accepted {
+			for _, value := range values {
+				if value != "" && a == value {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	name := strings.TrimPrefix(event.Actor.Attributes["name"], "/")
+	return match("type", event.Type) && match("event", event.Action) && match("container", event.Actor.ID, name)
+}
@@ -0,0 +1,205 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func newLifecycleServer(t *testing.T) (*DockerServer, *docker.Client) {
+	server, err := NewServer("127.0.0.1:0", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.imgIDs = map[string]string{"base": "a1234"}
+	client, err := docker.NewClient(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, client
+}
+
+func createLifecycleContainer(t *testing.T, client *docker.Client, name string) *docker.Container {
+	container, err := client.CreateContainer(docker.CreateContainerOptions{
+		Name:   name,
+		Config: &docker.Config{Image: "base", Cmd: []string{"date"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return container
+}
+
+func TestLifecycleFailNthStart(t *testing.T) {
+	t.Parallel()
+	server, client := newLifecycleServer(t)
+	defer server.Stop()
+	server.PrepareLifecycle(LifecycleHook{Action: LifecycleStart, Nth: 2, FailMessage: "cannot start container"})
+	container := createLifecycleContainer(t, client, "web")
+	if err := client.StartContainer(container.ID, nil); err != nil {
+		t.Fatalf("StartContainer: unexpected error on the first start: %v", err)
+	}
+	if err := client.StopContainer(container.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	err := client.StartContainer(container.ID, nil)
+	if e, ok := err.(*docker.Error); !ok || e.Status != 500 || e.Message != "cannot start container\n" {
+		t.Fatalf("StartContainer: wrong error on the second start: %#v", err)
+	}
+	if err := client.StartContainer(container.ID, nil); err != nil {
+		t.Errorf("StartContainer: unexpected error on the third start: %v", err)
+	}
+}
+
+func TestLifecycleContainerHook(t *testing.T) {
+	t.Parallel()
+	server, client := newLifecycleServer(t)
+	defer server.Stop()
+	server.PrepareLifecycle(LifecycleHook{Action: LifecycleRemove, Container: "db", FailStatus: 409, FailMessage: "in use"})
+	web := createLifecycleContainer(t, client, "web")
+	db := createLifecycleContainer(t, client, "db")
+	if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: web.ID}); err != nil {
+		t.Errorf("RemoveContainer: unexpected error for a container without hooks: %v", err)
+	}
+	err := client.RemoveContainer(docker.RemoveContainerOptions{ID: db.ID})
+	if e, ok := err.(*docker.Error); !ok || e.Status != 409 {
+		t.Errorf("RemoveContainer: wrong error: %#v", err)
+	}
+	server.ResetLifecycle()
+	if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: db.ID}); err != nil {
+		t.Errorf("RemoveContainer: unexpected error after the reset: %v", err)
+	}
+}
+
+func TestLifecycleDelayStop(t *testing.T) {
+	t.Parallel()
+	server, client := newLifecycleServer(t)
+	defer server.Stop()
+	delay := 100 * time.Millisecond
+	server.PrepareLifecycle(LifecycleHook{Action: LifecycleStop, Delay: delay})
+	container := createLifecycleContainer(t, client, "")
+	if err := client.StartContainer(container.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := client.StopContainer(container.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("StopContainer: want the stop delayed by %s. Took %s.", delay, elapsed)
+	}
+}
+
+func TestLifecycleOOM(t *testing.T) {
+	t.Parallel()
+	server, client := newLifecycleServer(t)
+	defer server.Stop()
+	var mu sync.Mutex
+	var actions []string
+	server.SetEventHook(func(event *docker.APIEvents) {
+		mu.Lock()
+		actions = append(actions, event.Action)
+		mu.Unlock()
+	})
+	server.PrepareLifecycle(LifecycleHook{Action: LifecycleStart, OOM: true})
+	container := createLifecycleContainer(t, client, "web")
+	if err := client.StartContainer(container.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	container, err := client.InspectContainer(container.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.State.Running || !container.State.OOMKilled || container.State.ExitCode != 137 {
+		t.Errorf("StartContainer: want the container killed by the OOM killer. Got %#v.", container.State)
+	}
+	if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: container.ID}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"create", "start", "oom", "die", "destroy"}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("SetEventHook: wrong events. Want %v. Got %v.", expected, actions)
+	}
+}
+
+func TestLifecycleKillEvents(t *testing.T) {
+	t.Parallel()
+	server, client := newLifecycleServer(t)
+	defer server.Stop()
+	var mu sync.Mutex
+	var events []*docker.APIEvents
+	server.SetEventHook(func(event *docker.APIEvents) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	})
+	container := createLifecycleContainer(t, client, "web")
+	if err := client.StartContainer(container.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.KillContainer(docker.KillContainerOptions{ID: container.ID}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var actions []string
+	for _, event := range events {
+		actions = append(actions, event.Action)
+		if event.Actor.ID != container.ID || event.Actor.Attributes["name"] != "web" || event.Type != "container" {
+			t.Errorf("SetEventHook: wrong event: %#v", event)
+		}
+	}
+	expected := []string{"create", "start", "kill", "die"}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("SetEventHook: wrong events. Want %v. Got %v.", expected, actions)
+	}
+}
+
+func TestLifecycleEventsEndpoint(t *testing.T) {
+	t.Parallel()
+	server, client := newLifecycleServer(t)
+	defer server.Stop()
+	server.PrepareLifecycle(LifecycleHook{Action: LifecycleStart, OOM: true})
+	web := createLifecycleContainer(t, client, "web")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	filters := url.QueryEscape(`{"event":["create","oom","die"],"container":["web"]}`)
+	req, _ := http.NewRequest("GET", server.URL()+"events?since=1&filters="+filters, nil)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	createLifecycleContainer(t, client, "db")
+	if err := client.StartContainer(web.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	decoder := json.NewDecoder(resp.Body)
+	var actions []string
+	for len(actions) < 3 {
+		var event docker.APIEvents
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Actor.ID != web.ID {
+			t.Errorf("/events: unexpected event: %#v", event)
+		}
+		actions = append(actions, event.Action)
+	}
+	if expected := []string{"create", "oom", "die"}; !reflect.DeepEqual(actions, expected) {
+		t.Errorf("/events: wrong events. Want %v. Got %v.", expected, actions)
+	}
+}
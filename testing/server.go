@@ -74,6 +74,12 @@ type DockerServer struct {
 	services       []*swarm.Service
 	nodeRR         int
 	servicePorts   int
+	clock          Clock
+	lifecycleMut   sync.Mutex
+	lifecycleHooks []*LifecycleHook
+	eventHook      func(*docker.APIEvents)
+	events         []*docker.APIEvents
+	eventListeners map[chan *docker.APIEvents]struct{}
 }
 
 type volumeCounter struct {
@@ -92,6 +98,7 @@ func baseDockerServer() DockerServer {
 		statsCallbacks: make(map[string]func(string) docker.Stats),
 		customHandlers: make(map[string]http.Handler),
 		uploadedFiles:  make(map[string]string),
+		clock:          realClock{},
 	}
 }
 
@@ -235,6 +242,16 @@ func (s *DockerServer) SetHook(hook func(*http.Request)) {
 	s.hook = hook
 }
 
+// SetClock changes the clock used by the server, which is the real clock by
+// default. It should be called before the server starts receiving requests.
+//
+// With a FakeClock, the times reported for containers and events, and the
+// transitions of the containers' healthchecks only change when the clock is
+// advanced.
+func (s *DockerServer) SetClock(clock Clock) {
+	s.clock = clock
+}
+
 // PrepareExec adds a callback to a container exec in the fake server.
 //
 // This function will be called whenever the given exec id is started, and the
@@ -492,6 +509,9 @@ func (s *DockerServer) createContainer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if _, ok := s.lifecycle(w, LifecycleCreate, name); !ok {
+		return
+	}
 	ports := map[docker.Port][]docker.PortBinding{}
 	for port := range config.ExposedPorts {
 		ports[port] = []docker.PortBinding{{
@@ -515,7 +535,7 @@ func (s *DockerServer) createContainer(w http.ResponseWriter, r *http.Request) {
 	container := docker.Container{
 		Name:       name,
 		ID:         generatedID,
-		Created:    time.Now(),
+		Created:    s.clock.Now(),
 		Path:       path,
 		Args:       args,
 		Config:     config.Config,
@@ -550,6 +570,7 @@ func (s *DockerServer) createContainer(w http.ResponseWriter, r *http.Request) {
 	s.cMut.Unlock()
 	w.WriteHeader(http.StatusCreated)
 	s.notify(&container)
+	s.emitEvent("create", &container)
 
 	json.NewEncoder(w).Encode(container)
 }
@@ -591,11 +612,35 @@ func (s *DockerServer) inspectContainer(w http.ResponseWriter, r *http.Request)
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	s.cMut.RLock()
-	defer s.cMut.RUnlock()
+	s.cMut.Lock()
+	defer s.cMut.Unlock()
+	s.updateHealth(container)
 	json.NewEncoder(w).Encode(container)
 }
 
+func hasHealthcheck(config *docker.Config) bool {
+	return config != nil && config.Healthcheck != nil && len(config.Healthcheck.Test) > 0 && config.Healthcheck.Test[0] != "NONE"
+}
+
+// updateHealth marks running containers as healthy once the first
+// healthcheck has run, one interval after the container was started.
+func (s *DockerServer) updateHealth(container *docker.Container) {
+	if !container.State.Running || container.State.Health.Status != "starting" || !hasHealthcheck(container.Config) {
+		return
+	}
+	interval := container.Config.Healthcheck.Interval
+	if interval == 0 {
+		interval = defaultHealthcheckInterval
+	}
+	check := container.State.StartedAt.Add(interval)
+	if s.clock.Now().Before(check) {
+		return
+	}
+	container.State.Health.Status = "healthy"
+	container.State.Health.FailingStreak = 0
+	container.State.Health.Log = append(container.State.Health.Log, docker.HealthCheck{Start: check, End: check})
+}
+
 func (s *DockerServer) statsContainer(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	_, err := s.findContainer(id)
@@ -692,6 +737,10 @@ func (s *DockerServer) startContainer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	oom, ok := s.lifecycle(w, LifecycleStart, container.ID, container.Name)
+	if !ok {
+		return
+	}
 	s.cMut.Lock()
 	defer s.cMut.Unlock()
 	defer r.Body.Close()
@@ -732,8 +781,21 @@ func (s *DockerServer) startContainer(w http.ResponseWriter, r *http.Request) {
 		container.NetworkSettings.Ports = ports
 	}
 	container.State.Running = true
-	container.State.StartedAt = time.Now()
+	container.State.StartedAt = s.clock.Now()
+	if hasHealthcheck(container.Config) {
+		container.State.Health = docker.Health{Status: "starting"}
+	}
 	s.notify(container)
+	s.emitEvent("start", container)
+	if oom {
+		container.State.Running = false
+		container.State.OOMKilled = true
+		container.State.ExitCode = oomExitCode
+		container.State.FinishedAt = s.clock.Now()
+		s.notify(container)
+		s.emitEvent("oom", container)
+		s.emitEvent("die", container)
+	}
 }
 
 func (s *DockerServer) stopContainer(w http.ResponseWriter, r *http.Request) {
@@ -743,6 +805,13 @@ func (s *DockerServer) stopContainer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	action := LifecycleStop
+	if strings.HasSuffix(r.URL.Path, "/kill") {
+		action = LifecycleKill
+	}
+	if _, ok := s.lifecycle(w, action, container.ID, container.Name); !ok {
+		return
+	}
 	s.cMut.Lock()
 	defer s.cMut.Unlock()
 	if !container.State.Running {
@@ -751,7 +820,15 @@ func (s *DockerServer) stopContainer(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 	container.State.Running = false
+	container.State.FinishedAt = s.clock.Now()
 	s.notify(container)
+	if action == LifecycleKill {
+		s.emitEvent("kill", container)
+		s.emitEvent("die", container)
+	} else {
+		s.emitEvent("die", container)
+		s.emitEvent("stop", container)
+	}
 }
 
 func (s *DockerServer) pauseContainer(w http.ResponseWriter, r *http.Request) {
@@ -865,6 +942,13 @@ func (s *DockerServer) waitContainer(w http.ResponseWriter, r *http.Request) {
 func (s *DockerServer) removeContainer(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	force := r.URL.Query().Get("force")
+	names := []string{id}
+	if container, err := s.findContainer(id); err == nil {
+		names = append(names, container.ID, container.Name)
+	}
+	if _, ok := s.lifecycle(w, LifecycleRemove, names...); !ok {
+		return
+	}
 	s.cMut.Lock()
 	defer s.cMut.Unlock()
 	container, err := s.findContainerWithLock(id, false)
@@ -880,6 +964,7 @@ func (s *DockerServer) removeContainer(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 	delete(s.containers, container.ID)
 	delete(s.contNameToID, container.Name)
+	s.emitEvent("destroy", container)
 }
 
 func (s *DockerServer) commitContainer(w http.ResponseWriter, r *http.Request) {
@@ -997,7 +1082,7 @@ func (s *DockerServer) buildImage(w http.ResponseWriter, r *http.Request) {
 	// we did not use that Dockerfile to build image cause we are a fake Docker daemon
 	image := docker.Image{
 		ID:      s.generateID(),
-		Created: time.Now(),
+		Created: s.clock.Now(),
 	}
 
 	query := r.URL.Query()
@@ -1129,49 +1214,10 @@ func (s *DockerServer) inspectImage(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(img)
 }
 
-func (s *DockerServer) listEvents(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	var events [][]byte
-	count := mathrand.Intn(20)
-	for i := 0; i < count; i++ {
-		data, err := json.Marshal(s.generateEvent())
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		events = append(events, data)
-	}
-	w.WriteHeader(http.StatusOK)
-	for _, d := range events {
-		fmt.Fprintln(w, d)
-		time.Sleep(time.Duration(mathrand.Intn(200)) * time.Millisecond)
-	}
-}
-
 func (s *DockerServer) pingDocker(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (s *DockerServer) generateEvent() *docker.APIEvents {
-	var eventType string
-	switch mathrand.Intn(4) {
-	case 0:
-		eventType = "create"
-	case 1:
-		eventType = "start"
-	case 2:
-		eventType = "stop"
-	case 3:
-		eventType = "destroy"
-	}
-	return &docker.APIEvents{
-		ID:     s.generateID(),
-		Status: eventType,
-		From:   "mybase:latest",
-		Time:   time.Now().Unix(),
-	}
-}
-
 func (s *DockerServer) loadImage(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
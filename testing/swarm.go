@@ -185,12 +185,12 @@ func (s *DockerServer) containerForService(srv *swarm.Service, name string) *doc
 		ID:         s.generateID(),
 		Name:       name,
 		Image:      srv.Spec.TaskTemplate.ContainerSpec.Image,
-		Created:    time.Now(),
+		Created:    s.clock.Now(),
 		Config:     &dockerConfig,
 		HostConfig: &hostConfig,
 		State: docker.State{
 			Running:   true,
-			StartedAt: time.Now(),
+			StartedAt: s.clock.Now(),
 			Pid:       rand.Int() % 50000,
 			ExitCode:  0,
 		},
@@ -21,14 +21,13 @@ type tlsClientCon struct {
 }
 
 func (c *tlsClientCon) CloseWrite() error {
-	// Go standard tls.Conn doesn't provide the CloseWrite() method so we do it
-	// on its underlying connection.
-	if cwc, ok := c.rawConn.(interface {
-		CloseWrite() error
-	}); ok {
-		return cwc.CloseWrite()
+	// tls.Conn.CloseWrite sends a close_notify alert, so the daemon sees a
+	// clean EOF, but it doesn't half-close the underlying connection, which
+	// is done here as well.
+	if err := c.Conn.CloseWrite(); err != nil {
+		return err
 	}
-	return nil
+	return closeWrite(c.rawConn)
 }
 
 func tlsDialWithDialer(dialer *net.Dialer, network, addr string, config *tls.Config) (net.Conn, error) {
@@ -0,0 +1,31 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTLSClientConCloseWrite(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewUnstartedServer(nil)
+	srv.StartTLS()
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+	conn, err := tlsDialWithDialer(&net.Dialer{}, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err = closeWrite(conn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.Write([]byte("data")); err == nil {
+		t.Error("tlsClientCon.CloseWrite: unexpected nil error writing after CloseWrite")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"time"
 )
@@ -30,6 +31,9 @@ type Volume struct {
 	Labels     map[string]string `json:"Labels,omitempty" yaml:"Labels,omitempty" toml:"Labels,omitempty"`
 	Options    map[string]string `json:"Options,omitempty" yaml:"Options,omitempty" toml:"Options,omitempty"`
 	CreatedAt  time.Time         `json:"CreatedAt,omitempty" yaml:"CreatedAt,omitempty" toml:"CreatedAt,omitempty"`
+
+	// UsageData is only set by DiskUsage.
+	UsageData *VolumeUsageData `json:"UsageData,omitempty" yaml:"UsageData,omitempty" toml:"UsageData,omitempty"`
 }
 
 // ListVolumesOptions specify parameters to the ListVolumes function.
@@ -51,23 +55,13 @@ func (c *Client) ListVolumes(opts ListVolumesOptions) ([]Volume, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	m := make(map[string]interface{})
-	if err = json.NewDecoder(resp.Body).Decode(&m); err != nil {
-		return nil, err
-	}
-	var volumes []Volume
-	volumesJSON, ok := m["Volumes"]
-	if !ok {
-		return volumes, nil
-	}
-	data, err := json.Marshal(volumesJSON)
-	if err != nil {
-		return nil, err
+	var list struct {
+		Volumes []Volume
 	}
-	if err := json.Unmarshal(data, &volumes); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
 		return nil, err
 	}
-	return volumes, nil
+	return list.Volumes, nil
 }
 
 // CreateVolumeOptions specify parameters to the CreateVolume function.
@@ -164,6 +158,10 @@ func (c *Client) RemoveVolumeWithOptions(opts RemoveVolumeOptions) error {
 type PruneVolumesOptions struct {
 	Filters map[string][]string
 	Context context.Context
+
+	// DryRun lists the volumes that would be removed without removing them.
+	// See PruneVolumes.
+	DryRun bool `qs:"-"`
 }
 
 // PruneVolumesResults specify results from the PruneVolumes function.
@@ -176,8 +174,15 @@ type PruneVolumesResults struct {
 
 // PruneVolumes deletes volumes which are unused.
 //
+// As the daemon has no dry run, with opts.DryRun the unused volumes matching
+// the filters are listed client-side and returned without being removed, with
+// their size as reported by DiskUsage.
+//
 // See https://goo.gl/f9XDem for more details.
 func (c *Client) PruneVolumes(opts PruneVolumesOptions) (*PruneVolumesResults, error) {
+	if opts.DryRun {
+		return c.pruneVolumesDryRun(opts)
+	}
 	path := "/volumes/prune?" + queryString(opts)
 	resp, err := c.do("POST", path, doOptions{context: opts.Context})
 	if err != nil {
@@ -190,3 +195,71 @@ func (c *Client) PruneVolumes(opts PruneVolumesOptions) (*PruneVolumesResults, e
 	}
 	return &results, nil
 }
+
+// DefaultVolumeHelperImage is the image used by VolumeBackup and
+// VolumeRestore when Client.VolumeHelperImage is not set.
+const DefaultVolumeHelperImage = "busybox:latest"
+
+// volumeHelperPath is the path where VolumeBackup and VolumeRestore mount the
+// volume in the helper container.
+const volumeHelperPath = "/volume"
+
+// VolumeBackup writes a tar archive with the contents of the given volume to
+// w. Entries in the archive are prefixed by "volume/", and the archive can be
+// restored with VolumeRestore.
+//
+// The archive is read from a helper container, created from the image in
+// Client.VolumeHelperImage with the volume mounted. The container is never
+// started, and it's removed before VolumeBackup returns.
+func (c *Client) VolumeBackup(volumeName string, w io.Writer) error {
+	if _, err := c.InspectVolume(volumeName); err != nil {
+		return err
+	}
+	return c.withVolumeHelper(volumeName, func(id string) error {
+		return c.DownloadFromContainer(id, DownloadFromContainerOptions{
+			OutputStream: w,
+			Path:         volumeHelperPath,
+		})
+	})
+}
+
+// VolumeRestore extracts a tar archive generated by VolumeBackup into the
+// given volume, creating the volume if it doesn't exist. Existing files in
+// the volume are overwritten.
+//
+// Like VolumeBackup, it uses a temporary helper container that is removed
+// before VolumeRestore returns.
+func (c *Client) VolumeRestore(volumeName string, r io.Reader) error {
+	return c.withVolumeHelper(volumeName, func(id string) error {
+		return c.UploadToContainer(id, UploadToContainerOptions{
+			InputStream: r,
+			Path:        "/",
+		})
+	})
+}
+
+func (c *Client) withVolumeHelper(volumeName string, fn func(id string) error) error {
+	image := c.VolumeHelperImage
+	if image == "" {
+		image = DefaultVolumeHelperImage
+	}
+	opts := CreateContainerOptions{
+		Config: &Config{Image: image, Cmd: []string{"true"}},
+		HostConfig: &HostConfig{
+			Mounts: []HostMount{{Type: "volume", Source: volumeName, Target: volumeHelperPath}},
+		},
+	}
+	container, err := c.CreateContainer(opts)
+	if err == ErrNoSuchImage {
+		repository, tag := ParseRepositoryTag(image)
+		if err = c.PullImage(PullImageOptions{Repository: repository, Tag: tag}, AuthConfiguration{}); err != nil {
+			return err
+		}
+		container, err = c.CreateContainer(opts)
+	}
+	if err != nil {
+		return err
+	}
+	defer c.RemoveContainer(RemoveContainerOptions{ID: container.ID, Force: true})
+	return fn(container.ID)
+}
@@ -5,8 +5,11 @@
 package docker
 
 import (
+	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
@@ -205,3 +208,109 @@ func TestPruneVolumes(t *testing.T) {
 		t.Errorf("PruneContainers: Expected %#v. Got %#v.", expected, got)
 	}
 }
+
+func newVolumeHelperServer(t *testing.T, archive []byte) (*httptest.Server, *[]string, *[]byte) {
+	var calls []string
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/volumes/missing":
+			http.Error(w, "no such volume", http.StatusNotFound)
+		case r.URL.Path == "/volumes/data":
+			w.Write([]byte(`{"Name":"data"}`))
+		case r.URL.Path == "/containers/create":
+			var opts struct {
+				Image      string
+				HostConfig HostConfig
+			}
+			json.NewDecoder(r.Body).Decode(&opts)
+			mounts := opts.HostConfig.Mounts
+			if opts.Image != "alpine:3.10" || len(mounts) != 1 || mounts[0].Source != "data" || mounts[0].Target != "/volume" {
+				t.Errorf("VolumeHelper: wrong helper container: %#v", opts)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"Id":"helper"}`))
+		case r.URL.Path == "/containers/helper/archive" && r.Method == http.MethodGet:
+			if path := r.URL.Query().Get("path"); path != "/volume" {
+				t.Errorf("VolumeBackup: wrong path. Want %q. Got %q.", "/volume", path)
+			}
+			w.Write(archive)
+		case r.URL.Path == "/containers/helper/archive" && r.Method == http.MethodPut:
+			uploaded, _ = ioutil.ReadAll(r.Body)
+		case r.URL.Path == "/containers/helper":
+			if r.URL.Query().Get("force") != "1" {
+				t.Errorf("VolumeHelper: helper container should be force removed")
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	return server, &calls, &uploaded
+}
+
+func TestVolumeBackup(t *testing.T) {
+	t.Parallel()
+	server, calls, _ := newVolumeHelperServer(t, []byte("tar data"))
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.VolumeHelperImage = "alpine:3.10"
+	var buf bytes.Buffer
+	if err = client.VolumeBackup("data", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "tar data" {
+		t.Errorf("VolumeBackup: wrong archive. Want %q. Got %q.", "tar data", buf.String())
+	}
+	expected := []string{
+		"GET /volumes/data",
+		"POST /containers/create",
+		"GET /containers/helper/archive",
+		"DELETE /containers/helper",
+	}
+	if !reflect.DeepEqual(*calls, expected) {
+		t.Errorf("VolumeBackup: wrong calls.\nWant %#v.\nGot %#v.", expected, *calls)
+	}
+}
+
+func TestVolumeBackupNoSuchVolume(t *testing.T) {
+	t.Parallel()
+	server, _, _ := newVolumeHelperServer(t, nil)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.VolumeBackup("missing", ioutil.Discard); err != ErrNoSuchVolume {
+		t.Errorf("VolumeBackup: wrong error. Want %#v. Got %#v.", ErrNoSuchVolume, err)
+	}
+}
+
+func TestVolumeRestore(t *testing.T) {
+	t.Parallel()
+	server, calls, uploaded := newVolumeHelperServer(t, nil)
+	defer server.Close()
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.VolumeHelperImage = "alpine:3.10"
+	if err = client.VolumeRestore("data", bytes.NewBufferString("tar data")); err != nil {
+		t.Fatal(err)
+	}
+	if string(*uploaded) != "tar data" {
+		t.Errorf("VolumeRestore: wrong archive. Want %q. Got %q.", "tar data", *uploaded)
+	}
+	expected := []string{
+		"POST /containers/create",
+		"PUT /containers/helper/archive",
+		"DELETE /containers/helper",
+	}
+	if !reflect.DeepEqual(*calls, expected) {
+		t.Errorf("VolumeRestore: wrong calls.\nWant %#v.\nGot %#v.", expected, *calls)
+	}
+}
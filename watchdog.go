@@ -0,0 +1,348 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultWatchdogCooldown = time.Minute
+
+// WatchdogAction is the action taken by a Watchdog when a container breaches
+// a threshold.
+type WatchdogAction int
+
+const (
+	// WatchdogAlert only reports the breach to Watchdog.OnBreach.
+	WatchdogAlert WatchdogAction = iota
+
+	// WatchdogRestart restarts the container.
+	WatchdogRestart
+
+	// WatchdogKill kills the container.
+	WatchdogKill
+)
+
+func (a WatchdogAction) String() string {
+	switch a {
+	case WatchdogAlert:
+		return "alert"
+	case WatchdogRestart:
+		return "restart"
+	case WatchdogKill:
+		return "kill"
+	}
+	return "unknown"
+}
+
+// Thresholds watched by a Watchdog, as reported in WatchdogBreach.Threshold.
+const (
+	WatchdogMemory     = "memory"
+	WatchdogThrottling = "throttling"
+	WatchdogRestarts   = "restarts"
+)
+
+// WatchdogThreshold is a limit on the usage of containers, and the action
+// taken when it's reached.
+type WatchdogThreshold struct {
+	// Limit is the value reaching the threshold. The threshold is disabled
+	// when it's 0.
+	Limit float64
+
+	Action WatchdogAction
+}
+
+// WatchdogBreach is a threshold reached by a container, as handed to
+// Watchdog.OnBreach.
+type WatchdogBreach struct {
+	ContainerID string
+	Name        string
+
+	// Threshold is the threshold reached, e.g. WatchdogMemory, and Value
+	// the value that reached its Limit.
+	Threshold string
+	Value     float64
+	Limit     float64
+
+	// Action is the action taken, and Err its error, if any.
+	Action WatchdogAction
+	Err    error
+
+	Time time.Time
+}
+
+// Watchdog watches the stats and the events of containers, taking actions
+// when they reach thresholds of resource usage, e.g. restarting the
+// containers using too much memory:
+//
+//	watchdog := client.NewWatchdog()
+//	watchdog.Filters = map[string][]string{"label": {"app=web"}}
+//	watchdog.Memory = docker.WatchdogThreshold{Limit: 90, Action: docker.WatchdogRestart}
+//	watchdog.OnBreach = func(breach docker.WatchdogBreach) { log.Print(breach) }
+//	err := watchdog.Run(ctx)
+type Watchdog struct {
+	// Filters select the containers watched, using the filters of
+	// ListContainers, e.g. {"label": ["app=web"]}. Only running containers
+	// are watched.
+	Filters map[string][]string
+
+	// Memory is the threshold of the memory usage of the containers, in
+	// percent of their limit. Like `docker stats`, the page cache isn't
+	// counted as used.
+	Memory WatchdogThreshold
+
+	// Throttling is the threshold of the CPU throttling of the containers:
+	// the ratio, between 0 and 1, of the CPU periods throttled between two
+	// samples of their stats.
+	Throttling WatchdogThreshold
+
+	// Restarts is the threshold of the number of times the containers were
+	// restarted by their restart policy, checked whenever they start.
+	Restarts WatchdogThreshold
+
+	// Cooldown is the minimum duration between two breaches of the same
+	// threshold by a container, so the actions have time to take effect.
+	// Defaults to one minute.
+	Cooldown time.Duration
+
+	// StopTimeout is the number of seconds the containers are given to stop
+	// when they're restarted.
+	StopTimeout uint
+
+	// OnBreach is called with every breach, after its action is taken. It's
+	// called from the goroutines of the watchdog, one call at a time.
+	OnBreach func(WatchdogBreach)
+
+	client *Client
+
+	mu         sync.Mutex
+	wg         sync.WaitGroup
+	watched    map[string]*watchedContainer
+	lastBreach map[string]time.Time
+	breachMu   sync.Mutex
+}
+
+type watchedContainer struct {
+	cancel context.CancelFunc
+}
+
+// NewWatchdog returns a Watchdog watching containers using the client. It
+// watches nothing until its thresholds are set.
+func (c *Client) NewWatchdog() *Watchdog {
+	return &Watchdog{client: c}
+}
+
+// Run watches the containers until the context is done or an error occurs.
+// Containers that start and match the filters are watched, and containers
+// that stop are no longer watched, as the events of the daemon report them.
+func (w *Watchdog) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	w.mu.Lock()
+	w.watched = make(map[string]*watchedContainer)
+	w.lastBreach = make(map[string]time.Time)
+	w.mu.Unlock()
+	defer func() {
+		cancel()
+		w.wg.Wait()
+	}()
+	events := make(chan *APIEvents)
+	eventsErr := make(chan error, 1)
+	// the events stream starts before listing the containers, so containers
+	// started in between aren't missed.
+	go func(since int64) {
+		eventsErr <- w.client.NewStreamSupervisor().Events(ctx, EventsOptions{
+			Since: since,
+			Filters: map[string][]string{
+				"type":  {"container"},
+				"event": {"start", "die"},
+			},
+		}, events)
+	}(time.Now().Unix())
+	if err := w.sync(ctx); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-eventsErr:
+			return err
+		case event := <-events:
+			switch event.Action {
+			case "start":
+				if err := w.sync(ctx); err != nil {
+					return err
+				}
+			case "die":
+				w.stop(event.Actor.ID)
+			}
+		}
+	}
+}
+
+// sync lists the containers that match the filters, watching the new ones
+// and no longer watching the ones that don't match anymore.
+func (w *Watchdog) sync(ctx context.Context) error {
+	containers, err := w.client.ListContainers(ListContainersOptions{Filters: w.Filters, Context: ctx})
+	if err != nil {
+		return err
+	}
+	matching := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		matching[container.ID] = true
+		w.mu.Lock()
+		_, ok := w.watched[container.ID]
+		w.mu.Unlock()
+		if ok {
+			continue
+		}
+		var name string
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		w.start(ctx, container.ID, name)
+		if w.Restarts.Limit > 0 {
+			if err := w.checkRestarts(ctx, container.ID, name); err != nil {
+				return err
+			}
+		}
+	}
+	w.mu.Lock()
+	var stale []string
+	for id := range w.watched {
+		if !matching[id] {
+			stale = append(stale, id)
+		}
+	}
+	w.mu.Unlock()
+	for _, id := range stale {
+		w.stop(id)
+	}
+	return nil
+}
+
+func (w *Watchdog) checkRestarts(ctx context.Context, id, name string) error {
+	container, err := w.client.InspectContainerWithContext(id, ctx)
+	if err != nil {
+		if _, ok := err.(*NoSuchContainer); ok {
+			return nil
+		}
+		return err
+	}
+	if count := float64(container.RestartCount); count >= w.Restarts.Limit {
+		w.breach(ctx, id, name, WatchdogRestarts, count, w.Restarts)
+	}
+	return nil
+}
+
+// start watches a container, streaming its stats when thresholds apply to
+// them.
+func (w *Watchdog) start(ctx context.Context, id, name string) {
+	ctx, cancel := context.WithCancel(ctx)
+	watched := &watchedContainer{cancel: cancel}
+	w.mu.Lock()
+	w.watched[id] = watched
+	w.mu.Unlock()
+	if w.Memory.Limit <= 0 && w.Throttling.Limit <= 0 {
+		return
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer cancel()
+		stats := make(chan *Stats)
+		errC := make(chan error, 1)
+		go func() {
+			errC <- w.client.Stats(StatsOptions{ID: id, Stats: stats, Stream: true, Context: ctx})
+		}()
+		for s := range stats {
+			w.check(ctx, id, name, s)
+		}
+		<-errC
+		w.mu.Lock()
+		if w.watched[id] == watched {
+			delete(w.watched, id)
+		}
+		w.mu.Unlock()
+	}()
+}
+
+func (w *Watchdog) stop(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if watched, ok := w.watched[id]; ok {
+		watched.cancel()
+		delete(w.watched, id)
+	}
+}
+
+// check compares a sample of the stats of a container to the thresholds.
+func (w *Watchdog) check(ctx context.Context, id, name string, stats *Stats) {
+	if w.Memory.Limit > 0 {
+		sample := newContainerStatsSample(id, name, stats)
+		if sample.MemoryLimit > 0 {
+			percent := float64(sample.MemoryUsage) / float64(sample.MemoryLimit) * 100
+			if percent >= w.Memory.Limit {
+				w.breach(ctx, id, name, WatchdogMemory, percent, w.Memory)
+			}
+		}
+	}
+	if w.Throttling.Limit > 0 {
+		if rate := statsThrottlingRate(stats); rate >= w.Throttling.Limit {
+			w.breach(ctx, id, name, WatchdogThrottling, rate, w.Throttling)
+		}
+	}
+}
+
+// breach takes the action of the threshold reached by a container and
+// reports it, unless the threshold was reached less than the cooldown ago.
+func (w *Watchdog) breach(ctx context.Context, id, name, threshold string, value float64, t WatchdogThreshold) {
+	cooldown := w.Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultWatchdogCooldown
+	}
+	now := time.Now()
+	key := id + "/" + threshold
+	w.mu.Lock()
+	if last, ok := w.lastBreach[key]; ok && now.Sub(last) < cooldown {
+		w.mu.Unlock()
+		return
+	}
+	w.lastBreach[key] = now
+	w.mu.Unlock()
+	breach := WatchdogBreach{
+		ContainerID: id,
+		Name:        name,
+		Threshold:   threshold,
+		Value:       value,
+		Limit:       t.Limit,
+		Action:      t.Action,
+		Time:        now,
+	}
+	switch t.Action {
+	case WatchdogRestart:
+		breach.Err = w.client.RestartContainer(id, w.StopTimeout)
+	case WatchdogKill:
+		breach.Err = w.client.KillContainer(KillContainerOptions{ID: id, Context: ctx})
+	}
+	if w.OnBreach != nil {
+		w.breachMu.Lock()
+		w.OnBreach(breach)
+		w.breachMu.Unlock()
+	}
+}
+
+// statsThrottlingRate computes the ratio of the CPU periods throttled
+// between a sample and the previous one.
+func statsThrottlingRate(stats *Stats) float64 {
+	current, previous := stats.CPUStats.ThrottlingData, stats.PreCPUStats.ThrottlingData
+	if current.Periods <= previous.Periods || current.ThrottledPeriods < previous.ThrottledPeriods {
+		return 0
+	}
+	return float64(current.ThrottledPeriods-previous.ThrottledPeriods) / float64(current.Periods-previous.Periods)
+}
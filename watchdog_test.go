@@ -0,0 +1,233 @@
+// Copyright 2019 go-dockerclient authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchdogDaemon is a fleetDaemon that can also inspect its containers and
+// records the actions taken on them.
+type watchdogDaemon struct {
+	*fleetDaemon
+	restartCounts map[string]int
+
+	mu      sync.Mutex
+	actions []string
+	stats   int
+}
+
+func (d *watchdogDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "POST":
+		d.mu.Lock()
+		d.actions = append(d.actions, r.URL.Path)
+		d.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path != "/containers/json" && strings.HasSuffix(r.URL.Path, "/json"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/containers/"), "/json")
+		fmt.Fprintf(w, `{"Id":%q,"RestartCount":%d}`, id, d.restartCounts[id])
+	default:
+		if strings.HasSuffix(r.URL.Path, "/stats") {
+			d.mu.Lock()
+			d.stats++
+			d.mu.Unlock()
+		}
+		d.fleetDaemon.ServeHTTP(w, r)
+	}
+}
+
+func (d *watchdogDaemon) recorded() ([]string, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.actions...), d.stats
+}
+
+// runWatchdog runs the watchdog against the daemon, returning the breaches it
+// reports and a function stopping it.
+func runWatchdog(t *testing.T, daemon *watchdogDaemon, watchdog func(*Client) *Watchdog) (<-chan WatchdogBreach, func()) {
+	server := httptest.NewServer(daemon)
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := watchdog(client)
+	breaches := make(chan WatchdogBreach, 10)
+	w.OnBreach = func(breach WatchdogBreach) {
+		breaches <- breach
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errC := make(chan error, 1)
+	go func() {
+		errC <- w.Run(ctx)
+	}()
+	return breaches, func() {
+		cancel()
+		select {
+		case err := <-errC:
+			if err != context.Canceled {
+				t.Errorf("Run: wrong error. Want %v. Got %v.", context.Canceled, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Run: didn't return after the context was cancelled")
+		}
+		server.Close()
+	}
+}
+
+func waitForBreach(t *testing.T, breaches <-chan WatchdogBreach) WatchdogBreach {
+	t.Helper()
+	select {
+	case breach := <-breaches:
+		return breach
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watchdog: timed out waiting for a breach")
+	}
+	return WatchdogBreach{}
+}
+
+func TestWatchdogMemory(t *testing.T) {
+	t.Parallel()
+	daemon := &watchdogDaemon{fleetDaemon: &fleetDaemon{
+		running: []string{"c1", "c2"},
+		samples: map[string]string{
+			"c1": `{"memory_stats": {"usage": 1000, "limit": 1000, "stats": {"total_inactive_file": 50}}}`,
+			"c2": `{"memory_stats": {"usage": 500, "limit": 1000}}`,
+		},
+		events: make(chan string),
+	}}
+	breaches, stop := runWatchdog(t, daemon, func(client *Client) *Watchdog {
+		w := client.NewWatchdog()
+		w.Memory = WatchdogThreshold{Limit: 90, Action: WatchdogRestart}
+		w.StopTimeout = 3
+		return w
+	})
+	breach := waitForBreach(t, breaches)
+	// samples keep coming, but the cooldown holds the next breaches back.
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	breach.Time = time.Time{}
+	expected := WatchdogBreach{
+		ContainerID: "c1",
+		Name:        "c1-name",
+		Threshold:   WatchdogMemory,
+		Value:       95,
+		Limit:       90,
+		Action:      WatchdogRestart,
+	}
+	if !reflect.DeepEqual(breach, expected) {
+		t.Errorf("Watchdog: wrong breach. Want %#v. Got %#v.", expected, breach)
+	}
+	if len(breaches) != 0 {
+		t.Errorf("Watchdog: want a single breach during the cooldown. Got %d more.", len(breaches))
+	}
+	actions, _ := daemon.recorded()
+	if expectedActions := []string{"/containers/c1/restart"}; !reflect.DeepEqual(actions, expectedActions) {
+		t.Errorf("Watchdog: wrong actions. Want %#v. Got %#v.", expectedActions, actions)
+	}
+}
+
+func TestWatchdogThrottling(t *testing.T) {
+	t.Parallel()
+	daemon := &watchdogDaemon{fleetDaemon: &fleetDaemon{
+		samples: map[string]string{
+			"c1": `{"cpu_stats": {"throttling_data": {"periods": 200, "throttled_periods": 60}},
+				"precpu_stats": {"throttling_data": {"periods": 100, "throttled_periods": 10}}}`,
+		},
+		events: make(chan string),
+	}}
+	breaches, stop := runWatchdog(t, daemon, func(client *Client) *Watchdog {
+		w := client.NewWatchdog()
+		w.Throttling = WatchdogThreshold{Limit: 0.3, Action: WatchdogKill}
+		return w
+	})
+	defer stop()
+	daemon.setRunning("c1")
+	daemon.events <- `{"Type": "container", "Action": "start", "Actor": {"ID": "c1"}, "time": 1}`
+	breach := waitForBreach(t, breaches)
+	if breach.ContainerID != "c1" || breach.Threshold != WatchdogThrottling || breach.Value != 0.5 || breach.Action != WatchdogKill || breach.Err != nil {
+		t.Errorf("Watchdog: wrong breach. Got %#v.", breach)
+	}
+	actions, _ := daemon.recorded()
+	if expectedActions := []string{"/containers/c1/kill"}; !reflect.DeepEqual(actions, expectedActions) {
+		t.Errorf("Watchdog: wrong actions. Want %#v. Got %#v.", expectedActions, actions)
+	}
+}
+
+func TestWatchdogRestarts(t *testing.T) {
+	t.Parallel()
+	daemon := &watchdogDaemon{
+		fleetDaemon: &fleetDaemon{
+			running: []string{"c1", "c2"},
+			events:  make(chan string),
+		},
+		restartCounts: map[string]int{"c1": 1, "c2": 5},
+	}
+	breaches, stop := runWatchdog(t, daemon, func(client *Client) *Watchdog {
+		w := client.NewWatchdog()
+		w.Restarts = WatchdogThreshold{Limit: 3}
+		return w
+	})
+	breach := waitForBreach(t, breaches)
+	stop()
+	if breach.ContainerID != "c2" || breach.Threshold != WatchdogRestarts || breach.Value != 5 || breach.Action != WatchdogAlert {
+		t.Errorf("Watchdog: wrong breach. Got %#v.", breach)
+	}
+	actions, stats := daemon.recorded()
+	if len(actions) != 0 {
+		t.Errorf("Watchdog: want no action on alerts. Got %#v.", actions)
+	}
+	if stats != 0 {
+		t.Errorf("Watchdog: want no stats streamed without stats thresholds. Got %d streams.", stats)
+	}
+}
+
+func TestStatsThrottlingRate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		periods, throttled, prePeriods, preThrottled uint64
+		expected                                     float64
+	}{
+		{200, 60, 100, 10, 0.5},
+		{200, 10, 100, 10, 0},
+		{100, 10, 100, 10, 0},
+		{100, 10, 0, 0, 0.1},
+		// counters reset, e.g. by a restart.
+		{10, 1, 100, 10, 0},
+	}
+	for _, tt := range tests {
+		var stats Stats
+		stats.CPUStats.ThrottlingData.Periods = tt.periods
+		stats.CPUStats.ThrottlingData.ThrottledPeriods = tt.throttled
+		stats.PreCPUStats.ThrottlingData.Periods = tt.prePeriods
+		stats.PreCPUStats.ThrottlingData.ThrottledPeriods = tt.preThrottled
+		if rate := statsThrottlingRate(&stats); rate != tt.expected {
+			t.Errorf("statsThrottlingRate(%+v): want %v. Got %v.", tt, tt.expected, rate)
+		}
+	}
+}
+
+func TestWatchdogActionString(t *testing.T) {
+	t.Parallel()
+	tests := map[WatchdogAction]string{
+		WatchdogAlert:      "alert",
+		WatchdogRestart:    "restart",
+		WatchdogKill:       "kill",
+		WatchdogAction(42): "unknown",
+	}
+	for action, expected := range tests {
+		if got := action.String(); got != expected {
+			t.Errorf("String(%d): want %q. Got %q.", int(action), expected, got)
+		}
+	}
+}